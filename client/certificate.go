@@ -0,0 +1,201 @@
+package chclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+// CertificateCheckInterval is how often a connected client checks if its certificate has to be renewed
+const CertificateCheckInterval = time.Hour
+
+// certificateRenewalDivisor defines when a certificate is renewed, it's when less than 1/certificateRenewalDivisor
+// of its validity is remaining
+const certificateRenewalDivisor = 3
+
+// loadCertificate returns the signer authenticating by the certificate, it returns nil values if the client isn't enrolled yet
+func loadCertificate(keyFile, certFile string) (ssh.Signer, *ssh.Certificate, error) {
+	keyBytes, err := os.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read auth key: %v", err)
+	}
+	certBytes, err := os.ReadFile(certFile)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read auth certificate: %v", err)
+	}
+
+	key, err := ssh.ParsePrivateKey(keyBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse auth key %s: %v", keyFile, err)
+	}
+	cert, err := parseCertificate(certBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse auth certificate %s: %v", certFile, err)
+	}
+
+	signer, err := ssh.NewCertSigner(cert, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("auth certificate %s doesn't belong to the auth key %s: %v", certFile, keyFile, err)
+	}
+	return signer, cert, nil
+}
+
+func parseCertificate(b []byte) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("not a certificate")
+	}
+	return cert, nil
+}
+
+// certificateNeedsRenewal returns true if there is no certificate or less than a third of its validity is remaining
+func certificateNeedsRenewal(cert *ssh.Certificate, now time.Time) bool {
+	if cert == nil {
+		return true
+	}
+	validAfter := time.Unix(int64(cert.ValidAfter), 0)
+	validBefore := time.Unix(int64(cert.ValidBefore), 0)
+	return validBefore.Sub(now) < validBefore.Sub(validAfter)/certificateRenewalDivisor
+}
+
+// certificateSigners is used as ssh public keys callback, it reads the files on every connection attempt to use renewed certificates.
+// Errors are only logged so the client falls back to the password.
+func (c *Client) certificateSigners() ([]ssh.Signer, error) {
+	signer, _, err := loadCertificate(c.configHolder.Client.AuthKeyFile, c.configHolder.Client.AuthCertFile)
+	if err != nil {
+		c.Errorf("Failed to load auth certificate: %v", err)
+		return nil, nil
+	}
+	if signer == nil {
+		return nil, nil
+	}
+	return []ssh.Signer{signer}, nil
+}
+
+// certificateLoop enrolls the client and renews its certificate over the existing connection until ctx is done
+func (c *Client) certificateLoop(ctx context.Context, conn ssh.Conn) {
+	ticker := time.NewTicker(CertificateCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.renewCertificateIfNeeded(ctx, conn); err != nil {
+			c.Errorf("Failed to renew auth certificate: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) renewCertificateIfNeeded(ctx context.Context, conn ssh.Conn) error {
+	keyFile := c.configHolder.Client.AuthKeyFile
+	certFile := c.configHolder.Client.AuthCertFile
+
+	_, cert, err := loadCertificate(keyFile, certFile)
+	if err != nil {
+		// a new certificate replaces the broken one
+		c.Errorf("%v", err)
+	}
+	if err == nil && !certificateNeedsRenewal(cert, time.Now()) {
+		return nil
+	}
+
+	pub, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate auth key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return err
+	}
+
+	reqBytes, err := json.Marshal(comm.SignCertificateRequest{
+		PublicKey: string(ssh.MarshalAuthorizedKey(sshPub)),
+	})
+	if err != nil {
+		return err
+	}
+	ok, respBytes, err := comm.SendRequestWithTimeout(ctx, conn, comm.RequestTypeSignCertificate, true, reqBytes, SendRequestTimeout, c.Logger)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("server rejected certificate request: %s", respBytes)
+	}
+	resp := comm.SignCertificateResponse{}
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return fmt.Errorf("failed to decode certificate response: %v", err)
+	}
+	newCert, err := parseCertificate([]byte(resp.Certificate))
+	if err != nil {
+		return fmt.Errorf("invalid certificate received: %v", err)
+	}
+	if string(newCert.Key.Marshal()) != string(sshPub.Marshal()) {
+		return errors.New("received certificate doesn't belong to the requested key")
+	}
+
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		return fmt.Errorf("failed to write auth key: %v", err)
+	}
+	if err := writeFileAtomic(certFile, ssh.MarshalAuthorizedKey(newCert), 0600); err != nil {
+		return fmt.Errorf("failed to write auth certificate: %v", err)
+	}
+
+	c.Infof("Received auth certificate with serial %d valid until %s", newCert.Serial, time.Unix(int64(newCert.ValidBefore), 0).UTC())
+	return nil
+}
+
+// writeFileAtomic replaces the file with a renamed temporary file, so it's never read partially written
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	// the data must be on disk before the rename, otherwise a crash could leave an empty file
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,116 @@
+package chclient
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func generateCertificate(t *testing.T, validAfter, validBefore time.Time) (ed25519.PrivateKey, *ssh.Certificate) {
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	require.NoError(t, err)
+
+	pub, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	cert := &ssh.Certificate{
+		Key:             sshPub,
+		Serial:          1,
+		CertType:        ssh.UserCert,
+		KeyId:           "client-auth-1",
+		ValidPrincipals: []string{"client-auth-1"},
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, caSigner))
+	return private, cert
+}
+
+func writeKey(t *testing.T, path string, key ed25519.PrivateKey) {
+	block, err := ssh.MarshalPrivateKey(key, "")
+	require.NoError(t, err)
+	require.NoError(t, writeFileAtomic(path, pem.EncodeToMemory(block), 0600))
+}
+
+func TestLoadCertificate(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "auth-key")
+	certFile := filepath.Join(dir, "auth-cert.pub")
+
+	signer, cert, err := loadCertificate(keyFile, certFile)
+	require.NoError(t, err, "not enrolled yet")
+	assert.Nil(t, signer)
+	assert.Nil(t, cert)
+
+	key, generated := generateCertificate(t, time.Now(), time.Now().Add(time.Hour))
+	writeKey(t, keyFile, key)
+	require.NoError(t, writeFileAtomic(certFile, ssh.MarshalAuthorizedKey(generated), 0600))
+
+	signer, cert, err = loadCertificate(keyFile, certFile)
+	require.NoError(t, err)
+	require.NotNil(t, signer)
+	assert.Equal(t, "client-auth-1", cert.KeyId)
+	assert.Equal(t, generated.Marshal(), signer.PublicKey().Marshal())
+
+	info, err := os.Stat(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	otherKey, _ := generateCertificate(t, time.Now(), time.Now().Add(time.Hour))
+	writeKey(t, keyFile, otherKey)
+	_, _, err = loadCertificate(keyFile, certFile)
+	assert.Error(t, err, "certificate of another key")
+
+	require.NoError(t, writeFileAtomic(certFile, ssh.MarshalAuthorizedKey(generated.Key), 0600))
+	_, _, err = loadCertificate(keyFile, certFile)
+	assert.Error(t, err, "public key instead of certificate")
+}
+
+func TestCertificateNeedsRenewal(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name        string
+		ValidAfter  time.Time
+		ValidBefore time.Time
+		Expected    bool
+	}{
+		{
+			Name:        "fresh",
+			ValidAfter:  now.Add(-time.Hour),
+			ValidBefore: now.Add(29 * time.Hour),
+			Expected:    false,
+		}, {
+			Name:        "less than a third remaining",
+			ValidAfter:  now.Add(-21 * time.Hour),
+			ValidBefore: now.Add(9 * time.Hour),
+			Expected:    true,
+		}, {
+			Name:        "expired",
+			ValidAfter:  now.Add(-31 * time.Hour),
+			ValidBefore: now.Add(-time.Hour),
+			Expected:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, cert := generateCertificate(t, tc.ValidAfter, tc.ValidBefore)
+			assert.Equal(t, tc.Expected, certificateNeedsRenewal(cert, now))
+		})
+	}
+
+	assert.True(t, certificateNeedsRenewal(nil, now), "not enrolled")
+}
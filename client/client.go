@@ -69,7 +69,8 @@ type Client struct {
 	filesAPI           files.FileAPI
 	watchdog           *Watchdog
 
-	mu sync.RWMutex
+	mu     sync.RWMutex
+	authMu sync.RWMutex
 }
 
 type sshClientConnection struct {
@@ -112,17 +113,58 @@ func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, err
 	}
 
 	client.sshConfig = &ssh.ClientConfig{
-		User:            config.Client.AuthUser,
-		Auth:            []ssh.AuthMethod{ssh.Password(config.Client.AuthPass)},
 		ClientVersion:   "SSH-" + chshare.ProtocolVersion + "-client",
 		HostKeyCallback: client.verifyServer,
 		Timeout:         AuthTimeout,
 	}
+	if config.Client.AuthUser != "" {
+		password, err := loadRotatedPassword(config.Client.DataDir, config.Client.AuthUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rotated credentials: %s", err)
+		}
+		if password != "" {
+			logger.Infof("Using the credentials of the last rotation from %s.", rotatedAuthFile(config.Client.DataDir))
+			config.Client.AuthPass = password
+			config.Client.Auth = config.Client.AuthUser + ":" + password
+		}
+	}
+	if !client.needsEnrollment() {
+		client.sshConfig.User, client.sshConfig.Auth, err = client.sshAuth()
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	logger.Infof("NewFetcher client instance with sessionID %s", sessionID)
 	return client, nil
 }
 
+// sshAuth returns the ssh user and auth methods for the configured credentials
+func (c *Client) sshAuth() (string, []ssh.AuthMethod, error) {
+	config := c.configHolder
+	user := config.Client.AuthUser
+	var auth []ssh.AuthMethod
+	if config.Client.AuthCertFile != "" {
+		// the certificate is tried first, the password is used to enroll and if the certificate is rejected
+		auth = append(auth, ssh.PublicKeysCallback(c.certificateSigners))
+		if user == "" {
+			_, cert, err := loadCertificate(config.Client.AuthKeyFile, config.Client.AuthCertFile)
+			if err != nil {
+				return "", nil, err
+			}
+			if cert == nil {
+				return "", nil, errors.New("client auth id must be set in 'auth' until the client has a certificate")
+			}
+			user = cert.KeyId
+		}
+	}
+	if config.Client.AuthPass != "" || len(auth) == 0 {
+		// the password can change while running when it's rotated
+		auth = append(auth, ssh.PasswordCallback(c.authPassword))
+	}
+	return user, auth, nil
+}
+
 // Run starts client and blocks while connected
 func (c *Client) Run(ctx context.Context) (err error) {
 	if err = c.Start(ctx); err != nil {
@@ -243,6 +285,13 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 
 		c.Logger.Debugf("conn loop attempt = %d", int(backoff.Attempt())+1)
 
+		if c.needsEnrollment() {
+			if err := c.enroll(ctx); err != nil {
+				connerr = err
+				continue
+			}
+		}
+
 		// make the connection attempt
 		var sshClientConn *sshClientConnection
 		var isPrimary bool
@@ -255,7 +304,7 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 			continue
 		default:
 			var err error
-			sshClientConn, isPrimary, err = c.connectToMainOrFallback()
+			sshClientConn, isPrimary, err = c.connectToMainOrFallback(c.sshConfig)
 			if err != nil {
 				connerr = err // Setting a connerr causes the loop to sleep and try again later
 				continue
@@ -295,6 +344,11 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 		c.ipAddressesFetcher.SetConn(sshClientConn.Connection)
 		c.monitor.SetConn(sshClientConn.Connection)
 
+		certCtx, cancelCert := context.WithCancel(ctx)
+		if c.configHolder.Client.AuthCertFile != "" {
+			go c.certificateLoop(certCtx, sshClientConn.Connection)
+		}
+
 		// watch for shutting down due to ctx.Done
 		go func() {
 			<-ctx.Done()
@@ -311,6 +365,7 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 		c.monitor.Stop()
 		c.updates.Stop()
 		c.ipAddressesFetcher.Stop()
+		cancelCert()
 		cancelSwitchback()
 
 		// use of closed network connection happens when switchback closes the connection, ignore the error
@@ -382,7 +437,7 @@ func (c *Client) handleServerSwitchBack(switchbackCtx context.Context, switchbac
 			switchbackTimer.Stop()
 			return
 		case <-switchbackTimer.C:
-			switchbackConn, err := c.connect(c.configHolder.Client.Server)
+			switchbackConn, err := c.connect(c.configHolder.Client.Server, c.sshConfig)
 			if err != nil {
 				c.Errorf("Switchback failed: %v", err.Error())
 				continue
@@ -395,10 +450,10 @@ func (c *Client) handleServerSwitchBack(switchbackCtx context.Context, switchbac
 	}
 }
 
-func (c *Client) connectToMainOrFallback() (conn *sshClientConnection, isPrimary bool, err error) {
+func (c *Client) connectToMainOrFallback(sshConfig *ssh.ClientConfig) (conn *sshClientConnection, isPrimary bool, err error) {
 	servers := append([]string{c.configHolder.Client.Server}, c.configHolder.Client.FallbackServers...)
 	for i, server := range servers {
-		conn, err = c.connect(server)
+		conn, err = c.connect(server, sshConfig)
 		if err != nil {
 			continue // Try the next server in the list
 		}
@@ -407,7 +462,7 @@ func (c *Client) connectToMainOrFallback() (conn *sshClientConnection, isPrimary
 	return nil, false, err
 }
 
-func (c *Client) connect(server string) (*sshClientConnection, error) {
+func (c *Client) connect(server string, sshConfig *ssh.ClientConfig) (*sshClientConnection, error) {
 	via := ""
 	if c.configHolder.Client.ProxyURL != nil {
 		via = " via " + c.configHolder.Client.ProxyURL.String()
@@ -436,7 +491,7 @@ func (c *Client) connect(server string) (*sshClientConnection, error) {
 
 	// perform SSH handshake on net.Conn
 	c.Debugf("Handshaking...")
-	sshClientConn, chans, reqs, err := ssh.NewClientConn(conn, "", c.sshConfig)
+	sshClientConn, chans, reqs, err := ssh.NewClientConn(conn, "", sshConfig)
 	if err != nil {
 		if strings.Contains(err.Error(), "unable to authenticate") {
 			c.Errorf("Authentication failed")
@@ -621,9 +676,18 @@ func (c *Client) handlePutCapabilitiesRequest(ctx context.Context, payload []byt
 func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClientConnection) {
 	c.Logger.Debugf("handleSSHRequests started")
 
+	reverse := newReverseTunnels(c.Logger.Fork("reverse"), sshClientConn.Connection)
+	defer reverse.closeAll()
+
 	for r := range sshClientConn.Requests {
 		c.Logger.Debugf("handling request: %s", r.Type)
-		c.Logger.Debugf("payload: %v", string(r.Payload))
+		switch r.Type {
+		case comm.RequestTypeRotateCredentials:
+		case comm.RequestTypeRunCmd:
+			c.Logger.Debugf("payload: %v", string(maskVaultEnv(r.Payload)))
+		default:
+			c.Logger.Debugf("payload: %v", string(r.Payload))
+		}
 		var err error
 		var resp interface{}
 		switch r.Type {
@@ -655,6 +719,18 @@ func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClient
 		case comm.RequestTypeCheckTunnelAllowed:
 			resp, err = c.checkTunnelAllowed(r.Payload)
 			// fall through for err and resp handling
+		case comm.RequestTypeFileSystem:
+			resp, err = c.handleFileSystemRequest(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeRotateCredentials:
+			resp, err = c.handleRotateCredentials(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeStartReverseTunnel:
+			resp, err = reverse.handleStartRequest(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeStopReverseTunnel:
+			resp, err = reverse.handleStopRequest(r.Payload)
+			// fall through for err and resp handling
 		case comm.RequestTypePing:
 			// use empty reply (and NOT empty resp with success reply)
 			_ = r.Reply(true, nil)
@@ -756,6 +832,15 @@ func (c *Client) stopRunning() {
 func (c *Client) connectStreams(chans <-chan ssh.NewChannel) {
 	c.Logger.Debugf("connectStreams started")
 	for ch := range chans {
+		switch ch.ChannelType() {
+		case comm.ChannelTerminal:
+			go c.handleTerminalChannel(ch)
+			continue
+		case comm.ChannelFileDownload:
+			go c.handleFileDownloadChannel(ch)
+			continue
+		}
+
 		remote := string(ch.ExtraData())
 		protocol := models.ProtocolTCP
 		c.Debugf("handling connect stream: remote=%s, protocol=%s", remote, protocol)
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"regexp"
 	"strings"
@@ -21,6 +22,7 @@ import (
 	"github.com/openrport/openrport/client/system"
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/vaultplaceholder"
 )
 
 var (
@@ -55,6 +57,25 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		InterpreterAliases:       c.configHolder.InterpreterAliases,
 	}
 
+	// values of vault placeholders are passed in environment variables, so they never get into the script or the result
+	command := job.Command
+	vaultEnv := job.VaultEnv
+	vaultValues := make([]string, 0, len(vaultEnv))
+	if len(vaultEnv) > 0 {
+		command = vaultplaceholder.Replace(job.Command, func(key string) string {
+			return interpreter.EnvReference(vaultplaceholder.EnvName(key))
+		})
+		for _, v := range vaultEnv {
+			vaultValues = append(vaultValues, v)
+		}
+		// the job is sent back to the server when the output is streamed
+		job.VaultEnv = nil
+		reqPayload, err = json.Marshal(job)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	encodingConfig, ok := c.configHolder.InterpreterAliasesEncodings[job.Interpreter]
 	var encoding *system.ShellEncoding
 	if ok {
@@ -72,7 +93,7 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 
 	decoder := encoding.GetOutputDecoder()
 
-	scriptPath, err := system.CreateScriptFile(c.configHolder.GetScriptsDir(), job.Command, interpreter, encoding.GetInputEncoder())
+	scriptPath, err := system.CreateScriptFile(c.configHolder.GetScriptsDir(), command, interpreter, encoding.GetInputEncoder())
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +102,12 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 	limitedStdErrCh := ioutil.Discard
 	closeStreamChannels := func() {}
 	if job.StreamResult {
+		// the output stored in files on the server is not truncated, only the result sent back is
+		streamLimit := c.configHolder.RemoteCommands.SendBackLimit
+		if job.StreamToFile {
+			streamLimit = math.MaxInt
+		}
+
 		stdOutCh, reqs, err := c.getConn().OpenChannel(models.ChannelStdout, reqPayload)
 		go ssh.DiscardRequests(reqs)
 		if err != nil {
@@ -89,7 +116,7 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		limitedStdOutCh = &LimitedWriter{
 			Writer:  stdOutCh,
 			Decoder: decoder,
-			Limit:   c.configHolder.RemoteCommands.SendBackLimit,
+			Limit:   streamLimit,
 		}
 
 		stdErrCh, reqs, err := c.getConn().OpenChannel(models.ChannelStderr, reqPayload)
@@ -100,7 +127,7 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		limitedStdErrCh = &LimitedWriter{
 			Writer:  stdErrCh,
 			Decoder: decoder,
-			Limit:   c.configHolder.RemoteCommands.SendBackLimit,
+			Limit:   streamLimit,
 		}
 
 		closeStreamChannels = func() {
@@ -114,7 +141,8 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		Command:     scriptPath,
 		WorkingDir:  job.Cwd,
 		IsSudo:      job.IsSudo,
-		HasShebang:  system.HasShebangLine(job.Command),
+		HasShebang:  system.HasShebangLine(command),
+		Env:         vaultEnv,
 	}
 	cmd := c.cmdExec.New(ctx, execCtx)
 	summary := NewSummaryBuffer()
@@ -122,6 +150,16 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 	stdErr := &CapacityBuffer{capacity: c.configHolder.RemoteCommands.SendBackLimit}
 	cmd.Stdout = io.MultiWriter(summary, stdOut, limitedStdOutCh)
 	cmd.Stderr = io.MultiWriter(stdErr, limitedStdErrCh)
+	flushOutput := func() {}
+	if len(vaultValues) > 0 {
+		maskedStdOut := vaultplaceholder.NewMaskingWriter(cmd.Stdout, vaultValues)
+		maskedStdErr := vaultplaceholder.NewMaskingWriter(cmd.Stderr, vaultValues)
+		cmd.Stdout, cmd.Stderr = maskedStdOut, maskedStdErr
+		flushOutput = func() {
+			_ = maskedStdOut.Flush()
+			_ = maskedStdErr.Flush()
+		}
+	}
 
 	c.Debugf("Input command: %s, sysProcAttributes: %+v, executable command: %s", job.Command, cmd.SysProcAttr, cmd.String())
 
@@ -170,17 +208,19 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		job.PID = &cmd.Process.Pid
 		job.StartedAt = startedAt
 
-		job.Error = c.buildErrText(execErr, stdOut, stdErr)
+		job.Error = vaultplaceholder.MaskValues(c.buildErrText(execErr, stdOut, stdErr), vaultValues)
 		if job.Error != "" {
 			c.Errorf(job.Error)
 		}
 
+		flushOutput()
 		summary.Stop()
 
+		// the output is masked once more after decoding, in case the values are encoded differently by the console
 		job.Result = &models.JobResult{
-			StdOut:  c.ToUTF8(stdOut.Bytes(), decoder),
-			StdErr:  c.ToUTF8(stdErr.Bytes(), decoder),
-			Summary: c.ToUTF8(summary.GetSummary(), decoder),
+			StdOut:  vaultplaceholder.MaskValues(c.ToUTF8(stdOut.Bytes(), decoder), vaultValues),
+			StdErr:  vaultplaceholder.MaskValues(c.ToUTF8(stdErr.Bytes(), decoder), vaultValues),
+			Summary: vaultplaceholder.MaskValues(c.ToUTF8(summary.GetSummary(), decoder), vaultValues),
 		}
 
 		// send the filled job to the server
@@ -204,6 +244,22 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 	}, nil
 }
 
+// maskVaultEnv returns the payload of a run cmd request with the values of vault placeholders masked
+func maskVaultEnv(payload []byte) []byte {
+	job := models.Job{}
+	if err := json.Unmarshal(payload, &job); err != nil || len(job.VaultEnv) == 0 {
+		return payload
+	}
+	for name := range job.VaultEnv {
+		job.VaultEnv[name] = vaultplaceholder.Mask
+	}
+	masked, err := json.Marshal(job)
+	if err != nil {
+		return nil
+	}
+	return masked
+}
+
 func (c *Client) buildErrText(execErr error, stdOut, stdErr *CapacityBuffer) string {
 	errs := make([]string, 0, 3)
 
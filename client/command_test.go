@@ -3,6 +3,7 @@ package chclient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -35,6 +36,10 @@ type CmdExecutorMock struct {
 	ReturnStdOut   []string
 	ReturnStdErr   []string
 
+	// execCtx and script are the context and the script content of the last command
+	execCtx *system.CmdExecutorContext
+	script  []byte
+
 	wg sync.WaitGroup
 }
 
@@ -43,6 +48,9 @@ func NewCmdExecutorMock() *CmdExecutorMock {
 }
 
 func (e *CmdExecutorMock) New(ctx context.Context, execCtx *system.CmdExecutorContext) *exec.Cmd {
+	e.execCtx = execCtx
+	e.script, _ = os.ReadFile(execCtx.Command)
+
 	var args []string
 	if execCtx.IsSudo {
 		args = append(args, "sudo -n")
@@ -186,6 +194,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 	"multi_job_id":null,
 	"schedule_id":null,
 	"stream_result":true,
+	"stream_to_file":false,
 	"error":"%s",
 `
 	wantJSONPart2 := `
@@ -356,6 +365,7 @@ func TestNoStreamResult(t *testing.T) {
 	"multi_job_id":null,
 	"schedule_id":null,
 	"stream_result":false,
+	"stream_to_file":false,
 	"error":"",
 	"result": {
 		"stdout": "output1output2output3<summary>test</summary>",
@@ -386,6 +396,60 @@ func TestNoStreamResult(t *testing.T) {
 	assert.Len(t, connMock.ChannelMocks, 0)
 }
 
+func TestHandleRunCmdRequestWithVaultEnv(t *testing.T) {
+	now = nowMockF
+
+	execMock := NewCmdExecutorMock()
+	execMock.ReturnPID = 123
+	execMock.ReturnStdOut = []string{"password: s3c", "r3t\n", "<summary>s3cr3t</summary>"}
+	execMock.ReturnStdErr = []string{"denied for s3cr3t"}
+	connMock := test.NewConnMock()
+	done := make(chan bool)
+	connMock.DoneChannel = done
+	configCopy := getDefaultValidMinConfig()
+	c := Client{
+		cmdExec:       execMock,
+		sshConnection: connMock,
+		Logger:        testLog,
+		configHolder:  &configCopy,
+	}
+
+	configCopy.Client.DataDir = filepath.Join(configCopy.Client.DataDir, "TestHandleRunCmdRequestWithVaultEnv")
+	defer func() {
+		os.RemoveAll(configCopy.Client.DataDir)
+	}()
+	require.NoError(t, PrepareDirs(&configCopy))
+	c.configHolder.RemoteCommands.SendBackLimit = 1024
+
+	jobToRunJSON := `
+{
+	"jid": "5f02b216-3f8a-42be-b66c-f4c1d0ea3809",
+	"command": "mysql -p\"{{ vault \"db-password\" }}\"",
+	"timeout_sec": 60,
+	"vault_env": {"RPORT_VAULT_DB_PASSWORD": "s3cr3t"}
+}`
+
+	_, err := c.HandleRunCmdRequest(context.Background(), []byte(jobToRunJSON))
+	require.NoError(t, err)
+	<-done
+
+	assert.Equal(t, map[string]string{"RPORT_VAULT_DB_PASSWORD": "s3cr3t"}, execMock.execCtx.Env)
+	assert.Equal(t, `mysql -p"${RPORT_VAULT_DB_PASSWORD}"`, string(execMock.script))
+
+	_, _, inputPayload := connMock.InputSendRequest()
+	job := models.Job{}
+	require.NoError(t, json.Unmarshal(inputPayload, &job))
+	assert.Nil(t, job.VaultEnv)
+	assert.Equal(t, `mysql -p"{{ vault "db-password" }}"`, job.Command)
+	assert.Equal(t, &models.JobResult{
+		StdOut:  "password: ******\n<summary>******</summary>",
+		StdErr:  "denied for ******",
+		Summary: "******",
+	}, job.Result)
+
+	assert.NotContains(t, string(maskVaultEnv([]byte(jobToRunJSON))), "s3cr3t")
+}
+
 func TestRemoteCommandsDisabled(t *testing.T) {
 	// given
 	c := Client{
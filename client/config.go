@@ -30,6 +30,9 @@ var (
 
 type ClientConfigHolder struct {
 	*clientconfig.Config
+
+	// ConfigFile is the path of the config file, the credentials received on enrollment are stored in it
+	ConfigFile string
 }
 
 func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) error {
@@ -65,6 +68,12 @@ func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) err
 	}
 
 	c.Client.AuthUser, c.Client.AuthPass = chshare.ParseAuth(c.Client.Auth)
+	if (c.Client.AuthKeyFile == "") != (c.Client.AuthCertFile == "") {
+		return errors.New("'auth_key_file' and 'auth_cert_file' must be set together")
+	}
+	if c.Client.EnrollmentToken != "" && c.Client.Auth == "" && c.ConfigFile == "" {
+		return errors.New("'enrollment_token' requires a config file to store the received credentials")
+	}
 
 	if err := c.parseRemoteScripts(skipScriptsDirValidation); err != nil {
 		return err
@@ -78,6 +87,14 @@ func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) err
 		return err
 	}
 
+	if err := c.ParseAndValidateFileDownloadConfig(); err != nil {
+		return err
+	}
+
+	if err := c.ParseAndValidateFileSystemConfig(); err != nil {
+		return err
+	}
+
 	if err := c.ParseAndValidateConnection(); err != nil {
 		return err
 	}
@@ -136,6 +153,32 @@ func (c *ClientConfigHolder) ParseAndValidateFilePushConfig() error {
 	return nil
 }
 
+func (c *ClientConfigHolder) ParseAndValidateFileDownloadConfig() error {
+	for _, patterns := range [][]string{c.FileDownloadConfig.Allowed, c.FileDownloadConfig.Denied} {
+		for _, globPattern := range patterns {
+			_, err := filepath.Match(globPattern, "/test")
+			if err != nil {
+				return fmt.Errorf("invalid glob pattern %s: %v", globPattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *ClientConfigHolder) ParseAndValidateFileSystemConfig() error {
+	for _, patterns := range [][]string{c.FileSystemConfig.Allowed, c.FileSystemConfig.Denied} {
+		for _, globPattern := range patterns {
+			_, err := filepath.Match(globPattern, "/test")
+			if err != nil {
+				return fmt.Errorf("invalid glob pattern %s: %v", globPattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (c *ClientConfigHolder) parseHeaders() error {
 	c.Connection.HTTPHeaders = http.Header{}
 	for _, h := range c.Connection.HeadersRaw {
@@ -398,6 +398,34 @@ func TestConfigParseAndValidateAuth(t *testing.T) {
 	}
 }
 
+func TestConfigParseAndValidateAuthCertificate(t *testing.T) {
+	config := getDefaultValidMinConfig()
+	config.Client.AuthKeyFile = "/var/lib/rport/auth-key"
+	err := config.ParseAndValidate(true)
+	assert.EqualError(t, err, "'auth_key_file' and 'auth_cert_file' must be set together")
+
+	config = getDefaultValidMinConfig()
+	config.Client.AuthKeyFile = "/var/lib/rport/auth-key"
+	config.Client.AuthCertFile = "/var/lib/rport/auth-cert.pub"
+	err = config.ParseAndValidate(true)
+	assert.NoError(t, err)
+}
+
+func TestConfigParseAndValidateEnrollmentToken(t *testing.T) {
+	config := getDefaultValidMinConfig()
+	config.Client.Auth = ""
+	config.Client.EnrollmentToken = "abcd1234_secret"
+	err := config.ParseAndValidate(true)
+	assert.EqualError(t, err, "'enrollment_token' requires a config file to store the received credentials")
+
+	config = getDefaultValidMinConfig()
+	config.Client.Auth = ""
+	config.Client.EnrollmentToken = "abcd1234_secret"
+	config.ConfigFile = "/etc/rport/rport.conf"
+	err = config.ParseAndValidate(true)
+	assert.NoError(t, err)
+}
+
 func TestScriptsExecutionEnabledButCommandsDisabled(t *testing.T) {
 	config := getDefaultValidMinConfig()
 	config.RemoteScripts.Enabled = true
@@ -717,6 +745,47 @@ func TestConfigParseAndValidateFilePushConfig(t *testing.T) {
 	}
 }
 
+func TestConfigParseAndValidateFileDownloadConfig(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Config        clientconfig.FileDownloadConfig
+		ExpectedError string
+	}{
+		{
+			Name:   "valid patterns",
+			Config: clientconfig.FileDownloadConfig{Allowed: []string{"/var/log/*"}, Denied: FileDownloadDenyGlobs},
+		},
+		{
+			Name:          "invalid allowed pattern",
+			Config:        clientconfig.FileDownloadConfig{Allowed: []string{"[a"}},
+			ExpectedError: "invalid glob pattern [a: syntax error in pattern",
+		},
+		{
+			Name:          "invalid denied pattern",
+			Config:        clientconfig.FileDownloadConfig{Denied: []string{"/proc", "["}},
+			ExpectedError: "invalid glob pattern [: syntax error in pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			config := getDefaultValidMinConfig()
+			config.FileDownloadConfig = tc.Config
+
+			err := config.ParseAndValidate(true)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
 func TestConfigParseInterpreterAliases(t *testing.T) {
 	alias := "test-alias"
 	testCases := []struct {
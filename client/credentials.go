@@ -0,0 +1,71 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+// RotatedAuthFileName is the file in the data dir with the credentials received on the last rotation.
+// It takes precedence over the password in the config, as long as the client auth id matches.
+const RotatedAuthFileName = "client-auth"
+
+func rotatedAuthFile(dataDir string) string {
+	return filepath.Join(dataDir, RotatedAuthFileName)
+}
+
+// loadRotatedPassword returns the password stored on the last rotation of the given client auth id
+func loadRotatedPassword(dataDir, clientAuthID string) (string, error) {
+	content, err := os.ReadFile(rotatedAuthFile(dataDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	id, password, _ := strings.Cut(strings.TrimSpace(string(content)), ":")
+	if id != clientAuthID {
+		return "", nil
+	}
+	return password, nil
+}
+
+// handleRotateCredentials stores the new password before the server is told to use it.
+// The connection stays up, the new password is used when the client connects the next time.
+func (c *Client) handleRotateCredentials(payload []byte) (interface{}, error) {
+	req := comm.RotateCredentialsRequest{}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("invalid rotate credentials request: %v", err)
+	}
+	if req.Password == "" {
+		return nil, errors.New("password must not be empty")
+	}
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	clientAuthID := c.configHolder.Client.AuthUser
+	if clientAuthID == "" {
+		return nil, errors.New("client auth id is unknown")
+	}
+	path := rotatedAuthFile(c.configHolder.Client.DataDir)
+	if err := writeFileAtomic(path, []byte(clientAuthID+":"+req.Password), 0600); err != nil {
+		return nil, fmt.Errorf("failed to store the new credentials in %s: %v", path, err)
+	}
+
+	c.configHolder.Client.AuthPass = req.Password
+	c.configHolder.Client.Auth = clientAuthID + ":" + req.Password
+	c.Infof("Received new credentials for client auth id %s, stored in %s.", clientAuthID, path)
+	return nil, nil
+}
+
+func (c *Client) authPassword() (string, error) {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
+	return c.configHolder.Client.AuthPass, nil
+}
@@ -0,0 +1,65 @@
+package chclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/test"
+)
+
+func newRotationTestConfig(dataDir, auth string) *ClientConfigHolder {
+	config := &ClientConfigHolder{
+		Config: &clientconfig.Config{
+			Client: clientconfig.ClientConfig{
+				Auth:    auth,
+				DataDir: dataDir,
+			},
+		},
+	}
+	config.Client.AuthUser, config.Client.AuthPass = chshare.ParseAuth(auth)
+	return config
+}
+
+func TestRotateCredentials(t *testing.T) {
+	dataDir := t.TempDir()
+	c, err := NewClient(newRotationTestConfig(dataDir, "auth-1:old-password"), test.NewFileAPIMock())
+	require.NoError(t, err)
+
+	_, err = c.handleRotateCredentials([]byte(`{"password": ""}`))
+	assert.Error(t, err)
+
+	_, err = c.handleRotateCredentials([]byte(`{"password": "new-password"}`))
+	require.NoError(t, err)
+
+	password, err := c.authPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "new-password", password)
+
+	path := filepath.Join(dataDir, RotatedAuthFileName)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "auth-1:new-password", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the rotated password is used after a restart, the config still has the old one
+	restarted, err := NewClient(newRotationTestConfig(dataDir, "auth-1:old-password"), test.NewFileAPIMock())
+	require.NoError(t, err)
+	password, err = restarted.authPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "new-password", password)
+
+	// credentials of another client auth id are ignored
+	other, err := NewClient(newRotationTestConfig(dataDir, "auth-2:pass-2"), test.NewFileAPIMock())
+	require.NoError(t, err)
+	password, err = other.authPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "pass-2", password)
+}
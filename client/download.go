@@ -0,0 +1,133 @@
+package chclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+)
+
+// handleFileDownloadChannel sends a file requested by the server through the channel
+func (c *Client) handleFileDownloadChannel(newChannel ssh.NewChannel) {
+	reject := func(reason ssh.RejectionReason, err error) {
+		c.Errorf("Rejecting file download: %v", err)
+		if err := newChannel.Reject(reason, err.Error()); err != nil {
+			c.Errorf("Failed to reject file download: %v", err)
+		}
+	}
+
+	if !c.configHolder.FileDownloadConfig.Enabled {
+		reject(ssh.Prohibited, fmt.Errorf("file downloads are disabled on this client, check [file-download] enabled option"))
+		return
+	}
+
+	req, err := comm.DecodeFileDownloadRequest(newChannel.ExtraData())
+	if err != nil {
+		reject(ssh.ConnectionFailed, err)
+		return
+	}
+
+	f, info, err := openFileForDownload(c.configHolder.FileDownloadConfig, req.Path)
+	if err != nil {
+		reject(ssh.Prohibited, err)
+		return
+	}
+	defer f.Close()
+
+	ch, reqs, err := newChannel.Accept()
+	if err != nil {
+		c.Errorf("Failed to accept file download: %v", err)
+		return
+	}
+	defer ch.Close()
+	go ssh.DiscardRequests(reqs)
+
+	c.Infof("Sending file %s to the server", req.Path)
+	result := &comm.FileDownloadResult{
+		Mode:    uint32(info.Mode().Perm()),
+		ModTime: info.ModTime(),
+	}
+	result.Size, err = io.Copy(ch, f)
+	if err != nil {
+		result.Error = err.Error()
+		c.Errorf("Failed to send file %s: %v", req.Path, err)
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		c.Errorf("Failed to encode file download result: %v", err)
+		return
+	}
+	if _, err := ch.SendRequest(comm.RequestTypeFileDownloadResult, false, payload); err != nil {
+		c.Errorf("Failed to send file download result: %v", err)
+	}
+	_ = ch.CloseWrite()
+}
+
+// openFileForDownload opens a regular file if it's allowed by the given config.
+// Symlinks are resolved before the path is checked, so they can't be used to access denied files.
+func openFileForDownload(cfg clientconfig.FileDownloadConfig, path string) (*os.File, os.FileInfo, error) {
+	if !filepath.IsAbs(path) {
+		return nil, nil, fmt.Errorf("path %q must be absolute", path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkFileDownloadAllowed(cfg, resolved); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	return f, info, nil
+}
+
+// checkFileDownloadAllowed returns an error if the path or any of its parent directories matches a denied pattern,
+// or if allowed patterns are given and neither the path nor any of its parent directories matches one of them
+func checkFileDownloadAllowed(cfg clientconfig.FileDownloadConfig, path string) error {
+	return checkPathAllowed(cfg.Allowed, cfg.Denied, path, "file download")
+}
+
+func checkPathAllowed(allowed, denied []string, path, request string) error {
+	if p := matchPathOrParents(denied, path); p != "" {
+		return fmt.Errorf("path %s matches denied pattern %s, therefore the %s request is rejected", path, p, request)
+	}
+	if len(allowed) > 0 && matchPathOrParents(allowed, path) == "" {
+		return fmt.Errorf("path %s doesn't match any allowed pattern, therefore the %s request is rejected", path, request)
+	}
+	return nil
+}
+
+// matchPathOrParents returns the first pattern matching the path or one of its parent directories
+func matchPathOrParents(patterns []string, path string) string {
+	for p := path; ; p = filepath.Dir(p) {
+		for _, pattern := range patterns {
+			// invalid patterns are rejected on config validation
+			if matched, _ := filepath.Match(pattern, p); matched {
+				return pattern
+			}
+		}
+		if filepath.Dir(p) == p {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+var FileDownloadDenyGlobs = []string{
+	"/etc/shadow*", "/etc/gshadow*", "/etc/ssh/ssh_host_*_key", "/proc", "/sys", "/dev",
+}
@@ -0,0 +1,113 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/clientconfig"
+)
+
+func TestCheckFileDownloadAllowed(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Config        clientconfig.FileDownloadConfig
+		Path          string
+		ExpectedError string
+	}{
+		{
+			Name:   "no patterns",
+			Path:   "/var/log/app.log",
+			Config: clientconfig.FileDownloadConfig{},
+		},
+		{
+			Name:          "denied file",
+			Path:          "/etc/shadow",
+			Config:        clientconfig.FileDownloadConfig{Denied: FileDownloadDenyGlobs},
+			ExpectedError: "path /etc/shadow matches denied pattern /etc/shadow*, therefore the file download request is rejected",
+		},
+		{
+			Name:          "denied parent dir",
+			Path:          "/proc/1/environ",
+			Config:        clientconfig.FileDownloadConfig{Denied: FileDownloadDenyGlobs},
+			ExpectedError: "path /proc/1/environ matches denied pattern /proc, therefore the file download request is rejected",
+		},
+		{
+			Name:   "allowed dir",
+			Path:   "/var/log/nginx/access.log",
+			Config: clientconfig.FileDownloadConfig{Allowed: []string{"/var/log"}},
+		},
+		{
+			Name:   "allowed glob",
+			Path:   "/var/log/app.log",
+			Config: clientconfig.FileDownloadConfig{Allowed: []string{"/var/log/*.log"}},
+		},
+		{
+			Name:          "not allowed",
+			Path:          "/home/user/.bash_history",
+			Config:        clientconfig.FileDownloadConfig{Allowed: []string{"/var/log"}},
+			ExpectedError: "path /home/user/.bash_history doesn't match any allowed pattern, therefore the file download request is rejected",
+		},
+		{
+			Name: "deny wins",
+			Path: "/var/log/secret/key",
+			Config: clientconfig.FileDownloadConfig{
+				Allowed: []string{"/var/log"},
+				Denied:  []string{"/var/log/secret"},
+			},
+			ExpectedError: "path /var/log/secret/key matches denied pattern /var/log/secret, therefore the file download request is rejected",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := checkFileDownloadAllowed(tc.Config, tc.Path)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOpenFileForDownload(t *testing.T) {
+	dir := t.TempDir()
+	allowedFile := filepath.Join(dir, "allowed", "app.log")
+	deniedFile := filepath.Join(dir, "denied", "secret")
+	require.NoError(t, os.MkdirAll(filepath.Dir(allowedFile), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Dir(deniedFile), 0700))
+	require.NoError(t, os.WriteFile(allowedFile, []byte("log line"), 0600))
+	require.NoError(t, os.WriteFile(deniedFile, []byte("secret"), 0600))
+	link := filepath.Join(dir, "allowed", "link")
+	require.NoError(t, os.Symlink(deniedFile, link))
+
+	cfg := clientconfig.FileDownloadConfig{Denied: []string{filepath.Join(dir, "denied")}}
+
+	f, info, err := openFileForDownload(cfg, allowedFile)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, int64(8), info.Size())
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "log line", string(content))
+
+	_, _, err = openFileForDownload(cfg, link)
+	assert.ErrorContains(t, err, "matches denied pattern")
+
+	_, _, err = openFileForDownload(cfg, filepath.Join(dir, "allowed"))
+	assert.ErrorContains(t, err, "is not a regular file")
+
+	_, _, err = openFileForDownload(cfg, "relative/path")
+	assert.EqualError(t, err, `path "relative/path" must be absolute`)
+
+	_, _, err = openFileForDownload(cfg, filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
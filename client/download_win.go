@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+var FileDownloadDenyGlobs = []string{
+	`C:\Windows\System32\config`,
+}
@@ -0,0 +1,160 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+// needsEnrollment returns true if the client has an enrollment token but no credentials yet
+func (c *Client) needsEnrollment() bool {
+	return c.configHolder.Client.EnrollmentToken != "" && c.configHolder.Client.Auth == ""
+}
+
+// enroll exchanges the enrollment token for client auth credentials, which are stored in the config file
+// and used for all following connections
+func (c *Client) enroll(ctx context.Context) error {
+	configFile := c.configHolder.ConfigFile
+	// the token can be used only once, so make sure the credentials can be stored before using it
+	if err := checkDirWritable(filepath.Dir(configFile)); err != nil {
+		return fmt.Errorf("can't store the credentials received on enrollment in %s: %v", configFile, err)
+	}
+
+	connReq, err := c.connectionRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	enrollConfig := *c.sshConfig
+	enrollConfig.User = comm.EnrollmentAuthID
+	enrollConfig.Auth = []ssh.AuthMethod{ssh.Password(c.configHolder.Client.EnrollmentToken)}
+
+	c.Infof("Enrolling with the enrollment token.")
+	sshClientConn, _, err := c.connectToMainOrFallback(&enrollConfig)
+	if err != nil {
+		return err
+	}
+	defer sshClientConn.Connection.Close()
+	go ssh.DiscardRequests(sshClientConn.Requests)
+	go func() {
+		for ch := range sshClientConn.Channels {
+			_ = ch.Reject(ssh.Prohibited, "enrolling")
+		}
+	}()
+
+	reqBytes, err := json.Marshal(comm.EnrollRequest{
+		ClientID: connReq.ID,
+		Name:     connReq.Name,
+	})
+	if err != nil {
+		return err
+	}
+	ok, respBytes, err := comm.SendRequestWithTimeout(ctx, sshClientConn.Connection, comm.RequestTypeEnroll, true, reqBytes, SendRequestTimeout, c.Logger)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("server rejected enrollment: %s", respBytes)
+	}
+	resp := comm.EnrollResponse{}
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return fmt.Errorf("failed to decode enrollment response: %v", err)
+	}
+	clientAuthID, password, found := strings.Cut(resp.Auth, ":")
+	if !found || clientAuthID == "" || password == "" {
+		return fmt.Errorf("invalid credentials received on enrollment")
+	}
+
+	if err := storeEnrolledAuth(configFile, resp.Auth); err != nil {
+		// the token is used up, the credentials are logged so they aren't lost
+		c.Errorf("Failed to store the credentials received on enrollment in %s, set 'auth = %q' manually: %v", configFile, resp.Auth, err)
+		return err
+	}
+
+	c.configHolder.Client.Auth = resp.Auth
+	c.configHolder.Client.AuthUser = clientAuthID
+	c.configHolder.Client.AuthPass = password
+	c.configHolder.Client.EnrollmentToken = ""
+	c.sshConfig.User, c.sshConfig.Auth, err = c.sshAuth()
+	if err != nil {
+		return err
+	}
+
+	if resp.Pending {
+		c.Infof("Enrolled with client auth id %s, connections are rejected until an admin approves the client.", clientAuthID)
+	} else {
+		c.Infof("Enrolled with client auth id %s.", clientAuthID)
+	}
+	return nil
+}
+
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".rport-enrollment")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// storeEnrolledAuth replaces the enrollment token in the config file with the received credentials
+func storeEnrolledAuth(configFile, auth string) error {
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(configFile, replaceEnrollmentToken(content, auth), info.Mode().Perm())
+}
+
+// replaceEnrollmentToken replaces the enrollment_token line in the [client] section with the auth line.
+// Other auth lines are commented out, the rest of the file is kept as is.
+func replaceEnrollmentToken(content []byte, auth string) []byte {
+	authLine := fmt.Sprintf("auth = %q", auth)
+	lines := strings.Split(string(content), "\n")
+	inClient := false
+	clientHeader := -1
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inClient = trimmed == "[client]"
+			if inClient {
+				clientHeader = i
+			}
+			continue
+		}
+		if !inClient || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, _, _ := strings.Cut(trimmed, "=")
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		switch strings.TrimSpace(key) {
+		case "enrollment_token":
+			lines[i] = indent + authLine
+			replaced = true
+		case "auth":
+			lines[i] = indent + "#" + trimmed
+		}
+	}
+	if replaced {
+		return []byte(strings.Join(lines, "\n"))
+	}
+
+	// the token wasn't set in the file, e.g. by an environment variable
+	if clientHeader == -1 {
+		return []byte(strings.Join(lines, "\n") + "\n[client]\n  " + authLine + "\n")
+	}
+	lines = append(lines[:clientHeader+1], append([]string{"  " + authLine}, lines[clientHeader+1:]...)...)
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,77 @@
+package chclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceEnrollmentToken(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Content  string
+		Expected string
+	}{
+		{
+			Name: "token in client section",
+			Content: `[client]
+  server = "http://localhost:8080"
+  #auth = "clientAuth1:1234"
+  auth = ""
+  enrollment_token = "abcd1234_secret"
+
+[logging]
+  enrollment_token = "not in client section"
+`,
+			Expected: `[client]
+  server = "http://localhost:8080"
+  #auth = "clientAuth1:1234"
+  #auth = ""
+  auth = "enrolled-1:pass"
+
+[logging]
+  enrollment_token = "not in client section"
+`,
+		}, {
+			Name: "token not in file",
+			Content: `[client]
+  server = "http://localhost:8080"
+`,
+			Expected: `[client]
+  auth = "enrolled-1:pass"
+  server = "http://localhost:8080"
+`,
+		}, {
+			Name:    "no client section",
+			Content: "[logging]\n",
+			Expected: `[logging]
+
+[client]
+  auth = "enrolled-1:pass"
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, string(replaceEnrollmentToken([]byte(tc.Content), "enrolled-1:pass")))
+		})
+	}
+}
+
+func TestStoreEnrolledAuth(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "rport.conf")
+	require.NoError(t, os.WriteFile(configFile, []byte("[client]\n  enrollment_token = \"abcd1234_secret\"\n"), 0640))
+
+	require.NoError(t, storeEnrolledAuth(configFile, "enrolled-1:pass"))
+
+	content, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "[client]\n  auth = \"enrolled-1:pass\"\n", string(content))
+	info, err := os.Stat(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
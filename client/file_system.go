@@ -0,0 +1,249 @@
+package chclient
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+)
+
+// handleFileSystemRequest lists, stats, deletes or creates files on behalf of the server
+func (c *Client) handleFileSystemRequest(payload []byte) (*comm.FileSystemResponse, error) {
+	cfg := c.configHolder.FileSystemConfig
+	if !cfg.Enabled {
+		return nil, errors.New("file system access is disabled on this client, check [file-system] enabled option")
+	}
+
+	req, err := comm.DecodeFileSystemRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(req.Path) {
+		return nil, fmt.Errorf("path %q must be absolute", req.Path)
+	}
+	path := filepath.Clean(req.Path)
+
+	switch req.Operation {
+	case comm.FileSystemOperationList:
+		return listDir(cfg, path, req.Offset, req.Limit)
+	case comm.FileSystemOperationStat:
+		return statFile(cfg, path)
+	case comm.FileSystemOperationDelete, comm.FileSystemOperationMkdir:
+		if !cfg.ModifyEnabled {
+			return nil, errors.New("modifying the file system is disabled on this client, check [file-system] modify_enabled option")
+		}
+		c.Infof("File system %s of %s requested by the server", req.Operation, path)
+		if req.Operation == comm.FileSystemOperationDelete {
+			return deleteFile(cfg, path, req.Recursive)
+		}
+		return makeDir(cfg, path, req.Recursive)
+	default:
+		return nil, fmt.Errorf("unknown file system operation %q", req.Operation)
+	}
+}
+
+// listDir returns the requested page of the directory entries sorted by name, entries matching a denied pattern are skipped
+func listDir(cfg clientconfig.FileSystemConfig, path string, offset, limit int) (*comm.FileSystemResponse, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFileSystemAllowed(cfg, resolved); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(resolved)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if matchPathOrParents(cfg.Denied, filepath.Join(resolved, entry.Name())) != "" {
+			continue
+		}
+		visible = append(visible, entry)
+	}
+
+	start, end := pageBounds(len(visible), offset, limit)
+	result := &comm.FileSystemResponse{
+		Entries:    make([]*comm.FileInfo, 0, end-start),
+		TotalCount: len(visible),
+	}
+	for _, entry := range visible[start:end] {
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// removed after reading the directory
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Entries = append(result.Entries, newFileInfo(filepath.Join(path, entry.Name()), filepath.Join(resolved, entry.Name()), info))
+	}
+	return result, nil
+}
+
+func statFile(cfg clientconfig.FileSystemConfig, path string) (*comm.FileSystemResponse, error) {
+	resolved, err := resolveParent(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFileSystemAllowed(cfg, resolved); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return &comm.FileSystemResponse{File: newFileInfo(path, resolved, info)}, nil
+}
+
+// deleteFile removes a file, a symbolic link or an empty directory. Non-empty directories are removed if recursive is set
+// and none of the contained files matches a denied pattern.
+func deleteFile(cfg clientconfig.FileSystemConfig, path string, recursive bool) (*comm.FileSystemResponse, error) {
+	resolved, err := resolveParent(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Dir(resolved) == resolved {
+		return nil, fmt.Errorf("deleting %s is not allowed", path)
+	}
+	if err := checkFileSystemAllowed(cfg, resolved); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if !recursive || !info.IsDir() {
+		if err := os.Remove(resolved); err != nil {
+			return nil, err
+		}
+		return &comm.FileSystemResponse{File: newFileInfo(path, resolved, info)}, nil
+	}
+
+	err = filepath.WalkDir(resolved, func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := checkFileSystemAllowed(cfg, p); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(resolved); err != nil {
+		return nil, err
+	}
+	return &comm.FileSystemResponse{File: newFileInfo(path, resolved, info)}, nil
+}
+
+// makeDir creates a directory, missing parent directories are created if recursive is set
+func makeDir(cfg clientconfig.FileSystemConfig, path string, recursive bool) (*comm.FileSystemResponse, error) {
+	var resolved string
+	var err error
+	if recursive {
+		resolved, err = resolveExistingPrefix(path)
+	} else {
+		resolved, err = resolveParent(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFileSystemAllowed(cfg, resolved); err != nil {
+		return nil, err
+	}
+
+	if recursive {
+		err = os.MkdirAll(resolved, os.ModePerm)
+	} else {
+		err = os.Mkdir(resolved, os.ModePerm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return &comm.FileSystemResponse{File: newFileInfo(path, resolved, info)}, nil
+}
+
+func checkFileSystemAllowed(cfg clientconfig.FileSystemConfig, path string) error {
+	return checkPathAllowed(cfg.Allowed, cfg.Denied, path, "file system")
+}
+
+// resolveParent resolves symbolic links in the parent directories of the path, but not in the last element,
+// so a symbolic link itself can be stated or deleted
+func resolveParent(path string) (string, error) {
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
+// resolveExistingPrefix resolves symbolic links in the longest existing part of the path
+func resolveExistingPrefix(path string) (string, error) {
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(p) == p {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(p))
+	}
+}
+
+func newFileInfo(path, resolved string, info fs.FileInfo) *comm.FileInfo {
+	result := &comm.FileInfo{
+		Name:    info.Name(),
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		result.Type = comm.FileTypeSymlink
+		result.LinkTarget, _ = os.Readlink(resolved)
+	case info.IsDir():
+		result.Type = comm.FileTypeDir
+	case info.Mode().IsRegular():
+		result.Type = comm.FileTypeFile
+	default:
+		result.Type = comm.FileTypeOther
+	}
+	return result
+}
+
+// pageBounds returns the slice bounds of the page, a limit <= 0 selects all entries from the offset
+func pageBounds(total, offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return offset, end
+}
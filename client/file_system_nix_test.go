@@ -0,0 +1,167 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+)
+
+func TestHandleFileSystemRequest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0600))
+
+	testCases := []struct {
+		Name          string
+		Config        clientconfig.FileSystemConfig
+		Request       comm.FileSystemRequest
+		ExpectedError string
+	}{
+		{
+			Name:          "disabled",
+			Config:        clientconfig.FileSystemConfig{},
+			Request:       comm.FileSystemRequest{Operation: comm.FileSystemOperationList, Path: dir},
+			ExpectedError: "file system access is disabled on this client, check [file-system] enabled option",
+		},
+		{
+			Name:          "modify disabled",
+			Config:        clientconfig.FileSystemConfig{Enabled: true},
+			Request:       comm.FileSystemRequest{Operation: comm.FileSystemOperationDelete, Path: filepath.Join(dir, "file.txt")},
+			ExpectedError: "modifying the file system is disabled on this client, check [file-system] modify_enabled option",
+		},
+		{
+			Name:          "relative path",
+			Config:        clientconfig.FileSystemConfig{Enabled: true},
+			Request:       comm.FileSystemRequest{Operation: comm.FileSystemOperationStat, Path: "file.txt"},
+			ExpectedError: `path "file.txt" must be absolute`,
+		},
+		{
+			Name:          "unknown operation",
+			Config:        clientconfig.FileSystemConfig{Enabled: true},
+			Request:       comm.FileSystemRequest{Operation: "chmod", Path: dir},
+			ExpectedError: `unknown file system operation "chmod"`,
+		},
+		{
+			Name:    "stat",
+			Config:  clientconfig.FileSystemConfig{Enabled: true},
+			Request: comm.FileSystemRequest{Operation: comm.FileSystemOperationStat, Path: filepath.Join(dir, "file.txt")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := &Client{
+				Logger:       testLog,
+				configHolder: &ClientConfigHolder{Config: &clientconfig.Config{FileSystemConfig: tc.Config}},
+			}
+			payload, err := json.Marshal(tc.Request)
+			require.NoError(t, err)
+
+			resp, err := c.handleFileSystemRequest(payload)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "file.txt", resp.File.Name)
+			assert.Equal(t, comm.FileTypeFile, resp.File.Type)
+			assert.Equal(t, int64(7), resp.File.Size)
+			assert.Equal(t, "-rw-------", resp.File.Mode)
+		})
+	}
+}
+
+func TestListDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"c.log", "a.log", "secret"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "b"), 0700))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "a.log"), filepath.Join(dir, "link")))
+
+	cfg := clientconfig.FileSystemConfig{Denied: []string{filepath.Join(dir, "secret")}}
+
+	resp, err := listDir(cfg, dir, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.TotalCount)
+	var names, types []string
+	for _, entry := range resp.Entries {
+		names = append(names, entry.Name)
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []string{"a.log", "b", "c.log", "link"}, names)
+	assert.Equal(t, []string{comm.FileTypeFile, comm.FileTypeDir, comm.FileTypeFile, comm.FileTypeSymlink}, types)
+	assert.Equal(t, filepath.Join(dir, "a.log"), resp.Entries[3].LinkTarget)
+	assert.Equal(t, filepath.Join(dir, "b"), resp.Entries[1].Path)
+
+	resp, err = listDir(cfg, dir, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.TotalCount)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, "b", resp.Entries[0].Name)
+	assert.Equal(t, "c.log", resp.Entries[1].Name)
+
+	resp, err = listDir(cfg, dir, 10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.TotalCount)
+	assert.Empty(t, resp.Entries)
+
+	_, err = listDir(clientconfig.FileSystemConfig{Allowed: []string{"/var/log"}}, dir, 0, 0)
+	assert.ErrorContains(t, err, "doesn't match any allowed pattern, therefore the file system request is rejected")
+}
+
+func TestDeleteFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs", "old"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logs", "old", "app.log"), nil, 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "data", "keys"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data", "keys", "id"), nil, 0600))
+
+	cfg := clientconfig.FileSystemConfig{Denied: []string{filepath.Join(dir, "data", "keys")}}
+
+	_, err := deleteFile(cfg, filepath.Join(dir, "logs"), false)
+	assert.Error(t, err)
+	assert.DirExists(t, filepath.Join(dir, "logs"))
+
+	resp, err := deleteFile(cfg, filepath.Join(dir, "logs"), true)
+	require.NoError(t, err)
+	assert.Equal(t, comm.FileTypeDir, resp.File.Type)
+	assert.NoDirExists(t, filepath.Join(dir, "logs"))
+
+	_, err = deleteFile(cfg, filepath.Join(dir, "data"), true)
+	assert.ErrorContains(t, err, "matches denied pattern")
+	assert.FileExists(t, filepath.Join(dir, "data", "keys", "id"))
+
+	_, err = deleteFile(cfg, "/", true)
+	assert.EqualError(t, err, "deleting / is not allowed")
+}
+
+func TestMakeDir(t *testing.T) {
+	dir := t.TempDir()
+	cfg := clientconfig.FileSystemConfig{Denied: []string{filepath.Join(dir, "denied")}}
+
+	resp, err := makeDir(cfg, filepath.Join(dir, "new"), false)
+	require.NoError(t, err)
+	assert.Equal(t, comm.FileTypeDir, resp.File.Type)
+	assert.DirExists(t, filepath.Join(dir, "new"))
+
+	_, err = makeDir(cfg, filepath.Join(dir, "a", "b"), false)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = makeDir(cfg, filepath.Join(dir, "a", "b"), true)
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, "a", "b"))
+
+	_, err = makeDir(cfg, filepath.Join(dir, "denied", "sub"), true)
+	assert.ErrorContains(t, err, "matches denied pattern")
+	assert.NoDirExists(t, filepath.Join(dir, "denied"))
+}
@@ -0,0 +1,117 @@
+package chclient
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+// reverseTunnels holds the listeners of the reverse tunnels started by the server on a connection.
+// Each accepted connection is forwarded through a channel, the server connects it to the destination of the tunnel.
+type reverseTunnels struct {
+	*logger.Logger
+	conn ssh.Conn
+
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+	connCount int
+}
+
+func newReverseTunnels(l *logger.Logger, conn ssh.Conn) *reverseTunnels {
+	return &reverseTunnels{
+		Logger:    l,
+		conn:      conn,
+		listeners: make(map[string]net.Listener),
+	}
+}
+
+func (r *reverseTunnels) handleStartRequest(payload []byte) (interface{}, error) {
+	req, err := comm.DecodeStartReverseTunnelRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.listeners[req.ID]; ok {
+		return nil, fmt.Errorf("reverse tunnel %s already started", req.ID)
+	}
+
+	l, err := net.Listen("tcp", req.Local)
+	if err != nil {
+		return nil, err
+	}
+	r.listeners[req.ID] = l
+	r.Infof("Reverse tunnel %s listening on %s", req.ID, l.Addr())
+
+	go r.serve(req.ID, l)
+	return nil, nil
+}
+
+func (r *reverseTunnels) handleStopRequest(payload []byte) (interface{}, error) {
+	req, err := comm.DecodeStopReverseTunnelRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// stopping is idempotent, the server stops tunnels also when the client might have closed them already
+	if l, ok := r.listeners[req.ID]; ok {
+		delete(r.listeners, req.ID)
+		l.Close()
+		r.Infof("Reverse tunnel %s stopped", req.ID)
+	}
+	return nil, nil
+}
+
+// closeAll closes all listeners, reverse tunnels end with the connection to the server
+func (r *reverseTunnels) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, l := range r.listeners {
+		delete(r.listeners, id)
+		l.Close()
+	}
+}
+
+func (r *reverseTunnels) serve(id string, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			r.Debugf("Reverse tunnel %s listener closed: %v", id, err)
+			return
+		}
+		go r.forward(id, conn)
+	}
+}
+
+func (r *reverseTunnels) forward(id string, conn net.Conn) {
+	defer conn.Close()
+
+	r.mu.Lock()
+	r.connCount++
+	l := r.Fork("tunnel#%s conn#%d", id, r.connCount)
+	r.mu.Unlock()
+
+	ch, reqs, err := r.conn.OpenChannel(comm.ChannelReverseTunnel, []byte(id))
+	if err != nil {
+		l.Errorf("Failed to forward connection from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	l.Debugf("Open from %s", conn.RemoteAddr())
+	s, rcv := chshare.Pipe(conn, ch)
+	l.Debugf("Close (sent %s received %s)", sizestr.ToString(s), sizestr.ToString(rcv))
+}
@@ -0,0 +1,87 @@
+package chclient
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+// channelConnFake connects opened channels to an echo, like the server connects them to the tunnel destination
+type channelConnFake struct {
+	ssh.Conn
+	opened chan string
+}
+
+func (c *channelConnFake) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	c.opened <- name + " " + string(data)
+
+	server, client := net.Pipe()
+	go func() {
+		defer server.Close()
+		_, _ = io.Copy(server, server)
+	}()
+	reqs := make(chan *ssh.Request)
+	close(reqs)
+	return &pipeChannel{Conn: client}, reqs, nil
+}
+
+type pipeChannel struct {
+	net.Conn
+}
+
+func (c *pipeChannel) CloseWrite() error {
+	return c.Close()
+}
+
+func (c *pipeChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return false, nil
+}
+
+func (c *pipeChannel) Stderr() io.ReadWriter {
+	return nil
+}
+
+func TestReverseTunnels(t *testing.T) {
+	conn := &channelConnFake{opened: make(chan string, 1)}
+	reverse := newReverseTunnels(testLog, conn)
+	defer reverse.closeAll()
+
+	start, err := json.Marshal(comm.StartReverseTunnelRequest{ID: "3", Local: "127.0.0.1:0"})
+	require.NoError(t, err)
+	_, err = reverse.handleStartRequest(start)
+	require.NoError(t, err)
+
+	_, err = reverse.handleStartRequest(start)
+	assert.EqualError(t, err, "reverse tunnel 3 already started")
+
+	addr := reverse.listeners["3"].Addr().String()
+	local, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer local.Close()
+
+	_, err = local.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(local, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+	assert.Equal(t, comm.ChannelReverseTunnel+" 3", <-conn.opened)
+
+	stop, err := json.Marshal(comm.StopReverseTunnelRequest{ID: "3"})
+	require.NoError(t, err)
+	_, err = reverse.handleStopRequest(stop)
+	require.NoError(t, err)
+	// stopping again is a no-op
+	_, err = reverse.handleStopRequest(stop)
+	require.NoError(t, err)
+
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
@@ -2,7 +2,9 @@ package system
 
 import (
 	"context"
+	"os"
 	"os/exec"
+	"sort"
 
 	"github.com/openrport/openrport/share/logger"
 )
@@ -13,6 +15,8 @@ type CmdExecutorContext struct {
 	WorkingDir  string
 	IsSudo      bool
 	HasShebang  bool
+	// Env holds environment variables set in addition to the ones of the client
+	Env map[string]string
 }
 
 type CmdExecutor interface {
@@ -38,3 +42,23 @@ func (e *CmdExecutorImpl) Start(cmd *exec.Cmd) error {
 func (e *CmdExecutorImpl) Wait(cmd *exec.Cmd) error {
 	return cmd.Wait()
 }
+
+// envNames returns the names of the additional environment variables in a stable order
+func (c *CmdExecutorContext) envNames() []string {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func setEnv(cmd *exec.Cmd, execCtx *CmdExecutorContext) {
+	if len(execCtx.Env) == 0 {
+		return
+	}
+	cmd.Env = os.Environ()
+	for _, name := range execCtx.envNames() {
+		cmd.Env = append(cmd.Env, name+"="+execCtx.Env[name])
+	}
+}
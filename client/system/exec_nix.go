@@ -15,6 +15,10 @@ func (e *CmdExecutorImpl) New(ctx context.Context, execCtx *CmdExecutorContext)
 	var args []string
 	if execCtx.IsSudo {
 		args = append(args, "sudo", "-n")
+		// sudo resets the environment, sudoers must allow keeping these variables
+		if len(execCtx.Env) > 0 {
+			args = append(args, "--preserve-env="+strings.Join(execCtx.envNames(), ","))
+		}
 	}
 
 	var interpreter string
@@ -37,6 +41,7 @@ func (e *CmdExecutorImpl) New(ctx context.Context, execCtx *CmdExecutorContext)
 
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
 	cmd.Dir = execCtx.WorkingDir
+	setEnv(cmd, execCtx)
 
 	return cmd
 }
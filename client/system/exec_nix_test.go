@@ -4,7 +4,14 @@
 package system
 
 import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
 	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/logger"
 )
 
 func getCmdBuildTestcases() []cmdBuildTestCase {
@@ -56,3 +63,18 @@ func getCmdBuildTestcases() []cmdBuildTestCase {
 		},
 	}
 }
+
+func TestBuildCmdWithEnv(t *testing.T) {
+	cmdExecutor := NewCmdExecutor(logger.NewLogger("client-system", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug))
+	execCtx := &CmdExecutorContext{
+		Command: "/script.sh",
+		IsSudo:  true,
+		Env:     map[string]string{"RPORT_VAULT_B": "b", "RPORT_VAULT_A": "a"},
+	}
+
+	cmd := cmdExecutor.New(context.Background(), execCtx)
+
+	assert.Contains(t, cmd.String(), "sudo -n --preserve-env=RPORT_VAULT_A,RPORT_VAULT_B /bin/sh /script.sh")
+	assert.Subset(t, cmd.Env, []string{"RPORT_VAULT_A=a", "RPORT_VAULT_B=b"})
+	assert.Greater(t, len(cmd.Env), 2)
+}
@@ -17,13 +17,17 @@ func (e *CmdExecutorImpl) New(ctx context.Context, execCtx *CmdExecutorContext)
 	interpreterPath := execCtx.Interpreter.Get()
 	e.Debugf("resolved interpreter %s for input %s", interpreterPath, execCtx.Interpreter.InterpreterNameFromInput)
 
+	var cmd *exec.Cmd
 	if execCtx.Interpreter.Matches(chshare.CmdShell, true) {
-		return buildCmdInterpreterCmd(ctx, execCtx, interpreterPath)
+		cmd = buildCmdInterpreterCmd(ctx, execCtx, interpreterPath)
 	} else if execCtx.Interpreter.Matches(chshare.PowerShell, false) {
-		return buildPowershellCmd(ctx, execCtx, interpreterPath)
+		cmd = buildPowershellCmd(ctx, execCtx, interpreterPath)
 	} else {
-		return buildDefaultCmd(ctx, execCtx, interpreterPath)
+		cmd = buildDefaultCmd(ctx, execCtx, interpreterPath)
 	}
+	setEnv(cmd, execCtx)
+
+	return cmd
 }
 
 func buildCmdInterpreterCmd(ctx context.Context, execCtx *CmdExecutorContext, interpreterPath string) *exec.Cmd {
@@ -1,6 +1,10 @@
 package system
 
-import "strings"
+import (
+	"strings"
+
+	chshare "github.com/openrport/openrport/share"
+)
 
 type Interpreter struct {
 	InterpreterNameFromInput string
@@ -21,3 +25,15 @@ func (i Interpreter) Matches(search string, exact bool) bool {
 
 	return search == i.InterpreterNameFromInput || strings.Contains(resolvedInterpreterStr, search)
 }
+
+// EnvReference returns how a script run by the interpreter refers to the value of an environment variable
+func (i Interpreter) EnvReference(name string) string {
+	switch {
+	case i.Matches(chshare.CmdShell, true):
+		return "%" + name + "%"
+	case i.Matches(chshare.PowerShell, false), i.Matches("pwsh", false):
+		return "$env:" + name
+	default:
+		return "${" + name + "}"
+	}
+}
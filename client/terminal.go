@@ -0,0 +1,115 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+const DefaultTerminalType = "xterm-256color"
+
+// terminalSession is an interactive shell attached to a pseudo terminal
+type terminalSession interface {
+	io.ReadWriteCloser
+	Resize(cols, rows uint16) error
+	// Wait blocks until the shell exits and returns its exit code
+	Wait() (int, error)
+}
+
+func (c *Client) handleTerminalChannel(newChannel ssh.NewChannel) {
+	if !c.configHolder.RemoteTerminal.Enabled {
+		c.Errorf("Rejecting terminal session: remote terminal is disabled")
+		if err := newChannel.Reject(ssh.Prohibited, "remote terminal is disabled"); err != nil {
+			c.Errorf("Failed to reject terminal session: %v", err)
+		}
+		return
+	}
+
+	req, err := comm.DecodeTerminalRequest(newChannel.ExtraData())
+	if err != nil {
+		c.Errorf("Rejecting terminal session: %v", err)
+		if err := newChannel.Reject(ssh.ConnectionFailed, err.Error()); err != nil {
+			c.Errorf("Failed to reject terminal session: %v", err)
+		}
+		return
+	}
+	if req.Term == "" {
+		req.Term = DefaultTerminalType
+	}
+
+	session, err := startTerminal(c.configHolder.RemoteTerminal.Shell, req)
+	if err != nil {
+		c.Errorf("Failed to start terminal session: %v", err)
+		if err := newChannel.Reject(ssh.ConnectionFailed, err.Error()); err != nil {
+			c.Errorf("Failed to reject terminal session: %v", err)
+		}
+		return
+	}
+
+	ch, reqs, err := newChannel.Accept()
+	if err != nil {
+		c.Errorf("Failed to accept terminal session: %v", err)
+		session.Close()
+		return
+	}
+	c.Infof("Terminal session started")
+
+	go c.handleTerminalRequests(session, reqs)
+	go func() {
+		// stdin of the shell ends when the server closes the channel, the shell is terminated then
+		_, _ = io.Copy(session, ch)
+		session.Close()
+	}()
+
+	// reading from the pty fails once the shell has exited
+	_, _ = io.Copy(ch, session)
+
+	exit := &comm.TerminalExit{}
+	exit.ExitCode, err = session.Wait()
+	if err != nil {
+		exit.Error = err.Error()
+	}
+	session.Close()
+
+	payload, err := json.Marshal(exit)
+	if err == nil {
+		_, _ = ch.SendRequest(comm.RequestTypeTerminalExit, false, payload)
+	}
+	ch.Close()
+	c.Infof("Terminal session finished with exit code %d", exit.ExitCode)
+}
+
+func (c *Client) handleTerminalRequests(session terminalSession, reqs <-chan *ssh.Request) {
+	for r := range reqs {
+		ok := false
+		if r.Type == comm.RequestTypeTerminalResize {
+			resize, err := comm.DecodeTerminalResize(r.Payload)
+			if err != nil {
+				c.Errorf("Invalid terminal resize request: %v", err)
+			} else if err := session.Resize(resize.Cols, resize.Rows); err != nil {
+				c.Errorf("Failed to resize terminal: %v", err)
+			} else {
+				ok = true
+			}
+		}
+		if r.WantReply {
+			_ = r.Reply(ok, nil)
+		}
+	}
+}
+
+func exitCodeFromErr(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return -1, err
+}
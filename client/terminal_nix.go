@@ -0,0 +1,69 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+const DefaultTerminalShell = "/bin/sh"
+
+type ptySession struct {
+	cmd  *exec.Cmd
+	ptmx *os.File
+}
+
+func startTerminal(shell string, req *comm.TerminalRequest) (terminalSession, error) {
+	if shell == "" {
+		shell = os.Getenv("SHELL")
+	}
+	if shell == "" {
+		shell = DefaultTerminalShell
+	}
+
+	cmd := exec.Command(shell)
+	cmd.Env = append(os.Environ(), "TERM="+req.Term)
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: req.Cols, Rows: req.Rows})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ptySession{
+		cmd:  cmd,
+		ptmx: ptmx,
+	}, nil
+}
+
+func (s *ptySession) Read(p []byte) (int, error) {
+	return s.ptmx.Read(p)
+}
+
+func (s *ptySession) Write(p []byte) (int, error) {
+	return s.ptmx.Write(p)
+}
+
+func (s *ptySession) Resize(cols, rows uint16) error {
+	return pty.Setsize(s.ptmx, &pty.Winsize{Cols: cols, Rows: rows})
+}
+
+func (s *ptySession) Wait() (int, error) {
+	return exitCodeFromErr(s.cmd.Wait())
+}
+
+// Close closes the pty and kills the shell if it is still running
+func (s *ptySession) Close() error {
+	err := s.ptmx.Close()
+	// the process might already be gone, nothing to do then
+	_ = s.cmd.Process.Kill()
+	return err
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+func TestStartTerminal(t *testing.T) {
+	session, err := startTerminal("/bin/sh", &comm.TerminalRequest{Cols: 80, Rows: 24, Term: DefaultTerminalType})
+	require.NoError(t, err)
+	defer session.Close()
+
+	require.NoError(t, session.Resize(100, 30))
+
+	_, err = session.Write([]byte("stty size; echo $TERM; exit 3\n"))
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	// reading fails with EIO once the shell has exited
+	_, _ = io.Copy(output, session)
+
+	exitCode, err := session.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	assert.Contains(t, output.String(), "30 100")
+	assert.Contains(t, output.String(), DefaultTerminalType)
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+import (
+	"errors"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+func startTerminal(shell string, req *comm.TerminalRequest) (terminalSession, error) {
+	return nil, errors.New("interactive terminal sessions are not supported on windows")
+}
@@ -44,6 +44,7 @@ func DecodeConfig(cfgPath string, pFlags *pflag.FlagSet, overrideConfigWithCLIAr
 	if err := chshare.DecodeViperConfig(viperCfg, config.Config, nil); err != nil {
 		return nil, err
 	}
+	config.ConfigFile = viperCfg.ConfigFileUsed()
 
 	if config.InterpreterAliases == nil {
 		config.InterpreterAliases = map[string]string{}
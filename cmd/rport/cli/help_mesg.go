@@ -122,6 +122,34 @@ var ClientHelp = `
     --remote-scripts-enabled, Enable or disable remote scripts.
     Defaults: false
 
+    --remote-terminal-enabled, Enable or disable interactive terminal sessions opened from the server.
+    Defaults: false
+
+    --remote-terminal-shell, Shell started for interactive terminal sessions.
+    Defaults: $SHELL or /bin/sh. Not supported on windows.
+
+    --file-download-enabled, Allow the server to download files from this client.
+    Defaults: false
+
+    --file-download-allowed, Glob pattern of files or directories the server may download.
+    Can be used multiple times. If not given, all files not denied can be downloaded.
+
+    --file-download-denied, Glob pattern of files or directories the server must not download.
+    Can be used multiple times. Defaults: /etc/shadow*, /etc/gshadow*, /etc/ssh/ssh_host_*_key, /proc, /sys, /dev
+    or C:\Windows\System32\config on windows
+
+    --file-system-enabled, Allow the server to list directories and read file information on this client.
+    Defaults: false
+
+    --file-system-modify-enabled, Additionally allow the server to delete files and to create directories.
+    Defaults: false
+
+    --file-system-allowed, Glob pattern of files or directories the server may access with the file browser.
+    Can be used multiple times. If not given, all files not denied can be accessed.
+
+    --file-system-denied, Glob pattern of files or directories the server must not access with the file browser.
+    Can be used multiple times. Defaults: same as --file-download-denied
+
     --data-dir, Temporary directory to store temp client data.
     Defaults: /var/lib/rport (unix) or C:\Program Files\rport (windows)
 
@@ -55,6 +55,18 @@ func BindPFlagsToViperConfig(pFlags *pflag.FlagSet, viperCfg *viper.Viper) {
 
 	_ = viperCfg.BindPFlag("file-reception.protected", pFlags.Lookup("file-reception-protected"))
 	_ = viperCfg.BindPFlag("file-reception.enabled", pFlags.Lookup("file-reception-enabled"))
+
+	_ = viperCfg.BindPFlag("file-download.enabled", pFlags.Lookup("file-download-enabled"))
+	_ = viperCfg.BindPFlag("file-download.allowed", pFlags.Lookup("file-download-allowed"))
+	_ = viperCfg.BindPFlag("file-download.denied", pFlags.Lookup("file-download-denied"))
+
+	_ = viperCfg.BindPFlag("file-system.enabled", pFlags.Lookup("file-system-enabled"))
+	_ = viperCfg.BindPFlag("file-system.modify_enabled", pFlags.Lookup("file-system-modify-enabled"))
+	_ = viperCfg.BindPFlag("file-system.allowed", pFlags.Lookup("file-system-allowed"))
+	_ = viperCfg.BindPFlag("file-system.denied", pFlags.Lookup("file-system-denied"))
+
+	_ = viperCfg.BindPFlag("remote-terminal.enabled", pFlags.Lookup("remote-terminal-enabled"))
+	_ = viperCfg.BindPFlag("remote-terminal.shell", pFlags.Lookup("remote-terminal-shell"))
 }
 
 func SetPFlags(pFlags *pflag.FlagSet) {
@@ -107,6 +119,15 @@ func SetPFlags(pFlags *pflag.FlagSet) {
 	pFlags.StringArray("monitoring-net-wan", []string{}, "")
 	pFlags.StringArray("file-reception-protected", []string{}, "")
 	pFlags.Bool("file-reception-enabled", true, "")
+	pFlags.Bool("file-download-enabled", false, "")
+	pFlags.StringArray("file-download-allowed", []string{}, "")
+	pFlags.StringArray("file-download-denied", []string{}, "")
+	pFlags.Bool("file-system-enabled", false, "")
+	pFlags.Bool("file-system-modify-enabled", false, "")
+	pFlags.StringArray("file-system-allowed", []string{}, "")
+	pFlags.StringArray("file-system-denied", []string{}, "")
+	pFlags.Bool("remote-terminal-enabled", false, "")
+	pFlags.String("remote-terminal-shell", "", "")
 	pFlags.String("bind-interface", "", "")
 }
 
@@ -141,4 +162,13 @@ func SetViperConfigDefaults(viperCfg *viper.Viper) {
 
 	viperCfg.SetDefault("file-reception.protected", chclient.FileReceptionGlobs)
 	viperCfg.SetDefault("file-reception.enabled", true)
+
+	viperCfg.SetDefault("file-download.enabled", false)
+	viperCfg.SetDefault("file-download.denied", chclient.FileDownloadDenyGlobs)
+
+	viperCfg.SetDefault("file-system.enabled", false)
+	viperCfg.SetDefault("file-system.modify_enabled", false)
+	viperCfg.SetDefault("file-system.denied", chclient.FileDownloadDenyGlobs)
+
+	viperCfg.SetDefault("remote-terminal.enabled", false)
 }
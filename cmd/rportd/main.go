@@ -39,6 +39,7 @@ const (
 	DefaultMaxRequestBytes                  = 10 * 1024       // 10 KB
 	DefaultMaxRequestBytesClient            = 512 * 1024      // 512KB
 	DefaultMaxFilePushBytes                 = int64(10 << 20) // 10M
+	DefaultJobOutputMaxSize                 = int64(1 << 30)  // 1G
 	DefaultCheckPortTimeout                 = 2 * time.Second
 	DefaultUsedPorts                        = "20000-30000"
 	DefaultExcludedPorts                    = "1-1024"
@@ -47,6 +48,8 @@ const (
 	DefaultRunRemoteCmdTimeoutSec           = 60
 	DefaultMonitoringDataStorageDuration    = "7d"
 	DefaultPairingURL                       = "https://pairing.openrport.io"
+	DefaultClientCertValidity               = 30 * 24 * time.Hour
+	DefaultClientAuthRotationGracePeriod    = time.Hour
 )
 
 var (
@@ -239,6 +242,9 @@ var serverHelp = `
     --novnc-root, Specifies local directory path. If specified, rportd will serve
     novnc javascript app from this directory.
 
+    --xtermjs-root, Specifies local directory path. If specified, rportd will serve
+    xterm.js for the browser based ssh client from this directory.
+
     --guacd-address, Specifies network address (host:port) of guacd daemon. If specified, rportd will serve
     remote desktop connections in browser using Guacamole protocol.
 
@@ -315,6 +321,7 @@ func init() {
 	lFlags.String("tunnel-proxy-cert-file", "", "")
 	lFlags.String("tunnel-proxy-key-file", "", "")
 	lFlags.String("novnc-root", "", "")
+	lFlags.String("xtermjs-root", "", "")
 	lFlags.String("guacd-address", "", "")
 
 	cfgPath = pFlags.StringP("config", "c", "", "location of the config file")
@@ -347,6 +354,8 @@ func init() {
 	viperCfg.SetDefault("server.check_port_timeout", DefaultCheckPortTimeout)
 	viperCfg.SetDefault("server.auth_write", true)
 	viperCfg.SetDefault("server.auth_multiuse_creds", true)
+	viperCfg.SetDefault("server.client_cert_validity", DefaultClientCertValidity)
+	viperCfg.SetDefault("server.client_auth_rotation_grace_period", DefaultClientAuthRotationGracePeriod)
 	viperCfg.SetDefault("server.run_remote_cmd_timeout_sec", DefaultRunRemoteCmdTimeoutSec)
 	viperCfg.SetDefault("server.client_login_wait", 2)
 	viperCfg.SetDefault("server.max_failed_login", 5)
@@ -369,6 +378,7 @@ func init() {
 	viperCfg.SetDefault("monitoring.enabled", true)
 	viperCfg.SetDefault("api.max_request_bytes", DefaultMaxRequestBytes)
 	viperCfg.SetDefault("api.max_filepush_size", DefaultMaxFilePushBytes)
+	viperCfg.SetDefault("job_output.max_size", DefaultJobOutputMaxSize)
 	viperCfg.SetDefault("api.enable_ws_test_endpoints", false)
 	viperCfg.SetDefault("api.totp_login_session_ttl", time.Minute*10)
 	viperCfg.SetDefault("api.totp_account_name", "RPort")
@@ -402,6 +412,7 @@ func bindPFlags() {
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_cert_file", pFlags.Lookup("tunnel-proxy-cert-file"))
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_key_file", pFlags.Lookup("tunnel-proxy-key-file"))
 	_ = viperCfg.BindPFlag("server.novnc_root", pFlags.Lookup("novnc-root"))
+	_ = viperCfg.BindPFlag("server.xtermjs_root", pFlags.Lookup("xtermjs-root"))
 	_ = viperCfg.BindPFlag("server.guacd_address", pFlags.Lookup("guacd-address"))
 
 	_ = viperCfg.BindPFlag("logging.log_file", pFlags.Lookup("log-file"))
@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	pgmigration "github.com/openrport/openrport/db/migration/postgres"
+	"github.com/openrport/openrport/db/postgres"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+// Backend opens the databases of the server stores. With SQLite every store has its own database file,
+// with PostgreSQL all stores share a single database.
+type Backend struct {
+	postgresDSN string
+}
+
+// NewSQLite returns a backend keeping every store in its own SQLite file.
+func NewSQLite() *Backend {
+	return &Backend{}
+}
+
+// NewPostgres returns a backend keeping all stores in the PostgreSQL database given by dsn.
+func NewPostgres(dsn string) *Backend {
+	return &Backend{postgresDSN: dsn}
+}
+
+func (b *Backend) IsPostgres() bool {
+	return b != nil && b.postgresDSN != ""
+}
+
+// Open returns the DB of the store with migrated DB scheme to the latest version.
+// sqlitePath, assetNames, asset and dataSourceOptions are only used for SQLite, on PostgreSQL the migrations of the store
+// are looked up by its name.
+func (b *Backend) Open(
+	store string,
+	sqlitePath string,
+	assetNames []string,
+	asset func(name string) ([]byte, error),
+	dataSourceOptions sqlite.DataSourceOptions,
+) (*sqlx.DB, error) {
+	if !b.IsPostgres() {
+		return sqlite.New(sqlitePath, assetNames, asset, dataSourceOptions)
+	}
+
+	pgAssetNames, pgAsset, err := pgmigration.Migrations(store)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := postgres.New(b.postgresDSN, store+"_schema_migrations", pgAssetNames, pgAsset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", store, err)
+	}
+
+	return db, nil
+}
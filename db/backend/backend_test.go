@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/dummy"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+// testPostgresDSNEnv allows to run the tests against a real postgres database, e.g. "host=127.0.0.1 user=rport dbname=rport_test"
+const testPostgresDSNEnv = "RPORT_TEST_POSTGRES_DSN"
+
+func TestSQLiteOpen(t *testing.T) {
+	b := NewSQLite()
+	assert.False(t, b.IsPostgres())
+
+	dbPath := path.Join(t.TempDir(), "dummy.db")
+	db, err := b.Open("dummy", dbPath, dummy.AssetNames(), dummy.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, "sqlite3", db.DriverName())
+	assert.FileExists(t, dbPath)
+}
+
+func TestNilBackendUsesSQLite(t *testing.T) {
+	var b *Backend
+	assert.False(t, b.IsPostgres())
+}
+
+func TestPostgresOpenUnknownStore(t *testing.T) {
+	b := NewPostgres("host=127.0.0.1")
+	assert.True(t, b.IsPostgres())
+
+	_, err := b.Open("unknown", "", nil, nil, sqlite.DataSourceOptions{})
+	assert.ErrorContains(t, err, `no postgres migrations found for "unknown"`)
+}
+
+func TestPostgresOpenAllStores(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", testPostgresDSNEnv)
+	}
+
+	b := NewPostgres(dsn)
+	for _, store := range []string{
+		"api_sessions",
+		"api_token",
+		"auditlog",
+		"client_groups",
+		"clients",
+		"ha",
+		"jobs",
+		"library",
+		"monitoring",
+		"notifications",
+		"vaults",
+	} {
+		db, err := b.Open(store, "", nil, nil, sqlite.DataSourceOptions{})
+		require.NoError(t, err, store)
+		assert.Equal(t, "postgres", db.DriverName())
+		require.NoError(t, db.Close())
+	}
+}
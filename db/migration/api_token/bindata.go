@@ -6,6 +6,8 @@
 // 002_plural_and_name.up.sql (169B)
 // 003_init.down.sql (57B)
 // 003_init.up.sql (513B)
+// 004_restrictions.down.sql (49B)
+// 004_restrictions.up.sql (53B)
 
 package api_token
 
@@ -193,6 +195,46 @@ func _003_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_restrictionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x31\x00\xce\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x61\x70\x69\x5f\x74\x6f\x6b\x65\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x72\x65\x73\x74\x72\x69\x63\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\xe6\xae\x16\x94\x31\x00\x00\x00")
+
+func _004_restrictionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_restrictionsDownSql,
+		"004_restrictions.down.sql",
+	)
+}
+
+func _004_restrictionsDownSql() (*asset, error) {
+	bytes, err := _004_restrictionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_restrictions.down.sql", size: 49, mode: os.FileMode(0644), modTime: time.Unix(1792200883, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7, 0xd8, 0x56, 0x69, 0x29, 0xa7, 0xb4, 0x6e, 0x27, 0x93, 0xdd, 0x39, 0x59, 0x74, 0x18, 0x7d, 0x65, 0xb0, 0xc0, 0xec, 0x2b, 0x11, 0xe5, 0xf9, 0x3e, 0x2b, 0xfe, 0xe1, 0xb7, 0x82, 0x73, 0x25}}
+	return a, nil
+}
+
+var __004_restrictionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x35\x00\xca\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x61\x70\x69\x5f\x74\x6f\x6b\x65\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x72\x65\x73\x74\x72\x69\x63\x74\x69\x6f\x6e\x73\x20\x54\x45\x58\x54\x3b\x0a\x03\x00\x60\xe7\xf9\x7f\x35\x00\x00\x00")
+
+func _004_restrictionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_restrictionsUpSql,
+		"004_restrictions.up.sql",
+	)
+}
+
+func _004_restrictionsUpSql() (*asset, error) {
+	bytes, err := _004_restrictionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_restrictions.up.sql", size: 53, mode: os.FileMode(0644), modTime: time.Unix(1792200883, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa1, 0x5f, 0xc9, 0xcf, 0x23, 0xb3, 0x94, 0x3c, 0x21, 0xce, 0xce, 0xdf, 0x98, 0x80, 0x9c, 0x25, 0x5a, 0x36, 0x43, 0x9c, 0x61, 0xd8, 0xfe, 0xc, 0x75, 0x7, 0xcc, 0x5c, 0x94, 0x37, 0xd1, 0x78}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +332,8 @@ var _bindata = map[string]func() (*asset, error){
 	"002_plural_and_name.up.sql":   _002_plural_and_nameUpSql,
 	"003_init.down.sql":            _003_initDownSql,
 	"003_init.up.sql":              _003_initUpSql,
+	"004_restrictions.down.sql":    _004_restrictionsDownSql,
+	"004_restrictions.up.sql":      _004_restrictionsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +388,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_plural_and_name.up.sql":   {_002_plural_and_nameUpSql, map[string]*bintree{}},
 	"003_init.down.sql":            {_003_initDownSql, map[string]*bintree{}},
 	"003_init.up.sql":              {_003_initUpSql, map[string]*bintree{}},
+	"004_restrictions.down.sql":    {_004_restrictionsDownSql, map[string]*bintree{}},
+	"004_restrictions.up.sql":      {_004_restrictionsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -2,6 +2,8 @@
 // sources:
 // 001_init.down.sql (23B)
 // 001_init.up.sql (928B)
+// 002_api_token.down.sql (48B)
+// 002_api_token.up.sql (57B)
 
 package auditlog
 
@@ -109,6 +111,46 @@ func _001_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __002_api_tokenDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x30\x00\xcf\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x60\x61\x75\x64\x69\x74\x6c\x6f\x67\x60\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x22\x61\x70\x69\x5f\x74\x6f\x6b\x65\x6e\x22\x3b\x0a\x03\x00\x91\x4f\x3a\xe3\x30\x00\x00\x00")
+
+func _002_api_tokenDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_api_tokenDownSql,
+		"002_api_token.down.sql",
+	)
+}
+
+func _002_api_tokenDownSql() (*asset, error) {
+	bytes, err := _002_api_tokenDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_api_token.down.sql", size: 48, mode: os.FileMode(0644), modTime: time.Unix(1792200883, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8d, 0x44, 0xdd, 0xa7, 0x67, 0x61, 0x45, 0xd, 0xae, 0xeb, 0xb9, 0xfe, 0x3c, 0x64, 0x6c, 0xbb, 0x29, 0x2e, 0xd, 0xf7, 0x14, 0x23, 0x5d, 0xbc, 0xc, 0xd, 0x9c, 0xc1, 0xac, 0x55, 0x4e, 0xe0}}
+	return a, nil
+}
+
+var __002_api_tokenUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x39\x00\xc6\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x60\x61\x75\x64\x69\x74\x6c\x6f\x67\x60\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x22\x61\x70\x69\x5f\x74\x6f\x6b\x65\x6e\x22\x20\x54\x45\x58\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x03\x00\x31\xd3\x95\x89\x39\x00\x00\x00")
+
+func _002_api_tokenUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_api_tokenUpSql,
+		"002_api_token.up.sql",
+	)
+}
+
+func _002_api_tokenUpSql() (*asset, error) {
+	bytes, err := _002_api_tokenUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_api_token.up.sql", size: 57, mode: os.FileMode(0644), modTime: time.Unix(1792200883, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3d, 0xda, 0x47, 0xfe, 0xe3, 0x8b, 0x65, 0xba, 0xa8, 0x77, 0xbb, 0xe4, 0xc6, 0xe8, 0x4e, 0xc6, 0x46, 0x36, 0x51, 0x44, 0x58, 0x48, 0xbf, 0xb1, 0x39, 0xd7, 0xc8, 0x50, 0xa, 0xac, 0x5c, 0xae}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -200,8 +242,10 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql": _001_initDownSql,
-	"001_init.up.sql":   _001_initUpSql,
+	"001_init.down.sql":      _001_initDownSql,
+	"001_init.up.sql":        _001_initUpSql,
+	"002_api_token.down.sql": _002_api_tokenDownSql,
+	"002_api_token.up.sql":   _002_api_tokenUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -250,8 +294,10 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql": {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":   {_001_initUpSql, map[string]*bintree{}},
+	"001_init.down.sql":      {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":        {_001_initUpSql, map[string]*bintree{}},
+	"002_api_token.down.sql": {_002_api_tokenDownSql, map[string]*bintree{}},
+	"002_api_token.up.sql":   {_002_api_tokenUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
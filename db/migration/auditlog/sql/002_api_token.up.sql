@@ -0,0 +1 @@
+ALTER TABLE `auditlog` ADD COLUMN "api_token" TEXT NULL;
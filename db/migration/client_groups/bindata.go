@@ -4,6 +4,12 @@
 // 001_init.up.sql (130B)
 // 002_add_allowed_user_groups.down.sql (0)
 // 002_add_allowed_user_groups.up.sql (79B)
+// 003_add_require_approval.down.sql (58B)
+// 003_add_require_approval.up.sql (77B)
+// 004_roles.down.sql (18B)
+// 004_roles.up.sql (233B)
+// 005_add_bandwidth_limits.down.sql (122B)
+// 005_add_bandwidth_limits.up.sql (160B)
 
 package client_groups
 
@@ -71,7 +77,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\xb6\xe6\x02\x04\x00\x00\xff\xff\xee\xde\xdd\xb3\x1a\x00\x00\x00")
+var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1a\x00\xe5\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x3b\x0a\x03\x00\xee\xde\xdd\xb3\x1a\x00\x00\x00")
 
 func _001_initDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -86,12 +92,12 @@ func _001_initDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.down.sql", size: 26, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.down.sql", size: 26, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe6, 0xb0, 0x84, 0x21, 0xc9, 0x49, 0x4c, 0x27, 0xff, 0xbe, 0xe7, 0x93, 0x92, 0xb1, 0xba, 0x91, 0x59, 0xf2, 0x6c, 0x61, 0xcd, 0x73, 0x64, 0xe7, 0xba, 0xd9, 0x18, 0xa4, 0xe2, 0x60, 0x4b, 0x34}}
 	return a, nil
 }
 
-var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\x48\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\xd0\xe0\x52\x50\x50\x50\xc8\x4c\x51\x08\x71\x8d\x08\x51\x08\x08\xf2\xf4\x75\x0c\x8a\x54\xf0\x76\x8d\x54\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\xd1\xe1\xe2\x4c\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x83\xa8\x43\x92\x2b\x48\x2c\x4a\xcc\x2d\x46\x15\xe6\xd2\x54\x08\xf7\x0c\xf1\xf0\x0f\x0d\x51\x08\xf2\x0f\xf7\x74\xb1\xe6\x02\x04\x00\x00\xff\xff\xa5\xc7\xf9\xc7\x82\x00\x00\x00")
+var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\x48\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\xd0\xe0\x52\x50\x50\x50\xc8\x4c\x51\x08\x71\x8d\x08\x51\x08\x08\xf2\xf4\x75\x0c\x8a\x54\xf0\x76\x8d\x54\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\xd1\xe1\xe2\x4c\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x83\xa8\x43\x92\x2b\x48\x2c\x4a\xcc\x2d\x46\x15\xe6\xd2\x54\x08\xf7\x0c\xf1\xf0\x0f\x0d\x51\x08\xf2\x0f\xf7\x74\xb1\xe6\x02\x0c\x00\xa5\xc7\xf9\xc7\x82\x00\x00\x00")
 
 func _001_initUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -106,12 +112,12 @@ func _001_initUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.up.sql", size: 130, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.up.sql", size: 130, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8f, 0xd4, 0x3f, 0x76, 0x69, 0xae, 0xb5, 0x56, 0x87, 0xaf, 0x4c, 0xf7, 0xbb, 0xcb, 0x98, 0x44, 0x2d, 0xd6, 0xb, 0xf4, 0x0, 0x2f, 0xbf, 0xd7, 0x92, 0xf3, 0xe2, 0xa5, 0xb8, 0x89, 0xda, 0x12}}
 	return a, nil
 }
 
-var __002_add_allowed_user_groupsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var __002_add_allowed_user_groupsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func _002_add_allowed_user_groupsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -126,12 +132,12 @@ func _002_add_allowed_user_groupsDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_add_allowed_user_groups.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_add_allowed_user_groups.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
 	return a, nil
 }
 
-var __002_add_allowed_user_groupsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4a\xcc\x29\x49\x2d\x52\x28\x49\x4c\xca\x49\x55\x50\x4a\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\x52\x48\x4c\x49\x51\x48\xcc\xc9\xc9\x2f\x4f\x4d\x89\x2f\x2d\x4e\x2d\x82\x4a\x28\x84\xb8\x46\x84\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x28\xb8\xb8\xba\x39\x86\xfa\x84\x28\xa8\x47\xc7\xaa\x5b\x03\x02\x00\x00\xff\xff\x73\xd5\xd2\x17\x4f\x00\x00\x00")
+var __002_add_allowed_user_groupsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4f\x00\xb0\xff\x61\x6c\x74\x65\x72\x20\x74\x61\x62\x6c\x65\x20\x22\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x22\x20\x61\x64\x64\x20\x61\x6c\x6c\x6f\x77\x65\x64\x5f\x75\x73\x65\x72\x5f\x67\x72\x6f\x75\x70\x73\x20\x54\x45\x58\x54\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x5b\x5d\x27\x3b\x03\x00\x73\xd5\xd2\x17\x4f\x00\x00\x00")
 
 func _002_add_allowed_user_groupsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -146,11 +152,131 @@ func _002_add_allowed_user_groupsUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_add_allowed_user_groups.up.sql", size: 79, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_add_allowed_user_groups.up.sql", size: 79, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x36, 0xf, 0x87, 0xdb, 0x75, 0x1c, 0x5, 0x91, 0x4c, 0xe5, 0x53, 0x89, 0xa1, 0xeb, 0xa3, 0xb9, 0x6f, 0x69, 0x2d, 0x4f, 0xf7, 0x1b, 0xc1, 0x5a, 0x68, 0x2, 0xd3, 0x40, 0x22, 0xf3, 0x37, 0x58}}
 	return a, nil
 }
 
+var __003_add_require_approvalDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3a\x00\xc5\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x22\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x22\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x72\x65\x71\x75\x69\x72\x65\x5f\x61\x70\x70\x72\x6f\x76\x61\x6c\x3b\x0a\x03\x00\x8b\xd2\x30\xc4\x3a\x00\x00\x00")
+
+func _003_add_require_approvalDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_add_require_approvalDownSql,
+		"003_add_require_approval.down.sql",
+	)
+}
+
+func _003_add_require_approvalDownSql() (*asset, error) {
+	bytes, err := _003_add_require_approvalDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_add_require_approval.down.sql", size: 58, mode: os.FileMode(0644), modTime: time.Unix(1792197144, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8e, 0xb7, 0x71, 0xc5, 0x22, 0xe6, 0x45, 0x62, 0xe7, 0xd0, 0x17, 0x6c, 0x35, 0xe8, 0x8b, 0x1, 0x6, 0x9c, 0x66, 0x35, 0xca, 0x1d, 0x6e, 0x57, 0x1c, 0xe9, 0xb4, 0xe9, 0x11, 0xa3, 0xa1, 0x3a}}
+	return a, nil
+}
+
+var __003_add_require_approvalUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4d\x00\xb2\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x22\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x22\x20\x41\x44\x44\x20\x72\x65\x71\x75\x69\x72\x65\x5f\x61\x70\x70\x72\x6f\x76\x61\x6c\x20\x42\x4f\x4f\x4c\x45\x41\x4e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x30\x3b\x0a\x03\x00\xe5\x6a\xf8\x11\x4d\x00\x00\x00")
+
+func _003_add_require_approvalUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_add_require_approvalUpSql,
+		"003_add_require_approval.up.sql",
+	)
+}
+
+func _003_add_require_approvalUpSql() (*asset, error) {
+	bytes, err := _003_add_require_approvalUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_add_require_approval.up.sql", size: 77, mode: os.FileMode(0644), modTime: time.Unix(1792197144, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6e, 0x8a, 0xa7, 0x33, 0x1d, 0x1b, 0xb8, 0x84, 0x7f, 0xab, 0x91, 0xcc, 0x2b, 0x45, 0xb9, 0x79, 0xd8, 0x3c, 0x91, 0x6e, 0xc5, 0xd6, 0xbb, 0x91, 0x46, 0x90, 0xaf, 0x42, 0xef, 0x17, 0x5e, 0x6c}}
+	return a, nil
+}
+
+var __004_rolesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x12\x00\xed\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x72\x6f\x6c\x65\x73\x3b\x0a\x03\x00\xf6\xfd\xe7\xf6\x12\x00\x00\x00")
+
+func _004_rolesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_rolesDownSql,
+		"004_roles.down.sql",
+	)
+}
+
+func _004_rolesDownSql() (*asset, error) {
+	bytes, err := _004_rolesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_roles.down.sql", size: 18, mode: os.FileMode(0644), modTime: time.Unix(1792200486, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc7, 0xde, 0x29, 0x40, 0x14, 0xf7, 0x7, 0x7b, 0x9d, 0xc4, 0xe3, 0x1f, 0xa7, 0x9c, 0x7b, 0x33, 0x1d, 0x7d, 0xf7, 0x7c, 0xf2, 0x5a, 0xbc, 0x24, 0xff, 0xbd, 0xa8, 0xce, 0x9e, 0xb8, 0xef, 0xbd}}
+	return a, nil
+}
+
+var __004_rolesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\xca\x31\x0a\xc2\x30\x14\x06\xe0\xbd\xa7\xf8\xb7\x2a\x78\x03\xa7\x68\x9f\x58\x8c\xad\x84\x17\x6a\x11\x71\x68\x83\x04\x8a\x09\x89\x99\xc4\xbb\x0b\xb6\x8e\x75\xfe\xbe\xad\x22\xc1\x04\x16\x1b\x49\x08\x6e\x30\x11\x8b\x0c\x00\x6c\x0f\xa6\x33\xe3\xa4\xca\xa3\x50\x2d\x0e\xd4\xa2\xaa\x19\x95\x96\x72\xf5\x1d\xbd\x89\x5d\xb0\xfe\x69\xdd\x63\xac\x3f\x46\x41\x3b\xa1\x25\x23\xcf\xc7\x99\xa2\x09\xb7\x7b\x70\xc9\xc7\xb9\x79\xb9\x4e\x37\xa4\xc1\xcc\xae\xd7\x7b\x5a\xb1\x73\xde\xfc\x59\xd9\x12\x4d\xc9\xfb\x5a\x33\x54\xdd\x94\xc5\x3a\xfb\x0c\x00\xf5\xf9\x00\xd7\xe9\x00\x00\x00")
+
+func _004_rolesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_rolesUpSql,
+		"004_roles.up.sql",
+	)
+}
+
+func _004_rolesUpSql() (*asset, error) {
+	bytes, err := _004_rolesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_roles.up.sql", size: 233, mode: os.FileMode(0644), modTime: time.Unix(1792200486, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x5c, 0x9, 0xa4, 0xa1, 0xba, 0xbc, 0xe7, 0xe, 0x54, 0x8b, 0xd9, 0xbd, 0xee, 0x59, 0x20, 0xd3, 0x9f, 0xeb, 0x5f, 0xb2, 0x67, 0x8, 0x3e, 0x87, 0x21, 0xfa, 0xf6, 0xbf, 0xd6, 0x3d, 0x9f, 0xf1}}
+	return a, nil
+}
+
+var __005_add_bandwidth_limitsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7a\x00\x85\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x22\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x22\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x62\x61\x6e\x64\x77\x69\x64\x74\x68\x5f\x6c\x69\x6d\x69\x74\x5f\x64\x6f\x77\x6e\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x22\x63\x6c\x69\x65\x6e\x74\x5f\x67\x72\x6f\x75\x70\x73\x22\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x62\x61\x6e\x64\x77\x69\x64\x74\x68\x5f\x6c\x69\x6d\x69\x74\x5f\x75\x70\x3b\x0a\x03\x00\x86\x1b\xeb\xe7\x7a\x00\x00\x00")
+
+func _005_add_bandwidth_limitsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_add_bandwidth_limitsDownSql,
+		"005_add_bandwidth_limits.down.sql",
+	)
+}
+
+func _005_add_bandwidth_limitsDownSql() (*asset, error) {
+	bytes, err := _005_add_bandwidth_limitsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_add_bandwidth_limits.down.sql", size: 122, mode: os.FileMode(0644), modTime: time.Unix(1792205406, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x0, 0x80, 0x3, 0xc5, 0x28, 0xc4, 0x3, 0x4d, 0xf4, 0x22, 0x98, 0xa9, 0xa5, 0xbc, 0xec, 0x28, 0x8f, 0xc6, 0xff, 0x24, 0x11, 0xea, 0x8a, 0xe3, 0xb8, 0xfd, 0x9a, 0x7c, 0x88, 0xe4, 0x20, 0xab}}
+	return a, nil
+}
+
+var __005_add_bandwidth_limitsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\x52\x70\x74\x71\x51\x48\x4a\xcc\x4b\x29\xcf\x4c\x29\xc9\x88\xcf\xc9\xcc\xcd\x2c\x89\x2f\x2d\x50\xf0\xf4\x0b\x71\x75\x77\x0d\x52\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x30\xb0\xe6\x22\xd5\xb8\x94\xfc\xf2\x3c\xbc\x06\x02\x06\x00\x19\xc8\x6b\x47\xa0\x00\x00\x00")
+
+func _005_add_bandwidth_limitsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_add_bandwidth_limitsUpSql,
+		"005_add_bandwidth_limits.up.sql",
+	)
+}
+
+func _005_add_bandwidth_limitsUpSql() (*asset, error) {
+	bytes, err := _005_add_bandwidth_limitsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_add_bandwidth_limits.up.sql", size: 160, mode: os.FileMode(0644), modTime: time.Unix(1792205406, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc4, 0x27, 0x66, 0xcf, 0xd3, 0x70, 0x58, 0x84, 0xb0, 0xf3, 0xa3, 0x5c, 0x1b, 0x76, 0x84, 0x61, 0xad, 0x3c, 0x1d, 0x26, 0x49, 0xa5, 0xbe, 0xa0, 0x1f, 0xf4, 0x6b, 0x9f, 0xf5, 0xa0, 0xcf, 0xdd}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -246,6 +372,12 @@ var _bindata = map[string]func() (*asset, error){
 	"001_init.up.sql":                      _001_initUpSql,
 	"002_add_allowed_user_groups.down.sql": _002_add_allowed_user_groupsDownSql,
 	"002_add_allowed_user_groups.up.sql":   _002_add_allowed_user_groupsUpSql,
+	"003_add_require_approval.down.sql":    _003_add_require_approvalDownSql,
+	"003_add_require_approval.up.sql":      _003_add_require_approvalUpSql,
+	"004_roles.down.sql":                   _004_rolesDownSql,
+	"004_roles.up.sql":                     _004_rolesUpSql,
+	"005_add_bandwidth_limits.down.sql":    _005_add_bandwidth_limitsDownSql,
+	"005_add_bandwidth_limits.up.sql":      _005_add_bandwidth_limitsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -298,6 +430,12 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"001_init.up.sql":                      {_001_initUpSql, map[string]*bintree{}},
 	"002_add_allowed_user_groups.down.sql": {_002_add_allowed_user_groupsDownSql, map[string]*bintree{}},
 	"002_add_allowed_user_groups.up.sql":   {_002_add_allowed_user_groupsUpSql, map[string]*bintree{}},
+	"003_add_require_approval.down.sql":    {_003_add_require_approvalDownSql, map[string]*bintree{}},
+	"003_add_require_approval.up.sql":      {_003_add_require_approvalUpSql, map[string]*bintree{}},
+	"004_roles.down.sql":                   {_004_rolesDownSql, map[string]*bintree{}},
+	"004_roles.up.sql":                     {_004_rolesUpSql, map[string]*bintree{}},
+	"005_add_bandwidth_limits.down.sql":    {_005_add_bandwidth_limitsDownSql, map[string]*bintree{}},
+	"005_add_bandwidth_limits.up.sql":      {_005_add_bandwidth_limitsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1 @@
+ALTER TABLE "client_groups" DROP COLUMN require_approval;
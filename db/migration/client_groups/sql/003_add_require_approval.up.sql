@@ -0,0 +1 @@
+ALTER TABLE "client_groups" ADD require_approval BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+CREATE TABLE roles (
+    id TEXT PRIMARY KEY NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_groups TEXT NOT NULL DEFAULT '[]',
+    rules TEXT NOT NULL DEFAULT '{}',
+    scope TEXT NOT NULL DEFAULT '{}'
+) WITHOUT ROWID;
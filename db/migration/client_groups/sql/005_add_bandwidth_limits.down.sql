@@ -0,0 +1,2 @@
+ALTER TABLE "client_groups" DROP COLUMN bandwidth_limit_down;
+ALTER TABLE "client_groups" DROP COLUMN bandwidth_limit_up;
@@ -0,0 +1,2 @@
+ALTER TABLE "client_groups" ADD bandwidth_limit_up INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "client_groups" ADD bandwidth_limit_down INTEGER NOT NULL DEFAULT 0;
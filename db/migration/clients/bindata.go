@@ -6,6 +6,14 @@
 // 002_stored_tunnels.up.sql (251B)
 // 003_add_tunnel_fields.down.sql (0)
 // 003_add_tunnel_fields.up.sql (104B)
+// 004_stored_tunnels_auto_start.down.sql (377B)
+// 004_stored_tunnels_auto_start.up.sql (422B)
+// 005_enrollment.down.sql (54B)
+// 005_enrollment.up.sql (785B)
+// 006_client_auth_rotation.down.sql (34B)
+// 006_client_auth_rotation.up.sql (435B)
+// 007_stored_tunnels_created_by.down.sql (51B)
+// 007_stored_tunnels_created_by.up.sql (68B)
 
 package clients
 
@@ -73,7 +81,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xc8\x4c\xa9\x88\x4f\xc9\x2c\x4e\xce\xcf\xcb\x4b\x4d\x2e\x49\x4d\x89\x2f\xc9\xcc\x4d\x8d\x4f\xce\xc9\x4c\xcd\x2b\xb1\xe6\xe2\xc2\xa7\x12\x55\x51\x88\xa3\x93\x8f\xab\x02\x44\xac\xd8\x9a\x0b\x10\x00\x00\xff\xff\x49\xd7\x0b\xc9\x63\x00\x00\x00")
+var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x63\x00\x9c\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x64\x69\x73\x63\x6f\x6e\x6e\x65\x63\x74\x65\x64\x5f\x74\x69\x6d\x65\x5f\x63\x6c\x69\x65\x6e\x74\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x64\x69\x73\x63\x6f\x6e\x6e\x65\x63\x74\x65\x64\x5f\x63\x6c\x69\x65\x6e\x74\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6c\x69\x65\x6e\x74\x73\x3b\x0a\x03\x00\x49\xd7\x0b\xc9\x63\x00\x00\x00")
 
 func _001_initDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -88,12 +96,12 @@ func _001_initDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.down.sql", size: 99, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.down.sql", size: 99, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xcd, 0xac, 0x7d, 0x7a, 0x69, 0xc0, 0x2e, 0x3, 0xab, 0xa5, 0x5e, 0xdd, 0x7f, 0xe1, 0xa5, 0x36, 0xba, 0x42, 0x8a, 0xc2, 0x59, 0x2b, 0x3b, 0xc5, 0xdf, 0xad, 0x4e, 0x98, 0x11, 0xc7, 0x83, 0x8c}}
 	return a, nil
 }
 
-var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x8f\x41\x6a\x85\x30\x14\x45\xc7\x66\x15\x77\x58\xc1\x1d\x74\x94\xea\x83\x86\x6a\x52\xd2\x27\xea\x28\x88\x09\x34\x60\xed\xc0\x14\xba\xfc\xd2\x5a\xb1\xf6\xf3\xf9\xe3\xdc\x9c\x73\x5e\x69\x49\x32\x81\xe5\x43\x4d\x98\xe6\x18\x96\xb4\xe2\x4e\x00\x40\xf4\x60\xea\x19\xcf\x56\x35\xd2\x0e\x78\xa2\x01\xda\x30\x74\x5b\xd7\x85\xc8\xb6\xb1\x1b\x3f\xd2\xab\xdb\xa7\xc7\xf3\x37\xc0\xc7\x75\x7a\x5f\x96\x30\xa5\xe0\xdd\x98\x50\x49\x26\x56\x0d\x15\x22\xf3\x21\x8d\x71\x5e\xcf\xbf\x44\x8e\x4e\xf1\xa3\x69\x19\xd6\x74\xaa\xba\x17\xe2\x37\x4f\xe9\x8a\x7a\x44\xff\xe9\x4e\xcc\x2d\xe1\xc7\x65\xf4\x51\x7f\xe1\xa5\x97\xb2\xc0\xb9\x37\xbf\x09\x4f\xf1\x2d\xec\x86\xec\x2f\x7e\x3f\xe3\xbf\x27\xbf\x26\xfa\x0a\x00\x00\xff\xff\x7e\x6a\x27\xc9\x64\x01\x00\x00")
+var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x8f\x41\x6a\x85\x30\x14\x45\xc7\x66\x15\x77\x58\xc1\x1d\x74\x94\xea\x83\x86\x6a\x52\xd2\x27\xea\x28\x88\x09\x34\x60\xed\xc0\x14\xba\xfc\xd2\x5a\xb1\xf6\xf3\xf9\xe3\xdc\x9c\x73\x5e\x69\x49\x32\x81\xe5\x43\x4d\x98\xe6\x18\x96\xb4\xe2\x4e\x00\x40\xf4\x60\xea\x19\xcf\x56\x35\xd2\x0e\x78\xa2\x01\xda\x30\x74\x5b\xd7\x85\xc8\xb6\xb1\x1b\x3f\xd2\xab\xdb\xa7\xc7\xf3\x37\xc0\xc7\x75\x7a\x5f\x96\x30\xa5\xe0\xdd\x98\x50\x49\x26\x56\x0d\x15\x22\xf3\x21\x8d\x71\x5e\xcf\xbf\x44\x8e\x4e\xf1\xa3\x69\x19\xd6\x74\xaa\xba\x17\xe2\x37\x4f\xe9\x8a\x7a\x44\xff\xe9\x4e\xcc\x2d\xe1\x27\xd6\xe8\xa3\xfe\xc2\x4b\x2f\x65\x81\x73\x6f\x7e\x13\x9e\xe2\x5b\xd8\x0d\xd9\x5f\xfc\x7e\xc6\x7f\x4f\x7e\x4d\xf4\x35\x00\x7e\x6a\x27\xc9\x64\x01\x00\x00")
 
 func _001_initUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -108,12 +116,12 @@ func _001_initUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.up.sql", size: 356, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.up.sql", size: 356, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc3, 0x2b, 0xa4, 0xcd, 0x5a, 0x2b, 0x3, 0x1a, 0x8b, 0x97, 0x4d, 0xb1, 0x36, 0xc6, 0x80, 0x38, 0x46, 0x9d, 0x65, 0x4e, 0x84, 0xf1, 0x50, 0x2d, 0x65, 0xca, 0xdc, 0x5a, 0xb7, 0xef, 0x7d, 0x1b}}
 	return a, nil
 }
 
-var __002_stored_tunnelsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x28\x2e\xc9\x2f\x4a\x4d\x89\x2f\x29\xcd\xcb\x4b\xcd\x29\xb6\xe6\x02\x04\x00\x00\xff\xff\x25\xf8\x88\xbe\x1b\x00\x00\x00")
+var __002_stored_tunnelsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1b\x00\xe4\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x6f\x72\x65\x64\x5f\x74\x75\x6e\x6e\x65\x6c\x73\x3b\x0a\x03\x00\x25\xf8\x88\xbe\x1b\x00\x00\x00")
 
 func _002_stored_tunnelsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -128,12 +136,12 @@ func _002_stored_tunnelsDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_stored_tunnels.down.sql", size: 27, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_stored_tunnels.down.sql", size: 27, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x9b, 0x91, 0x9f, 0x67, 0xc1, 0xc6, 0x9c, 0x33, 0x35, 0xc4, 0xe8, 0xaa, 0xc8, 0x4f, 0x65, 0xdc, 0x44, 0xbc, 0xa7, 0xf8, 0x25, 0x26, 0x9a, 0x21, 0xb9, 0x30, 0xc9, 0x6a, 0x11, 0x5a, 0x9d, 0xc2}}
 	return a, nil
 }
 
-var __002_stored_tunnelsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8c\x4b\x6a\xc3\x30\x14\x45\xe7\x5e\xc5\x1d\x26\xd0\x1d\x74\xa4\xc8\xaf\x10\x2a\x2b\x45\x79\x81\x66\x24\x84\xf5\xa0\x02\x47\x0e\x92\xba\xff\x82\x3f\x83\x92\xe1\x3d\xf7\x70\xb4\x23\xc5\x04\x56\x27\x43\xa8\x6d\x2e\x12\x7d\xfb\xcd\x59\xa6\x8a\x43\x07\x00\x29\x82\xe9\x9b\xf1\xe5\xce\x83\x72\x77\x7c\xd2\x1d\xf6\xc2\xb0\x37\x63\xde\x16\x63\x9c\x92\xe4\xe6\x77\x71\x3f\xe1\xe8\x83\x1c\x59\x4d\xd7\x4d\xa9\x87\x14\x8f\xb8\x58\xf4\x64\x88\x09\x5a\x5d\xb5\xea\x69\xab\x14\x09\x4d\xa2\x0f\x0d\xbd\x62\xe2\xf3\xb0\x1d\x39\x3c\x64\x29\xaf\xb3\x8e\x3f\xf2\x0f\x14\x79\xcc\x4d\x7c\x7a\xbe\xb2\xe7\x5c\x1a\xec\x6d\x38\x91\x5b\x79\x18\xa7\xc5\xea\x8e\xef\xdd\x5f\x00\x00\x00\xff\xff\x04\x1b\x73\xc3\xfb\x00\x00\x00")
+var __002_stored_tunnelsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8c\x41\x6a\xc3\x30\x10\x45\xf7\x3e\xc5\x5f\x26\xd0\x1b\x74\xa5\xd8\xbf\x10\x6a\x2b\x45\x99\x40\xb2\x32\xc2\x1a\xa8\xc0\x91\x83\xa4\xde\xbf\x90\xd8\x8b\xd2\xe5\xfc\xf7\xe6\xb5\x8e\x46\x08\x31\x87\x9e\x28\x75\xc9\x1a\xc6\xfa\x93\x92\xce\x05\xbb\x06\x00\x62\x80\xf0\x2a\xf8\x72\xc7\xc1\xb8\x1b\x3e\x79\x83\x3d\x09\xec\xa5\xef\xdf\x9e\xc6\x34\x47\x4d\x75\xdc\xc4\x0d\xc2\xf1\x83\x8e\xb6\xe5\x79\x55\xca\x2e\x86\x3d\x4e\x16\x1d\x7b\x0a\xd1\x9a\x73\x6b\x3a\xae\x95\xac\xbe\x6a\x18\x7d\x45\x67\x84\x72\x1c\x56\x90\xfc\x5d\x9f\xe5\xd7\x59\xa6\x6f\xfd\x33\x64\xbd\x2f\x55\xc7\xf8\xf8\xbf\x3d\x96\x5c\x61\x2f\xc3\x81\xee\xf5\xec\xa7\x19\xc2\xab\x34\xfb\xf7\xe6\x77\x00\x04\x1b\x73\xc3\xfb\x00\x00\x00")
 
 func _002_stored_tunnelsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -148,12 +156,12 @@ func _002_stored_tunnelsUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_stored_tunnels.up.sql", size: 251, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_stored_tunnels.up.sql", size: 251, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x28, 0xac, 0x2a, 0x25, 0x9b, 0xf5, 0xe, 0xdd, 0xa3, 0x6b, 0x3c, 0x5c, 0xf, 0x55, 0x6c, 0x1d, 0x6f, 0x71, 0x6, 0x96, 0xa8, 0x52, 0x61, 0x14, 0x8c, 0xf8, 0xbd, 0x3f, 0x22, 0x40, 0x7, 0x11}}
 	return a, nil
 }
 
-var __003_add_tunnel_fieldsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var __003_add_tunnel_fieldsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func _003_add_tunnel_fieldsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -168,12 +176,12 @@ func _003_add_tunnel_fieldsDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "003_add_tunnel_fields.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "003_add_tunnel_fields.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
 	return a, nil
 }
 
-var __003_add_tunnel_fieldsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2e\xc9\x2f\x4a\x4d\x89\x2f\x29\xcd\xcb\x4b\xcd\x29\x56\x70\x74\x71\x51\x28\x28\x4d\xca\xc9\x4c\x8e\x2f\xc8\x2f\x2a\x51\xf0\x0b\xf5\x75\x72\x0d\xb2\xe6\x22\xa0\x25\xad\xb4\xa8\x24\x23\xb5\x28\x3e\xbf\xa0\x24\x33\x3f\xaf\x58\x21\xc4\x35\x22\xc4\x9a\x0b\x10\x00\x00\xff\xff\x0a\x14\xe8\x34\x68\x00\x00\x00")
+var __003_add_tunnel_fieldsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x68\x00\x97\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x6f\x72\x65\x64\x5f\x74\x75\x6e\x6e\x65\x6c\x73\x20\x41\x44\x44\x20\x70\x75\x62\x6c\x69\x63\x5f\x70\x6f\x72\x74\x20\x4e\x55\x4d\x42\x45\x52\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x6f\x72\x65\x64\x5f\x74\x75\x6e\x6e\x65\x6c\x73\x20\x41\x44\x44\x20\x66\x75\x72\x74\x68\x65\x72\x5f\x6f\x70\x74\x69\x6f\x6e\x73\x20\x54\x45\x58\x54\x3b\x0a\x03\x00\x0a\x14\xe8\x34\x68\x00\x00\x00")
 
 func _003_add_tunnel_fieldsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -188,11 +196,171 @@ func _003_add_tunnel_fieldsUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "003_add_tunnel_fields.up.sql", size: 104, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "003_add_tunnel_fields.up.sql", size: 104, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc8, 0xb4, 0x56, 0x2e, 0x4f, 0x67, 0x61, 0xf6, 0x5e, 0xa9, 0xbe, 0x37, 0xcd, 0xfb, 0x4, 0x18, 0xa, 0xd8, 0xd5, 0xac, 0x95, 0x52, 0x7c, 0x6c, 0xfc, 0x48, 0xbe, 0x4c, 0xb0, 0x70, 0x1e, 0xbe}}
 	return a, nil
 }
 
+var __004_stored_tunnels_auto_startDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xcd\x41\x0e\x83\x20\x10\x46\xe1\xbd\xa7\xe0\x1e\xae\x6c\xeb\x8e\xd6\xc6\xd8\xf5\x64\x02\x93\x4a\x82\x90\x30\xff\xdc\xdf\x2b\xc0\xfe\xbd\x7c\x8b\x3f\xd6\xdd\x1d\xcb\xc3\xaf\x4e\x51\x9b\x44\x82\x95\x22\x59\xdd\x6b\xdf\xbe\xee\xb9\xf9\xdf\xfb\xe3\xd8\x50\x49\xc1\x0d\xf3\x34\xbc\x90\x86\x53\xa2\x65\xe9\x7e\x53\xcc\x42\x48\x97\x54\x03\x5d\xa9\x18\x44\xc7\xe0\x90\xab\xf6\x7b\x0a\x46\x7f\x9d\x59\x41\xd2\x5a\x6d\x63\x00\x85\x93\xcb\x5f\x22\x31\xe6\xe9\x1e\x00\x78\xbb\xce\xbd\x79\x01\x00\x00")
+
+func _004_stored_tunnels_auto_startDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_stored_tunnels_auto_startDownSql,
+		"004_stored_tunnels_auto_start.down.sql",
+	)
+}
+
+func _004_stored_tunnels_auto_startDownSql() (*asset, error) {
+	bytes, err := _004_stored_tunnels_auto_startDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_stored_tunnels_auto_start.down.sql", size: 377, mode: os.FileMode(0644), modTime: time.Unix(1792196628, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xfa, 0xa4, 0x85, 0x42, 0xe9, 0x47, 0x55, 0x4, 0x96, 0xcc, 0xf, 0x80, 0x8d, 0x40, 0xd9, 0xb4, 0xaf, 0xe7, 0x9, 0xf5, 0xe5, 0x19, 0xcd, 0xf, 0x38, 0x7f, 0x59, 0xa9, 0xae, 0x70, 0x7, 0xc3}}
+	return a, nil
+}
+
+var __004_stored_tunnels_auto_startUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xcf\x41\xaa\x84\x30\x0c\xc6\xf1\xbd\xa7\xc8\xce\x43\xb8\xaa\xb4\x0f\x1e\x54\x07\x24\xc2\xec\x42\xb1\x61\x14\x6a\x0b\x4d\x7a\xff\x01\x2f\x30\xe2\x01\xf2\xfb\xe7\x33\x1e\xdd\x02\x68\x46\xef\x40\xb4\x54\x8e\xa4\x2d\x67\x4e\x02\xc6\x5a\x08\x4d\x0b\x89\x86\xaa\x80\xee\x8d\x30\xbf\x10\xe6\xd5\x7b\xb0\xee\xcf\xac\x1e\xa1\xef\x87\xee\xb6\x41\xb2\xed\x1c\x5b\xe2\x0b\xfb\x79\x78\xc4\xc4\xa4\xc7\xc9\xa5\x29\x9d\x47\x6e\xca\x02\xf3\x3a\x8d\x6e\xb9\x17\xdd\x52\x91\x9b\x2d\xd1\xa0\xfc\x7c\x63\x0a\xa2\xc4\xb5\x96\xfa\xdc\xb8\x5e\xa0\x6d\x0f\xf9\xc3\x91\x82\x82\x35\xe8\xf0\x7f\x72\x43\xf7\x1d\x00\x01\xf0\xa8\xbd\xa6\x01\x00\x00")
+
+func _004_stored_tunnels_auto_startUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_stored_tunnels_auto_startUpSql,
+		"004_stored_tunnels_auto_start.up.sql",
+	)
+}
+
+func _004_stored_tunnels_auto_startUpSql() (*asset, error) {
+	bytes, err := _004_stored_tunnels_auto_startUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_stored_tunnels_auto_start.up.sql", size: 422, mode: os.FileMode(0644), modTime: time.Unix(1792196628, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb, 0x59, 0x42, 0x44, 0x2b, 0x8c, 0x3a, 0xc9, 0x65, 0x48, 0x56, 0x36, 0xe5, 0x6d, 0x74, 0xba, 0xa, 0xe6, 0x8, 0x7d, 0xac, 0x55, 0x26, 0x5f, 0xcb, 0xcb, 0x17, 0xfe, 0x58, 0x6e, 0xeb, 0x4e}}
+	return a, nil
+}
+
+var __005_enrollmentDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x36\x00\xc9\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x65\x6e\x72\x6f\x6c\x6c\x6d\x65\x6e\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x65\x6e\x72\x6f\x6c\x6c\x6d\x65\x6e\x74\x5f\x74\x6f\x6b\x65\x6e\x73\x3b\x0a\x03\x00\x1b\x1d\x03\x61\x36\x00\x00\x00")
+
+func _005_enrollmentDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_enrollmentDownSql,
+		"005_enrollment.down.sql",
+	)
+}
+
+func _005_enrollmentDownSql() (*asset, error) {
+	bytes, err := _005_enrollmentDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_enrollment.down.sql", size: 54, mode: os.FileMode(0644), modTime: time.Unix(1792202246, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x47, 0xc6, 0x1f, 0xe3, 0xd, 0xf7, 0x95, 0xf4, 0xa8, 0xfa, 0xf9, 0xbe, 0xf2, 0xbe, 0xc8, 0xb9, 0xae, 0x24, 0x3e, 0x8a, 0x37, 0xfc, 0xe6, 0xa8, 0x9b, 0x92, 0x50, 0x26, 0xc, 0x41, 0xc9, 0x7f}}
+	return a, nil
+}
+
+var __005_enrollmentUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\xcd\x4e\xeb\x30\x10\x46\xf7\x79\x8a\xd9\xf5\x56\xea\xe2\xee\xbb\x72\xdb\x01\x45\xa4\x0e\x0a\xae\xd4\xae\x2c\x93\x0c\xaa\x45\xfe\xb0\x1d\x54\xde\x1e\x51\x97\xa8\x4e\x0a\x61\xeb\xef\xcc\x8c\x7d\xc6\xeb\x0c\x99\x40\x10\x6c\x95\x20\x50\x6d\x9a\xb2\xac\xa8\x76\xd2\x35\xaf\x54\x5b\xf8\x17\x01\x00\xe8\x02\x04\xee\x05\x3c\x66\xf1\x96\x65\x07\x78\xc0\x03\xf0\x54\x00\xdf\x25\xc9\xe2\x4c\x1c\x95\x3d\x7a\x26\x3c\x2f\xc8\xe6\x46\xb7\x4e\x37\x75\x18\xc3\x06\xef\xd8\x2e\x11\x30\x9b\x79\xb2\x52\x27\xd9\x59\xb2\x10\x73\x81\xf7\x98\x0d\x1a\xdd\x8c\xfa\x26\xff\x7d\x0f\x43\x6f\x9d\x36\x24\x55\xdb\x9a\xe6\x5d\x95\xb0\x4a\xd3\x04\x19\x1f\x17\xbc\xa8\xd2\x92\x2f\xa2\x53\xab\x0d\x59\xa9\x1c\x6c\x98\x40\x11\x6f\xb1\xe7\x3d\x91\x1b\x52\x8e\x8a\x3f\x10\xcf\x1f\xe1\x2b\xa3\xf9\x32\x8a\x7e\x50\xfc\x2d\x37\x2f\xf5\x97\x70\xd5\xb9\xa3\x9c\x16\x7d\xde\x4b\xcf\x85\x99\x75\xca\x75\xf6\x56\x72\x99\x31\x2c\x1b\x2d\xe1\xc2\xd5\xaa\xa2\x09\xd2\x50\xd5\x38\x92\xba\x9d\xe0\xfc\x97\xfa\x55\x5e\x41\xb9\x2e\x86\xc4\x28\xed\xd5\x0e\xb4\xc6\x7c\x83\xfb\x6b\xad\xd2\x6b\x90\xd7\x93\x53\x1e\x8a\xf7\xc8\x22\xbc\x1d\x3e\xad\xe7\xcb\xe8\x73\x00\xe2\xc5\xf1\xb6\x11\x03\x00\x00")
+
+func _005_enrollmentUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_enrollmentUpSql,
+		"005_enrollment.up.sql",
+	)
+}
+
+func _005_enrollmentUpSql() (*asset, error) {
+	bytes, err := _005_enrollmentUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_enrollment.up.sql", size: 785, mode: os.FileMode(0644), modTime: time.Unix(1792202246, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf3, 0x10, 0x2, 0xf6, 0x9, 0x6c, 0xc8, 0x5d, 0xa5, 0x4d, 0xed, 0x81, 0xaf, 0xe4, 0x6d, 0xf0, 0xd4, 0xab, 0xfa, 0x40, 0x5, 0x38, 0xbd, 0x48, 0x23, 0x60, 0x8d, 0xf8, 0x1f, 0x59, 0x6c, 0x39}}
+	return a, nil
+}
+
+var __006_client_auth_rotationDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x22\x00\xdd\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6c\x69\x65\x6e\x74\x5f\x61\x75\x74\x68\x5f\x72\x6f\x74\x61\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\xfc\x55\x11\xa1\x22\x00\x00\x00")
+
+func _006_client_auth_rotationDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_client_auth_rotationDownSql,
+		"006_client_auth_rotation.down.sql",
+	)
+}
+
+func _006_client_auth_rotationDownSql() (*asset, error) {
+	bytes, err := _006_client_auth_rotationDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_client_auth_rotation.down.sql", size: 34, mode: os.FileMode(0644), modTime: time.Unix(1792202771, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8a, 0x17, 0x7a, 0x40, 0x1a, 0x3f, 0x1b, 0xe6, 0x34, 0x51, 0x6a, 0xa9, 0x7f, 0x20, 0xb, 0xa3, 0x8, 0x5c, 0x95, 0x1a, 0xb1, 0xc2, 0xef, 0x2a, 0x2, 0x16, 0x39, 0xf0, 0xe5, 0x64, 0x66, 0xb2}}
+	return a, nil
+}
+
+var __006_client_auth_rotationUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xd0\x41\x6b\x02\x31\x10\x05\xe0\x7b\x7e\xc5\xdc\x54\xe8\xa1\x77\x4f\x69\x77\x5a\x96\xae\xb1\x2c\x23\xe8\x29\x0c\xee\xc0\x06\x42\x52\x92\xd1\xf6\xe7\x17\xba\x22\xb5\x4a\xf1\x9c\x2f\x79\x2f\xef\xb9\x47\x4b\x08\x64\x9f\x3a\x84\x7d\x0c\x92\xd4\xf3\x41\x47\x5f\xb2\xb2\x86\x9c\x2a\xcc\x0d\x00\x5c\x9c\x85\x01\x08\xb7\x04\xef\x7d\xbb\xb2\xfd\x0e\xde\x70\x07\x6e\x4d\xe0\x36\x5d\xf7\xf0\xa3\x43\x52\x29\x47\x8e\xbe\xca\x1e\x5a\x47\xf8\x8a\xfd\x99\x40\x83\x2f\x76\xd3\x11\x3c\x4e\x38\xc9\x97\x9e\xf3\x3c\x2b\x34\x96\x90\xda\x15\xfe\x7a\x30\x72\x3d\x19\x19\xfe\x23\x52\x4a\x2e\x53\xbb\xab\xb8\xd9\x6c\xca\xe3\xa8\x52\x12\xab\xf8\x0f\xae\xf5\x33\x97\xc1\x8f\x5c\xc7\xbb\x6f\x1d\x39\x86\xc1\x1f\x92\x86\x78\xd9\xc3\x2c\x96\xc6\x9c\x16\x6d\x5d\x83\xdb\xdb\x8b\xfa\xab\x0f\xaf\xdd\x6d\x09\xf3\xbf\x74\xb1\x34\xdf\x03\x00\xb2\x14\xe3\x6e\xb3\x01\x00\x00")
+
+func _006_client_auth_rotationUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_client_auth_rotationUpSql,
+		"006_client_auth_rotation.up.sql",
+	)
+}
+
+func _006_client_auth_rotationUpSql() (*asset, error) {
+	bytes, err := _006_client_auth_rotationUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_client_auth_rotation.up.sql", size: 435, mode: os.FileMode(0644), modTime: time.Unix(1792202771, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x27, 0x69, 0x32, 0x84, 0x89, 0x5e, 0x26, 0x7e, 0x8d, 0xff, 0xb6, 0xc3, 0xb9, 0x40, 0x9c, 0xe5, 0x55, 0x64, 0xd0, 0xb1, 0xad, 0x45, 0x50, 0xf0, 0x88, 0x2b, 0xb4, 0x65, 0x77, 0xe5, 0x17, 0xaa}}
+	return a, nil
+}
+
+var __007_stored_tunnels_created_byDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x33\x00\xcc\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x6f\x72\x65\x64\x5f\x74\x75\x6e\x6e\x65\x6c\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x72\x65\x61\x74\x65\x64\x5f\x62\x79\x3b\x0a\x03\x00\x0f\xf9\xd3\xf0\x33\x00\x00\x00")
+
+func _007_stored_tunnels_created_byDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__007_stored_tunnels_created_byDownSql,
+		"007_stored_tunnels_created_by.down.sql",
+	)
+}
+
+func _007_stored_tunnels_created_byDownSql() (*asset, error) {
+	bytes, err := _007_stored_tunnels_created_byDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "007_stored_tunnels_created_by.down.sql", size: 51, mode: os.FileMode(0644), modTime: time.Unix(1792208872, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc4, 0x86, 0x7f, 0x6f, 0x70, 0xdb, 0x90, 0x3, 0xf3, 0xd8, 0x5e, 0x42, 0x50, 0x29, 0xea, 0x17, 0xf6, 0xdc, 0x92, 0xaa, 0x4d, 0xcd, 0xbf, 0x67, 0xff, 0xa4, 0xa1, 0x18, 0x5d, 0x3b, 0xbb, 0x54}}
+	return a, nil
+}
+
+var __007_stored_tunnels_created_byUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x44\x00\xbb\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x6f\x72\x65\x64\x5f\x74\x75\x6e\x6e\x65\x6c\x73\x20\x41\x44\x44\x20\x63\x72\x65\x61\x74\x65\x64\x5f\x62\x79\x20\x54\x45\x58\x54\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x27\x3b\x0a\x03\x00\xbd\x44\x00\x2a\x44\x00\x00\x00")
+
+func _007_stored_tunnels_created_byUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__007_stored_tunnels_created_byUpSql,
+		"007_stored_tunnels_created_by.up.sql",
+	)
+}
+
+func _007_stored_tunnels_created_byUpSql() (*asset, error) {
+	bytes, err := _007_stored_tunnels_created_byUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "007_stored_tunnels_created_by.up.sql", size: 68, mode: os.FileMode(0644), modTime: time.Unix(1792208872, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x73, 0x8a, 0x6b, 0xb5, 0x87, 0x6, 0x4d, 0xe6, 0x20, 0x7c, 0xba, 0xd5, 0x85, 0xd8, 0xe, 0x65, 0x31, 0x30, 0x5d, 0x42, 0x3c, 0x4e, 0xed, 0x31, 0x3e, 0x4a, 0x56, 0xc1, 0x5e, 0x74, 0xb, 0xdd}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -284,12 +452,20 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql":              _001_initDownSql,
-	"001_init.up.sql":                _001_initUpSql,
-	"002_stored_tunnels.down.sql":    _002_stored_tunnelsDownSql,
-	"002_stored_tunnels.up.sql":      _002_stored_tunnelsUpSql,
-	"003_add_tunnel_fields.down.sql": _003_add_tunnel_fieldsDownSql,
-	"003_add_tunnel_fields.up.sql":   _003_add_tunnel_fieldsUpSql,
+	"001_init.down.sql":                      _001_initDownSql,
+	"001_init.up.sql":                        _001_initUpSql,
+	"002_stored_tunnels.down.sql":            _002_stored_tunnelsDownSql,
+	"002_stored_tunnels.up.sql":              _002_stored_tunnelsUpSql,
+	"003_add_tunnel_fields.down.sql":         _003_add_tunnel_fieldsDownSql,
+	"003_add_tunnel_fields.up.sql":           _003_add_tunnel_fieldsUpSql,
+	"004_stored_tunnels_auto_start.down.sql": _004_stored_tunnels_auto_startDownSql,
+	"004_stored_tunnels_auto_start.up.sql":   _004_stored_tunnels_auto_startUpSql,
+	"005_enrollment.down.sql":                _005_enrollmentDownSql,
+	"005_enrollment.up.sql":                  _005_enrollmentUpSql,
+	"006_client_auth_rotation.down.sql":      _006_client_auth_rotationDownSql,
+	"006_client_auth_rotation.up.sql":        _006_client_auth_rotationUpSql,
+	"007_stored_tunnels_created_by.down.sql": _007_stored_tunnels_created_byDownSql,
+	"007_stored_tunnels_created_by.up.sql":   _007_stored_tunnels_created_byUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -338,12 +514,20 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql":              {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":                {_001_initUpSql, map[string]*bintree{}},
-	"002_stored_tunnels.down.sql":    {_002_stored_tunnelsDownSql, map[string]*bintree{}},
-	"002_stored_tunnels.up.sql":      {_002_stored_tunnelsUpSql, map[string]*bintree{}},
-	"003_add_tunnel_fields.down.sql": {_003_add_tunnel_fieldsDownSql, map[string]*bintree{}},
-	"003_add_tunnel_fields.up.sql":   {_003_add_tunnel_fieldsUpSql, map[string]*bintree{}},
+	"001_init.down.sql":                      {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":                        {_001_initUpSql, map[string]*bintree{}},
+	"002_stored_tunnels.down.sql":            {_002_stored_tunnelsDownSql, map[string]*bintree{}},
+	"002_stored_tunnels.up.sql":              {_002_stored_tunnelsUpSql, map[string]*bintree{}},
+	"003_add_tunnel_fields.down.sql":         {_003_add_tunnel_fieldsDownSql, map[string]*bintree{}},
+	"003_add_tunnel_fields.up.sql":           {_003_add_tunnel_fieldsUpSql, map[string]*bintree{}},
+	"004_stored_tunnels_auto_start.down.sql": {_004_stored_tunnels_auto_startDownSql, map[string]*bintree{}},
+	"004_stored_tunnels_auto_start.up.sql":   {_004_stored_tunnels_auto_startUpSql, map[string]*bintree{}},
+	"005_enrollment.down.sql":                {_005_enrollmentDownSql, map[string]*bintree{}},
+	"005_enrollment.up.sql":                  {_005_enrollmentUpSql, map[string]*bintree{}},
+	"006_client_auth_rotation.down.sql":      {_006_client_auth_rotationDownSql, map[string]*bintree{}},
+	"006_client_auth_rotation.up.sql":        {_006_client_auth_rotationUpSql, map[string]*bintree{}},
+	"007_stored_tunnels_created_by.down.sql": {_007_stored_tunnels_created_byDownSql, map[string]*bintree{}},
+	"007_stored_tunnels_created_by.up.sql":   {_007_stored_tunnels_created_byUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,7 @@
+ALTER TABLE stored_tunnels DROP COLUMN auto_start;
+ALTER TABLE stored_tunnels DROP COLUMN auto_start_schedule;
+ALTER TABLE stored_tunnels DROP COLUMN idle_timeout_minutes;
+ALTER TABLE stored_tunnels DROP COLUMN auto_close;
+ALTER TABLE stored_tunnels DROP COLUMN state;
+ALTER TABLE stored_tunnels DROP COLUMN last_error;
+ALTER TABLE stored_tunnels DROP COLUMN state_changed_at;
@@ -0,0 +1,7 @@
+ALTER TABLE stored_tunnels ADD auto_start TEXT NOT NULL DEFAULT '';
+ALTER TABLE stored_tunnels ADD auto_start_schedule TEXT;
+ALTER TABLE stored_tunnels ADD idle_timeout_minutes NUMBER;
+ALTER TABLE stored_tunnels ADD auto_close TEXT;
+ALTER TABLE stored_tunnels ADD state TEXT NOT NULL DEFAULT '';
+ALTER TABLE stored_tunnels ADD last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE stored_tunnels ADD state_changed_at DATETIME;
@@ -0,0 +1,2 @@
+DROP TABLE enrollments;
+DROP TABLE enrollment_tokens;
@@ -0,0 +1,25 @@
+CREATE TABLE enrollment_tokens (
+    id TEXT PRIMARY KEY NOT NULL,
+    hash TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    max_uses INTEGER NOT NULL,
+    uses INTEGER NOT NULL DEFAULT 0,
+    require_approval BOOLEAN NOT NULL DEFAULT false,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    created_by TEXT NOT NULL
+);
+
+CREATE TABLE enrollments (
+    client_auth_id TEXT PRIMARY KEY NOT NULL,
+    token_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    client_id TEXT NOT NULL DEFAULT '',
+    client_name TEXT NOT NULL DEFAULT '',
+    remote_ip TEXT NOT NULL DEFAULT '',
+    enrolled_at DATETIME NOT NULL,
+    decided_at DATETIME NULL,
+    decided_by TEXT NULL
+);
+
+CREATE INDEX enrollments_status_enrolled_at ON enrollments (status, enrolled_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE stored_tunnels ADD created_by TEXT NOT NULL DEFAULT '';
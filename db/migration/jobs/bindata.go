@@ -6,6 +6,8 @@
 // 002_schedules.up.sql (228B)
 // 003_multi_job_schedule_id.down.sql (0)
 // 003_multi_job_schedule_id.up.sql (50B)
+// 004_approvals.down.sql (22B)
+// 004_approvals.up.sql (495B)
 
 package jobs
 
@@ -73,7 +75,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xc8\x4c\xa9\x88\xcf\xca\x4f\x2a\x8e\x4f\xce\xc9\x4c\xcd\x2b\x89\xcf\x4c\x89\x2f\xc9\xcc\x4d\xb5\xe6\xe2\xc2\xa6\x28\xb7\x34\xa7\x24\x33\x3e\x33\x05\x26\x1d\xe2\xe8\xe4\xe3\xaa\x00\x92\x42\x15\x81\xa8\x83\x88\x03\x02\x00\x00\xff\xff\x32\x12\x92\x70\x6d\x00\x00\x00")
+var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6d\x00\x92\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x6a\x6f\x62\x73\x5f\x63\x6c\x69\x65\x6e\x74\x5f\x69\x64\x5f\x74\x69\x6d\x65\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x6a\x6f\x62\x73\x5f\x6d\x75\x6c\x74\x69\x5f\x69\x64\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6a\x6f\x62\x73\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x75\x6c\x74\x69\x5f\x6a\x6f\x62\x73\x3b\x0a\x03\x00\x32\x12\x92\x70\x6d\x00\x00\x00")
 
 func _001_initDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -88,12 +90,12 @@ func _001_initDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.down.sql", size: 109, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.down.sql", size: 109, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x5e, 0x88, 0xea, 0xcd, 0x76, 0x62, 0xa9, 0x87, 0xa2, 0xc7, 0xf7, 0x6a, 0xdd, 0x1, 0xaa, 0xab, 0x37, 0x9f, 0x8e, 0xd5, 0xf0, 0x2c, 0xe1, 0xaa, 0xf9, 0x4e, 0xe2, 0xe9, 0x5b, 0xe6, 0x64, 0xa9}}
 	return a, nil
 }
 
-var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x91\xcd\x6e\xab\x30\x10\x85\xf7\x3c\xc5\x2c\x41\xca\x1b\x64\xc5\x85\xe1\xd6\x2a\x31\x95\x99\x28\x64\x65\x91\xd8\x55\x07\x91\x54\x8a\x1d\xa9\x7d\xfb\xaa\xa6\x41\x71\xd5\xf4\x67\xfd\x1d\xcf\x7c\x73\x5c\x28\xcc\x09\x81\xf2\x7f\x35\x82\xa8\x40\x36\x04\xd8\x89\x96\x5a\x38\x9c\x47\xcf\x7a\x78\xde\x39\x48\x13\x00\x80\x81\x0d\x10\x76\x04\x0f\x4a\xac\x72\xb5\x85\x7b\xdc\x86\x07\x72\x5d\xd7\x8b\x10\x71\xbe\x3f\x79\x6b\x74\xef\xa1\xcc\x09\x49\xac\xf0\x53\x62\x7f\xb2\xfd\x7b\x62\xf7\x3a\xcd\x8a\xa9\xb1\xbe\xe7\xd1\xc5\x28\xc9\x60\x23\xe8\xae\x59\x13\xa8\x66\x23\xca\x65\x92\x44\xda\x7f\x56\xf4\x67\xf7\xd5\xf2\x9f\xe5\x1f\xf9\xc8\xee\x29\x8e\xfc\xe6\xac\xfd\xc8\xf6\xe8\xf5\x45\x2e\x86\x73\xcf\x17\xfe\x4d\x15\x13\xaa\x1a\x85\xe2\xbf\x0c\xc7\xa5\xd7\xcf\x33\x50\x58\xa1\x42\x59\xe0\xf5\xff\xa5\x03\x9b\xec\x76\x8b\x42\x96\xd8\x01\x9b\x97\x10\xd6\xb3\xac\xf6\x7c\xb0\x61\x61\x23\x3f\x4a\x9e\xd9\x22\xee\x02\xdb\x22\xbb\x39\x70\x12\x61\x13\x8f\x8a\xbc\x97\xc9\x5b\x00\x00\x00\xff\xff\x97\x9b\x70\x8a\x89\x02\x00\x00")
+var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x91\xd1\x6e\xb2\x40\x10\x85\xef\x79\x8a\x73\x09\x89\x6f\xe0\x15\x3f\x0c\x7f\x37\xc5\xa5\x59\xc6\x88\x57\x04\x5d\x9a\x0e\x41\x9b\xc8\x9a\xb4\x6f\xdf\x08\x29\x71\x4d\x6d\xec\xf5\x77\x76\xe6\xdb\x33\x89\xa1\x98\x09\x1c\xff\xcb\x09\x2a\x83\x2e\x18\x54\xa9\x92\x4b\x1c\xce\xbd\x93\xba\x7b\xdf\x0d\x08\x03\x00\xe8\xc4\x82\xa9\x62\xbc\x18\xb5\x8a\xcd\x16\xcf\xb4\x1d\x1f\xe8\x75\x9e\x2f\xc6\xc8\xe0\x9a\x93\x6b\x6d\xdd\x38\xa4\x31\x13\xab\x15\xdd\x24\xf6\xa7\xb6\xb9\x24\x76\x9f\xd3\x2c\x9f\xda\xd6\x35\xd2\x0f\x3e\x0a\x22\x6c\x14\x3f\x15\x6b\x86\x29\x36\x2a\x5d\x06\x81\xa7\xfd\x67\x45\x77\xbe\xd9\xf0\xa8\xfc\xab\x1c\x65\x78\xf3\x23\x8f\x7c\x6b\xdf\x4b\x7b\x74\xb5\xd8\x9f\xe0\xdc\xf3\x37\xff\xa5\x8a\x09\x65\x85\x21\xf5\x5f\x8f\xfd\x87\xd7\xcf\x23\x18\xca\xc8\x90\x4e\xe8\xfa\x7e\x61\x27\x36\xba\xdf\xa2\xd2\x29\x55\x10\xfb\x31\x1e\xbb\x9e\x65\x6b\x27\x87\x76\x5c\x58\x68\x5c\x10\xc2\x99\x2d\xfc\x2e\xa8\x4c\xa2\xbb\x03\x27\x11\xb1\xfe\x28\xcf\x7b\x19\x7c\x0d\x00\x97\x9b\x70\x8a\x89\x02\x00\x00")
 
 func _001_initUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -108,12 +110,12 @@ func _001_initUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.up.sql", size: 649, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "001_init.up.sql", size: 649, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x86, 0xd8, 0xc4, 0xe8, 0x45, 0x78, 0x1a, 0xe5, 0x4c, 0xce, 0xed, 0xd5, 0x39, 0x69, 0xdd, 0xb, 0xe3, 0xf, 0xf1, 0x7, 0x13, 0x6c, 0xd2, 0xf1, 0xe3, 0xe7, 0x3, 0x43, 0xb5, 0xa2, 0x27, 0x31}}
 	return a, nil
 }
 
-var __002_schedulesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x28\x4e\xce\x48\x4d\x29\xcd\x49\x2d\xb6\xe6\x02\x04\x00\x00\xff\xff\x0b\xb6\x9b\xfb\x16\x00\x00\x00")
+var __002_schedulesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x16\x00\xe9\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x63\x68\x65\x64\x75\x6c\x65\x73\x3b\x0a\x03\x00\x0b\xb6\x9b\xfb\x16\x00\x00\x00")
 
 func _002_schedulesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -128,12 +130,12 @@ func _002_schedulesDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_schedules.down.sql", size: 22, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_schedules.down.sql", size: 22, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x29, 0x36, 0x1d, 0xbd, 0x98, 0x87, 0xed, 0xf6, 0xc7, 0xbe, 0xaa, 0xe3, 0xae, 0x12, 0x22, 0x75, 0x50, 0x48, 0xe7, 0xf3, 0xde, 0x5b, 0xa6, 0x71, 0x29, 0x7, 0xa2, 0x18, 0x46, 0x33, 0x6, 0x81}}
 	return a, nil
 }
 
-var __002_schedulesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\x28\x4e\xce\x48\x4d\x29\xcd\x49\x2d\x56\xd0\xe0\x52\x50\x50\x50\xc8\x4c\x51\x08\x71\x8d\x08\x51\x08\x08\xf2\xf4\x75\x0c\x8a\x54\xf0\x76\x8d\x54\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\xd1\x01\xab\x48\x2e\x4a\x4d\x2c\x49\x4d\x89\x4f\x2c\x51\x70\x71\x0c\x71\x0d\xf1\xf4\x75\xc5\xa1\x22\xa9\x12\x62\x16\xaa\x6c\x5e\x62\x6e\x2a\x36\x71\x98\x4b\xb0\xc9\x95\x54\x16\x60\x15\x4f\x49\x2d\x49\xcc\xcc\x29\x46\x95\xe2\xd2\xb4\xe6\x02\x04\x00\x00\xff\xff\x2a\xa0\x1c\xb3\xe4\x00\x00\x00")
+var __002_schedulesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\x28\x4e\xce\x48\x4d\x29\xcd\x49\x2d\x56\xd0\xe0\x52\x50\x50\x50\xc8\x4c\x51\x08\x71\x8d\x08\x51\x08\x08\xf2\xf4\x75\x0c\x8a\x54\xf0\x76\x8d\x54\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\xd1\x01\xab\x48\x2e\x4a\x4d\x2c\x49\x4d\x89\x4f\x2c\x51\x70\x71\x0c\x71\x0d\xf1\xf4\x75\xc5\xa1\x22\xa9\x12\x62\x16\xaa\x6c\x5e\x62\x6e\x2a\x36\x71\x98\x4b\xb0\xc9\x95\x54\x16\x60\x15\x4f\x49\x2d\x49\xcc\xcc\x29\x46\x95\xe2\xd2\xb4\xe6\x02\x0c\x00\x2a\xa0\x1c\xb3\xe4\x00\x00\x00")
 
 func _002_schedulesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -148,12 +150,12 @@ func _002_schedulesUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "002_schedules.up.sql", size: 228, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "002_schedules.up.sql", size: 228, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x9f, 0x77, 0x95, 0xe9, 0xe7, 0x93, 0xb9, 0x79, 0xf2, 0x6c, 0xd0, 0x17, 0x16, 0x60, 0x2b, 0xca, 0xcd, 0x50, 0x5a, 0xc2, 0x9a, 0xcb, 0x4e, 0xbf, 0x92, 0xb6, 0x3f, 0x7d, 0xb, 0x85, 0x40, 0x7a}}
 	return a, nil
 }
 
-var __003_multi_job_schedule_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var __003_multi_job_schedule_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
 
 func _003_multi_job_schedule_idDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -168,12 +170,12 @@ func _003_multi_job_schedule_idDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "003_multi_job_schedule_id.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "003_multi_job_schedule_id.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
 	return a, nil
 }
 
-var __003_multi_job_schedule_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x2d\xcd\x29\xc9\x8c\xcf\xca\x4f\x2a\x56\x70\x74\x71\x51\x28\x4e\xce\x48\x4d\x29\xcd\x49\x8d\xcf\x4c\x51\x08\x71\x8d\x08\x51\xf0\x0b\xf5\xf1\xb1\xe6\x02\x04\x00\x00\xff\xff\x79\xff\xa0\x5d\x32\x00\x00\x00")
+var __003_multi_job_schedule_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x32\x00\xcd\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x6d\x75\x6c\x74\x69\x5f\x6a\x6f\x62\x73\x20\x41\x44\x44\x20\x73\x63\x68\x65\x64\x75\x6c\x65\x5f\x69\x64\x20\x54\x45\x58\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x03\x00\x79\xff\xa0\x5d\x32\x00\x00\x00")
 
 func _003_multi_job_schedule_idUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -188,11 +190,51 @@ func _003_multi_job_schedule_idUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "003_multi_job_schedule_id.up.sql", size: 50, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	info := bindataFileInfo{name: "003_multi_job_schedule_id.up.sql", size: 50, mode: os.FileMode(0644), modTime: time.Unix(1708336002, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x54, 0x85, 0xe7, 0xf3, 0x22, 0x90, 0x8c, 0xe8, 0x7a, 0xb, 0x7f, 0x78, 0x63, 0x5a, 0xc4, 0x42, 0x2e, 0x25, 0x6b, 0x3a, 0x76, 0x48, 0x47, 0x1e, 0x69, 0xf7, 0xa7, 0xc4, 0x61, 0x5d, 0x69, 0xfd}}
 	return a, nil
 }
 
+var __004_approvalsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x16\x00\xe9\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x61\x70\x70\x72\x6f\x76\x61\x6c\x73\x3b\x0a\x03\x00\xde\x84\xf5\xa1\x16\x00\x00\x00")
+
+func _004_approvalsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_approvalsDownSql,
+		"004_approvals.down.sql",
+	)
+}
+
+func _004_approvalsDownSql() (*asset, error) {
+	bytes, err := _004_approvalsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_approvals.down.sql", size: 22, mode: os.FileMode(0644), modTime: time.Unix(1792197193, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6f, 0x92, 0x7f, 0x94, 0xde, 0x39, 0x97, 0xae, 0x7d, 0x8d, 0xb3, 0x73, 0x17, 0x49, 0x3a, 0xdb, 0x84, 0x59, 0xe5, 0x39, 0x6c, 0xc4, 0x8e, 0xdb, 0x21, 0x7, 0x91, 0xeb, 0xf8, 0xda, 0xf6, 0x37}}
+	return a, nil
+}
+
+var __004_approvalsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x91\x51\x4b\xc3\x30\x14\x85\xdf\xf3\x2b\xce\xdb\x1c\xec\x1f\xec\x29\xae\x57\x28\x76\x9d\xd4\x0c\x36\x44\x42\x5c\x2e\x52\x28\x6b\x4d\x6e\x85\xfd\x7b\x61\xd5\xd6\xc5\xc9\x5e\x7b\xbf\x73\x7a\x72\xce\xaa\x22\x6d\x08\x46\xdf\x17\x04\xd7\x75\xa1\xfd\x74\x4d\xc4\x9d\x02\x80\xda\xc3\xd0\xce\xe0\xa9\xca\xd7\xba\xda\xe3\x91\xf6\x28\x37\x06\xe5\xb6\x28\x16\x67\x42\x4e\x1d\x0f\xcc\xe5\xf7\x28\x4e\xfa\x78\xed\x72\x68\x6a\x3e\x8a\x7d\x0f\x6d\xdf\xd9\xda\x27\x0c\x32\x7a\xd0\xdb\xc2\x60\xf6\xf2\x3a\x1b\x04\x81\x3f\x7a\x8e\xc2\xde\x3a\x41\xa6\x0d\x99\x7c\x4d\x89\xe9\xc4\xbc\x9d\xae\xfd\xd4\xf3\xa1\xf6\xa9\xc3\x9f\xeb\xa8\x1d\x2f\x81\x5d\x6c\x8f\xff\x45\x1c\x03\xc6\xbe\x11\xfb\x53\xd6\xe4\xcb\x21\xb4\xe1\xa6\xf8\x9c\xfc\x92\x52\xf3\xa5\x52\xdf\xcb\xe4\x65\x46\xbb\x69\x19\x3b\x34\x6b\xa7\x17\x3b\xc1\xa6\xfc\x3d\xdd\x40\x2c\x92\xe2\xe8\x79\x35\x5f\xaa\xaf\x01\x00\xec\xe3\x24\x67\xef\x01\x00\x00")
+
+func _004_approvalsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_approvalsUpSql,
+		"004_approvals.up.sql",
+	)
+}
+
+func _004_approvalsUpSql() (*asset, error) {
+	bytes, err := _004_approvalsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_approvals.up.sql", size: 495, mode: os.FileMode(0644), modTime: time.Unix(1792197193, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xee, 0x90, 0x87, 0xbf, 0x63, 0x74, 0xa4, 0xa7, 0xbb, 0x96, 0x58, 0x46, 0x66, 0xf8, 0xf5, 0x6b, 0x8a, 0xc2, 0x60, 0xc2, 0xed, 0x33, 0x12, 0x71, 0x7c, 0xee, 0x81, 0xbb, 0x33, 0xeb, 0xf, 0xb0}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +332,8 @@ var _bindata = map[string]func() (*asset, error){
 	"002_schedules.up.sql":               _002_schedulesUpSql,
 	"003_multi_job_schedule_id.down.sql": _003_multi_job_schedule_idDownSql,
 	"003_multi_job_schedule_id.up.sql":   _003_multi_job_schedule_idUpSql,
+	"004_approvals.down.sql":             _004_approvalsDownSql,
+	"004_approvals.up.sql":               _004_approvalsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +388,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_schedules.up.sql":               {_002_schedulesUpSql, map[string]*bintree{}},
 	"003_multi_job_schedule_id.down.sql": {_003_multi_job_schedule_idDownSql, map[string]*bintree{}},
 	"003_multi_job_schedule_id.up.sql":   {_003_multi_job_schedule_idUpSql, map[string]*bintree{}},
+	"004_approvals.down.sql":             {_004_approvalsDownSql, map[string]*bintree{}},
+	"004_approvals.up.sql":               {_004_approvalsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
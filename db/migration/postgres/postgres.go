@@ -0,0 +1,32 @@
+// Package postgres holds the PostgreSQL migrations of all server stores. Unlike SQLite, where every store has its own
+// database file, all stores share a single PostgreSQL database, so the migrations of each store live in its own folder.
+package postgres
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+//go:embed sql
+var migrations embed.FS
+
+// Migrations returns the names of the migrations of the given store and a function to read them.
+func Migrations(store string) (assetNames []string, asset func(name string) ([]byte, error), err error) {
+	dir := path.Join("sql", store)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no postgres migrations found for %q: %w", store, err)
+	}
+
+	for _, e := range entries {
+		assetNames = append(assetNames, e.Name())
+	}
+
+	asset = func(name string) ([]byte, error) {
+		return migrations.ReadFile(path.Join(dir, name))
+	}
+
+	return assetNames, asset, nil
+}
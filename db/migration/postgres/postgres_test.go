@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stores = []string{
+	"api_sessions",
+	"api_token",
+	"auditlog",
+	"client_groups",
+	"clients",
+	"ha",
+	"jobs",
+	"library",
+	"monitoring",
+	"notifications",
+	"vaults",
+}
+
+func TestMigrations(t *testing.T) {
+	for _, store := range stores {
+		t.Run(store, func(t *testing.T) {
+			assetNames, asset, err := Migrations(store)
+			require.NoError(t, err)
+			require.NotEmpty(t, assetNames)
+
+			for _, name := range assetNames {
+				var pair string
+				switch {
+				case strings.HasSuffix(name, ".up.sql"):
+					pair = strings.TrimSuffix(name, ".up.sql") + ".down.sql"
+				case strings.HasSuffix(name, ".down.sql"):
+					pair = strings.TrimSuffix(name, ".down.sql") + ".up.sql"
+				default:
+					t.Fatalf("unexpected migration file %q", name)
+				}
+				assert.Contains(t, assetNames, pair)
+
+				content, err := asset(name)
+				require.NoError(t, err)
+				assert.NotEmpty(t, content)
+			}
+		})
+	}
+}
+
+func TestMigrationsUnknownStore(t *testing.T) {
+	_, _, err := Migrations("unknown")
+	assert.ErrorContains(t, err, `no postgres migrations found for "unknown"`)
+}
@@ -0,0 +1,13 @@
+CREATE TABLE api_tokens (
+    username TEXT NOT NULL CHECK (username != ''),
+    prefix TEXT NOT NULL CHECK (prefix != ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ,
+    scope TEXT,
+    token TEXT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (username, prefix)
+);
+
+CREATE UNIQUE INDEX api_tokens_unique_name
+    ON api_tokens (username, name);
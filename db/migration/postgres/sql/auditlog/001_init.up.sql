@@ -0,0 +1,27 @@
+CREATE TABLE auditlog (
+    timestamp TIMESTAMPTZ NOT NULL,
+    application TEXT NOT NULL,
+    action TEXT NOT NULL,
+    username TEXT NULL,
+    remote_ip TEXT NULL,
+    affected_id TEXT NULL,
+    client_id TEXT NULL,
+    client_hostname TEXT NULL,
+    request TEXT NULL,
+    response TEXT NULL
+);
+
+CREATE INDEX auditlog_timestamp_application_action_affected_id
+    ON auditlog (timestamp, application, action, affected_id);
+
+CREATE INDEX auditlog_client_id
+    ON auditlog (client_id);
+
+CREATE INDEX auditlog_client_hostname
+    ON auditlog (client_hostname);
+
+CREATE INDEX auditlog_username
+    ON auditlog (username);
+
+CREATE INDEX auditlog_remote_ip
+    ON auditlog (remote_ip);
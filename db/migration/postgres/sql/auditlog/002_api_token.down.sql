@@ -0,0 +1 @@
+ALTER TABLE auditlog DROP COLUMN api_token;
@@ -0,0 +1 @@
+DROP TABLE client_groups;
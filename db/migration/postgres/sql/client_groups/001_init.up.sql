@@ -0,0 +1,6 @@
+CREATE TABLE client_groups (
+    id TEXT PRIMARY KEY NOT NULL,
+    description TEXT NOT NULL,
+    params TEXT NOT NULL,
+    allowed_user_groups TEXT NOT NULL DEFAULT '[]'
+);
@@ -0,0 +1,2 @@
+ALTER TABLE client_groups ADD bandwidth_limit_up BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE client_groups ADD bandwidth_limit_down BIGINT NOT NULL DEFAULT 0;
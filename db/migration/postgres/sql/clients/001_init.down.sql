@@ -0,0 +1,2 @@
+DROP TABLE stored_tunnels;
+DROP TABLE clients;
@@ -0,0 +1,25 @@
+CREATE TABLE clients (
+    id TEXT PRIMARY KEY NOT NULL,
+    client_auth_id TEXT NOT NULL,
+    disconnected_at TIMESTAMPTZ,
+    details TEXT NOT NULL
+);
+
+CREATE INDEX clients_disconnected_at
+    ON clients (disconnected_at DESC, client_auth_id);
+
+CREATE TABLE stored_tunnels (
+    id TEXT PRIMARY KEY NOT NULL,
+    client_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    name TEXT,
+    scheme TEXT,
+    remote_ip TEXT,
+    remote_port INTEGER,
+    public_port INTEGER,
+    acl TEXT,
+    further_options TEXT
+);
+
+CREATE INDEX stored_tunnels_client_id
+    ON stored_tunnels (client_id);
@@ -0,0 +1,11 @@
+CREATE TABLE client_auth_rotations (
+    client_auth_id TEXT PRIMARY KEY NOT NULL,
+    interval_sec INTEGER NOT NULL DEFAULT 0,
+    next_rotation_at TIMESTAMPTZ NULL,
+    last_rotated_at TIMESTAMPTZ NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    alternate_password_hash TEXT NOT NULL DEFAULT '',
+    alternate_valid_until TIMESTAMPTZ NULL
+);
+
+CREATE INDEX client_auth_rotations_next_rotation_at ON client_auth_rotations (next_rotation_at);
@@ -0,0 +1,3 @@
+DROP TABLE ha_client_nodes;
+DROP TABLE ha_nodes;
+DROP TABLE ha_leases;
@@ -0,0 +1,20 @@
+CREATE TABLE ha_leases (
+    name TEXT PRIMARY KEY,
+    node_id TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE ha_nodes (
+    node_id TEXT PRIMARY KEY,
+    api_url TEXT NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE ha_client_nodes (
+    client_id TEXT PRIMARY KEY,
+    node_id TEXT NOT NULL,
+    connected_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ha_client_nodes_node_id
+    ON ha_client_nodes (node_id);
@@ -0,0 +1,37 @@
+CREATE TABLE multi_jobs (
+    jid TEXT PRIMARY KEY NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    schedule_id TEXT NULL,
+    details TEXT NOT NULL
+);
+
+CREATE INDEX multi_jobs_schedule_id
+    ON multi_jobs (schedule_id);
+
+CREATE TABLE jobs (
+    jid TEXT PRIMARY KEY NOT NULL,
+    status TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    created_by TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    multi_job_id TEXT,
+    details TEXT NOT NULL
+);
+
+CREATE INDEX jobs_client_id_finished_at
+    ON jobs (client_id, finished_at DESC);
+
+CREATE INDEX jobs_multi_job_id
+    ON jobs (multi_job_id);
+
+CREATE TABLE schedules (
+    id TEXT PRIMARY KEY NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    name TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    type TEXT NOT NULL,
+    details TEXT NOT NULL
+);
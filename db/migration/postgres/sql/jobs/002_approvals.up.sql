@@ -0,0 +1,16 @@
+CREATE TABLE approvals (
+    id TEXT PRIMARY KEY NOT NULL,
+    type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    client_group_ids TEXT NOT NULL DEFAULT '[]',
+    requested_at TIMESTAMPTZ NOT NULL,
+    requested_by TEXT NOT NULL,
+    decided_at TIMESTAMPTZ NULL,
+    decided_by TEXT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    result_id TEXT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    request TEXT NOT NULL
+);
+
+CREATE INDEX approvals_status_requested_at ON approvals (status, requested_at DESC);
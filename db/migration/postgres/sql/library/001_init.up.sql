@@ -0,0 +1,32 @@
+CREATE TABLE scripts (
+    id TEXT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by TEXT NOT NULL DEFAULT '',
+    interpreter TEXT,
+    is_sudo BOOLEAN NOT NULL DEFAULT FALSE,
+    cwd TEXT,
+    script TEXT NOT NULL,
+    tags TEXT NOT NULL DEFAULT '[]',
+    timeout_sec INTEGER NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX scripts_unique_name
+    ON scripts (name);
+
+CREATE TABLE commands (
+    id TEXT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by TEXT NOT NULL,
+    cmd TEXT NOT NULL,
+    tags TEXT NOT NULL DEFAULT '[]',
+    timeout_sec INTEGER NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX commands_unique_name
+    ON commands (name);
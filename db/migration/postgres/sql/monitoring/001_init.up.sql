@@ -0,0 +1,17 @@
+CREATE TABLE measurements (
+    client_id TEXT NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    cpu_usage_percent DOUBLE PRECISION NOT NULL,
+    memory_usage_percent DOUBLE PRECISION NOT NULL,
+    io_usage_percent DOUBLE PRECISION NOT NULL,
+    processes TEXT,
+    mountpoints TEXT,
+    net_lan_in BIGINT,
+    net_lan_out BIGINT,
+    net_wan_in BIGINT,
+    net_wan_out BIGINT,
+    PRIMARY KEY (client_id, timestamp)
+);
+
+CREATE INDEX measurements_timestamp
+    ON measurements (timestamp);
@@ -0,0 +1 @@
+DROP TABLE notifications_log;
@@ -0,0 +1,21 @@
+CREATE TABLE notifications_log (
+    -- keeps the insertion order of the log entries, like the implicit rowid of sqlite
+    oid BIGSERIAL PRIMARY KEY,
+    notification_id CHAR(26) NOT NULL CHECK (notification_id != ''),
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "contentType" VARCHAR(50) NOT NULL DEFAULT '',
+    reference_id VARCHAR(26) NOT NULL DEFAULT '',
+    transport TEXT NOT NULL DEFAULT '',
+    recipients TEXT NOT NULL DEFAULT '',
+    state VARCHAR(20) NOT NULL CHECK (state != ''),
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    out TEXT NOT NULL DEFAULT '',
+    err TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX notifications_log_notification_id
+    ON notifications_log (notification_id);
+
+CREATE INDEX notifications_log_timestamp
+    ON notifications_log (timestamp);
@@ -0,0 +1,2 @@
+DROP TABLE status;
+DROP TABLE "values";
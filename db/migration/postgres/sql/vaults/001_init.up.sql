@@ -0,0 +1,25 @@
+CREATE TABLE "values" (
+    id SERIAL PRIMARY KEY,
+    client_id TEXT NOT NULL DEFAULT '0',
+    required_group TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by TEXT,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    type TEXT NOT NULL
+);
+
+CREATE INDEX values_key
+    ON "values" (key);
+
+CREATE UNIQUE INDEX values_unique_client_id_key
+    ON "values" (client_id, key);
+
+CREATE TABLE status (
+    id SERIAL PRIMARY KEY,
+    db_status TEXT NOT NULL,
+    enc_check TEXT,
+    dec_check TEXT
+);
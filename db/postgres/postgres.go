@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // register the postgres driver
+)
+
+const DriverName = "postgres"
+
+// New returns a new postgres DB instance with migrated DB scheme to the latest version.
+// All stores share the same database, migrationsTable keeps track of the migrations applied for the store.
+// assetNames and asset are used to migrate DB scheme.
+func New(dsn string, migrationsTable string, assetNames []string, asset func(name string) ([]byte, error)) (*sqlx.DB, error) {
+	if err := migrateUp(dsn, migrationsTable, assetNames, asset); err != nil {
+		return nil, err
+	}
+
+	db, err := sqlx.Connect(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %v", err)
+	}
+
+	return db, nil
+}
+
+// migrateUp uses a separate connection, closing the migration instance also closes the underlying DB.
+func migrateUp(dsn string, migrationsTable string, assetNames []string, asset func(name string) ([]byte, error)) error {
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect to DB: %v", err)
+	}
+
+	sourceDriver, err := bindata.WithInstance(bindata.Resource(assetNames, asset))
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to init DB source driver: %v", err)
+	}
+
+	dbDriver, err := postgres.WithInstance(db, &postgres.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to init DB migration driver: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("go-bindata", sourceDriver, DriverName, dbDriver)
+	if err != nil {
+		dbDriver.Close()
+		return fmt.Errorf("failed to init DB migration instance: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate DB to the latest version: %v", err)
+	}
+
+	return nil
+}
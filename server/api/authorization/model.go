@@ -1,20 +1,38 @@
 package authorization
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
-
+	"fmt"
+	"net"
 	"strings"
 	"time"
+
+	"github.com/openrport/openrport/server/bearer"
 )
 
 type APIToken struct {
-	Username  string        `json:"username,omitempty" db:"username"`
-	Prefix    string        `json:"prefix,omitempty" db:"prefix"`
-	Name      string        `json:"name,omitempty" db:"name"`
-	CreatedAt *time.Time    `json:"created_at,omitempty" db:"created_at"`
-	ExpiresAt *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
-	Scope     APITokenScope `json:"scope,omitempty" db:"scope"`
-	Token     string        `json:"token,omitempty" db:"token"`
+	Username     string                `json:"username,omitempty" db:"username"`
+	Prefix       string                `json:"prefix,omitempty" db:"prefix"`
+	Name         string                `json:"name,omitempty" db:"name"`
+	CreatedAt    *time.Time            `json:"created_at,omitempty" db:"created_at"`
+	ExpiresAt    *time.Time            `json:"expires_at,omitempty" db:"expires_at"`
+	Scope        APITokenScope         `json:"scope,omitempty" db:"scope"`
+	Restrictions *APITokenRestrictions `json:"restrictions,omitempty" db:"restrictions"`
+	Token        string                `json:"token,omitempty" db:"token"`
+}
+
+// APITokenRestrictions limit the requests a token can be used for in addition to its scope
+type APITokenRestrictions struct {
+	// ClientGroups and ClientTags limit the token to requests on clients which are members of one of the client
+	// groups or have one of the tags, or to requests on one of the client groups
+	ClientGroups []string `json:"client_groups,omitempty"`
+	ClientTags   []string `json:"client_tags,omitempty"`
+	// Endpoints limit the token to the matching request URIs and methods
+	Endpoints []bearer.Scope `json:"endpoints,omitempty"`
+	// AllowedIPs holds the IP addresses and networks in CIDR notation the token can be used from
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
 }
 
 const APITokenPrefixLength = 8
@@ -50,3 +68,91 @@ func IsValidScope(scope APITokenScope) bool {
 	}
 	return false
 }
+
+func (r *APITokenRestrictions) Validate() error {
+	if len(r.ClientGroups) == 0 && len(r.ClientTags) == 0 && len(r.Endpoints) == 0 && len(r.AllowedIPs) == 0 {
+		return errors.New("restrictions cannot be empty")
+	}
+	for _, e := range r.Endpoints {
+		if e.URI == "" || e.Method == "" {
+			return fmt.Errorf("endpoint %+v: uri and method are required", e)
+		}
+	}
+	for _, ip := range r.AllowedIPs {
+		if _, err := parseIPNet(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasClientRestrictions returns true if the token is limited to some clients
+func (r *APITokenRestrictions) HasClientRestrictions() bool {
+	return len(r.ClientGroups) > 0 || len(r.ClientTags) > 0
+}
+
+// AllowsEndpoint returns true if the request uri and method match the endpoint restrictions
+func (r *APITokenRestrictions) AllowsEndpoint(uri, method string) bool {
+	return len(r.Endpoints) == 0 || bearer.MatchesScopes(uri, method, r.Endpoints)
+}
+
+// AllowsIP returns true if the ip matches the allowed IPs
+func (r *APITokenRestrictions) AllowsIP(ip string) bool {
+	if len(r.AllowedIPs) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, allowed := range r.AllowedIPs {
+		ipNet, err := parseIPNet(allowed)
+		if err == nil && ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIPNet(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", value)
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %v", value, err)
+	}
+	return ipNet, nil
+}
+
+func (r APITokenRestrictions) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (r *APITokenRestrictions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("failed to decode 'restrictions' field: unknown column type %T", value)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return fmt.Errorf("failed to decode 'restrictions' field: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package authorization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openrport/openrport/server/bearer"
+)
+
+func TestRestrictionsValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		restrictions APITokenRestrictions
+		wantErr      string
+	}{
+		{
+			name: "valid",
+			restrictions: APITokenRestrictions{
+				ClientTags: []string{"ci"},
+				Endpoints:  []bearer.Scope{{URI: "/api/v1/clients/*", Method: "POST"}},
+				AllowedIPs: []string{"192.0.2.10", "10.0.0.0/8", "2001:db8::/32"},
+			},
+		},
+		{
+			name:    "empty",
+			wantErr: "restrictions cannot be empty",
+		},
+		{
+			name:         "endpoint without method",
+			restrictions: APITokenRestrictions{Endpoints: []bearer.Scope{{URI: "/api/v1/clients"}}},
+			wantErr:      "uri and method are required",
+		},
+		{
+			name:         "invalid ip",
+			restrictions: APITokenRestrictions{AllowedIPs: []string{"192.0.2"}},
+			wantErr:      `invalid IP address "192.0.2"`,
+		},
+		{
+			name:         "invalid cidr",
+			restrictions: APITokenRestrictions{AllowedIPs: []string{"10.0.0.0/33"}},
+			wantErr:      `invalid CIDR "10.0.0.0/33"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.restrictions.Validate()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestrictionsAllowsIP(t *testing.T) {
+	r := &APITokenRestrictions{AllowedIPs: []string{"192.0.2.10", "10.0.0.0/8", "2001:db8::/32"}}
+
+	assert.True(t, r.AllowsIP("192.0.2.10"))
+	assert.True(t, r.AllowsIP("10.1.2.3"))
+	assert.True(t, r.AllowsIP("2001:db8::1"))
+	assert.False(t, r.AllowsIP("192.0.2.11"))
+	assert.False(t, r.AllowsIP("2001:db9::1"))
+	assert.False(t, r.AllowsIP(""))
+
+	assert.True(t, (&APITokenRestrictions{}).AllowsIP("192.0.2.11"))
+}
+
+func TestRestrictionsAllowsEndpoint(t *testing.T) {
+	r := &APITokenRestrictions{Endpoints: []bearer.Scope{
+		{URI: "/api/v1/clients/*", Method: "*"},
+		{URI: "/api/v1/clients/*", Method: "DELETE", Exclude: true},
+		{URI: "/api/v1/commands", Method: "GET"},
+	}}
+
+	assert.True(t, r.AllowsEndpoint("/api/v1/clients/abc/commands", "POST"))
+	assert.True(t, r.AllowsEndpoint("/api/v1/commands/", "GET"))
+	assert.False(t, r.AllowsEndpoint("/api/v1/clients/abc", "DELETE"))
+	assert.False(t, r.AllowsEndpoint("/api/v1/clients", "GET"))
+	assert.False(t, r.AllowsEndpoint("/api/v1/commands", "POST"))
+
+	assert.True(t, (&APITokenRestrictions{}).AllowsEndpoint("/api/v1/users", "DELETE"))
+}
@@ -6,15 +6,19 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
 )
 
 type SqliteProvider struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	converter *query.SQLConverter
 }
 
 func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
 	return &SqliteProvider{
-		db: db,
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
 	}
 }
 
@@ -22,7 +26,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context, username string) ([]*APITok
 	var result []*APIToken
 	err := p.db.SelectContext(
 		ctx, &result,
-		"SELECT * FROM api_tokens WHERE username = ?",
+		p.converter.Rebind("SELECT * FROM api_tokens WHERE username = ?"),
 		username,
 	)
 	if err != nil {
@@ -37,7 +41,7 @@ func (p *SqliteProvider) Get(ctx context.Context, username, prefix string) (*API
 
 	err := p.db.GetContext(ctx,
 		res,
-		"SELECT * FROM api_tokens WHERE username = ? AND prefix = ?",
+		p.converter.Rebind("SELECT * FROM api_tokens WHERE username = ? AND prefix = ?"),
 		username,
 		prefix,
 	)
@@ -56,7 +60,7 @@ func (p *SqliteProvider) GetByName(ctx context.Context, username, name string) (
 	res := &APIToken{}
 	err := p.db.GetContext(ctx,
 		res,
-		"SELECT * FROM api_tokens WHERE username = ? AND name = ?",
+		p.converter.Rebind("SELECT * FROM api_tokens WHERE username = ? AND name = ?"),
 		username,
 		name,
 	)
@@ -72,13 +76,13 @@ func (p *SqliteProvider) GetByName(ctx context.Context, username, name string) (
 func (p *SqliteProvider) Save(ctx context.Context, tokenLine *APIToken) (err error) {
 	res, err := p.db.NamedExecContext(
 		ctx,
-		`INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token)
+		`INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, restrictions, token)
 			      VALUES (:username, :prefix, :name, 
-					CASE WHEN :created_at IS NOT NULL THEN :created_at ELSE CURRENT_TIMESTAMP END,
-					:expires_at, :scope, :token)
+					COALESCE(:created_at, CURRENT_TIMESTAMP),
+					:expires_at, :scope, :restrictions, :token)
 			 	ON CONFLICT(username, prefix) DO UPDATE SET
-				 expires_at=CASE WHEN :expires_at IS NOT NULL THEN EXCLUDED.expires_at ELSE api_tokens.expires_at END,
-				 name=CASE WHEN :name != "" THEN EXCLUDED.name ELSE api_tokens.name END
+				 expires_at=COALESCE(EXCLUDED.expires_at, api_tokens.expires_at),
+				 name=CASE WHEN EXCLUDED.name != '' THEN EXCLUDED.name ELSE api_tokens.name END
 				WHERE EXCLUDED.username = api_tokens.username AND
 				       EXCLUDED.prefix = api_tokens.prefix`,
 		tokenLine,
@@ -101,7 +105,7 @@ func (p *SqliteProvider) Save(ctx context.Context, tokenLine *APIToken) (err err
 func (p *SqliteProvider) Delete(ctx context.Context, username, prefix string) error {
 	res, err := p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_tokens WHERE username = ? AND prefix = ?",
+		p.converter.Rebind("DELETE FROM api_tokens WHERE username = ? AND prefix = ?"),
 		username,
 		prefix,
 	)
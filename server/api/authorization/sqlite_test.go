@@ -12,6 +12,7 @@ import (
 
 	"github.com/openrport/openrport/db/migration/api_token"
 	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/share/ptr"
 	"github.com/openrport/openrport/share/test"
 )
@@ -137,6 +138,27 @@ func TestCreate(t *testing.T) {
 	test.AssertRowsEqual(t, dbProv.db, expectedRows, q, []interface{}{})
 }
 
+func TestCreateWithRestrictions(t *testing.T) {
+	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	dbProv := NewSqliteProvider(db)
+	defer dbProv.Close()
+
+	ctx := context.Background()
+	itemToSave := demoData[2]
+	itemToSave.Restrictions = &APITokenRestrictions{
+		ClientGroups: []string{"ci-runners"},
+		Endpoints:    []bearer.Scope{{URI: "/api/v1/clients/*", Method: "*"}},
+		AllowedIPs:   []string{"10.0.0.0/8"},
+	}
+	err = dbProv.Save(ctx, &itemToSave)
+	require.NoError(t, err)
+
+	val, err := dbProv.Get(ctx, itemToSave.Username, itemToSave.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, itemToSave, *val)
+}
+
 func TestUpdate(t *testing.T) {
 
 	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
@@ -268,13 +290,14 @@ func TestDelete(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"username":   demoData[0].Username,
-			"prefix":     demoData[0].Prefix,
-			"name":       demoData[0].Name,
-			"created_at": *demoData[0].CreatedAt,
-			"expires_at": *demoData[0].ExpiresAt,
-			"scope":      "read", // needed to avoid test fail using itemToSave.Scope which is of type enum
-			"token":      demoData[0].Token,
+			"username":     demoData[0].Username,
+			"prefix":       demoData[0].Prefix,
+			"name":         demoData[0].Name,
+			"created_at":   *demoData[0].CreatedAt,
+			"expires_at":   *demoData[0].ExpiresAt,
+			"scope":        "read", // needed to avoid test fail using itemToSave.Scope which is of type enum
+			"restrictions": nil,
+			"token":        demoData[0].Token,
 		},
 	}
 	q := "SELECT * FROM `api_tokens`"
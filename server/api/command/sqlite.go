@@ -40,7 +40,7 @@ func (p *SqliteProvider) GetByID(ctx context.Context, id string, ro *query.Retri
 	q = p.converter.ConvertRetrieveOptionsToQuery(ro, q)
 
 	val = new(Command)
-	err = p.db.GetContext(ctx, val, q, id)
+	err = p.db.GetContext(ctx, val, p.converter.Rebind(q), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return val, false, nil
@@ -59,7 +59,7 @@ func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]Com
 
 	q, params := p.converter.ConvertListOptionsToQuery(lo, q)
 
-	err := p.db.SelectContext(ctx, &values, q, params...)
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
 	if err != nil {
 		return values, err
 	}
@@ -77,10 +77,10 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Command) (string, error) {
 
 		_, err = p.db.NamedExecContext(
 			ctx,
-			"INSERT INTO `commands` "+
+			p.converter.Rebind("INSERT INTO `commands` "+
 				"(`id`, `name`, `created_at`, `created_by`, `updated_at`, `updated_by`, `cmd`, `tags`, `timeout_sec`)"+
 				" VALUES "+
-				"(:id, :name, :created_at, :created_by, :updated_at, :updated_by, :cmd, :tags, :timeout_sec)",
+				"(:id, :name, :created_at, :created_by, :updated_at, :updated_by, :cmd, :tags, :timeout_sec)"),
 			s,
 		)
 
@@ -95,13 +95,13 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Command) (string, error) {
 		"`tags` = :tags, " +
 		"`timeout_sec` = :timeout_sec " +
 		"WHERE id = :id"
-	_, err := p.db.NamedExecContext(ctx, q, s)
+	_, err := p.db.NamedExecContext(ctx, p.converter.Rebind(q), s)
 
 	return s.ID, err
 }
 
 func (p *SqliteProvider) Delete(ctx context.Context, id string) error {
-	res, err := p.db.ExecContext(ctx, "DELETE FROM `commands` WHERE `id` = ?", id)
+	res, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM `commands` WHERE `id` = ?"), id)
 
 	if err != nil {
 		return err
@@ -25,3 +25,16 @@ func GetUser(ctx context.Context, log *logger.Logger) string {
 	}
 	return user
 }
+
+const apiTokenCtxKey userCtxKeyType = "api_token"
+
+// WithAPIToken returns a copy of a given context that contains the prefix of the API token used to authenticate.
+func WithAPIToken(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, apiTokenCtxKey, prefix)
+}
+
+// GetAPIToken returns the prefix of the API token from a given context, it's empty if no API token was used.
+func GetAPIToken(ctx context.Context) string {
+	prefix, _ := ctx.Value(apiTokenCtxKey).(string)
+	return prefix
+}
@@ -2,28 +2,75 @@ package jobs
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/openrport/openrport/share/models"
 )
 
 type CleanupProvider interface {
 	CleanupJobsMultiJobs(context.Context, int) error
+	GetByJID(clientID, jid string) (*models.Job, error)
+}
+
+// OutputStore holds the job output streamed to files, see joboutput.Manager
+type OutputStore interface {
+	JIDs() ([]string, error)
+	Delete(jid string) error
+	DeleteModifiedBefore(t time.Time) (int, error)
 }
 
 type CleanupTask struct {
-	provider CleanupProvider
-	maxJobs  int
+	provider        CleanupProvider
+	maxJobs         int
+	output          OutputStore
+	outputRetention time.Duration
 }
 
-func NewCleanupTask(provider CleanupProvider, maxJobs int) *CleanupTask {
+// NewCleanupTask returns a task that keeps the latest maxJobs jobs. Output files are removed once older than outputRetention
+// or when their job was removed. output can be nil.
+func NewCleanupTask(provider CleanupProvider, maxJobs int, output OutputStore, outputRetention time.Duration) *CleanupTask {
 	return &CleanupTask{
-		provider: provider,
-		maxJobs:  maxJobs,
+		provider:        provider,
+		maxJobs:         maxJobs,
+		output:          output,
+		outputRetention: outputRetention,
 	}
 }
 
 func (t *CleanupTask) Run(ctx context.Context) error {
-	return t.provider.CleanupJobsMultiJobs(ctx, t.maxJobs)
+	if err := t.provider.CleanupJobsMultiJobs(ctx, t.maxJobs); err != nil {
+		return err
+	}
+	if t.output == nil {
+		return nil
+	}
+	return t.cleanupOutput()
+}
+
+func (t *CleanupTask) cleanupOutput() error {
+	if _, err := t.output.DeleteModifiedBefore(time.Now().Add(-t.outputRetention)); err != nil {
+		return errors.Wrap(err, "deleting outdated job output")
+	}
+
+	jids, err := t.output.JIDs()
+	if err != nil {
+		return errors.Wrap(err, "listing job output")
+	}
+	for _, jid := range jids {
+		job, err := t.provider.GetByJID("", jid)
+		if err != nil {
+			return err
+		}
+		if job != nil {
+			continue
+		}
+		if err := t.output.Delete(jid); err != nil {
+			return errors.Wrapf(err, "deleting output of job %s", jid)
+		}
+	}
+	return nil
 }
 
 func (p *SqliteProvider) CleanupJobsMultiJobs(ctx context.Context, maxJobs int) error {
@@ -10,7 +10,9 @@ import (
 
 	"github.com/openrport/openrport/db/migration/jobs"
 	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/joboutput"
 	"github.com/openrport/openrport/server/test/jb"
+	"github.com/openrport/openrport/share/models"
 )
 
 func TestCleanupJobsMultiJobs(t *testing.T) {
@@ -84,3 +86,39 @@ func TestCleanupJobsMultiJobs(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, j)
 }
+
+func TestCleanupTaskJobOutput(t *testing.T) {
+	ctx := context.Background()
+	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewSqliteProvider(jobsDB, testLog)
+	defer p.Close()
+
+	output, err := joboutput.NewManager(t.TempDir(), 0, testLog)
+	require.NoError(t, err)
+
+	j1 := jb.New(t).StartedAt(time.Now().Add(-time.Minute)).Build()
+	j2 := jb.New(t).StartedAt(time.Now()).Build()
+	require.NoError(t, p.SaveJob(j1))
+	require.NoError(t, p.SaveJob(j2))
+	for _, jid := range []string{j1.JID, j2.JID, "unknown-job"} {
+		f, err := output.Create(jid, models.ChannelStdout)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	task := NewCleanupTask(p, 1, output, time.Hour)
+	require.NoError(t, task.Run(ctx))
+
+	// output of the removed job j1 and of the unknown job is deleted
+	jids, err := output.JIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{j2.JID}, jids)
+
+	task = NewCleanupTask(p, 1, output, 0)
+	require.NoError(t, task.Run(ctx))
+
+	jids, err = output.JIDs()
+	require.NoError(t, err)
+	assert.Empty(t, jids)
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 
 	"github.com/openrport/openrport/db/sqlite"
@@ -110,7 +109,7 @@ func (p *SqliteProvider) GetDB() (db *sqlx.DB) {
 
 func (p *SqliteProvider) GetByJID(clientID, jid string) (*models.Job, error) {
 	res := &jobSqlite{}
-	err := p.db.Get(res, "SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid WHERE jobs.jid=?", jid)
+	err := p.db.Get(res, p.converter.Rebind("SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid WHERE jobs.jid=?"), jid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -138,7 +137,7 @@ func (p *SqliteProvider) List(ctx context.Context, options *query.ListOptions) (
 	q, params := p.converter.AppendOptionsToQuery(options, q, nil)
 
 	var res []*jobSqlite
-	err := p.db.SelectContext(ctx, &res, q, params...)
+	err := p.db.SelectContext(ctx, &res, p.converter.Rebind(q), params...)
 	if err != nil {
 		return nil, err
 	}
@@ -149,22 +148,48 @@ func (p *SqliteProvider) Count(ctx context.Context, options *query.ListOptions)
 	countOptions := *options
 	countOptions.Pagination = nil
 
-	q := "SELECT count(*) FROM (SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid)"
+	q := "SELECT count(*) FROM (SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid) AS jobs"
 	q, params := p.converter.AppendOptionsToQuery(&countOptions, q, nil)
 
 	var result int
-	err := p.db.GetContext(ctx, &result, q, params...)
+	err := p.db.GetContext(ctx, &result, p.converter.Rebind(q), params...)
 	if err != nil {
 		return 0, err
 	}
 	return result, nil
 }
 
+// CountByStatus returns the number of jobs per status
+func (p *SqliteProvider) CountByStatus(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	err := p.db.SelectContext(ctx, &rows, "SELECT status, count(*) AS count FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(rows))
+	for _, row := range rows {
+		result[row.Status] = row.Count
+	}
+	return result, nil
+}
+
 // SaveJob creates a new or updates an existing job.
 func (p *SqliteProvider) SaveJob(job *models.Job) error {
 	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
-		result, err = p.db.NamedExec(`INSERT OR REPLACE INTO jobs (jid, status, started_at, finished_at, created_by, client_id, multi_job_id, details)
-		VALUES (:jid, :status, :started_at, :finished_at, :created_by, :client_id, :multi_job_id, :details)`,
+		result, err = p.db.NamedExec(`INSERT INTO jobs (jid, status, started_at, finished_at, created_by, client_id, multi_job_id, details)
+		VALUES (:jid, :status, :started_at, :finished_at, :created_by, :client_id, :multi_job_id, :details)
+		ON CONFLICT (jid) DO UPDATE SET
+			status = EXCLUDED.status,
+			started_at = EXCLUDED.started_at,
+			finished_at = EXCLUDED.finished_at,
+			created_by = EXCLUDED.created_by,
+			client_id = EXCLUDED.client_id,
+			multi_job_id = EXCLUDED.multi_job_id,
+			details = EXCLUDED.details`,
 			convertToSqlite(job))
 		return result, err
 	}, "savejob", p.log)
@@ -177,23 +202,23 @@ func (p *SqliteProvider) SaveJob(job *models.Job) error {
 
 // CreateJob creates a new job. If already exists with the same ID - does nothing and returns nil.
 func (p *SqliteProvider) CreateJob(job *models.Job) error {
-	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
+	result, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
 		result, err = p.db.NamedExec(`INSERT INTO jobs (jid, status, started_at, finished_at, created_by, client_id, multi_job_id, details)
-		VALUES (:jid, :status, :started_at, :finished_at, :created_by, :client_id, :multi_job_id, :details)`,
+		VALUES (:jid, :status, :started_at, :finished_at, :created_by, :client_id, :multi_job_id, :details)
+		ON CONFLICT (jid) DO NOTHING`,
 			convertToSqlite(job))
 		return result, err
 	}, "createjob", p.log)
 	if err != nil {
-		// check if it's "already exist" err
-		typeErr, ok := err.(sqlite3.Error)
-		if ok && typeErr.Code == sqlite3.ErrConstraint {
-			p.log.Debugf("Job already exist with ID: %s", job.JID)
-			return nil
-		}
+		return err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		p.log.Debugf("Job already exist with ID: %s", job.JID)
 	} else {
 		p.log.Debugf("Job saved successfully: %v", *job)
 	}
-	return err
+	return nil
 }
 
 func (p *SqliteProvider) Close() error {
@@ -215,16 +240,17 @@ type jobSqlite struct {
 }
 
 type JobDetails struct {
-	Command     string            `json:"command"`
-	Cwd         string            `json:"cwd"`
-	IsSudo      bool              `json:"is_sudo"`
-	IsScript    bool              `json:"is_script"`
-	Interpreter string            `json:"interpreter"`
-	PID         *int              `json:"pid"`
-	TimeoutSec  int               `json:"timeout_sec"`
-	Error       string            `json:"error"`
-	Result      *models.JobResult `json:"result"`
-	ClientName  string            `json:"client_name"`
+	Command      string            `json:"command"`
+	Cwd          string            `json:"cwd"`
+	IsSudo       bool              `json:"is_sudo"`
+	IsScript     bool              `json:"is_script"`
+	Interpreter  string            `json:"interpreter"`
+	PID          *int              `json:"pid"`
+	TimeoutSec   int               `json:"timeout_sec"`
+	Error        string            `json:"error"`
+	Result       *models.JobResult `json:"result"`
+	ClientName   string            `json:"client_name"`
+	StreamToFile bool              `json:"stream_to_file"`
 }
 
 func (d *JobDetails) Scan(value interface{}) error {
@@ -281,6 +307,7 @@ func (j *jobSqlite) convert() *models.Job {
 		res.Cwd = j.Details.Cwd
 		res.IsSudo = j.Details.IsSudo
 		res.IsScript = j.Details.IsScript
+		res.StreamToFile = j.Details.StreamToFile
 	}
 	if j.FinishedAt.Valid {
 		res.FinishedAt = &j.FinishedAt.Time
@@ -299,16 +326,17 @@ func convertToSqlite(job *models.Job) *jobSqlite {
 		CreatedBy: job.CreatedBy,
 		ClientID:  job.ClientID,
 		Details: &JobDetails{
-			Command:     job.Command,
-			Interpreter: job.Interpreter,
-			PID:         job.PID,
-			TimeoutSec:  job.TimeoutSec,
-			Result:      job.Result,
-			Error:       job.Error,
-			ClientName:  job.ClientName,
-			Cwd:         job.Cwd,
-			IsSudo:      job.IsSudo,
-			IsScript:    job.IsScript,
+			Command:      job.Command,
+			Interpreter:  job.Interpreter,
+			PID:          job.PID,
+			TimeoutSec:   job.TimeoutSec,
+			Result:       job.Result,
+			Error:        job.Error,
+			ClientName:   job.ClientName,
+			Cwd:          job.Cwd,
+			IsSudo:       job.IsSudo,
+			IsScript:     job.IsScript,
+			StreamToFile: job.StreamToFile,
 		},
 	}
 	if job.MultiJobID != nil {
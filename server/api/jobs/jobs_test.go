@@ -91,6 +91,24 @@ func TestJobsSqliteProvider(t *testing.T) {
 	assert.ElementsMatch(t, []*models.Job{job1, job2}, gotJSc1)
 }
 
+func TestCountByStatus(t *testing.T) {
+	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewSqliteProvider(jobsDB, testLog)
+	defer p.Close()
+
+	require.NoError(t, p.SaveJob(jb.New(t).Status(models.JobStatusRunning).Build()))
+	require.NoError(t, p.SaveJob(jb.New(t).Status(models.JobStatusSuccessful).Build()))
+	require.NoError(t, p.SaveJob(jb.New(t).Status(models.JobStatusSuccessful).Build()))
+
+	counts, err := p.CountByStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		models.JobStatusRunning:    1,
+		models.JobStatusSuccessful: 2,
+	}, counts)
+}
+
 func TestCreateJob(t *testing.T) {
 	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
 	require.NoError(t, err)
@@ -17,6 +17,7 @@ type MultiJobRequest struct {
 	TimeoutSec          int                   `json:"timeout_sec"`
 	ExecuteConcurrently bool                  `json:"execute_concurrently"`
 	AbortOnError        *bool                 `json:"abort_on_error"` // pointer is used because it's default value is true. Otherwise it would be more difficult to check whether this field is missing or not
+	StreamToFile        bool                  `json:"stream_to_file"`
 
 	Username       string               `json:"-"`
 	IsScript       bool                 `json:"-"`
@@ -32,7 +32,7 @@ var MultiJobSupportedSorts = map[string]bool{
 // GetMultiJob returns a multi-client job with fetched all clients' jobs.
 func (p *SqliteProvider) GetMultiJob(ctx context.Context, jid string) (*models.MultiJob, error) {
 	res := &multiJobSqlite{}
-	err := p.db.Get(res, "SELECT * FROM multi_jobs WHERE jid=?", jid)
+	err := p.db.Get(res, p.converter.Rebind("SELECT * FROM multi_jobs WHERE jid=?"), jid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -67,7 +67,7 @@ func (p *SqliteProvider) GetMultiJobSummaries(ctx context.Context, options *quer
 	if len(options.Sorts) == 0 {
 		options.Sorts = []query.SortOption{
 			{
-				Column: "DATETIME(started_at)",
+				Column: p.converter.DateTime("started_at"),
 				IsASC:  false,
 			},
 			{
@@ -79,7 +79,7 @@ func (p *SqliteProvider) GetMultiJobSummaries(ctx context.Context, options *quer
 	q := "SELECT jid, started_at, created_by, schedule_id FROM multi_jobs"
 	q, params := p.converter.ConvertListOptionsToQuery(options, q)
 
-	err := p.db.SelectContext(ctx, &res, q, params...)
+	err := p.db.SelectContext(ctx, &res, p.converter.Rebind(q), params...)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +95,7 @@ func (p *SqliteProvider) CountMultiJobs(ctx context.Context, options *query.List
 	q := "SELECT count(*) FROM multi_jobs"
 	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, q)
 
-	err := p.db.GetContext(ctx, &result, q, params...)
+	err := p.db.GetContext(ctx, &result, p.converter.Rebind(q), params...)
 	if err != nil {
 		return 0, err
 	}
@@ -105,11 +105,15 @@ func (p *SqliteProvider) CountMultiJobs(ctx context.Context, options *query.List
 // SaveMultiJob creates a new or updates an existing multi-client job (without child jobs).
 func (p *SqliteProvider) SaveMultiJob(job *models.MultiJob) error {
 	_, err := p.db.NamedExec(`
-INSERT OR REPLACE INTO multi_jobs (
+INSERT INTO multi_jobs (
 	jid, started_at, created_by, schedule_id, details
 ) VALUES (
 	:jid, :started_at, :created_by, :schedule_id, :details
-)`,
+) ON CONFLICT (jid) DO UPDATE SET
+	started_at = EXCLUDED.started_at,
+	created_by = EXCLUDED.created_by,
+	schedule_id = EXCLUDED.schedule_id,
+	details = EXCLUDED.details`,
 		convertMultiJobToSqlite(job))
 	if err == nil {
 		p.log.Debugf("Multi-client Job saved successfully: %v", *job)
@@ -130,16 +134,17 @@ type multiJobSummarySqlite struct {
 }
 
 type multiJobDetailSqlite struct {
-	ClientIDs   []string              `json:"client_ids"`
-	GroupIDs    []string              `json:"group_ids"`
-	ClientTags  *models.JobClientTags `json:"tags"`
-	Command     string                `json:"command"`
-	Interpreter string                `json:"interpreter"`
-	Cwd         string                `json:"cwd"`
-	IsSudo      bool                  `json:"is_sudo"`
-	TimeoutSec  int                   `json:"timeout_sec"`
-	Concurrent  bool                  `json:"concurrent"`
-	AbortOnErr  bool                  `json:"abort_on_err"`
+	ClientIDs    []string              `json:"client_ids"`
+	GroupIDs     []string              `json:"group_ids"`
+	ClientTags   *models.JobClientTags `json:"tags"`
+	Command      string                `json:"command"`
+	Interpreter  string                `json:"interpreter"`
+	Cwd          string                `json:"cwd"`
+	IsSudo       bool                  `json:"is_sudo"`
+	TimeoutSec   int                   `json:"timeout_sec"`
+	Concurrent   bool                  `json:"concurrent"`
+	AbortOnErr   bool                  `json:"abort_on_err"`
+	StreamToFile bool                  `json:"stream_to_file"`
 }
 
 func (d *multiJobDetailSqlite) Scan(value interface{}) error {
@@ -200,6 +205,7 @@ func (j *multiJobSqlite) convert() *models.MultiJob {
 		TimeoutSec:      d.TimeoutSec,
 		Concurrent:      d.Concurrent,
 		AbortOnErr:      d.AbortOnErr,
+		StreamToFile:    d.StreamToFile,
 	}
 }
 
@@ -212,16 +218,17 @@ func convertMultiJobToSqlite(job *models.MultiJob) *multiJobSqlite {
 			ScheduleID: job.ScheduleID,
 		},
 		Details: &multiJobDetailSqlite{
-			ClientIDs:   job.ClientIDs,
-			GroupIDs:    job.GroupIDs,
-			ClientTags:  job.ClientTags,
-			Command:     job.Command,
-			Interpreter: job.Interpreter,
-			Cwd:         job.Cwd,
-			IsSudo:      job.IsSudo,
-			TimeoutSec:  job.TimeoutSec,
-			Concurrent:  job.Concurrent,
-			AbortOnErr:  job.AbortOnErr,
+			ClientIDs:    job.ClientIDs,
+			GroupIDs:     job.GroupIDs,
+			ClientTags:   job.ClientTags,
+			Command:      job.Command,
+			Interpreter:  job.Interpreter,
+			Cwd:          job.Cwd,
+			IsSudo:       job.IsSudo,
+			TimeoutSec:   job.TimeoutSec,
+			Concurrent:   job.Concurrent,
+			AbortOnErr:   job.AbortOnErr,
+			StreamToFile: job.StreamToFile,
 		},
 	}
 }
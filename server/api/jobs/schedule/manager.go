@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -68,6 +69,13 @@ type Manager struct {
 	cron      Cron
 
 	runRemoteCmdTimeoutSec int
+
+	// isLeader is nil unless the server runs in HA mode, then only the leader runs the schedules
+	isLeader func() bool
+
+	// specs keeps the cron spec of each schedule added to cron, used to sync schedules changed on other nodes
+	specsMu sync.Mutex
+	specs   map[string]string
 }
 
 func New(ctx context.Context, logger *logger.Logger, db *sqlx.DB, jobRunner JobRunner, runRemoteCmdTimeoutSec int) (*Manager, error) {
@@ -94,6 +102,7 @@ func NewManager(jobRunner JobRunner, db *sqlx.DB, logger *logger.Logger, runRemo
 		jobRunner: jobRunner,
 		provider:  newSQLiteProvider(db),
 		cron:      newCron(),
+		specs:     make(map[string]string),
 
 		runRemoteCmdTimeoutSec: runRemoteCmdTimeoutSec,
 	}
@@ -156,7 +165,7 @@ func (m *Manager) Create(ctx context.Context, s *Schedule, user string) (*Schedu
 	s.CreatedAt = time.Now()
 	s.CreatedBy = user
 
-	err = m.validate(s)
+	err = m.Validate(s)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +186,7 @@ func (m *Manager) Create(ctx context.Context, s *Schedule, user string) (*Schedu
 func (m *Manager) Update(ctx context.Context, id string, s *Schedule) (*Schedule, error) {
 	s.ID = id
 
-	err := m.validate(s)
+	err := m.Validate(s)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +196,7 @@ func (m *Manager) Update(ctx context.Context, id string, s *Schedule) (*Schedule
 		return nil, err
 	}
 
-	m.cron.Remove(s.ID)
+	m.removeCron(s.ID)
 	err = m.addCron(s)
 	if err != nil {
 		return nil, err
@@ -207,11 +216,52 @@ func (m *Manager) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	m.cron.Remove(id)
+	m.removeCron(id)
 	return nil
 }
 
-func (m *Manager) validate(s *Schedule) error {
+// SetLeaderCheck makes the manager skip the schedules while isLeader returns false.
+func (m *Manager) SetLeaderCheck(isLeader func() bool) {
+	m.isLeader = isLeader
+}
+
+// Sync updates the cron entries to the schedules stored in the DB, which might have been changed by other nodes
+// sharing the DB.
+func (m *Manager) Sync(ctx context.Context) error {
+	stored, err := m.provider.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	m.specsMu.Lock()
+	known := make(map[string]string, len(m.specs))
+	for id, spec := range m.specs {
+		known[id] = spec
+	}
+	m.specsMu.Unlock()
+
+	for _, s := range stored {
+		spec, ok := known[s.ID]
+		delete(known, s.ID)
+		if ok && spec == s.Schedule {
+			continue
+		}
+		m.removeCron(s.ID)
+		if err := m.addCron(s); err != nil {
+			m.Errorf("Could not add schedule %s: %v", s.ID, err)
+		}
+	}
+
+	// the remaining ones were deleted
+	for id := range known {
+		m.removeCron(id)
+	}
+
+	return nil
+}
+
+// Validate checks the schedule before it is stored
+func (m *Manager) Validate(s *Schedule) error {
 	if s.Type != TypeCommand && s.Type != TypeScript {
 		return &errors.APIError{
 			Message:    "Invalid type.",
@@ -269,10 +319,31 @@ func (m *Manager) validate(s *Schedule) error {
 }
 
 func (m *Manager) addCron(s *Schedule) error {
-	return m.cron.Add(s.ID, s.Schedule, m.run)
+	err := m.cron.Add(s.ID, s.Schedule, m.run)
+	if err != nil {
+		return err
+	}
+
+	m.specsMu.Lock()
+	defer m.specsMu.Unlock()
+	m.specs[s.ID] = s.Schedule
+
+	return nil
+}
+
+func (m *Manager) removeCron(id string) {
+	m.cron.Remove(id)
+
+	m.specsMu.Lock()
+	defer m.specsMu.Unlock()
+	delete(m.specs, id)
 }
 
 func (m *Manager) run(ctx context.Context, id string) {
+	if m.isLeader != nil && !m.isLeader() {
+		return
+	}
+
 	schedule, err := m.provider.Get(ctx, id)
 	if err != nil {
 		m.Errorf("Could not get schedule %s: %v", id, err)
@@ -316,6 +387,7 @@ func (m *Manager) run(ctx context.Context, id string) {
 		ExecuteConcurrently: schedule.Details.ExecuteConcurrently,
 		AbortOnError:        schedule.Details.AbortOnError,
 		IsScript:            schedule.Type == TypeScript,
+		StreamToFile:        schedule.Details.StreamToFile,
 	})
 	if err != nil {
 		m.Errorf("Error running schedule %s: %v", id, err)
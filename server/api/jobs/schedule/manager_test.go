@@ -1,11 +1,35 @@
 package schedule
 
 import (
+	"context"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/openrport/openrport/db/migration/jobs"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/share/logger"
 )
 
+type fakeCron struct {
+	entries map[string]string
+}
+
+func (c *fakeCron) Validate(string) error {
+	return nil
+}
+
+func (c *fakeCron) Add(id string, schedule string, f func(context.Context, string)) error {
+	c.entries[id] = schedule
+	return nil
+}
+
+func (c *fakeCron) Remove(id string) {
+	delete(c.entries, id)
+}
+
 func TestValidate(t *testing.T) {
 	manager := &Manager{
 		cron: newCron(),
@@ -110,7 +134,7 @@ func TestValidate(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Parallel()
 
-			err := manager.validate(tc.Schedule)
+			err := manager.Validate(tc.Schedule)
 
 			if tc.ExpectedError == "" {
 				assert.NoError(t, err)
@@ -120,3 +144,38 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestSync(t *testing.T) {
+	db, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	cron := &fakeCron{entries: make(map[string]string)}
+	manager := NewManager(nil, db, logger.NewLogger("schedule-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug), 60)
+	manager.cron = cron
+
+	// schedule 1 is known already with an outdated spec, schedule 3 was deleted on another node
+	require.NoError(t, manager.addCron(&Schedule{Base: Base{ID: "1", Schedule: "0 * * * *"}}))
+	require.NoError(t, manager.addCron(&Schedule{Base: Base{ID: "3", Schedule: "0 * * * *"}}))
+	for _, s := range testData {
+		require.NoError(t, manager.provider.Insert(ctx, s))
+	}
+
+	require.NoError(t, manager.Sync(ctx))
+
+	expected := map[string]string{
+		"1": "* * * * *",
+		"2": "*/5 * * * *",
+	}
+	assert.Equal(t, expected, cron.entries)
+	assert.Equal(t, expected, manager.specs)
+}
+
+func TestRunSkippedIfNotLeader(t *testing.T) {
+	manager := &Manager{}
+	manager.SetLeaderCheck(func() bool { return false })
+
+	// provider and job runner are nil, so it would panic if the schedule was run
+	manager.run(context.Background(), "1")
+}
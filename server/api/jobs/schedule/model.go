@@ -78,6 +78,7 @@ type Details struct {
 	ExecuteConcurrently bool                  `json:"execute_concurrently" db:"-"`
 	AbortOnError        *bool                 `json:"abort_on_error" db:"-"`
 	Overlaps            bool                  `json:"overlaps" db:"-"`
+	StreamToFile        bool                  `json:"stream_to_file" db:"-"`
 }
 
 func (d *Details) Scan(value interface{}) error {
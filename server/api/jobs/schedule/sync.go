@@ -0,0 +1,16 @@
+package schedule
+
+import "context"
+
+// SyncTask picks up the schedules created, changed or deleted on other nodes sharing the DB.
+type SyncTask struct {
+	manager *Manager
+}
+
+func NewSyncTask(manager *Manager) *SyncTask {
+	return &SyncTask{manager: manager}
+}
+
+func (t *SyncTask) Run(ctx context.Context) error {
+	return t.manager.Sync(ctx)
+}
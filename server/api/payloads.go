@@ -86,14 +86,15 @@ func newAPIErrorsPayload(errors errors2.APIErrors) ErrorPayload {
 }
 
 type ExecuteInput struct {
-	Command     string `json:"command"`
-	Script      string `json:"script"`
-	Interpreter string `json:"interpreter"`
-	Cwd         string `json:"cwd"`
-	IsSudo      bool   `json:"is_sudo"`
-	TimeoutSec  int    `json:"timeout_sec"`
-	ClientID    string
-	IsScript    bool
+	Command      string `json:"command"`
+	Script       string `json:"script"`
+	Interpreter  string `json:"interpreter"`
+	Cwd          string `json:"cwd"`
+	IsSudo       bool   `json:"is_sudo"`
+	TimeoutSec   int    `json:"timeout_sec"`
+	StreamToFile bool   `json:"stream_to_file"`
+	ClientID     string
+	IsScript     bool
 }
 
 type Meta struct {
@@ -10,10 +10,12 @@ import (
 
 	"github.com/openrport/openrport/db/migration/api_sessions"
 	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/share/query"
 )
 
 type SqliteProvider struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	converter *query.SQLConverter
 }
 
 func NewSqliteProvider(dbPath string, dataSourceOptions sqlite.DataSourceOptions) (*SqliteProvider, error) {
@@ -22,14 +24,22 @@ func NewSqliteProvider(dbPath string, dataSourceOptions sqlite.DataSourceOptions
 		return nil, fmt.Errorf("unable to create api session DB instance: %w", err)
 	}
 
-	return &SqliteProvider{db: db}, nil
+	return NewDBProvider(db), nil
+}
+
+// NewDBProvider returns a provider using an already opened and migrated DB.
+func NewDBProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
 }
 
 func (p *SqliteProvider) GetAll(ctx context.Context) ([]APISession, error) {
 	var result []APISession
 	err := p.db.SelectContext(
 		ctx, &result,
-		"SELECT * FROM api_sessions WHERE DATETIME(expires_at) >= DATETIME(?)",
+		p.converter.Rebind("SELECT * FROM api_sessions WHERE "+p.converter.DateTime("expires_at")+" >= "+p.converter.DateTime("?")),
 		time.Now(),
 	)
 	if err != nil {
@@ -42,7 +52,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context) ([]APISession, error) {
 func (p *SqliteProvider) Get(ctx context.Context, sessionID int64) (found bool, sessionInfo APISession, err error) {
 	err = p.db.GetContext(ctx,
 		&sessionInfo,
-		"SELECT * FROM api_sessions WHERE session_id = ?",
+		p.converter.Rebind("SELECT * FROM api_sessions WHERE session_id = ?"),
 		sessionID,
 	)
 	if err != nil {
@@ -71,20 +81,20 @@ func (p *SqliteProvider) Save(ctx context.Context, session APISession) (sessionI
 }
 
 func (p *SqliteProvider) add(ctx context.Context, session APISession) (sessionID int64, err error) {
-	result, err := p.db.NamedExecContext(
-		ctx,
+	q, args, err := p.db.BindNamed(
 		"INSERT INTO"+
 			" api_sessions (expires_at, username, last_access_at, user_agent, ip_address)"+
-			" VALUES (:expires_at, :username, :last_access_at, :user_agent, :ip_address)",
+			" VALUES (:expires_at, :username, :last_access_at, :user_agent, :ip_address)"+
+			" RETURNING session_id",
 		session,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("unable to create api session: %w", err)
 	}
 
-	sessionID, err = result.LastInsertId()
+	err = p.db.GetContext(ctx, &sessionID, q, args...)
 	if err != nil {
-		return 0, fmt.Errorf("unable to get api session ID for save: %w", err)
+		return 0, fmt.Errorf("unable to create api session: %w", err)
 	}
 
 	return sessionID, nil
@@ -110,7 +120,7 @@ func (p *SqliteProvider) update(ctx context.Context, session APISession) (sessio
 func (p *SqliteProvider) Delete(ctx context.Context, sessionID int64) error {
 	_, err := p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_sessions WHERE session_id = ?",
+		p.converter.Rebind("DELETE FROM api_sessions WHERE session_id = ?"),
 		sessionID,
 	)
 	if err != nil {
@@ -123,7 +133,7 @@ func (p *SqliteProvider) Delete(ctx context.Context, sessionID int64) error {
 func (p *SqliteProvider) DeleteExpired(ctx context.Context) error {
 	_, err := p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_sessions WHERE DATETIME(expires_at) <= DATETIME(?)",
+		p.converter.Rebind("DELETE FROM api_sessions WHERE "+p.converter.DateTime("expires_at")+" <= "+p.converter.DateTime("?")),
 		time.Now(),
 	)
 	if err != nil {
@@ -140,7 +150,7 @@ func (p *SqliteProvider) Close() error {
 func (p *SqliteProvider) DeleteAllByUser(ctx context.Context, username string) (err error) {
 	_, err = p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_sessions WHERE username = ?",
+		p.converter.Rebind("DELETE FROM api_sessions WHERE username = ?"),
 		username,
 	)
 	if err != nil {
@@ -153,7 +163,7 @@ func (p *SqliteProvider) DeleteAllByUser(ctx context.Context, username string) (
 func (p *SqliteProvider) DeleteByID(ctx context.Context, username string, sessionID int64) (err error) {
 	_, err = p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_sessions WHERE username = ? AND session_id = ?",
+		p.converter.Rebind("DELETE FROM api_sessions WHERE username = ? AND session_id = ?"),
 		username,
 		sessionID,
 	)
@@ -10,19 +10,23 @@ import (
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/share/enums"
 	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/query"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type UserDatabase struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	converter *query.SQLConverter
 
-	usersTableName        string
-	groupsTableName       string
-	groupDetailsTableName string
+	usersTableName  string
+	groupsTableName string
+	groupDetails    *GroupDetailsTable
 
 	twoFAOn     bool
 	totPOn      bool
+	webAuthnOn  bool
+	scimOn      bool
 	plusEnabled bool
 	logger      *logger.Logger
 }
@@ -30,24 +34,33 @@ type UserDatabase struct {
 func NewUserDatabase(
 	DB *sqlx.DB,
 	usersTableName, groupsTableName, groupDetailsTableName string,
-	twoFAOn, totPOn bool,
+	twoFAOn, totPOn, webAuthnOn, scimOn bool,
 	plusEnabled bool, logger *logger.Logger,
 ) (*UserDatabase, error) {
 	d := &UserDatabase{
-		db: DB,
+		db:        DB,
+		converter: query.NewSQLConverter(DB.DriverName()),
 
-		usersTableName:        usersTableName,
-		groupsTableName:       groupsTableName,
-		groupDetailsTableName: groupDetailsTableName,
+		usersTableName:  usersTableName,
+		groupsTableName: groupsTableName,
 
 		twoFAOn:     twoFAOn,
 		totPOn:      totPOn,
+		webAuthnOn:  webAuthnOn,
+		scimOn:      scimOn,
 		plusEnabled: plusEnabled,
 		logger:      logger,
 	}
 	if err := d.checkDatabaseTables(); err != nil {
 		return nil, err
 	}
+	if groupDetailsTableName != "" {
+		groupDetails, err := NewGroupDetailsTable(DB, groupDetailsTableName, plusEnabled)
+		if err != nil {
+			return nil, err
+		}
+		d.groupDetails = groupDetails
+	}
 	return d, nil
 }
 
@@ -59,46 +72,40 @@ func (d *UserDatabase) getSelectClause() string {
 	if d.totPOn {
 		s += ", totp_secret"
 	}
+	if d.webAuthnOn {
+		s += ", webauthn_credentials"
+	}
+	if d.scimOn {
+		s += ", disabled"
+	}
 	return s
 }
 
 // checkDatabaseTables @todo use context for all db operations
 func (d *UserDatabase) checkDatabaseTables() error {
-	_, err := d.db.Exec(fmt.Sprintf("SELECT %s FROM `%s` LIMIT 0", d.getSelectClause(), d.usersTableName))
+	_, err := d.db.Exec(d.converter.Rebind(fmt.Sprintf("SELECT %s FROM `%s` LIMIT 0", d.getSelectClause(), d.usersTableName)))
 	if err != nil {
-		err = fmt.Errorf("%v, if you have 2fa enabled please check additional column requirements at https://oss.rport.io/docs/no02-api-auth.html#database", err)
+		err = fmt.Errorf("%v, if you have 2fa, webauthn or scim enabled please check additional column requirements at https://oss.rport.io/docs/no02-api-auth.html#database", err)
 		return err
 	}
-	_, err = d.db.Exec(fmt.Sprintf("SELECT username, `group` FROM `%s` LIMIT 0", d.groupsTableName))
+	_, err = d.db.Exec(d.converter.Rebind(fmt.Sprintf("SELECT username, `group` FROM `%s` LIMIT 0", d.groupsTableName)))
 	if err != nil {
 		return err
 	}
-	if d.groupDetailsTableName != "" {
-		extPermSelect := ""
-		if d.plusEnabled {
-			// when plus is enabled, we need to select the other fields as well
-			extPermSelect = ", tunnels_restricted, commands_restricted"
-		}
-		_, err = d.db.Exec(fmt.Sprintf("SELECT name, permissions %s FROM `%s` LIMIT 0", extPermSelect, d.groupDetailsTableName))
-		if err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
 // GetByUsername @todo use context for all db operations
 func (d *UserDatabase) GetByUsername(username string) (*User, error) {
 	user := &User{}
-	err := d.db.Get(user, fmt.Sprintf("SELECT %s FROM `%s` WHERE username = ? LIMIT 1", d.getSelectClause(), d.usersTableName), username)
+	err := d.db.Get(user, d.converter.Rebind(fmt.Sprintf("SELECT %s FROM `%s` WHERE username = ? LIMIT 1", d.getSelectClause(), d.usersTableName)), username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	err = d.db.Select(&user.Groups, fmt.Sprintf("SELECT DISTINCT(`group`) FROM `%s` WHERE username = ?", d.groupsTableName), username)
+	err = d.db.Select(&user.Groups, d.converter.Rebind(fmt.Sprintf("SELECT DISTINCT(`group`) FROM `%s` WHERE username = ?", d.groupsTableName)), username)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -109,7 +116,7 @@ func (d *UserDatabase) GetByUsername(username string) (*User, error) {
 // GetAll @todo use context for all db operations
 func (d *UserDatabase) GetAll() ([]*User, error) {
 	var usrs []*User
-	err := d.db.Select(&usrs, fmt.Sprintf("SELECT %s FROM `%s` ORDER BY username", d.getSelectClause(), d.usersTableName))
+	err := d.db.Select(&usrs, d.converter.Rebind(fmt.Sprintf("SELECT %s FROM `%s` ORDER BY username", d.getSelectClause(), d.usersTableName)))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -121,7 +128,7 @@ func (d *UserDatabase) GetAll() ([]*User, error) {
 		Username string `db:"username"`
 		Group    string `db:"group"`
 	}
-	err = d.db.Select(&groups, fmt.Sprintf("SELECT `username`, `group` FROM `%s` ORDER BY `group`", d.groupsTableName))
+	err = d.db.Select(&groups, d.converter.Rebind(fmt.Sprintf("SELECT `username`, `group` FROM `%s` ORDER BY `group`", d.groupsTableName)))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return usrs, nil
@@ -142,15 +149,16 @@ func (d *UserDatabase) GetAll() ([]*User, error) {
 func (d *UserDatabase) ListGroups() ([]Group, error) {
 	var groups []Group
 
-	if d.groupDetailsTableName != "" {
-		err := d.db.Select(&groups, fmt.Sprintf("SELECT * FROM `%s` ORDER BY `name`", d.groupDetailsTableName))
-		if err != nil && err != sql.ErrNoRows {
+	if d.groupDetails != nil {
+		var err error
+		groups, err = d.groupDetails.List()
+		if err != nil {
 			return nil, err
 		}
 	}
 
 	var userGroups []string
-	err := d.db.Select(&userGroups, fmt.Sprintf("SELECT DISTINCT `group` FROM `%s` ORDER BY `group`", d.groupsTableName))
+	err := d.db.Select(&userGroups, d.converter.Rebind(fmt.Sprintf("SELECT DISTINCT `group` FROM `%s` ORDER BY `group`", d.groupsTableName)))
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -172,66 +180,26 @@ func (d *UserDatabase) ListGroups() ([]Group, error) {
 }
 
 func (d *UserDatabase) GetGroup(name string) (Group, error) {
-	if d.groupDetailsTableName == "" {
-		return NewGroup(name, nil, nil), nil
-	}
-
-	group := Group{}
-	err := d.db.Get(&group, fmt.Sprintf("SELECT * FROM `%s` WHERE name = ? LIMIT 1", d.groupDetailsTableName), name)
-	if err == sql.ErrNoRows {
+	if d.groupDetails == nil {
 		return NewGroup(name, nil, nil), nil
-	} else if err != nil {
-		return Group{}, err
 	}
 
-	return group, nil
+	return d.groupDetails.Get(name)
 }
 
 func (d *UserDatabase) UpdateGroup(name string, group Group) error {
-	if d.groupDetailsTableName == "" {
+	if d.groupDetails == nil {
 		return errors2.APIError{
 			Message:    "User group details table must be configured for this operation.",
 			HTTPStatus: http.StatusBadRequest,
 		}
 	}
 
-	// We rely on a unique index. Let the database decide, if INSERT or UPDATE is needed.
-	var err error
-	group.Name = name
-
-	qt1 := ""
-	qt2 := ""
-	if group.TunnelsRestricted != nil {
-		qt1 = ", tunnels_restricted"
-		qt2 = ", :tunnels_restricted"
-	}
-
-	qc1 := ""
-	qc2 := ""
-	if group.CommandsRestricted != nil {
-		qc1 = ", commands_restricted"
-		qc2 = ", :commands_restricted"
-	}
-	// compose the query (assume the extended fields are present)
-	// We rely on a unique index. Let the database decide, if INSERT or UPDATE is needed.
-	qb := fmt.Sprintf("REPLACE INTO `%s` (name, permissions%s%s) VALUES (:name, :permissions%s%s)", d.groupDetailsTableName, qt1, qc1, qt2, qc2)
-
-	_, err = d.db.NamedExec(qb, group)
-
-	if err != nil {
-		if d.plusEnabled {
-			return err
-		}
-		_, err = d.db.NamedExec(qb+"(name, permissions) VALUES (:name, :permissions)", group) // ignore the extended fields
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return d.groupDetails.Update(name, group)
 }
 
 func (d *UserDatabase) DeleteGroup(name string) error {
-	if d.groupDetailsTableName == "" {
+	if d.groupDetails == nil {
 		return errors2.APIError{
 			Message:    "User group details table must be configured for this operation.",
 			HTTPStatus: http.StatusBadRequest,
@@ -243,13 +211,13 @@ func (d *UserDatabase) DeleteGroup(name string) error {
 		return err
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `group` = ?", d.groupsTableName), name)
+	_, err = tx.Exec(d.converter.Rebind(fmt.Sprintf("DELETE FROM `%s` WHERE `group` = ?", d.groupsTableName)), name)
 	if err != nil {
 		d.handleRollback(tx)
 		return err
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `name` = ?", d.groupDetailsTableName), name)
+	err = d.groupDetails.Delete(tx, name)
 	if err != nil {
 		d.handleRollback(tx)
 		return err
@@ -291,13 +259,23 @@ func (d *UserDatabase) Add(usr *User) error {
 		params = append(params, usr.TotP)
 	}
 
+	if d.webAuthnOn {
+		columns = append(columns, "`webauthn_credentials`")
+		params = append(params, usr.WebAuthnCredentials)
+	}
+
+	if d.scimOn {
+		columns = append(columns, "`disabled`")
+		params = append(params, usr.IsDisabled())
+	}
+
 	_, err = tx.Exec(
-		fmt.Sprintf(
+		d.converter.Rebind(fmt.Sprintf(
 			"INSERT INTO `%s` (%s) VALUES (%s)",
 			d.usersTableName,
 			strings.Join(columns, ", "),
 			strings.TrimRight(strings.Repeat("?,", len(params)), ","),
-		),
+		)),
 		params...,
 	)
 
@@ -308,7 +286,7 @@ func (d *UserDatabase) Add(usr *User) error {
 
 	for i := range usr.Groups {
 		_, err := tx.Exec(
-			fmt.Sprintf("INSERT INTO `%s` (`username`, `group`) VALUES (?, ?)", d.groupsTableName),
+			d.converter.Rebind(fmt.Sprintf("INSERT INTO `%s` (`username`, `group`) VALUES (?, ?)", d.groupsTableName)),
 			usr.Username,
 			usr.Groups[i],
 		)
@@ -354,6 +332,16 @@ func (d *UserDatabase) Update(usr *User, usernameToUpdate string) error {
 		params = append(params, usr.TotP)
 	}
 
+	if usr.WebAuthnCredentials != nil {
+		statements = append(statements, "`webauthn_credentials` = ?")
+		params = append(params, usr.WebAuthnCredentials)
+	}
+
+	if d.scimOn && usr.Disabled != nil {
+		statements = append(statements, "`disabled` = ?")
+		params = append(params, usr.Disabled)
+	}
+
 	if usr.Username != "" && usr.Username != usernameToUpdate {
 		statements = append(statements, "`username` = ?")
 		params = append(params, usr.Username)
@@ -365,11 +353,11 @@ func (d *UserDatabase) Update(usr *User, usernameToUpdate string) error {
 	}
 
 	if len(params) > 0 {
-		q := fmt.Sprintf(
+		q := d.converter.Rebind(fmt.Sprintf(
 			"UPDATE `%s` SET %s WHERE username = ?",
 			d.usersTableName,
 			strings.Join(statements, ", "),
-		)
+		))
 		params = append(params, usernameToUpdate)
 		_, err := tx.Exec(q, params...)
 		if err != nil {
@@ -380,7 +368,7 @@ func (d *UserDatabase) Update(usr *User, usernameToUpdate string) error {
 
 	if usr.Username != "" && usernameToUpdate != usr.Username {
 		_, err := tx.Exec(
-			fmt.Sprintf("UPDATE `%s` SET `username` = ? WHERE `username` = ?", d.groupsTableName),
+			d.converter.Rebind(fmt.Sprintf("UPDATE `%s` SET `username` = ? WHERE `username` = ?", d.groupsTableName)),
 			usr.Username,
 			usernameToUpdate,
 		)
@@ -396,7 +384,7 @@ func (d *UserDatabase) Update(usr *User, usernameToUpdate string) error {
 	}
 
 	if usr.Groups != nil {
-		_, err := tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.groupsTableName), groupUserName)
+		_, err := tx.Exec(d.converter.Rebind(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.groupsTableName)), groupUserName)
 		if err != nil {
 			d.handleRollback(tx)
 			return err
@@ -406,7 +394,7 @@ func (d *UserDatabase) Update(usr *User, usernameToUpdate string) error {
 	for i := range usr.Groups {
 		group := usr.Groups[i]
 		_, err := tx.Exec(
-			fmt.Sprintf("INSERT INTO `%s` (`username`, `group`) VALUES (?, ?)", d.groupsTableName),
+			d.converter.Rebind(fmt.Sprintf("INSERT INTO `%s` (`username`, `group`) VALUES (?, ?)", d.groupsTableName)),
 			groupUserName,
 			group,
 		)
@@ -431,13 +419,13 @@ func (d *UserDatabase) Delete(usernameToDelete string) error {
 		return err
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.usersTableName), usernameToDelete)
+	_, err = tx.Exec(d.converter.Rebind(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.usersTableName)), usernameToDelete)
 	if err != nil {
 		d.handleRollback(tx)
 		return err
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.groupsTableName), usernameToDelete)
+	_, err = tx.Exec(d.converter.Rebind(fmt.Sprintf("DELETE FROM `%s` WHERE `username` = ?", d.groupsTableName)), usernameToDelete)
 	if err != nil {
 		d.handleRollback(tx)
 		return err
@@ -450,5 +438,5 @@ func (d UserDatabase) Type() enums.ProviderSource {
 	return enums.ProviderSourceDB
 }
 func (d UserDatabase) SupportsGroupPermissions() bool {
-	return d.groupDetailsTableName != ""
+	return d.groupDetails != nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	extperm "github.com/openrport/openrport/plus/capabilities/extendedpermission"
 	chshare "github.com/openrport/openrport/share/logger"
@@ -111,7 +112,7 @@ func TestNewUserDatabase(t *testing.T) {
 			_, err = db.Exec("CREATE TABLE `invalid_group_details` (name TEXT, other TEXT)")
 			require.NoError(t, err)
 
-			_, err = NewUserDatabase(db, tc.UsersTable, tc.GroupsTable, tc.GroupDetailsTable, tc.twoFAOn, tc.totPOn, false, testLog)
+			_, err = NewUserDatabase(db, tc.UsersTable, tc.GroupsTable, tc.GroupDetailsTable, tc.twoFAOn, tc.totPOn, false, false, false, testLog)
 			if tc.ExpectedError == "" {
 				require.NoError(t, err)
 			} else {
@@ -133,7 +134,7 @@ func TestGetByUsername(t *testing.T) {
 	err = prepareDummyData(db, true, false, false)
 	require.NoError(t, err)
 
-	d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, testLog)
+	d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, false, false, testLog)
 	require.NoError(t, err)
 
 	testCases := []struct {
@@ -196,7 +197,7 @@ func TestGetAll(t *testing.T) {
 	err = prepareDummyData(db, false, true, false)
 	require.NoError(t, err)
 
-	d, err := NewUserDatabase(db, "users", "groups", "", false, true, false, testLog)
+	d, err := NewUserDatabase(db, "users", "groups", "", false, true, false, false, false, testLog)
 	require.NoError(t, err)
 
 	actualUsers, err := d.GetAll()
@@ -268,7 +269,7 @@ func TestListGroups(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, false, false, testLog)
 			require.NoError(t, err)
 
 			actualGroups, err := d.ListGroups()
@@ -324,7 +325,7 @@ func TestListGroupsPlusEnabled(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, true, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, false, true, testLog)
 			require.NoError(t, err)
 
 			actualGroups, err := d.ListGroups()
@@ -374,7 +375,7 @@ func TestGetGroup(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, false, false, testLog)
 			require.NoError(t, err)
 
 			actual, err := d.GetGroup(tc.Group)
@@ -444,7 +445,7 @@ func TestGetGroupPlusEnabled(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, true, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", tc.DetailsTable, false, false, false, false, true, testLog)
 			require.NoError(t, err)
 
 			actual, err := d.GetGroup(tc.Group)
@@ -482,7 +483,7 @@ func TestUpdateGroup(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, false, false, false, testLog)
 			require.NoError(t, err)
 
 			err = d.UpdateGroup(tc.Group.Name, tc.Group)
@@ -589,7 +590,7 @@ func TestUpdateGroupPlusEnabled(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, true, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, false, false, true, testLog)
 			require.NoError(t, err)
 
 			err = d.UpdateGroup(tc.Group.Name, tc.Group)
@@ -617,7 +618,7 @@ func TestDeleteGroup(t *testing.T) {
 	err = prepareDummyData(db, false, false, false)
 	require.NoError(t, err)
 
-	d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	d, err := NewUserDatabase(db, "users", "groups", "group_details", false, false, false, false, false, testLog)
 	require.NoError(t, err)
 
 	err = d.DeleteGroup("group1")
@@ -681,7 +682,7 @@ func TestAdd(t *testing.T) {
 			err = prepareTables(db, false, false, false)
 			require.NoError(t, err)
 
-			d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, false, false, testLog)
 			require.NoError(t, err)
 
 			err = d.Add(testCase.userToChange)
@@ -865,7 +866,7 @@ func TestUpdate(t *testing.T) {
 			err = prepareDummyData(db, false, false, false)
 			require.NoError(t, err)
 
-			d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, testLog)
+			d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, false, false, testLog)
 			require.NoError(t, err)
 
 			testCase := testCases[i]
@@ -890,7 +891,7 @@ func TestDelete(t *testing.T) {
 	err = prepareDummyData(db, false, false, false)
 	require.NoError(t, err)
 
-	d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, testLog)
+	d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, false, false, testLog)
 	require.NoError(t, err)
 
 	err = d.Delete("user1")
@@ -906,6 +907,85 @@ func TestDelete(t *testing.T) {
 	assertGroupTableEquals(t, db, d.groupsTableName, []map[string]interface{}{})
 }
 
+func TestWebAuthnCredentials(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = prepareTables(db, false, false, false)
+	require.NoError(t, err)
+
+	_, err = NewUserDatabase(db, "users", "groups", "", false, false, true, false, false, testLog)
+	require.ErrorContains(t, err, "no such column: webauthn_credentials")
+
+	_, err = db.Exec("ALTER TABLE `users` ADD COLUMN webauthn_credentials TEXT")
+	require.NoError(t, err)
+	d, err := NewUserDatabase(db, "users", "groups", "", false, false, true, false, false, testLog)
+	require.NoError(t, err)
+
+	require.NoError(t, d.Add(&User{Username: "user1", Password: "pass1"}))
+	user, err := d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.Empty(t, user.WebAuthnCredentials)
+
+	creds := WebAuthnCredentials{{
+		ID:        []byte{1, 2, 3},
+		Name:      "key",
+		PublicKey: []byte{4, 5, 6},
+		SignCount: 7,
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	require.NoError(t, d.Update(&User{WebAuthnCredentials: creds}, "user1"))
+	user, err = d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.Equal(t, creds, user.WebAuthnCredentials)
+
+	// other changes keep the credentials
+	require.NoError(t, d.Update(&User{Password: "pass2"}, "user1"))
+	user, err = d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.Equal(t, creds, user.WebAuthnCredentials)
+
+	require.NoError(t, d.Update(&User{WebAuthnCredentials: WebAuthnCredentials{}}, "user1"))
+	user, err = d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.Empty(t, user.WebAuthnCredentials)
+}
+
+func TestDisabledUsers(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = prepareTables(db, false, false, false)
+	require.NoError(t, err)
+
+	_, err = NewUserDatabase(db, "users", "groups", "", false, false, false, true, false, testLog)
+	require.ErrorContains(t, err, "no such column: disabled")
+
+	_, err = db.Exec("ALTER TABLE `users` ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT 0")
+	require.NoError(t, err)
+	d, err := NewUserDatabase(db, "users", "groups", "", false, false, false, true, false, testLog)
+	require.NoError(t, err)
+
+	disabled, enabled := true, false
+	require.NoError(t, d.Add(&User{Username: "user1", Password: "pass1", Disabled: &disabled}))
+	user, err := d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.True(t, user.IsDisabled())
+
+	// other changes keep the status
+	require.NoError(t, d.Update(&User{Password: "pass2"}, "user1"))
+	user, err = d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.True(t, user.IsDisabled())
+
+	require.NoError(t, d.Update(&User{Disabled: &enabled}, "user1"))
+	user, err = d.GetByUsername("user1")
+	require.NoError(t, err)
+	assert.False(t, user.IsDisabled())
+}
+
 func prepareTables(db *sqlx.DB, twoFAOn, totPON bool, plusEnabled bool) error {
 	q := "CREATE TABLE `users` (username TEXT PRIMARY KEY, password TEXT, password_expired BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0%s)"
 	dynamicFieldsQ := ""
@@ -152,6 +152,12 @@ func (fa *FileAdapter) Update(dataToChange *User, usernameToFind string) error {
 	if dataToChange.TotP != "" {
 		users[userFound].TotP = dataToChange.TotP
 	}
+	if dataToChange.WebAuthnCredentials != nil {
+		users[userFound].WebAuthnCredentials = dataToChange.WebAuthnCredentials
+	}
+	if dataToChange.Disabled != nil {
+		users[userFound].Disabled = dataToChange.Disabled
+	}
 
 	err = fa.FileProvider.SaveUsersToFile(users)
 	if err != nil {
@@ -0,0 +1,126 @@
+package users
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
+)
+
+// GroupDetailsTable stores the permissions of user groups in the auth database,
+// it's shared by the providers that keep group memberships elsewhere
+type GroupDetailsTable struct {
+	db          *sqlx.DB
+	converter   *query.SQLConverter
+	tableName   string
+	plusEnabled bool
+}
+
+func NewGroupDetailsTable(db *sqlx.DB, tableName string, plusEnabled bool) (*GroupDetailsTable, error) {
+	t := &GroupDetailsTable{
+		db:          db,
+		converter:   query.NewSQLConverter(db.DriverName()),
+		tableName:   tableName,
+		plusEnabled: plusEnabled,
+	}
+	if err := t.checkTable(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *GroupDetailsTable) checkTable() error {
+	extPermSelect := ""
+	if t.plusEnabled {
+		// when plus is enabled, we need to select the other fields as well
+		extPermSelect = ", tunnels_restricted, commands_restricted"
+	}
+	_, err := t.db.Exec(t.converter.Rebind(fmt.Sprintf("SELECT name, permissions %s FROM `%s` LIMIT 0", extPermSelect, t.tableName)))
+	return err
+}
+
+// List returns all groups having details stored
+func (t *GroupDetailsTable) List() ([]Group, error) {
+	var groups []Group
+	err := t.db.Select(&groups, t.converter.Rebind(fmt.Sprintf("SELECT * FROM `%s` ORDER BY `name`", t.tableName)))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Get returns the group with the given name, a group without stored details has no permissions
+func (t *GroupDetailsTable) Get(name string) (Group, error) {
+	group := Group{}
+	err := t.db.Get(&group, t.converter.Rebind(fmt.Sprintf("SELECT * FROM `%s` WHERE name = ? LIMIT 1", t.tableName)), name)
+	if err == sql.ErrNoRows {
+		return NewGroup(name, nil, nil), nil
+	} else if err != nil {
+		return Group{}, err
+	}
+
+	return group, nil
+}
+
+// Update inserts or replaces the details of the given group
+func (t *GroupDetailsTable) Update(name string, group Group) error {
+	var err error
+	group.Name = name
+
+	qt1 := ""
+	qt2 := ""
+	if group.TunnelsRestricted != nil {
+		qt1 = ", tunnels_restricted"
+		qt2 = ", :tunnels_restricted"
+	}
+
+	qc1 := ""
+	qc2 := ""
+	if group.CommandsRestricted != nil {
+		qc1 = ", commands_restricted"
+		qc2 = ", :commands_restricted"
+	}
+	// compose the query (assume the extended fields are present)
+	// We rely on a unique index. Let the database decide, if INSERT or UPDATE is needed.
+	qb := fmt.Sprintf("REPLACE INTO `%s` (name, permissions%s%s) VALUES (:name, :permissions%s%s)", t.tableName, qt1, qc1, qt2, qc2)
+	if t.db.DriverName() == query.DriverPostgres {
+		// PostgreSQL has no REPLACE, update the given columns on conflict instead
+		qb = fmt.Sprintf(
+			"INSERT INTO `%s` (name, permissions%s%s) VALUES (:name, :permissions%s%s) ON CONFLICT (name) DO UPDATE SET permissions = EXCLUDED.permissions",
+			t.tableName, qt1, qc1, qt2, qc2,
+		)
+		if group.TunnelsRestricted != nil {
+			qb += ", tunnels_restricted = EXCLUDED.tunnels_restricted"
+		}
+		if group.CommandsRestricted != nil {
+			qb += ", commands_restricted = EXCLUDED.commands_restricted"
+		}
+	}
+
+	_, err = t.db.NamedExec(t.converter.Rebind(qb), group)
+
+	if err != nil {
+		if t.plusEnabled {
+			return err
+		}
+		_, err = t.db.NamedExec(qb+"(name, permissions) VALUES (:name, :permissions)", group) // ignore the extended fields
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the details of the given group, using the given transaction if not nil
+func (t *GroupDetailsTable) Delete(tx *sqlx.Tx, name string) error {
+	q := t.converter.Rebind(fmt.Sprintf("DELETE FROM `%s` WHERE `name` = ?", t.tableName))
+	var err error
+	if tx != nil {
+		_, err = tx.Exec(q, name)
+	} else {
+		_, err = t.db.Exec(q, name)
+	}
+	return err
+}
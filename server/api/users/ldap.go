@@ -0,0 +1,449 @@
+package users
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/share/enums"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const ldapPageSize = 500
+
+// PasswordVerifier is implemented by providers which don't store the passwords of users but check them against an external system
+type PasswordVerifier interface {
+	VerifyPassword(username, password string) (bool, error)
+}
+
+// ldapConn is the part of *ldap.Conn used by LDAPProvider
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
+	Close() error
+}
+
+type cachedLDAPUser struct {
+	user      *User
+	expiresAt time.Time
+}
+
+// LDAPProvider authenticates users against an LDAP directory or Active Directory and maps their LDAP groups to rport groups.
+// Users are read-only, the permissions of groups are kept in the group details table if configured.
+type LDAPProvider struct {
+	config       chconfig.LDAPConfig
+	groupDetails *GroupDetailsTable
+	logger       *logger.Logger
+
+	dial func() (ldapConn, error)
+	now  func() time.Time
+
+	byUsername map[string]cachedLDAPUser
+	mu         sync.RWMutex
+}
+
+func NewLDAPProvider(config chconfig.LDAPConfig, groupDetails *GroupDetailsTable, logger *logger.Logger) (*LDAPProvider, error) {
+	tlsConfig, err := newLDAPTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &LDAPProvider{
+		config:       config,
+		groupDetails: groupDetails,
+		logger:       logger,
+		now:          time.Now,
+		byUsername:   make(map[string]cachedLDAPUser),
+	}
+	p.dial = func() (ldapConn, error) {
+		conn, err := ldap.DialURL(config.URL, ldap.DialWithTLSConfig(tlsConfig))
+		if err != nil {
+			return nil, err
+		}
+		if config.StartTLS {
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to start tls: %w", err)
+			}
+		}
+		return conn, nil
+	}
+	return p, nil
+}
+
+func newLDAPTLSConfig(config chconfig.LDAPConfig) (*tls.Config, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: config.InsecureSkipVerify, //nolint:gosec // explicitly enabled by the admin
+		MinVersion:         tls.VersionTLS12,
+	}
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ldap_ca_file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in ldap_ca_file %q", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func (p *LDAPProvider) Type() enums.ProviderSource {
+	return enums.ProviderSourceLDAP
+}
+
+func (p *LDAPProvider) SupportsGroupPermissions() bool {
+	return p.groupDetails != nil
+}
+
+// connect opens a new connection bound with the configured service account, it must be closed by the caller
+func (p *LDAPProvider) connect() (ldapConn, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap server: %w", err)
+	}
+	if p.config.BindDN != "" {
+		if err := conn.Bind(p.config.BindDN, p.config.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to bind to ldap server as %q: %w", p.config.BindDN, err)
+		}
+	}
+	return conn, nil
+}
+
+// GetByUsername returns the user with the given username or nil if not found in the directory
+// or when the user isn't a member of any mapped group. Lookups are cached for the configured TTL.
+func (p *LDAPProvider) GetByUsername(username string) (*User, error) {
+	if username == "" {
+		return nil, nil
+	}
+
+	p.mu.RLock()
+	cached, ok := p.byUsername[username]
+	p.mu.RUnlock()
+	if ok && p.now().Before(cached.expiresAt) {
+		return cached.user, nil
+	}
+
+	conn, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := p.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	var user *User
+	if entry != nil {
+		user, err = p.toUser(conn, entry, username)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.cache(username, user)
+	return user, nil
+}
+
+// GetAll returns all users matching the configured user filter
+func (p *LDAPProvider) GetAll() ([]*User, error) {
+	conn, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := conn.SearchWithPaging(p.userSearchRequest("*", 0), ldapPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ldap users: %w", err)
+	}
+
+	usrs := make([]*User, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		username := entry.GetAttributeValue(p.config.UsernameAttribute)
+		if username == "" {
+			continue
+		}
+		user, err := p.toUser(conn, entry, username)
+		if err != nil {
+			return nil, err
+		}
+		p.cache(username, user)
+		if user != nil {
+			usrs = append(usrs, user)
+		}
+	}
+	return usrs, nil
+}
+
+// VerifyPassword checks the password by binding as the user
+func (p *LDAPProvider) VerifyPassword(username, password string) (bool, error) {
+	// an empty password would result in an unauthenticated bind that always succeeds
+	if username == "" || password == "" {
+		return false, nil
+	}
+
+	conn, err := p.connect()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	entry, err := p.findUser(conn, username)
+	if err != nil || entry == nil {
+		return false, err
+	}
+
+	err = conn.Bind(entry.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to bind to ldap server as %q: %w", entry.DN, err)
+	}
+	return true, nil
+}
+
+func (p *LDAPProvider) ListGroups() ([]Group, error) {
+	var groups []Group
+	if p.groupDetails != nil {
+		var err error
+		groups, err = p.groupDetails.List()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var userGroups []string
+	if len(p.config.GroupMapping) > 0 {
+		for _, g := range p.config.GroupMapping {
+			userGroups = append(userGroups, g)
+		}
+	} else {
+		usrs, err := p.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range usrs {
+			userGroups = append(userGroups, u.Groups...)
+		}
+	}
+	sort.Strings(userGroups)
+
+	for _, ug := range userGroups {
+		found := false
+		for _, g := range groups {
+			if ug == g.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, NewGroup(ug, nil, nil))
+		}
+	}
+
+	return groups, nil
+}
+
+func (p *LDAPProvider) GetGroup(name string) (Group, error) {
+	if p.groupDetails == nil {
+		return NewGroup(name, nil, nil), nil
+	}
+	return p.groupDetails.Get(name)
+}
+
+func (p *LDAPProvider) UpdateGroup(name string, group Group) error {
+	if p.groupDetails == nil {
+		return errors2.APIError{
+			Message:    "User group details table must be configured for this operation.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return p.groupDetails.Update(name, group)
+}
+
+// DeleteGroup removes the group details only, the group memberships are managed in the directory
+func (p *LDAPProvider) DeleteGroup(name string) error {
+	if p.groupDetails == nil {
+		return errors2.APIError{
+			Message:    "User group details table must be configured for this operation.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return p.groupDetails.Delete(nil, name)
+}
+
+func (p *LDAPProvider) Add(*User) error {
+	return errors2.APIError{
+		Message:    "Users are managed in the LDAP directory, this operation is not supported.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) Update(*User, string) error {
+	return errors2.APIError{
+		Message:    "Users are managed in the LDAP directory, this operation is not supported.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) Delete(string) error {
+	return errors2.APIError{
+		Message:    "Users are managed in the LDAP directory, this operation is not supported.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) cache(username string, user *User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byUsername[username] = cachedLDAPUser{
+		user:      user,
+		expiresAt: p.now().Add(p.config.CacheTTL),
+	}
+}
+
+func (p *LDAPProvider) userSearchRequest(filterValue string, sizeLimit int) *ldap.SearchRequest {
+	attributes := []string{p.config.UsernameAttribute, p.config.EmailAttribute}
+	if p.config.GroupBaseDN == "" {
+		attributes = append(attributes, p.config.GroupAttribute)
+	}
+	return ldap.NewSearchRequest(
+		p.config.UserBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, sizeLimit, 0, false,
+		fmt.Sprintf(p.config.UserFilter, filterValue),
+		attributes,
+		nil,
+	)
+}
+
+// findUser returns the directory entry of the given user or nil if not found
+func (p *LDAPProvider) findUser(conn ldapConn, username string) (*ldap.Entry, error) {
+	res, err := conn.Search(p.userSearchRequest(ldap.EscapeFilter(username), 2))
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap user filter matches more than one entry for %q", username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ldap user %q: %w", username, err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("ldap user filter matches more than one entry for %q", username)
+	}
+}
+
+// toUser converts the directory entry to a user, it returns nil if a group mapping is configured but none of the user groups is mapped
+func (p *LDAPProvider) toUser(conn ldapConn, entry *ldap.Entry, username string) (*User, error) {
+	groupDNs, err := p.userGroupDNs(conn, entry)
+	if err != nil {
+		return nil, err
+	}
+	groups := p.mapGroups(groupDNs)
+	if len(p.config.GroupMapping) > 0 && len(groups) == 0 {
+		p.logger.Debugf("ldap user %q is not a member of any mapped group", username)
+		return nil, nil
+	}
+
+	return &User{
+		Username:    username,
+		Groups:      groups,
+		TwoFASendTo: entry.GetAttributeValue(p.config.EmailAttribute),
+	}, nil
+}
+
+func (p *LDAPProvider) userGroupDNs(conn ldapConn, entry *ldap.Entry) ([]string, error) {
+	if p.config.GroupBaseDN == "" {
+		return entry.GetAttributeValues(p.config.GroupAttribute), nil
+	}
+
+	res, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		p.config.GroupBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(p.config.GroupFilter, ldap.EscapeFilter(entry.DN)),
+		[]string{"cn"},
+		nil,
+	), ldapPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ldap groups of %q: %w", entry.DN, err)
+	}
+	dns := make([]string, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		dns = append(dns, e.DN)
+	}
+	return dns, nil
+}
+
+// mapGroups returns the rport groups of the given LDAP group DNs. Without a group mapping the CN of each group is used,
+// otherwise only the groups mapped by their DN or CN are returned.
+func (p *LDAPProvider) mapGroups(groupDNs []string) []string {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, dn := range groupDNs {
+		cn := groupCN(dn)
+		group := cn
+		if len(p.config.GroupMapping) > 0 {
+			group = p.mappedGroup(dn, cn)
+		}
+		if group == "" || seen[group] {
+			continue
+		}
+		seen[group] = true
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// mappedGroup returns the rport group mapped to the LDAP group, a mapping by DN takes precedence over one by CN
+func (p *LDAPProvider) mappedGroup(dn, cn string) string {
+	byCN := ""
+	for ldapGroup, group := range p.config.GroupMapping {
+		if strings.EqualFold(ldapGroup, dn) {
+			return group
+		}
+		if strings.EqualFold(ldapGroup, cn) {
+			byCN = group
+		}
+	}
+	return byCN
+}
+
+// groupCN returns the value of the first CN of the given DN or the DN itself if it has none
+func groupCN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return dn
+	}
+	for _, attr := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") {
+			return attr.Value
+		}
+	}
+	return dn
+}
@@ -0,0 +1,276 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/chconfig"
+)
+
+// fakeLDAP is an in memory directory returning the entries configured for a base DN and filter
+type fakeLDAP struct {
+	passwords map[string]string
+	entries   map[string][]*ldap.Entry
+	dials     int
+	boundAs   string
+}
+
+func (f *fakeLDAP) dial() (ldapConn, error) {
+	f.dials++
+	return f, nil
+}
+
+func (f *fakeLDAP) Bind(username, password string) error {
+	if f.passwords[username] == "" || f.passwords[username] != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, fmt.Errorf("invalid credentials"))
+	}
+	f.boundAs = username
+	return nil
+}
+
+func (f *fakeLDAP) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	return &ldap.SearchResult{Entries: f.entries[req.BaseDN+"|"+req.Filter]}, nil
+}
+
+func (f *fakeLDAP) SearchWithPaging(req *ldap.SearchRequest, _ uint32) (*ldap.SearchResult, error) {
+	return f.Search(req)
+}
+
+func (f *fakeLDAP) Close() error {
+	return nil
+}
+
+func newFakeLDAP() *fakeLDAP {
+	alice := ldap.NewEntry("uid=alice,ou=users,dc=example,dc=com", map[string][]string{
+		"uid":      {"alice"},
+		"mail":     {"alice@example.com"},
+		"memberOf": {"cn=Admins,ou=groups,dc=example,dc=com", "cn=Ops,ou=groups,dc=example,dc=com"},
+	})
+	bob := ldap.NewEntry("uid=bob,ou=users,dc=example,dc=com", map[string][]string{
+		"uid":      {"bob"},
+		"memberOf": {"cn=Sales,ou=groups,dc=example,dc=com"},
+	})
+	return &fakeLDAP{
+		passwords: map[string]string{
+			"cn=admin,dc=example,dc=com":           "admin-pass",
+			"uid=alice,ou=users,dc=example,dc=com": "alice-pass",
+		},
+		entries: map[string][]*ldap.Entry{
+			"ou=users,dc=example,dc=com|(uid=alice)": {alice},
+			"ou=users,dc=example,dc=com|(uid=bob)":   {bob},
+			"ou=users,dc=example,dc=com|(uid=*)":     {alice, bob},
+			"ou=groups,dc=example,dc=com|(member=uid=alice,ou=users,dc=example,dc=com)": {
+				ldap.NewEntry("cn=Ops,ou=groups,dc=example,dc=com", nil),
+			},
+		},
+	}
+}
+
+func newTestLDAPProvider(t *testing.T, fake *fakeLDAP, config chconfig.LDAPConfig, groupDetails *GroupDetailsTable) *LDAPProvider {
+	config.URL = "ldap://ldap.example.com"
+	config.UserBaseDN = "ou=users,dc=example,dc=com"
+	config.BindDN = "cn=admin,dc=example,dc=com"
+	config.BindPassword = "admin-pass"
+	config.UserFilter = chconfig.DefaultLDAPUserFilter
+	config.UsernameAttribute = chconfig.DefaultLDAPUsernameAttribute
+	config.EmailAttribute = chconfig.DefaultLDAPEmailAttribute
+	if config.GroupBaseDN == "" {
+		config.GroupAttribute = chconfig.DefaultLDAPGroupAttribute
+	}
+	config.CacheTTL = time.Minute
+
+	p, err := NewLDAPProvider(config, groupDetails, testLog)
+	require.NoError(t, err)
+	p.dial = fake.dial
+	return p
+}
+
+func TestLDAPProviderGetByUsername(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Config   chconfig.LDAPConfig
+		Username string
+		Expected *User
+	}{
+		{
+			Name:     "group cn",
+			Username: "alice",
+			Expected: &User{Username: "alice", Groups: []string{"Admins", "Ops"}, TwoFASendTo: "alice@example.com"},
+		},
+		{
+			Name: "group mapping",
+			Config: chconfig.LDAPConfig{
+				GroupMapping: map[string]string{
+					"cn=admins,ou=groups,dc=example,dc=com": Administrators,
+					"ops":                                   "operators",
+				},
+			},
+			Username: "alice",
+			Expected: &User{Username: "alice", Groups: []string{Administrators, "operators"}, TwoFASendTo: "alice@example.com"},
+		},
+		{
+			Name: "no mapped group",
+			Config: chconfig.LDAPConfig{
+				GroupMapping: map[string]string{"admins": Administrators},
+			},
+			Username: "bob",
+		},
+		{
+			Name: "group search",
+			Config: chconfig.LDAPConfig{
+				GroupBaseDN: "ou=groups,dc=example,dc=com",
+				GroupFilter: "(member=%s)",
+			},
+			Username: "alice",
+			Expected: &User{Username: "alice", Groups: []string{"Ops"}, TwoFASendTo: "alice@example.com"},
+		},
+		{
+			Name:     "not found",
+			Username: "carol",
+		},
+		{
+			Name:     "filter is escaped",
+			Username: "*",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			fake := newFakeLDAP()
+			p := newTestLDAPProvider(t, fake, tc.Config, nil)
+
+			user, err := p.GetByUsername(tc.Username)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, user)
+			assert.Equal(t, "cn=admin,dc=example,dc=com", fake.boundAs)
+		})
+	}
+}
+
+func TestLDAPProviderCache(t *testing.T) {
+	fake := newFakeLDAP()
+	p := newTestLDAPProvider(t, fake, chconfig.LDAPConfig{}, nil)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	user, err := p.GetByUsername("alice")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	missing, err := p.GetByUsername("carol")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+	assert.Equal(t, 2, fake.dials)
+
+	cached, err := p.GetByUsername("alice")
+	require.NoError(t, err)
+	assert.Same(t, user, cached)
+	_, err = p.GetByUsername("carol")
+	require.NoError(t, err)
+	assert.Equal(t, 2, fake.dials)
+
+	now = now.Add(2 * time.Minute)
+	reloaded, err := p.GetByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, user, reloaded)
+	assert.Equal(t, 3, fake.dials)
+}
+
+func TestLDAPProviderVerifyPassword(t *testing.T) {
+	fake := newFakeLDAP()
+	p := newTestLDAPProvider(t, fake, chconfig.LDAPConfig{}, nil)
+
+	ok, err := p.VerifyPassword("alice", "alice-pass")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "uid=alice,ou=users,dc=example,dc=com", fake.boundAs)
+
+	ok, err = p.VerifyPassword("alice", "wrong")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = p.VerifyPassword("alice", "")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = p.VerifyPassword("carol", "alice-pass")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var _ PasswordVerifier = p
+}
+
+func TestLDAPProviderGetAll(t *testing.T) {
+	fake := newFakeLDAP()
+	p := newTestLDAPProvider(t, fake, chconfig.LDAPConfig{
+		GroupMapping: map[string]string{"admins": Administrators},
+	}, nil)
+
+	usrs, err := p.GetAll()
+	require.NoError(t, err)
+	assert.Equal(t, []*User{
+		{Username: "alice", Groups: []string{Administrators}, TwoFASendTo: "alice@example.com"},
+	}, usrs)
+
+	groups, err := p.ListGroups()
+	require.NoError(t, err)
+	assert.Equal(t, []Group{AdministratorsGroup}, groups)
+}
+
+func TestLDAPProviderGroups(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, prepareTables(db, false, false, false))
+
+	groupDetails, err := NewGroupDetailsTable(db, "group_details", false)
+	require.NoError(t, err)
+	p := newTestLDAPProvider(t, newFakeLDAP(), chconfig.LDAPConfig{}, groupDetails)
+	assert.True(t, p.SupportsGroupPermissions())
+
+	require.NoError(t, p.UpdateGroup("Ops", NewGroup("Ops", nil, nil, PermissionCommands)))
+
+	group, err := p.GetGroup("Ops")
+	require.NoError(t, err)
+	assert.Equal(t, NewGroup("Ops", nil, nil, PermissionCommands), group)
+
+	groups, err := p.ListGroups()
+	require.NoError(t, err)
+	assert.Equal(t, []Group{
+		NewGroup("Ops", nil, nil, PermissionCommands),
+		NewGroup("Admins", nil, nil),
+		NewGroup("Sales", nil, nil),
+	}, groups)
+
+	require.NoError(t, p.DeleteGroup("Ops"))
+	group, err = p.GetGroup("Ops")
+	require.NoError(t, err)
+	assert.Equal(t, NewGroup("Ops", nil, nil), group)
+
+	noDetails := newTestLDAPProvider(t, newFakeLDAP(), chconfig.LDAPConfig{}, nil)
+	assert.False(t, noDetails.SupportsGroupPermissions())
+	err = noDetails.UpdateGroup("Ops", Group{})
+	assert.Equal(t, errors2.APIError{
+		Message:    "User group details table must be configured for this operation.",
+		HTTPStatus: http.StatusBadRequest,
+	}, err)
+}
+
+func TestLDAPProviderIsReadOnly(t *testing.T) {
+	p := newTestLDAPProvider(t, newFakeLDAP(), chconfig.LDAPConfig{}, nil)
+	expected := errors2.APIError{
+		Message:    "Users are managed in the LDAP directory, this operation is not supported.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+
+	assert.Equal(t, expected, p.Add(&User{Username: "carol"}))
+	assert.Equal(t, expected, p.Update(&User{Password: "new"}, "alice"))
+	assert.Equal(t, expected, p.Delete("alice"))
+}
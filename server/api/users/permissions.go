@@ -11,22 +11,26 @@ const (
 	PermissionTunnels    = "tunnels"
 	PermissionScripts    = "scripts"
 	PermissionCommands   = "commands"
+	PermissionTerminal   = "terminal"
 	PermissionVault      = "vault"
 	PermissionScheduler  = "scheduler"
 	PermissionMonitoring = "monitoring"
 	PermissionUploads    = "uploads"
 	PermissionsAuditLog  = "auditlog"
+	PermissionApprovals  = "approvals"
 )
 
 var AllPermissions = []string{
 	PermissionTunnels,
 	PermissionScripts,
 	PermissionCommands,
+	PermissionTerminal,
 	PermissionVault,
 	PermissionScheduler,
 	PermissionMonitoring,
 	PermissionUploads,
 	PermissionsAuditLog,
+	PermissionApprovals,
 }
 
 type Permissions struct {
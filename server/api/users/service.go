@@ -55,7 +55,20 @@ func NewAPIService(provider Provider, twoFAOn bool, passwordMinLength int, Passw
 func NewAPIServiceFromConfig(authDB *sqlx.DB, config *chconfig.Config) (*APIService, error) {
 	var usersProvider Provider
 	var err error
-	if rportplus.IsOAuthPermittedUserList(config.PlusConfig) {
+	if config.API.LDAP.IsEnabled() {
+		logger := logger.NewLogger("ldap", config.Logging.LogOutput, config.Logging.LogLevel)
+		var groupDetails *GroupDetailsTable
+		if config.API.AuthGroupDetailsTable != "" {
+			groupDetails, err = NewGroupDetailsTable(authDB, config.API.AuthGroupDetailsTable, rportplus.IsPlusEnabled(config.PlusConfig))
+			if err != nil {
+				return nil, err
+			}
+		}
+		usersProvider, err = NewLDAPProvider(config.API.LDAP, groupDetails, logger)
+		if err != nil {
+			return nil, err
+		}
+	} else if rportplus.IsOAuthPermittedUserList(config.PlusConfig) {
 		if config.API.AuthFile != "" {
 			logger := logger.NewLogger("auth-file", config.Logging.LogOutput, config.Logging.LogLevel)
 			usersProvider, err = NewFileAdapter(logger, NewFileManager(logger, config.API.AuthFile))
@@ -103,6 +116,14 @@ func (as APIService) GetProviderType() enums.ProviderSource {
 	return as.Provider.Type()
 }
 
+// GetPasswordVerifier returns the verifier of user passwords if the provider doesn't store them, otherwise nil
+func (as *APIService) GetPasswordVerifier() PasswordVerifier {
+	if v, ok := as.Provider.(PasswordVerifier); ok {
+		return v
+	}
+	return nil
+}
+
 func (as *APIService) GetAll() ([]*User, error) {
 	return as.Provider.GetAll()
 }
@@ -271,7 +292,9 @@ func (as *APIService) validate(dataToChange *User, usernameToFind string) error
 			dataToChange.PasswordExpired == nil &&
 			dataToChange.Groups == nil &&
 			(!as.TwoFAOn || dataToChange.TwoFASendTo == "") &&
-			dataToChange.TotP == "" {
+			dataToChange.TotP == "" &&
+			dataToChange.WebAuthnCredentials == nil &&
+			dataToChange.Disabled == nil {
 			errs = append(errs, errors2.APIError{
 				Message:    "nothing to change",
 				HTTPStatus: http.StatusBadRequest,
@@ -417,6 +440,8 @@ func newAPIAuthDatabase(authDB *sqlx.DB, config *chconfig.Config, logger *logger
 		config.API.AuthGroupDetailsTable,
 		config.API.IsTwoFAOn(),
 		config.API.TotPEnabled,
+		config.API.WebAuthn.Enabled,
+		config.API.SCIM.IsEnabled(),
 		rportplus.IsPlusEnabled(config.PlusConfig),
 		logger,
 	)
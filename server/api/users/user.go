@@ -5,9 +5,12 @@ type User struct {
 	Username        string   `json:"username" db:"username"`
 	Password        string   `json:"password" db:"password"`
 	PasswordExpired *bool    `json:"password_expired" db:"password_expired"`
+	Disabled        *bool    `json:"disabled,omitempty" db:"disabled"`
 	Groups          []string `json:"groups" db:"-"`
 	TwoFASendTo     string   `json:"two_fa_send_to" db:"two_fa_send_to"`
 	TotP            string   `json:"totp_secret,omitempty" db:"totp_secret"`
+
+	WebAuthnCredentials WebAuthnCredentials `json:"webauthn_credentials,omitempty" db:"webauthn_credentials"`
 }
 
 func (u User) GetGroups() []string {
@@ -27,6 +30,11 @@ func (u User) IsAdmin() bool {
 	return false
 }
 
+// IsDisabled returns true if the user was deactivated and must not log in
+func (u User) IsDisabled() bool {
+	return u.Disabled != nil && *u.Disabled
+}
+
 func PasswordExpired(f bool) *bool {
 	return &f
 }
@@ -0,0 +1,67 @@
+package users
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WebAuthnCredential is a security key or passkey registered by a user
+type WebAuthnCredential struct {
+	ID              []byte     `json:"id"`
+	Name            string     `json:"name"`
+	PublicKey       []byte     `json:"public_key"`
+	AttestationType string     `json:"attestation_type"`
+	Transport       []string   `json:"transport,omitempty"`
+	AAGUID          []byte     `json:"aaguid,omitempty"`
+	SignCount       uint32     `json:"sign_count"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnCredentials are stored as json in the users table, nil means no change on updates while an empty list removes all credentials
+type WebAuthnCredentials []WebAuthnCredential
+
+func (c *WebAuthnCredentials) Scan(value interface{}) error {
+	if c == nil {
+		return errors.New("'webauthn_credentials' cannot be nil")
+	}
+
+	var err error
+	switch d := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if d == "" {
+			return nil
+		}
+		err = json.Unmarshal([]byte(d), c)
+	case []uint8:
+		if len(d) == 0 {
+			return nil
+		}
+		err = json.Unmarshal(d, c)
+	default:
+		return fmt.Errorf("failed to decode json column: unknown comlumn type %T", value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to decode 'webauthn_credentials' field: %v", err)
+	}
+	return nil
+}
+
+func (c WebAuthnCredentials) Value() (driver.Value, error) {
+	if c == nil {
+		c = WebAuthnCredentials{}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'webauthn_credentials' field: %v", err)
+	}
+	return string(b), nil
+}
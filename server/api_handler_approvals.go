@@ -0,0 +1,292 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/api/jobs"
+	"github.com/openrport/openrport/server/api/jobs/schedule"
+	"github.com/openrport/openrport/server/approvals"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients/clientdata"
+)
+
+type rejectApprovalRequest struct {
+	Reason string `json:"reason"`
+}
+
+// approvalClientGroups returns the ids of the client groups requiring approval that contain one of the given clients
+func approvalClientGroups(clients []*clientdata.Client, clientGroups []*cgroups.ClientGroup) []string {
+	var ids []string
+	for _, group := range clientGroups {
+		if !group.RequireApproval {
+			continue
+		}
+		for _, client := range clients {
+			if client.BelongsTo(group) {
+				ids = append(ids, group.ID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// checkApprovalNotRequired returns an error if one of the clients belongs to a client group requiring approval.
+// It's used by the endpoints that execute right away, the approval can only be requested for multi client jobs and schedules.
+func (al *APIListener) checkApprovalNotRequired(ctx context.Context, clients []*clientdata.Client) error {
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	if groupIDs := approvalClientGroups(clients, clientGroups); len(groupIDs) > 0 {
+		return errors2.APIError{
+			Message: fmt.Sprintf(
+				"Client groups %s require approval, use POST /commands, /scripts or /schedules to request it.",
+				strings.Join(groupIDs, ", "),
+			),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
+// requestApproval stores the request as pending approval instead of executing it
+func (al *APIListener) requestApproval(
+	w http.ResponseWriter,
+	req *http.Request,
+	typ string,
+	groupIDs []string,
+	request interface{},
+	clients []*clientdata.Client,
+) {
+	username := api.GetUser(req.Context(), al.Logger)
+	approval, err := al.approvalManager.Create(req.Context(), typ, groupIDs, request, username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationApproval, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(request).
+		WithResponse(approval).
+		WithID(approval.ID).
+		SaveForMultipleClients(clients)
+
+	al.writeJSONResponse(w, http.StatusAccepted, api.NewSuccessPayload(approval))
+
+	al.Debugf("Approval[id=%q] created for %s on client groups %s.", approval.ID, typ, groupIDs)
+}
+
+func (al *APIListener) handleListApprovals(w http.ResponseWriter, req *http.Request) {
+	payload, err := al.approvalManager.List(req.Context(), req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, payload)
+}
+
+func (al *APIListener) handleGetApproval(w http.ResponseWriter, req *http.Request) {
+	approval, err := al.approvalManager.Get(req.Context(), mux.Vars(req)["approval_id"])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(approval))
+}
+
+func (al *APIListener) handleApproveApproval(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	id := mux.Vars(req)["approval_id"]
+
+	approval, err := al.approvalManager.Get(ctx, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := approvals.CheckPending(approval); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	// the targeted clients are resolved again, they might have changed since the request was made
+	execute, clients, err := al.prepareApprovedRequest(ctx, approval)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	err = al.clientService.CheckClientsAccess(clients, curUser, clientGroups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	approval, err = al.approvalManager.Approve(ctx, id, curUser.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationApproval, auditlog.ActionApprove).
+		WithHTTPRequest(req).
+		WithResponse(approval).
+		WithID(approval.ID).
+		SaveForMultipleClients(clients)
+
+	resultID, execErr := execute()
+	if err := al.approvalManager.SetResult(ctx, approval, resultID, execErr); err != nil {
+		al.Errorf("Failed to store the result of approval[id=%q]: %v", approval.ID, err)
+	}
+
+	if execErr != nil {
+		al.auditLog.Entry(auditlog.ApplicationApproval, auditlog.ActionFailed).
+			WithHTTPRequest(req).
+			WithResponse(approval).
+			WithID(approval.ID).
+			SaveForMultipleClients(clients)
+		al.jsonError(w, execErr)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationApproval, auditlog.ActionExecuteStart).
+		WithHTTPRequest(req).
+		WithResponse(approval).
+		WithID(approval.ID).
+		SaveForMultipleClients(clients)
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(approval))
+
+	al.Debugf("Approval[id=%q] approved, %s %q created.", approval.ID, approval.Type, resultID)
+}
+
+func (al *APIListener) handleRejectApproval(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	id := mux.Vars(req)["approval_id"]
+
+	var reqBody rejectApprovalRequest
+	if req.ContentLength != 0 {
+		if err := parseRequestBody(req.Body, &reqBody); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	approval, err := al.approvalManager.Reject(ctx, id, curUser.Username, reqBody.Reason)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationApproval, auditlog.ActionReject).
+		WithHTTPRequest(req).
+		WithRequest(reqBody).
+		WithResponse(approval).
+		WithID(approval.ID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(approval))
+
+	al.Debugf("Approval[id=%q] rejected.", approval.ID)
+}
+
+// prepareApprovedRequest decodes and validates the stored request. It returns a func to execute it, which returns
+// the id of the created multi job or the created or updated schedule, and the targeted clients.
+func (al *APIListener) prepareApprovedRequest(ctx context.Context, approval *approvals.Approval) (func() (string, error), []*clientdata.Client, error) {
+	switch approval.Type {
+	case approvals.TypeCommand, approvals.TypeScript:
+		multiJobRequest := &jobs.MultiJobRequest{}
+		if err := approval.DecodeRequest(multiJobRequest); err != nil {
+			return nil, nil, err
+		}
+
+		orderedClients, _, err := al.getOrderedClientsWithValidation(ctx, multiJobRequest)
+		if err != nil {
+			return nil, nil, err
+		}
+		multiJobRequest.OrderedClients = orderedClients
+		multiJobRequest.Username = approval.RequestedBy
+
+		if approval.Type == approvals.TypeScript {
+			if err := al.enrichScriptInput(multiJobRequest); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return func() (string, error) {
+			multiJob, err := al.StartMultiClientJob(ctx, multiJobRequest)
+			if err != nil {
+				return "", err
+			}
+			return multiJob.JID, nil
+		}, orderedClients, nil
+	case approvals.TypeSchedule:
+		scheduleInput := &schedule.Schedule{}
+		if err := approval.DecodeRequest(scheduleInput); err != nil {
+			return nil, nil, err
+		}
+
+		orderedClients, _, err := al.getOrderedClientsWithValidation(ctx, scheduleInput)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return func() (string, error) {
+			s, err := al.scheduleManager.Create(ctx, scheduleInput, approval.RequestedBy)
+			if err != nil {
+				return "", err
+			}
+			return s.ID, nil
+		}, orderedClients, nil
+	case approvals.TypeScheduleUpdate:
+		updateRequest := &scheduleUpdateRequest{}
+		if err := approval.DecodeRequest(updateRequest); err != nil {
+			return nil, nil, err
+		}
+
+		orderedClients, _, err := al.getOrderedClientsWithValidation(ctx, &updateRequest.Schedule)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return func() (string, error) {
+			s, err := al.scheduleManager.Update(ctx, updateRequest.ScheduleID, &updateRequest.Schedule)
+			if err != nil {
+				return "", err
+			}
+			if s == nil {
+				return "", fmt.Errorf("schedule %q was deleted meanwhile", updateRequest.ScheduleID)
+			}
+			return s.ID, nil
+		}, orderedClients, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown approval type %q", approval.Type)
+}
@@ -0,0 +1,245 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/jobs/schedule"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/approvals"
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+)
+
+func TestApprovalClientGroups(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+
+	prod := makeClientGroup("prod", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}})
+	prod.RequireApproval = true
+	dev := makeClientGroup("dev", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}})
+	all := makeClientGroup("all", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"*"}})
+	all.RequireApproval = true
+	groups := []*cgroups.ClientGroup{prod, dev, all}
+
+	assert.Equal(t, []string{"prod", "all"}, approvalClientGroups([]*clientdata.Client{c1, c2}, groups))
+	assert.Equal(t, []string{"all"}, approvalClientGroups([]*clientdata.Client{c2}, groups))
+	assert.Empty(t, approvalClientGroups([]*clientdata.Client{c2}, []*cgroups.ClientGroup{prod, dev}))
+}
+
+func TestHandleApproveMultiClientCommand(t *testing.T) {
+	requester := makeTestUser("requester")
+	approver := makeTestUser("approver")
+
+	connMock := makeConnMock(t, 1, time.Date(2020, 10, 10, 10, 10, 1, 0, time.UTC))
+	c1 := clients.New(t).ID("client-1").Connection(connMock).Logger(testLog).Build()
+
+	al := makeAPIListener(requester,
+		clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog),
+		60,
+		nil,
+		testLog)
+	al.userService = users.NewAPIService(users.NewStaticProvider([]*users.User{requester, approver}), false, 0, -1)
+
+	jp := makeJobsProvider(t, DataSourceOptions, testLog)
+	defer jp.Close()
+	gp := makeGroupsProvider(t, DataSourceOptions)
+	defer gp.Close()
+
+	al.jobProvider = jp
+	al.clientGroupProvider = gp
+	al.approvalManager = approvals.NewManager(approvals.NewSQLiteProvider(jp.GetDB()), nil, "", nil, testLog)
+	al.initRouter()
+
+	group := makeClientGroup("prod", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}})
+	group.RequireApproval = true
+	require.NoError(t, gp.Create(context.Background(), group))
+
+	requesterCtx := api.WithUser(context.Background(), requester.Username)
+	approverCtx := api.WithUser(context.Background(), approver.Username)
+
+	// the command is not executed, but waits for approval
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/commands", strings.NewReader(`{"command": "/bin/date", "client_ids": ["client-1"]}`))
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req.WithContext(requesterCtx))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	resp := struct {
+		Data approvals.Approval `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, approvals.StatusPending, resp.Data.Status)
+	assert.Equal(t, approvals.TypeCommand, resp.Data.Type)
+	assert.Equal(t, "requester", resp.Data.RequestedBy)
+	assert.EqualValues(t, []string{"prod"}, resp.Data.ClientGroupIDs)
+	sentRequest, _, _ := connMock.InputSendRequest()
+	assert.Empty(t, sentRequest)
+	approveURL := "/api/v1/approvals/" + resp.Data.ID + "/approve"
+
+	// the requester can't approve
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, approveURL, nil).WithContext(requesterCtx))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	done := make(chan bool)
+	al.testDone = done
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, approveURL, nil).WithContext(approverCtx))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	<-done
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, approvals.StatusApproved, resp.Data.Status)
+	require.NotNil(t, resp.Data.DecidedBy)
+	assert.Equal(t, "approver", *resp.Data.DecidedBy)
+	require.NotNil(t, resp.Data.ResultID)
+
+	multiJob, err := jp.GetMultiJob(context.Background(), *resp.Data.ResultID)
+	require.NoError(t, err)
+	require.NotNil(t, multiJob)
+	assert.Equal(t, "requester", multiJob.CreatedBy)
+	assert.Equal(t, "/bin/date", multiJob.Command)
+	assert.Len(t, multiJob.Jobs, 1)
+
+	// can't be decided twice
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+resp.Data.ID+"/reject", nil).WithContext(approverCtx))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHandleApproveScheduleUpdate(t *testing.T) {
+	requester := makeTestUser("requester")
+	approver := makeTestUser("approver")
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+
+	al := makeAPIListener(requester,
+		clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog),
+		60,
+		nil,
+		testLog)
+	al.userService = users.NewAPIService(users.NewStaticProvider([]*users.User{requester, approver}), false, 0, -1)
+
+	jp := makeJobsProvider(t, DataSourceOptions, testLog)
+	defer jp.Close()
+	gp := makeGroupsProvider(t, DataSourceOptions)
+	defer gp.Close()
+
+	al.jobProvider = jp
+	al.clientGroupProvider = gp
+	al.scheduleManager = makeScheduleManager(t, jp, al, testLog)
+	al.approvalManager = approvals.NewManager(approvals.NewSQLiteProvider(jp.GetDB()), nil, "", nil, testLog)
+	al.initRouter()
+
+	group := makeClientGroup("prod", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}})
+	group.RequireApproval = true
+	require.NoError(t, gp.Create(context.Background(), group))
+
+	requesterCtx := api.WithUser(context.Background(), requester.Username)
+	approverCtx := api.WithUser(context.Background(), approver.Username)
+
+	existing, err := al.scheduleManager.Create(requesterCtx, &schedule.Schedule{
+		Base: schedule.Base{
+			Name:     "date",
+			Type:     schedule.TypeCommand,
+			Schedule: "* * * * *",
+		},
+		Details: schedule.Details{
+			ClientIDs: []string{"client-2"},
+			Command:   "/bin/date",
+		},
+	}, requester.Username)
+	require.NoError(t, err)
+
+	// moving the schedule onto a client of the group waits for approval
+	body := `{"name": "date", "type": "command", "schedule": "* * * * *", "command": "/bin/rm -rf /", "client_ids": ["client-1"]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/schedules/"+existing.ID, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req.WithContext(requesterCtx))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	resp := struct {
+		Data approvals.Approval `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, approvals.StatusPending, resp.Data.Status)
+	assert.Equal(t, approvals.TypeScheduleUpdate, resp.Data.Type)
+	assert.EqualValues(t, []string{"prod"}, resp.Data.ClientGroupIDs)
+
+	stored, err := al.scheduleManager.Get(context.Background(), existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/date", stored.Details.Command)
+	assert.Equal(t, []string{"client-2"}, stored.Details.ClientIDs)
+
+	// an unknown schedule is not found, even if its clients require approval
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/schedules/unknown", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, req.WithContext(requesterCtx))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+resp.Data.ID+"/approve", nil).WithContext(approverCtx))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, approvals.StatusApproved, resp.Data.Status)
+	require.NotNil(t, resp.Data.ResultID)
+	assert.Equal(t, existing.ID, *resp.Data.ResultID)
+
+	stored, err = al.scheduleManager.Get(context.Background(), existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/rm -rf /", stored.Details.Command)
+	assert.Equal(t, []string{"client-1"}, stored.Details.ClientIDs)
+}
+
+func TestImmediateJobsRejectApprovalClientGroups(t *testing.T) {
+	user := makeTestUser("user")
+
+	connMock := makeConnMock(t, 1, time.Date(2020, 10, 10, 10, 10, 1, 0, time.UTC))
+	c1 := clients.New(t).ID("client-1").Connection(connMock).Logger(testLog).Build()
+
+	al := makeAPIListener(user,
+		clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog),
+		60,
+		nil,
+		testLog)
+
+	jp := makeJobsProvider(t, DataSourceOptions, testLog)
+	defer jp.Close()
+	gp := makeGroupsProvider(t, DataSourceOptions)
+	defer gp.Close()
+
+	al.jobProvider = jp
+	al.clientGroupProvider = gp
+	al.initRouter()
+
+	group := makeClientGroup("prod", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}})
+	group.RequireApproval = true
+	require.NoError(t, gp.Create(context.Background(), group))
+
+	ctx := api.WithUser(context.Background(), user.Username)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/clients/client-1/commands", strings.NewReader(`{"command": "/bin/date"}`))
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Client groups prod require approval")
+	sentRequest, _, _ := connMock.InputSendRequest()
+	assert.Empty(t, sentRequest)
+
+	err := al.checkApprovalNotRequired(ctx, []*clientdata.Client{c1})
+	require.Error(t, err)
+	require.NoError(t, gp.Delete(ctx, "prod"))
+	assert.NoError(t, al.checkApprovalNotRequired(ctx, []*clientdata.Client{c1}))
+}
@@ -0,0 +1,171 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/query"
+)
+
+type ClientDirectoryRequest struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// handleListClientFiles handles GET /clients/{client_id}/fs, the entries of the directory are paginated by the client
+func (al *APIListener) handleListClientFiles(w http.ResponseWriter, req *http.Request) {
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, nil /* sorts */, nil /* filters */, nil /* fields */, &query.PaginationConfig{
+		DefaultLimit: 100,
+		MaxLimit:     1000,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp, err := al.sendFileSystemRequest(req, &comm.FileSystemRequest{
+		Operation: comm.FileSystemOperationList,
+		Path:      req.URL.Query().Get("path"),
+		Offset:    options.Pagination.ValidatedOffset,
+		Limit:     options.Pagination.ValidatedLimit,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: resp.Entries,
+		Meta: api.NewMeta(resp.TotalCount),
+	})
+}
+
+// handleStatClientFile handles GET /clients/{client_id}/fs/stat
+func (al *APIListener) handleStatClientFile(w http.ResponseWriter, req *http.Request) {
+	resp, err := al.sendFileSystemRequest(req, &comm.FileSystemRequest{
+		Operation: comm.FileSystemOperationStat,
+		Path:      req.URL.Query().Get("path"),
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp.File))
+}
+
+// handleDeleteClientFile handles DELETE /clients/{client_id}/fs
+func (al *APIListener) handleDeleteClientFile(w http.ResponseWriter, req *http.Request) {
+	recursive := false
+	recursiveStr := req.URL.Query().Get("recursive")
+	if recursiveStr != "" {
+		var err error
+		recursive, err = strconv.ParseBool(recursiveStr)
+		if err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid recursive param: %v.", recursiveStr))
+			return
+		}
+	}
+
+	fsReq := &comm.FileSystemRequest{
+		Operation: comm.FileSystemOperationDelete,
+		Path:      req.URL.Query().Get("path"),
+		Recursive: recursive,
+	}
+	if _, err := al.sendFileSystemRequest(req, fsReq); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePostClientDirectory handles POST /clients/{client_id}/fs/directories
+func (al *APIListener) handlePostClientDirectory(w http.ResponseWriter, req *http.Request) {
+	var reqBody ClientDirectoryRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp, err := al.sendFileSystemRequest(req, &comm.FileSystemRequest{
+		Operation: comm.FileSystemOperationMkdir,
+		Path:      reqBody.Path,
+		Recursive: reqBody.Recursive,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(resp.File))
+}
+
+// sendFileSystemRequest sends the request to the active client of the request context.
+// Operations modifying the file system are written to the audit log, also if the client rejects them.
+func (al *APIListener) sendFileSystemRequest(req *http.Request, fsReq *comm.FileSystemRequest) (*comm.FileSystemResponse, error) {
+	if fsReq.Path == "" {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "Missing path.",
+		}
+	}
+
+	client, err := al.getClientFromContext(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if cfg := client.GetFileSystemConfig(); cfg != nil && !cfg.Enabled {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    "File system access is disabled on this client or not supported by its version, check [file-system] enabled option.",
+		}
+	}
+
+	resp := &comm.FileSystemResponse{}
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeFileSystem, fsReq, resp, al.Log())
+
+	switch fsReq.Operation {
+	case comm.FileSystemOperationDelete:
+		al.auditFileSystemRequest(req, client, auditlog.ActionDelete, fsReq, err)
+	case comm.FileSystemOperationMkdir:
+		al.auditFileSystemRequest(req, client, auditlog.ActionCreate, fsReq, err)
+	}
+
+	if err != nil {
+		var clientErr *comm.ClientError
+		if errors.As(err, &clientErr) {
+			return nil, errors2.APIError{
+				HTTPStatus: http.StatusConflict,
+				Message:    fmt.Sprintf("Failed to %s %s on client.", fsReq.Operation, fsReq.Path),
+				Err:        err,
+			}
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (al *APIListener) auditFileSystemRequest(req *http.Request, client *clientdata.Client, action string, fsReq *comm.FileSystemRequest, err error) {
+	var response interface{}
+	if err != nil {
+		response = map[string]string{"error": err.Error()}
+	}
+	al.auditLog.Entry(auditlog.ApplicationClientFS, action).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(map[string]interface{}{
+			"path":      fsReq.Path,
+			"recursive": fsReq.Recursive,
+		}).
+		WithResponse(response).
+		Save()
+}
@@ -0,0 +1,181 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/test"
+)
+
+func TestHandleClientFS(t *testing.T) {
+	enabled := clients.New(t).Logger(testLog).Config(&clientconfig.Config{
+		FileSystemConfig: clientconfig.FileSystemConfig{Enabled: true},
+	}).Build()
+	disabled := clients.New(t).Logger(testLog).Config(&clientconfig.Config{}).Build()
+
+	testCases := []struct {
+		Name               string
+		Method             string
+		URL                string
+		Body               string
+		ClientResponse     string
+		ClientError        bool
+		ExpectedStatus     int
+		ExpectedRequest    *comm.FileSystemRequest
+		ExpectedJSONOutput string
+	}{
+		{
+			Name:           "list",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/" + enabled.GetID() + "/fs?path=/var/log&page[limit]=1&page[offset]=2",
+			ClientResponse: `{"entries":[{"name":"syslog","path":"/var/log/syslog","type":"file","size":10,"mode":"-rw-r-----","mod_time":"2022-01-01T00:00:00Z"}],"total_count":3}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedRequest: &comm.FileSystemRequest{
+				Operation: comm.FileSystemOperationList,
+				Path:      "/var/log",
+				Offset:    2,
+				Limit:     1,
+			},
+			ExpectedJSONOutput: `{"data":[{"name":"syslog","path":"/var/log/syslog","type":"file","size":10,"mode":"-rw-r-----","mod_time":"2022-01-01T00:00:00Z"}],"meta":{"count":3}}`,
+		},
+		{
+			Name:               "list with too big limit",
+			Method:             http.MethodGet,
+			URL:                "/api/v1/clients/" + enabled.GetID() + "/fs?path=/var/log&page[limit]=5000",
+			ExpectedStatus:     http.StatusBadRequest,
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"pagination limit too big (5000) maximum is 1000","detail":""}]}`,
+		},
+		{
+			Name:           "stat",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/" + enabled.GetID() + "/fs/stat?path=/tmp",
+			ClientResponse: `{"file":{"name":"tmp","path":"/tmp","type":"dir","size":4096,"mode":"dtrwxrwxrwx","mod_time":"2022-01-01T00:00:00Z"},"total_count":0}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedRequest: &comm.FileSystemRequest{
+				Operation: comm.FileSystemOperationStat,
+				Path:      "/tmp",
+			},
+			ExpectedJSONOutput: `{"data":{"name":"tmp","path":"/tmp","type":"dir","size":4096,"mode":"dtrwxrwxrwx","mod_time":"2022-01-01T00:00:00Z"}}`,
+		},
+		{
+			Name:               "missing path",
+			Method:             http.MethodGet,
+			URL:                "/api/v1/clients/" + enabled.GetID() + "/fs/stat",
+			ExpectedStatus:     http.StatusBadRequest,
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"Missing path.","detail":""}]}`,
+		},
+		{
+			Name:           "delete",
+			Method:         http.MethodDelete,
+			URL:            "/api/v1/clients/" + enabled.GetID() + "/fs?path=/tmp/old&recursive=true",
+			ClientResponse: `{"file":{"name":"old","path":"/tmp/old","type":"dir","size":4096,"mode":"drwx------","mod_time":"2022-01-01T00:00:00Z"},"total_count":0}`,
+			ExpectedStatus: http.StatusNoContent,
+			ExpectedRequest: &comm.FileSystemRequest{
+				Operation: comm.FileSystemOperationDelete,
+				Path:      "/tmp/old",
+				Recursive: true,
+			},
+		},
+		{
+			Name:               "delete with invalid recursive param",
+			Method:             http.MethodDelete,
+			URL:                "/api/v1/clients/" + enabled.GetID() + "/fs?path=/tmp/old&recursive=maybe",
+			ExpectedStatus:     http.StatusBadRequest,
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"Invalid recursive param: maybe.","detail":""}]}`,
+		},
+		{
+			Name:           "mkdir",
+			Method:         http.MethodPost,
+			URL:            "/api/v1/clients/" + enabled.GetID() + "/fs/directories",
+			Body:           `{"path":"/tmp/new/sub","recursive":true}`,
+			ClientResponse: `{"file":{"name":"sub","path":"/tmp/new/sub","type":"dir","size":4096,"mode":"drwxr-xr-x","mod_time":"2022-01-01T00:00:00Z"},"total_count":0}`,
+			ExpectedStatus: http.StatusCreated,
+			ExpectedRequest: &comm.FileSystemRequest{
+				Operation: comm.FileSystemOperationMkdir,
+				Path:      "/tmp/new/sub",
+				Recursive: true,
+			},
+			ExpectedJSONOutput: `{"data":{"name":"sub","path":"/tmp/new/sub","type":"dir","size":4096,"mode":"drwxr-xr-x","mod_time":"2022-01-01T00:00:00Z"}}`,
+		},
+		{
+			Name:           "rejected by client",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/" + enabled.GetID() + "/fs?path=/etc",
+			ClientResponse: "path /etc doesn't match any allowed pattern, therefore the file system request is rejected",
+			ClientError:    true,
+			ExpectedStatus: http.StatusConflict,
+			ExpectedRequest: &comm.FileSystemRequest{
+				Operation: comm.FileSystemOperationList,
+				Path:      "/etc",
+				Limit:     100,
+			},
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"Failed to list /etc on client.","detail":"client error: path /etc doesn't match any allowed pattern, therefore the file system request is rejected"}]}`,
+		},
+		{
+			Name:               "disabled on client",
+			Method:             http.MethodGet,
+			URL:                "/api/v1/clients/" + disabled.GetID() + "/fs?path=/etc",
+			ExpectedStatus:     http.StatusConflict,
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"File system access is disabled on this client or not supported by its version, check [file-system] enabled option.","detail":""}]}`,
+		},
+		{
+			Name:               "unknown client",
+			Method:             http.MethodGet,
+			URL:                "/api/v1/clients/unknown/fs?path=/etc",
+			ExpectedStatus:     http.StatusNotFound,
+			ExpectedJSONOutput: `{"errors":[{"code":"","title":"Active client with id=\"unknown\" not found.","detail":""}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			connMock := test.NewConnMock()
+			connMock.ReturnOk = !tc.ClientError
+			connMock.ReturnResponsePayload = []byte(tc.ClientResponse)
+			enabled.SetConnection(connMock)
+			clientService := clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{enabled, disabled}, &hour, testLog), testLog, nil)
+			al := APIListener{
+				insecureForTests: true,
+				Server: &Server{
+					clientService: clientService,
+					config: &chconfig.Config{
+						API: chconfig.APIConfig{
+							MaxRequestBytes: 1024 * 1024,
+						},
+					},
+				},
+				Logger: testLog,
+			}
+			al.initRouter()
+
+			req := httptest.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.Body))
+			w := httptest.NewRecorder()
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			if tc.ExpectedJSONOutput != "" {
+				assert.JSONEq(t, tc.ExpectedJSONOutput, w.Body.String())
+			}
+			name, _, payload := connMock.InputSendRequest()
+			if tc.ExpectedRequest != nil {
+				assert.Equal(t, comm.RequestTypeFileSystem, name)
+				actual := &comm.FileSystemRequest{}
+				require.NoError(t, json.Unmarshal(payload, actual))
+				assert.Equal(t, tc.ExpectedRequest, actual)
+			} else {
+				assert.Empty(t, name)
+			}
+		})
+	}
+}
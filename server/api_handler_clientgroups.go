@@ -101,6 +101,9 @@ func validateInputClientGroup(group cgroups.ClientGroup) error {
 	if invalidGroupIDRegexp.MatchString(group.ID) {
 		return fmt.Errorf("invalid group ID %q: can contain only %q", group.ID, validGroupIDChars)
 	}
+	if group.BandwidthLimitUp < 0 || group.BandwidthLimitDown < 0 {
+		return errors.New("bandwidth limits cannot be negative")
+	}
 	if group.Params != nil && group.Params.Tag != nil {
 		_, _, err := cgroups.ParseTag(group.Params.Tag)
 		if err != nil {
@@ -244,6 +247,9 @@ type ClientGroupPayload struct {
 	Description         *string               `json:"description,omitempty"`
 	Params              *cgroups.ClientParams `json:"params,omitempty" db:"params"`
 	AllowedUserGroups   *types.StringSlice    `json:"allowed_user_groups,omitempty"`
+	RequireApproval     *bool                 `json:"require_approval,omitempty"`
+	BandwidthLimitUp    *int64                `json:"bandwidth_limit_up,omitempty"`
+	BandwidthLimitDown  *int64                `json:"bandwidth_limit_down,omitempty"`
 	ClientIDs           *[]string             `json:"client_ids,omitempty" db:"-"`
 	NumClients          *int                  `json:"num_clients,omitempty" db:"-"`
 	NumClientsConnected *int                  `json:"num_clients_connected,omitempty" db:"-"`
@@ -276,6 +282,12 @@ func (al *APIListener) convertToClientGroupPayload(clientGroup *cgroups.ClientGr
 			p.Params = clientGroup.Params
 		case "allowed_user_groups":
 			p.AllowedUserGroups = &clientGroup.AllowedUserGroups
+		case "require_approval":
+			p.RequireApproval = &clientGroup.RequireApproval
+		case "bandwidth_limit_up":
+			p.BandwidthLimitUp = &clientGroup.BandwidthLimitUp
+		case "bandwidth_limit_down":
+			p.BandwidthLimitDown = &clientGroup.BandwidthLimitDown
 		case "client_ids":
 			p.ClientIDs = &clientGroup.ClientIDs
 		case "num_clients":
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/ssh"
@@ -22,6 +23,7 @@ import (
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/query"
+	"github.com/openrport/openrport/share/vaultplaceholder"
 )
 
 func getCorrespondingSortFunc(sorts []query.SortOption) (sortFunc func(a []*clientdata.CalculatedClient, desc bool), desc bool, err error) {
@@ -175,6 +177,12 @@ func (al *APIListener) handleGetClients(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	filteredClients, err = al.filterTokenClients(req.Context(), filteredClients)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	sortFunc(filteredClients, desc)
 
 	totalCount := len(filteredClients)
@@ -231,20 +239,33 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 	localAddr := req.URL.Query().Get("local")
 	remoteAddr := req.URL.Query().Get("remote")
 
-	remoteStr := localAddr + ":" + remoteAddr
-	if localAddr == "" {
-		remoteStr = remoteAddr
-	}
-
 	protocol := req.URL.Query().Get("protocol")
-	if protocol != "" {
-		remoteStr += "/" + protocol
-	}
 
-	remote, err := models.NewRemote(remoteStr)
-	if err != nil {
-		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("failed to decode %q: %v", remoteStr, err))
-		return
+	var remote *models.Remote
+	if protocol == models.ProtocolSOCKS5 {
+		if remoteAddr != "" {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "socks5 tunnels don't have a remote, destinations are requested by the connecting applications")
+			return
+		}
+		remote, err = models.NewSOCKS5Remote(localAddr)
+		if err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("failed to decode %q: %v", localAddr, err))
+			return
+		}
+	} else {
+		remoteStr := localAddr + ":" + remoteAddr
+		if localAddr == "" {
+			remoteStr = remoteAddr
+		}
+		if protocol != "" {
+			remoteStr += "/" + protocol
+		}
+
+		remote, err = models.NewRemote(remoteStr)
+		if err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("failed to decode %q: %v", remoteStr, err))
+			return
+		}
 	}
 
 	client.Log().Debugf("requested remote = %#v", remote)
@@ -281,6 +302,12 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	err = setBandwidthOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	aclStr := req.URL.Query().Get("acl")
 	if _, err = clienttunnel.ParseTunnelACL(aclStr); err != nil {
 		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeInvalidACL, fmt.Sprintf("Invalid ACL: %s", err))
@@ -290,38 +317,74 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		remote.ACL = &aclStr
 	}
 
-	allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), al.Log())
+	err = al.setSSHOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	remote.Record, err = al.parseRecordParam(req)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
-	if !allowed {
-		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel destination is not allowed by client configuration.")
+	if remote.Record && !remote.IsProtocol(models.ProtocolTCP) {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Recording not supported with protocol %s.", remote.Protocol))
 		return
 	}
 
-	if existing := al.clientService.FindTunnelByRemote(client, remote); existing != nil {
-		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelExist, "Tunnel already exist.")
+	err = al.setReverseOptionsForRemote(req, remote, localAddr)
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
 
-	for _, t := range client.GetTunnels() {
-		if t.Remote.Remote() == remote.Remote() && t.Remote.IsProtocol(remote.Protocol) && t.EqualACL(remote.ACL) {
-			al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelToPortExist, fmt.Sprintf("Tunnel to port %s already exists.", remote.RemotePort))
-			return
-		}
+	err = al.setTargetClientOptionsForRemote(req, client, remote, localAddr)
+	if err != nil {
+		al.jsonError(w, err)
+		return
 	}
 
-	if checkPortStr := req.URL.Query().Get("check_port"); checkPortStr != "0" && remote.IsProtocol(models.ProtocolTCP) {
-		err = al.checkRemotePort(*remote, client.GetConnection())
+	if remote.ListensOnClient() {
+		if existing := al.clientService.FindTunnelByRemote(client, remote); existing != nil {
+			al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelExist, "Tunnel already exist.")
+			return
+		}
+	} else if remote.Protocol != models.ProtocolSOCKS5 {
+		// the client checks each destination of socks5 tunnels when it's requested
+		allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), al.Log())
 		if err != nil {
 			al.jsonError(w, err)
 			return
 		}
+		if !allowed {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel destination is not allowed by client configuration.")
+			return
+		}
+
+		if existing := al.clientService.FindTunnelByRemote(client, remote); existing != nil {
+			al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelExist, "Tunnel already exist.")
+			return
+		}
+
+		for _, t := range client.GetTunnels() {
+			if t.Remote.Remote() == remote.Remote() && t.Remote.IsProtocol(remote.Protocol) && t.EqualACL(remote.ACL) {
+				al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelToPortExist, fmt.Sprintf("Tunnel to port %s already exists.", remote.RemotePort))
+				return
+			}
+		}
+
+		if checkPortStr := req.URL.Query().Get("check_port"); checkPortStr != "0" && remote.IsProtocol(models.ProtocolTCP) {
+			err = al.checkRemotePort(*remote, client.GetConnection())
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+		}
 	}
 
-	if remote.IsLocalSpecified() {
-		err = al.checkLocalPort(remote.LocalPort, remote.Protocol)
+	if remote.IsLocalSpecified() && !remote.ListensOnClient() {
+		err = al.checkLocalPort(remote.LocalPort, remote.ListenProtocol())
 		if err != nil {
 			al.jsonError(w, err)
 			return
@@ -407,7 +470,7 @@ func (al *APIListener) setAuthOptionsForRemote(req *http.Request, remote *models
 	authUser := req.URL.Query().Get("auth_user")
 	authPassword := req.URL.Query().Get("auth_password")
 	if authUser != "" || authPassword != "" {
-		if !remote.HTTPProxy {
+		if !remote.HTTPProxy && remote.Protocol != models.ProtocolSOCKS5 {
 			return apierrors.NewAPIError(http.StatusBadRequest, "", "http basic authentication requires http_proxy to be activated on the requested tunnel", nil)
 		}
 		if authPassword != "" && authUser == "" {
@@ -418,8 +481,59 @@ func (al *APIListener) setAuthOptionsForRemote(req *http.Request, remote *models
 		}
 		remote.AuthUser = authUser
 		remote.AuthPassword = authPassword
+		if vaultplaceholder.Has(authPassword) {
+			// the placeholder is kept in the tunnel, the password is resolved for the proxy only
+			remote.ResolvedAuthPassword, err = al.resolveVaultPlaceholders(req.Context(), authPassword, mux.Vars(req)[routes.ParamClientID], api.GetUser(req.Context(), al.Logger))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// setSSHOptionsForRemote sets the host key and the credentials the ssh tunnel proxy logs in with, the password has to
+// refer to the vault so it's never stored with the tunnel. Credentials from the vault are only sent to the pinned host
+// key and only if the proxy isn't open to anyone.
+func (al *APIListener) setSSHOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
+	sshUser := req.URL.Query().Get("ssh_user")
+	sshPassword := req.URL.Query().Get("ssh_password")
+	sshHostKey := req.URL.Query().Get("ssh_host_key")
+	if sshUser == "" && sshPassword == "" && sshHostKey == "" {
+		return nil
+	}
+	if !remote.HTTPProxy || remote.Scheme == nil || *remote.Scheme != "ssh" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh options require http_proxy to be activated on a tunnel with scheme ssh", nil)
+	}
+	if sshHostKey != "" {
+		if err := validation.ValidateSSHHostKeyFingerprint(sshHostKey); err != nil {
+			return err
+		}
+		remote.SSHHostKey = sshHostKey
+	}
+	if sshUser == "" && sshPassword == "" {
+		return nil
+	}
+	if sshPassword != "" && sshUser == "" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh_password requires ssh_user", nil)
+	}
+	if sshUser != "" && sshPassword == "" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh_user requires ssh_password", nil)
+	}
+	if !vaultplaceholder.Has(sshPassword) {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh_password must refer to a vault value", nil)
+	}
+	if remote.SSHHostKey == "" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh credentials require ssh_host_key", nil)
+	}
+	if remote.AuthUser == "" && remote.ACL == nil {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "ssh credentials require auth_user and auth_password or an acl", nil)
 	}
 
+	remote.SSHUser = sshUser
+	remote.SSHPassword = sshPassword
+	remote.ResolvedSSHPassword, err = al.resolveVaultPlaceholders(req.Context(), sshPassword, mux.Vars(req)[routes.ParamClientID], api.GetUser(req.Context(), al.Logger))
 	return err
 }
 
@@ -445,6 +559,147 @@ func (al *APIListener) setAutoCloseIdleOptionsForRemote(req *http.Request, remot
 	return err
 }
 
+// setBandwidthOptionsForRemote sets the bandwidth limits of the tunnel in bytes per second
+func setBandwidthOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
+	remote.BandwidthLimitUp, err = parseBandwidthLimitParam(req, "bandwidth_limit_up")
+	if err != nil {
+		return err
+	}
+	remote.BandwidthLimitDown, err = parseBandwidthLimitParam(req, "bandwidth_limit_down")
+	return err
+}
+
+func parseBandwidthLimitParam(req *http.Request, param string) (int64, error) {
+	limitStr := req.URL.Query().Get(param)
+	if limitStr == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s param: %s.", param, limitStr), err)
+	}
+	return limit, nil
+}
+
+// setReverseOptionsForRemote makes the remote a reverse tunnel if requested, the client listens on local then and
+// connections are forwarded to the remote address on the server side
+func (al *APIListener) setReverseOptionsForRemote(req *http.Request, remote *models.Remote, localAddr string) error {
+	reverseStr := req.URL.Query().Get("reverse")
+	if reverseStr == "" {
+		return nil
+	}
+	reverse, err := strconv.ParseBool(reverseStr)
+	if err != nil {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid reverse param: %s.", reverseStr), err)
+	}
+	if !reverse {
+		return nil
+	}
+
+	if err := setClientListenerForRemote(remote, localAddr, "Reverse tunnels"); err != nil {
+		return err
+	}
+	if !al.clientService.IsReverseTunnelAllowed(remote.Remote()) {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Reverse tunnel destination %s is not allowed by server configuration.", remote.Remote()), nil)
+	}
+
+	remote.Reverse = true
+	return nil
+}
+
+// setTargetClientOptionsForRemote makes the remote a client-to-client tunnel if a target client is requested, the
+// client listens on local then and connections are forwarded to the remote address on the target client
+func (al *APIListener) setTargetClientOptionsForRemote(req *http.Request, client *clientdata.Client, remote *models.Remote, localAddr string) error {
+	targetClientID := req.URL.Query().Get("target_client_id")
+	if targetClientID == "" {
+		return nil
+	}
+
+	switch {
+	case remote.Reverse:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "Client-to-client tunnels can't be reverse.", nil)
+	case targetClientID == client.GetID():
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "The target client must be another client.", nil)
+	}
+	if err := setClientListenerForRemote(remote, localAddr, "Client-to-client tunnels"); err != nil {
+		return err
+	}
+
+	target, err := al.clientService.GetActiveByID(targetClientID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return apierrors.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("target client with id %s not found", targetClientID), nil)
+	}
+	if target.IsPaused() {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("target client with id %s is paused (reason = %s)", targetClientID, target.GetPausedReason()), nil)
+	}
+	if !target.IsConnectedHere() {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("target client with id %s is connected to another node, both clients must be connected to the same node", targetClientID), nil)
+	}
+
+	ctx := req.Context()
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		return err
+	}
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	err = al.clientService.CheckClientsAccess([]*clientdata.Client{client, target}, curUser, clientGroups)
+	if err != nil {
+		return err
+	}
+
+	err = clients.CheckClientToClientTunnelAllowed(remote, client.GetConnection(), target.GetConnection(), al.Log())
+	if err != nil {
+		return err
+	}
+
+	remote.TargetClientID = targetClientID
+	return nil
+}
+
+// setClientListenerForRemote validates a remote listening on the client, name is used in the errors
+func setClientListenerForRemote(remote *models.Remote, localAddr, name string) error {
+	switch {
+	case remote.Protocol != models.ProtocolTCP:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s not supported with protocol %s.", name, remote.Protocol), nil)
+	case remote.HTTPProxy:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s can't be used with http_proxy.", name), nil)
+	case remote.ACL != nil:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s can't be used with acl.", name), nil)
+	case remote.Record:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s can't be recorded.", name), nil)
+	case localAddr == "":
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s require the local port the client listens on.", name), nil)
+	}
+
+	if !strings.Contains(localAddr, ":") {
+		// unless given, the client listens on localhost only
+		remote.LocalHost = models.LocalHost
+	}
+	return nil
+}
+
+// parseRecordParam parses the optional "record" query param used to request a recording of a session
+func (al *APIListener) parseRecordParam(req *http.Request) (bool, error) {
+	recordStr := req.URL.Query().Get("record")
+	if recordStr == "" {
+		return false, nil
+	}
+	record, err := strconv.ParseBool(recordStr)
+	if err != nil {
+		return false, apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid record param: %s.", recordStr), err)
+	}
+	if record && al.recordings == nil {
+		return false, apierrors.NewAPIError(http.StatusBadRequest, "", "recording of sessions not enabled", nil)
+	}
+	return record, nil
+}
+
 // TODO: remove this check, do it in client srv in startClientTunnels when https://github.com/realvnc-labs/rport/pull/252 will be in master.
 // APIError needs both httpStatusCode and errorCode. To avoid too many merge conflicts with PR252 temporarily use this check to avoid breaking UI
 func (al *APIListener) checkLocalPort(localPort, protocol string) (err error) {
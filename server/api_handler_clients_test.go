@@ -113,6 +113,7 @@ func TestHandleGetClient(t *testing.T) {
                 "name": "",
                 "owner": "",
                 "protocol": "tcp",
+                "record": false,
                 "lhost":"0.0.0.0",
                 "lport":"2222",
                 "rhost":"0.0.0.0",
@@ -134,6 +135,7 @@ func TestHandleGetClient(t *testing.T) {
                 "name": "",
                 "owner": "",
                 "protocol": "tcp",
+                "record": false,
                 "lhost":"0.0.0.0",
                 "lport":"4000",
                 "rhost":"0.0.0.0",
@@ -393,6 +395,10 @@ func (mcs *SimpleMockClientService) StartClientTunnels(client *clientdata.Client
 	return tunnels, nil
 }
 
+func (mcs *SimpleMockClientService) IsReverseTunnelAllowed(destination string) bool {
+	return destination == "127.0.0.1:8080"
+}
+
 func makeTunnelResponse(id string, remote *models.Remote) (response *clienttunnel.Tunnel) {
 	response = &clienttunnel.Tunnel{
 		ID:     id,
@@ -427,6 +433,7 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"name": "TUNNELNAME",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -454,6 +461,7 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -481,6 +489,7 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -508,6 +517,7 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -531,6 +541,130 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 			URL:           "/api/v1/clients/client-1/tunnels?scheme=http&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&auth_user=admin&http_proxy=1",
 			ExpectedError: "auth_user requires auth_password",
 		},
+		{
+			Name:          "SSH credentials without tunnel proxy",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&ssh_user=root&ssh_password=%7B%7B+vault+%22root%22+%7D%7D",
+			ExpectedError: "ssh options require http_proxy to be activated on a tunnel with scheme ssh",
+		},
+		{
+			Name:          "SSH password not from vault",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&ssh_user=root&ssh_password=secret&http_proxy=1",
+			ExpectedError: "ssh_password must refer to a vault value",
+		},
+		{
+			Name:          "SSH credentials without host key",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&ssh_user=root&ssh_password=%7B%7B+vault+%22root%22+%7D%7D&http_proxy=1",
+			ExpectedError: "ssh credentials require ssh_host_key",
+		},
+		{
+			Name:          "SSH credentials without auth or acl",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&ssh_user=root&ssh_password=%7B%7B+vault+%22root%22+%7D%7D&ssh_host_key=SHA256%3A47DEQpj8HBSa%2B%2FTImW%2B5JCeuQeRkm5NMpJWZG3hSuFU&http_proxy=1",
+			ExpectedError: "ssh credentials require auth_user and auth_password or an acl",
+		},
+		{
+			Name:          "Invalid SSH host key",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&ssh_host_key=MD5%3A16%3A27&http_proxy=1",
+			ExpectedError: "invalid ssh_host_key, expected a SHA256 fingerprint as printed by ssh-keygen -l",
+		},
+		{
+			Name:          "Record with recordings disabled",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&record=true",
+			ExpectedError: "recording of sessions not enabled",
+		},
+		{
+			Name:          "Invalid record param",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&record=maybe",
+			ExpectedError: "Invalid record param: maybe.",
+		},
+		{
+			Name: "Reverse",
+			URL:  "/api/v1/clients/client-1/tunnels?local=4000&remote=127.0.0.1%3A8080&reverse=1",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"record": false,
+				"lhost": "127.0.0.1",
+				"lport": "4000",
+				"rhost": "127.0.0.1",
+				"rport": "8080",
+				"lport_random": false,
+				"scheme": null,
+				"acl": null,
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"reverse": true
+			}
+		}`,
+		},
+		{
+			Name: "With bandwidth limits",
+			URL:  "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A8080&check_port=0&bandwidth_limit_up=1048576&bandwidth_limit_down=524288",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"record": false,
+				"lhost": "0.0.0.0",
+				"lport": "3390",
+				"rhost": "0.0.0.0",
+				"rport": "8080",
+				"lport_random": false,
+				"scheme": null,
+				"acl": null,
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"bandwidth_limit_up": 1048576,
+				"bandwidth_limit_down": 524288
+			}
+		}`,
+		},
+		{
+			Name:          "Invalid bandwidth limit",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&bandwidth_limit_up=-1",
+			ExpectedError: "Invalid bandwidth_limit_up param: -1.",
+		},
+		{
+			Name:          "Reverse to not allowed destination",
+			URL:           "/api/v1/clients/client-1/tunnels?local=4000&remote=10.0.0.1%3A8080&reverse=1",
+			ExpectedError: "Reverse tunnel destination 10.0.0.1:8080 is not allowed by server configuration.",
+		},
+		{
+			Name:          "Reverse with acl",
+			URL:           "/api/v1/clients/client-1/tunnels?acl=127.0.0.1&local=4000&remote=127.0.0.1%3A8080&reverse=1",
+			ExpectedError: "Reverse tunnels can't be used with acl.",
+		},
+		{
+			Name:          "Reverse without local",
+			URL:           "/api/v1/clients/client-1/tunnels?remote=127.0.0.1%3A8080&reverse=1",
+			ExpectedError: "Reverse tunnels require the local port the client listens on.",
+		},
+		{
+			Name:          "Client-to-client to itself",
+			URL:           "/api/v1/clients/client-1/tunnels?local=5433&remote=10.0.0.7%3A5432&target_client_id=client-1",
+			ExpectedError: "The target client must be another client.",
+		},
+		{
+			Name:          "Client-to-client with udp",
+			URL:           "/api/v1/clients/client-1/tunnels?local=5433&remote=10.0.0.7%3A5432&protocol=udp&target_client_id=client-2",
+			ExpectedError: "Client-to-client tunnels not supported with protocol udp.",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -588,6 +722,123 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 	}
 }
 
+type clientToClientMockService struct {
+	*SimpleMockClientService
+}
+
+func (mcs *clientToClientMockService) GetActiveByID(id string) (*clientdata.Client, error) {
+	for _, c := range mcs.ActiveClients {
+		if c.GetID() == id {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestHandlePutClientToClientTunnel(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte("{ \"IsAllowed\": true }")
+	user := &users.User{
+		Username: "test-user",
+		Groups:   []string{"site-1"},
+	}
+	mockUsersService := &MockUsersService{
+		UserService: users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+
+	testCases := []struct {
+		Name           string
+		TargetGroups   []string
+		ExpectedStatus int
+		ExpectedJSON   string
+		ExpectedError  string
+	}{
+		{
+			Name:           "access to both clients",
+			TargetGroups:   []string{"site-1"},
+			ExpectedStatus: http.StatusOK,
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"record": false,
+				"lhost": "127.0.0.1",
+				"lport": "5433",
+				"rhost": "10.0.0.7",
+				"rport": "5432",
+				"lport_random": false,
+				"scheme": null,
+				"acl": null,
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"target_client_id": "client-2"
+			}
+		}`,
+		},
+		{
+			Name:           "no access to target client",
+			TargetGroups:   []string{"site-2"},
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedError:  "Access denied to client(s) with ID(s): client-2",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).AllowedUserGroups([]string{"site-1"}).Logger(testLog).Build()
+			c1.SetConnection(connMock)
+			c2 := clients.New(t).ID("client-2").ClientAuthID(cl2.ID).AllowedUserGroups(tc.TargetGroups).Logger(testLog).Build()
+			c2.SetConnection(connMock)
+
+			al := APIListener{
+				insecureForTests: true,
+				Server: &Server{
+					clientService: &clientToClientMockService{
+						SimpleMockClientService: &SimpleMockClientService{
+							ExpectedIDs:   []string{"10"},
+							ActiveClients: []*clientdata.Client{c1, c2},
+						},
+					},
+					config: &chconfig.Config{
+						API: chconfig.APIConfig{
+							MaxRequestBytes: 1024 * 1024,
+						},
+					},
+					clientGroupProvider: mockClientGroupProvider{},
+				},
+				userService: mockUsersService,
+				Logger:      testLog,
+			}
+			al.initRouter()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/v1/clients/client-1/tunnels?local=5433&remote=10.0.0.7%3A5432&target_client_id=client-2", nil)
+			req = req.WithContext(api.WithUser(req.Context(), user.Username))
+
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code, fmt.Sprintf("Response Body: %s", w.Body))
+			if tc.ExpectedError == "" {
+				assert.JSONEq(t, tc.ExpectedJSON, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tc.ExpectedError)
+			}
+		})
+	}
+}
+
 func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 	connMock := test.NewConnMock()
 	connMock.ReturnOk = true
@@ -617,6 +868,7 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"name": "TUNNELNAME",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -645,6 +897,7 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -673,6 +926,7 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -701,6 +955,7 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"name": "",
 				"owner": "test-user",
 				"protocol": "tcp",
+				"record": false,
 				"lhost": "0.0.0.0",
 				"lport": "3390",
 				"rhost": "0.0.0.0",
@@ -729,6 +984,7 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 					"name": "",
 					"owner": "test-user",
 					"protocol": "tcp",
+					"record": false,
 					"lhost": "0.0.0.0",
 					"lport": "3390",
 					"rhost": "0.0.0.0",
@@ -153,6 +153,11 @@ func (al *APIListener) handleDeleteClientAuth(w http.ResponseWriter, req *http.R
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	if al.clientAuthRotation != nil {
+		if err := al.clientAuthRotation.Delete(req.Context(), clientAuthID); err != nil {
+			al.Errorf("Failed to delete rotation of client auth %q: %v", clientAuthID, err)
+		}
+	}
 	al.Infof("ClientAuth %q deleted.", clientAuthID)
 
 	al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionDelete).
@@ -0,0 +1,80 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clientsauth/rotation"
+	"github.com/openrport/openrport/server/routes"
+)
+
+func (al *APIListener) handleGetClientAuthRotation(w http.ResponseWriter, req *http.Request) {
+	r, err := al.clientAuthRotation.Get(req.Context(), mux.Vars(req)[routes.ParamClientAuthID])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(r))
+}
+
+func (al *APIListener) handlePutClientAuthRotation(w http.ResponseWriter, req *http.Request) {
+	if !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	var reqBody rotation.ScheduleRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	clientAuthID := mux.Vars(req)[routes.ParamClientAuthID]
+	r, err := al.clientAuthRotation.Schedule(req.Context(), clientAuthID, reqBody.IntervalSec)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithRequest(reqBody).
+		WithResponse(r).
+		WithID(clientAuthID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(r))
+
+	al.Debugf("Rotation of client auth %q scheduled every %ds.", clientAuthID, reqBody.IntervalSec)
+}
+
+func (al *APIListener) handleRotateClientAuth(w http.ResponseWriter, req *http.Request) {
+	if !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	clientAuthID := mux.Vars(req)[routes.ParamClientAuthID]
+	r, err := al.clientAuthRotation.Rotate(req.Context(), clientAuthID)
+	if err != nil {
+		al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionFailed).
+			WithHTTPRequest(req).
+			WithResponse(map[string]string{"error": err.Error()}).
+			WithID(clientAuthID).
+			Save()
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionRotate).
+		WithHTTPRequest(req).
+		WithResponse(r).
+		WithID(clientAuthID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(r))
+
+	al.Infof("Password of client auth %q rotated.", clientAuthID)
+}
@@ -0,0 +1,67 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientsmigration "github.com/openrport/openrport/db/migration/clients"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/server/clientsauth/rotation"
+)
+
+func TestHandleClientAuthRotation(t *testing.T) {
+	admin := makeTestUser("admin")
+	al := makeAPIListener(admin, clients.NewClientRepository(nil, &hour, testLog), 60, nil, testLog)
+
+	db, err := sqlite.New(":memory:", clientsmigration.AssetNames(), clientsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	al.config.Server.AuthWrite = true
+	al.clientAuthProvider = clientsauth.NewMockFileProvider([]*clientsauth.ClientAuth{{ID: "auth-1", Password: "pass"}}, t)
+	al.clientAuthRotation = rotation.NewManager(rotation.NewSQLiteProvider(db), al.clientAuthProvider, al.clientService, time.Hour, testLog)
+	al.initRouter()
+
+	ctx := api.WithUser(context.Background(), admin.Username)
+
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/clients-auth/auth-1/rotation", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data": {"client_auth_id": "auth-1", "interval_sec": 0, "next_rotation_at": null, "last_rotated_at": null, "last_error": "", "alternate_valid_until": null}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/clients-auth/auth-1/rotation", strings.NewReader(`{"interval_sec": 86400}`))
+	al.router.ServeHTTP(w, req.WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	scheduled := struct {
+		Data rotation.Rotation `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scheduled))
+	assert.Equal(t, int64(86400), scheduled.Data.IntervalSec)
+	require.NotNil(t, scheduled.Data.NextRotationAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *scheduled.Data.NextRotationAt, time.Minute)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/clients-auth/auth-1/rotate", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusConflict, w.Code, "no client connected")
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/clients-auth/unknown/rotation", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	al.config.Server.AuthWrite = false
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/clients-auth/auth-1/rotate", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -11,7 +11,9 @@ import (
 
 	"github.com/openrport/openrport/server/api"
 	"github.com/openrport/openrport/server/api/jobs"
+	"github.com/openrport/openrport/server/approvals"
 	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/server/validation"
 	"github.com/openrport/openrport/share/comm"
@@ -319,6 +321,11 @@ func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *
 
 	reqBody.Username = curUser.Username
 
+	if groupIDs := approvalClientGroups(reqBody.OrderedClients, clientGroups); len(groupIDs) > 0 {
+		al.requestApproval(w, req, approvals.TypeCommand, groupIDs, reqBody, reqBody.OrderedClients)
+		return
+	}
+
 	multiJob, err := al.StartMultiClientJob(ctx, &reqBody)
 	if err != nil {
 		al.jsonError(w, err)
@@ -370,6 +377,11 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		return nil
 	}
 
+	if err := al.checkApprovalNotRequired(ctx, []*clientdata.Client{client}); err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
+
 	// send the command to the client
 	// Send a job with all possible info in order to get the full-populated job back (in client-listener) when it's done.
 	// Needed when server restarts to get all job data from client. Because on server restart job running info is lost.
@@ -379,21 +391,29 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		return nil
 	}
 	curJob := models.Job{
-		JID:         jid,
-		FinishedAt:  nil,
-		ClientID:    executeInput.ClientID,
-		ClientName:  client.GetName(),
-		Command:     executeInput.Command,
-		Interpreter: executeInput.Interpreter,
-		CreatedBy:   api.GetUser(ctx, al.Logger),
-		TimeoutSec:  executeInput.TimeoutSec,
-		Result:      nil,
-		Cwd:         executeInput.Cwd,
-		IsSudo:      executeInput.IsSudo,
-		IsScript:    executeInput.IsScript,
+		JID:          jid,
+		FinishedAt:   nil,
+		ClientID:     executeInput.ClientID,
+		ClientName:   client.GetName(),
+		Command:      executeInput.Command,
+		Interpreter:  executeInput.Interpreter,
+		CreatedBy:    api.GetUser(ctx, al.Logger),
+		TimeoutSec:   executeInput.TimeoutSec,
+		Result:       nil,
+		Cwd:          executeInput.Cwd,
+		IsSudo:       executeInput.IsSudo,
+		IsScript:     executeInput.IsScript,
+		StreamResult: executeInput.StreamToFile,
+		StreamToFile: executeInput.StreamToFile,
+	}
+	curJob.VaultEnv, err = al.resolveVaultEnv(ctx, curJob.Command, client, curJob.CreatedBy)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil
 	}
 	sshResp := &comm.RunCmdResponse{}
 	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, curJob, sshResp, al.Log())
+	curJob.VaultEnv = nil
 	if err != nil {
 		if _, ok := err.(*comm.ClientError); ok {
 			al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
@@ -449,6 +469,11 @@ func (al *APIListener) handleCommandsWS(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	if err := al.checkApprovalNotRequired(ctx, orderedClients); err != nil {
+		uiConnTS.WriteError("", err)
+		return
+	}
+
 	inboundMsg.OrderedClients = orderedClients
 	inboundMsg.IsScript = false
 
@@ -138,6 +138,14 @@ func TestHandlePostCommand(t *testing.T) {
 			wantStatusCode: http.StatusOK,
 			wantTimeout:    gotCmdTimeoutSec,
 		},
+		{
+			name:           "vault placeholder on old client",
+			requestBody:    `{"command": "echo {{ vault \"pwd\" }}"}`,
+			cid:            c1.GetID(),
+			clients:        []*clientdata.Client{c1},
+			wantStatusCode: http.StatusBadRequest,
+			wantErrTitle:   fmt.Sprintf("client %s version 0.1.12 doesn't support vault placeholders, please upgrade it", c1.GetID()),
+		},
 		{
 			name:            "valid cmd with interpreter",
 			requestBody:     `{"command": "` + gotCmd + `","interpreter": "powershell"}`,
@@ -287,6 +295,7 @@ func TestHandlePostCommand(t *testing.T) {
 							MaxRequestBytes: 1024 * 1024,
 						},
 					},
+					clientGroupProvider: mockClientGroupProvider{},
 				},
 				Logger: testLog,
 			}
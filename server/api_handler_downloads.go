@@ -0,0 +1,362 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/downloads"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/query"
+)
+
+var downloadsSupportedFilters = map[string]bool{
+	"client_id":  true,
+	"path":       true,
+	"status":     true,
+	"created_by": true,
+}
+
+type DownloadRequest struct {
+	Path       string                `json:"path"`
+	ClientIDs  []string              `json:"client_ids"`
+	GroupIDs   []string              `json:"group_ids"`
+	ClientTags *models.JobClientTags `json:"tags"`
+}
+
+func (dr *DownloadRequest) GetClientIDs() (ids []string) {
+	return dr.ClientIDs
+}
+
+func (dr *DownloadRequest) GetGroupIDs() (ids []string) {
+	return dr.GroupIDs
+}
+
+func (dr *DownloadRequest) GetClientTags() (clientTags *models.JobClientTags) {
+	return dr.ClientTags
+}
+
+// handleGetClientFile handles GET /clients/{client_id}/files.
+// The file is downloaded synchronously and stored on the server.
+func (al *APIListener) handleGetClientFile(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+	path := req.URL.Query().Get("path")
+	if path == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Missing path param.")
+		return
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Active client with id=%q not found.", clientID))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	download, err := al.downloads.Create(clientID, path, curUser.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationDownloads, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(download.ID).
+		WithRequest(map[string]string{"path": path}).
+		Save()
+
+	err = al.fetchClientFile(client, download)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusConflict, fmt.Sprintf("Failed to download %s from client.", path), err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(download))
+}
+
+// handlePostDownloads handles POST /downloads.
+// It starts downloading the file from all targeted clients in the background and returns the created downloads.
+func (al *APIListener) handlePostDownloads(w http.ResponseWriter, req *http.Request) {
+	var reqBody DownloadRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if reqBody.Path == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Missing path.")
+		return
+	}
+
+	ctx := req.Context()
+	orderedClients, _, err := al.getOrderedClientsWithValidation(ctx, &reqBody)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := al.clientService.CheckClientsAccess(orderedClients, curUser, clientGroups); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	created := make([]*downloads.Download, 0, len(orderedClients))
+	for _, client := range orderedClients {
+		download, err := al.downloads.Create(client.GetID(), reqBody.Path, curUser.Username)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		created = append(created, download)
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationDownloads, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(reqBody).
+		SaveForMultipleClients(orderedClients)
+
+	// the downloads are returned before they finish, so the fetched copies must not be shared with the response
+	response := make([]downloads.Download, len(created))
+	for i, download := range created {
+		response[i] = *download
+	}
+	go al.fetchFileFromClients(orderedClients, created)
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(response))
+}
+
+func (al *APIListener) fetchFileFromClients(clients []*clientdata.Client, created []*downloads.Download) {
+	wg := &sync.WaitGroup{}
+	for i := range clients {
+		wg.Add(1)
+		go func(client *clientdata.Client, download *downloads.Download) {
+			defer wg.Done()
+			_ = al.fetchClientFile(client, download)
+		}(clients[i], created[i])
+	}
+	wg.Wait()
+}
+
+// fetchClientFile downloads the file from the client, the result is stored in the download and the audit log
+func (al *APIListener) fetchClientFile(client *clientdata.Client, download *downloads.Download) error {
+	var err error
+	cfg := client.GetFileDownloadConfig()
+	if client.GetDisconnectedAt() != nil {
+		err = al.downloads.Fail(download, errors.New("client is not connected"))
+	} else if cfg != nil && !cfg.Enabled {
+		err = al.downloads.Fail(download, errors.New("file downloads are disabled on this client or not supported by its version, check [file-download] enabled option"))
+	} else if client.GetConnection() == nil {
+		// connected to another node of the cluster
+		err = al.fetchRelayedClientFile(client, download)
+	} else {
+		err = al.downloads.Fetch(client.GetConnection(), download)
+	}
+
+	if err != nil {
+		al.Errorf("download failure: %v, download id: %s, file path: %s, client %s", err, download.ID, download.Path, client.GetID())
+		al.auditLog.Entry(auditlog.ApplicationDownloads, auditlog.ActionFailed).
+			WithID(download.ID).
+			WithClient(client).
+			WithResponse(download).
+			Save()
+		return err
+	}
+
+	al.Infof("download success, download id: %s, file path: %s, client %s", download.ID, download.Path, client.GetID())
+	al.auditLog.Entry(auditlog.ApplicationDownloads, auditlog.ActionSuccess).
+		WithID(download.ID).
+		WithClient(client).
+		WithResponse(download).
+		Save()
+	return nil
+}
+
+// handleListDownloads handles GET /downloads
+func (al *APIListener) handleListDownloads(w http.ResponseWriter, req *http.Request) {
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, nil /* sorts */, downloadsSupportedFilters, nil /* fields */, &query.PaginationConfig{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	list, err := al.downloads.List()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	checkAccess, err := al.downloadAccessChecker(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	filtered := make([]*downloads.Download, 0, len(list))
+	for _, download := range list {
+		matches, err := query.MatchesFilters(download, options.Filters)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !matches {
+			continue
+		}
+		// downloads of clients the user has no access to, including deleted clients, are hidden
+		if err := checkAccess(download); err != nil {
+			continue
+		}
+		filtered = append(filtered, download)
+	}
+
+	totalCount := len(filtered)
+	start, end := options.Pagination.GetStartEnd(totalCount)
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: filtered[start:end],
+		Meta: api.NewMeta(totalCount),
+	})
+}
+
+// handleGetDownload handles GET /downloads/{download_id}
+func (al *APIListener) handleGetDownload(w http.ResponseWriter, req *http.Request) {
+	download, err := al.getDownloadWithAccessCheck(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(download))
+}
+
+// handleGetDownloadContent handles GET /downloads/{download_id}/content
+func (al *APIListener) handleGetDownloadContent(w http.ResponseWriter, req *http.Request) {
+	download, err := al.getDownloadWithAccessCheck(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	f, err := al.downloads.Open(download)
+	if errors.Is(err, downloads.ErrNotFound) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Download with id=%q has no content, status: %s.", download.ID, download.Status))
+		return
+	}
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.Filename()))
+	var modTime time.Time
+	if download.ModTime != nil {
+		modTime = *download.ModTime
+	}
+	http.ServeContent(w, req, download.Filename(), modTime, f)
+}
+
+// handleDeleteDownload handles DELETE /downloads/{download_id}
+func (al *APIListener) handleDeleteDownload(w http.ResponseWriter, req *http.Request) {
+	download, err := al.getDownloadWithAccessCheck(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if download.Status == downloads.StatusRunning {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, "Download is still running.")
+		return
+	}
+
+	if err := al.downloads.Delete(download.ID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationDownloads, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(download.ID).
+		WithRequest(download).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (al *APIListener) getDownloadWithAccessCheck(req *http.Request) (*downloads.Download, error) {
+	id := mux.Vars(req)[routes.ParamDownloadID]
+	download, err := al.downloads.Get(id)
+	if errors.Is(err, downloads.ErrNotFound) {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("Download with id=%q not found.", id),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checkAccess, err := al.downloadAccessChecker(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccess(download); err != nil {
+		return nil, err
+	}
+	return download, nil
+}
+
+// downloadAccessChecker returns a func that fails if the current user has no access to the client a file was downloaded from
+func (al *APIListener) downloadAccessChecker(req *http.Request) (func(*downloads.Download) error, error) {
+	if al.insecureForTests {
+		return func(*downloads.Download) error { return nil }, nil
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	clientGroups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(download *downloads.Download) error {
+		if curUser.IsAdmin() {
+			return nil
+		}
+		return al.clientService.CheckClientAccess(download.ClientID, curUser, clientGroups)
+	}, nil
+}
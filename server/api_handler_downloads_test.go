@@ -0,0 +1,97 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/downloads"
+)
+
+func TestHandleDownloads(t *testing.T) {
+	manager, err := downloads.NewManager(t.TempDir(), testLog)
+	require.NoError(t, err)
+
+	failed, err := manager.Create("client-1", "/etc/shadow", "admin")
+	require.NoError(t, err)
+	require.Error(t, manager.Fail(failed, assert.AnError))
+	running, err := manager.Create("client-2", "/var/log/app.log", "admin")
+	require.NoError(t, err)
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			downloads: manager,
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	t.Run("list with filter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/downloads?filter[status]=failed", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		result := struct {
+			Data []*downloads.Download `json:"data"`
+			Meta struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 1, result.Meta.Count)
+		require.Len(t, result.Data, 1)
+		assert.Equal(t, failed.ID, result.Data[0].ID)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/downloads/"+running.ID, nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"running"`)
+	})
+
+	t.Run("content of failed download", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/downloads/"+failed.ID+"/content", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete running download", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/downloads/"+running.ID, nil))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/downloads/"+failed.ID, nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		w = httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/downloads/"+failed.ID, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("post without path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/downloads", strings.NewReader(`{"client_ids":["client-1"]}`)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Missing path.")
+	})
+}
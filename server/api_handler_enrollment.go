@@ -0,0 +1,146 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/enrollment"
+	"github.com/openrport/openrport/server/routes"
+)
+
+// enrollmentTokenCreated is returned only once, when the token is created
+type enrollmentTokenCreated struct {
+	*enrollment.Token
+	Value string `json:"token"`
+}
+
+func (al *APIListener) handleListEnrollmentTokens(w http.ResponseWriter, req *http.Request) {
+	tokens, err := al.enrollmentManager.ListTokens(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tokens))
+}
+
+func (al *APIListener) handlePostEnrollmentToken(w http.ResponseWriter, req *http.Request) {
+	// enrolled clients get credentials added to the client auth provider
+	if !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	var reqBody enrollment.TokenRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	token, value, err := al.enrollmentManager.CreateToken(req.Context(), reqBody, username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientEnrollmentToken, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(reqBody).
+		WithResponse(token).
+		WithID(token.ID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(enrollmentTokenCreated{
+		Token: token,
+		Value: value,
+	}))
+
+	al.Debugf("Enrollment token[id=%q] created.", token.ID)
+}
+
+func (al *APIListener) handleDeleteEnrollmentToken(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamEnrollmentTokenID]
+
+	if err := al.enrollmentManager.DeleteToken(req.Context(), id); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientEnrollmentToken, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+
+	al.Debugf("Enrollment token[id=%q] deleted.", id)
+}
+
+func (al *APIListener) handleListEnrollments(w http.ResponseWriter, req *http.Request) {
+	payload, err := al.enrollmentManager.List(req.Context(), req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, payload)
+}
+
+func (al *APIListener) handleGetEnrollment(w http.ResponseWriter, req *http.Request) {
+	e, err := al.enrollmentManager.Get(req.Context(), mux.Vars(req)[routes.ParamClientAuthID])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(e))
+}
+
+func (al *APIListener) handleApproveEnrollment(w http.ResponseWriter, req *http.Request) {
+	if !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	e, err := al.enrollmentManager.Approve(req.Context(), mux.Vars(req)[routes.ParamClientAuthID], username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientEnrollment, auditlog.ActionApprove).
+		WithHTTPRequest(req).
+		WithResponse(e).
+		WithID(e.ClientAuthID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(e))
+
+	al.Infof("Enrollment of client auth %q approved.", e.ClientAuthID)
+}
+
+func (al *APIListener) handleRejectEnrollment(w http.ResponseWriter, req *http.Request) {
+	if !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	e, err := al.enrollmentManager.Reject(req.Context(), mux.Vars(req)[routes.ParamClientAuthID], username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientEnrollment, auditlog.ActionReject).
+		WithHTTPRequest(req).
+		WithResponse(e).
+		WithID(e.ClientAuthID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(e))
+
+	al.Infof("Enrollment of client auth %q rejected.", e.ClientAuthID)
+}
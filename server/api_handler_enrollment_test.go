@@ -0,0 +1,105 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientsmigration "github.com/openrport/openrport/db/migration/clients"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/server/enrollment"
+)
+
+func TestHandleEnrollment(t *testing.T) {
+	admin := makeTestUser("admin")
+	al := makeAPIListener(admin, clients.NewClientRepository(nil, &hour, testLog), 60, nil, testLog)
+
+	db, err := sqlite.New(":memory:", clientsmigration.AssetNames(), clientsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	al.config.Server.AuthWrite = true
+	al.clientAuthProvider = clientsauth.NewMockFileProvider(nil, t)
+	al.enrollmentManager = enrollment.NewManager(enrollment.NewSQLiteProvider(db), al.clientAuthProvider)
+	al.initRouter()
+
+	ctx := api.WithUser(context.Background(), admin.Username)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/enrollment-tokens", strings.NewReader(`{"description": "office", "require_approval": true}`))
+	al.router.ServeHTTP(w, req.WithContext(ctx))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	created := struct {
+		Data struct {
+			ID              string `json:"id"`
+			Token           string `json:"token"`
+			MaxUses         int    `json:"max_uses"`
+			RequireApproval bool   `json:"require_approval"`
+			CreatedBy       string `json:"created_by"`
+		} `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Data.Token, created.Data.ID+"_"))
+	assert.Equal(t, 1, created.Data.MaxUses)
+	assert.True(t, created.Data.RequireApproval)
+	assert.Equal(t, "admin", created.Data.CreatedBy)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/enrollment-tokens", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Data.Token)
+	assert.NotContains(t, w.Body.String(), "hash")
+
+	e, _, err := al.enrollmentManager.Enroll(ctx, created.Data.Token, "client-1", "Client 1", "192.0.2.1")
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/enrollments?filter[status]=pending", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	list := struct {
+		Data []enrollment.Enrollment `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, e.ClientAuthID, list.Data[0].ClientAuthID)
+	assert.Equal(t, "Client 1", list.Data[0].ClientName)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/enrollments/"+e.ClientAuthID+"/approve", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, al.enrollmentManager.CheckApproved(ctx, e.ClientAuthID))
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/enrollments/"+e.ClientAuthID+"/reject", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/enrollment-tokens/"+created.Data.ID, nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/enrollment-tokens/"+created.Data.ID, nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlePostEnrollmentTokenReadOnlyClientAuth(t *testing.T) {
+	admin := makeTestUser("admin")
+	al := makeAPIListener(admin, clients.NewClientRepository(nil, &hour, testLog), 60, nil, testLog)
+	al.clientAuthProvider = clientsauth.NewMockFileProvider(nil, t)
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/enrollment-tokens", strings.NewReader(`{}`))
+	al.router.ServeHTTP(w, req.WithContext(api.WithUser(context.Background(), admin.Username)))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
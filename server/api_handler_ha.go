@@ -0,0 +1,191 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/downloads"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/ws"
+)
+
+// dispatchedJobPollInterval is the interval to check the result of a job run by another node
+var dispatchedJobPollInterval = time.Second
+
+// dispatchedJobGrace is the time to wait for the result of a job run by another node after its timeout
+var dispatchedJobGrace = 30 * time.Second
+
+// haClientPath returns the path of the API of another node for requests about a client connected to that node
+func haClientPath(clientID, resource string) string {
+	return routes.AllRoutesPrefix + "/ha/clients/" + url.PathEscape(clientID) + "/" + resource
+}
+
+// handleHAPostClientJob handles POST /ha/clients/{client_id}/jobs. Another node sends the job of a multi-client job
+// for a client connected to this node. The job is stored by the sending node.
+func (al *APIListener) handleHAPostClientJob(w http.ResponseWriter, req *http.Request) {
+	client, err := al.getClientConnectedHere(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	var job models.Job
+	if err := parseRequestBody(req.Body, &job); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if job.ClientID != client.GetID() {
+		http.Error(w, fmt.Sprintf("job is for client %s", job.ClientID), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := al.runJob(req.Context(), &job, client)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+// handleHAPostClientDownload handles POST /ha/clients/{client_id}/downloads. Another node downloads a file from a
+// client connected to this node, the file is passed on without storing it. The result of the client is sent as trailer.
+func (al *APIListener) handleHAPostClientDownload(w http.ResponseWriter, req *http.Request) {
+	client, err := al.getClientConnectedHere(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	var downloadReq comm.FileDownloadRequest
+	if err := parseRequestBody(req.Body, &downloadReq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Trailer", downloads.RelayResultTrailer)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	result, err := downloads.Relay(client.GetConnection(), downloadReq.Path, w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		al.Errorf("failed to encode download result: %v", err)
+		return
+	}
+	w.Header().Set(downloads.RelayResultTrailer, string(b))
+}
+
+func (al *APIListener) getClientConnectedHere(req *http.Request) (*clientdata.Client, error) {
+	client, err := al.clientService.GetActiveByID(mux.Vars(req)[routes.ParamClientID])
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !client.IsConnectedHere() {
+		return nil, ErrClientNotConnected
+	}
+	return client, nil
+}
+
+// dispatchJob lets the node the client is connected to run the job
+func (al *APIListener) dispatchJob(job *models.Job) (*comm.RunCmdResponse, error) {
+	ctx := context.Background()
+	apiURL, err := al.ha.ClientNodeURL(ctx, job.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if apiURL == "" {
+		return nil, ErrClientNotConnected
+	}
+
+	resp, err := al.ha.Dispatch(ctx, apiURL, haClientPath(job.ClientID, "jobs"), job)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	runResp := &comm.RunCmdResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(runResp); err != nil {
+		return nil, fmt.Errorf("invalid response of node %s: %v", apiURL, err)
+	}
+	return runResp, nil
+}
+
+// awaitDispatchedJob waits for the result of a job run by another node. That node receives the result from the
+// client and stores it in the shared DB, from there it's passed on like the results of clients connected to this node.
+func (al *APIListener) awaitDispatchedJob(uiConnTS *ws.ConcurrentWebSocket, job models.Job) {
+	timeout := time.Duration(job.TimeoutSec) * time.Second
+	if job.TimeoutSec <= 0 {
+		timeout = time.Duration(al.config.Server.RunRemoteCmdTimeoutSec) * time.Second
+	}
+	deadline := time.Now().Add(timeout + dispatchedJobGrace)
+
+	result := &job
+	for time.Now().Before(deadline) {
+		time.Sleep(dispatchedJobPollInterval)
+		stored, err := al.jobProvider.GetByJID(job.ClientID, job.JID)
+		if err != nil {
+			al.Errorf("%s, Failed to get the result of the job: %v", job.LogPrefix(), err)
+			continue
+		}
+		if stored != nil && stored.Status != models.JobStatusRunning {
+			result = stored
+			break
+		}
+	}
+	if result.Status == models.JobStatusRunning {
+		al.Errorf("%s, No result of the job run by another node after %s.", job.LogPrefix(), timeout+dispatchedJobGrace)
+	}
+
+	if uiConnTS != nil {
+		_ = uiConnTS.WriteJSON(result)
+	}
+	if job.MultiJobID != nil {
+		if done := al.jobsDoneChannel.Get(*job.MultiJobID); done != nil {
+			done <- result
+		}
+	}
+}
+
+// fetchRelayedClientFile downloads the file from a client connected to another node, which relays the file
+func (al *APIListener) fetchRelayedClientFile(client *clientdata.Client, download *downloads.Download) error {
+	if al.ha == nil {
+		return al.downloads.Fail(download, ErrClientNotConnected)
+	}
+
+	ctx := context.Background()
+	apiURL, err := al.ha.ClientNodeURL(ctx, client.GetID())
+	if err != nil {
+		return al.downloads.Fail(download, err)
+	}
+	if apiURL == "" {
+		return al.downloads.Fail(download, ErrClientNotConnected)
+	}
+
+	resp, err := al.ha.Dispatch(ctx, apiURL, haClientPath(client.GetID(), "downloads"), comm.FileDownloadRequest{Path: download.Path})
+	if err != nil {
+		return al.downloads.Fail(download, err)
+	}
+	defer resp.Body.Close()
+
+	return al.downloads.FetchRelayed(resp.Body, download, func() (*comm.FileDownloadResult, error) {
+		result := &comm.FileDownloadResult{}
+		if err := json.Unmarshal([]byte(resp.Trailer.Get(downloads.RelayResultTrailer)), result); err != nil {
+			return nil, errors.New("connection closed before the file was transferred completely")
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,137 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/test"
+)
+
+func TestHandleHAPostClientJob(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	sshResp := comm.RunCmdResponse{Pid: 123, StartedAt: time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC)}
+	sshRespBytes, err := json.Marshal(sshResp)
+	require.NoError(t, err)
+	connMock.ReturnResponsePayload = sshRespBytes
+
+	local := clients.New(t).ID("local").Connection(connMock).Logger(testLog).Build()
+	elsewhere := clients.New(t).ID("elsewhere").Logger(testLog).Build()
+
+	testCases := []struct {
+		Name           string
+		ClientID       string
+		Body           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "connected here",
+			ClientID:       "local",
+			Body:           `{"jid": "job-1", "client_id": "local", "command": "date"}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   `{"Pid": 123, "StartedAt": "2020-10-10T10:10:10Z"}`,
+		},
+		{
+			Name:           "connected to another node",
+			ClientID:       "elsewhere",
+			Body:           `{"jid": "job-1", "client_id": "elsewhere", "command": "date"}`,
+			ExpectedStatus: http.StatusConflict,
+			ExpectedBody:   "client is not connected\n",
+		},
+		{
+			Name:           "job of other client",
+			ClientID:       "local",
+			Body:           `{"jid": "job-1", "client_id": "other", "command": "date"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedBody:   "job is for client other\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			al := APIListener{
+				Server: &Server{
+					clientService: &clientToClientMockService{
+						SimpleMockClientService: &SimpleMockClientService{
+							ActiveClients: []*clientdata.Client{local, elsewhere},
+						},
+					},
+				},
+				Logger: testLog,
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ha/clients/"+tc.ClientID+"/jobs", strings.NewReader(tc.Body))
+			req = mux.SetURLVars(req, map[string]string{"client_id": tc.ClientID})
+			w := httptest.NewRecorder()
+
+			al.handleHAPostClientJob(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			if tc.ExpectedStatus == http.StatusOK {
+				assert.JSONEq(t, tc.ExpectedBody, w.Body.String())
+			} else {
+				assert.Equal(t, tc.ExpectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAwaitDispatchedJob(t *testing.T) {
+	dispatchedJobPollInterval = time.Millisecond
+	defer func() {
+		dispatchedJobPollInterval = time.Second
+	}()
+
+	jp := makeJobsProvider(t, DataSourceOptions, testLog)
+	defer jp.Close()
+	multiJobID := "multi-1"
+	job := models.Job{
+		JID:        "job-1",
+		ClientID:   "elsewhere",
+		Status:     models.JobStatusRunning,
+		MultiJobID: &multiJobID,
+		TimeoutSec: 60,
+	}
+	require.NoError(t, jp.CreateJob(&job))
+
+	al := APIListener{
+		Server: &Server{
+			config:      &chconfig.Config{},
+			jobProvider: jp,
+			jobsDoneChannel: jobResultChanMap{
+				m: make(map[string]chan *models.Job),
+			},
+		},
+		Logger: testLog,
+	}
+	done := make(chan *models.Job)
+	al.jobsDoneChannel.Set(multiJobID, done)
+
+	go al.awaitDispatchedJob(nil, job)
+
+	// the node the client is connected to stores the result
+	finished := job
+	finished.Status = models.JobStatusSuccessful
+	require.NoError(t, jp.SaveJob(&finished))
+
+	select {
+	case result := <-done:
+		assert.Equal(t, "job-1", result.JID)
+		assert.Equal(t, models.JobStatusSuccessful, result.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result of the dispatched job")
+	}
+}
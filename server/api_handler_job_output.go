@@ -0,0 +1,195 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/openrport/openrport/server/joboutput"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/models"
+)
+
+// jobOutputTailInterval is how often a tailed job output file is checked for new data
+var jobOutputTailInterval = 500 * time.Millisecond
+
+// handleDownloadJobOutput handles GET /clients/{client_id}/commands/{job_id}/output/{stream}
+func (al *APIListener) handleDownloadJobOutput(w http.ResponseWriter, req *http.Request) {
+	job, stream, ok := al.getJobWithOutput(w, req)
+	if !ok {
+		return
+	}
+
+	f, err := al.jobOutput.Open(job.JID, stream)
+	if err != nil {
+		al.handleJobOutputError(w, job.JID, err)
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("%s.%s.txt", job.JID, stream)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	// the output of running jobs is still growing, so it gets no modification time to not be cached
+	var modTime time.Time
+	if job.FinishedAt != nil {
+		modTime = *job.FinishedAt
+	}
+	http.ServeContent(w, req, filename, modTime, f)
+}
+
+// handleJobOutputWS handles GET /ws/clients/{client_id}/commands/{job_id}/output/{stream}
+// It sends the output from the given offset as text messages and follows it until the job is finished.
+func (al *APIListener) handleJobOutputWS(w http.ResponseWriter, req *http.Request) {
+	job, stream, ok := al.getJobWithOutput(w, req)
+	if !ok {
+		return
+	}
+
+	var offset int64
+	if v := req.URL.Query().Get("offset"); v != "" {
+		var err error
+		offset, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || offset < 0 {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset param: %s.", v))
+			return
+		}
+	}
+
+	uiConn, err := apiUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		al.Errorf("Failed to establish WS connection: %v", err)
+		return
+	}
+	defer uiConn.Close()
+
+	// the websocket is only read to notice when it's closed by the other side
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := uiConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	l := al.Logger.Fork("job-output#%s", job.JID)
+	err = al.tailJobOutput(uiConn, job, stream, offset, closed)
+	if err != nil {
+		l.Errorf("Failed to tail %s: %v", stream, err)
+		_ = uiConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, ""), time.Now().Add(time.Second))
+		return
+	}
+
+	l.Debugf("Tailing %s finished", stream)
+	_ = uiConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
+// tailJobOutput writes the output file to the websocket until the job is finished and all output is sent,
+// or the websocket is closed
+func (al *APIListener) tailJobOutput(uiConn *websocket.Conn, job *models.Job, stream string, offset int64, closed <-chan struct{}) error {
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	data := make([]byte, 4096)
+	for {
+		finished := job.Status != models.JobStatusRunning
+
+		if f == nil {
+			var err error
+			f, err = al.jobOutput.Open(job.JID, stream)
+			if err != nil && !errors.Is(err, joboutput.ErrNotFound) {
+				return err
+			}
+			if f != nil {
+				if _, err := f.Seek(offset, io.SeekStart); err != nil {
+					return err
+				}
+			}
+		}
+
+		// send everything written so far
+		for f != nil {
+			n, err := f.Read(data)
+			if n > 0 {
+				if err := uiConn.WriteMessage(websocket.TextMessage, data[:n]); err != nil {
+					return err
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-closed:
+			return nil
+		case <-time.After(jobOutputTailInterval):
+		}
+
+		updated, err := al.jobProvider.GetByJID(job.ClientID, job.JID)
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			return nil
+		}
+		job = updated
+	}
+}
+
+// getJobWithOutput returns the job and the requested output stream, it writes an error response if the job has no output stored
+func (al *APIListener) getJobWithOutput(w http.ResponseWriter, req *http.Request) (*models.Job, string, bool) {
+	vars := mux.Vars(req)
+	cid := vars[routes.ParamClientID]
+	jid := vars[routes.ParamJobID]
+	stream := vars[routes.ParamOutputStream]
+
+	if stream != models.ChannelStdout && stream != models.ChannelStderr {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid output stream %q, expected %q or %q.", stream, models.ChannelStdout, models.ChannelStderr))
+		return nil, "", false
+	}
+
+	job, err := al.jobProvider.GetByJID(cid, jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a job[id=%q].", jid), err)
+		return nil, "", false
+	}
+	if job == nil || job.ClientID != cid {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Job[id=%q] not found.", jid))
+		return nil, "", false
+	}
+	if !job.StreamToFile {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Job[id=%q] was not started with stream_to_file, its output is not stored.", jid))
+		return nil, "", false
+	}
+
+	return job, stream, true
+}
+
+func (al *APIListener) handleJobOutputError(w http.ResponseWriter, jid string, err error) {
+	if errors.Is(err, joboutput.ErrNotFound) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Output of job[id=%q] not found.", jid))
+		return
+	}
+	al.jsonError(w, err)
+}
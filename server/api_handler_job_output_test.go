@@ -0,0 +1,172 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/joboutput"
+	"github.com/openrport/openrport/server/test/jb"
+	"github.com/openrport/openrport/share/models"
+)
+
+func makeJobOutputAPIListener(t *testing.T) (*APIListener, *joboutput.Manager) {
+	t.Helper()
+	jp := makeJobsProvider(t, DataSourceOptions, testLog)
+	t.Cleanup(func() { jp.Close() })
+	output, err := joboutput.NewManager(t.TempDir(), 0, testLog)
+	require.NoError(t, err)
+
+	al := &APIListener{
+		Server: &Server{
+			jobProvider: jp,
+			jobOutput:   output,
+		},
+		Logger: testLog,
+	}
+	return al, output
+}
+
+func writeJobOutput(t *testing.T, output *joboutput.Manager, jid, stream, data string) {
+	t.Helper()
+	f, err := output.Create(jid, stream)
+	require.NoError(t, err)
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestHandleDownloadJobOutput(t *testing.T) {
+	al, output := makeJobOutputAPIListener(t)
+
+	job := jb.New(t).ClientID("client-1").Status(models.JobStatusSuccessful).FinishedAt(time.Now()).Build()
+	job.StreamToFile = true
+	require.NoError(t, al.jobProvider.SaveJob(job))
+	inlineJob := jb.New(t).ClientID("client-1").Build()
+	require.NoError(t, al.jobProvider.SaveJob(inlineJob))
+	writeJobOutput(t, output, job.JID, models.ChannelStdout, "0123456789")
+
+	testCases := []struct {
+		Name           string
+		ClientID       string
+		JID            string
+		Stream         string
+		Range          string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "full output",
+			ClientID:       "client-1",
+			JID:            job.JID,
+			Stream:         models.ChannelStdout,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "0123456789",
+		},
+		{
+			Name:           "range",
+			ClientID:       "client-1",
+			JID:            job.JID,
+			Stream:         models.ChannelStdout,
+			Range:          "bytes=2-4",
+			ExpectedStatus: http.StatusPartialContent,
+			ExpectedBody:   "234",
+		},
+		{
+			Name:           "no stderr written",
+			ClientID:       "client-1",
+			JID:            job.JID,
+			Stream:         models.ChannelStderr,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "invalid stream",
+			ClientID:       "client-1",
+			JID:            job.JID,
+			Stream:         "stdin",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "other client",
+			ClientID:       "client-2",
+			JID:            job.JID,
+			Stream:         models.ChannelStdout,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "output not streamed to file",
+			ClientID:       "client-1",
+			JID:            inlineJob.JID,
+			Stream:         models.ChannelStdout,
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/output", nil)
+			if tc.Range != "" {
+				req.Header.Set("Range", tc.Range)
+			}
+			req = mux.SetURLVars(req, map[string]string{
+				"client_id": tc.ClientID,
+				"job_id":    tc.JID,
+				"stream":    tc.Stream,
+			})
+			w := httptest.NewRecorder()
+
+			al.handleDownloadJobOutput(w, req)
+
+			require.Equal(t, tc.ExpectedStatus, w.Code, w.Body.String())
+			if tc.ExpectedBody != "" {
+				assert.Equal(t, tc.ExpectedBody, w.Body.String())
+				assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHandleJobOutputWS(t *testing.T) {
+	defer func(interval time.Duration) { jobOutputTailInterval = interval }(jobOutputTailInterval)
+	jobOutputTailInterval = 10 * time.Millisecond
+
+	al, output := makeJobOutputAPIListener(t)
+
+	job := jb.New(t).ClientID("client-1").Status(models.JobStatusRunning).Build()
+	job.StreamToFile = true
+	require.NoError(t, al.jobProvider.SaveJob(job))
+	writeJobOutput(t, output, job.JID, models.ChannelStdout, "first ")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{client_id}/{job_id}/{stream}", al.handleJobOutputWS)
+	s := httptest.NewServer(router)
+	defer s.Close()
+
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/client-1/" + job.JID + "/stdout?offset=2"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "rst ", string(msg))
+
+	// output written later is followed until the job is finished
+	writeJobOutput(t, output, job.JID, models.ChannelStdout, "second")
+	_, msg, err = conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(msg))
+
+	job.Status = models.JobStatusSuccessful
+	require.NoError(t, al.jobProvider.SaveJob(job))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+}
@@ -20,6 +20,8 @@ type twoFAResponse struct {
 	SendTo         string `json:"send_to"`
 	DeliveryMethod string `json:"delivery_method"`
 	TotPKeyStatus  string `json:"totp_key_status"`
+	// WebAuthnKeyStatus is set only if the login has to be confirmed with a webauthn credential
+	WebAuthnKeyStatus string `json:"webauthn_key_status,omitempty"`
 }
 
 type loginResponse struct {
@@ -103,6 +105,12 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
+	// security keys take precedence over the other second factors
+	if al.isWebAuthnRequired(user) {
+		al.sendWebAuthnLoginToken(user, lifetime, w, req)
+		return
+	}
+
 	if al.config.API.IsTwoFAOn() {
 		sendTo, err := al.twoFASrv.SendToken(req.Context(), username, req.UserAgent(), chshare.RemoteIP(req))
 		if err != nil {
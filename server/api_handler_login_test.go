@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -20,6 +22,10 @@ import (
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/ptr"
 	"github.com/openrport/openrport/share/random"
 	"github.com/openrport/openrport/share/security"
@@ -140,6 +146,15 @@ func TestAPITokenOps(t *testing.T) {
 			wantErrTitle:   "missing or invalid name.",
 			wantErrDetail:  "field name is required, 250 characters max",
 		},
+		{
+			descr:          "new token with invalid restrictions",
+			requestMethod:  http.MethodPost,
+			requestURL:     "/api/v1/me/tokens",
+			requestBody:    strings.NewReader(`{"scope": "` + string(authorization.APITokenRead) + `", "name": "ci", "restrictions": {"allowed_ips": ["10.0.0.0/33"]}}`),
+			wantStatusCode: http.StatusBadRequest,
+			wantErrTitle:   "invalid restrictions.",
+			wantErrDetail:  `invalid CIDR "10.0.0.0/33": invalid CIDR address: 10.0.0.0/33`,
+		},
 		{
 			descr:          "new token read creation",
 			requestMethod:  http.MethodPost,
@@ -499,6 +514,291 @@ func TestWrapWithAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestWrapWithAuthMiddlewareRestrictedToken(t *testing.T) {
+	ctx := context.Background()
+
+	user := &users.User{
+		Username: "user1",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+	}
+	tokenProvider := CommonAPITokenTestDb(t, "user1", "theprefi", "the name", authorization.APITokenReadWrite, "mynicefi-xedl-enth-long-livedpasswor")
+	err := tokenProvider.Save(ctx, &authorization.APIToken{
+		Username:  "user1",
+		Prefix:    "restrict",
+		Name:      "ci",
+		ExpiresAt: ptr.Time(time.Date(2051, 1, 1, 2, 0, 0, 0, time.UTC)),
+		Scope:     authorization.APITokenReadWrite,
+		Restrictions: &authorization.APITokenRestrictions{
+			ClientTags: []string{"Linux"},
+			Endpoints:  []bearer.Scope{{URI: "/api/v1/clients/*", Method: http.MethodGet}},
+			AllowedIPs: []string{"192.0.2.0/24"},
+		},
+		Token: "mynicefi-xedl-enth-long-livedpasswor",
+	})
+	require.NoError(t, err)
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	al := APIListener{
+		Logger:      testLog,
+		apiSessions: newEmptyAPISessionCache(t),
+		bannedUsers: security.NewBanList(0),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes:  1024 * 1024,
+					TrustedProxyNets: []*net.IPNet{{IP: net.IPv4(10, 0, 0, 1), Mask: net.CIDRMask(32, 32)}},
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		tokenManager: authorization.NewManager(tokenProvider),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+
+	var gotAPIToken string
+	router := mux.NewRouter()
+	router.Use(al.wrapWithAuthMiddleware(false))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		gotAPIToken = api.GetAPIToken(r.Context())
+	}
+	router.HandleFunc("/api/v1/clients", handler)
+	router.HandleFunc("/api/v1/clients/{client_id}", handler)
+
+	testCases := []struct {
+		Name             string
+		Password         string
+		Method           string
+		URL              string
+		RemoteAddr       string
+		XForwardedFor    string
+		ExpectedStatus   int
+		ExpectedAPIToken string
+	}{
+		{
+			Name:             "unrestricted token",
+			Password:         "theprefi_mynicefi-xedl-enth-long-livedpasswor",
+			Method:           http.MethodDelete,
+			URL:              "/api/v1/clients",
+			RemoteAddr:       "198.51.100.1:1234",
+			ExpectedStatus:   http.StatusOK,
+			ExpectedAPIToken: "theprefi",
+		},
+		{
+			Name:           "password",
+			Password:       "pwd",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients",
+			RemoteAddr:     "198.51.100.1:1234",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:             "restricted token",
+			Password:         "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:           http.MethodGet,
+			URL:              "/api/v1/clients/client-1",
+			RemoteAddr:       "192.0.2.10:1234",
+			ExpectedStatus:   http.StatusOK,
+			ExpectedAPIToken: "restrict",
+		},
+		{
+			Name:           "restricted token, not allowed ip",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/client-1",
+			RemoteAddr:     "198.51.100.1:1234",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "restricted token, spoofed forwarded ip",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/client-1",
+			RemoteAddr:     "198.51.100.1:1234",
+			XForwardedFor:  "192.0.2.10",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:             "restricted token, via trusted proxy",
+			Password:         "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:           http.MethodGet,
+			URL:              "/api/v1/clients/client-1",
+			RemoteAddr:       "10.0.0.1:1234",
+			XForwardedFor:    "198.51.100.1, 192.0.2.10",
+			ExpectedStatus:   http.StatusOK,
+			ExpectedAPIToken: "restrict",
+		},
+		{
+			Name:           "restricted token, spoofed forwarded ip via trusted proxy",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/client-1",
+			RemoteAddr:     "10.0.0.1:1234",
+			XForwardedFor:  "192.0.2.10, 198.51.100.1",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "restricted token, not allowed method",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodDelete,
+			URL:            "/api/v1/clients/client-1",
+			RemoteAddr:     "192.0.2.10:1234",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "restricted token, request on all clients",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients",
+			RemoteAddr:     "192.0.2.10:1234",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "restricted token, unknown client",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/clients/client-2",
+			RemoteAddr:     "192.0.2.10:1234",
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			gotAPIToken = ""
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.Method, tc.URL, nil)
+			req.RemoteAddr = tc.RemoteAddr
+			if tc.XForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.XForwardedFor)
+			}
+			req.SetBasicAuth(user.Username, tc.Password)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code, w.Body.String())
+			assert.Equal(t, tc.ExpectedAPIToken, gotAPIToken)
+		})
+	}
+}
+
+func TestClientRestrictedTokenOnClientSets(t *testing.T) {
+	ctx := context.Background()
+
+	user := &users.User{
+		Username: "user1",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	tokenProvider := CommonAPITokenTestDb(t, "user1", "theprefi", "the name", authorization.APITokenReadWrite, "mynicefi-xedl-enth-long-livedpasswor")
+	err := tokenProvider.Save(ctx, &authorization.APIToken{
+		Username:     "user1",
+		Prefix:       "restrict",
+		Name:         "ci",
+		Scope:        authorization.APITokenReadWrite,
+		Restrictions: &authorization.APITokenRestrictions{ClientTags: []string{"Linux"}},
+		Token:        "mynicefi-xedl-enth-long-livedpasswor",
+	})
+	require.NoError(t, err)
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c1.SetTags([]string{"Linux"})
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+	c2.SetTags([]string{"Windows"})
+	al := APIListener{
+		Logger:      testLog,
+		apiSessions: newEmptyAPISessionCache(t),
+		bannedUsers: security.NewBanList(0),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog), testLog, nil),
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		tokenManager: authorization.NewManager(tokenProvider),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+
+	t.Run("auth middleware", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Use(al.wrapWithAuthMiddleware(false))
+		handler := func(w http.ResponseWriter, r *http.Request) {}
+		router.HandleFunc("/api/v1/commands", handler).Methods(http.MethodPost).Name(routes.MultiClientCommandRouteName)
+		router.HandleFunc("/api/v1/users", handler).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/commands", nil)
+		req.SetBasicAuth(user.Username, "restrict_mynicefi-xedl-enth-long-livedpasswor")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "the clients are checked by the handler")
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.SetBasicAuth(user.Username, "restrict_mynicefi-xedl-enth-long-livedpasswor")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	tokenCtx := api.WithAPIToken(api.WithUser(ctx, "user1"), "restrict")
+
+	t.Run("multi client request", func(t *testing.T) {
+		testCases := []struct {
+			Name          string
+			Request       *DownloadRequest
+			ExpectedError string
+		}{
+			{
+				Name:    "allowed client",
+				Request: &DownloadRequest{ClientIDs: []string{"client-1"}},
+			},
+			{
+				Name:          "not allowed client",
+				Request:       &DownloadRequest{ClientIDs: []string{"client-1", "client-2"}},
+				ExpectedError: "The API token is not allowed for client client-2.",
+			},
+			{
+				Name:          "not allowed tag",
+				Request:       &DownloadRequest{ClientTags: &models.JobClientTags{Tags: []string{"Windows"}, Operator: "OR"}},
+				ExpectedError: "The API token is not allowed for client client-2.",
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				_, _, err := al.getOrderedClientsWithValidation(tokenCtx, tc.Request)
+				if tc.ExpectedError != "" {
+					assert.EqualError(t, err, tc.ExpectedError)
+				} else {
+					assert.NoError(t, err)
+				}
+			})
+		}
+
+		_, _, err := al.getOrderedClientsWithValidation(api.WithUser(ctx, "user1"), &DownloadRequest{ClientIDs: []string{"client-1", "client-2"}})
+		assert.NoError(t, err, "a password isn't restricted")
+	})
+
+	t.Run("list clients", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clients", nil).WithContext(tokenCtx)
+		al.handleGetClients(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		resp := struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, "client-1", resp.Data[0].ID)
+	})
+}
+
 func TestAPISessionUpdates(t *testing.T) {
 	ctx := context.Background()
 
@@ -191,7 +191,12 @@ func (al *APIListener) handleChangeMe(w http.ResponseWriter, req *http.Request)
 			return
 		}
 
-		if !verifyPassword(curUser.Password, r.OldPassword) {
+		passwordOk, err := al.verifyUserPassword(curUser, r.OldPassword)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !passwordOk {
 			al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "Incorrect old password.")
 			return
 		}
@@ -238,11 +243,12 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	type APITokenPayload struct {
-		Prefix    string                      `json:"prefix" db:"prefix"`
-		Name      string                      `json:"name" db:"name"`
-		CreatedAt *time.Time                  `json:"created_at" db:"created_at"`
-		ExpiresAt *time.Time                  `json:"expires_at" db:"expires_at"`
-		Scope     authorization.APITokenScope `json:"scope" db:"scope"`
+		Prefix       string                              `json:"prefix" db:"prefix"`
+		Name         string                              `json:"name" db:"name"`
+		CreatedAt    *time.Time                          `json:"created_at" db:"created_at"`
+		ExpiresAt    *time.Time                          `json:"expires_at" db:"expires_at"`
+		Scope        authorization.APITokenScope         `json:"scope" db:"scope"`
+		Restrictions *authorization.APITokenRestrictions `json:"restrictions,omitempty" db:"restrictions"`
 	}
 
 	apitokenset, err := al.tokenManager.GetAll(req.Context(), user.Username)
@@ -255,11 +261,12 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 	for _, at := range apitokenset {
 		apiTokenToSend = append(apiTokenToSend,
 			APITokenPayload{
-				Prefix:    at.Prefix,
-				Name:      at.Name,
-				CreatedAt: at.CreatedAt,
-				ExpiresAt: at.ExpiresAt,
-				Scope:     at.Scope,
+				Prefix:       at.Prefix,
+				Name:         at.Name,
+				CreatedAt:    at.CreatedAt,
+				ExpiresAt:    at.ExpiresAt,
+				Scope:        at.Scope,
+				Restrictions: at.Restrictions,
 			})
 	}
 
@@ -278,9 +285,10 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	var r struct {
-		Scope     authorization.APITokenScope `json:"scope"`
-		Name      string                      `json:"name"`
-		ExpiresAt *time.Time                  `json:"expires_at"`
+		Scope        authorization.APITokenScope         `json:"scope"`
+		Name         string                              `json:"name"`
+		ExpiresAt    *time.Time                          `json:"expires_at"`
+		Restrictions *authorization.APITokenRestrictions `json:"restrictions"`
 	}
 	err = parseRequestBody(req.Body, &r)
 	if err != nil {
@@ -303,6 +311,13 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if r.Restrictions != nil {
+		if err := r.Restrictions.Validate(); err != nil {
+			al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, "", "invalid restrictions.", err.Error())
+			return
+		}
+	}
+
 	createdAt := ptr.Time(time.Now().Truncate(time.Second).UTC())
 	if r.ExpiresAt == nil {
 		r.ExpiresAt = ptr.Time(createdAt.AddDate(1 /* year */, 0, 0)) // expiry date default is creation date + one year
@@ -322,13 +337,14 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 	}
 
 	newAPIToken := &authorization.APIToken{
-		Username:  user.Username,
-		Prefix:    newPrefix,
-		Name:      r.Name,
-		Scope:     r.Scope,
-		CreatedAt: createdAt,
-		ExpiresAt: r.ExpiresAt,
-		Token:     tokenHashStr,
+		Username:     user.Username,
+		Prefix:       newPrefix,
+		Name:         r.Name,
+		Scope:        r.Scope,
+		Restrictions: r.Restrictions,
+		CreatedAt:    createdAt,
+		ExpiresAt:    r.ExpiresAt,
+		Token:        tokenHashStr,
 	}
 	err = al.tokenManager.Create(req.Context(), newAPIToken)
 	if err != nil {
@@ -345,10 +361,11 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(
 		authorization.APIToken{
-			ExpiresAt: r.ExpiresAt,
-			Scope:     r.Scope,
-			Token:     fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
-			Prefix:    newPrefix,
+			ExpiresAt:    r.ExpiresAt,
+			Scope:        r.Scope,
+			Restrictions: r.Restrictions,
+			Token:        fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
+			Prefix:       newPrefix,
 		}))
 }
 
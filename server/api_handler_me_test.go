@@ -51,11 +51,13 @@ func TestHandleMeStaticAuth(t *testing.T) {
 			],
 			"two_fa_send_to": "",
 			"effective_user_permissions": {
+				"approvals": true,
 				"auditlog": true,
 				"commands": true,
 				"monitoring": true,
 				"scheduler": true,
 				"scripts": true,
+				"terminal": true,
 				"tunnels": true,
 				"uploads": true,
 				"vault": true
@@ -106,6 +108,8 @@ func TestHandleMeDBAuth(t *testing.T) {
 		false,
 		false,
 		false,
+		false,
+		false,
 		logger)
 	require.NoError(t, err)
 
@@ -137,11 +141,13 @@ func TestHandleMeDBAuth(t *testing.T) {
 			],
 			"two_fa_send_to": "",
 			"effective_user_permissions": {
+				"approvals": false,
 				"auditlog": false,
 				"commands": false,
 				"monitoring": true,
 				"scheduler": false,
 				"scripts": false,
+				"terminal": false,
 				"tunnels": false,
 				"uploads": false,
 				"vault": true
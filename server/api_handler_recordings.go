@@ -0,0 +1,110 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/recording"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/query"
+)
+
+var recordingsSupportedFilters = map[string]bool{
+	"type":      true,
+	"format":    true,
+	"client_id": true,
+	"tunnel_id": true,
+	"scheme":    true,
+	"username":  true,
+	"truncated": true,
+}
+
+// handleListRecordings handles GET /recordings
+func (al *APIListener) handleListRecordings(w http.ResponseWriter, req *http.Request) {
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, nil /* sorts */, recordingsSupportedFilters, nil /* fields */, &query.PaginationConfig{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	recs, err := al.recordings.List()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	filtered := make([]*recording.Recording, 0, len(recs))
+	for _, rec := range recs {
+		matches, err := query.MatchesFilters(rec, options.Filters)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if matches {
+			filtered = append(filtered, rec)
+		}
+	}
+
+	totalCount := len(filtered)
+	start, end := options.Pagination.GetStartEnd(totalCount)
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: filtered[start:end],
+		Meta: api.NewMeta(totalCount),
+	})
+}
+
+// handleGetRecording handles GET /recordings/{recording_id}
+func (al *APIListener) handleGetRecording(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRecordingID]
+
+	rec, err := al.recordings.Get(id)
+	if err != nil {
+		al.handleRecordingError(w, id, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rec))
+}
+
+// handleDownloadRecording handles GET /recordings/{recording_id}/download
+func (al *APIListener) handleDownloadRecording(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRecordingID]
+
+	rec, f, err := al.recordings.Open(id)
+	if err != nil {
+		al.handleRecordingError(w, id, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", rec.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rec.Filename()))
+	// recordings in progress are still growing, so they get no modification time to not be cached
+	var modTime time.Time
+	if rec.FinishedAt != nil {
+		modTime = *rec.FinishedAt
+	}
+	http.ServeContent(w, req, rec.Filename(), modTime, f)
+}
+
+func (al *APIListener) handleRecordingError(w http.ResponseWriter, id string, err error) {
+	if errors.Is(err, recording.ErrNotFound) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Recording with id=%q not found.", id))
+		return
+	}
+	al.jsonError(w, err)
+}
+
+func (al *APIListener) handleRecordingsDisabled(w http.ResponseWriter, req *http.Request) {
+	al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "recordings disabled. enable them in the [recordings] section of the server config.")
+}
@@ -0,0 +1,96 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/recording"
+)
+
+func TestHandleRecordings(t *testing.T) {
+	manager, err := recording.NewManager(recording.Options{Dir: filepath.Join(t.TempDir(), "recordings")}, testLog)
+	require.NoError(t, err)
+
+	terminal, err := manager.Start(&recording.Recording{Type: recording.TypeTerminal, Format: recording.FormatAsciicast, ClientID: "client-1"}, 80, 24)
+	require.NoError(t, err)
+	terminal.Output([]byte("hello"))
+	require.NoError(t, terminal.Close())
+	tunnel, err := manager.Start(&recording.Recording{Type: recording.TypeTunnel, Format: recording.FormatRaw, ClientID: "client-2"}, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, tunnel.Close())
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config:     &chconfig.Config{},
+			recordings: manager,
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	t.Run("list with filter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/recordings?filter[type]=terminal", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		result := struct {
+			Data []*recording.Recording `json:"data"`
+			Meta struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 1, result.Meta.Count)
+		require.Len(t, result.Data, 1)
+		assert.Equal(t, terminal.ID(), result.Data[0].ID)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/recordings/"+tunnel.ID(), nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"client_id":"client-2"`)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/recordings/"+terminal.ID()+"/download", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-asciicast", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="`+terminal.ID()+`.cast"`, w.Header().Get("Content-Disposition"))
+		assert.Contains(t, w.Body.String(), `"o","hello"]`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/recordings/unknown/download", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandleRecordingsDisabled(t *testing.T) {
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{},
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/recordings", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,123 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/roles"
+	"github.com/openrport/openrport/server/routes"
+)
+
+func (al *APIListener) handleGetRoles(w http.ResponseWriter, req *http.Request) {
+	res, err := al.roleManager.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(res))
+}
+
+func (al *APIListener) handleGetRole(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRoleID]
+
+	role, err := al.roleManager.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(role))
+}
+
+func (al *APIListener) handlePostRoles(w http.ResponseWriter, req *http.Request) {
+	var role roles.Role
+	err := parseRequestBody(req.Body, &role)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	_, err = al.roleManager.Get(req.Context(), role.ID)
+	if err == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Role[id=%q] already exists.", role.ID))
+		return
+	}
+	var apiErr errors2.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusNotFound {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.roleManager.Save(req.Context(), &role); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthRole, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(role).
+		WithID(role.ID).
+		Save()
+
+	w.WriteHeader(http.StatusCreated)
+	al.Debugf("Role [id=%q] created.", role.ID)
+}
+
+func (al *APIListener) handlePutRole(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRoleID]
+
+	var role roles.Role
+	err := parseRequestBody(req.Body, &role)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if id != role.ID {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("%q route param doesn't match role ID from request body.", routes.ParamRoleID))
+		return
+	}
+
+	if _, err := al.roleManager.Get(req.Context(), id); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.roleManager.Save(req.Context(), &role); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthRole, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithRequest(role).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+	al.Debugf("Role [id=%q] updated.", id)
+}
+
+func (al *APIListener) handleDeleteRole(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRoleID]
+
+	if err := al.roleManager.Delete(req.Context(), id); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthRole, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+	al.Debugf("Role [id=%q] deleted.", id)
+}
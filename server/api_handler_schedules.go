@@ -10,10 +10,17 @@ import (
 	"github.com/openrport/openrport/server/api"
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/jobs/schedule"
+	"github.com/openrport/openrport/server/approvals"
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/clients/clientdata"
 )
 
+// scheduleUpdateRequest is the schedule update stored while it waits for approval
+type scheduleUpdateRequest struct {
+	ScheduleID string            `json:"schedule_id"`
+	Schedule   schedule.Schedule `json:"schedule"`
+}
+
 func (al *APIListener) handleListSchedules(w http.ResponseWriter, req *http.Request) {
 	items, err := al.scheduleManager.List(req.Context(), req)
 	if err != nil {
@@ -64,6 +71,21 @@ func (al *APIListener) handlePostSchedules(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if groupIDs := approvalClientGroups(orderedClients, clientGroups); len(groupIDs) > 0 {
+		// validate now, an invalid schedule would only fail once approved
+		if err := al.scheduleManager.Validate(&scheduleInput); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		al.requestApproval(w, req, approvals.TypeSchedule, groupIDs, scheduleInput, orderedClients)
+		return
+	}
+
 	storedValue, err := al.scheduleManager.Create(ctx, &scheduleInput, username)
 	if err != nil {
 		al.jsonError(w, err)
@@ -95,6 +117,33 @@ func (al *APIListener) handleUpdateSchedule(w http.ResponseWriter, req *http.Req
 		return
 	}
 
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if groupIDs := approvalClientGroups(orderedClients, clientGroups); len(groupIDs) > 0 {
+		existing, err := al.scheduleManager.Get(ctx, idStr)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if existing == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Cannot find a schedule by the provided id: %s", idStr))
+			return
+		}
+		if err := al.scheduleManager.Validate(&scheduleInput); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		request := scheduleUpdateRequest{
+			ScheduleID: idStr,
+			Schedule:   scheduleInput,
+		}
+		al.requestApproval(w, req, approvals.TypeScheduleUpdate, groupIDs, request, orderedClients)
+		return
+	}
+
 	storedValue, err := al.scheduleManager.Update(ctx, idStr, &scheduleInput)
 	if err != nil {
 		al.jsonError(w, err)
@@ -11,6 +11,7 @@ import (
 	"github.com/openrport/openrport/server/api"
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/jobs"
+	"github.com/openrport/openrport/server/approvals"
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/share/ws"
@@ -91,6 +92,7 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
 	if err != nil {
 		al.jsonError(w, err)
+		return
 	}
 	err = al.clientService.CheckClientsAccess(inboundMsg.OrderedClients, curUser, clientGroups)
 	if err != nil {
@@ -100,6 +102,11 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 
 	inboundMsg.Username = curUser.Username
 
+	if groupIDs := approvalClientGroups(inboundMsg.OrderedClients, clientGroups); len(groupIDs) > 0 {
+		al.requestApproval(w, req, approvals.TypeScript, groupIDs, inboundMsg, inboundMsg.OrderedClients)
+		return
+	}
+
 	multiJob, err := al.StartMultiClientJob(ctx, inboundMsg)
 	if err != nil {
 		al.jsonError(w, err)
@@ -150,6 +157,11 @@ func (al *APIListener) handleScriptsWS(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if err := al.checkApprovalNotRequired(ctx, orderedClients); err != nil {
+		uiConnTS.WriteError("", err)
+		return
+	}
+
 	inboundMsg.OrderedClients = orderedClients
 
 	err = al.enrichScriptInput(inboundMsg)
@@ -32,6 +32,7 @@ func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request)
 		"pairing_url":               al.config.Server.PairingURL,
 		"clients_auth_source":       al.clientAuthProvider.Source(),
 		"clients_auth_mode":         al.getClientsAuthMode(),
+		"clients_cert_enrollment":   al.clientCertAuthority != nil && al.clientCertAuthority.CanSign(),
 		"users_auth_source":         al.userService.GetProviderType(),
 		"group_permissions_enabled": al.userService.SupportsGroupPermissions(),
 		"two_fa_enabled":            al.config.API.IsTwoFAOn() || al.config.API.TotPEnabled,
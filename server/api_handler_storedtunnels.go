@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/clients"
 	"github.com/openrport/openrport/server/clients/storedtunnels"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/share/query"
@@ -33,6 +34,11 @@ func (al *APIListener) handleGetStoredTunnels(w http.ResponseWriter, req *http.R
 		al.jsonError(w, err)
 		return
 	}
+	if entries, ok := result.Data.([]*storedtunnels.StoredTunnel); ok {
+		for _, t := range entries {
+			t.State = clients.StoredTunnelState(client, t)
+		}
+	}
 
 	al.writeJSONResponse(w, http.StatusOK, result)
 }
@@ -59,6 +65,8 @@ func (al *APIListener) handlePostStoredTunnels(w http.ResponseWriter, req *http.
 		return
 	}
 
+	storedTunnel.CreatedBy = api.GetUser(ctx, al.Logger)
+
 	result, err := al.storedTunnels.Create(ctx, client.GetID(), storedTunnel)
 	if err != nil {
 		al.jsonError(w, err)
@@ -117,6 +125,8 @@ func (al *APIListener) handlePutStoredTunnel(w http.ResponseWriter, req *http.Re
 		return
 	}
 	storedTunnel.ID = tunnelID
+	// the tunnel keeps the user who created it
+	storedTunnel.CreatedBy = ""
 
 	result, err := al.storedTunnels.Update(ctx, client.GetID(), storedTunnel)
 	if err != nil {
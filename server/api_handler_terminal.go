@@ -0,0 +1,265 @@
+package chserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/recording"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	defaultTerminalCols = 80
+	defaultTerminalRows = 24
+
+	terminalMsgTypeInput  = "input"
+	terminalMsgTypeResize = "resize"
+	terminalMsgTypeExit   = "exit"
+
+	terminalReadBufferSize = 32 * 1024
+)
+
+// TerminalMessage is a control message exchanged as websocket text message with the browser.
+// The terminal data itself is sent as binary messages in both directions.
+type TerminalMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	Cols     uint16 `json:"cols,omitempty"`
+	Rows     uint16 `json:"rows,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleTerminalWS handles GET /ws/clients/{client_id}/terminal
+func (al *APIListener) handleTerminalWS(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+	if clientID == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Missing %q route param.", routes.ParamClientID))
+		return
+	}
+
+	termReq, err := parseTerminalRequest(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	record, err := al.parseRecordParam(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Active client with id=%q not found.", clientID))
+		return
+	}
+
+	payload, err := json.Marshal(termReq)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sshChan, reqs, err := client.GetConnection().OpenChannel(comm.ChannelTerminal, payload)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to open a terminal session on the client.", err)
+		return
+	}
+
+	uiConn, err := apiUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		al.Errorf("Failed to establish WS connection: %v", err)
+		sshChan.Close()
+		return
+	}
+	defer uiConn.Close()
+
+	var rec *recording.Recorder
+	if al.recordings.ShouldRecord(record) {
+		rec, err = al.recordings.Start(&recording.Recording{
+			Type:       recording.TypeTerminal,
+			Format:     recording.FormatAsciicast,
+			ClientID:   clientID,
+			Username:   curUser.Username,
+			SourceAddr: req.RemoteAddr,
+		}, termReq.Cols, termReq.Rows)
+		if err != nil {
+			// the session is not aborted, as it would also not be recorded with recordings disabled
+			al.Errorf("Failed to start recording of terminal session on client %s: %v", clientID, err)
+		}
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTerminal, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(termReq).
+		WithResponse(map[string]interface{}{
+			"recording_id": rec.ID(),
+		}).
+		Save()
+
+	startedAt := time.Now()
+	exit := runTerminalSession(al.Logger.Fork("terminal#%s", clientID), uiConn, sshChan, reqs, rec)
+	if err := rec.Close(); err != nil {
+		al.Errorf("Failed to finish recording %s: %v", rec.ID(), err)
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTerminal, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithResponse(map[string]interface{}{
+			"exit_code":        exit.ExitCode,
+			"error":            exit.Error,
+			"duration_seconds": int(time.Since(startedAt).Seconds()),
+		}).
+		Save()
+}
+
+func parseTerminalRequest(req *http.Request) (*comm.TerminalRequest, error) {
+	termReq := &comm.TerminalRequest{
+		Cols: defaultTerminalCols,
+		Rows: defaultTerminalRows,
+		Term: req.URL.Query().Get("term"),
+	}
+
+	for param, target := range map[string]*uint16{"cols": &termReq.Cols, "rows": &termReq.Rows} {
+		v := req.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		size, err := strconv.ParseUint(v, 10, 16)
+		if err != nil || size == 0 {
+			return nil, errors2.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Message:    fmt.Sprintf("Invalid %s param: %s.", param, v),
+			}
+		}
+		*target = uint16(size)
+	}
+
+	return termReq, nil
+}
+
+// runTerminalSession pipes data between the websocket and the terminal channel until either side closes it.
+// The session is recorded to rec, which may be nil.
+func runTerminalSession(l *logger.Logger, uiConn *websocket.Conn, sshChan ssh.Channel, reqs <-chan *ssh.Request, rec *recording.Recorder) *comm.TerminalExit {
+	exit := &comm.TerminalExit{ExitCode: -1}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// closed by the ssh package together with the channel
+		for r := range reqs {
+			if r.Type == comm.RequestTypeTerminalExit {
+				if err := json.Unmarshal(r.Payload, exit); err != nil {
+					l.Errorf("Invalid terminal exit request: %v", err)
+				}
+			}
+			if r.WantReply {
+				_ = r.Reply(false, nil)
+			}
+		}
+	}()
+
+	go func() {
+		defer sshChan.Close()
+		for {
+			msgType, data, err := uiConn.ReadMessage()
+			if err != nil {
+				l.Debugf("Terminal websocket closed: %v", err)
+				return
+			}
+			if err := forwardTerminalInput(sshChan, rec, msgType, data); err != nil {
+				l.Errorf("Failed to forward terminal input: %v", err)
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, terminalReadBufferSize)
+	for {
+		n, err := sshChan.Read(buf)
+		if n > 0 {
+			rec.Output(buf[:n])
+			if werr := uiConn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+				l.Debugf("Failed to write terminal output: %v", werr)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	sshChan.Close()
+	wg.Wait()
+
+	exitCode := exit.ExitCode
+	_ = uiConn.WriteJSON(&TerminalMessage{
+		Type:     terminalMsgTypeExit,
+		ExitCode: &exitCode,
+		Error:    exit.Error,
+	})
+	_ = uiConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+	return exit
+}
+
+func forwardTerminalInput(sshChan ssh.Channel, rec *recording.Recorder, msgType int, data []byte) error {
+	if msgType == websocket.BinaryMessage {
+		rec.Input(data)
+		_, err := sshChan.Write(data)
+		return err
+	}
+
+	msg := &TerminalMessage{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return fmt.Errorf("invalid terminal message: %v", err)
+	}
+
+	switch msg.Type {
+	case terminalMsgTypeInput:
+		rec.Input([]byte(msg.Data))
+		_, err := sshChan.Write([]byte(msg.Data))
+		return err
+	case terminalMsgTypeResize:
+		if msg.Cols == 0 || msg.Rows == 0 {
+			return fmt.Errorf("invalid terminal size %dx%d", msg.Cols, msg.Rows)
+		}
+		payload, err := json.Marshal(&comm.TerminalResize{Cols: msg.Cols, Rows: msg.Rows})
+		if err != nil {
+			return err
+		}
+		_, err = sshChan.SendRequest(comm.RequestTypeTerminalResize, false, payload)
+		if err == nil {
+			rec.Resize(msg.Cols, msg.Rows)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown terminal message type %q", msg.Type)
+	}
+}
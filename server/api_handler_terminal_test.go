@@ -0,0 +1,176 @@
+package chserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+type fakeTerminalChannel struct {
+	io.Reader
+	io.Writer
+
+	mu       sync.Mutex
+	requests []string
+	closed   bool
+	onClose  func()
+}
+
+func (c *fakeTerminalChannel) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.onClose()
+	}
+	return nil
+}
+
+func (c *fakeTerminalChannel) CloseWrite() error {
+	return nil
+}
+
+func (c *fakeTerminalChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, name+" "+string(payload))
+	return true, nil
+}
+
+func (c *fakeTerminalChannel) Stderr() io.ReadWriter {
+	return nil
+}
+
+func TestParseTerminalRequest(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Query       string
+		Expected    *comm.TerminalRequest
+		ExpectedErr string
+	}{
+		{
+			Name:     "defaults",
+			Expected: &comm.TerminalRequest{Cols: 80, Rows: 24},
+		},
+		{
+			Name:     "custom size and term",
+			Query:    "?cols=120&rows=40&term=xterm",
+			Expected: &comm.TerminalRequest{Cols: 120, Rows: 40, Term: "xterm"},
+		},
+		{
+			Name:        "invalid cols",
+			Query:       "?cols=abc",
+			ExpectedErr: "Invalid cols param: abc.",
+		},
+		{
+			Name:        "zero rows",
+			Query:       "?rows=0",
+			ExpectedErr: "Invalid rows param: 0.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ws/clients/client-1/terminal"+tc.Query, nil)
+
+			termReq, err := parseTerminalRequest(req)
+			if tc.ExpectedErr != "" {
+				require.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, termReq)
+		})
+	}
+}
+
+func TestRunTerminalSession(t *testing.T) {
+	outputReader, outputWriter := io.Pipe()
+	inputReader, inputWriter := io.Pipe()
+	reqs := make(chan *ssh.Request)
+	sshChan := &fakeTerminalChannel{
+		Reader: outputReader,
+		Writer: inputWriter,
+		onClose: func() {
+			outputReader.Close()
+			inputWriter.Close()
+		},
+	}
+
+	done := make(chan *comm.TerminalExit)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uiConn, err := apiUpgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		done <- runTerminalSession(testLog, uiConn, sshChan, reqs, nil)
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial(httpToWS(t, s.URL), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// browser input is forwarded to the client
+	require.NoError(t, ws.WriteMessage(websocket.BinaryMessage, []byte("ls\n")))
+	input := make([]byte, 3)
+	_, err = io.ReadFull(inputReader, input)
+	require.NoError(t, err)
+	assert.Equal(t, "ls\n", string(input))
+
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"input","data":"pwd\n"}`)))
+	input = make([]byte, 4)
+	_, err = io.ReadFull(inputReader, input)
+	require.NoError(t, err)
+	assert.Equal(t, "pwd\n", string(input))
+
+	// client output is forwarded to the browser
+	go func() {
+		_, _ = outputWriter.Write([]byte("file.txt\n"))
+	}()
+	msgType, msg, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, "file.txt\n", string(msg))
+
+	// the client reports the exit code and closes the channel
+	go func() {
+		reqs <- &ssh.Request{Type: comm.RequestTypeTerminalExit, Payload: []byte(`{"exit_code":3}`)}
+		close(reqs)
+		outputWriter.Close()
+	}()
+
+	msgType, msg, err = ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, msgType)
+	exitMsg := &TerminalMessage{}
+	require.NoError(t, json.Unmarshal(msg, exitMsg))
+	assert.Equal(t, terminalMsgTypeExit, exitMsg.Type)
+	require.NotNil(t, exitMsg.ExitCode)
+	assert.Equal(t, 3, *exitMsg.ExitCode)
+
+	exit := <-done
+	assert.Equal(t, 3, exit.ExitCode)
+}
+
+func TestForwardTerminalResize(t *testing.T) {
+	sshChan := &fakeTerminalChannel{}
+
+	err := forwardTerminalInput(sshChan, nil, websocket.TextMessage, []byte(`{"type":"resize","cols":100,"rows":30}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{comm.RequestTypeTerminalResize + ` {"cols":100,"rows":30}`}, sshChan.requests)
+
+	err = forwardTerminalInput(sshChan, nil, websocket.TextMessage, []byte(`{"type":"resize","cols":0,"rows":30}`))
+	assert.EqualError(t, err, "invalid terminal size 0x30")
+
+	err = forwardTerminalInput(sshChan, nil, websocket.TextMessage, []byte(`{"type":"unknown"}`))
+	assert.EqualError(t, err, `unknown terminal message type "unknown"`)
+}
@@ -11,6 +11,7 @@ import (
 
 type TunnelPayload struct {
 	models.Remote
+	clienttunnel.TrafficStats
 	ID        string    `json:"id"`
 	ClientID  string    `json:"client_id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -18,10 +19,11 @@ type TunnelPayload struct {
 
 func convertToTunnelPayload(t *clienttunnel.Tunnel, clientID string) TunnelPayload {
 	return TunnelPayload{
-		Remote:    t.Remote,
-		ID:        t.ID,
-		ClientID:  clientID,
-		CreatedAt: t.CreatedAt,
+		Remote:       t.Remote,
+		TrafficStats: t.Traffic.Stats(),
+		ID:           t.ID,
+		ClientID:     clientID,
+		CreatedAt:    t.CreatedAt,
 	}
 }
 
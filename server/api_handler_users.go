@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 
 	rportplus "github.com/openrport/openrport/plus"
 	extperm "github.com/openrport/openrport/plus/capabilities/extendedpermission"
 	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/roles"
 	"github.com/openrport/openrport/server/routes"
 )
 
@@ -43,8 +46,17 @@ func (al *APIListener) handleGetUsers(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	managedGroups, allGroups, err := al.managedUserGroups(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	usersToSend := make([]UserPayload, 0, len(usrs))
 	for _, user := range usrs {
+		if !allGroups && (len(user.Groups) == 0 || !roles.IsSubset(user.Groups, managedGroups)) {
+			continue
+		}
 		payload := UserPayload{
 			Username:    user.Username,
 			Groups:      user.Groups,
@@ -60,6 +72,79 @@ func (al *APIListener) handleGetUsers(w http.ResponseWriter, req *http.Request)
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// managedUserGroups returns the user groups the current user can manage by a custom role limited to some user groups.
+// If all is true, the user can manage all groups.
+func (al *APIListener) managedUserGroups(req *http.Request) (groups []string, all bool, err error) {
+	if al.insecureForTests || al.roleManager == nil {
+		return nil, true, nil
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return nil, false, err
+	}
+	if curUser.IsAdmin() {
+		return nil, true, nil
+	}
+
+	return al.roleManager.ManagedUserGroups(req.Context(), curUser.Groups, roles.ResourceUsers, roles.AccessForMethod(req.Method))
+}
+
+// checkManagedUser returns an error if a user who isn't admin tries to grant admin rights, to change an admin
+// or to change a user outside of the user groups the current user can manage.
+// Roles on users without user groups scope can manage all groups, but not the Administrators group.
+func (al *APIListener) checkManagedUser(req *http.Request, userID string, groups []string) error {
+	if al.insecureForTests || al.roleManager == nil {
+		return nil
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return err
+	}
+	if curUser.IsAdmin() {
+		return nil
+	}
+
+	for _, group := range groups {
+		if group == users.Administrators {
+			return errors2.APIError{
+				Message:    fmt.Sprintf("only administrators can assign users to the %s group", users.Administrators),
+				HTTPStatus: http.StatusForbidden,
+			}
+		}
+	}
+
+	if userID == "" {
+		return nil
+	}
+	existing, err := al.userService.GetByUsername(userID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+	if existing.IsAdmin() {
+		return errors2.APIError{
+			Message:    "only administrators can change or delete administrators",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+
+	managedGroups, allGroups, err := al.managedUserGroups(req)
+	if err != nil {
+		return err
+	}
+	if !allGroups && (len(existing.Groups) == 0 || !roles.IsSubset(existing.Groups, managedGroups)) {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("only users of the groups %s can be changed", strings.Join(managedGroups, ", ")),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
 func (al *APIListener) handleChangeUser(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 
@@ -81,6 +166,24 @@ func (al *APIListener) handleChangeUser(w http.ResponseWriter, req *http.Request
 		al.jsonError(w, err)
 		return
 	}
+	// security keys can only be registered by the user
+	user.WebAuthnCredentials = nil
+	// users are deactivated by the identity provider through scim
+	user.Disabled = nil
+
+	managedGroups, allGroups, err := al.managedUserGroups(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !allGroups && (user.Groups != nil || userID == "") && (len(user.Groups) == 0 || !roles.IsSubset(user.Groups, managedGroups)) {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, fmt.Sprintf("users can only be assigned to the groups: %s", strings.Join(managedGroups, ", ")))
+		return
+	}
+	if err := al.checkManagedUser(req, userID, user.Groups); err != nil {
+		al.jsonError(w, err)
+		return
+	}
 
 	if err := al.userService.Change(&user, userID); err != nil {
 		al.jsonError(w, err)
@@ -148,6 +251,11 @@ func (al *APIListener) handleDeleteUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	if err := al.checkManagedUser(req, userID, nil); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	if err := al.userService.Delete(userID); err != nil {
 		al.jsonError(w, err)
 		return
@@ -170,6 +278,11 @@ func (al *APIListener) handleDeleteUsersTotP(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	if err := al.checkManagedUser(req, userID, nil); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	user, err := al.userService.GetByUsername(userID)
 	if err != nil {
 		al.jsonError(w, err)
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,11 +15,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	clientgroupsmigration "github.com/openrport/openrport/db/migration/client_groups"
+	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/server/api"
 	"github.com/openrport/openrport/server/api/session"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/roles"
+	"github.com/openrport/openrport/share/enums"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/security"
 )
@@ -195,6 +200,173 @@ func TestShouldErrorWhenNonAdminUser(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
 }
 
+func TestRoleGrantsAccessToUsers(t *testing.T) {
+	al, adminUser := setupTestAPIListenerUserAPISessions(t, nil)
+
+	ctx := context.Background()
+
+	supportUser := &users.User{
+		Username: "user1",
+		Password: "pa55word",
+		Groups:   []string{"support"},
+	}
+	al.userService = users.NewAPIService(users.NewStaticProvider([]*users.User{adminUser, supportUser}), false, 0, -1)
+
+	db, err := sqlite.New(":memory:", clientgroupsmigration.AssetNames(), clientgroupsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	al.roleManager = roles.NewManager(roles.NewSQLiteProvider(db))
+	require.NoError(t, al.roleManager.Save(ctx, &roles.Role{
+		ID:         "helpdesk",
+		UserGroups: []string{"support"},
+		Rules:      roles.Rules{roles.ResourceUsers: roles.AccessRead},
+	}))
+
+	testCases := []struct {
+		method     string
+		url        string
+		statusCode int
+	}{
+		{method: http.MethodGet, url: "/api/v1/users/user1/sessions", statusCode: http.StatusOK},
+		{method: http.MethodDelete, url: "/api/v1/users/user1/sessions", statusCode: http.StatusForbidden},
+		{method: http.MethodGet, url: "/api/v1/user-groups", statusCode: http.StatusForbidden},
+		{method: http.MethodGet, url: "/api/v1/roles", statusCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			req.SetBasicAuth(supportUser.Username, supportUser.Password)
+
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.statusCode, w.Result().StatusCode, w.Body.String())
+		})
+	}
+}
+
+// writableStaticProvider accepts the changes of users without storing them
+type writableStaticProvider struct {
+	*users.StaticProvider
+}
+
+func (writableStaticProvider) Type() enums.ProviderSource {
+	return enums.ProviderSourceFile
+}
+
+func (writableStaticProvider) Add(*users.User) error {
+	return nil
+}
+
+func (writableStaticProvider) Update(*users.User, string) error {
+	return nil
+}
+
+func (writableStaticProvider) Delete(string) error {
+	return nil
+}
+
+func TestRoleCannotManageAdministrators(t *testing.T) {
+	al, adminUser := setupTestAPIListenerUserAPISessions(t, nil)
+
+	ctx := context.Background()
+
+	managerUser := &users.User{
+		Username: "manager",
+		Password: "pa55word",
+		Groups:   []string{"managers"},
+	}
+	supportUser := &users.User{
+		Username: "user1",
+		Password: "pa55word",
+		Groups:   []string{"support"},
+	}
+	al.config.API.MaxRequestBytes = 1024 * 1024
+	al.initRouter()
+	al.userService = users.NewAPIService(writableStaticProvider{users.NewStaticProvider([]*users.User{adminUser, managerUser, supportUser})}, false, 0, -1)
+
+	db, err := sqlite.New(":memory:", clientgroupsmigration.AssetNames(), clientgroupsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	al.roleManager = roles.NewManager(roles.NewSQLiteProvider(db))
+	// not limited to user groups
+	require.NoError(t, al.roleManager.Save(ctx, &roles.Role{
+		ID:         "user-managers",
+		UserGroups: []string{"managers"},
+		Rules:      roles.Rules{roles.ResourceUsers: roles.AccessWrite},
+	}))
+
+	testCases := []struct {
+		name       string
+		method     string
+		url        string
+		body       string
+		statusCode int
+	}{
+		{
+			name:       "assign another group",
+			method:     http.MethodPut,
+			url:        "/api/v1/users/user1",
+			body:       `{"groups": ["support", "dev"]}`,
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "assign Administrators",
+			method:     http.MethodPut,
+			url:        "/api/v1/users/user1",
+			body:       `{"groups": ["Administrators"]}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "assign Administrators to self",
+			method:     http.MethodPut,
+			url:        "/api/v1/users/manager",
+			body:       `{"groups": ["managers", "Administrators"]}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "create an administrator",
+			method:     http.MethodPost,
+			url:        "/api/v1/users",
+			body:       `{"username": "new-admin", "password": "pa55word1", "groups": ["Administrators"]}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "remove Administrators",
+			method:     http.MethodPut,
+			url:        "/api/v1/users/admin",
+			body:       `{"groups": ["support"]}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "change the password of an administrator",
+			method:     http.MethodPut,
+			url:        "/api/v1/users/admin",
+			body:       `{"password": "pa55word1"}`,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "delete an administrator",
+			method:     http.MethodDelete,
+			url:        "/api/v1/users/admin",
+			statusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.SetBasicAuth(managerUser.Username, managerUser.Password)
+
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.statusCode, w.Result().StatusCode, w.Body.String())
+		})
+	}
+}
+
 type MockAPISessionStorageProvider struct {
 	*session.SqliteProvider
 
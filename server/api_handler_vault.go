@@ -33,7 +33,13 @@ func (al *APIListener) handleVaultUnlock(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	err = al.vaultManager.UnLock(req.Context(), passReq.Password)
+	pass, err := al.vaultPassword(req, passReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.vaultManager.UnLock(req.Context(), pass)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -68,7 +74,13 @@ func (al *APIListener) handleVaultInit(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	err = al.vaultManager.Init(req.Context(), passReq.Password)
+	pass, err := al.vaultPassword(req, passReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.vaultManager.Init(req.Context(), pass)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -81,6 +93,52 @@ func (al *APIListener) handleVaultInit(w http.ResponseWriter, req *http.Request)
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (al *APIListener) handleVaultReKey(w http.ResponseWriter, req *http.Request) {
+	var passReq vault.PassRequest
+	err := parseRequestBody(req.Body, &passReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	pass, err := al.vaultPassword(req, passReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.vaultManager.ReKey(req.Context(), pass)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationVault, "rekey").
+		WithHTTPRequest(req).
+		WithRequest(map[string]interface{}{
+			"from_key_provider": passReq.FromKeyProvider,
+		}).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// vaultPassword returns the password of the request, or the key of the key provider if requested
+func (al *APIListener) vaultPassword(req *http.Request, passReq vault.PassRequest) (string, error) {
+	if !passReq.FromKeyProvider {
+		return passReq.Password, nil
+	}
+
+	if al.vaultKeyProvider == nil {
+		return "", errors2.APIError{
+			Message:    "no vault key provider is configured",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	return al.vaultKeyProvider.Key(req.Context())
+}
+
 func (al *APIListener) handleListVaultValues(w http.ResponseWriter, req *http.Request) {
 	items, err := al.vaultManager.List(req.Context(), req)
 	if err != nil {
@@ -0,0 +1,384 @@
+package chserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	rportplus "github.com/openrport/openrport/plus"
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/bearer"
+	"github.com/openrport/openrport/server/routes"
+	chshare "github.com/openrport/openrport/share"
+)
+
+const webAuthnDeliveryMethod = "webauthn"
+
+type WebAuthnCredentialPayload struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Transport  []string   `json:"transport"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func newWebAuthnCredentialPayload(c users.WebAuthnCredential) WebAuthnCredentialPayload {
+	transport := c.Transport
+	if transport == nil {
+		transport = []string{}
+	}
+	return WebAuthnCredentialPayload{
+		ID:         EncodeWebAuthnCredentialID(c.ID),
+		Name:       c.Name,
+		Transport:  transport,
+		CreatedAt:  c.CreatedAt,
+		LastUsedAt: c.LastUsedAt,
+	}
+}
+
+type webAuthnRegistrationRequest struct {
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// isWebAuthnRequired returns true if the user has to confirm the login with a webauthn credential
+func (al *APIListener) isWebAuthnRequired(user *users.User) bool {
+	if !al.config.API.WebAuthn.Enabled {
+		return false
+	}
+	return len(user.WebAuthnCredentials) > 0 || (al.config.API.WebAuthn.RequiredForAdmins && user.IsAdmin())
+}
+
+func (al *APIListener) wrapWebAuthnEnabledMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if al.webAuthn == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "WebAuthn is disabled")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// sendWebAuthnLoginToken responds to a successful password login with a token limited to the webauthn login,
+// users without credentials are allowed to register their first one
+func (al *APIListener) sendWebAuthnLoginToken(user *users.User, lifetime time.Duration, w http.ResponseWriter, req *http.Request) {
+	loginResp := loginResponse{
+		TwoFA: &twoFAResponse{
+			DeliveryMethod: webAuthnDeliveryMethod,
+		},
+	}
+
+	scopes := append([]bearer.Scope{}, bearer.ScopesWebAuthnCheckOnly...)
+	if len(user.WebAuthnCredentials) == 0 {
+		scopes = append(scopes, bearer.ScopesWebAuthnRegisterOnly...)
+		loginResp.TwoFA.WebAuthnKeyStatus = WebAuthnKeyPending.String()
+	} else {
+		loginResp.TwoFA.WebAuthnKeyStatus = WebAuthnKeyExists.String()
+	}
+
+	tokenStr, err := bearer.CreateAuthToken(
+		req.Context(),
+		al.apiSessions,
+		al.config.API.JWTSecret,
+		lifetime,
+		user.Username,
+		scopes,
+		req.UserAgent(),
+		chshare.RemoteIP(req),
+	)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	loginResp.Token = &tokenStr
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(loginResp))
+}
+
+func (al *APIListener) handlePostWebAuthnRegistration(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	options, err := al.webAuthn.BeginRegistration(user)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(options))
+}
+
+func (al *APIListener) handleGetWebAuthnCredentials(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	creds := make([]WebAuthnCredentialPayload, 0, len(user.WebAuthnCredentials))
+	for _, c := range user.WebAuthnCredentials {
+		creds = append(creds, newWebAuthnCredentialPayload(c))
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(creds))
+}
+
+func (al *APIListener) handlePostWebAuthnCredential(w http.ResponseWriter, req *http.Request) {
+	var r webAuthnRegistrationRequest
+	err := parseRequestBody(req.Body, &r)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if r.Name == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	cred, err := al.webAuthn.FinishRegistration(user, r.Name, bytes.NewReader(r.Credential))
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	creds := append(users.WebAuthnCredentials{}, user.WebAuthnCredentials...)
+	creds = append(creds, *cred)
+	if err := al.userService.Change(&users.User{WebAuthnCredentials: creds}, user.Username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(user.Username).
+		WithRequest(newWebAuthnCredentialPayload(*cred)).
+		Save()
+
+	al.Debugf("WebAuthn credential %q is registered for user [%s].", cred.Name, user.Username)
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(newWebAuthnCredentialPayload(*cred)))
+}
+
+func (al *APIListener) handleDeleteWebAuthnCredential(w http.ResponseWriter, req *http.Request) {
+	credentialID := mux.Vars(req)[routes.ParamCredentialID]
+
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	creds := users.WebAuthnCredentials{}
+	for _, c := range user.WebAuthnCredentials {
+		if EncodeWebAuthnCredentialID(c.ID) != credentialID {
+			creds = append(creds, c)
+		}
+	}
+	if len(creds) == len(user.WebAuthnCredentials) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "webauthn credential not found")
+		return
+	}
+
+	if err := al.userService.Change(&users.User{WebAuthnCredentials: creds}, user.Username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(user.Username).
+		Save()
+
+	al.Debugf("WebAuthn credential %s is deleted for user [%s].", credentialID, user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (al *APIListener) handleDeleteUsersWebAuthnCredentials(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	userID, userIDProvided := vars[routes.ParamUserID]
+	if !userIDProvided {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Empty user id provided")
+		return
+	}
+
+	if err := al.checkManagedUser(req, userID, nil); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	user, err := al.userService.GetByUsername(userID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err := al.userService.Change(&users.User{WebAuthnCredentials: users.WebAuthnCredentials{}}, userID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(userID).
+		Save()
+
+	al.Debugf("All WebAuthn credentials are deleted for user [%s].", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webAuthnLoginUsername returns the user of the limited token issued by the password login,
+// without a token the login is passwordless and the user is identified by the credential
+func (al *APIListener) webAuthnLoginUsername(req *http.Request) (string, error) {
+	if rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Err:        errors.New("built-in authorization disabled. please authorize via your configured authorization"),
+		}
+	}
+
+	bearerToken, bearerAuthProvided := bearer.GetBearerToken(req)
+	if !bearerAuthProvided {
+		return "", nil
+	}
+
+	isAuthorized, token, err := al.checkBearerToken(req.Context(), bearerToken, req.URL.Path, req.Method)
+	if err != nil {
+		return "", err
+	}
+	if !isAuthorized {
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Message:    "access denied",
+		}
+	}
+
+	if al.bannedUsers.IsBanned(token.AppClaims.Username) {
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusTooManyRequests,
+			Err:        ErrTooManyRequests,
+		}
+	}
+	return token.AppClaims.Username, nil
+}
+
+func (al *APIListener) handlePostWebAuthnLoginOptions(w http.ResponseWriter, req *http.Request) {
+	username, err := al.webAuthnLoginUsername(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var user *users.User
+	if username != "" {
+		user, err = al.userService.GetByUsername(username)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if user == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if len(user.WebAuthnCredentials) == 0 {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, "no webauthn credential registered for this user")
+			return
+		}
+	}
+
+	options, err := al.webAuthn.BeginLogin(user)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(options))
+}
+
+func (al *APIListener) handlePostWebAuthnLogin(w http.ResponseWriter, req *http.Request) {
+	username, err := al.webAuthnLoginUsername(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	user, err := al.webAuthn.FinishLogin(username, req.Body, al.userService.GetByUsername)
+	if err != nil {
+		if user != nil {
+			al.bannedUsers.Add(user.Username)
+		}
+		if !al.handleBannedIPs(req, false) {
+			return
+		}
+		al.Errorf(err.Error())
+		al.jsonError(w, err)
+		return
+	}
+
+	if al.bannedUsers.IsBanned(user.Username) {
+		al.jsonErrorResponseWithTitle(w, http.StatusTooManyRequests, ErrTooManyRequests.Error())
+		return
+	}
+
+	if user.IsDisabled() {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if !al.handleBannedIPs(req, true) {
+		return
+	}
+
+	if user.PasswordExpired != nil && *user.PasswordExpired {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, ErrThatPasswordHasExpired.Error())
+		return
+	}
+
+	// store the new sign count to detect cloned keys
+	if err := al.userService.Change(&users.User{WebAuthnCredentials: user.WebAuthnCredentials}, user.Username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.sendJWTToken(user.Username, w, req)
+}
@@ -0,0 +1,147 @@
+package chserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/share/security"
+)
+
+type webAuthnTestResponse struct {
+	Data struct {
+		Token     string `json:"token"`
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+		TwoFA *twoFAResponse `json:"two_fa"`
+	} `json:"data"`
+}
+
+func TestWebAuthnLoginFlow(t *testing.T) {
+	admin := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	mockUsersService := &MockUsersService{
+		UserService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin}), false, 0, -1),
+	}
+	webAuthnConfig := testWebAuthnConfig
+	webAuthnConfig.RequiredForAdmins = true
+	webAuthn, err := NewWebAuthnService(webAuthnConfig)
+	require.NoError(t, err)
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					JWTSecret:       "secret",
+					WebAuthn:        webAuthnConfig,
+				},
+			},
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: mockUsersService,
+		apiSessions: newEmptyAPISessionCache(t),
+		webAuthn:    webAuthn,
+	}
+	al.initRouter()
+
+	call := func(method, url, token string, body []byte) (int, webAuthnTestResponse) {
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+
+		var resp webAuthnTestResponse
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		}
+		return w.Code, resp
+	}
+
+	// the password login of an administrator without a key only allows to register one
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+	req.SetBasicAuth("admin", "pwd")
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var login webAuthnTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	assert.Equal(t, &twoFAResponse{DeliveryMethod: "webauthn", WebAuthnKeyStatus: "pending"}, login.Data.TwoFA)
+	limitedToken := login.Data.Token
+
+	status, _ := call(http.MethodGet, "/api/v1/me", limitedToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	key := newSoftAuthenticator(t, admin.Username)
+	status, options := call(http.MethodPost, "/api/v1/me/webauthn/registration", limitedToken, nil)
+	require.Equal(t, http.StatusOK, status)
+	registration, err := json.Marshal(map[string]interface{}{
+		"name":       "my key",
+		"credential": json.RawMessage(key.register(options.Data.PublicKey.Challenge)),
+	})
+	require.NoError(t, err)
+	status, _ = call(http.MethodPost, "/api/v1/me/webauthn/credentials", limitedToken, registration)
+	require.Equal(t, http.StatusCreated, status)
+	require.Len(t, mockUsersService.ChangeUser.WebAuthnCredentials, 1)
+	admin.WebAuthnCredentials = mockUsersService.ChangeUser.WebAuthnCredentials
+
+	t.Run("second factor", func(t *testing.T) {
+		status, options := call(http.MethodPost, "/api/v1/login/webauthn/options", limitedToken, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, resp := call(http.MethodPost, "/api/v1/login/webauthn", limitedToken, key.assert(options.Data.PublicKey.Challenge))
+		require.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, resp.Data.Token)
+		assert.Nil(t, resp.Data.TwoFA)
+		assert.Equal(t, key.signCount, mockUsersService.ChangeUser.WebAuthnCredentials[0].SignCount)
+
+		status, _ = call(http.MethodGet, "/api/v1/me", resp.Data.Token, nil)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("passwordless", func(t *testing.T) {
+		status, options := call(http.MethodPost, "/api/v1/login/webauthn/options", "", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		status, resp := call(http.MethodPost, "/api/v1/login/webauthn", "", key.assert(options.Data.PublicKey.Challenge))
+		require.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, resp.Data.Token)
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		status, options := call(http.MethodPost, "/api/v1/login/webauthn/options", "", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		other := newSoftAuthenticator(t, admin.Username)
+		status, _ = call(http.MethodPost, "/api/v1/login/webauthn", "", other.assert(options.Data.PublicKey.Challenge))
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("password login of user with key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+		req.SetBasicAuth("admin", "pwd")
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var login webAuthnTestResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+		assert.Equal(t, &twoFAResponse{DeliveryMethod: "webauthn", WebAuthnKeyStatus: "exists"}, login.Data.TwoFA)
+
+		status, _ := call(http.MethodPost, "/api/v1/me/webauthn/registration", login.Data.Token, nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -60,6 +60,11 @@ func (al *APIListener) getOrderedClientsWithValidation(
 			return nil, 0, err
 		}
 	}
+
+	err = al.checkTokenClients(ctx, targetedClients)
+	if err != nil {
+		return nil, 0, err
+	}
 	return targetedClients, groupClientsCount, nil
 }
 
@@ -232,3 +237,10 @@ func validateClientTagsTargeting(orderedClients []*clientdata.Client) (err error
 func hasClientTags(params TargetingParams) (has bool) {
 	return params.GetClientTags() != nil
 }
+
+// isClientConnectedHere returns true if the client holds its ssh connection on this server, in HA mode requests for
+// other clients are forwarded to the node they are connected to.
+func (al *APIListener) isClientConnectedHere(clientID string) bool {
+	client, err := al.clientService.GetActiveByID(clientID)
+	return err == nil && client != nil && client.IsConnectedHere()
+}
@@ -83,17 +83,18 @@ func (al *APIListener) handleCommandsExecutionWS(
 				StartedAt: time.Now(),
 				CreatedBy: createdBy,
 			},
-			ClientIDs:   inboundMsg.ClientIDs,
-			GroupIDs:    inboundMsg.GroupIDs,
-			ClientTags:  inboundMsg.ClientTags,
-			Command:     inboundMsg.Command,
-			Cwd:         inboundMsg.Cwd,
-			Interpreter: inboundMsg.Interpreter,
-			TimeoutSec:  inboundMsg.TimeoutSec,
-			Concurrent:  inboundMsg.ExecuteConcurrently,
-			AbortOnErr:  abortOnErr,
-			IsSudo:      inboundMsg.IsSudo,
-			IsScript:    inboundMsg.IsScript,
+			ClientIDs:    inboundMsg.ClientIDs,
+			GroupIDs:     inboundMsg.GroupIDs,
+			ClientTags:   inboundMsg.ClientTags,
+			Command:      inboundMsg.Command,
+			Cwd:          inboundMsg.Cwd,
+			Interpreter:  inboundMsg.Interpreter,
+			TimeoutSec:   inboundMsg.TimeoutSec,
+			Concurrent:   inboundMsg.ExecuteConcurrently,
+			AbortOnErr:   abortOnErr,
+			IsSudo:       inboundMsg.IsSudo,
+			IsScript:     inboundMsg.IsScript,
+			StreamToFile: inboundMsg.StreamToFile,
 		}
 		if err := al.jobProvider.SaveMultiJob(multiJob); err != nil {
 			uiConnTS.WriteError("Failed to persist a new multi-client job.", err)
@@ -134,6 +135,7 @@ func (al *APIListener) handleCommandsExecutionWS(
 					multiJob.TimeoutSec,
 					multiJob.IsSudo,
 					multiJob.IsScript,
+					multiJob.StreamToFile,
 					client,
 				)
 			} else {
@@ -148,6 +150,7 @@ func (al *APIListener) handleCommandsExecutionWS(
 					multiJob.TimeoutSec,
 					multiJob.IsSudo,
 					multiJob.IsScript,
+					multiJob.StreamToFile,
 					client,
 				)
 
@@ -186,6 +189,7 @@ func (al *APIListener) handleCommandsExecutionWS(
 			inboundMsg.TimeoutSec,
 			inboundMsg.IsSudo,
 			inboundMsg.IsScript,
+			inboundMsg.StreamToFile,
 			client,
 		)
 	}
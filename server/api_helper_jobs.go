@@ -26,6 +26,7 @@ type JobProvider interface {
 	GetByJID(clientID, jid string) (*models.Job, error)
 	List(ctx context.Context, options *query.ListOptions) ([]*models.Job, error)
 	Count(ctx context.Context, options *query.ListOptions) (int, error)
+	CountByStatus(ctx context.Context) (map[string]int, error)
 	// SaveJob creates or updates a job
 	SaveJob(job *models.Job) error
 	// CreateJob creates a new job. If already exist with a given JID - do nothing and return nil
@@ -43,7 +44,7 @@ func (al *APIListener) createAndRunJob(
 	multiJobID *string,
 	jid, cmd, interpreter, createdBy, cwd string,
 	timeoutSec int,
-	isSudo, isScript bool,
+	isSudo, isScript, streamToFile bool,
 	client *clientdata.Client,
 ) error {
 	curJob := models.Job{
@@ -59,18 +60,25 @@ func (al *APIListener) createAndRunJob(
 		CreatedBy:    createdBy,
 		TimeoutSec:   timeoutSec,
 		MultiJobID:   multiJobID,
-		StreamResult: uiConnTS != nil,
+		StreamResult: uiConnTS != nil || streamToFile,
+		StreamToFile: streamToFile,
 	}
 	logPrefix := curJob.LogPrefix()
 
 	// send the command to the client
-	sshResp := &comm.RunCmdResponse{}
-
+	var sshResp *comm.RunCmdResponse
 	var err error
+	dispatched := false
 	if !client.IsPaused() {
-		if client.Connection != nil {
-			err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, curJob, sshResp, al.Log())
-		} else {
+		switch {
+		case client.Connection != nil:
+			sshResp, err = al.runJob(context.Background(), &curJob, client)
+		case client.IsConnected() && al.ha != nil:
+			// the client is connected to another node of the cluster, the output can't be streamed from there
+			curJob.StreamResult = streamToFile
+			sshResp, err = al.dispatchJob(&curJob)
+			dispatched = true
+		default:
 			err = ErrClientNotConnected
 		}
 	} else {
@@ -108,9 +116,29 @@ func (al *APIListener) createAndRunJob(
 		al.Errorf("%s, Failed to persist job: %v", logPrefix, dbErr)
 	}
 
+	if dispatched && err == nil {
+		go al.awaitDispatchedJob(uiConnTS, curJob)
+	}
+
 	return err
 }
 
+// runJob sends the job to the client connected to this server, the values of vault placeholders are only sent along
+func (al *APIListener) runJob(ctx context.Context, job *models.Job, client *clientdata.Client) (*comm.RunCmdResponse, error) {
+	var err error
+	job.VaultEnv, err = al.resolveVaultEnv(ctx, job.Command, client, job.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		job.VaultEnv = nil
+	}()
+
+	sshResp := &comm.RunCmdResponse{}
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, job, sshResp, al.Log())
+	return sshResp, err
+}
+
 func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) (*models.MultiJob, error) {
 	jid, err := generateNewJobID()
 	if err != nil {
@@ -161,17 +189,18 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 			CreatedBy:  multiJobRequest.Username,
 			ScheduleID: multiJobRequest.ScheduleID,
 		},
-		ClientIDs:   multiJobRequest.ClientIDs,
-		GroupIDs:    multiJobRequest.GroupIDs,
-		ClientTags:  multiJobRequest.ClientTags,
-		Command:     command,
-		Interpreter: multiJobRequest.Interpreter,
-		Cwd:         multiJobRequest.Cwd,
-		IsScript:    multiJobRequest.IsScript,
-		IsSudo:      multiJobRequest.IsSudo,
-		TimeoutSec:  multiJobRequest.TimeoutSec,
-		Concurrent:  multiJobRequest.ExecuteConcurrently,
-		AbortOnErr:  abortOnErr,
+		ClientIDs:    multiJobRequest.ClientIDs,
+		GroupIDs:     multiJobRequest.GroupIDs,
+		ClientTags:   multiJobRequest.ClientTags,
+		Command:      command,
+		Interpreter:  multiJobRequest.Interpreter,
+		Cwd:          multiJobRequest.Cwd,
+		IsScript:     multiJobRequest.IsScript,
+		IsSudo:       multiJobRequest.IsSudo,
+		TimeoutSec:   multiJobRequest.TimeoutSec,
+		Concurrent:   multiJobRequest.ExecuteConcurrently,
+		AbortOnErr:   abortOnErr,
+		StreamToFile: multiJobRequest.StreamToFile,
 	}
 	if err := al.jobProvider.SaveMultiJob(multiJob); err != nil {
 		return nil, err
@@ -213,6 +242,7 @@ func (al *APIListener) executeMultiClientJob(
 				job.TimeoutSec,
 				job.IsSudo,
 				job.IsScript,
+				job.StreamToFile,
 				client,
 			)
 		} else {
@@ -227,6 +257,7 @@ func (al *APIListener) executeMultiClientJob(
 				job.TimeoutSec,
 				job.IsSudo,
 				job.IsScript,
+				job.StreamToFile,
 				client,
 			)
 			if err != nil {
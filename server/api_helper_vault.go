@@ -0,0 +1,92 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/vault"
+	"github.com/openrport/openrport/share/vaultplaceholder"
+)
+
+// vaultUser returns the user whose vault access is used to resolve placeholders, it's the user who started the job or tunnel
+func (al *APIListener) vaultUser(username string) (vault.UserDataProvider, error) {
+	user, err := al.userService.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %q not found", username)
+	}
+	return user, nil
+}
+
+// resolveVaultEnv resolves the vault placeholders in a command or script for a client. It returns the values by the name
+// of the environment variable the client passes them in, nil if there are no placeholders.
+func (al *APIListener) resolveVaultEnv(ctx context.Context, text string, client *clientdata.Client, username string) (map[string]string, error) {
+	keys := vaultplaceholder.Keys(text)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if !client.SupportsVaultEnv() {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("client %s version %s doesn't support vault placeholders, please upgrade it", client.GetID(), client.GetVersion()),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	clientID := client.GetID()
+
+	user, err := al.vaultUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string, len(keys))
+	keysByEnvName := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := vaultplaceholder.EnvName(key)
+		if other, ok := keysByEnvName[name]; ok {
+			return nil, errors2.APIError{
+				Message:    fmt.Sprintf("vault keys %q and %q can't be used together, both are passed in %s", other, key, name),
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+		keysByEnvName[name] = key
+
+		value, err := al.vaultManager.Resolve(ctx, key, clientID, user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve vault key %q: %w", key, err)
+		}
+		env[name] = value
+	}
+
+	return env, nil
+}
+
+// resolveVaultPlaceholders returns text with its vault placeholders replaced by the values for a client
+func (al *APIListener) resolveVaultPlaceholders(ctx context.Context, text, clientID, username string) (string, error) {
+	if !vaultplaceholder.Has(text) {
+		return text, nil
+	}
+
+	user, err := al.vaultUser(username)
+	if err != nil {
+		return "", err
+	}
+
+	var resolveErr error
+	resolved := vaultplaceholder.Replace(text, func(key string) string {
+		value, err := al.vaultManager.Resolve(ctx, key, clientID, user)
+		if err != nil && resolveErr == nil {
+			resolveErr = fmt.Errorf("failed to resolve vault key %q: %w", key, err)
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	return resolved, nil
+}
@@ -21,9 +21,10 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jpillora/requestlog"
 
+	"github.com/openrport/openrport/db/migration/api_sessions"
 	"github.com/openrport/openrport/db/migration/api_token"
 	"github.com/openrport/openrport/db/migration/library"
-	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/db/migration/vaults"
 	rportplus "github.com/openrport/openrport/plus"
 	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/server/notifications/channels/rmailer"
@@ -42,6 +43,7 @@ import (
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/vault"
+	"github.com/openrport/openrport/server/vault/keyprovider"
 
 	extperm "github.com/openrport/openrport/plus/capabilities/extendedpermission"
 	chshare "github.com/openrport/openrport/share"
@@ -72,15 +74,17 @@ type APIListener struct {
 	bannedUsers       *security.BanList
 	bannedIPs         *security.MaxBadAttemptsBanList
 	twoFASrv          TwoFAService
+	webAuthn          *WebAuthnService
 
 	testDone chan bool // is used only in tests to be able to wait until async task is done
 
-	userService    UserService
-	vaultManager   *vault.Manager
-	scriptManager  *script.Manager
-	tokenManager   *authorization.Manager
-	commandManager *command.Manager
-	storedTunnels  *storedtunnels.Manager
+	userService      UserService
+	vaultManager     *vault.Manager
+	vaultKeyProvider vault.KeyProvider
+	scriptManager    *script.Manager
+	tokenManager     *authorization.Manager
+	commandManager   *command.Manager
+	storedTunnels    *storedtunnels.Manager
 
 	notificationsStorage   notificationsSQLite.Repository
 	notificationsProcessor notifications.Processor
@@ -112,6 +116,7 @@ type UserService interface {
 	SupportsGroupPermissions() bool
 	GetEffectiveUserPermissions(*users.User) (map[string]bool, error)
 	GetEffectiveUserExtendedPermissions(*users.User) ([]extperm.PermissionParams, []extperm.PermissionParams)
+	GetPasswordVerifier() users.PasswordVerifier
 }
 
 func NewAPIListener(
@@ -130,12 +135,20 @@ func NewAPIListener(
 
 	vaultDBProviderFactory := vault.NewStatefulDbProviderFactory(
 		func() (vault.DbProvider, error) {
-			return vault.NewSqliteProvider(config, vaultLogger)
+			if !server.db.IsPostgres() {
+				return vault.NewSqliteProvider(config, vaultLogger)
+			}
+			vaultDB, err := server.db.Open("vaults", "", vaults.AssetNames(), vaults.Asset, vault.DataSourceOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed init vault DB instance: %w", err)
+			}
+			return vault.NewDBProvider(vaultDB, vaultLogger), nil
 		},
 		&vault.NotInitDbProvider{},
 	)
 
-	db, err := sqlite.New(
+	db, err := server.db.Open(
+		"notifications",
 		path.Join(config.Server.DataDir, "notifications.db"),
 		notificationsSQLite.AssetNames(),
 		notificationsSQLite.Asset,
@@ -168,11 +181,14 @@ func NewAPIListener(
 	notificationProcessor := notifications.NewProcessor(notificationsLogger, store, notificationConsumers...)
 	notificationsCleaner := notificationsSQLite.StartCleaner(notificationLogger, store, config.Notifications.LogStorageDuration, config.Notifications.CleanupInterval)
 
-	// init vault DB if it already exists
-	fs := files.NewFileSystem()
-	exist, err := fs.Exist(config.GetVaultDBPath())
-	if err != nil {
-		return nil, fmt.Errorf("failed to check if vault DB %q exists: %v", config.GetVaultDBPath(), err)
+	// init vault DB if it already exists, on postgres the vault tables are always there and its status tells if it's initialized
+	exist := server.db.IsPostgres()
+	if !exist {
+		fs := files.NewFileSystem()
+		exist, err = fs.Exist(config.GetVaultDBPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if vault DB %q exists: %v", config.GetVaultDBPath(), err)
+		}
 	}
 	if exist {
 		err := vaultDBProviderFactory.Init()
@@ -181,7 +197,8 @@ func NewAPIListener(
 		}
 	}
 
-	libraryDb, err := sqlite.New(
+	libraryDb, err := server.db.Open(
+		"library",
 		path.Join(config.Server.DataDir, "library.db"),
 		library.AssetNames(),
 		library.Asset,
@@ -191,7 +208,8 @@ func NewAPIListener(
 		return nil, fmt.Errorf("failed init library DB instance: %w", err)
 	}
 
-	apiTokenDb, err := sqlite.New(
+	apiTokenDb, err := server.db.Open(
+		"api_token",
 		path.Join(config.Server.DataDir, "api_token.db"),
 		api_token.AssetNames(),
 		api_token.Asset,
@@ -303,6 +321,14 @@ func NewAPIListener(
 		a.Logger.Infof("2FA is enabled via an Authenticator app")
 	}
 
+	if config.API.WebAuthn.Enabled {
+		a.webAuthn, err = NewWebAuthnService(config.API.WebAuthn)
+		if err != nil {
+			return nil, err
+		}
+		a.Logger.Infof("WebAuthn is enabled for relying party %s", config.API.WebAuthn.RPID)
+	}
+
 	if config.API.MaxFailedLogin > 0 && config.API.BanTime > 0 {
 		a.bannedIPs = security.NewMaxBadAttemptsBanList(
 			config.API.MaxFailedLogin,
@@ -311,6 +337,15 @@ func NewAPIListener(
 		)
 	}
 
+	if kp := keyprovider.New(config.Vault); kp != nil {
+		a.vaultKeyProvider = kp
+		if exist {
+			if err := a.vaultManager.UnLockWithKeyProvider(ctx, kp); err != nil {
+				vaultLogger.Errorf("Failed to unlock the vault with key provider %q, unlock it via the API: %v", config.Vault.KeyProvider, err)
+			}
+		}
+	}
+
 	if config.API.AccessLogFile != "" {
 		accessLogFile, err := os.OpenFile(config.API.AccessLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
@@ -319,10 +354,17 @@ func NewAPIListener(
 		a.accessLogFile = accessLogFile
 	}
 
-	sessionDB, err := session.NewSqliteProvider(path.Join(config.Server.DataDir, "api_sessions.db"), config.Server.GetSQLiteDataSourceOptions())
+	apiSessionsDB, err := server.db.Open(
+		"api_sessions",
+		path.Join(config.Server.DataDir, "api_sessions.db"),
+		api_sessions.AssetNames(),
+		api_sessions.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to create api session DB instance: %w", err)
 	}
+	sessionDB := session.NewDBProvider(apiSessionsDB)
 
 	a.apiSessions, err = session.NewCache(ctx, bearer.DefaultTokenLifetime, cleanupAPISessionsInterval, sessionDB, nil)
 	if err != nil {
@@ -393,59 +435,64 @@ var ErrCantLoadThatToken = errors.New("there was a problem accessing that token
 var ErrPrefixNotFound = errors.New("there is no token with that prefix")
 var ErrInvalidScopeOfThatToken = errors.New("the scope of the provided token is not authorized for this operation")
 var ErrThatTokenHasExpired = errors.New("the provided token has expired")
+var ErrTokenRestricted = errors.New("the restrictions of the provided token don't allow this request")
 
-// lookupUser is used to get the user on every request in auth middleware
-func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, err error) {
+// lookupUser is used to get the user on every request in auth middleware,
+// apiToken is the prefix of the API token if one was used instead of the password
+func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, apiToken string, err error) {
 	if !isBearerOnly {
 		if basicUser, basicPwd, basicAuthProvided := r.BasicAuth(); basicAuthProvided {
-			return al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+			return al.handleBasicAuth(r, basicUser, basicPwd)
 		}
 	}
 
 	if bearerToken, bearerAuthProvided := bearer.GetBearerToken(r); bearerAuthProvided {
 		isAuthorized, token, err := al.checkBearerToken(r.Context(), bearerToken, r.URL.Path, r.Method)
 		if err != nil {
-			return isAuthorized, "", err
+			return isAuthorized, "", "", err
 		}
 
-		return isAuthorized, token.AppClaims.Username, nil
+		return isAuthorized, token.AppClaims.Username, "", nil
 	}
 
 	// case when no auth method is provided
 	if al.bannedUsers.IsBanned("") {
-		return false, "", ErrTooManyRequests
+		return false, "", "", ErrTooManyRequests
 	}
 
-	return false, "", nil
+	return false, "", "", nil
 }
 
 // handleBasicAuth checks username and password against either user's password or token
-func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, username, password string) (authorized bool, name string, err error) {
+func (al *APIListener) handleBasicAuth(r *http.Request, username, password string) (authorized bool, name string, apiToken string, err error) {
 	if al.bannedUsers.IsBanned(username) {
-		return false, username, ErrTooManyRequests
+		return false, username, "", ErrTooManyRequests
 	}
 
 	if username == "" {
-		return false, "", nil
+		return false, "", "", nil
 	}
 
 	user, err := al.userService.GetByUsername(username)
 	if err != nil {
-		return false, username, fmt.Errorf("failed to get user: %v", err)
+		return false, username, "", fmt.Errorf("failed to get user: %v", err)
 	}
-	if user == nil {
-		return false, username, nil
+	if user == nil || user.IsDisabled() {
+		return false, username, "", nil
 	}
 
 	if user.PasswordExpired != nil && *user.PasswordExpired {
-		return false, username, ErrThatPasswordHasExpired
+		return false, username, "", ErrThatPasswordHasExpired
 	}
 
 	// skip basic auth with password when 2fa is enabled
-	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
-		passwordOk := verifyPassword(user.Password, password)
+	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled && !al.isWebAuthnRequired(user) {
+		passwordOk, err := al.verifyUserPassword(user, password)
+		if err != nil {
+			return false, username, "", err
+		}
 		if passwordOk {
-			return true, username, nil
+			return true, username, "", nil
 		}
 	}
 
@@ -454,38 +501,77 @@ func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, u
 	// TODO: this type of tokens "User tokens", meant to be used by scripts - used in place of the password at each request - should be renamed "passwords" or "long lived passwords" or "encrypted long lived passwords"
 	prefix, password, err := authorization.Extract(password)
 	if err != nil {
-		return false, username, nil
+		return false, username, "", nil
 	}
-	userToken, err := al.tokenManager.Get(ctx, username, prefix)
+	userToken, err := al.tokenManager.Get(r.Context(), username, prefix)
 	if err != nil {
-		return false, username, err
+		return false, username, "", err
 	}
 
 	if userToken != nil {
 		if userToken.ExpiresAt != nil {
 			if userToken.ExpiresAt.Before(time.Now()) {
-				return false, username, nil
+				return false, username, "", nil
 			}
 		}
 		tokenOk := verifyPassword(userToken.Token, password)
 		if tokenOk {
+			scopeOk := false
 			switch userToken.Scope {
 			case authorization.APITokenRead:
-				if httpverb == "GET" && !strings.Contains(urlpath, "/ws") {
-					return true, username, nil
-				}
+				scopeOk = r.Method == "GET" && !strings.Contains(r.URL.Path, "/ws")
 			case authorization.APITokenReadWrite:
-				return true, username, nil
+				scopeOk = true
 			case authorization.APITokenClientsAuth:
-				if strings.Contains(urlpath, "clients-auth") {
-					return true, username, nil
-				}
+				scopeOk = strings.Contains(r.URL.Path, "clients-auth")
+			}
+			if !scopeOk {
+				return false, username, "", ErrInvalidScopeOfThatToken
 			}
-			return false, username, ErrInvalidScopeOfThatToken
+			if err := al.checkAPITokenRestrictions(r, userToken); err != nil {
+				return false, username, "", err
+			}
+			return true, username, userToken.Prefix, nil
 		}
 	}
 
-	return false, username, nil
+	return false, username, "", nil
+}
+
+// checkAPITokenRestrictions returns ErrTokenRestricted if the request doesn't match the restrictions of the token
+func (al *APIListener) checkAPITokenRestrictions(r *http.Request, token *authorization.APIToken) error {
+	restrictions := token.Restrictions
+	if restrictions == nil {
+		return nil
+	}
+
+	// X-Forwarded-For can be sent by anyone, it's only used if it's set by a trusted proxy
+	if ip := chshare.TrustedRemoteIP(r, al.config.API.TrustedProxyNets); !restrictions.AllowsIP(ip) {
+		al.Infof("API token %q of %q used from not allowed IP %s", token.Prefix, token.Username, ip)
+		return ErrTokenRestricted
+	}
+
+	if !restrictions.AllowsEndpoint(r.URL.Path, r.Method) {
+		al.Infof("API token %q of %q is not allowed for %s %s", token.Prefix, token.Username, r.Method, r.URL.Path)
+		return ErrTokenRestricted
+	}
+
+	if restrictions.HasClientRestrictions() {
+		target, err := al.roleTarget(r)
+		if err != nil {
+			return err
+		}
+		if target.Client == nil && isClientSetRoute(r) {
+			// the clients are resolved by the handler, it checks them with checkTokenClients
+			return nil
+		}
+		if !tokenClientScope(restrictions).Matches(target) {
+			al.Infof("API token %q of %q is not allowed for clients of %s %s", token.Prefix, token.Username, r.Method, r.URL.Path)
+			return ErrTokenRestricted
+		}
+	}
+
+	return nil
 }
 
 func (al *APIListener) checkBearerToken(ctx context.Context, bearerToken, uri, method string) (bool, *bearer.TokenContext, error) {
@@ -552,7 +638,7 @@ func (al *APIListener) validateCredentials(username, password string, skipPasswo
 		}
 	}
 
-	if user == nil {
+	if user == nil || user.IsDisabled() {
 		return false, user, nil
 	}
 
@@ -560,7 +646,16 @@ func (al *APIListener) validateCredentials(username, password string, skipPasswo
 		return true, user, nil
 	}
 
-	return verifyPassword(user.Password, password), user, nil
+	passwordOk, err := al.verifyUserPassword(user, password)
+	return passwordOk, user, err
+}
+
+// verifyUserPassword checks the password against the users provider if it doesn't store passwords, otherwise against the stored one
+func (al *APIListener) verifyUserPassword(user *users.User, password string) (bool, error) {
+	if verifier := al.userService.GetPasswordVerifier(); verifier != nil {
+		return verifier.VerifyPassword(user.Username, password)
+	}
+	return verifyPassword(user.Password, password), nil
 }
 
 func (al *APIListener) shouldCreateMissingUser(user *users.User, skipPasswordValidation bool) bool {
@@ -594,6 +689,7 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var authorized bool
 		var username string
+		var apiToken string
 		var err error
 
 		tokenStr := r.URL.Query().Get(WebSocketAccessTokenQueryParam)
@@ -601,7 +697,7 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 			basicUser, basicPwd, basicAuthProvided := r.BasicAuth()
 
 			if basicAuthProvided {
-				authorized, username, err = al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+				authorized, username, apiToken, err = al.handleBasicAuth(r, basicUser, basicPwd)
 			} else {
 				if !al.handleBannedIPs(r, false) {
 					return
@@ -622,6 +718,10 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 				al.jsonErrorResponse(w, http.StatusTooManyRequests, err)
 				return
 			}
+			if errors.Is(err, ErrTokenRestricted) {
+				al.jsonErrorResponse(w, http.StatusForbidden, err)
+				return
+			}
 			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -637,6 +737,9 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 		}
 
 		newCtx := api.WithUser(r.Context(), username)
+		if apiToken != "" {
+			newCtx = api.WithAPIToken(newCtx, apiToken)
+		}
 		f.ServeHTTP(w, r.WithContext(newCtx))
 	}
 }
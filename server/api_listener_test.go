@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/server/api/users"
 )
@@ -28,6 +29,12 @@ func TestValidateCredentials(t *testing.T) {
 		Username: "user2",
 		Password: "user2",
 	}
+	disabled := true
+	u2Disabled := &users.User{
+		Username: "user2",
+		Password: "user2",
+		Disabled: &disabled,
+	}
 
 	testCases := []struct {
 		descr string // Test Case Description
@@ -108,6 +115,13 @@ func TestValidateCredentials(t *testing.T) {
 			password:  u2Plaintext.Password,
 			wantRes:   false,
 		},
+		{
+			descr:     "unauthorized, user deactivated",
+			repoUsers: []*users.User{u1Plaintext, u2Disabled},
+			username:  u2Disabled.Username,
+			password:  u2Disabled.Password,
+			wantRes:   false,
+		},
 		{
 			descr:     "unauthorized, user not found",
 			repoUsers: []*users.User{u1Plaintext},
@@ -132,3 +146,35 @@ func TestValidateCredentials(t *testing.T) {
 		assert.Equalf(t, gotRes, tc.wantRes, msg)
 	}
 }
+
+type passwordVerifierProvider struct {
+	*users.StaticProvider
+	passwords map[string]string
+}
+
+func (p *passwordVerifierProvider) VerifyPassword(username, password string) (bool, error) {
+	return password != "" && p.passwords[username] == password, nil
+}
+
+func TestValidateCredentialsWithPasswordVerifier(t *testing.T) {
+	// the provider doesn't store passwords, so they are checked by the provider itself
+	provider := &passwordVerifierProvider{
+		StaticProvider: users.NewStaticProvider([]*users.User{{Username: "alice"}}),
+		passwords:      map[string]string{"alice": "alice-pass"},
+	}
+	al := &APIListener{}
+	al.userService = users.NewAPIService(provider, false, 0, -1)
+
+	ok, user, err := al.validateCredentials("alice", "alice-pass", false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", user.Username)
+
+	ok, _, err = al.validateCredentials("alice", "", false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, _, err = al.validateCredentials("alice", "wrong", false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -11,10 +11,14 @@ import (
 
 	rportplus "github.com/openrport/openrport/plus"
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/authorization"
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/roles"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/share/enums"
 	"github.com/openrport/openrport/share/logger"
@@ -33,32 +37,187 @@ func (al *APIListener) wrapStaticPassModeMiddleware(next http.HandlerFunc) http.
 	}
 }
 
-func (al *APIListener) wrapAdminAccessMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if al.insecureForTests {
-			next.ServeHTTP(w, r)
-			return
+// wrapAdminAccessMiddleware allows the access to administrators and to users having a custom role granting access to the resource
+func (al *APIListener) wrapAdminAccessMiddleware(resource string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if al.insecureForTests {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := al.getUserModelForAuth(r.Context())
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+
+			if user.IsAdmin() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, err := al.authorizeByRoles(r, user, resource)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+			if allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			al.jsonError(w, errors2.APIError{
+				Message: fmt.Sprintf(
+					"current user should belong to %s group or have a role granting %s access to %q to access this resource",
+					users.Administrators,
+					roles.AccessForMethod(r.Method),
+					resource,
+				),
+				HTTPStatus: http.StatusForbidden,
+			})
+		})
+	}
+}
+
+// authorizeByRoles returns true if a custom role of the user grants the access the request needs to the resource
+func (al *APIListener) authorizeByRoles(r *http.Request, user *users.User, resource string) (bool, error) {
+	if al.roleManager == nil {
+		return false, nil
+	}
+
+	target, err := al.roleTarget(r)
+	if err != nil {
+		return false, err
+	}
+
+	return al.roleManager.Authorize(r.Context(), user.Groups, resource, roles.AccessForMethod(r.Method), target)
+}
+
+// roleTarget returns the client, client group, user or user group the request is performed on
+func (al *APIListener) roleTarget(r *http.Request) (roles.Target, error) {
+	vars := mux.Vars(r)
+	target := roles.Target{}
+
+	if clientID := vars[routes.ParamClientID]; clientID != "" {
+		client, err := al.clientService.GetByID(clientID)
+		if err != nil {
+			return target, err
 		}
+		if client != nil {
+			clientGroups, err := al.clientGroupProvider.GetAll(r.Context())
+			if err != nil {
+				return target, err
+			}
+			target.Client = clientRoleTarget(client, clientGroups)
+		}
+	}
+	if groupID := vars[routes.ParamGroupID]; groupID != "" {
+		target.Client = &roles.ClientTarget{Groups: []string{groupID}}
+	}
 
-		user, err := al.getUserModelForAuth(r.Context())
+	if userID := vars[routes.ParamUserID]; userID != "" {
+		user, err := al.userService.GetByUsername(userID)
 		if err != nil {
-			al.jsonError(w, err)
-			return
+			return target, err
+		}
+		target.UserGroups = []string{}
+		if user != nil {
+			target.UserGroups = append(target.UserGroups, user.Groups...)
 		}
+	}
+	if groupName := vars["group_name"]; groupName != "" {
+		target.UserGroups = []string{groupName}
+	}
 
-		if user.IsAdmin() {
-			next.ServeHTTP(w, r)
-			return
+	return target, nil
+}
+
+// clientRoleTarget returns the tags and the client groups of the client to match them against a scope
+func clientRoleTarget(client *clientdata.Client, clientGroups []*cgroups.ClientGroup) *roles.ClientTarget {
+	target := &roles.ClientTarget{Tags: client.GetTags()}
+	for _, g := range clientGroups {
+		if client.BelongsTo(g) {
+			target.Groups = append(target.Groups, g.ID)
 		}
+	}
+	return target
+}
 
-		al.jsonError(w, errors2.APIError{
-			Message: fmt.Sprintf(
-				"current user should belong to %s group to access this resource",
-				users.Administrators,
-			),
-			HTTPStatus: http.StatusForbidden,
-		})
-	})
+// clientSetRoutes are the routes on a set of clients, they are checked against client restrictions of API tokens once the clients are resolved
+var clientSetRoutes = map[string]bool{
+	routes.ClientsListRouteName:        true,
+	routes.MultiClientCommandRouteName: true,
+	routes.MultiClientScriptRouteName:  true,
+	routes.CommandsWSRouteName:         true,
+	routes.ScriptsWSRouteName:          true,
+	routes.SchedulesCreateRouteName:    true,
+	routes.DownloadsCreateRouteName:    true,
+}
+
+func isClientSetRoute(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	return route != nil && clientSetRoutes[route.GetName()]
+}
+
+func tokenClientScope(restrictions *authorization.APITokenRestrictions) roles.Scope {
+	return roles.Scope{ClientGroups: restrictions.ClientGroups, ClientTags: restrictions.ClientTags}
+}
+
+// getTokenClientRestrictions returns the restrictions of the API token used for the request if it's limited to some clients, otherwise nil
+func (al *APIListener) getTokenClientRestrictions(ctx context.Context) (*authorization.APITokenRestrictions, error) {
+	prefix := api.GetAPIToken(ctx)
+	if prefix == "" {
+		return nil, nil
+	}
+	token, err := al.tokenManager.Get(ctx, api.GetUser(ctx, al.Logger), prefix)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Restrictions == nil || !token.Restrictions.HasClientRestrictions() {
+		return nil, nil
+	}
+	return token.Restrictions, nil
+}
+
+// checkTokenClients returns an error if the API token used for the request isn't allowed for all of the given clients
+func (al *APIListener) checkTokenClients(ctx context.Context, clients []*clientdata.Client) error {
+	restrictions, err := al.getTokenClientRestrictions(ctx)
+	if err != nil || restrictions == nil {
+		return err
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	scope := tokenClientScope(restrictions)
+	for _, client := range clients {
+		if !scope.Matches(roles.Target{Client: clientRoleTarget(client, clientGroups)}) {
+			return errors2.APIError{
+				Message:    fmt.Sprintf("The API token is not allowed for client %s.", client.GetID()),
+				HTTPStatus: http.StatusForbidden,
+			}
+		}
+	}
+	return nil
+}
+
+// filterTokenClients returns the given clients the API token used for the request is allowed for
+func (al *APIListener) filterTokenClients(ctx context.Context, clients []*clientdata.CalculatedClient) ([]*clientdata.CalculatedClient, error) {
+	restrictions, err := al.getTokenClientRestrictions(ctx)
+	if err != nil || restrictions == nil {
+		return clients, err
+	}
+
+	scope := tokenClientScope(restrictions)
+	allowed := []*clientdata.CalculatedClient{}
+	for _, client := range clients {
+		if scope.Matches(roles.Target{Client: &roles.ClientTarget{Groups: client.Groups, Tags: client.GetTags()}}) {
+			allowed = append(allowed, client)
+		}
+	}
+	return allowed, nil
 }
 
 func (al *APIListener) wrapTotPEnabledMiddleware(next http.HandlerFunc) http.HandlerFunc {
@@ -75,13 +234,17 @@ func (al *APIListener) wrapTotPEnabledMiddleware(next http.HandlerFunc) http.Han
 func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareFunc {
 	return func(f http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authorized, username, err := al.lookupUser(r, isBearerOnly)
+			authorized, username, apiToken, err := al.lookupUser(r, isBearerOnly)
 			if err != nil {
 				al.Logf(logger.LogLevelError, err.Error())
 				if errors.Is(err, ErrTooManyRequests) {
 					al.jsonErrorResponse(w, http.StatusTooManyRequests, err)
 					return
 				}
+				if errors.Is(err, ErrTokenRestricted) {
+					al.jsonErrorResponse(w, http.StatusForbidden, err)
+					return
+				}
 				al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
@@ -97,6 +260,9 @@ func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareF
 			}
 
 			newCtx := api.WithUser(r.Context(), username)
+			if apiToken != "" {
+				newCtx = api.WithAPIToken(newCtx, apiToken)
+			}
 
 			token, hasBearerToken := bearer.GetBearerToken(r)
 			if hasBearerToken {
@@ -194,8 +360,15 @@ func (al *APIListener) permissionsMiddleware(permission string) mux.MiddlewareFu
 				// Check group permissions only if supported otherwise let pass.
 				err = al.userService.CheckPermission(currUser, permission)
 				if err != nil {
-					al.jsonError(w, err)
-					return
+					allowed, roleErr := al.authorizeByRoles(r, currUser, permission)
+					if roleErr != nil {
+						al.jsonError(w, roleErr)
+						return
+					}
+					if !allowed {
+						al.jsonError(w, err)
+						return
+					}
 				}
 				if rportplus.IsPlusEnabled(al.config.PlusConfig) &&
 					(permission == users.PermissionTunnels ||
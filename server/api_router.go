@@ -12,7 +12,9 @@ import (
 	"github.com/openrport/openrport/plus/capabilities/oauth"
 	"github.com/openrport/openrport/server/api/middleware"
 	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/roles"
 	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/server/scim"
 	"github.com/openrport/openrport/share/security"
 )
 
@@ -52,13 +54,22 @@ func (al *APIListener) initRouter() {
 	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handlePutToken).Methods(http.MethodPut)
 	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handleDeleteToken).Methods(http.MethodDelete)
 
-	secureAPI.HandleFunc("/clients", al.handleGetClients).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/clients", al.handleGetClients).Methods(http.MethodGet).Name(routes.ClientsListRouteName)
 	clientDetails := secureAPI.PathPrefix("/clients/{client_id}").Subrouter()
+	clientDetails.Use(al.ha.ForwardMiddleware(al.isClientConnectedHere))
 	clientDetails.Use(al.wrapClientAccessMiddleware)
 	clientDetails.HandleFunc("", al.handleGetClient).Methods(http.MethodGet)
 	clientDetails.HandleFunc("", al.handleDeleteClient).Methods(http.MethodDelete)
-	clientDetails.Handle("/acl", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostClientACL))).Methods(http.MethodPost)
+	clientDetails.Handle("/acl", al.wrapAdminAccessMiddleware(roles.ResourceClients)(http.HandlerFunc(al.handlePostClientACL))).Methods(http.MethodPost)
 	clientDetails.Handle("/scripts", al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleExecuteScript))).Methods(http.MethodPost)
+	clientDetails.Handle("/files", al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleGetClientFile))).Methods(http.MethodGet)
+
+	clientFS := clientDetails.PathPrefix("/fs").Subrouter()
+	clientFS.Use(al.permissionsMiddleware(users.PermissionUploads), al.withActiveClient)
+	clientFS.HandleFunc("", al.handleListClientFiles).Methods(http.MethodGet)
+	clientFS.HandleFunc("", al.handleDeleteClientFile).Methods(http.MethodDelete)
+	clientFS.HandleFunc("/stat", al.handleStatClientFile).Methods(http.MethodGet)
+	clientFS.HandleFunc("/directories", al.handlePostClientDirectory).Methods(http.MethodPost)
 
 	clientAttributes := clientDetails.PathPrefix("/attributes").Subrouter()
 	clientAttributes.Use(al.withActiveClient)
@@ -70,6 +81,7 @@ func (al *APIListener) initRouter() {
 	clientCommands.HandleFunc("", al.handlePostCommand).Methods(http.MethodPost)
 	clientCommands.HandleFunc("", al.handleGetCommands).Methods(http.MethodGet)
 	clientCommands.HandleFunc("/{job_id}", al.handleGetCommand).Methods(http.MethodGet)
+	clientCommands.HandleFunc("/{job_id}/output/{"+routes.ParamOutputStream+"}", al.handleDownloadJobOutput).Methods(http.MethodGet)
 
 	clientTunnels := clientDetails.NewRoute().Subrouter()
 	clientTunnels.Use(al.permissionsMiddleware(users.PermissionTunnels))
@@ -102,44 +114,94 @@ func (al *APIListener) initRouter() {
 
 	secureAPI.Handle("/tunnels", al.permissionsMiddleware(users.PermissionTunnels)(http.HandlerFunc(al.handleGetTunnels))).Methods(http.MethodGet)
 	secureAPI.Handle("/auditlog", al.permissionsMiddleware(users.PermissionsAuditLog)(http.HandlerFunc(al.handleListAuditLog))).Methods(http.MethodGet)
+
+	recordings := secureAPI.PathPrefix("/recordings").Subrouter()
+	recordings.Use(al.permissionsMiddleware(users.PermissionsAuditLog))
+	if al.recordings != nil {
+		recordings.HandleFunc("", al.handleListRecordings).Methods(http.MethodGet)
+		recordings.HandleFunc("/{"+routes.ParamRecordingID+"}", al.handleGetRecording).Methods(http.MethodGet)
+		recordings.HandleFunc("/{"+routes.ParamRecordingID+"}/download", al.handleDownloadRecording).Methods(http.MethodGet)
+	} else {
+		recordings.HandleFunc("", al.handleRecordingsDisabled).Methods(http.MethodGet)
+		recordings.HandleFunc("/{"+routes.ParamRecordingID+"}", al.handleRecordingsDisabled).Methods(http.MethodGet)
+		recordings.HandleFunc("/{"+routes.ParamRecordingID+"}/download", al.handleRecordingsDisabled).Methods(http.MethodGet)
+	}
 	secureAPI.Handle("/files", al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleFileUploads))).Methods(http.MethodPost).Name(routes.FilesUploadRouteName)
 
+	fileDownloads := secureAPI.PathPrefix("/downloads").Subrouter()
+	fileDownloads.Use(al.permissionsMiddleware(users.PermissionUploads))
+	fileDownloads.HandleFunc("", al.handleListDownloads).Methods(http.MethodGet)
+	fileDownloads.HandleFunc("", al.handlePostDownloads).Methods(http.MethodPost).Name(routes.DownloadsCreateRouteName)
+	fileDownloads.HandleFunc("/{"+routes.ParamDownloadID+"}", al.handleGetDownload).Methods(http.MethodGet)
+	fileDownloads.HandleFunc("/{"+routes.ParamDownloadID+"}", al.handleDeleteDownload).Methods(http.MethodDelete)
+	fileDownloads.HandleFunc("/{"+routes.ParamDownloadID+"}/content", al.handleGetDownloadContent).Methods(http.MethodGet)
+
 	secureAPI.HandleFunc("/client-groups", al.handleGetClientGroups).Methods(http.MethodGet)
 	secureAPI.HandleFunc("/client-groups/{group_id}", al.handleGetClientGroup).Methods(http.MethodGet)
 
-	adminOnly := secureAPI.NewRoute().Subrouter()
-	adminOnly.Use(al.wrapAdminAccessMiddleware)
-	adminOnly.HandleFunc("/client-groups", al.handlePostClientGroups).Methods(http.MethodPost)
-	adminOnly.HandleFunc("/client-groups/{group_id}", al.handlePutClientGroup).Methods(http.MethodPut)
-	adminOnly.HandleFunc("/client-groups/{group_id}", al.handleDeleteClientGroup).Methods(http.MethodDelete)
-	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleGetUsers)).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPost)
-	adminOnly.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPut)
-	adminOnly.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleDeleteUser)).Methods(http.MethodDelete)
-	adminOnly.HandleFunc("/users/{user_id}/totp-secret", al.wrapStaticPassModeMiddleware(
+	clientGroupsAdmin := secureAPI.NewRoute().Subrouter()
+	clientGroupsAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceClientGroups))
+	clientGroupsAdmin.HandleFunc("/client-groups", al.handlePostClientGroups).Methods(http.MethodPost)
+	clientGroupsAdmin.HandleFunc("/client-groups/{group_id}", al.handlePutClientGroup).Methods(http.MethodPut)
+	clientGroupsAdmin.HandleFunc("/client-groups/{group_id}", al.handleDeleteClientGroup).Methods(http.MethodDelete)
+
+	usersAdmin := secureAPI.NewRoute().Subrouter()
+	usersAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceUsers))
+	usersAdmin.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleGetUsers)).Methods(http.MethodGet)
+	usersAdmin.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPost)
+	usersAdmin.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPut)
+	usersAdmin.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleDeleteUser)).Methods(http.MethodDelete)
+	usersAdmin.HandleFunc("/users/{user_id}/totp-secret", al.wrapStaticPassModeMiddleware(
 		al.wrapTotPEnabledMiddleware(al.handleDeleteUsersTotP),
 	)).Methods(http.MethodDelete)
+	usersAdmin.HandleFunc("/users/{user_id}/webauthn-credentials", al.wrapStaticPassModeMiddleware(
+		al.wrapWebAuthnEnabledMiddleware(al.handleDeleteUsersWebAuthnCredentials),
+	)).Methods(http.MethodDelete)
 
-	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleGetUserAPISessions).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleDeleteAllUserAPISessions).Methods(http.MethodDelete)
-	adminOnly.HandleFunc("/users/{user_id}/sessions/{session_id}", al.handleDeleteUserAPISession).Methods(http.MethodDelete)
-
-	adminOnly.HandleFunc("/user-groups", al.handleListUserGroups).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleGetUserGroup)).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleUpdateUserGroup)).Methods(http.MethodPut)
-	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleDeleteUserGroup)).Methods(http.MethodDelete)
-
-	adminOnly.HandleFunc("/clients-auth", al.handleGetClientsAuth).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleGetClientAuth).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/clients-auth", al.handlePostClientsAuth).Methods(http.MethodPost)
-	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleDeleteClientAuth).Methods(http.MethodDelete)
-
-	adminOnly.HandleFunc("/notification-logs", al.handleGetNotifications).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/notification-logs/{notification_id}", al.handleGetNotificationDetails).Methods(http.MethodGet)
+	usersAdmin.HandleFunc("/users/{user_id}/sessions", al.handleGetUserAPISessions).Methods(http.MethodGet)
+	usersAdmin.HandleFunc("/users/{user_id}/sessions", al.handleDeleteAllUserAPISessions).Methods(http.MethodDelete)
+	usersAdmin.HandleFunc("/users/{user_id}/sessions/{session_id}", al.handleDeleteUserAPISession).Methods(http.MethodDelete)
+
+	userGroupsAdmin := secureAPI.NewRoute().Subrouter()
+	userGroupsAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceUserGroups))
+	userGroupsAdmin.HandleFunc("/user-groups", al.handleListUserGroups).Methods(http.MethodGet)
+	userGroupsAdmin.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleGetUserGroup)).Methods(http.MethodGet)
+	userGroupsAdmin.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleUpdateUserGroup)).Methods(http.MethodPut)
+	userGroupsAdmin.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleDeleteUserGroup)).Methods(http.MethodDelete)
+
+	rolesAdmin := secureAPI.NewRoute().Subrouter()
+	rolesAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceRoles))
+	rolesAdmin.HandleFunc("/roles", al.handleGetRoles).Methods(http.MethodGet)
+	rolesAdmin.HandleFunc("/roles", al.handlePostRoles).Methods(http.MethodPost)
+	rolesAdmin.HandleFunc("/roles/{role_id}", al.handleGetRole).Methods(http.MethodGet)
+	rolesAdmin.HandleFunc("/roles/{role_id}", al.handlePutRole).Methods(http.MethodPut)
+	rolesAdmin.HandleFunc("/roles/{role_id}", al.handleDeleteRole).Methods(http.MethodDelete)
+
+	clientsAuthAdmin := secureAPI.NewRoute().Subrouter()
+	clientsAuthAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceClientsAuth))
+	clientsAuthAdmin.HandleFunc("/clients-auth", al.handleGetClientsAuth).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/clients-auth/{client_auth_id}", al.handleGetClientAuth).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/clients-auth", al.handlePostClientsAuth).Methods(http.MethodPost)
+	clientsAuthAdmin.HandleFunc("/clients-auth/{client_auth_id}", al.handleDeleteClientAuth).Methods(http.MethodDelete)
+	clientsAuthAdmin.HandleFunc("/clients-auth/{client_auth_id}/rotation", al.handleGetClientAuthRotation).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/clients-auth/{client_auth_id}/rotation", al.handlePutClientAuthRotation).Methods(http.MethodPut)
+	clientsAuthAdmin.HandleFunc("/clients-auth/{client_auth_id}/rotate", al.handleRotateClientAuth).Methods(http.MethodPost)
+	clientsAuthAdmin.HandleFunc("/enrollment-tokens", al.handleListEnrollmentTokens).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/enrollment-tokens", al.handlePostEnrollmentToken).Methods(http.MethodPost)
+	clientsAuthAdmin.HandleFunc("/enrollment-tokens/{token_id}", al.handleDeleteEnrollmentToken).Methods(http.MethodDelete)
+	clientsAuthAdmin.HandleFunc("/enrollments", al.handleListEnrollments).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/enrollments/{client_auth_id}", al.handleGetEnrollment).Methods(http.MethodGet)
+	clientsAuthAdmin.HandleFunc("/enrollments/{client_auth_id}/approve", al.handleApproveEnrollment).Methods(http.MethodPost)
+	clientsAuthAdmin.HandleFunc("/enrollments/{client_auth_id}/reject", al.handleRejectEnrollment).Methods(http.MethodPost)
+
+	notificationLogsAdmin := secureAPI.NewRoute().Subrouter()
+	notificationLogsAdmin.Use(al.wrapAdminAccessMiddleware(roles.ResourceNotificationLogs))
+	notificationLogsAdmin.HandleFunc("/notification-logs", al.handleGetNotifications).Methods(http.MethodGet)
+	notificationLogsAdmin.HandleFunc("/notification-logs/{notification_id}", al.handleGetNotificationDetails).Methods(http.MethodGet)
 
 	commands := secureAPI.NewRoute().Subrouter()
 	commands.Use(al.permissionsMiddleware(users.PermissionCommands))
-	commands.HandleFunc("/commands", al.handlePostMultiClientCommand).Methods(http.MethodPost)
+	commands.HandleFunc("/commands", al.handlePostMultiClientCommand).Methods(http.MethodPost).Name(routes.MultiClientCommandRouteName)
 	commands.HandleFunc("/commands", al.handleGetMultiClientCommands).Methods(http.MethodGet)
 	commands.HandleFunc("/commands/{job_id}", al.handleGetMultiClientCommand).Methods(http.MethodGet)
 	commands.HandleFunc("/commands/{job_id}/jobs", al.handleGetMultiClientCommandJobs).Methods(http.MethodGet)
@@ -156,14 +218,15 @@ func (al *APIListener) initRouter() {
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleScriptUpdate).Methods(http.MethodPut)
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleReadScript).Methods(http.MethodGet)
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleDeleteScript).Methods(http.MethodDelete)
-	scripts.HandleFunc("/scripts", al.handlePostMultiClientScript).Methods(http.MethodPost)
+	scripts.HandleFunc("/scripts", al.handlePostMultiClientScript).Methods(http.MethodPost).Name(routes.MultiClientScriptRouteName)
 
 	vault := secureAPI.NewRoute().Subrouter()
 	vault.Use(al.permissionsMiddleware(users.PermissionVault))
 	vault.HandleFunc("/vault-admin", al.handleGetVaultStatus).Methods(http.MethodGet)
-	vault.Handle("/vault-admin/sesame", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleVaultUnlock))).Methods(http.MethodPost)
-	vault.Handle("/vault-admin/init", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleVaultInit))).Methods(http.MethodPost)
-	vault.Handle("/vault-admin/sesame", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleVaultLock))).Methods(http.MethodDelete)
+	vault.Handle("/vault-admin/sesame", al.wrapAdminAccessMiddleware(roles.ResourceVaultAdmin)(http.HandlerFunc(al.handleVaultUnlock))).Methods(http.MethodPost)
+	vault.Handle("/vault-admin/init", al.wrapAdminAccessMiddleware(roles.ResourceVaultAdmin)(http.HandlerFunc(al.handleVaultInit))).Methods(http.MethodPost)
+	vault.Handle("/vault-admin/sesame", al.wrapAdminAccessMiddleware(roles.ResourceVaultAdmin)(http.HandlerFunc(al.handleVaultLock))).Methods(http.MethodDelete)
+	vault.Handle("/vault-admin/rekey", al.wrapAdminAccessMiddleware(roles.ResourceVaultAdmin)(http.HandlerFunc(al.handleVaultReKey))).Methods(http.MethodPost)
 	vault.HandleFunc("/vault", al.handleListVaultValues).Methods(http.MethodGet)
 	vault.HandleFunc("/vault", al.handleVaultStoreValue).Methods(http.MethodPost)
 	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}", al.handleReadVaultValue).Methods(http.MethodGet)
@@ -173,26 +236,50 @@ func (al *APIListener) initRouter() {
 	schedules := secureAPI.PathPrefix("/schedules").Subrouter()
 	schedules.Use(al.permissionsMiddleware(users.PermissionScheduler))
 	schedules.HandleFunc("", al.handleListSchedules).Methods(http.MethodGet)
-	schedules.HandleFunc("", al.handlePostSchedules).Methods(http.MethodPost)
+	schedules.HandleFunc("", al.handlePostSchedules).Methods(http.MethodPost).Name(routes.SchedulesCreateRouteName)
 	schedules.HandleFunc("/{schedule_id}", al.handleGetSchedule).Methods(http.MethodGet)
 	schedules.HandleFunc("/{schedule_id}", al.handleUpdateSchedule).Methods(http.MethodPut)
 	schedules.HandleFunc("/{schedule_id}", al.handleDeleteSchedule).Methods(http.MethodDelete)
 
+	approvalRoutes := secureAPI.PathPrefix("/approvals").Subrouter()
+	approvalRoutes.Use(al.permissionsMiddleware(users.PermissionApprovals))
+	approvalRoutes.HandleFunc("", al.handleListApprovals).Methods(http.MethodGet)
+	approvalRoutes.HandleFunc("/{approval_id}", al.handleGetApproval).Methods(http.MethodGet)
+	approvalRoutes.HandleFunc("/{approval_id}/approve", al.handleApproveApproval).Methods(http.MethodPost)
+	approvalRoutes.HandleFunc("/{approval_id}/reject", al.handleRejectApproval).Methods(http.MethodPost)
+
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handleGetTotP)).Methods(http.MethodGet)
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handlePostTotP)).Methods(http.MethodPost)
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handleDeleteTotP)).Methods(http.MethodDelete)
 
+	secureAPI.HandleFunc(routes.WebAuthnRegistrationRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnRegistration)).Methods(http.MethodPost)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute, al.wrapWebAuthnEnabledMiddleware(al.handleGetWebAuthnCredentials)).Methods(http.MethodGet)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnCredential)).Methods(http.MethodPost)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute+"/{"+routes.ParamCredentialID+"}", al.wrapWebAuthnEnabledMiddleware(al.handleDeleteWebAuthnCredential)).Methods(http.MethodDelete)
+
 	// all routes defined below do not have authorization middleware, auth is done in each handler separately
 	api.HandleFunc("/login", al.handleGetLogin).Methods(http.MethodGet)
 	api.HandleFunc("/login", al.handlePostLogin).Methods(http.MethodPost)
 	api.HandleFunc("/logout", al.handleDeleteLogout).Methods(http.MethodDelete)
 	api.Handle(routes.Verify2FaRoute, al.wrapWithAuthMiddleware(true)(al.handlePostVerify2FAToken())).Methods(http.MethodPost)
+	api.HandleFunc(routes.WebAuthnLoginOptionsRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnLoginOptions)).Methods(http.MethodPost)
+	api.HandleFunc(routes.WebAuthnLoginRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnLogin)).Methods(http.MethodPost)
 
 	// web sockets
 	// common auth middleware is not used due to JS issue https://stackoverflow.com/questions/22383089/is-it-possible-to-use-bearer-authentication-for-websocket-upgrade-requests
-	api.HandleFunc("/ws/commands", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(al.handleCommandsWS)))).Methods(http.MethodGet)
-	api.HandleFunc("/ws/scripts", al.wsAuth(al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleScriptsWS)))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/commands", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(al.handleCommandsWS)))).Methods(http.MethodGet).Name(routes.CommandsWSRouteName)
+	api.HandleFunc("/ws/scripts", al.wsAuth(al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleScriptsWS)))).Methods(http.MethodGet).Name(routes.ScriptsWSRouteName)
 	api.HandleFunc("/ws/uploads", al.wsAuth(al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleUploadsWS)))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/clients/{client_id}/commands/{job_id}/output/{"+routes.ParamOutputStream+"}", al.wsAuth(al.ha.ForwardMiddleware(al.isClientConnectedHere)(al.permissionsMiddleware(users.PermissionCommands)(al.wrapClientAccessMiddleware(http.HandlerFunc(al.handleJobOutputWS)))))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/clients/{client_id}/terminal", al.wsAuth(al.ha.ForwardMiddleware(al.isClientConnectedHere)(al.permissionsMiddleware(users.PermissionTerminal)(al.wrapClientAccessMiddleware(http.HandlerFunc(al.handleTerminalWS)))))).Methods(http.MethodGet)
+
+	// requests the nodes of a HA cluster send each other for the clients connected to the receiving node
+	if al.ha != nil {
+		haAPI := api.PathPrefix("/ha").Subrouter()
+		haAPI.Use(al.ha.NodeAuth)
+		haAPI.HandleFunc("/clients/{client_id}/jobs", al.handleHAPostClientJob).Methods(http.MethodPost)
+		haAPI.HandleFunc("/clients/{client_id}/downloads", al.handleHAPostClientDownload).Methods(http.MethodPost)
+	}
 
 	if al.config.API.EnableWsTestEndpoints {
 		api.HandleFunc("/test/commands/ui", al.wsCommands)
@@ -200,6 +287,17 @@ func (al *APIListener) initRouter() {
 		api.HandleFunc("/test/uploads/ui", al.wsUploads)
 	}
 
+	if al.config.API.SCIM.IsEnabled() {
+		api.PathPrefix(routes.SCIMRoutesPrefix).Handler(scim.NewHandler(
+			routes.AllRoutesPrefix+routes.SCIMRoutesPrefix,
+			al.config.API.SCIM.Token,
+			al.userService,
+			al.apiSessions,
+			al.config.API.TwoFATokenDelivery == "smtp",
+			al.Logger.Fork("scim"),
+		))
+	}
+
 	if al.bannedIPs != nil {
 		api.Use(security.RejectBannedIPs(al.bannedIPs))
 	}
@@ -225,27 +323,27 @@ func (al *APIListener) initRouter() {
 	if rportplus.IsPlusEnabled(al.config.PlusConfig) {
 		secureASRouter := secureAPI.PathPrefix(routes.AlertingServiceRoutesPrefix).Subrouter()
 
-		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetRuleSet))).Methods(http.MethodGet)
-		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteRuleSet))).Methods(http.MethodDelete)
+		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetRuleSet))).Methods(http.MethodGet)
+		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleDeleteRuleSet))).Methods(http.MethodDelete)
 
-		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleSaveRuleSet))).Methods(http.MethodPut)
+		secureASRouter.Handle(routes.ASRuleSetRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleSaveRuleSet))).Methods(http.MethodPut)
 
-		secureASRouter.Handle(routes.ASProblemsRoute+"/{"+routes.ParamProblemID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetProblem))).Methods(http.MethodGet)
-		secureASRouter.Handle(routes.ASProblemsRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetLatestProblems))).Methods(http.MethodGet)
+		secureASRouter.Handle(routes.ASProblemsRoute+"/{"+routes.ParamProblemID+"}", al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetProblem))).Methods(http.MethodGet)
+		secureASRouter.Handle(routes.ASProblemsRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetLatestProblems))).Methods(http.MethodGet)
 
-		secureASRouter.Handle(routes.ASProblemsRoute+"/{"+routes.ParamProblemID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleUpdateProblem))).Methods(http.MethodPut)
+		secureASRouter.Handle(routes.ASProblemsRoute+"/{"+routes.ParamProblemID+"}", al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleUpdateProblem))).Methods(http.MethodPut)
 
-		secureASRouter.Handle(routes.ASTemplatesRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetAllTemplates))).Methods(http.MethodGet)
+		secureASRouter.Handle(routes.ASTemplatesRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetAllTemplates))).Methods(http.MethodGet)
 		secureASRouter.Handle(routes.ASTemplatesRoute+"/{"+routes.ParamTemplateID+"}",
-			al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetTemplate))).Methods(http.MethodGet)
+			al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetTemplate))).Methods(http.MethodGet)
 		secureASRouter.Handle(routes.ASTemplatesRoute+"/{"+routes.ParamTemplateID+"}",
-			al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteTemplate))).Methods(http.MethodDelete)
+			al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleDeleteTemplate))).Methods(http.MethodDelete)
 
-		secureASRouter.Handle(routes.ASTemplatesRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleSaveTemplate))).Methods(http.MethodPost)
-		secureASRouter.Handle(routes.ASTemplatesRoute+"/{"+routes.ParamTemplateID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleSaveTemplate))).Methods(http.MethodPut)
+		secureASRouter.Handle(routes.ASTemplatesRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleSaveTemplate))).Methods(http.MethodPost)
+		secureASRouter.Handle(routes.ASTemplatesRoute+"/{"+routes.ParamTemplateID+"}", al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleSaveTemplate))).Methods(http.MethodPut)
 
-		secureASRouter.Handle(routes.ASRuleSetRoute+routes.ASRunTestRulesRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleTestRules))).Methods(http.MethodPut)
-		secureASRouter.Handle(routes.ASRuleSetRoute+routes.ASSampleDataRoute+"/{"+routes.ParamSampleDataChoice+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetSampleData))).Methods(http.MethodGet)
+		secureASRouter.Handle(routes.ASRuleSetRoute+routes.ASRunTestRulesRoute, al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleTestRules))).Methods(http.MethodPut)
+		secureASRouter.Handle(routes.ASRuleSetRoute+routes.ASSampleDataRoute+"/{"+routes.ParamSampleDataChoice+"}", al.wrapAdminAccessMiddleware(roles.ResourceAlerting)(http.HandlerFunc(al.handleGetSampleData))).Methods(http.MethodGet)
 	}
 
 	if rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
@@ -0,0 +1,46 @@
+package approvals
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/openrport/openrport/share/types"
+)
+
+const (
+	TypeCommand        = "command"
+	TypeScript         = "script"
+	TypeSchedule       = "schedule"
+	TypeScheduleUpdate = "schedule_update"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Approval is a command, script, new or updated schedule that targets clients of a client group requiring approval.
+// It's executed only after another user approved it.
+type Approval struct {
+	ID     string `json:"id" db:"id"`
+	Type   string `json:"type" db:"type"`
+	Status string `json:"status" db:"status"`
+	// ClientGroupIDs are the groups that required the approval
+	ClientGroupIDs types.StringSlice `json:"client_group_ids" db:"client_group_ids"`
+	RequestedAt    time.Time         `json:"requested_at" db:"requested_at"`
+	RequestedBy    string            `json:"requested_by" db:"requested_by"`
+	DecidedAt      *time.Time        `json:"decided_at" db:"decided_at"`
+	DecidedBy      *string           `json:"decided_by" db:"decided_by"`
+	Reason         string            `json:"reason" db:"reason"`
+	// ResultID is the id of the multi job or the schedule created or updated once approved
+	ResultID *string `json:"result_id" db:"result_id"`
+	// Error is set if the request failed to execute after it was approved
+	Error   string           `json:"error" db:"error"`
+	Request types.JSONString `json:"request" db:"request"`
+}
+
+// DecodeRequest unmarshals the stored request body into v
+func (a *Approval) DecodeRequest(v interface{}) error {
+	return json.Unmarshal([]byte(a.Request), v)
+}
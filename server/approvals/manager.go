@@ -0,0 +1,256 @@
+package approvals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/notifications"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/query"
+	"github.com/openrport/openrport/share/random"
+	"github.com/openrport/openrport/share/refs"
+	"github.com/openrport/openrport/share/types"
+)
+
+const NotificationRefType refs.IdentifiableType = "approval"
+
+var (
+	supportedSorts = map[string]bool{
+		"requested_at": true,
+		"requested_by": true,
+		"decided_at":   true,
+		"type":         true,
+		"status":       true,
+	}
+	supportedFilters = map[string]bool{
+		"id":           true,
+		"type":         true,
+		"status":       true,
+		"requested_by": true,
+		"decided_by":   true,
+		"requested_at": true,
+	}
+	defaultSorts = map[string][]string{
+		"sort": {"-requested_at"},
+	}
+)
+
+type Provider interface {
+	Insert(ctx context.Context, a *Approval) error
+	Get(ctx context.Context, id string) (*Approval, error)
+	List(ctx context.Context, options *query.ListOptions) ([]*Approval, error)
+	Count(ctx context.Context, options *query.ListOptions) (int, error)
+	Decide(ctx context.Context, a *Approval) (bool, error)
+	SetResult(ctx context.Context, a *Approval) error
+}
+
+// Manager keeps track of the requests waiting for approval. It doesn't execute the approved requests,
+// that's up to the caller, which then reports the outcome with SetResult.
+type Manager struct {
+	provider   Provider
+	dispatcher notifications.Dispatcher
+	logger     *logger.Logger
+
+	notificationTarget     string
+	notificationRecipients []string
+}
+
+func NewManager(provider Provider, dispatcher notifications.Dispatcher, notificationTarget string, notificationRecipients []string, logger *logger.Logger) *Manager {
+	return &Manager{
+		provider:               provider,
+		dispatcher:             dispatcher,
+		logger:                 logger,
+		notificationTarget:     notificationTarget,
+		notificationRecipients: notificationRecipients,
+	}
+}
+
+// Create stores a new pending approval for the given request
+func (m *Manager) Create(ctx context.Context, typ string, clientGroupIDs []string, request interface{}, username string) (*Approval, error) {
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Approval{
+		ID:             id,
+		Type:           typ,
+		Status:         StatusPending,
+		ClientGroupIDs: clientGroupIDs,
+		RequestedAt:    time.Now().UTC(),
+		RequestedBy:    username,
+		Request:        types.JSONString(body),
+	}
+
+	if err := m.provider.Insert(ctx, a); err != nil {
+		return nil, err
+	}
+
+	m.notify(ctx, a,
+		fmt.Sprintf("Approval required: %s requested by %s", a.Type, a.RequestedBy),
+		fmt.Sprintf("%s requested a %s on clients of the client group(s) %s, which requires approval.\nApproval ID: %s",
+			a.RequestedBy, a.Type, strings.Join(a.ClientGroupIDs, ", "), a.ID),
+	)
+
+	return a, nil
+}
+
+func (m *Manager) List(ctx context.Context, r *http.Request) (*api.SuccessPayload, error) {
+	options := query.NewOptions(r, defaultSorts, nil, nil)
+	err := query.ValidateListOptions(options, supportedSorts, supportedFilters, nil /*fields*/, &query.PaginationConfig{
+		MaxLimit:     100,
+		DefaultLimit: 20,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := m.provider.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := m.provider.Count(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.SuccessPayload{
+		Data: entries,
+		Meta: api.NewMeta(count),
+	}, nil
+}
+
+// Get returns the approval with the given id or a 404 APIError
+func (m *Manager) Get(ctx context.Context, id string) (*Approval, error) {
+	a, err := m.provider.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, errors.APIError{
+			Message:    fmt.Sprintf("Approval[id=%q] not found.", id),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return a, nil
+}
+
+// Approve marks the pending approval as approved. Users can't approve their own requests.
+func (m *Manager) Approve(ctx context.Context, id string, username string) (*Approval, error) {
+	a, err := m.decide(ctx, id, username, StatusApproved, "")
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(ctx, a,
+		fmt.Sprintf("Approved: %s requested by %s", a.Type, a.RequestedBy),
+		fmt.Sprintf("%s approved the %s requested by %s.\nApproval ID: %s", username, a.Type, a.RequestedBy, a.ID),
+	)
+
+	return a, nil
+}
+
+// Reject marks the pending approval as rejected, the request is never executed
+func (m *Manager) Reject(ctx context.Context, id string, username string, reason string) (*Approval, error) {
+	a, err := m.decide(ctx, id, username, StatusRejected, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	m.notify(ctx, a,
+		fmt.Sprintf("Rejected: %s requested by %s", a.Type, a.RequestedBy),
+		fmt.Sprintf("%s rejected the %s requested by %s.\nReason: %s\nApproval ID: %s", username, a.Type, a.RequestedBy, reason, a.ID),
+	)
+
+	return a, nil
+}
+
+func (m *Manager) decide(ctx context.Context, id string, username string, status string, reason string) (*Approval, error) {
+	a, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.RequestedBy == username {
+		return nil, errors.APIError{
+			Message:    "Requests must be approved or rejected by another user.",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+
+	if err := CheckPending(a); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	a.Status = status
+	a.DecidedAt = &now
+	a.DecidedBy = &username
+	a.Reason = reason
+
+	ok, err := m.provider.Decide(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// decided concurrently by another user
+		return nil, errNotPending(id)
+	}
+
+	return a, nil
+}
+
+// CheckPending returns a 409 APIError if the approval was already approved or rejected
+func CheckPending(a *Approval) error {
+	if a.Status != StatusPending {
+		return errNotPending(a.ID)
+	}
+	return nil
+}
+
+func errNotPending(id string) error {
+	return errors.APIError{
+		Message:    fmt.Sprintf("Approval[id=%q] is not pending anymore.", id),
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// SetResult stores the id of the multi job or schedule created for an approved request, or the error if it failed
+func (m *Manager) SetResult(ctx context.Context, a *Approval, resultID string, execErr error) error {
+	if resultID != "" {
+		a.ResultID = &resultID
+	}
+	if execErr != nil {
+		a.Error = execErr.Error()
+	}
+	return m.provider.SetResult(ctx, a)
+}
+
+func (m *Manager) notify(ctx context.Context, a *Approval, subject, content string) {
+	if m.notificationTarget == "" {
+		return
+	}
+
+	_, err := m.dispatcher.Dispatch(ctx, refs.NewIdentifiable(NotificationRefType, a.ID), notifications.NotificationData{
+		Target:      m.notificationTarget,
+		Recipients:  m.notificationRecipients,
+		Subject:     subject,
+		Content:     content,
+		ContentType: notifications.ContentTypeTextPlain,
+	})
+	if err != nil {
+		m.logger.Errorf("failed to send notification for approval %s: %v", a.ID, err)
+	}
+}
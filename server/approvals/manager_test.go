@@ -0,0 +1,108 @@
+package approvals
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/openrport/openrport/db/migration/jobs"
+	"github.com/openrport/openrport/server/notifications"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/refs"
+	"github.com/openrport/openrport/share/test"
+)
+
+var testLog = logger.NewLogger("approvals-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type fakeDispatcher struct {
+	sent []notifications.NotificationData
+}
+
+func (d *fakeDispatcher) Dispatch(_ context.Context, _ refs.Identifiable, n notifications.NotificationData) (refs.Identifiable, error) {
+	d.sent = append(d.sent, n)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+func newTestManager(t *testing.T) (*Manager, *fakeDispatcher) {
+	db := test.NewMemoryDB(t, jobsmigration.AssetNames(), jobsmigration.Asset)
+
+	dispatcher := &fakeDispatcher{}
+	return NewManager(NewSQLiteProvider(db), dispatcher, "smtp", []string{"ops@example.com"}, testLog), dispatcher
+}
+
+func TestApprove(t *testing.T) {
+	ctx := context.Background()
+	m, dispatcher := newTestManager(t)
+
+	a, err := m.Create(ctx, TypeCommand, []string{"prod"}, map[string]string{"command": "reboot"}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, a.Status)
+	require.Len(t, dispatcher.sent, 1)
+	assert.Equal(t, "smtp", dispatcher.sent[0].Target)
+	assert.Equal(t, []string{"ops@example.com"}, dispatcher.sent[0].Recipients)
+	assert.Equal(t, "Approval required: command requested by alice", dispatcher.sent[0].Subject)
+
+	_, err = m.Approve(ctx, a.ID, "alice")
+	test.RequireHTTPStatus(t, err, 403)
+
+	approved, err := m.Approve(ctx, a.ID, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, approved.Status)
+	require.Len(t, dispatcher.sent, 2)
+
+	require.NoError(t, m.SetResult(ctx, approved, "jid-1", nil))
+
+	stored, err := m.Get(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, stored.Status)
+	assert.Equal(t, "bob", *stored.DecidedBy)
+	assert.Equal(t, "jid-1", *stored.ResultID)
+	var request map[string]string
+	require.NoError(t, stored.DecodeRequest(&request))
+	assert.Equal(t, map[string]string{"command": "reboot"}, request)
+
+	_, err = m.Reject(ctx, a.ID, "carol", "too late")
+	test.RequireHTTPStatus(t, err, 409)
+}
+
+func TestReject(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	a, err := m.Create(ctx, TypeSchedule, []string{"prod"}, map[string]string{}, "alice")
+	require.NoError(t, err)
+
+	rejected, err := m.Reject(ctx, a.ID, "bob", "not during business hours")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRejected, rejected.Status)
+	assert.Equal(t, "not during business hours", rejected.Reason)
+
+	_, err = m.Approve(ctx, a.ID, "carol")
+	test.RequireHTTPStatus(t, err, 409)
+
+	_, err = m.Get(ctx, "unknown")
+	test.RequireHTTPStatus(t, err, 404)
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	a1, err := m.Create(ctx, TypeCommand, []string{"prod"}, map[string]string{}, "alice")
+	require.NoError(t, err)
+	_, err = m.Create(ctx, TypeScript, []string{"prod"}, map[string]string{}, "alice")
+	require.NoError(t, err)
+	_, err = m.Reject(ctx, a1.ID, "bob", "")
+	require.NoError(t, err)
+
+	payload, err := m.List(ctx, httptest.NewRequest("GET", "/approvals?filter[status]=pending", nil))
+	require.NoError(t, err)
+	list := payload.Data.([]*Approval)
+	require.Len(t, list, 1)
+	assert.Equal(t, TypeScript, list[0].Type)
+	assert.Equal(t, 1, payload.Meta.Count)
+}
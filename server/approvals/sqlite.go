@@ -0,0 +1,119 @@
+package approvals
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
+)
+
+type SQLiteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSQLiteProvider(db *sqlx.DB) *SQLiteProvider {
+	return &SQLiteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+func (p *SQLiteProvider) Insert(ctx context.Context, a *Approval) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO approvals (
+			id,
+			type,
+			status,
+			client_group_ids,
+			requested_at,
+			requested_by,
+			request
+		) VALUES (
+			:id,
+			:type,
+			:status,
+			:client_group_ids,
+			:requested_at,
+			:requested_by,
+			:request
+		)`,
+		a,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) Get(ctx context.Context, id string) (*Approval, error) {
+	a := &Approval{}
+	err := p.db.GetContext(ctx, a, p.converter.Rebind("SELECT * FROM approvals WHERE id = ?"), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (p *SQLiteProvider) List(ctx context.Context, options *query.ListOptions) ([]*Approval, error) {
+	q, params := p.converter.ConvertListOptionsToQuery(options, "SELECT * FROM approvals")
+
+	values := []*Approval{}
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *SQLiteProvider) Count(ctx context.Context, options *query.ListOptions) (int, error) {
+	countOptions := *options
+	countOptions.Sorts = nil
+	countOptions.Pagination = nil
+	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, "SELECT COUNT(*) FROM approvals")
+
+	var count int
+	err := p.db.GetContext(ctx, &count, p.converter.Rebind(q), params...)
+	return count, err
+}
+
+// Decide stores the decision on a pending approval. It returns false if the approval is not pending anymore,
+// so the same request can't be approved twice.
+func (p *SQLiteProvider) Decide(ctx context.Context, a *Approval) (bool, error) {
+	res, err := p.db.NamedExecContext(ctx,
+		`UPDATE approvals SET
+			status = :status,
+			decided_at = :decided_at,
+			decided_by = :decided_by,
+			reason = :reason
+		WHERE id = :id AND status = '`+StatusPending+`'`,
+		a,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SetResult stores the outcome of executing an approved request
+func (p *SQLiteProvider) SetResult(ctx context.Context, a *Approval) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`UPDATE approvals SET
+			result_id = :result_id,
+			error = :error
+		WHERE id = :id`,
+		a,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) Close() error {
+	return p.db.Close()
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/auditlog/config"
 
+	"github.com/openrport/openrport/db/backend"
+	"github.com/openrport/openrport/db/migration/auditlog"
 	"github.com/openrport/openrport/db/sqlite"
 
 	"github.com/openrport/openrport/server/api"
@@ -26,6 +28,7 @@ var (
 		"timestamp[since]": true,
 		"timestamp[until]": true,
 		"username":         true,
+		"api_token":        true,
 		"remote_ip":        true,
 		"application":      true,
 		"action":           true,
@@ -36,6 +39,7 @@ var (
 	supportedSorts = map[string]bool{
 		"timestamp":       true,
 		"username":        true,
+		"api_token":       true,
 		"remote_ip":       true,
 		"application":     true,
 		"action":          true,
@@ -71,14 +75,22 @@ func (e *NotAllowedError) Error() string {
 	return e.Msg
 }
 
-func New(l *logger.Logger, cg ClientGetter, dataDir string, cfg config.Config, dataSourceOptions sqlite.DataSourceOptions) (*AuditLog, error) {
+// New returns the audit log. On PostgreSQL the entries are kept in a single table, rotation only applies to SQLite.
+func New(l *logger.Logger, cg ClientGetter, dataDir string, cfg config.Config, dataSourceOptions sqlite.DataSourceOptions, dbBackend *backend.Backend) (*AuditLog, error) {
 	a := &AuditLog{
 		logger:       l,
 		clientGetter: cg,
 		config:       cfg,
 	}
 
-	if cfg.Enable {
+	if cfg.Enable && dbBackend.IsPostgres() {
+		db, err := dbBackend.Open("auditlog", "", auditlog.AssetNames(), auditlog.Asset, dataSourceOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		a.provider = newDBProvider(db)
+	} else if cfg.Enable {
 		rotation, err := newRotationProvider(
 			l,
 			cfg.RotationPeriod(),
@@ -21,7 +21,7 @@ func TestNotEnabled(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 
 	mockProvider := &mockProvider{}
-	auditLog, err := New(nil, nil, "", config.Config{Enable: false}, DataSourceOptions)
+	auditLog, err := New(nil, nil, "", config.Config{Enable: false}, DataSourceOptions, nil)
 	require.NoError(t, err)
 	auditLog.provider = mockProvider
 
@@ -131,9 +131,7 @@ func TestList(t *testing.T) {
 	}
 	db, err := sqlite.New(":memory:", auditlog.AssetNames(), auditlog.Asset, DataSourceOptions)
 	require.NoError(t, err)
-	dbProv := &SQLiteProvider{
-		db: db,
-	}
+	dbProv := newDBProvider(db)
 	auditLog := &AuditLog{
 		config: config.Config{
 			Enable: true,
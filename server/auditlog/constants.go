@@ -8,26 +8,39 @@ const (
 	ActionExecuteDone  = "execute.done"
 	ActionSuccess      = "success"
 	ActionFailed       = "failed"
+	ActionApprove      = "approve"
+	ActionReject       = "reject"
+	ActionRotate       = "rotate"
+	ActionClose        = "close"
 )
 
 const (
-	ApplicationAuthUser        = "auth.user"
-	ApplicationAuthUserMe      = "auth.user.me"
-	ApplicationAuthUserMeToken = "auth.user.me.token" //nolint:gosec
-	ApplicationAuthUserTotP    = "auth.user.totp"
-	ApplicationAuthUserGroup   = "auth.user.group"
-	ApplicationAuthAPISession  = "auth.api.session"
-	ApplicationAuthAPISessions = "auth.api.sessions"
-	ApplicationClient          = "client"
-	ApplicationClientACL       = "client.acl"
-	ApplicationClientAuth      = "client.auth"
-	ApplicationClientGroup     = "client.group"
-	ApplicationClientTunnel    = "client.tunnel"
-	ApplicationClientCommand   = "client.command"
-	ApplicationClientScript    = "client.script"
-	ApplicationLibraryCommand  = "library.command"
-	ApplicationLibraryScript   = "library.script"
-	ApplicationVault           = "vault"
-	ApplicationSchedule        = "schedule"
-	ApplicationUploads         = "uploads"
+	ApplicationAuthUser              = "auth.user"
+	ApplicationAuthUserMe            = "auth.user.me"
+	ApplicationAuthUserMeToken       = "auth.user.me.token" //nolint:gosec
+	ApplicationAuthUserTotP          = "auth.user.totp"
+	ApplicationAuthUserWebAuthn      = "auth.user.webauthn"
+	ApplicationAuthUserGroup         = "auth.user.group"
+	ApplicationAuthRole              = "auth.role"
+	ApplicationAuthAPISession        = "auth.api.session"
+	ApplicationAuthAPISessions       = "auth.api.sessions"
+	ApplicationClient                = "client"
+	ApplicationClientACL             = "client.acl"
+	ApplicationClientAuth            = "client.auth"
+	ApplicationClientCert            = "client.cert"
+	ApplicationClientEnrollment      = "client.enrollment"
+	ApplicationClientEnrollmentToken = "client.enrollment.token"
+	ApplicationClientGroup           = "client.group"
+	ApplicationClientTunnel          = "client.tunnel"
+	ApplicationClientCommand         = "client.command"
+	ApplicationClientScript          = "client.script"
+	ApplicationClientTerminal        = "client.terminal"
+	ApplicationClientFS              = "client.fs"
+	ApplicationLibraryCommand        = "library.command"
+	ApplicationLibraryScript         = "library.script"
+	ApplicationVault                 = "vault"
+	ApplicationSchedule              = "schedule"
+	ApplicationUploads               = "uploads"
+	ApplicationDownloads             = "downloads"
+	ApplicationApproval              = "approval"
 )
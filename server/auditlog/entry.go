@@ -14,6 +14,7 @@ import (
 type Entry struct {
 	Timestamp      time.Time `db:"timestamp" json:"timestamp"`
 	Username       string    `db:"username" json:"username"`
+	APIToken       string    `db:"api_token" json:"api_token"`
 	RemoteIP       string    `db:"remote_ip" json:"remote_ip"`
 	Application    string    `db:"application" json:"application"`
 	Action         string    `db:"action" json:"action"`
@@ -41,11 +42,22 @@ func (e *Entry) WithHTTPRequest(req *http.Request) *Entry {
 	}
 
 	e.Username = api.GetUser(req.Context(), e.al.logger)
+	e.APIToken = api.GetAPIToken(req.Context())
 	e.RemoteIP = chshare.RemoteIP(req)
 
 	return e
 }
 
+// WithRemoteIP sets the remote ip for entries not created by an API request
+func (e *Entry) WithRemoteIP(ip string) *Entry {
+	if e == nil {
+		return e
+	}
+
+	e.RemoteIP = ip
+	return e
+}
+
 func (e *Entry) WithRequest(request interface{}) *Entry {
 	if e == nil {
 		return e
@@ -73,9 +73,7 @@ func TestRotation(t *testing.T) {
 func assertRotatedSqlite(t *testing.T, dir, expectedUsername string) {
 	db, err := sqlite.New(path.Join(dir, time.Now().Format(rotatedFilename)), auditlog.AssetNames(), auditlog.Asset, dso)
 	require.NoError(t, err)
-	sqlite := &SQLiteProvider{
-		db: db,
-	}
+	sqlite := newDBProvider(db)
 	defer sqlite.Close()
 
 	entries, err := sqlite.List(context.Background(), &query.ListOptions{})
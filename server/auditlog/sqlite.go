@@ -27,10 +27,14 @@ func newSQLiteProvider(dataDir string, dataSourceOptions sqlite.DataSourceOption
 	if err != nil {
 		return nil, err
 	}
+	return newDBProvider(db), nil
+}
+
+func newDBProvider(db *sqlx.DB) *SQLiteProvider {
 	return &SQLiteProvider{
 		db:        db,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SQLiteProvider) Save(e *Entry) error {
@@ -38,6 +42,7 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 		`INSERT INTO auditlog (
 			timestamp,
 			username,
+			api_token,
 			remote_ip,
 			application,
 			action,
@@ -49,6 +54,7 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 		) VALUES (
 			:timestamp,
 			:username,
+			:api_token,
 			:remote_ip,
 			:application,
 			:action,
@@ -71,7 +77,7 @@ func (p *SQLiteProvider) List(ctx context.Context, options *query.ListOptions) (
 
 	q, params := p.converter.ConvertListOptionsToQuery(options, q)
 
-	err := p.db.SelectContext(ctx, &values, q, params...)
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
 	if err != nil {
 		return values, err
 	}
@@ -87,7 +93,7 @@ func (p *SQLiteProvider) Count(ctx context.Context, options *query.ListOptions)
 	countOptions.Pagination = nil
 	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, q)
 
-	err := p.db.GetContext(ctx, &result, q, params...)
+	err := p.db.GetContext(ctx, &result, p.converter.Rebind(q), params...)
 	if err != nil {
 		return 0, err
 	}
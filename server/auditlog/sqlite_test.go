@@ -14,14 +14,13 @@ import (
 func TestSqliteSave(t *testing.T) {
 	db, err := sqlite.New(":memory:", auditlog.AssetNames(), auditlog.Asset, DataSourceOptions)
 	require.NoError(t, err)
-	dbProv := SQLiteProvider{
-		db: db,
-	}
+	dbProv := newDBProvider(db)
 	defer dbProv.Close()
 
 	e := &Entry{
 		Timestamp:      time.Date(2021, 10, 19, 13, 57, 58, 0, time.UTC),
 		Username:       "admin",
+		APIToken:       "a1b2c3d4",
 		RemoteIP:       "192.168.55.23",
 		Application:    ApplicationLibraryCommand,
 		Action:         ActionCreate,
@@ -38,6 +37,7 @@ func TestSqliteSave(t *testing.T) {
 		{
 			"timestamp":       e.Timestamp,
 			"username":        e.Username,
+			"api_token":       e.APIToken,
 			"remote_ip":       e.RemoteIP,
 			"application":     e.Application,
 			"action":          e.Action,
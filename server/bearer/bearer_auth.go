@@ -60,6 +60,28 @@ var Scopes2FaCheckOnly = []Scope{
 	},
 }
 
+var ScopesWebAuthnCheckOnly = []Scope{
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnLoginOptionsRoute,
+		Method: http.MethodPost,
+	},
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnLoginRoute,
+		Method: http.MethodPost,
+	},
+}
+
+var ScopesWebAuthnRegisterOnly = []Scope{
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnRegistrationRoute,
+		Method: http.MethodPost,
+	},
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnCredentialsRoute,
+		Method: http.MethodPost,
+	},
+}
+
 type TokenContext struct {
 	AppClaims *AppTokenClaims
 	RawToken  string
@@ -139,12 +161,19 @@ func currentURIMatchesTokenScopes(currentURI, currentMethod string, tokenScopes
 	if len(tokenScopes) == 0 {
 		return true
 	}
+	return MatchesScopes(currentURI, currentMethod, tokenScopes)
+}
+
+// MatchesScopes returns true if at least one scope includes the uri and method and no scope excludes them.
+// A scope URI ending with "/*" matches all URIs starting with it.
+func MatchesScopes(currentURI, currentMethod string, tokenScopes []Scope) bool {
 	currentURI = "/" + strings.Trim(currentURI, "/")
 
 	hasAtLeastOneMatch := false
 	hasExcludeMatch := false
 	for _, tokenScope := range tokenScopes {
-		uriMatched := tokenScope.URI == "*" || currentURI == tokenScope.URI
+		uriMatched := tokenScope.URI == "*" || currentURI == tokenScope.URI ||
+			strings.HasSuffix(tokenScope.URI, "/*") && strings.HasPrefix(currentURI, strings.TrimSuffix(tokenScope.URI, "*"))
 		methodMatched := tokenScope.Method == "*" || currentMethod == tokenScope.Method
 
 		if uriMatched && methodMatched {
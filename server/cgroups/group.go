@@ -24,6 +24,9 @@ var OptionsSupportedFields = map[string]map[string]bool{
 		"description":           true,
 		"params":                true,
 		"allowed_user_groups":   true,
+		"require_approval":      true,
+		"bandwidth_limit_up":    true,
+		"bandwidth_limit_down":  true,
 		"client_ids":            true,
 		"num_clients":           true,
 		"num_clients_connected": true,
@@ -46,6 +49,13 @@ type ClientGroup struct {
 	Description       string            `json:"description" db:"description"`
 	Params            *ClientParams     `json:"params" db:"params"`
 	AllowedUserGroups types.StringSlice `json:"allowed_user_groups" db:"allowed_user_groups"`
+	// RequireApproval enables the four-eyes principle, commands, scripts and schedules that target clients of the group
+	// must be approved by a second user before they are executed.
+	RequireApproval bool `json:"require_approval" db:"require_approval"`
+	// BandwidthLimitUp and BandwidthLimitDown limit the bytes per second of all tunnels of a client together, the traffic
+	// sent to and received from the client. 0 is unlimited, the lowest limit applies to clients in several groups.
+	BandwidthLimitUp   int64 `json:"bandwidth_limit_up" db:"bandwidth_limit_up"`
+	BandwidthLimitDown int64 `json:"bandwidth_limit_down" db:"bandwidth_limit_down"`
 	// ClientIDs shows what clients belong to a given group. Note: it's populated separately.
 	ClientIDs []string `json:"client_ids" db:"-"`
 }
@@ -36,7 +36,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context) ([]*ClientGroup, error) {
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		"SELECT * FROM client_groups ORDER BY id COLLATE NOCASE",
+		"SELECT * FROM client_groups ORDER BY LOWER(id)",
 	)
 	if err != nil {
 		return nil, err
@@ -52,7 +52,7 @@ func (p *SqliteProvider) List(ctx context.Context, options *query.ListOptions) (
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		q,
+		p.converter.Rebind(q),
 		params...,
 	)
 	if err != nil {
@@ -63,7 +63,7 @@ func (p *SqliteProvider) List(ctx context.Context, options *query.ListOptions) (
 
 func (p *SqliteProvider) Get(ctx context.Context, id string) (*ClientGroup, error) {
 	res := &ClientGroup{}
-	err := p.db.GetContext(ctx, res, "SELECT * FROM client_groups WHERE id = ?", id)
+	err := p.db.GetContext(ctx, res, p.converter.Rebind("SELECT * FROM client_groups WHERE id = ?"), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -76,7 +76,7 @@ func (p *SqliteProvider) Get(ctx context.Context, id string) (*ClientGroup, erro
 func (p *SqliteProvider) Create(ctx context.Context, group *ClientGroup) error {
 	_, err := p.db.NamedExecContext(
 		ctx,
-		"INSERT INTO client_groups (id, description, params, allowed_user_groups) VALUES (:id, :description, :params, :allowed_user_groups)",
+		"INSERT INTO client_groups (id, description, params, allowed_user_groups, require_approval, bandwidth_limit_up, bandwidth_limit_down) VALUES (:id, :description, :params, :allowed_user_groups, :require_approval, :bandwidth_limit_up, :bandwidth_limit_down)",
 		group,
 	)
 	return err
@@ -85,14 +85,21 @@ func (p *SqliteProvider) Create(ctx context.Context, group *ClientGroup) error {
 func (p *SqliteProvider) Update(ctx context.Context, group *ClientGroup) error {
 	_, err := p.db.NamedExecContext(
 		ctx,
-		"INSERT OR REPLACE INTO client_groups (id, description, params, allowed_user_groups) VALUES (:id, :description, :params, :allowed_user_groups)",
+		`INSERT INTO client_groups (id, description, params, allowed_user_groups, require_approval, bandwidth_limit_up, bandwidth_limit_down) VALUES (:id, :description, :params, :allowed_user_groups, :require_approval, :bandwidth_limit_up, :bandwidth_limit_down)
+		ON CONFLICT (id) DO UPDATE SET
+			description = EXCLUDED.description,
+			params = EXCLUDED.params,
+			allowed_user_groups = EXCLUDED.allowed_user_groups,
+			require_approval = EXCLUDED.require_approval,
+			bandwidth_limit_up = EXCLUDED.bandwidth_limit_up,
+			bandwidth_limit_down = EXCLUDED.bandwidth_limit_down`,
 		group,
 	)
 	return err
 }
 
 func (p *SqliteProvider) Delete(ctx context.Context, id string) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM client_groups WHERE id = ?", id)
+	_, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM client_groups WHERE id = ?"), id)
 	return err
 }
 
@@ -37,6 +37,7 @@ import (
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
 	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/server/vault/keyprovider"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/email"
 	"github.com/openrport/openrport/share/logger"
@@ -71,6 +72,8 @@ type APIConfig struct {
 	MaxRequestBytes        int64    `mapstructure:"max_request_bytes"`
 	MaxFilePushSize        int64    `mapstructure:"max_filepush_size"`
 	CORS                   []string `mapstructure:"cors"`
+	TrustedProxies         []string `mapstructure:"trusted_proxies"`
+	TrustedProxyNets       []*net.IPNet
 
 	TwoFATokenDelivery       string                 `mapstructure:"two_fa_token_delivery"`
 	TwoFATokenTTLSeconds     int                    `mapstructure:"two_fa_token_ttl_seconds"`
@@ -83,6 +86,151 @@ type APIConfig struct {
 	TotPEnabled             bool            `mapstructure:"totp_enabled"`
 	TotPLoginSessionTimeout time.Duration   `mapstructure:"totp_login_session_ttl"`
 	TotPAccountName         string          `mapstructure:"totp_account_name"`
+	LDAP                    LDAPConfig      `mapstructure:",squash"`
+	WebAuthn                WebAuthnConfig  `mapstructure:",squash"`
+	SCIM                    SCIMConfig      `mapstructure:",squash"`
+}
+
+// LDAPConfig configures the authentication of API users against an LDAP directory or Active Directory.
+type LDAPConfig struct {
+	URL                string            `mapstructure:"ldap_url"`
+	StartTLS           bool              `mapstructure:"ldap_start_tls"`
+	InsecureSkipVerify bool              `mapstructure:"ldap_insecure_skip_verify"`
+	CAFile             string            `mapstructure:"ldap_ca_file"`
+	BindDN             string            `mapstructure:"ldap_bind_dn"`
+	BindPassword       string            `mapstructure:"ldap_bind_password"`
+	UserBaseDN         string            `mapstructure:"ldap_user_base_dn"`
+	UserFilter         string            `mapstructure:"ldap_user_filter"`
+	UsernameAttribute  string            `mapstructure:"ldap_username_attribute"`
+	EmailAttribute     string            `mapstructure:"ldap_email_attribute"`
+	GroupAttribute     string            `mapstructure:"ldap_group_attribute"`
+	GroupBaseDN        string            `mapstructure:"ldap_group_base_dn"`
+	GroupFilter        string            `mapstructure:"ldap_group_filter"`
+	GroupMapping       map[string]string `mapstructure:"ldap_group_mapping"`
+	CacheTTL           time.Duration     `mapstructure:"ldap_cache_ttl"`
+}
+
+const (
+	DefaultLDAPUserFilter        = "(uid=%s)"
+	DefaultLDAPUsernameAttribute = "uid"
+	DefaultLDAPEmailAttribute    = "mail"
+	DefaultLDAPGroupAttribute    = "memberOf"
+	DefaultLDAPCacheTTL          = 5 * time.Minute
+)
+
+func (l *LDAPConfig) IsEnabled() bool {
+	return l.URL != ""
+}
+
+func (l *LDAPConfig) parseAndValidate() error {
+	if !l.IsEnabled() {
+		return nil
+	}
+
+	u, err := url.Parse(l.URL)
+	if err != nil {
+		return fmt.Errorf("invalid 'ldap_url': %v", err)
+	}
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return fmt.Errorf("invalid 'ldap_url' scheme %q: expected 'ldap' or 'ldaps'", u.Scheme)
+	}
+	if l.StartTLS && u.Scheme == "ldaps" {
+		return errors.New("'ldap_start_tls' cannot be used with an 'ldaps' url")
+	}
+	if l.CAFile != "" {
+		if _, err := os.Stat(l.CAFile); err != nil {
+			return fmt.Errorf("invalid 'ldap_ca_file': %v", err)
+		}
+	}
+	if l.UserBaseDN == "" {
+		return errors.New("'ldap_user_base_dn' must be set when 'ldap_url' is set")
+	}
+	if l.BindDN != "" && l.BindPassword == "" {
+		return errors.New("'ldap_bind_password' must be set when 'ldap_bind_dn' is set")
+	}
+
+	if l.UserFilter == "" {
+		l.UserFilter = DefaultLDAPUserFilter
+	}
+	if strings.Count(l.UserFilter, "%s") != 1 {
+		return fmt.Errorf("invalid 'ldap_user_filter' %q: expected exactly one '%%s' for the username", l.UserFilter)
+	}
+	if l.UsernameAttribute == "" {
+		l.UsernameAttribute = DefaultLDAPUsernameAttribute
+	}
+	if l.EmailAttribute == "" {
+		l.EmailAttribute = DefaultLDAPEmailAttribute
+	}
+	if l.GroupBaseDN != "" {
+		if strings.Count(l.GroupFilter, "%s") != 1 {
+			return fmt.Errorf("invalid 'ldap_group_filter' %q: expected exactly one '%%s' for the user DN when 'ldap_group_base_dn' is set", l.GroupFilter)
+		}
+	} else if l.GroupAttribute == "" {
+		l.GroupAttribute = DefaultLDAPGroupAttribute
+	}
+	if l.CacheTTL == 0 {
+		l.CacheTTL = DefaultLDAPCacheTTL
+	}
+	if l.CacheTTL < 0 {
+		return fmt.Errorf("invalid 'ldap_cache_ttl': %s", l.CacheTTL)
+	}
+
+	return nil
+}
+
+// WebAuthnConfig configures security keys and passkeys for the login of API users.
+type WebAuthnConfig struct {
+	Enabled           bool     `mapstructure:"webauthn_enabled"`
+	RPID              string   `mapstructure:"webauthn_rp_id"`
+	RPDisplayName     string   `mapstructure:"webauthn_rp_display_name"`
+	RPOrigins         []string `mapstructure:"webauthn_rp_origins"`
+	RequiredForAdmins bool     `mapstructure:"webauthn_required_for_admins"`
+}
+
+const DefaultWebAuthnRPDisplayName = "RPort"
+
+func (w *WebAuthnConfig) parseAndValidate(baseURL string) error {
+	if !w.Enabled {
+		if w.RequiredForAdmins {
+			return errors.New("'webauthn_required_for_admins' requires 'webauthn_enabled'")
+		}
+		return nil
+	}
+
+	if w.RPID == "" || len(w.RPOrigins) == 0 {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" {
+			return errors.New("'base_url' or 'webauthn_rp_id' and 'webauthn_rp_origins' must be set when 'webauthn_enabled' is set")
+		}
+		if w.RPID == "" {
+			w.RPID = u.Hostname()
+		}
+		if len(w.RPOrigins) == 0 {
+			w.RPOrigins = []string{u.Scheme + "://" + u.Host}
+		}
+	}
+	for _, origin := range w.RPOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid 'webauthn_rp_origins' value %q: expected an origin like 'https://rport.example.com'", origin)
+		}
+	}
+	if w.RPDisplayName == "" {
+		w.RPDisplayName = DefaultWebAuthnRPDisplayName
+	}
+
+	return nil
+}
+
+// SCIMConfig configures the SCIM 2.0 endpoint used by identity providers to provision API users.
+type SCIMConfig struct {
+	Token string `mapstructure:"scim_token"`
+}
+
+const MinSCIMTokenLength = 32
+
+func (s SCIMConfig) IsEnabled() bool {
+	return s.Token != ""
 }
 
 func (c *APIConfig) IsTwoFAOn() bool {
@@ -113,6 +261,12 @@ const (
 	DefaultVaultDBName             = "vault.sqlite.db"
 	NotificationLogStorageDuration = "7d"
 	NotificationLogCleanupInterval = "1d"
+	RecordingsStorageDuration      = "30d"
+	RecordingsCleanupInterval      = "1h"
+	DefaultRecordingsDirName       = "recordings"
+	JobOutputStorageDuration       = "30d"
+	DefaultJobOutputDirName        = "job-output"
+	DefaultDownloadsDirName        = "downloads"
 
 	socketPrefix = "socket:"
 )
@@ -150,6 +304,11 @@ type ServerConfig struct {
 	AuthWrite                            bool                                   `mapstructure:"auth_write"`
 	AuthMultiuseCreds                    bool                                   `mapstructure:"auth_multiuse_creds"`
 	EquateClientauthidClientid           bool                                   `mapstructure:"equate_clientauthid_clientid"`
+	ClientCertCAKey                      string                                 `mapstructure:"client_cert_ca_key"`
+	ClientCertTrustedCAs                 []string                               `mapstructure:"client_cert_trusted_cas"`
+	ClientCertValidity                   time.Duration                          `mapstructure:"client_cert_validity"`
+	ClientCertRevocationList             string                                 `mapstructure:"client_cert_revocation_list"`
+	ClientAuthRotationGracePeriod        time.Duration                          `mapstructure:"client_auth_rotation_grace_period"`
 	AllowRoot                            bool                                   `mapstructure:"allow_root"`
 	ClientLoginWait                      float32                                `mapstructure:"client_login_wait"`
 	MaxFailedLogin                       int                                    `mapstructure:"max_failed_login"`
@@ -157,6 +316,7 @@ type ServerConfig struct {
 	InternalTunnelProxyConfig            clienttunnel.InternalTunnelProxyConfig `mapstructure:",squash"`
 	JobsMaxResults                       int                                    `mapstructure:"jobs_max_results"`
 	AcmeHTTPPort                         int                                    `mapstructure:"acme_http_port"`
+	ReverseTunnelAllowed                 []string                               `mapstructure:"reverse_tunnel_allowed"`
 
 	// DEPRECATED, only here for backwards compatibility
 	MaxRequestBytes       int64 `mapstructure:"max_request_bytes"`
@@ -174,6 +334,8 @@ type DatabaseConfig struct {
 	User     string `mapstructure:"db_user"`
 	Password string `mapstructure:"db_password"`
 	Name     string `mapstructure:"db_name"`
+	// SSLMode is only used for postgres
+	SSLMode string `mapstructure:"db_ssl_mode"`
 
 	Driver string
 	Dsn    string
@@ -327,6 +489,83 @@ func (n *NotificationsConfig) parseAndValidateCleanup() error {
 	return nil
 }
 
+type RecordingsConfig struct {
+	Enabled               bool   `mapstructure:"enabled"`
+	RecordAll             bool   `mapstructure:"record_all"`
+	Dir                   string `mapstructure:"dir"`
+	MaxRecordingSize      int64  `mapstructure:"max_recording_size"`
+	StorageDurationString string `mapstructure:"storage_duration"`
+	StorageDuration       time.Duration
+	CleanupIntervalString string `mapstructure:"cleanup_interval"`
+	CleanupInterval       time.Duration
+}
+
+func (r *RecordingsConfig) parseAndValidate(dataDir string) error {
+	if !r.Enabled {
+		if r.RecordAll {
+			return errors.New("'record_all' requires recordings to be enabled")
+		}
+		return nil
+	}
+
+	if r.Dir == "" {
+		r.Dir = path.Join(dataDir, DefaultRecordingsDirName)
+	}
+
+	if r.MaxRecordingSize < 0 {
+		return fmt.Errorf("'max_recording_size' cannot be negative: %d", r.MaxRecordingSize)
+	}
+
+	if r.StorageDurationString == "" {
+		r.StorageDurationString = RecordingsStorageDuration
+	}
+	storageDuration, err := str2duration.ParseDuration(r.StorageDurationString)
+	if err != nil {
+		return fmt.Errorf("invalid 'storage_duration': %v", err)
+	}
+	r.StorageDuration = storageDuration
+
+	if r.CleanupIntervalString == "" {
+		r.CleanupIntervalString = RecordingsCleanupInterval
+	}
+	cleanupInterval, err := str2duration.ParseDuration(r.CleanupIntervalString)
+	if err != nil {
+		return fmt.Errorf("invalid 'cleanup_interval': %v", err)
+	}
+	r.CleanupInterval = cleanupInterval
+
+	return nil
+}
+
+// JobOutputConfig configures where the full output of jobs started with "stream_to_file" is stored.
+type JobOutputConfig struct {
+	Dir                   string `mapstructure:"dir"`
+	MaxSize               int64  `mapstructure:"max_size"`
+	StorageDurationString string `mapstructure:"storage_duration"`
+	StorageDuration       time.Duration
+}
+
+func (j *JobOutputConfig) parseAndValidate(dataDir string) error {
+	if j.Dir == "" {
+		j.Dir = path.Join(dataDir, DefaultJobOutputDirName)
+	}
+
+	if j.MaxSize < 0 {
+		return fmt.Errorf("'max_size' cannot be negative: %d", j.MaxSize)
+	}
+
+	if j.StorageDurationString == "" {
+		j.StorageDurationString = JobOutputStorageDuration
+	}
+	storageDuration, err := str2duration.ParseDuration(j.StorageDurationString)
+	if err != nil {
+		return fmt.Errorf("invalid 'storage_duration': %v", err)
+	}
+	j.StorageDuration = storageDuration
+
+	return nil
+}
+
 type Config struct {
 	Server        ServerConfig         `mapstructure:"server"`
 	Caddy         caddy.Config         `mapstructure:"caddy-integration"`
@@ -337,6 +576,12 @@ type Config struct {
 	SMTP          SMTPConfig           `mapstructure:"smtp"`
 	Monitoring    MonitoringConfig     `mapstructure:"monitoring"`
 	Notifications NotificationsConfig  `mapstructure:"notifications"`
+	Recordings    RecordingsConfig     `mapstructure:"recordings"`
+	Metrics       MetricsConfig        `mapstructure:"metrics"`
+	HA            HAConfig             `mapstructure:"ha"`
+	Approvals     ApprovalsConfig      `mapstructure:"approvals"`
+	Vault         keyprovider.Config   `mapstructure:"vault"`
+	JobOutput     JobOutputConfig      `mapstructure:"job_output"`
 	PlusConfig    rportplus.PlusConfig `mapstructure:",squash"`
 }
 
@@ -352,6 +597,10 @@ func (c *Config) GetUploadDir() string {
 	return filepath.Join(c.Server.DataDir, files.DefaultUploadTempFolder)
 }
 
+func (c *Config) GetDownloadDir() string {
+	return filepath.Join(c.Server.DataDir, DefaultDownloadsDirName)
+}
+
 func (s *ServerConfig) GetSQLiteDataSourceOptions() sqlite.DataSourceOptions {
 	return sqlite.DataSourceOptions{WALEnabled: s.SqliteWAL}
 }
@@ -369,6 +618,122 @@ func (c *Config) AllowedPorts() mapset.Set {
 	return c.Server.allowedPorts
 }
 
+type MetricsConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Address  string `mapstructure:"address"`
+	Auth     string `mapstructure:"auth"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+func (m *MetricsConfig) parseAndValidate() error {
+	if !m.Enabled {
+		return nil
+	}
+
+	if m.Address == "" {
+		return errors.New("'address' is required")
+	}
+
+	if m.Auth != "" {
+		user, pass, found := strings.Cut(m.Auth, ":")
+		if !found || user == "" || pass == "" {
+			return errors.New("'auth' must be given as <user>:<password>")
+		}
+	}
+
+	if (m.CertFile == "") != (m.KeyFile == "") {
+		return errors.New("'cert_file' and 'key_file' must be set together")
+	}
+
+	return nil
+}
+
+// GetAuth returns the user and password required to access the metrics, both are empty if no auth is configured
+func (m *MetricsConfig) GetAuth() (user, pass string) {
+	user, pass, _ = strings.Cut(m.Auth, ":")
+	return user, pass
+}
+
+const DefaultHALeaseDuration = 15 * time.Second
+
+type HAConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	NodeID        string        `mapstructure:"node_id"`
+	APIURL        string        `mapstructure:"api_url"`
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+}
+
+func (h *HAConfig) parseAndValidate(db *DatabaseConfig, api *APIConfig) error {
+	if !h.Enabled {
+		return nil
+	}
+
+	if !db.IsPostgres() {
+		return errors.New("requires 'db_type' = 'postgres' to share the state between the nodes")
+	}
+
+	// the nodes accept the tokens issued by each other and authenticate each other with it
+	if api.JWTSecret == "" {
+		return errors.New("requires 'jwt_secret' in [api], the same on all nodes")
+	}
+
+	if h.NodeID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("'node_id' is not set and the hostname can't be used: %v", err)
+		}
+		h.NodeID = hostname
+	}
+
+	if h.APIURL == "" {
+		return errors.New("'api_url' is required")
+	}
+	u, err := url.Parse(h.APIURL)
+	if err != nil {
+		return fmt.Errorf("invalid 'api_url': %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid 'api_url' %q: expected an absolute http or https url", h.APIURL)
+	}
+	h.APIURL = strings.TrimSuffix(h.APIURL, "/")
+
+	if h.LeaseDuration == 0 {
+		h.LeaseDuration = DefaultHALeaseDuration
+	}
+	if h.LeaseDuration < 3*time.Second {
+		return errors.New("'lease_duration' must be at least 3s")
+	}
+
+	return nil
+}
+
+// ApprovalsConfig configures who is notified about commands, scripts and schedules waiting for approval.
+type ApprovalsConfig struct {
+	// NotificationTarget is either "smtp" or the name of a script in the notification script dir
+	NotificationTarget     string   `mapstructure:"notification_target"`
+	NotificationRecipients []string `mapstructure:"notification_recipients"`
+}
+
+func (a *ApprovalsConfig) parseAndValidate(smtp *SMTPConfig) error {
+	if a.NotificationTarget == "" {
+		if len(a.NotificationRecipients) > 0 {
+			return errors.New("'notification_target' is required when 'notification_recipients' are set")
+		}
+		return nil
+	}
+
+	if len(a.NotificationRecipients) == 0 {
+		return errors.New("'notification_recipients' are required when 'notification_target' is set")
+	}
+
+	if a.NotificationTarget == "smtp" && smtp.Server == "" {
+		return errors.New("'notification_target' = \"smtp\" requires the [smtp] section to be configured")
+	}
+
+	return nil
+}
+
 func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 	rpl, err := ConfigReplaceDeprecated(&c.Server)
 	for old, new := range rpl {
@@ -399,6 +764,10 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	if err := c.Server.parseAndValidateReverseTunnelAllowed(); err != nil {
+		return err
+	}
+
 	if err := c.Server.InternalTunnelProxyConfig.ParseAndValidate(); err != nil {
 		return err
 	}
@@ -425,6 +794,11 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	// before the API, which generates a jwt_secret if it's not set
+	if err := c.HA.parseAndValidate(&c.Database, &c.API); err != nil {
+		return fmt.Errorf("ha: %v", err)
+	}
+
 	if err := c.parseAndValidateAPI(mLog); err != nil {
 		return fmt.Errorf("API: %v", err)
 	}
@@ -454,6 +828,26 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	if err := c.Recordings.parseAndValidate(c.Server.DataDir); err != nil {
+		return fmt.Errorf("recordings: %v", err)
+	}
+
+	if err := c.Metrics.parseAndValidate(); err != nil {
+		return fmt.Errorf("metrics: %v", err)
+	}
+
+	if err := c.Approvals.parseAndValidate(&c.SMTP); err != nil {
+		return fmt.Errorf("approvals: %v", err)
+	}
+
+	if err := c.JobOutput.parseAndValidate(c.Server.DataDir); err != nil {
+		return fmt.Errorf("job_output: %v", err)
+	}
+
+	if err := c.Vault.ParseAndValidate(); err != nil {
+		return fmt.Errorf("vault: %v", err)
+	}
+
 	return nil
 }
 
@@ -483,6 +877,16 @@ func (c *Config) parseAndValidateClientAuth() error {
 		}
 	}
 
+	if c.Server.ClientCertCAKey != "" && c.Server.ClientCertValidity <= 0 {
+		return fmt.Errorf("'client_cert_validity' must be positive, got %v", c.Server.ClientCertValidity)
+	}
+	if c.Server.ClientCertRevocationList != "" && c.Server.ClientCertCAKey == "" && len(c.Server.ClientCertTrustedCAs) == 0 {
+		return errors.New("'client_cert_revocation_list' requires 'client_cert_ca_key' or 'client_cert_trusted_cas' to be set")
+	}
+	if c.Server.ClientAuthRotationGracePeriod < 0 {
+		return fmt.Errorf("'client_auth_rotation_grace_period' must not be negative, got %v", c.Server.ClientAuthRotationGracePeriod)
+	}
+
 	return nil
 }
 
@@ -517,6 +921,16 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 			return err
 		}
 
+		err = c.parseAndValidateWebAuthn()
+		if err != nil {
+			return err
+		}
+
+		err = c.parseAndValidateSCIM()
+		if err != nil {
+			return err
+		}
+
 		if c.API.TLSMin != "" && c.API.TLSMin != "1.2" && c.API.TLSMin != "1.3" {
 			return errors.New("TLS must be either 1.2 or 1.3")
 		}
@@ -527,6 +941,11 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 
 		c.API.CORS = parseAndValidateCORS(mLog, c.API.CORS)
 
+		c.API.TrustedProxyNets, err = parseTrustedProxies(c.API.TrustedProxies)
+		if err != nil {
+			return err
+		}
+
 	} else {
 		// API disabled
 		if c.API.DocRoot != "" {
@@ -692,6 +1111,37 @@ func (c *Config) parseAndValidateTotPSecret() error {
 		return errors.New("conflicting 2FA configuration, two factor auth and totp_enabled options cannot be both enabled")
 	}
 
+	if c.API.TotPEnabled && c.API.LDAP.IsEnabled() {
+		return errors.New("totp_enabled is not available with 'ldap_url', the TotP secrets of LDAP users cannot be stored")
+	}
+
+	return nil
+}
+
+func (c *Config) parseAndValidateWebAuthn() error {
+	if c.API.WebAuthn.Enabled {
+		if c.API.Auth != "" {
+			return errors.New("webauthn is not available if you use a single static user-password pair")
+		}
+		if c.API.LDAP.IsEnabled() {
+			return errors.New("webauthn is not available with 'ldap_url', the credentials of LDAP users cannot be stored")
+		}
+	}
+
+	return c.API.WebAuthn.parseAndValidate(c.API.BaseURL)
+}
+
+func (c *Config) parseAndValidateSCIM() error {
+	if !c.API.SCIM.IsEnabled() {
+		return nil
+	}
+	if c.API.AuthFile == "" && c.API.AuthUserTable == "" {
+		return errors.New("'scim_token' requires users stored in 'auth_file' or 'auth_user_table'")
+	}
+	if len(c.API.SCIM.Token) < MinSCIMTokenLength {
+		return fmt.Errorf("'scim_token' must be at least %d characters long", MinSCIMTokenLength)
+	}
+
 	return nil
 }
 
@@ -728,8 +1178,20 @@ func (c *Config) parseAndValidate2FA() error {
 }
 
 func (c *Config) parseAndValidateAPIAuth() error {
-	if c.API.AuthFile == "" && c.API.Auth == "" && c.API.AuthUserTable == "" {
-		return errors.New("authentication must be enabled: set either 'auth', 'auth_file' or 'auth_user_table'")
+	if c.API.AuthFile == "" && c.API.Auth == "" && c.API.AuthUserTable == "" && !c.API.LDAP.IsEnabled() {
+		return errors.New("authentication must be enabled: set either 'auth', 'auth_file', 'auth_user_table' or 'ldap_url'")
+	}
+
+	if c.API.LDAP.IsEnabled() {
+		if c.API.Auth != "" || c.API.AuthFile != "" || c.API.AuthUserTable != "" {
+			return errors.New("'ldap_url' cannot be used together with 'auth', 'auth_file' or 'auth_user_table'")
+		}
+		if c.API.AuthGroupDetailsTable != "" && c.Database.Type == "" {
+			return errors.New("'db_type' must be set when 'auth_group_details_table' is set")
+		}
+		if err := c.API.LDAP.parseAndValidate(); err != nil {
+			return err
+		}
 	}
 
 	if c.API.AuthFile != "" && c.API.Auth != "" {
@@ -806,6 +1268,37 @@ func (s *ServerConfig) parseAndValidatePorts() error {
 	return nil
 }
 
+func (s *ServerConfig) parseAndValidateReverseTunnelAllowed() error {
+	for _, allowed := range s.ReverseTunnelAllowed {
+		host, port, err := net.SplitHostPort(allowed)
+		if err != nil || host == "" {
+			return fmt.Errorf("invalid 'reverse_tunnel_allowed' value %q: expected host:port", allowed)
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("invalid 'reverse_tunnel_allowed' value %q: invalid port", allowed)
+		}
+	}
+	return nil
+}
+
+// IsReverseTunnelAllowed returns true if reverse tunnels may forward connections to the server-side destination host:port
+func (s *ServerConfig) IsReverseTunnelAllowed(destination string) bool {
+	host, port, err := net.SplitHostPort(destination)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.ReverseTunnelAllowed {
+		allowedHost, allowedPort, err := net.SplitHostPort(allowed)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(host, allowedHost) && port == allowedPort {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *ServerConfig) parseAndValidateURLs() error {
 	if len(s.URL) == 0 {
 		s.URL = []string{"http://" + s.ListenAddress}
@@ -868,14 +1361,54 @@ func (d *DatabaseConfig) ParseAndValidate() error {
 	case "sqlite":
 		d.Driver = "sqlite3"
 		d.Dsn = d.Name
+	case "postgres":
+		d.Driver = "postgres"
+		d.Dsn = d.postgresDsn()
 	default:
-		return fmt.Errorf("invalid 'db_type', expected 'mysql' or 'sqlite', got %q", d.Type)
+		return fmt.Errorf("invalid 'db_type', expected 'mysql', 'sqlite' or 'postgres', got %q", d.Type)
 	}
 
 	return nil
 }
 
+// IsPostgres returns true if all server stores are kept in the configured postgres database.
+func (d *DatabaseConfig) IsPostgres() bool {
+	return d.Type == "postgres"
+}
+
+// postgresDsn returns the connection string in the key=value format of lib/pq, unset values fall back to the libpq defaults.
+func (d *DatabaseConfig) postgresDsn() string {
+	var params []string
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		value = strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value)
+		params = append(params, fmt.Sprintf("%s='%s'", key, value))
+	}
+
+	if strings.HasPrefix(d.Host, socketPrefix) {
+		add("host", strings.TrimPrefix(d.Host, socketPrefix))
+	} else if host, port, err := net.SplitHostPort(d.Host); err == nil {
+		add("host", host)
+		add("port", port)
+	} else {
+		add("host", d.Host)
+	}
+	add("user", d.User)
+	add("password", d.Password)
+	add("dbname", d.Name)
+	add("sslmode", d.SSLMode)
+
+	return strings.Join(params, " ")
+}
+
 func (d *DatabaseConfig) DsnForLogs() string {
+	if d.Password != "" && d.IsPostgres() {
+		hidden := *d
+		hidden.Password = "***"
+		return hidden.postgresDsn()
+	}
 	if d.Password != "" {
 		// hide the password
 		return strings.Replace(d.Dsn, ":"+d.Password, ":***", 1)
@@ -883,6 +1416,31 @@ func (d *DatabaseConfig) DsnForLogs() string {
 	return d.Dsn
 }
 
+// parseTrustedProxies accepts single addresses and networks in CIDR notation
+func parseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		cidr := value
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid 'trusted_proxies' address %q", value)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'trusted_proxies' network %q: %v", value, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
 func generateJWTSecret() (string, error) {
 	data := make([]byte, 10)
 	if _, err := rand.Read(data); err != nil {
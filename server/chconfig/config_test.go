@@ -1,6 +1,7 @@
 package chconfig
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -127,7 +128,7 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 			Database: DatabaseConfig{
 				Type: "mongodb",
 			},
-			ExpectedError: "invalid 'db_type', expected 'mysql' or 'sqlite', got \"mongodb\"",
+			ExpectedError: "invalid 'db_type', expected 'mysql', 'sqlite' or 'postgres', got \"mongodb\"",
 		}, {
 			Name: "sqlite",
 			Database: DatabaseConfig{
@@ -172,6 +173,34 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 			},
 			ExpectedDriver: "mysql",
 			ExpectedDSN:    "user:password@tcp(127.0.0.1:3306)/testdb",
+		}, {
+			Name: "postgres defaults",
+			Database: DatabaseConfig{
+				Type: "postgres",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    "",
+		}, {
+			Name: "postgres socket",
+			Database: DatabaseConfig{
+				Type: "postgres",
+				Host: "socket:/var/run/postgresql",
+				Name: "rport",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    "host='/var/run/postgresql' dbname='rport'",
+		}, {
+			Name: "postgres host with user, password and ssl mode",
+			Database: DatabaseConfig{
+				Type:     "postgres",
+				Host:     "db.example.com:5432",
+				Name:     "rport",
+				User:     "rport",
+				Password: `it's\secret`,
+				SSLMode:  "verify-full",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    `host='db.example.com' port='5432' user='rport' password='it\'s\\secret' dbname='rport' sslmode='verify-full'`,
 		},
 	}
 
@@ -187,6 +216,16 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 	}
 }
 
+func TestDatabaseDsnForLogs(t *testing.T) {
+	mysql := DatabaseConfig{Type: "mysql", Host: "127.0.0.1:3306", Name: "rport", User: "rport", Password: "secret"}
+	require.NoError(t, mysql.ParseAndValidate())
+	assert.Equal(t, "rport:***@tcp(127.0.0.1:3306)/rport", mysql.DsnForLogs())
+
+	postgres := DatabaseConfig{Type: "postgres", Host: "127.0.0.1", Name: "rport", User: "rport", Password: "secret"}
+	require.NoError(t, postgres.ParseAndValidate())
+	assert.Equal(t, "host='127.0.0.1' user='rport' password='***' dbname='rport'", postgres.DsnForLogs())
+}
+
 func TestParseAndValidateClientAuth(t *testing.T) {
 	testCases := []struct {
 		Name                 string
@@ -268,6 +307,34 @@ func TestParseAndValidateClientAuth(t *testing.T) {
 					Type: "sqlite",
 				},
 			},
+		}, {
+			Name: "client cert without validity",
+			Config: Config{
+				Server: ServerConfig{
+					AuthFile:        "test.json",
+					ClientCertCAKey: "client-ca-key",
+				},
+			},
+			ExpectedError: "'client_cert_validity' must be positive, got 0s",
+		}, {
+			Name: "client cert revocation list without CA",
+			Config: Config{
+				Server: ServerConfig{
+					AuthFile:                 "test.json",
+					ClientCertRevocationList: "revoked",
+				},
+			},
+			ExpectedError: "'client_cert_revocation_list' requires 'client_cert_ca_key' or 'client_cert_trusted_cas' to be set",
+		}, {
+			Name: "valid client cert",
+			Config: Config{
+				Server: ServerConfig{
+					AuthFile:                 "test.json",
+					ClientCertCAKey:          "client-ca-key",
+					ClientCertValidity:       time.Hour,
+					ClientCertRevocationList: "revoked",
+				},
+			},
 		},
 	}
 
@@ -312,7 +379,7 @@ func TestParseAndValidateAPI(t *testing.T) {
 					Address: "0.0.0.0:3000",
 				},
 			},
-			ExpectedError: "API: authentication must be enabled: set either 'auth', 'auth_file' or 'auth_user_table'",
+			ExpectedError: "API: authentication must be enabled: set either 'auth', 'auth_file', 'auth_user_table' or 'ldap_url'",
 		}, {
 			Name: "api enabled, auth and auth_file",
 			Config: Config{
@@ -376,6 +443,43 @@ func TestParseAndValidateAPI(t *testing.T) {
 					Type: "sqlite",
 				},
 			},
+		}, {
+			Name: "api enabled, ldap and auth_file",
+			Config: Config{
+				API: APIConfig{
+					Address:  "0.0.0.0:3000",
+					AuthFile: "test.json",
+					LDAP: LDAPConfig{
+						URL:        "ldap://ldap.example.com",
+						UserBaseDN: "ou=users,dc=example,dc=com",
+					},
+				},
+			},
+			ExpectedError: "API: 'ldap_url' cannot be used together with 'auth', 'auth_file' or 'auth_user_table'",
+		}, {
+			Name: "api enabled, ldap with group details table without db",
+			Config: Config{
+				API: APIConfig{
+					Address:               "0.0.0.0:3000",
+					AuthGroupDetailsTable: "group_details",
+					LDAP: LDAPConfig{
+						URL:        "ldap://ldap.example.com",
+						UserBaseDN: "ou=users,dc=example,dc=com",
+					},
+				},
+			},
+			ExpectedError: "API: 'db_type' must be set when 'auth_group_details_table' is set",
+		}, {
+			Name: "api enabled, valid ldap auth",
+			Config: Config{
+				API: APIConfig{
+					Address: "0.0.0.0:3000",
+					LDAP: LDAPConfig{
+						URL:        "ldap://ldap.example.com",
+						UserBaseDN: "ou=users,dc=example,dc=com",
+					},
+				},
+			},
 		}, {
 			Name: "api enabled, valid auth",
 			Config: Config{
@@ -651,6 +755,29 @@ func TestParseAndValidateAPI(t *testing.T) {
 	}
 }
 
+func TestReverseTunnelAllowed(t *testing.T) {
+	config := ServerConfig{
+		ReverseTunnelAllowed: []string{"mirror.example.com:443", "10.0.0.5:27000", "[2001:db8::1]:8080"},
+	}
+	require.NoError(t, config.parseAndValidateReverseTunnelAllowed())
+
+	assert.True(t, config.IsReverseTunnelAllowed("mirror.example.com:443"))
+	assert.True(t, config.IsReverseTunnelAllowed("Mirror.Example.com:443"))
+	assert.True(t, config.IsReverseTunnelAllowed("10.0.0.5:27000"))
+	assert.True(t, config.IsReverseTunnelAllowed("[2001:db8::1]:8080"))
+	assert.False(t, config.IsReverseTunnelAllowed("mirror.example.com:80"))
+	assert.False(t, config.IsReverseTunnelAllowed("10.0.0.6:27000"))
+	assert.False(t, config.IsReverseTunnelAllowed("10.0.0.5"))
+	assert.False(t, (&ServerConfig{}).IsReverseTunnelAllowed("10.0.0.5:27000"))
+
+	for _, invalid := range []string{"10.0.0.5", ":443", "host:abc", "host:70000"} {
+		config := ServerConfig{
+			ReverseTunnelAllowed: []string{invalid},
+		}
+		assert.Error(t, config.parseAndValidateReverseTunnelAllowed(), invalid)
+	}
+}
+
 func TestParseAndValidatePorts(t *testing.T) {
 	testCases := []struct {
 		Name                      string
@@ -857,3 +984,548 @@ func TestParseAndValidateCORS(t *testing.T) {
 	}
 	assert.Equal(t, expected, result)
 }
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := parseTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8", "::1"})
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "127.0.0.1/32", nets[0].String())
+	assert.Equal(t, "10.0.0.0/8", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+
+	_, err = parseTrustedProxies([]string{"localhost"})
+	assert.EqualError(t, err, `invalid 'trusted_proxies' address "localhost"`)
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.EqualError(t, err, `invalid 'trusted_proxies' network "10.0.0.0/33": invalid CIDR address: 10.0.0.0/33`)
+}
+
+func TestParseAndValidateRecordings(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      RecordingsConfig
+		Expected    RecordingsConfig
+		ExpectedErr string
+	}{
+		{
+			Name:     "disabled",
+			Config:   RecordingsConfig{},
+			Expected: RecordingsConfig{},
+		},
+		{
+			Name:        "record all without enabled",
+			Config:      RecordingsConfig{RecordAll: true},
+			ExpectedErr: "'record_all' requires recordings to be enabled",
+		},
+		{
+			Name:   "defaults",
+			Config: RecordingsConfig{Enabled: true},
+			Expected: RecordingsConfig{
+				Enabled:               true,
+				Dir:                   "/data/recordings",
+				StorageDurationString: "30d",
+				StorageDuration:       30 * 24 * time.Hour,
+				CleanupIntervalString: "1h",
+				CleanupInterval:       time.Hour,
+			},
+		},
+		{
+			Name: "custom values",
+			Config: RecordingsConfig{
+				Enabled:               true,
+				RecordAll:             true,
+				Dir:                   "/var/recordings",
+				MaxRecordingSize:      1024,
+				StorageDurationString: "7d",
+				CleanupIntervalString: "10m",
+			},
+			Expected: RecordingsConfig{
+				Enabled:               true,
+				RecordAll:             true,
+				Dir:                   "/var/recordings",
+				MaxRecordingSize:      1024,
+				StorageDurationString: "7d",
+				StorageDuration:       7 * 24 * time.Hour,
+				CleanupIntervalString: "10m",
+				CleanupInterval:       10 * time.Minute,
+			},
+		},
+		{
+			Name:        "negative max size",
+			Config:      RecordingsConfig{Enabled: true, MaxRecordingSize: -1},
+			ExpectedErr: "'max_recording_size' cannot be negative: -1",
+		},
+		{
+			Name:        "invalid storage duration",
+			Config:      RecordingsConfig{Enabled: true, StorageDurationString: "abc"},
+			ExpectedErr: "invalid 'storage_duration'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate("/data")
+			if tc.ExpectedErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, tc.Config)
+		})
+	}
+}
+
+func TestParseAndValidateJobOutput(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      JobOutputConfig
+		Expected    JobOutputConfig
+		ExpectedErr string
+	}{
+		{
+			Name:   "defaults",
+			Config: JobOutputConfig{},
+			Expected: JobOutputConfig{
+				Dir:                   "/data/job-output",
+				StorageDurationString: "30d",
+				StorageDuration:       30 * 24 * time.Hour,
+			},
+		},
+		{
+			Name: "custom values",
+			Config: JobOutputConfig{
+				Dir:                   "/var/job-output",
+				MaxSize:               1024,
+				StorageDurationString: "12h",
+			},
+			Expected: JobOutputConfig{
+				Dir:                   "/var/job-output",
+				MaxSize:               1024,
+				StorageDurationString: "12h",
+				StorageDuration:       12 * time.Hour,
+			},
+		},
+		{
+			Name:        "invalid storage duration",
+			Config:      JobOutputConfig{StorageDurationString: "abc"},
+			ExpectedErr: "invalid 'storage_duration'",
+		},
+		{
+			Name:        "negative max size",
+			Config:      JobOutputConfig{MaxSize: -1},
+			ExpectedErr: "'max_size' cannot be negative: -1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate("/data")
+			if tc.ExpectedErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, tc.Config)
+		})
+	}
+}
+
+func TestParseAndValidateLDAP(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      LDAPConfig
+		Expected    LDAPConfig
+		ExpectedErr string
+	}{
+		{
+			Name:     "disabled",
+			Config:   LDAPConfig{},
+			Expected: LDAPConfig{},
+		},
+		{
+			Name: "defaults",
+			Config: LDAPConfig{
+				URL:        "ldaps://ldap.example.com",
+				UserBaseDN: "ou=users,dc=example,dc=com",
+			},
+			Expected: LDAPConfig{
+				URL:               "ldaps://ldap.example.com",
+				UserBaseDN:        "ou=users,dc=example,dc=com",
+				UserFilter:        "(uid=%s)",
+				UsernameAttribute: "uid",
+				EmailAttribute:    "mail",
+				GroupAttribute:    "memberOf",
+				CacheTTL:          5 * time.Minute,
+			},
+		},
+		{
+			Name: "group search",
+			Config: LDAPConfig{
+				URL:         "ldap://ldap.example.com",
+				StartTLS:    true,
+				UserBaseDN:  "ou=users,dc=example,dc=com",
+				UserFilter:  "(&(objectClass=user)(sAMAccountName=%s))",
+				GroupBaseDN: "ou=groups,dc=example,dc=com",
+				GroupFilter: "(member=%s)",
+				CacheTTL:    time.Minute,
+			},
+			Expected: LDAPConfig{
+				URL:               "ldap://ldap.example.com",
+				StartTLS:          true,
+				UserBaseDN:        "ou=users,dc=example,dc=com",
+				UserFilter:        "(&(objectClass=user)(sAMAccountName=%s))",
+				UsernameAttribute: "uid",
+				EmailAttribute:    "mail",
+				GroupBaseDN:       "ou=groups,dc=example,dc=com",
+				GroupFilter:       "(member=%s)",
+				CacheTTL:          time.Minute,
+			},
+		},
+		{
+			Name:        "invalid scheme",
+			Config:      LDAPConfig{URL: "http://ldap.example.com", UserBaseDN: "dc=example"},
+			ExpectedErr: `invalid 'ldap_url' scheme "http"`,
+		},
+		{
+			Name:        "start tls with ldaps",
+			Config:      LDAPConfig{URL: "ldaps://ldap.example.com", StartTLS: true, UserBaseDN: "dc=example"},
+			ExpectedErr: "'ldap_start_tls' cannot be used with an 'ldaps' url",
+		},
+		{
+			Name:        "missing user base dn",
+			Config:      LDAPConfig{URL: "ldap://ldap.example.com"},
+			ExpectedErr: "'ldap_user_base_dn' must be set",
+		},
+		{
+			Name:        "bind dn without password",
+			Config:      LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", BindDN: "cn=admin,dc=example"},
+			ExpectedErr: "'ldap_bind_password' must be set",
+		},
+		{
+			Name:        "user filter without placeholder",
+			Config:      LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", UserFilter: "(uid=admin)"},
+			ExpectedErr: "invalid 'ldap_user_filter'",
+		},
+		{
+			Name:        "group base dn without filter",
+			Config:      LDAPConfig{URL: "ldap://ldap.example.com", UserBaseDN: "dc=example", GroupBaseDN: "ou=groups,dc=example"},
+			ExpectedErr: "invalid 'ldap_group_filter'",
+		},
+		{
+			Name:        "missing ca file",
+			Config:      LDAPConfig{URL: "ldaps://ldap.example.com", UserBaseDN: "dc=example", CAFile: "/not/existing/ca.pem"},
+			ExpectedErr: "invalid 'ldap_ca_file'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate()
+			if tc.ExpectedErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, tc.Config)
+		})
+	}
+}
+
+func TestParseAndValidateMetrics(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      MetricsConfig
+		ExpectedErr string
+	}{
+		{
+			Name:   "disabled",
+			Config: MetricsConfig{},
+		},
+		{
+			Name:        "missing address",
+			Config:      MetricsConfig{Enabled: true},
+			ExpectedErr: "'address' is required",
+		},
+		{
+			Name:   "with auth and tls",
+			Config: MetricsConfig{Enabled: true, Address: "0.0.0.0:9090", Auth: "prometheus:secret", CertFile: "/tmp/cert.pem", KeyFile: "/tmp/key.pem"},
+		},
+		{
+			Name:        "invalid auth",
+			Config:      MetricsConfig{Enabled: true, Address: "127.0.0.1:9090", Auth: "prometheus"},
+			ExpectedErr: "'auth' must be given as <user>:<password>",
+		},
+		{
+			Name:        "cert without key",
+			Config:      MetricsConfig{Enabled: true, Address: "127.0.0.1:9090", CertFile: "/tmp/cert.pem"},
+			ExpectedErr: "'cert_file' and 'key_file' must be set together",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate()
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParseAndValidateHA(t *testing.T) {
+	postgres := &DatabaseConfig{Type: "postgres"}
+	api := &APIConfig{JWTSecret: "secret"}
+	testCases := []struct {
+		Name        string
+		Config      HAConfig
+		DB          *DatabaseConfig
+		API         *APIConfig
+		Expected    HAConfig
+		ExpectedErr string
+	}{
+		{
+			Name:     "disabled",
+			Config:   HAConfig{},
+			DB:       &DatabaseConfig{},
+			Expected: HAConfig{},
+		},
+		{
+			Name:        "sqlite",
+			Config:      HAConfig{Enabled: true, NodeID: "node1", APIURL: "http://10.0.0.1:3000"},
+			DB:          &DatabaseConfig{Type: "sqlite"},
+			ExpectedErr: "requires 'db_type' = 'postgres' to share the state between the nodes",
+		},
+		{
+			Name:        "missing jwt secret",
+			Config:      HAConfig{Enabled: true, NodeID: "node1", APIURL: "http://10.0.0.1:3000"},
+			DB:          postgres,
+			API:         &APIConfig{},
+			ExpectedErr: "requires 'jwt_secret' in [api], the same on all nodes",
+		},
+		{
+			Name:     "defaults",
+			Config:   HAConfig{Enabled: true, NodeID: "node1", APIURL: "https://10.0.0.1:3000/"},
+			DB:       postgres,
+			Expected: HAConfig{Enabled: true, NodeID: "node1", APIURL: "https://10.0.0.1:3000", LeaseDuration: DefaultHALeaseDuration},
+		},
+		{
+			Name:        "missing api url",
+			Config:      HAConfig{Enabled: true, NodeID: "node1"},
+			DB:          postgres,
+			ExpectedErr: "'api_url' is required",
+		},
+		{
+			Name:        "relative api url",
+			Config:      HAConfig{Enabled: true, NodeID: "node1", APIURL: "/api/v1"},
+			DB:          postgres,
+			ExpectedErr: `invalid 'api_url' "/api/v1": expected an absolute http or https url`,
+		},
+		{
+			Name:        "lease too short",
+			Config:      HAConfig{Enabled: true, NodeID: "node1", APIURL: "http://10.0.0.1:3000", LeaseDuration: time.Second},
+			DB:          postgres,
+			ExpectedErr: "'lease_duration' must be at least 3s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.API == nil {
+				tc.API = api
+			}
+			err := tc.Config.parseAndValidate(tc.DB, tc.API)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, tc.Config)
+		})
+	}
+}
+
+func TestParseAndValidateHANodeIDDefaultsToHostname(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	cfg := HAConfig{Enabled: true, APIURL: "http://10.0.0.1:3000"}
+	require.NoError(t, cfg.parseAndValidate(&DatabaseConfig{Type: "postgres"}, &APIConfig{JWTSecret: "secret"}))
+
+	assert.Equal(t, hostname, cfg.NodeID)
+}
+
+func TestParseAndValidateApprovals(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      ApprovalsConfig
+		SMTP        SMTPConfig
+		ExpectedErr string
+	}{
+		{
+			Name:   "no notifications",
+			Config: ApprovalsConfig{},
+		},
+		{
+			Name:   "script",
+			Config: ApprovalsConfig{NotificationTarget: "approvals.sh", NotificationRecipients: []string{"ops"}},
+		},
+		{
+			Name:   "smtp",
+			Config: ApprovalsConfig{NotificationTarget: "smtp", NotificationRecipients: []string{"ops@example.com"}},
+			SMTP:   SMTPConfig{Server: "smtp.example.com:587"},
+		},
+		{
+			Name:        "smtp not configured",
+			Config:      ApprovalsConfig{NotificationTarget: "smtp", NotificationRecipients: []string{"ops@example.com"}},
+			ExpectedErr: `'notification_target' = "smtp" requires the [smtp] section to be configured`,
+		},
+		{
+			Name:        "missing recipients",
+			Config:      ApprovalsConfig{NotificationTarget: "approvals.sh"},
+			ExpectedErr: "'notification_recipients' are required when 'notification_target' is set",
+		},
+		{
+			Name:        "missing target",
+			Config:      ApprovalsConfig{NotificationRecipients: []string{"ops"}},
+			ExpectedErr: "'notification_target' is required when 'notification_recipients' are set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate(&tc.SMTP)
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseAndValidateWebAuthn(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		BaseURL     string
+		Config      WebAuthnConfig
+		Expected    WebAuthnConfig
+		ExpectedErr string
+	}{
+		{
+			Name:     "disabled",
+			Config:   WebAuthnConfig{},
+			Expected: WebAuthnConfig{},
+		},
+		{
+			Name:    "defaults from base url",
+			BaseURL: "https://rport.example.com:8443/",
+			Config:  WebAuthnConfig{Enabled: true},
+			Expected: WebAuthnConfig{
+				Enabled:       true,
+				RPID:          "rport.example.com",
+				RPDisplayName: "RPort",
+				RPOrigins:     []string{"https://rport.example.com:8443"},
+			},
+		},
+		{
+			Name: "explicit relying party",
+			Config: WebAuthnConfig{
+				Enabled:           true,
+				RPID:              "example.com",
+				RPDisplayName:     "Example",
+				RPOrigins:         []string{"https://rport.example.com", "https://ui.example.com"},
+				RequiredForAdmins: true,
+			},
+			Expected: WebAuthnConfig{
+				Enabled:           true,
+				RPID:              "example.com",
+				RPDisplayName:     "Example",
+				RPOrigins:         []string{"https://rport.example.com", "https://ui.example.com"},
+				RequiredForAdmins: true,
+			},
+		},
+		{
+			Name:        "no base url",
+			Config:      WebAuthnConfig{Enabled: true, RPID: "example.com"},
+			ExpectedErr: "'base_url' or 'webauthn_rp_id' and 'webauthn_rp_origins' must be set when 'webauthn_enabled' is set",
+		},
+		{
+			Name:        "invalid origin",
+			Config:      WebAuthnConfig{Enabled: true, RPID: "example.com", RPOrigins: []string{"example.com"}},
+			ExpectedErr: `invalid 'webauthn_rp_origins' value "example.com"`,
+		},
+		{
+			Name:        "required for admins when disabled",
+			Config:      WebAuthnConfig{RequiredForAdmins: true},
+			ExpectedErr: "'webauthn_required_for_admins' requires 'webauthn_enabled'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidate(tc.BaseURL)
+			if tc.ExpectedErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, tc.Config)
+		})
+	}
+}
+
+func TestParseAndValidateSCIM(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		API         APIConfig
+		ExpectedErr string
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name: "users in database",
+			API: APIConfig{
+				AuthUserTable: "users",
+				SCIM:          SCIMConfig{Token: "0123456789abcdef0123456789abcdef"},
+			},
+		},
+		{
+			Name: "users in file",
+			API: APIConfig{
+				AuthFile: "/etc/rport/users.json",
+				SCIM:     SCIMConfig{Token: "0123456789abcdef0123456789abcdef"},
+			},
+		},
+		{
+			Name: "static user",
+			API: APIConfig{
+				Auth: "admin:foobaz",
+				SCIM: SCIMConfig{Token: "0123456789abcdef0123456789abcdef"},
+			},
+			ExpectedErr: "'scim_token' requires users stored in 'auth_file' or 'auth_user_table'",
+		},
+		{
+			Name: "short token",
+			API: APIConfig{
+				AuthUserTable: "users",
+				SCIM:          SCIMConfig{Token: "secret"},
+			},
+			ExpectedErr: "'scim_token' must be at least 32 characters long",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := &Config{API: tc.API}
+			err := c.parseAndValidateSCIM()
+			if tc.ExpectedErr != "" {
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
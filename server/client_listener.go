@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,8 +29,12 @@ import (
 	"github.com/openrport/openrport/server/api/middleware"
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clientcert"
 	"github.com/openrport/openrport/server/clients"
 	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/enrollment"
+	"github.com/openrport/openrport/server/joboutput"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/logger"
@@ -40,6 +45,9 @@ import (
 const (
 	ConnectionRequestTimeOut = 5 * 60 * time.Second
 
+	// PermissionEnrollmentToken is the ssh permission extension holding the token of enrolling clients
+	PermissionEnrollmentToken = "rport-enrollment-token"
+
 	ClientRequestsLog     = "requests"
 	ClientPingsLog        = "ping"
 	ClientMeasurementsLog = "measurements"
@@ -126,6 +134,10 @@ func NewClientListener(server *Server, privateKey ssh.Signer) (*ClientListener,
 		PasswordCallback: cl.authUser,
 	}
 
+	if server.clientCertAuthority != nil {
+		cl.sshConfig.PublicKeyCallback = cl.authCertificate
+	}
+
 	cl.sshConfig.AddHostKey(privateKey)
 
 	// setup reverse proxy
@@ -153,6 +165,10 @@ func NewClientListener(server *Server, privateKey ssh.Signer) (*ClientListener,
 func (cl *ClientListener) authUser(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
 	clientAuthID := c.User()
 
+	if clientAuthID == comm.EnrollmentAuthID {
+		return cl.authEnrollmentToken(c, password)
+	}
+
 	if cl.bannedClientAuths.IsBanned(clientAuthID) {
 		cl.log().Infof("Failed login attempt for client auth id %q, forcing to wait for %vs (%s)",
 			clientAuthID,
@@ -169,7 +185,12 @@ func (cl *ClientListener) authUser(c ssh.ConnMetadata, password []byte) (*ssh.Pe
 
 	ip := cl.getIP(c.RemoteAddr())
 	// constant time compare is used for security reasons
-	if clientAuth == nil || subtle.ConstantTimeCompare([]byte(clientAuth.Password), password) != 1 {
+	valid := clientAuth != nil && subtle.ConstantTimeCompare([]byte(clientAuth.Password), password) == 1
+	if !valid && cl.server.clientAuthRotation != nil {
+		// the previous password is accepted during the grace period after a rotation
+		valid = cl.server.clientAuthRotation.CheckAlternatePassword(cl.getCtx(), clientAuthID, password)
+	}
+	if !valid {
 		cl.log().Debugf("Login failed for client auth id: %s", clientAuthID)
 		cl.bannedClientAuths.Add(clientAuthID)
 		if cl.bannedIPs != nil {
@@ -184,6 +205,44 @@ func (cl *ClientListener) authUser(c ssh.ConnMetadata, password []byte) (*ssh.Pe
 	return nil, nil
 }
 
+// authEnrollmentToken validates the token of an enrolling client. The enrollment auth id is shared by all enrolling clients,
+// so only the IP is banned on failures.
+func (cl *ClientListener) authEnrollmentToken(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	ip := cl.getIP(c.RemoteAddr())
+	if cl.server.enrollmentManager == nil {
+		return nil, errors.New("enrollment is disabled")
+	}
+
+	_, err := cl.server.enrollmentManager.Authenticate(cl.getCtx(), string(password))
+	if err != nil {
+		cl.log().Debugf("Enrollment failed from %s: %v", ip, err)
+		if cl.bannedIPs != nil {
+			cl.bannedIPs.AddBadAttempt(ip)
+		}
+		return nil, err
+	}
+
+	if cl.bannedIPs != nil {
+		cl.bannedIPs.AddSuccessAttempt(ip)
+	}
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			PermissionEnrollmentToken: string(password),
+		},
+	}, nil
+}
+
+// authCertificate is responsible for validating the ssh certificate of the client, the client auth provider is not involved.
+// Failures don't ban the client auth id because clients fall back to the password if their certificate is not accepted.
+func (cl *ClientListener) authCertificate(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	perms, err := cl.server.clientCertAuthority.Authenticate(c, key)
+	if err != nil {
+		cl.log().Debugf("Certificate login failed for client auth id %s (%s): %v", c.User(), cl.getIP(c.RemoteAddr()), err)
+		return nil, err
+	}
+	return perms, nil
+}
+
 func (cl *ClientListener) getIP(addr net.Addr) string {
 	addrStr := addr.String()
 	host, _, err := net.SplitHostPort(addrStr)
@@ -256,6 +315,11 @@ func (cl *ClientListener) handleClient(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte{})
 }
 
+// SSHHandshakes returns the number of ssh handshakes in progress and the max number of concurrent handshakes
+func (cl *ClientListener) SSHHandshakes() (inProgress, limit int) {
+	return len(cl.inprogressSSHHandshakes), cap(cl.inprogressSSHHandshakes)
+}
+
 func (cl *ClientListener) nextClientIndex() int32 {
 	return atomic.AddInt32(&cl.clientIndexAutoIncrement, 1)
 }
@@ -352,6 +416,11 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	if sshConn.Permissions != nil && sshConn.Permissions.Extensions[PermissionEnrollmentToken] != "" {
+		cl.handleEnrollment(sshConn, chans, reqs, clientLog, sshConn.Permissions.Extensions[PermissionEnrollmentToken])
+		return
+	}
+
 	// verify configuration
 	clientLog.Debugf("Verifying configuration...")
 
@@ -368,6 +437,15 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 
 	// get the current client auth id
 	clientAuthID := sshConn.User()
+	if sshConn.Permissions != nil && sshConn.Permissions.Extensions[clientcert.PermissionCertSerial] != "" {
+		clientLog.Debugf("Client auth id %s authenticated by certificate with serial %s", clientAuthID, sshConn.Permissions.Extensions[clientcert.PermissionCertSerial])
+	}
+
+	if err := cl.checkEnrollmentApproved(cl.getCtx(), clientAuthID); err != nil {
+		clientLog.Infof("Client auth id %s is not allowed to connect: %v", clientAuthID, err)
+		cl.replyConnectionError(r, err)
+		return
+	}
 
 	clientID, err := cl.getClientID(connRequest.ID, cl.server.config, clientAuthID)
 	if err != nil {
@@ -385,6 +463,10 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	}
 	clientLog.Debugf("Client service started for %s (%s) within %s", client.GetID(), client.GetName(), time.Since(ts1))
 
+	if err := cl.server.ha.ClientConnected(ctx, clientID); err != nil {
+		clientLog.Errorf("could not register client on ha node: %v", err)
+	}
+
 	ts2 := time.Now()
 
 	cl.replyConnectionSuccess(r, connRequest.Remotes)
@@ -395,8 +477,8 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	clientLog.Debugf("opened %s within %s", clientBanner, time.Since(ts2))
 
 	// now run handler for other client requests and connections
-	go cl.handleSSHRequests(clientLog, clientID, reqs)
-	go cl.handleSSHChannels(clientLog.GetLogger(), chans)
+	go cl.handleSSHRequests(clientLog, clientID, clientAuthID, reqs)
+	go cl.handleSSHChannels(clientLog.GetLogger(), client, chans)
 
 	// wait until we're disconnected from the client
 	if err = sshConn.Wait(); err != nil {
@@ -408,6 +490,79 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	if err != nil {
 		cl.log().Errorf("could not terminate client: %s", err)
 	}
+
+	if err := cl.server.ha.ClientDisconnected(cl.getCtx(), clientID); err != nil {
+		cl.log().Errorf("could not unregister client from ha node: %v", err)
+	}
+}
+
+// checkEnrollmentApproved rejects enrolled clients until an admin approved them
+func (cl *ClientListener) checkEnrollmentApproved(ctx context.Context, clientAuthID string) error {
+	if cl.server.enrollmentManager == nil {
+		return nil
+	}
+	return cl.server.enrollmentManager.CheckApproved(ctx, clientAuthID)
+}
+
+// handleEnrollment handles connections authenticated by an enrollment token. The client receives its own client auth credentials
+// and reconnects with them, so the connection is closed after the enroll request.
+func (cl *ClientListener) handleEnrollment(sshConn *ssh.ServerConn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request, clientLog *logger.DynamicLogger, token string) {
+	defer sshConn.Close()
+
+	// the channels must be serviced, the loop ends when the connection is closed
+	go func() {
+		for ch := range chans {
+			_ = ch.Reject(ssh.Prohibited, "no channels before enrollment")
+		}
+	}()
+
+	var r *ssh.Request
+	select {
+	case r = <-reqs:
+	case <-cl.getCtx().Done():
+		return
+	case <-time.After(ConnectionRequestTimeOut):
+		clientLog.Debugf("enroll request timeout exceeded")
+		return
+	}
+	if r == nil {
+		return
+	}
+	if r.Type != comm.RequestTypeEnroll {
+		cl.replyConnectionError(r, errors.New("expecting enroll request"))
+		return
+	}
+	if len(r.Payload) > int(cl.server.config.Server.MaxRequestBytesClient) {
+		cl.replyConnectionError(r, fmt.Errorf("request data exceeds the limit of %d bytes, actual size: %d", cl.server.config.Server.MaxRequestBytesClient, len(r.Payload)))
+		return
+	}
+
+	req := comm.EnrollRequest{}
+	if err := json.Unmarshal(r.Payload, &req); err != nil {
+		comm.ReplyError(clientLog.GetLogger(), r, fmt.Errorf("invalid enroll request: %v", err))
+		return
+	}
+
+	ip := cl.getIP(sshConn.RemoteAddr())
+	e, clientAuth, err := cl.server.enrollmentManager.Enroll(cl.getCtx(), token, req.ClientID, req.Name, ip)
+	if err != nil {
+		clientLog.Errorf("Failed to enroll client %q (%s): %v", req.Name, ip, err)
+		comm.ReplyError(clientLog.GetLogger(), r, err)
+		return
+	}
+	clientLog.Infof("Client %q (%s) enrolled as client auth id %s with token %s, status: %s", req.Name, ip, e.ClientAuthID, e.TokenID, e.Status)
+
+	cl.server.auditLog.Entry(auditlog.ApplicationClientEnrollment, auditlog.ActionCreate).
+		WithID(e.ClientAuthID).
+		WithRemoteIP(ip).
+		WithRequest(req).
+		WithResponse(e).
+		Save()
+
+	comm.ReplySuccessJSON(clientLog.GetLogger(), r, comm.EnrollResponse{
+		Auth:    clientAuth.ID + ":" + clientAuth.Password,
+		Pending: e.Status == enrollment.StatusPending,
+	})
 }
 
 // checkVersions print if client and server versions dont match.
@@ -470,7 +625,7 @@ func (cl *ClientListener) replyConnectionError(r *ssh.Request, err error) {
 	}
 }
 
-func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, clientID string, reqs <-chan *ssh.Request) {
+func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, clientID, clientAuthID string, reqs <-chan *ssh.Request) {
 	clientService := cl.getClientService()
 
 	for r := range reqs {
@@ -611,6 +766,10 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 
 			cl.server.monitoringQueue.Notify(measurement)
 
+			if cl.server.metrics != nil {
+				cl.server.metrics.SaveMeasurement(measurement)
+			}
+
 			if rportplus.IsPlusEnabled(cl.server.config.PlusConfig) {
 				alertingCap := cl.server.plusManager.GetAlertingCapabilityEx()
 				if alertingCap != nil {
@@ -631,6 +790,8 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 				clientLog.Errorf("Failed to save IPAddresses status: %s", err)
 				continue
 			}
+		case comm.RequestTypeSignCertificate:
+			cl.handleSignCertificate(clientLog, clientID, clientAuthID, r)
 		default:
 			clientLog.Debugf("Unknown request: %s", r.Type)
 		}
@@ -639,6 +800,48 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 	clientLog.Debugf("Client listener for %s stopped", clientID)
 }
 
+// handleSignCertificate issues a certificate for the client auth id of the connection. Clients request it to enroll after
+// authenticating by password and to rotate their certificate before it expires.
+func (cl *ClientListener) handleSignCertificate(clientLog *logger.DynamicLogger, clientID, clientAuthID string, r *ssh.Request) {
+	if cl.server.clientCertAuthority == nil {
+		comm.ReplyError(clientLog.GetLogger(), r, clientcert.ErrSigningDisabled)
+		return
+	}
+
+	req := comm.SignCertificateRequest{}
+	if err := json.Unmarshal(r.Payload, &req); err != nil {
+		clientLog.Errorf("Failed to unmarshal sign certificate request: %s", err)
+		comm.ReplyError(clientLog.GetLogger(), r, err)
+		return
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(req.PublicKey))
+	if err != nil {
+		comm.ReplyError(clientLog.GetLogger(), r, fmt.Errorf("invalid public key: %v", err))
+		return
+	}
+
+	cert, err := cl.server.clientCertAuthority.Sign(key, clientAuthID)
+	if err != nil {
+		clientLog.Errorf("Failed to sign certificate for client auth id %s: %v", clientAuthID, err)
+		comm.ReplyError(clientLog.GetLogger(), r, err)
+		return
+	}
+	clientLog.Infof("Issued certificate with serial %d for client auth id %s valid until %s", cert.Serial, clientAuthID, time.Unix(int64(cert.ValidBefore), 0).UTC())
+
+	cl.server.auditLog.Entry(auditlog.ApplicationClientCert, auditlog.ActionCreate).
+		WithID(strconv.FormatUint(cert.Serial, 10)).
+		WithClientID(clientID).
+		WithResponse(map[string]interface{}{
+			"client_auth_id": clientAuthID,
+			"valid_before":   time.Unix(int64(cert.ValidBefore), 0).UTC(),
+		}).
+		Save()
+
+	comm.ReplySuccessJSON(clientLog.GetLogger(), r, comm.SignCertificateResponse{
+		Certificate: clientcert.MarshalPublicKey(cert),
+	})
+}
+
 func (cl *ClientListener) sendMeasurementToAlertingService(
 	alertingCap alertingcap.CapabilityEx,
 	measurement *models.Measurement,
@@ -665,6 +868,14 @@ func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode cmd result request: %s", err)
 	}
+	// clients don't send the values of vault placeholders back, but make sure they are never stored
+	if resp.VaultEnv != nil {
+		resp.VaultEnv = nil
+		respBytes, err = json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var wsJID string
 	if resp.MultiJobID != nil {
@@ -691,9 +902,14 @@ func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 	return &resp, nil
 }
 
-func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-chan ssh.NewChannel) {
+func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, client *clientdata.Client, chans <-chan ssh.NewChannel) {
 	for ch := range chans {
 		ch := ch
+		if ch.ChannelType() == comm.ChannelReverseTunnel {
+			go cl.handleReverseTunnelChannel(clientLog, client, ch)
+			continue
+		}
+
 		extraData := string(ch.ExtraData())
 		stream, reqs, err := ch.Accept()
 		if err != nil {
@@ -712,7 +928,8 @@ func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-ch
 			cl.handleSessionChannel(stream, clientLog)
 		case models.ChannelStdout, models.ChannelStderr:
 			go func() {
-				err := cl.handleOutputChannel(ch.ChannelType(), ch.ExtraData(), clientLog, stream)
+				defer stream.Close()
+				err := cl.handleOutputChannel(ch.ChannelType(), client.GetID(), ch.ExtraData(), clientLog, stream)
 				if err != nil {
 					clientLog.Errorf("Error handling output channel %s: %v", ch.ChannelType(), err)
 				}
@@ -725,6 +942,31 @@ func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-ch
 	}
 }
 
+// handleReverseTunnelChannel connects a connection accepted by a reverse or client-to-client tunnel on the client to the
+// destination of the tunnel
+func (cl *ClientListener) handleReverseTunnelChannel(clientLog *logger.Logger, client *clientdata.Client, ch ssh.NewChannel) {
+	tunnelID := string(ch.ExtraData())
+	tunnel := cl.getClientService().FindTunnel(client, tunnelID)
+	if tunnel == nil || !tunnel.ListensOnClient() {
+		clientLog.Infof("Rejecting reverse tunnel connection: tunnel %q not found", tunnelID)
+		_ = ch.Reject(ssh.Prohibited, "reverse tunnel not found")
+		return
+	}
+	// the target client of client-to-client tunnels checks the destination itself
+	if tunnel.Reverse && !cl.getClientService().IsReverseTunnelAllowed(tunnel.Remote.Remote()) {
+		clientLog.Infof(`Rejecting reverse tunnel connection to %s: not allowed by "reverse_tunnel_allowed" config`, tunnel.Remote.Remote())
+		_ = ch.Reject(ssh.Prohibited, `not allowed with "reverse_tunnel_allowed" config`)
+		return
+	}
+
+	handler, ok := tunnel.TunnelProtocol.(clienttunnel.ChannelHandler)
+	if !ok {
+		_ = ch.Reject(ssh.ConnectionFailed, "tunnel doesn't accept connections from the client")
+		return
+	}
+	handler.HandleChannel(ch)
+}
+
 type outputChannelData struct {
 	JID        string            `json:"jid"`
 	ClientID   string            `json:"client_id"`
@@ -732,9 +974,42 @@ type outputChannelData struct {
 	Result     *models.JobResult `json:"result"`
 }
 
-func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, clientLog *logger.Logger, stream io.Reader) error {
-	job := models.Job{}
-	err := json.Unmarshal(jobData, &job)
+// outputChannelJobWait is how long the job of an output channel is waited for. The client opens the channels before it
+// responds to the request to run the job, so the job might not be stored yet.
+var outputChannelJobWait = 30 * time.Second
+
+const outputChannelJobPollInterval = 100 * time.Millisecond
+
+// getOutputChannelJob returns the stored job of an output channel opened by the client. Only the job id is taken from
+// the channel data, the job must be a job of the client.
+func (cl *ClientListener) getOutputChannelJob(clientID string, jobData []byte) (*models.Job, error) {
+	channelJob := models.Job{}
+	err := json.Unmarshal(jobData, &channelJob)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(outputChannelJobWait)
+	for {
+		job, err := cl.server.jobProvider.GetByJID(clientID, channelJob.JID)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			if job.ClientID != clientID {
+				return nil, fmt.Errorf("job %s is not a job of the client", channelJob.JID)
+			}
+			return job, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("job %s not found", channelJob.JID)
+		}
+		time.Sleep(outputChannelJobPollInterval)
+	}
+}
+
+func (cl *ClientListener) handleOutputChannel(typ, clientID string, jobData []byte, clientLog *logger.Logger, stream io.Reader) error {
+	job, err := cl.getOutputChannelJob(clientID, jobData)
 	if err != nil {
 		return err
 	}
@@ -748,6 +1023,15 @@ func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, client
 
 	ws := cl.server.uiJobWebSockets.Get(wsJID)
 
+	var outputFile *joboutput.File
+	if job.StreamToFile && cl.server.jobOutput != nil {
+		outputFile, err = cl.server.jobOutput.Create(job.JID, typ)
+		if err != nil {
+			return fmt.Errorf("failed to create %s output file for job %s: %v", typ, job.JID, err)
+		}
+		defer outputFile.Close()
+	}
+
 	ocd := outputChannelData{
 		JID:        job.JID,
 		ClientID:   job.ClientID,
@@ -765,6 +1049,12 @@ func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, client
 			return err
 		}
 
+		if outputFile != nil {
+			if _, err := outputFile.Write(data[:n]); err != nil {
+				return fmt.Errorf("failed to write %s output of job %s: %v", typ, job.JID, err)
+			}
+		}
+
 		if ws != nil {
 			switch typ {
 			case models.ChannelStdout:
@@ -781,7 +1071,7 @@ func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, client
 				clientLog.Errorf("Failed to write message to UI Web Socket: %v", err)
 				// proceed further
 			}
-		} else {
+		} else if outputFile == nil {
 			clientLog.Debugf("WS conn not found handling output channel. No active listeners connected")
 		}
 	}
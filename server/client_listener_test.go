@@ -1,15 +1,31 @@
 package chserver
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 
+	clientsmigration "github.com/openrport/openrport/db/migration/clients"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clientcert"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/server/enrollment"
+	"github.com/openrport/openrport/server/joboutput"
+	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/ptr"
@@ -18,7 +34,8 @@ import (
 
 func TestHandleOutputChannel(t *testing.T) {
 	log := logger.NewLogger("client-listener-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
-	cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache()}}
+	jp := NewJobProviderMock()
+	cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), jobProvider: jp}}
 	mockConn := &connMock{}
 	ws := ws.NewConcurrentWebSocket(mockConn, log)
 	cl.server.uiJobWebSockets.Set("test-jid", ws)
@@ -32,7 +49,8 @@ func TestHandleOutputChannel(t *testing.T) {
 		{
 			Name: "no ws",
 			Job: models.Job{
-				JID: "other-jid",
+				JID:      "other-jid",
+				ClientID: "client-1",
 			},
 			Type:     models.ChannelStdout,
 			Expected: nil,
@@ -40,11 +58,13 @@ func TestHandleOutputChannel(t *testing.T) {
 		{
 			Name: "jid",
 			Job: models.Job{
-				JID: "test-jid",
+				JID:      "test-jid",
+				ClientID: "client-1",
 			},
 			Type: models.ChannelStdout,
 			Expected: outputChannelData{
-				JID: "test-jid",
+				JID:      "test-jid",
+				ClientID: "client-1",
 				Result: &models.JobResult{
 					StdOut: "test-output",
 				},
@@ -55,10 +75,12 @@ func TestHandleOutputChannel(t *testing.T) {
 			Job: models.Job{
 				MultiJobID: ptr.String("test-jid"),
 				JID:        "job-jid",
+				ClientID:   "client-1",
 			},
 			Type: models.ChannelStdout,
 			Expected: outputChannelData{
-				JID: "job-jid",
+				JID:      "job-jid",
+				ClientID: "client-1",
 				Result: &models.JobResult{
 					StdOut: "test-output",
 				},
@@ -67,11 +89,13 @@ func TestHandleOutputChannel(t *testing.T) {
 		{
 			Name: "stderr",
 			Job: models.Job{
-				JID: "test-jid",
+				JID:      "test-jid",
+				ClientID: "client-1",
 			},
 			Type: models.ChannelStderr,
 			Expected: outputChannelData{
-				JID: "test-jid",
+				JID:      "test-jid",
+				ClientID: "client-1",
 				Result: &models.JobResult{
 					StdErr: "test-output",
 				},
@@ -84,7 +108,8 @@ func TestHandleOutputChannel(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			// t.Parallel()
 
-			jobData, err := json.Marshal(tc.Job)
+			jp.ReturnJob = &tc.Job
+			jobData, err := json.Marshal(models.Job{JID: tc.Job.JID})
 			require.NoError(t, err)
 
 			reader, writer := io.Pipe()
@@ -94,7 +119,7 @@ func TestHandleOutputChannel(t *testing.T) {
 
 			go func() {
 				defer wg.Done()
-				err := cl.handleOutputChannel(tc.Type, jobData, log, reader)
+				err := cl.handleOutputChannel(tc.Type, "client-1", jobData, log, reader)
 				require.NoError(t, err)
 			}()
 
@@ -109,6 +134,90 @@ func TestHandleOutputChannel(t *testing.T) {
 	}
 }
 
+func TestHandleOutputChannelToFile(t *testing.T) {
+	log := logger.NewLogger("client-listener-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	output, err := joboutput.NewManager(t.TempDir(), 0, log)
+	require.NoError(t, err)
+	jp := NewJobProviderMock()
+	jp.ReturnJob = &models.Job{JID: "test-jid", ClientID: "client-1", StreamToFile: true}
+	cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), jobOutput: output, jobProvider: jp}}
+
+	jobData, err := json.Marshal(models.Job{JID: "test-jid"})
+	require.NoError(t, err)
+
+	for _, chunk := range []string{"line 1\n", "line 2\n"} {
+		err = cl.handleOutputChannel(models.ChannelStdout, "client-1", jobData, log, strings.NewReader(chunk))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "client-1", jp.InputCID)
+	assert.Equal(t, "test-jid", jp.InputJID)
+
+	f, err := output.Open("test-jid", models.ChannelStdout)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", string(data))
+
+	_, err = output.Open("test-jid", models.ChannelStderr)
+	assert.ErrorIs(t, err, joboutput.ErrNotFound)
+}
+
+func TestHandleOutputChannelVerifiesJob(t *testing.T) {
+	defer func(wait time.Duration) { outputChannelJobWait = wait }(outputChannelJobWait)
+	outputChannelJobWait = 0
+
+	log := logger.NewLogger("client-listener-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+	testCases := []struct {
+		Name          string
+		ChannelJob    models.Job
+		StoredJob     *models.Job
+		ExpectedError string
+	}{
+		{
+			Name:          "unknown job",
+			ChannelJob:    models.Job{JID: "test-jid", StreamToFile: true},
+			ExpectedError: "job test-jid not found",
+		},
+		{
+			Name:          "job of another client",
+			ChannelJob:    models.Job{JID: "test-jid", ClientID: "client-1", StreamToFile: true},
+			StoredJob:     &models.Job{JID: "test-jid", ClientID: "client-2", StreamToFile: true},
+			ExpectedError: "job test-jid is not a job of the client",
+		},
+		{
+			Name:       "stream to file not requested",
+			ChannelJob: models.Job{JID: "test-jid", StreamToFile: true},
+			StoredJob:  &models.Job{JID: "test-jid", ClientID: "client-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			output, err := joboutput.NewManager(t.TempDir(), 0, log)
+			require.NoError(t, err)
+			jp := NewJobProviderMock()
+			jp.ReturnJob = tc.StoredJob
+			cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), jobOutput: output, jobProvider: jp}}
+
+			jobData, err := json.Marshal(tc.ChannelJob)
+			require.NoError(t, err)
+
+			err = cl.handleOutputChannel(models.ChannelStdout, "client-1", jobData, log, strings.NewReader("output"))
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			_, err = output.Open("test-jid", models.ChannelStdout)
+			assert.ErrorIs(t, err, joboutput.ErrNotFound)
+		})
+	}
+}
+
 type connMock struct {
 	ws.Conn
 
@@ -119,3 +228,180 @@ func (c *connMock) WriteJSON(data interface{}) error {
 	c.LastWrite = data
 	return nil
 }
+
+func TestClientCertificateAuthAndSigning(t *testing.T) {
+	authority, err := clientcert.NewAuthority(clientcert.Config{CAKeyFile: filepath.Join(t.TempDir(), "ca-key"), Validity: time.Hour}, testLog)
+	require.NoError(t, err)
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	config := &chconfig.Config{Server: chconfig.ServerConfig{MaxConcurrentSSHConnectionHandshakes: 1, MaxRequestBytesClient: 10 * 1024}}
+	cl, err := NewClientListener(&Server{config: config, clientCertAuthority: authority}, hostSigner)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	connect := func(user string, signer ssh.Signer) (ssh.Conn, error) {
+		go func() {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			sshConn, chans, reqs, err := ssh.NewServerConn(serverConn, cl.sshConfig)
+			if err != nil {
+				return
+			}
+			go func() {
+				for ch := range chans {
+					_ = ch.Reject(ssh.Prohibited, "")
+				}
+			}()
+			clientLog := logger.ForkToDynamicLogger(testLog, "client", true, true)
+			cl.handleSSHRequests(clientLog, "client-1", sshConn.User(), reqs)
+		}()
+		clientConn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		conn, _, _, err := ssh.NewClientConn(clientConn, "", &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		return conn, err
+	}
+
+	newSigner := func() ssh.Signer {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signer, err := ssh.NewSignerFromKey(key)
+		require.NoError(t, err)
+		return signer
+	}
+
+	key := newSigner()
+	cert, err := authority.Sign(key.PublicKey(), "client-auth-1")
+	require.NoError(t, err)
+	certSigner, err := ssh.NewCertSigner(cert, key)
+	require.NoError(t, err)
+
+	_, err = connect("client-auth-2", certSigner)
+	assert.Error(t, err, "certificate of another client auth id")
+	_, err = connect("client-auth-1", key)
+	assert.Error(t, err, "plain key")
+
+	conn, err := connect("client-auth-1", certSigner)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// rotate over the existing connection
+	newKey := newSigner()
+	resp := comm.SignCertificateResponse{}
+	err = comm.SendRequestAndGetResponse(conn, comm.RequestTypeSignCertificate, comm.SignCertificateRequest{
+		PublicKey: string(ssh.MarshalAuthorizedKey(newKey.PublicKey())),
+	}, &resp, testLog)
+	require.NoError(t, err)
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Certificate))
+	require.NoError(t, err)
+	newCert, ok := pub.(*ssh.Certificate)
+	require.True(t, ok)
+	assert.Equal(t, "client-auth-1", newCert.KeyId)
+	assert.NotEqual(t, cert.Serial, newCert.Serial)
+
+	newCertSigner, err := ssh.NewCertSigner(newCert, newKey)
+	require.NoError(t, err)
+	conn2, err := connect("client-auth-1", newCertSigner)
+	require.NoError(t, err)
+	conn2.Close()
+}
+
+func TestClientEnrollment(t *testing.T) {
+	ctx := context.Background()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	db, err := sqlite.New(":memory:", clientsmigration.AssetNames(), clientsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	clientAuthProvider := clientsauth.NewMockFileProvider(nil, t)
+	enrollmentManager := enrollment.NewManager(enrollment.NewSQLiteProvider(db), clientAuthProvider)
+
+	config := &chconfig.Config{Server: chconfig.ServerConfig{MaxConcurrentSSHConnectionHandshakes: 1, MaxRequestBytesClient: 10 * 1024}}
+	cl, err := NewClientListener(&Server{config: config, clientAuthProvider: clientAuthProvider, enrollmentManager: enrollmentManager}, hostSigner)
+	require.NoError(t, err)
+	cl.ctx = ctx
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	connect := func(user, password string) (ssh.Conn, error) {
+		go func() {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			sshConn, chans, reqs, err := ssh.NewServerConn(serverConn, cl.sshConfig)
+			if err != nil {
+				return
+			}
+			clientLog := logger.ForkToDynamicLogger(testLog, "client", true, true)
+			cl.handleEnrollment(sshConn, chans, reqs, clientLog, sshConn.Permissions.Extensions[PermissionEnrollmentToken])
+		}()
+		clientConn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		conn, _, _, err := ssh.NewClientConn(clientConn, "", &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.Password(password)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		return conn, err
+	}
+
+	_, token, err := enrollmentManager.CreateToken(ctx, enrollment.TokenRequest{RequireApproval: true}, "admin")
+	require.NoError(t, err)
+
+	_, err = connect(comm.EnrollmentAuthID, "invalid_token")
+	assert.Error(t, err)
+
+	conn, err := connect(comm.EnrollmentAuthID, token)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp := comm.EnrollResponse{}
+	err = comm.SendRequestAndGetResponse(conn, comm.RequestTypeEnroll, comm.EnrollRequest{ClientID: "client-1", Name: "Client 1"}, &resp, testLog)
+	require.NoError(t, err)
+	assert.True(t, resp.Pending)
+
+	clientAuthID, password, ok := strings.Cut(resp.Auth, ":")
+	require.True(t, ok)
+	clientAuth, err := clientAuthProvider.Get(clientAuthID)
+	require.NoError(t, err)
+	require.NotNil(t, clientAuth)
+	assert.Equal(t, password, clientAuth.Password)
+	assert.Error(t, cl.checkEnrollmentApproved(ctx, clientAuthID))
+
+	_, err = connect(comm.EnrollmentAuthID, token)
+	assert.Error(t, err, "token used up")
+
+	_, token, err = enrollmentManager.CreateToken(ctx, enrollment.TokenRequest{}, "admin")
+	require.NoError(t, err)
+	conn, err = connect(comm.EnrollmentAuthID, token)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _, err = conn.OpenChannel("session", nil)
+	assert.Error(t, err, "channels are rejected")
+
+	err = comm.SendRequestAndGetResponse(conn, comm.RequestTypeEnroll, comm.EnrollRequest{Name: strings.Repeat("x", 11*1024)}, &resp, testLog)
+	assert.ErrorContains(t, err, "request data exceeds the limit of 10240 bytes")
+
+	conn, err = connect(comm.EnrollmentAuthID, token)
+	require.NoError(t, err, "token not used by the rejected request")
+	defer conn.Close()
+}
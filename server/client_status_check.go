@@ -87,6 +87,10 @@ func (t *ClientsStatusCheckTask) getDueClients() (dueClients []*clientdata.Clien
 	var now = time.Now()
 	activeClients := t.clientsRepo.GetAllActiveClients()
 	for _, c := range activeClients {
+		if c.GetConnection() == nil {
+			// connected to another node of the cluster, which checks it
+			continue
+		}
 		// Shorten the threshold aka make heartbeat older than it is because the ping response is stored after this check.
 		// Clients would get checked only every second time otherwise.
 		if c.HasLastHeartbeatAt() {
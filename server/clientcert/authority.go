@@ -0,0 +1,244 @@
+package clientcert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+// ClockSkew is subtracted from the start of the validity of issued certificates to tolerate clients with a clock running late
+const ClockSkew = 5 * time.Minute
+
+const (
+	// PermissionCertSerial and PermissionCertKeyID are the extensions of the ssh permissions of clients authenticated by a certificate
+	PermissionCertSerial = "rport-cert-serial"
+	PermissionCertKeyID  = "rport-cert-key-id"
+)
+
+var ErrSigningDisabled = errors.New("issuing client certificates is disabled, 'client_cert_ca_key' is not set")
+
+type Config struct {
+	// CAKeyFile is the private key used to sign client certificates, it's generated if it doesn't exist
+	CAKeyFile string
+	// TrustedCAFiles contain public keys of external CAs in the authorized_keys format
+	TrustedCAFiles []string
+	// Validity of issued certificates
+	Validity time.Duration
+	// RevocationListFile contains the revoked certificates, see RevocationList
+	RevocationListFile string
+}
+
+// Enabled returns true if clients can authenticate by certificates
+func (c Config) Enabled() bool {
+	return c.CAKeyFile != "" || len(c.TrustedCAFiles) > 0
+}
+
+// Authority authenticates clients presenting an ssh user certificate and issues certificates if it has a CA key.
+// The key id and the only principal of a certificate are the client auth id.
+type Authority struct {
+	signer   ssh.Signer
+	trusted  []ssh.PublicKey
+	validity time.Duration
+	revoked  *RevocationList
+	now      func() time.Time
+}
+
+func NewAuthority(config Config, l *logger.Logger) (*Authority, error) {
+	a := &Authority{
+		validity: config.Validity,
+		now:      time.Now,
+	}
+
+	if config.CAKeyFile != "" {
+		signer, generated, err := loadOrGenerateCAKey(config.CAKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if generated {
+			l.Infof("Generated client certificate CA key %s", config.CAKeyFile)
+		}
+		l.Infof("Client certificate CA: %s", MarshalPublicKey(signer.PublicKey()))
+		a.signer = signer
+		a.trusted = append(a.trusted, signer.PublicKey())
+	}
+
+	for _, f := range config.TrustedCAFiles {
+		keys, err := loadPublicKeys(f)
+		if err != nil {
+			return nil, err
+		}
+		a.trusted = append(a.trusted, keys...)
+	}
+
+	if config.RevocationListFile != "" {
+		revoked, err := NewRevocationList(config.RevocationListFile, l)
+		if err != nil {
+			return nil, err
+		}
+		a.revoked = revoked
+	}
+
+	return a, nil
+}
+
+// CanSign returns true if the authority has a CA key to issue certificates
+func (a *Authority) CanSign() bool {
+	return a.signer != nil
+}
+
+// Authenticate is used as ssh public key callback, only valid certificates of trusted CAs issued for the client auth id are accepted
+func (a *Authority) Authenticate(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	checker := &ssh.CertChecker{
+		IsUserAuthority: a.isTrusted,
+		IsRevoked:       a.isRevoked,
+		Clock:           a.now,
+	}
+	perms, err := checker.Authenticate(conn, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := key.(*ssh.Certificate)
+	if cert.KeyId != conn.User() {
+		return nil, fmt.Errorf("certificate key id %q doesn't match the client auth id %q", cert.KeyId, conn.User())
+	}
+
+	if perms.Extensions == nil {
+		perms.Extensions = map[string]string{}
+	}
+	perms.Extensions[PermissionCertSerial] = strconv.FormatUint(cert.Serial, 10)
+	perms.Extensions[PermissionCertKeyID] = cert.KeyId
+	return perms, nil
+}
+
+// Sign issues a certificate for the public key of a client
+func (a *Authority) Sign(key ssh.PublicKey, clientAuthID string) (*ssh.Certificate, error) {
+	if a.signer == nil {
+		return nil, ErrSigningDisabled
+	}
+	if clientAuthID == "" {
+		return nil, errors.New("client auth id cannot be empty")
+	}
+	if _, ok := key.(*ssh.Certificate); ok {
+		return nil, errors.New("expected a public key, got a certificate")
+	}
+	if a.revoked != nil && a.revoked.KeyIDRevoked(clientAuthID) {
+		return nil, fmt.Errorf("certificates of client auth id %q are revoked", clientAuthID)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	cert := &ssh.Certificate{
+		Key:             key,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           clientAuthID,
+		ValidPrincipals: []string{clientAuthID},
+		ValidAfter:      uint64(now.Add(-ClockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(a.validity).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, a.signer); err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %v", err)
+	}
+	return cert, nil
+}
+
+func (a *Authority) isTrusted(auth ssh.PublicKey) bool {
+	marshaled := auth.Marshal()
+	for _, k := range a.trusted {
+		if string(k.Marshal()) == string(marshaled) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authority) isRevoked(cert *ssh.Certificate) bool {
+	return a.revoked != nil && a.revoked.Contains(cert)
+}
+
+// MarshalPublicKey returns the key in the authorized_keys format without trailing newline
+func MarshalPublicKey(key ssh.PublicKey) string {
+	b := ssh.MarshalAuthorizedKey(key)
+	return string(b[:len(b)-1])
+}
+
+func randomSerial() (uint64, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, fmt.Errorf("failed to generate certificate serial: %v", err)
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func loadOrGenerateCAKey(path string) (ssh.Signer, bool, error) {
+	b, err := os.ReadFile(path)
+	if err == nil {
+		signer, err := ssh.ParsePrivateKey(b)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse client certificate CA key %s: %v", path, err)
+		}
+		return signer, false, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("failed to read client certificate CA key: %v", err)
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate client certificate CA key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "rportd client certificate CA")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode client certificate CA key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, fmt.Errorf("failed to create directory for client certificate CA key: %v", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, false, fmt.Errorf("failed to write client certificate CA key: %v", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil, false, err
+	}
+	return signer, true, nil
+}
+
+func loadPublicKeys(path string) ([]ssh.PublicKey, error) {
+	rest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted client certificate CA: %v", err)
+	}
+
+	var keys []ssh.PublicKey
+	for len(rest) > 0 {
+		var key ssh.PublicKey
+		key, _, _, rest, err = ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			if len(keys) > 0 {
+				// no more keys in the remaining lines
+				break
+			}
+			return nil, fmt.Errorf("failed to parse trusted client certificate CA %s: %v", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,172 @@
+package clientcert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+var testLog = logger.NewLogger("client-cert", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type connMetadata struct {
+	user string
+}
+
+func (c connMetadata) User() string          { return c.user }
+func (c connMetadata) SessionID() []byte     { return nil }
+func (c connMetadata) ClientVersion() []byte { return nil }
+func (c connMetadata) ServerVersion() []byte { return nil }
+func (c connMetadata) RemoteAddr() net.Addr  { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+func (c connMetadata) LocalAddr() net.Addr   { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+
+func generatePublicKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return key
+}
+
+func TestNewAuthorityGeneratesCAKey(t *testing.T) {
+	caKeyFile := filepath.Join(t.TempDir(), "ca", "client-ca-key")
+
+	a1, err := NewAuthority(Config{CAKeyFile: caKeyFile, Validity: time.Hour}, testLog)
+	require.NoError(t, err)
+	assert.True(t, a1.CanSign())
+
+	info, err := os.Stat(caKeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	a2, err := NewAuthority(Config{CAKeyFile: caKeyFile, Validity: time.Hour}, testLog)
+	require.NoError(t, err)
+	assert.Equal(t, a1.signer.PublicKey().Marshal(), a2.signer.PublicKey().Marshal())
+}
+
+func TestSignAndAuthenticate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	a, err := NewAuthority(Config{CAKeyFile: filepath.Join(t.TempDir(), "ca-key"), Validity: 24 * time.Hour}, testLog)
+	require.NoError(t, err)
+	a.now = func() time.Time { return now }
+
+	key := generatePublicKey(t)
+	cert, err := a.Sign(key, "client-auth-1")
+	require.NoError(t, err)
+	assert.Equal(t, "client-auth-1", cert.KeyId)
+	assert.Equal(t, []string{"client-auth-1"}, cert.ValidPrincipals)
+	assert.Equal(t, uint64(now.Add(-ClockSkew).Unix()), cert.ValidAfter)
+	assert.Equal(t, uint64(now.Add(24*time.Hour).Unix()), cert.ValidBefore)
+
+	perms, err := a.Authenticate(connMetadata{user: "client-auth-1"}, cert)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatUint(cert.Serial, 10), perms.Extensions[PermissionCertSerial])
+	assert.Equal(t, "client-auth-1", perms.Extensions[PermissionCertKeyID])
+
+	_, err = a.Authenticate(connMetadata{user: "client-auth-2"}, cert)
+	assert.Error(t, err, "other client auth id")
+
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, key)
+	assert.Error(t, err, "plain public key")
+
+	a.now = func() time.Time { return now.Add(25 * time.Hour) }
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert)
+	assert.Error(t, err, "expired")
+
+	_, err = a.Sign(cert, "client-auth-1")
+	assert.EqualError(t, err, "expected a public key, got a certificate")
+}
+
+func TestAuthenticateTrustedCA(t *testing.T) {
+	dir := t.TempDir()
+	external, err := NewAuthority(Config{CAKeyFile: filepath.Join(dir, "external-ca-key"), Validity: time.Hour}, testLog)
+	require.NoError(t, err)
+	caFile := filepath.Join(dir, "trusted-cas")
+	require.NoError(t, os.WriteFile(caFile, []byte("# external CA\n"+MarshalPublicKey(external.signer.PublicKey())+"\n"), 0600))
+
+	a, err := NewAuthority(Config{TrustedCAFiles: []string{caFile}}, testLog)
+	require.NoError(t, err)
+	assert.False(t, a.CanSign())
+
+	cert, err := external.Sign(generatePublicKey(t), "client-auth-1")
+	require.NoError(t, err)
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert)
+	assert.NoError(t, err)
+
+	untrusted, err := NewAuthority(Config{CAKeyFile: filepath.Join(dir, "untrusted-ca-key"), Validity: time.Hour}, testLog)
+	require.NoError(t, err)
+	cert, err = untrusted.Sign(generatePublicKey(t), "client-auth-1")
+	require.NoError(t, err)
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert)
+	assert.Error(t, err)
+
+	_, err = a.Sign(generatePublicKey(t), "client-auth-1")
+	assert.Equal(t, ErrSigningDisabled, err)
+}
+
+func TestAuthenticateRevoked(t *testing.T) {
+	dir := t.TempDir()
+	revocationFile := filepath.Join(dir, "revoked")
+	require.NoError(t, os.WriteFile(revocationFile, []byte("# revoked certificates\n"), 0600))
+
+	a, err := NewAuthority(Config{CAKeyFile: filepath.Join(dir, "ca-key"), Validity: time.Hour, RevocationListFile: revocationFile}, testLog)
+	require.NoError(t, err)
+
+	cert1, err := a.Sign(generatePublicKey(t), "client-auth-1")
+	require.NoError(t, err)
+	cert2, err := a.Sign(generatePublicKey(t), "client-auth-1")
+	require.NoError(t, err)
+	cert3, err := a.Sign(generatePublicKey(t), "client-auth-2")
+	require.NoError(t, err)
+
+	content := "serial:" + strconv.FormatUint(cert1.Serial, 10) + "\n"
+	require.NoError(t, os.WriteFile(revocationFile, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(revocationFile, time.Now(), time.Now().Add(time.Minute)))
+
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert1)
+	assert.Error(t, err)
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert2)
+	assert.NoError(t, err)
+
+	content += "key_id:client-auth-2\n"
+	require.NoError(t, os.WriteFile(revocationFile, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(revocationFile, time.Now(), time.Now().Add(2*time.Minute)))
+
+	_, err = a.Authenticate(connMetadata{user: "client-auth-2"}, cert3)
+	assert.Error(t, err)
+	_, err = a.Sign(generatePublicKey(t), "client-auth-2")
+	assert.EqualError(t, err, `certificates of client auth id "client-auth-2" are revoked`)
+
+	// an invalid list is ignored and the previous one is kept
+	require.NoError(t, os.WriteFile(revocationFile, []byte("invalid\n"), 0600))
+	require.NoError(t, os.Chtimes(revocationFile, time.Now(), time.Now().Add(3*time.Minute)))
+
+	_, err = a.Authenticate(connMetadata{user: "client-auth-1"}, cert1)
+	assert.Error(t, err)
+}
+
+func TestParseRevocationList(t *testing.T) {
+	serials, keyIDs, err := parseRevocationList([]byte("# comment\n\nserial: 42\nkey_id:client-auth-1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]bool{42: true}, serials)
+	assert.Equal(t, map[string]bool{"client-auth-1": true}, keyIDs)
+
+	_, _, err = parseRevocationList([]byte("serial:abc\n"))
+	assert.EqualError(t, err, `line 1: invalid serial: strconv.ParseUint: parsing "abc": invalid syntax`)
+
+	_, _, err = parseRevocationList([]byte("serial:1\nkey_id:\n"))
+	assert.EqualError(t, err, "line 2: empty key id")
+
+	_, _, err = parseRevocationList([]byte("42\n"))
+	assert.EqualError(t, err, `line 1: expected "serial:" or "key_id:" prefix`)
+}
@@ -0,0 +1,137 @@
+package clientcert
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	revokedSerialPrefix = "serial:"
+	revokedKeyIDPrefix  = "key_id:"
+)
+
+// RevocationList holds the revoked client certificates read from a file. Each line of the file is either
+// "serial:<serial number>" to revoke a single certificate or "key_id:<client auth id>" to revoke all certificates
+// issued for a client auth id. Empty lines and lines starting with "#" are ignored.
+// The file is read again when it has been modified.
+type RevocationList struct {
+	path   string
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	modTime time.Time
+	serials map[uint64]bool
+	keyIDs  map[string]bool
+}
+
+func NewRevocationList(path string, l *logger.Logger) (*RevocationList, error) {
+	r := &RevocationList{
+		path:   path,
+		logger: l,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Contains returns true if the certificate or all certificates of its key id are revoked
+func (r *RevocationList) Contains(cert *ssh.Certificate) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refresh()
+	return r.serials[cert.Serial] || r.keyIDs[cert.KeyId]
+}
+
+// KeyIDRevoked returns true if all certificates of the key id are revoked
+func (r *RevocationList) KeyIDRevoked(keyID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refresh()
+	return r.keyIDs[keyID]
+}
+
+// refresh reads the file again if it has been modified, on errors the previous list is kept
+func (r *RevocationList) refresh() {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		r.logger.Errorf("Failed to check client certificate revocation list: %v", err)
+		return
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return
+	}
+	if err := r.reload(); err != nil {
+		r.logger.Errorf("Failed to reload client certificate revocation list, using the previous one: %v", err)
+	}
+}
+
+func (r *RevocationList) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read client certificate revocation list: %v", err)
+	}
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read client certificate revocation list: %v", err)
+	}
+
+	serials, keyIDs, err := parseRevocationList(b)
+	if err != nil {
+		return fmt.Errorf("invalid client certificate revocation list %s: %v", r.path, err)
+	}
+
+	r.serials = serials
+	r.keyIDs = keyIDs
+	r.modTime = info.ModTime()
+	return nil
+}
+
+func parseRevocationList(b []byte) (map[uint64]bool, map[string]bool, error) {
+	serials := make(map[uint64]bool)
+	keyIDs := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, revokedSerialPrefix):
+			serial, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, revokedSerialPrefix)), 10, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: invalid serial: %v", lineNo, err)
+			}
+			serials[serial] = true
+		case strings.HasPrefix(line, revokedKeyIDPrefix):
+			keyID := strings.TrimSpace(strings.TrimPrefix(line, revokedKeyIDPrefix))
+			if keyID == "" {
+				return nil, nil, fmt.Errorf("line %d: empty key id", lineNo)
+			}
+			keyIDs[keyID] = true
+		default:
+			return nil, nil, fmt.Errorf("line %d: expected %q or %q prefix", lineNo, revokedSerialPrefix, revokedKeyIDPrefix)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return serials, keyIDs, nil
+}
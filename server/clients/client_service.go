@@ -22,11 +22,13 @@ import (
 
 	"github.com/openrport/openrport/server/acme"
 	apiErrors "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/caddy"
 	"github.com/openrport/openrport/server/cgroups"
 	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
 	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/server/recording"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
@@ -71,6 +73,14 @@ type ClientService interface {
 	GetRepo() *ClientRepository
 
 	SetCaddyAPI(capi caddy.API)
+	SetRecordingManager(recordings *recording.Manager)
+	SetClientGroupProvider(clientGroups cgroups.ClientGroupProvider)
+	SetAuditLog(auditLog *auditlog.AuditLog)
+	SetStoredTunnels(storedTunnels StoredTunnels)
+	SetReverseTunnelAllowed(allowed func(destination string) bool)
+	SetVaultResolver(resolve VaultResolver)
+	IsReverseTunnelAllowed(destination string) bool
+	StartStoredTunnels(ctx context.Context, since, now time.Time) error
 	StartClientTunnels(client *clientdata.Client, remotes []*models.Remote) ([]*clienttunnel.Tunnel, error)
 	StartTunnel(c *clientdata.Client, r *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error)
 	FindTunnel(c *clientdata.Client, id string) *clienttunnel.Tunnel
@@ -87,6 +97,17 @@ type ClientServiceProvider struct {
 	logger            *logger.Logger
 	acme              *acme.Acme
 	alertingService   alertingcap.Service
+	recordings        *recording.Manager
+	storedTunnels     StoredTunnels
+	storedTunnelsMu   sync.Mutex
+	// reverseTunnelAllowed checks the server-side destinations of reverse tunnels, they are not allowed if nil
+	reverseTunnelAllowed func(destination string) bool
+	// resolveVault resolves the vault placeholders of tunnels started without a request of a user
+	resolveVault VaultResolver
+	clientGroups cgroups.ClientGroupProvider
+	auditLog     *auditlog.AuditLog
+	bandwidthsMu sync.Mutex
+	bandwidths   map[string]*clienttunnel.Bandwidth // bandwidth limiters shared by all tunnels of a client
 
 	licensecap licensecap.CapabilityEx
 
@@ -192,6 +213,7 @@ func NewClientService(
 		repo:              repo,
 		logger:            logger.Fork("client-service"),
 		acme:              acme,
+		bandwidths:        make(map[string]*clienttunnel.Bandwidth),
 	}
 
 	return csp
@@ -203,10 +225,11 @@ func InitClientService(
 	portDistributor *ports.PortDistributor,
 	db *sqlx.DB,
 	keepDisconnectedClients *time.Duration,
+	clientNodes ClientNodes,
 	logger *logger.Logger,
 	acme *acme.Acme,
 ) (*ClientServiceProvider, error) {
-	repo, err := InitClientRepository(ctx, db, keepDisconnectedClients, logger)
+	repo, err := InitClientRepository(ctx, db, keepDisconnectedClients, clientNodes, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init Client Repository: %v", err)
 	}
@@ -214,10 +237,37 @@ func InitClientService(
 	return NewClientService(tunnelProxyConfig, portDistributor, repo, logger, acme), nil
 }
 
+func (s *ClientServiceProvider) SetReverseTunnelAllowed(allowed func(destination string) bool) {
+	s.reverseTunnelAllowed = allowed
+}
+
+// IsReverseTunnelAllowed returns true if reverse tunnels may forward connections to the server-side destination
+func (s *ClientServiceProvider) IsReverseTunnelAllowed(destination string) bool {
+	return s.reverseTunnelAllowed != nil && s.reverseTunnelAllowed(destination)
+}
+
+func (s *ClientServiceProvider) SetVaultResolver(resolve VaultResolver) {
+	s.resolveVault = resolve
+}
+
 func (s *ClientServiceProvider) SetPlusLicenseInfoCap(licensecap licensecap.CapabilityEx) {
 	s.licensecap = licensecap
 }
 
+func (s *ClientServiceProvider) SetRecordingManager(recordings *recording.Manager) {
+	s.recordings = recordings
+}
+
+// SetClientGroupProvider enables the bandwidth limits of client groups
+func (s *ClientServiceProvider) SetClientGroupProvider(clientGroups cgroups.ClientGroupProvider) {
+	s.clientGroups = clientGroups
+}
+
+// SetAuditLog enables audit log entries with the traffic totals of closed tunnels
+func (s *ClientServiceProvider) SetAuditLog(auditLog *auditlog.AuditLog) {
+	s.auditLog = auditLog
+}
+
 func (s *ClientServiceProvider) SetPlusAlertingServiceCap(as alertingcap.Service) {
 	s.alertingService = as
 	if s.alertingService != nil {
@@ -371,7 +421,7 @@ func (s *ClientServiceProvider) StartClient(
 			return nil, fmt.Errorf("client is already connected: %s [%s]", client.GetName(), clientID)
 		}
 
-		oldTunnels := getTunnelsToReestablish(getRemotes(client.GetTunnels()), req.Remotes)
+		oldTunnels := getTunnelsToReestablish(withoutStoredTunnels(getRemotes(client.GetTunnels())), req.Remotes)
 
 		clientVersion, err := version.NewVersion(req.Version)
 		if err != nil {
@@ -386,6 +436,7 @@ func (s *ClientServiceProvider) StartClient(
 		} else {
 			clog.Infof("client %s (%s) version %s does not support 'tunnel_allowed' policies. Consider upgrading.", client.GetID(), client.GetName(), client.GetVersion())
 		}
+		oldTunnels = s.excludeUnresolvableTunnels(ctx, clog, clientID, oldTunnels)
 
 		clog.Infof("tunnels to create %d: %v", len(req.Remotes), req.Remotes)
 		if len(oldTunnels) > 0 {
@@ -419,6 +470,10 @@ func (s *ClientServiceProvider) StartClient(
 		return nil, err
 	}
 
+	if s.storedTunnels != nil && !client.IsPaused() {
+		go s.startStoredTunnelsOnConnect(client)
+	}
+
 	// TODO: (rs): should we keep this?
 	totalClients := repo.GetAllActiveClients()
 	s.log().Debugf("total clients = %d (last: %s)", len(totalClients), client.GetName())
@@ -515,9 +570,14 @@ func (s *ClientServiceProvider) startClientTunnels(client *clientdata.Client, re
 
 	tunnels := make([]*clienttunnel.Tunnel, 0, len(remotes))
 	for _, remote := range remotes {
-		if !remote.IsLocalSpecified() {
+		if remote.ListensOnClient() {
+			// the local port is on the client
+			if !remote.IsLocalSpecified() {
+				return nil, apiErrors.NewAPIError(http.StatusBadRequest, "", "Tunnels listening on the client require a local port.", nil)
+			}
+		} else if !remote.IsLocalSpecified() {
 			clog.Debugf("no local specified")
-			port, err := s.portDistributor.GetRandomPort(remote.Protocol)
+			port, err := s.portDistributor.GetRandomPort(remote.ListenProtocol())
 			if err != nil {
 				return nil, err
 			}
@@ -527,7 +587,7 @@ func (s *ClientServiceProvider) startClientTunnels(client *clientdata.Client, re
 			clog.Debugf("using random port %s", remote.LocalPort)
 		} else {
 			clog.Debugf("checking local port %s", remote.LocalPort)
-			if err := s.checkLocalPort(remote.Protocol, remote.LocalPort); err != nil {
+			if err := s.checkLocalPort(remote.ListenProtocol(), remote.LocalPort); err != nil {
 				return nil, err
 			}
 		}
@@ -577,6 +637,10 @@ func (s *ClientServiceProvider) checkLocalPort(protocol, port string) error {
 
 func (s *ClientServiceProvider) Terminate(client *clientdata.Client) error {
 	s.log().Infof("terminating client: %s: %s", client.GetID(), client.GetName())
+	// tunnels end with the connection, they are started again when the client reconnects
+	for _, t := range client.GetTunnels() {
+		s.auditTunnelClosed(client, t)
+	}
 	keepDisconnectedClientsDuration := s.repo.GetKeepDisconnectedClients()
 	if keepDisconnectedClientsDuration != nil && *keepDisconnectedClientsDuration == 0 {
 		return s.repo.Delete(client)
@@ -746,6 +810,26 @@ func (s *ClientServiceProvider) getExistingClientByID(clientID string) (*clientd
 func (s *ClientServiceProvider) excludeNotAllowedTunnels(clog *logger.Logger, tunnels []*models.Remote, conn ssh.Conn) ([]*models.Remote, error) {
 	filtered := make([]*models.Remote, 0, len(tunnels))
 	for _, t := range tunnels {
+		if t.TargetClientID != "" {
+			if !s.isClientToClientTunnelAllowed(clog, t, conn) {
+				continue
+			}
+			filtered = append(filtered, t)
+			continue
+		}
+		if t.Reverse {
+			if !s.IsReverseTunnelAllowed(t.Remote()) {
+				clog.Infof("Reverse tunnel %q is no longer allowed by server config, removing.", t)
+				continue
+			}
+			filtered = append(filtered, t)
+			continue
+		}
+		if t.Protocol == models.ProtocolSOCKS5 {
+			// the client checks each destination of socks5 tunnels
+			filtered = append(filtered, t)
+			continue
+		}
 		allowed, err := clienttunnel.IsAllowed(t.Remote(), conn, s.log())
 		if err != nil {
 			if strings.Contains(err.Error(), "unknown request") {
@@ -762,6 +846,44 @@ func (s *ClientServiceProvider) excludeNotAllowedTunnels(clog *logger.Logger, tu
 	return filtered, nil
 }
 
+// isClientToClientTunnelAllowed checks a client-to-client tunnel to be re-established, the target client must be
+// connected and both clients must allow it
+func (s *ClientServiceProvider) isClientToClientTunnelAllowed(clog *logger.Logger, t *models.Remote, conn ssh.Conn) bool {
+	target, err := s.GetActiveByID(t.TargetClientID)
+	if err != nil || target == nil || !target.IsConnectedHere() {
+		clog.Infof("Tunnel %q not re-established, target client is not connected.", t)
+		return false
+	}
+
+	err = CheckClientToClientTunnelAllowed(t, conn, target.GetConnection(), s.log())
+	if err != nil {
+		clog.Infof("Tunnel %q not re-established: %v", t, err)
+		return false
+	}
+	return true
+}
+
+// CheckClientToClientTunnelAllowed returns an error unless the tunnel_allowed config of the listening client allows its
+// local address and the config of the target client allows the remote address
+func CheckClientToClientTunnelAllowed(remote *models.Remote, conn, targetConn ssh.Conn, l *logger.Logger) error {
+	allowed, err := clienttunnel.IsAllowed(remote.Local(), conn, l)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apiErrors.NewAPIError(http.StatusBadRequest, "", "Tunnel local address is not allowed by client configuration.", nil)
+	}
+
+	allowed, err = clienttunnel.IsAllowed(remote.Remote(), targetConn, l)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apiErrors.NewAPIError(http.StatusBadRequest, "", "Tunnel destination is not allowed by target client configuration.", nil)
+	}
+	return nil
+}
+
 // TODO: (rs): can this move to the tunnel package?
 func (s *ClientServiceProvider) FindTunnelByRemote(c *clientdata.Client, r *models.Remote) *clienttunnel.Tunnel {
 	for _, tunnel := range c.GetTunnels() {
@@ -886,9 +1008,13 @@ func (s *ClientServiceProvider) startCaddyDownstreamProxy(
 }
 
 func (s *ClientServiceProvider) startRegularTunnel(ctx context.Context, client *clientdata.Client, remote *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error) {
+	if remote.TargetClientID != "" {
+		return s.startClientToClientTunnel(ctx, client, remote)
+	}
+
 	tunnelID := client.NewTunnelID()
 
-	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl)
+	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl, s.tunnelRecorder(client, tunnelID, remote), s.clientBandwidth(client))
 	if err != nil {
 		return nil, err
 	}
@@ -901,6 +1027,105 @@ func (s *ClientServiceProvider) startRegularTunnel(ctx context.Context, client *
 	return tunnel, nil
 }
 
+func (s *ClientServiceProvider) startClientToClientTunnel(ctx context.Context, client *clientdata.Client, remote *models.Remote) (*clienttunnel.Tunnel, error) {
+	target, err := s.GetActiveByID(remote.TargetClientID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || !target.IsConnectedHere() {
+		return nil, fmt.Errorf("target client %s is not connected", remote.TargetClientID)
+	}
+
+	tunnel, err := clienttunnel.NewClientToClientTunnel(client.Log(), client.GetConnection(), client.NewTunnelID(), *remote, target.GetConnection(), s.clientBandwidth(client))
+	if err != nil {
+		return nil, err
+	}
+
+	err = tunnel.Start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.terminateTunnelOnTargetDisconnect(ctx, tunnel, client, target)
+
+	return tunnel, nil
+}
+
+// terminateTunnelOnTargetDisconnect ends a client-to-client tunnel together with the connection of the target client
+func (s *ClientServiceProvider) terminateTunnelOnTargetDisconnect(ctx context.Context, t *clienttunnel.Tunnel, c, target *clientdata.Client) {
+	select {
+	case <-ctx.Done():
+	case <-target.GetContext().Done():
+		c.Log().Infof("Terminating tunnel %s, target client %s disconnected", t.ID, target.GetID())
+		if err := s.TerminateTunnel(c, t, true); err != nil {
+			c.Log().Errorf("Failed to terminate tunnel %s: %v", t.ID, err)
+		}
+	}
+}
+
+// tunnelRecorder returns nil when the tunnel is not to be recorded
+func (s *ClientServiceProvider) tunnelRecorder(client *clientdata.Client, tunnelID string, remote *models.Remote) clienttunnel.ConnRecorder {
+	if !s.recordings.ShouldRecord(remote.Record) {
+		return nil
+	}
+	return s.recordings.NewTunnelRecorder(client.GetID(), tunnelID, *remote)
+}
+
+// clientBandwidth returns the limiters shared by all tunnels of the client. The limits are the lowest of the groups the
+// client belongs to, they are updated whenever a tunnel of the client is started.
+func (s *ClientServiceProvider) clientBandwidth(client *clientdata.Client) *clienttunnel.Bandwidth {
+	if s.clientGroups == nil {
+		return nil
+	}
+	groups, err := s.clientGroups.GetAll(client.GetContext())
+	if err != nil {
+		client.Log().Errorf("Failed to get client groups, bandwidth limits are not updated: %v", err)
+	}
+
+	var up, down int64
+	for _, group := range groups {
+		if client.BelongsTo(group) {
+			up = lowestBandwidthLimit(up, group.BandwidthLimitUp)
+			down = lowestBandwidthLimit(down, group.BandwidthLimitDown)
+		}
+	}
+
+	s.bandwidthsMu.Lock()
+	defer s.bandwidthsMu.Unlock()
+
+	bandwidth, ok := s.bandwidths[client.GetID()]
+	if !ok {
+		bandwidth = &clienttunnel.Bandwidth{
+			Up:   &clienttunnel.RateLimiter{},
+			Down: &clienttunnel.RateLimiter{},
+		}
+		s.bandwidths[client.GetID()] = bandwidth
+	}
+	if err == nil {
+		bandwidth.Up.SetRate(up)
+		bandwidth.Down.SetRate(down)
+	}
+	return bandwidth
+}
+
+// lowestBandwidthLimit returns the lower limit, 0 is unlimited
+func lowestBandwidthLimit(a, b int64) int64 {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// auditTunnelClosed saves the traffic totals of a tunnel when it's closed
+func (s *ClientServiceProvider) auditTunnelClosed(c *clientdata.Client, t *clienttunnel.Tunnel) {
+	s.auditLog.Entry(auditlog.ApplicationClientTunnel, auditlog.ActionClose).
+		WithClient(c).
+		WithID(t.ID).
+		WithRequest(t.Remote).
+		WithResponse(t.Traffic.Stats()).
+		Save()
+}
+
 func (s *ClientServiceProvider) startTunnelWithProxy(
 	ctx context.Context,
 	client *clientdata.Client,
@@ -935,7 +1160,7 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 	tunnelID := client.NewTunnelID()
 
 	// original tunnel will use the reconfigured original remote
-	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl)
+	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl, s.tunnelRecorder(client, tunnelID, remote), s.clientBandwidth(client))
 	if err != nil {
 		return nil, err
 	}
@@ -1014,6 +1239,7 @@ func (s *ClientServiceProvider) cleanupAfterAutoClose(c *clientdata.Client, t *c
 	}
 
 	c.RemoveTunnelByID(t.ID)
+	s.auditTunnelClosed(c, t)
 
 	err := s.repo.Save(c)
 	if err != nil {
@@ -1046,6 +1272,7 @@ func (s *ClientServiceProvider) TerminateTunnel(c *clientdata.Client, t *clientt
 	}
 
 	c.RemoveTunnelByID(t.ID)
+	s.auditTunnelClosed(c, t)
 
 	err = s.repo.Save(c)
 	if err != nil {
@@ -157,7 +157,7 @@ func TestStartClientConcurrency(t *testing.T) {
 		mockConns = append(mockConns, mockConn)
 	}
 
-	repo, err := InitClientRepository(context.Background(), clientDB, nil, testLog)
+	repo, err := InitClientRepository(context.Background(), clientDB, nil, nil, testLog)
 	require.NoError(t, err)
 
 	cs := &ClientServiceProvider{
@@ -993,3 +993,48 @@ func TestShouldStartTunnelsWithSubdomains(t *testing.T) {
 		})
 	}
 }
+
+type clientGroupsFake struct {
+	cgroups.ClientGroupProvider
+	groups []*cgroups.ClientGroup
+}
+
+func (p *clientGroupsFake) GetAll(context.Context) ([]*cgroups.ClientGroup, error) {
+	return p.groups, nil
+}
+
+func TestClientBandwidth(t *testing.T) {
+	c1 := New(t).Logger(testLog).Build()
+	c2 := New(t).Logger(testLog).Build()
+	groups := &clientGroupsFake{
+		groups: []*cgroups.ClientGroup{
+			{
+				ID:                 "1",
+				Params:             &cgroups.ClientParams{ClientID: &cgroups.ParamValues{cgroups.Param(c1.GetID())}},
+				BandwidthLimitUp:   2000,
+				BandwidthLimitDown: 1000,
+			},
+			{
+				ID:               "2",
+				Params:           &cgroups.ClientParams{ClientID: &cgroups.ParamValues{cgroups.Param(c1.GetID())}},
+				BandwidthLimitUp: 500,
+			},
+		},
+	}
+	clientService := NewClientService(nil, nil, NewClientRepository([]*clientdata.Client{c1, c2}, nil, testLog), testLog, nil)
+	assert.Nil(t, clientService.clientBandwidth(c1))
+
+	clientService.SetClientGroupProvider(groups)
+	b1 := clientService.clientBandwidth(c1)
+	assert.Equal(t, int64(500), b1.Up.Rate())
+	assert.Equal(t, int64(1000), b1.Down.Rate())
+
+	b2 := clientService.clientBandwidth(c2)
+	assert.Equal(t, int64(0), b2.Up.Rate())
+	assert.Equal(t, int64(0), b2.Down.Rate())
+
+	// limits are shared by the tunnels of the client and updated with the groups
+	groups.groups[1].BandwidthLimitUp = 0
+	assert.Same(t, b1, clientService.clientBandwidth(c1))
+	assert.Equal(t, int64(2000), b1.Up.Rate())
+}
@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/hashicorp/go-version"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/openrport/openrport/server/api/users"
@@ -177,6 +178,23 @@ func (c *Client) GetVersion() (version string) {
 	return c.Version
 }
 
+// vaultEnvMinVersion is the first client version passing the values of vault placeholders as environment variables
+var vaultEnvMinVersion = version.Must(version.NewVersion("1.0.0"))
+
+// SupportsVaultEnv returns true if the client runs commands and scripts with the values of vault placeholders passed as
+// environment variables. Older clients would run them with the placeholders as they are.
+func (c *Client) SupportsVaultEnv() bool {
+	v := c.GetVersion()
+	if v == chshare.SourceVersion {
+		return true
+	}
+	clientVersion, err := version.NewVersion(v)
+	if err != nil {
+		return false
+	}
+	return clientVersion.GreaterThanOrEqual(vaultEnvMinVersion)
+}
+
 // TODO: (rs): these extra getters probably aren't required. talk to KK about options.
 
 func (c *Client) GetAddress() (address string) {
@@ -378,6 +396,28 @@ func (c *Client) GetFileReceptionConfig() (fileReceptionConfig *clientconfig.Fil
 	return &c.ClientConfiguration.FileReceptionConfig
 }
 
+func (c *Client) GetFileDownloadConfig() *clientconfig.FileDownloadConfig {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	if c.ClientConfiguration == nil {
+		return nil
+	}
+
+	return &c.ClientConfiguration.FileDownloadConfig
+}
+
+func (c *Client) GetFileSystemConfig() *clientconfig.FileSystemConfig {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	if c.ClientConfiguration == nil {
+		return nil
+	}
+
+	return &c.ClientConfiguration.FileSystemConfig
+}
+
 // test only
 func (c *Client) SetID(id string) {
 	c.flock.Lock()
@@ -488,6 +528,12 @@ func (c *Client) IsConnected() bool {
 	return c.GetDisconnectedAt() == nil
 }
 
+// IsConnectedHere returns true if the client holds its connection on this server. In a high availability cluster
+// the clients connected to other nodes are synced from the database without a connection.
+func (c *Client) IsConnectedHere() bool {
+	return c.IsConnected() && c.GetConnection() != nil
+}
+
 func (c *Client) SetConnected() {
 	c.Log().Debugf("%s: set to connected at %s", c.GetID(), time.Now())
 	c.SetDisconnectedAt(nil)
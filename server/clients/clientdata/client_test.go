@@ -436,3 +436,24 @@ func TestToCalculatedWhenDisconnected(t *testing.T) {
 	assert.Equal(t, client, calculated.Client)
 	assert.Equal(t, "disconnected", string(calculated.ConnectionState))
 }
+
+func TestSupportsVaultEnv(t *testing.T) {
+	testCases := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "0.9.13", expected: false},
+		{version: "1.0.0-rc1", expected: false},
+		{version: "1.0.0", expected: true},
+		{version: "1.2.3", expected: true},
+		{version: "0.0.0-src", expected: true},
+		{version: "unknown", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			client := &Client{Version: tc.version}
+			assert.Equal(t, tc.expected, client.SupportsVaultEnv())
+		})
+	}
+}
@@ -2,6 +2,8 @@ package clienttunnel
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -20,6 +22,11 @@ type TunnelProtocol interface {
 	SetACL(*TunnelACL)
 }
 
+// ConnRecorder records the traffic of tunnel connections
+type ConnRecorder interface {
+	Record(conn io.ReadWriteCloser, sourceAddr string) io.ReadWriteCloser
+}
+
 type MultiProtocolTunnel struct {
 	Protocols []TunnelProtocol
 }
@@ -71,24 +78,35 @@ type Tunnel struct {
 
 	TunnelProtocol      `json:"-"`
 	InternalTunnelProxy *InternalTunnelProxy `json:"-"`
+	Traffic             *Traffic             `json:"-"`
 	CreatedAt           time.Time            `json:"created_at"`
 }
 
-func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL) (*Tunnel, error) {
+// NewTunnel creates a new tunnel, recorder is optional and only used for tcp connections. bandwidth holds the limits
+// shared by all tunnels of the client, it's optional.
+func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL, recorder ConnRecorder, bandwidth *Bandwidth) (*Tunnel, error) {
 	logger = logger.Fork("tunnel#%s:%s", id, remote)
 	logger.Debugf("new tunnel with remote = %#v", remote)
 
+	traffic := NewTraffic(remote, bandwidth)
 	var tunnelProtocol TunnelProtocol
-	switch remote.Protocol {
-	case models.ProtocolUDP:
-		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl)
-	case models.ProtocolTCP:
-		tunnelProtocol = newTunnelTCP(logger, ssh, remote, acl)
-	case models.ProtocolTCPUDP:
+	switch {
+	case remote.Reverse:
+		if remote.Protocol != models.ProtocolTCP {
+			return nil, errors.Errorf("unsupported protocol %q for reverse tunnels", remote.Protocol)
+		}
+		tunnelProtocol = newTunnelReverse(logger, ssh, id, remote, traffic)
+	case remote.Protocol == models.ProtocolUDP:
+		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl, traffic)
+	case remote.Protocol == models.ProtocolTCP:
+		tunnelProtocol = newTunnelTCP(logger, ssh, remote, acl, recorder, traffic)
+	case remote.Protocol == models.ProtocolSOCKS5:
+		tunnelProtocol = newTunnelSOCKS5(logger, ssh, remote, acl, traffic)
+	case remote.Protocol == models.ProtocolTCPUDP:
 		tunnelProtocol = &MultiProtocolTunnel{
 			Protocols: []TunnelProtocol{
-				newTunnelTCP(logger, ssh, remote, acl),
-				newTunnelUDP(logger, ssh, remote, acl),
+				newTunnelTCP(logger, ssh, remote, acl, recorder, traffic),
+				newTunnelUDP(logger, ssh, remote, acl, traffic),
 			},
 		}
 	default:
@@ -99,6 +117,42 @@ func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Rem
 		Remote:         remote,
 		ID:             id,
 		TunnelProtocol: tunnelProtocol,
+		Traffic:        traffic,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// NewClientToClientTunnel creates a new tunnel listening on the client of ssh, connections are forwarded to the
+// remote address through the target client
+func NewClientToClientTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, target ssh.Conn, bandwidth *Bandwidth) (*Tunnel, error) {
+	logger = logger.Fork("tunnel#%s:%s", id, remote)
+	logger.Debugf("new client-to-client tunnel with remote = %#v", remote)
+
+	if remote.Protocol != models.ProtocolTCP {
+		return nil, errors.Errorf("unsupported protocol %q for client-to-client tunnels", remote.Protocol)
+	}
+
+	traffic := NewTraffic(remote, bandwidth)
+	return &Tunnel{
+		Remote:         remote,
+		ID:             id,
+		TunnelProtocol: newTunnelClientToClient(logger, ssh, id, remote, target, traffic),
+		Traffic:        traffic,
 		CreatedAt:      time.Now(),
 	}, nil
 }
+
+// MarshalJSON adds the open connections of tunnels without a fixed remote
+func (t *Tunnel) MarshalJSON() ([]byte, error) {
+	type tunnel Tunnel
+	res := struct {
+		*tunnel
+		Connections []Connection `json:"connections,omitempty"`
+	}{
+		tunnel: (*tunnel)(t),
+	}
+	if lister, ok := t.TunnelProtocol.(ConnectionsLister); ok {
+		res.Connections = lister.Connections()
+	}
+	return json.Marshal(res)
+}
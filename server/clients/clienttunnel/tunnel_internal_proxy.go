@@ -37,6 +37,7 @@ type InternalTunnelProxyConfig struct {
 	KeyFile      string   `mapstructure:"tunnel_proxy_key_file"`
 	EnableAcme   bool     `mapstructure:"tunnel_enable_acme"`
 	NovncRoot    string   `mapstructure:"novnc_root"`
+	XtermjsRoot  string   `mapstructure:"xtermjs_root"`
 	TLSMin       string   `mapstructure:"tls_min"`
 	GuacdAddress string   `mapstructure:"guacd_address"`
 	CORS         []string `mapstructure:"tunnel_cors"`
@@ -258,6 +259,23 @@ func (tp *InternalTunnelProxy) sendHTML(w http.ResponseWriter, statusCode int, m
 	_, _ = w.Write([]byte(m))
 }
 
+// basicAuth middleware to require the auth_user and auth_password of the tunnel
+func (tp *InternalTunnelProxy) basicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote := tp.Tunnel.Remote
+		if remote.AuthUser != "" && remote.AuthPassword != "" {
+			wantPassword, resolved := remote.BasicAuthPassword()
+			user, password, ok := r.BasicAuth()
+			if !ok || !resolved || user != remote.AuthUser || password != wantPassword {
+				w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // noCache middleware to disable caching
 func (tp *InternalTunnelProxy) noCache(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
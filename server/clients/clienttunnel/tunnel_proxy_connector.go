@@ -17,6 +17,8 @@ func NewTunnelProxyConnector(tp *InternalTunnelProxy) TunnelProxyConnector {
 		return NewTunnelConnectorVNC(tp)
 	case "rdp":
 		return NewTunnelConnectorRDP(tp)
+	case "ssh":
+		return NewTunnelConnectorSSH(tp)
 	}
 
 	return nil
@@ -0,0 +1,278 @@
+package clienttunnel
+
+import (
+	_ "embed" //to embed the ssh terminal template
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshMessageConnect = "connect"
+	sshMessageResize  = "resize"
+	sshTerminalType   = "xterm-256color"
+)
+
+// sshDialTimeout limits the time to connect and log in to the ssh server behind the tunnel
+var sshDialTimeout = 15 * time.Second
+
+//go:embed ssh/index.html
+var sshIndexHTML string
+
+// sshUpgrader only accepts websockets opened by the page of the proxy, other sites must not be able to use the
+// session of the browser to log in. gorilla/websocket checks the origin if CheckOrigin isn't set.
+var sshUpgrader = websocket.Upgrader{
+	ReadBufferSize:  websocketBufferSize,
+	WriteBufferSize: websocketBufferSize,
+}
+
+// sshMessage is a control message sent by the browser as websocket text message, the terminal input is sent as binary
+// message. The first message connects, the credentials are ignored if the tunnel has credentials from the vault.
+type sshMessage struct {
+	Type       string `json:"type"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key"`
+	Cols       int    `json:"cols"`
+	Rows       int    `json:"rows"`
+}
+
+// TunnelProxyConnectorSSH serves a browser terminal using xterm.js and logs in to the ssh server on the tunnel
+// endpoint from the server side
+type TunnelProxyConnectorSSH struct {
+	tunnelProxy *InternalTunnelProxy
+}
+
+func NewTunnelConnectorSSH(tp *InternalTunnelProxy) *TunnelProxyConnectorSSH {
+	return &TunnelProxyConnectorSSH{tunnelProxy: tp}
+}
+
+// InitRouter called when tunnel proxy is started
+func (tc *TunnelProxyConnectorSSH) InitRouter(router *mux.Router) *mux.Router {
+	router.Use(tc.tunnelProxy.noCache)
+	router.Use(tc.tunnelProxy.basicAuth)
+
+	router.HandleFunc("/ssh", tc.serveSSH)
+	router.HandleFunc("/", tc.serveIndex)
+
+	//handle xterm.js from local filesystem
+	if tc.tunnelProxy.Config.XtermjsRoot != "" {
+		tc.tunnelProxy.Logger.Infof("serving xterm.js from: %s", tc.tunnelProxy.Config.XtermjsRoot)
+		router.PathPrefix("/xterm/").Handler(http.StripPrefix("/xterm/", http.FileServer(http.Dir(tc.tunnelProxy.Config.XtermjsRoot))))
+	}
+
+	return router
+}
+
+func (tc *TunnelProxyConnectorSSH) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if tc.tunnelProxy.Config.XtermjsRoot == "" {
+		tc.tunnelProxy.sendHTML(w, http.StatusBadRequest, "No xterm.js configured.")
+		return
+	}
+
+	user, _, fromVault := tc.tunnelProxy.Tunnel.Remote.SSHCredentials()
+	templateData := map[string]interface{}{
+		"username":  user,
+		"fromVault": fromVault,
+	}
+
+	tc.tunnelProxy.serveTemplate(w, r, sshIndexHTML, templateData)
+}
+
+func (tc *TunnelProxyConnectorSSH) serveSSH(w http.ResponseWriter, r *http.Request) {
+	tc.tunnelProxy.Logger.Infof("TunnelProxyConnectorSSH: connect to tunnel: %s", tc.tunnelProxy.TunnelAddr())
+
+	wsConn, err := sshUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		tc.tunnelProxy.Logger.Errorf("failed to upgrade websocket request: %v", err)
+		return
+	}
+	defer wsConn.Close()
+	terminal := &websocketWriter{conn: wsConn}
+
+	var msg sshMessage
+	if err := wsConn.ReadJSON(&msg); err != nil || msg.Type != sshMessageConnect {
+		tc.tunnelProxy.Logger.Errorf("failed to read ssh connect message: %v", err)
+		return
+	}
+
+	client, fingerprint, err := tc.dial(msg)
+	if err != nil {
+		tc.tunnelProxy.Logger.Infof("ssh login to %s failed: %v", tc.tunnelProxy.TunnelAddr(), err)
+		terminal.Printf("SSH login failed: %v\r\n", err)
+		return
+	}
+	defer client.Close()
+	terminal.Printf("Connected to %s, host key %s\r\n", tc.tunnelProxy.TunnelAddr(), fingerprint)
+
+	session, err := client.NewSession()
+	if err != nil {
+		terminal.Printf("Failed to open session: %v\r\n", err)
+		return
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		terminal.Printf("Failed to open session: %v\r\n", err)
+		return
+	}
+	session.Stdout = terminal
+	session.Stderr = terminal
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(sshTerminalType, msg.Rows, msg.Cols, modes); err != nil {
+		terminal.Printf("Failed to request terminal: %v\r\n", err)
+		return
+	}
+	if err := session.Shell(); err != nil {
+		terminal.Printf("Failed to start shell: %v\r\n", err)
+		return
+	}
+
+	// closing the websocket ends the loop below when the shell exits
+	go func() {
+		_ = session.Wait()
+		terminal.Close()
+	}()
+
+	for {
+		msgType, data, err := wsConn.ReadMessage()
+		if err != nil {
+			tc.tunnelProxy.Logger.Debugf("ssh session to %s closed: %v", tc.tunnelProxy.TunnelAddr(), err)
+			return
+		}
+		if msgType == websocket.BinaryMessage {
+			if _, err := stdin.Write(data); err != nil {
+				return
+			}
+			continue
+		}
+
+		var ctrl sshMessage
+		if err := json.Unmarshal(data, &ctrl); err != nil {
+			tc.tunnelProxy.Logger.Debugf("invalid ssh control message: %v", err)
+			continue
+		}
+		if ctrl.Type == sshMessageResize {
+			_ = session.WindowChange(ctrl.Rows, ctrl.Cols)
+		}
+	}
+}
+
+// dial logs in to the ssh server on the tunnel endpoint, it returns the fingerprint of its host key. The host key must
+// match the one pinned with the tunnel, without one the user checks the fingerprint. Credentials from the vault are
+// only sent to a pinned host key.
+func (tc *TunnelProxyConnectorSSH) dial(msg sshMessage) (*ssh.Client, string, error) {
+	pinnedHostKey := tc.tunnelProxy.Tunnel.Remote.SSHHostKey
+	user := msg.Username
+	auth, err := sshAuthMethods(msg.Password, msg.PrivateKey)
+	if vaultUser, secret, ok := tc.tunnelProxy.Tunnel.Remote.SSHCredentials(); ok {
+		if pinnedHostKey == "" {
+			return nil, "", errors.New("credentials from the vault require a pinned host key")
+		}
+		user = vaultUser
+		auth, err = sshAuthMethodsFromSecret(secret)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var fingerprint string
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: auth,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			fingerprint = ssh.FingerprintSHA256(key)
+			if pinnedHostKey != "" && fingerprint != pinnedHostKey {
+				return fmt.Errorf("host key %s doesn't match the pinned host key %s", fingerprint, pinnedHostKey)
+			}
+			return nil
+		},
+		Timeout: sshDialTimeout,
+	}
+	client, err := ssh.Dial("tcp", tc.tunnelProxy.TunnelAddr(), config)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, fingerprint, nil
+}
+
+// sshAuthMethods returns the auth methods for the credentials entered by the user, the password is the passphrase of
+// the private key if both are given
+func sshAuthMethods(password, privateKey string) ([]ssh.AuthMethod, error) {
+	if privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		var missingErr *ssh.PassphraseMissingError
+		if errors.As(err, &missingErr) && password != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(password))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+	if password == "" {
+		return nil, errors.New("password or private key required")
+	}
+	return []ssh.AuthMethod{
+		ssh.Password(password),
+		ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range questions {
+				answers[i] = password
+			}
+			return answers, nil
+		}),
+	}, nil
+}
+
+// sshAuthMethodsFromSecret returns the auth methods for a vault value, which is a private key or a password
+func sshAuthMethodsFromSecret(secret string) ([]ssh.AuthMethod, error) {
+	if _, err := ssh.ParsePrivateKey([]byte(secret)); err == nil {
+		return sshAuthMethods("", secret)
+	}
+	return sshAuthMethods(secret, "")
+}
+
+// websocketWriter sends the terminal output as binary messages, stdout and stderr of a session are written concurrently
+type websocketWriter struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (w *websocketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close tells the browser the session ended and closes the websocket
+func (w *websocketWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_ = w.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session closed"))
+	w.conn.Close()
+}
+
+// Printf writes a status line to the terminal
+func (w *websocketWriter) Printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(w, format, args...)
+}
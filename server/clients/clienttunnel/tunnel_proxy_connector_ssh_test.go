@@ -0,0 +1,186 @@
+package clienttunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// startEchoSSHServer accepts the password "secret" for any user and echoes the input of a shell, it returns the
+// fingerprint of its host key
+func startEchoSSHServer(t *testing.T) (net.Listener, string) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "secret" {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newCh := range chans {
+					ch, chReqs, err := newCh.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range chReqs {
+							_ = req.Reply(req.Type == "pty-req" || req.Type == "shell" || req.Type == "window-change", nil)
+						}
+					}()
+					go func() {
+						defer ch.Close()
+						_, _ = io.Copy(ch, ch)
+					}()
+				}
+			}()
+		}
+	}()
+	return l, ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func TestTunnelProxyConnectorSSH(t *testing.T) {
+	sshServer, hostKey := startEchoSSHServer(t)
+	defer sshServer.Close()
+	tunnelHost, tunnelPort, err := net.SplitHostPort(sshServer.Addr().String())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		remote     models.Remote
+		basicAuth  bool
+		origin     string
+		connect    sshMessage
+		wantOutput string
+		wantStatus int
+	}{
+		{
+			name:       "credentials from user",
+			connect:    sshMessage{Type: sshMessageConnect, Username: "root", Password: "secret", Cols: 80, Rows: 24},
+			wantOutput: "ping",
+		},
+		{
+			name:       "credentials from vault",
+			remote:     models.Remote{SSHUser: "root", SSHPassword: `{{ vault "root" }}`, ResolvedSSHPassword: "secret", SSHHostKey: hostKey},
+			connect:    sshMessage{Type: sshMessageConnect, Cols: 80, Rows: 24},
+			wantOutput: "ping",
+		},
+		{
+			name:       "credentials from vault without pinned host key",
+			remote:     models.Remote{SSHUser: "root", SSHPassword: `{{ vault "root" }}`, ResolvedSSHPassword: "secret"},
+			connect:    sshMessage{Type: sshMessageConnect, Cols: 80, Rows: 24},
+			wantOutput: "credentials from the vault require a pinned host key",
+		},
+		{
+			name:       "pinned host key",
+			remote:     models.Remote{SSHHostKey: hostKey},
+			connect:    sshMessage{Type: sshMessageConnect, Username: "root", Password: "secret", Cols: 80, Rows: 24},
+			wantOutput: "ping",
+		},
+		{
+			name:       "other host key",
+			remote:     models.Remote{SSHHostKey: "SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU"},
+			connect:    sshMessage{Type: sshMessageConnect, Username: "root", Password: "secret", Cols: 80, Rows: 24},
+			wantOutput: "doesn't match the pinned host key",
+		},
+		{
+			name:       "other origin",
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wrong password",
+			connect:    sshMessage{Type: sshMessageConnect, Username: "root", Password: "wrong", Cols: 80, Rows: 24},
+			wantOutput: "SSH login failed",
+		},
+		{
+			name:       "basic auth required",
+			remote:     models.Remote{AuthUser: "admin", AuthPassword: "foo"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "with basic auth",
+			remote:     models.Remote{AuthUser: "admin", AuthPassword: "foo"},
+			basicAuth:  true,
+			connect:    sshMessage{Type: sshMessageConnect, Username: "root", Password: "secret", Cols: 80, Rows: 24},
+			wantOutput: "ping",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := "ssh"
+			tc.remote.Scheme = &scheme
+			tp := &InternalTunnelProxy{
+				Tunnel:     &Tunnel{Remote: tc.remote},
+				Logger:     logger.NewLogger("ssh-proxy-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+				Config:     &InternalTunnelProxyConfig{},
+				TunnelHost: tunnelHost,
+				TunnelPort: tunnelPort,
+			}
+			server := httptest.NewServer(NewTunnelProxyConnector(tp).InitRouter(mux.NewRouter()))
+			defer server.Close()
+
+			header := http.Header{}
+			if tc.basicAuth {
+				req := &http.Request{Header: header}
+				req.SetBasicAuth("admin", "foo")
+			}
+			if tc.origin != "" {
+				header.Set("Origin", tc.origin)
+			}
+			wsConn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ssh", header)
+			if tc.wantStatus != 0 {
+				require.Error(t, err)
+				assert.Equal(t, tc.wantStatus, resp.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			defer wsConn.Close()
+
+			require.NoError(t, wsConn.WriteJSON(tc.connect))
+			require.NoError(t, wsConn.WriteMessage(websocket.BinaryMessage, []byte("ping")))
+
+			var output string
+			for !strings.Contains(output, tc.wantOutput) {
+				_, data, err := wsConn.ReadMessage()
+				require.NoError(t, err, "output so far: %q", output)
+				output += string(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package clienttunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// reverseDialTimeout limits the time to connect to the server-side destination of a reverse tunnel
+var reverseDialTimeout = 10 * time.Second
+
+// ChannelHandler is implemented by tunnels whose connections are initiated by the client
+type ChannelHandler interface {
+	HandleChannel(ch ssh.NewChannel)
+}
+
+// tunnelReverse lets the client listen on the local address of the remote, the client opens a channel for each
+// connection which is connected to the connection returned by dial.
+type tunnelReverse struct {
+	// Declare 64-bit integer before 32-bit for alignment when compiling Go on 32-bit ARM platforms
+	lastConnClose int64 // time stored as int64 so it can be used with atomic
+	*logger.Logger
+	models.Remote
+	id      string
+	sshConn ssh.Conn
+	dial    func() (io.ReadWriteCloser, error)
+	traffic *Traffic
+
+	mtx                       sync.Mutex
+	ctx                       context.Context
+	stopFn                    func()
+	connectionIDAutoIncrement int32
+	connCount                 int32
+	wg                        sync.WaitGroup
+}
+
+// newTunnelReverse returns a tunnel connecting to the remote address on the server side
+func newTunnelReverse(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, traffic *Traffic) *tunnelReverse {
+	t := &tunnelReverse{
+		Logger:  logger,
+		Remote:  remote,
+		id:      id,
+		sshConn: ssh,
+		traffic: traffic,
+	}
+	t.dial = func() (io.ReadWriteCloser, error) {
+		return net.DialTimeout("tcp", t.Remote.Remote(), reverseDialTimeout)
+	}
+	return t
+}
+
+// newTunnelClientToClient returns a tunnel connecting to the remote address through the target client, which checks
+// the remote address against its tunnel_allowed config
+func newTunnelClientToClient(logger *logger.Logger, sshConn ssh.Conn, id string, remote models.Remote, target ssh.Conn, traffic *Traffic) *tunnelReverse {
+	t := &tunnelReverse{
+		Logger:  logger,
+		Remote:  remote,
+		id:      id,
+		sshConn: sshConn,
+		traffic: traffic,
+	}
+	t.dial = func() (io.ReadWriteCloser, error) {
+		ch, reqs, err := target.OpenChannel("rport", []byte(t.Remote.Remote()))
+		if err != nil {
+			return nil, err
+		}
+		go ssh.DiscardRequests(reqs)
+		return ch, nil
+	}
+	return t
+}
+
+func (t *tunnelReverse) Start(ctx context.Context) error {
+	t.Debugf("starting reverse tunnel, client listens on %s", t.Local())
+
+	req := &comm.StartReverseTunnelRequest{
+		ID:    t.id,
+		Local: t.Local(),
+	}
+	if err := comm.SendRequestAndGetResponse(t.sshConn, comm.RequestTypeStartReverseTunnel, req, nil, t.Logger); err != nil {
+		return fmt.Errorf("%s: %s", t.Logger.Prefix(), err)
+	}
+	atomic.StoreInt64(&t.lastConnClose, time.Now().Unix())
+
+	t.mtx.Lock()
+	t.ctx, t.stopFn = context.WithCancel(ctx)
+	t.mtx.Unlock()
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		<-t.ctx.Done()
+		t.stopListener()
+	}()
+	return nil
+}
+
+// stopListener asks the client to close the listener, it's not sent on closed connections
+func (t *tunnelReverse) stopListener() {
+	req := &comm.StopReverseTunnelRequest{
+		ID: t.id,
+	}
+	err := comm.SendRequestAndGetResponse(t.sshConn, comm.RequestTypeStopReverseTunnel, req, nil, t.Logger)
+	if err != nil {
+		t.Debugf("Failed to stop reverse tunnel on client: %v", err)
+	}
+}
+
+func (t *tunnelReverse) Terminate(force bool) error {
+	n := atomic.LoadInt32(&t.connCount)
+	if !force && n > 0 {
+		return fmt.Errorf("tunnel has %d active connection(s)", n)
+	}
+
+	t.mtx.Lock()
+	stopFn := t.stopFn
+	t.stopFn = nil
+	t.mtx.Unlock()
+	if stopFn == nil {
+		return nil
+	}
+
+	stopFn()
+	t.wg.Wait()
+	t.Infof("stopped")
+	return nil
+}
+
+func (t *tunnelReverse) LastActive() time.Time {
+	if atomic.LoadInt32(&t.connCount) > 0 {
+		return time.Now()
+	}
+	return time.Unix(atomic.LoadInt64(&t.lastConnClose), 0)
+}
+
+// SetACL is not supported, connections are accepted by the client
+func (t *tunnelReverse) SetACL(*TunnelACL) {}
+
+// HandleChannel connects a channel opened by the client to the remote address
+func (t *tunnelReverse) HandleChannel(ch ssh.NewChannel) {
+	t.mtx.Lock()
+	ctx := t.ctx
+	running := t.stopFn != nil
+	t.mtx.Unlock()
+	if !running {
+		_ = ch.Reject(ssh.ConnectionFailed, "tunnel is not running")
+		return
+	}
+
+	connID := atomic.AddInt32(&t.connectionIDAutoIncrement, 1)
+	l := t.Fork("conn#%d", connID)
+
+	dst, err := t.dial()
+	if err != nil {
+		l.Infof("Could not connect to %s: %v", t.Remote.Remote(), err)
+		reason := ssh.ConnectionFailed
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			reason = openErr.Reason
+		}
+		_ = ch.Reject(reason, err.Error())
+		return
+	}
+
+	src, reqs, err := ch.Accept()
+	if err != nil {
+		l.Debugf("Failed to accept channel: %v", err)
+		dst.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	// reading from the destination is the traffic sent to the client
+	dst = t.traffic.Conn(dst)
+
+	atomic.AddInt32(&t.connCount, 1)
+	t.wg.Add(1)
+	defer func() {
+		atomic.StoreInt64(&t.lastConnClose, time.Now().Unix())
+		atomic.AddInt32(&t.connCount, -1)
+		t.wg.Done()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	// link ctx to the connection
+	go func() {
+		select {
+		case <-ctx.Done():
+			src.Close()
+			dst.Close()
+		case <-done:
+		}
+	}()
+
+	l.Debugf("Open")
+	s, r := chshare.Pipe(src, dst)
+	l.Debugf("Close (sent %s received %s)", sizestr.ToString(s), sizestr.ToString(r))
+}
@@ -0,0 +1,162 @@
+package clienttunnel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+type requestsConnFake struct {
+	ssh.Conn
+	requests chan string
+}
+
+func (c *requestsConnFake) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	c.requests <- name + " " + string(payload)
+	return true, nil, nil
+}
+
+type newChannelFake struct {
+	ssh.NewChannel
+	extraData []byte
+	accepted  net.Conn
+	rejected  chan ssh.RejectionReason
+}
+
+func (c *newChannelFake) ExtraData() []byte {
+	return c.extraData
+}
+
+func (c *newChannelFake) Accept() (ssh.Channel, <-chan *ssh.Request, error) {
+	reqs := make(chan *ssh.Request)
+	close(reqs)
+	return &channelFake{Conn: c.accepted}, reqs, nil
+}
+
+func (c *newChannelFake) Reject(reason ssh.RejectionReason, message string) error {
+	c.rejected <- reason
+	return nil
+}
+
+func TestTunnelReverse(t *testing.T) {
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	remote, err := models.NewRemote("127.0.0.1:4000:" + echo.Addr().String())
+	require.NoError(t, err)
+	remote.Reverse = true
+
+	sshConn := &requestsConnFake{requests: make(chan string, 2)}
+	log := logger.NewLogger("reverse-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	tunnel, err := NewTunnel(log, sshConn, "1", *remote, nil, nil, nil)
+	require.NoError(t, err)
+
+	// connections are rejected before the tunnel is started
+	notStarted := &newChannelFake{rejected: make(chan ssh.RejectionReason, 1)}
+	tunnel.TunnelProtocol.(ChannelHandler).HandleChannel(notStarted)
+	assert.Equal(t, ssh.ConnectionFailed, <-notStarted.rejected)
+
+	require.NoError(t, tunnel.Start(context.Background()))
+	wantStart, err := json.Marshal(comm.StartReverseTunnelRequest{ID: "1", Local: "127.0.0.1:4000"})
+	require.NoError(t, err)
+	assert.Equal(t, comm.RequestTypeStartReverseTunnel+" "+string(wantStart), <-sshConn.requests)
+
+	server, client := net.Pipe()
+	ch := &newChannelFake{accepted: server, extraData: []byte("1")}
+	go tunnel.TunnelProtocol.(ChannelHandler).HandleChannel(ch)
+
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(client, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+	assert.Equal(t, TrafficStats{BytesUp: 4, BytesDown: 4, ActiveConnections: 1}, tunnel.Traffic.Stats())
+
+	assert.EqualError(t, tunnel.Terminate(false), "tunnel has 1 active connection(s)")
+	require.NoError(t, tunnel.Terminate(true))
+
+	wantStop, err := json.Marshal(comm.StopReverseTunnelRequest{ID: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, comm.RequestTypeStopReverseTunnel+" "+string(wantStop), <-sshConn.requests)
+	_, err = client.Read(buf)
+	assert.Error(t, err)
+}
+
+func TestTunnelClientToClient(t *testing.T) {
+	testCases := []struct {
+		name         string
+		remote       string
+		wantRejected ssh.RejectionReason
+	}{
+		{
+			name:   "allowed by target",
+			remote: "127.0.0.1:4000:10.0.0.7:5432",
+		},
+		{
+			name:         "not allowed by target",
+			remote:       "127.0.0.1:4000:10.0.0.7:22",
+			wantRejected: ssh.Prohibited,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote, err := models.NewRemote(tc.remote)
+			require.NoError(t, err)
+			remote.TargetClientID = "client-2"
+
+			sshConn := &requestsConnFake{requests: make(chan string, 2)}
+			target := &sshConnFake{opened: make(chan string, 1)}
+			log := logger.NewLogger("client-to-client-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+			tunnel, err := NewClientToClientTunnel(log, sshConn, "1", *remote, target, nil)
+			require.NoError(t, err)
+			require.NoError(t, tunnel.Start(context.Background()))
+			defer func() {
+				assert.NoError(t, tunnel.Terminate(true))
+			}()
+
+			server, client := net.Pipe()
+			defer client.Close()
+			ch := &newChannelFake{accepted: server, extraData: []byte("1"), rejected: make(chan ssh.RejectionReason, 1)}
+			go tunnel.TunnelProtocol.(ChannelHandler).HandleChannel(ch)
+
+			if tc.wantRejected != 0 {
+				assert.Equal(t, tc.wantRejected, <-ch.rejected)
+				return
+			}
+
+			_, err = client.Write([]byte("ping"))
+			require.NoError(t, err)
+			buf := make([]byte, 4)
+			_, err = io.ReadFull(client, buf)
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(buf))
+			assert.Equal(t, "10.0.0.7:5432", <-target.opened)
+		})
+	}
+}
@@ -0,0 +1,627 @@
+package clienttunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// SOCKS5 protocol values, see RFC 1928 and RFC 1929
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone         = 0x00
+	socks5AuthPassword     = 0x02
+	socks5AuthNoAcceptable = 0xff
+	socks5AuthVersion      = 0x01
+
+	socks5CmdConnect      = 0x01
+	socks5CmdUDPAssociate = 0x03
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+
+	socks5ReplySucceeded           = 0x00
+	socks5ReplyFailure             = 0x01
+	socks5ReplyNotAllowed          = 0x02
+	socks5ReplyCmdNotSupported     = 0x07
+	socks5ReplyAddrTypeUnsupported = 0x08
+)
+
+// socks5HandshakeTimeout limits the time until a connection requested its destination
+var socks5HandshakeTimeout = 30 * time.Second
+
+var errSOCKS5AddrType = errors.New("unsupported address type")
+
+// Connection is a connection through a tunnel to a destination requested by the connecting application
+type Connection struct {
+	ID          int       `json:"id"`
+	Protocol    string    `json:"protocol"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ConnectionsLister is implemented by tunnels without a fixed remote, it lists the connections to the destinations
+type ConnectionsLister interface {
+	Connections() []Connection
+}
+
+// tunnelSOCKS5 is a SOCKS5 server. Each CONNECT and each destination of a UDP ASSOCIATE opens a channel to the client,
+// which checks the destination against its tunnel_allowed config.
+type tunnelSOCKS5 struct {
+	// Declare 64-bit integer before 32-bit for alignment when compiling Go on 32-bit ARM platforms
+	lastActive int64 // time stored as int64 so it can be used with atomic
+	*logger.Logger
+	models.Remote
+	sshConn ssh.Conn
+	acl     atomic.Pointer[TunnelACL] // parsed Remote.ACL field
+	traffic *Traffic
+
+	stopFn    func()
+	connCount int32
+	wg        sync.WaitGroup
+
+	mtx          sync.Mutex
+	lastConnID   int
+	connections  map[int]Connection
+	lastClientID int
+}
+
+func newTunnelSOCKS5(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, traffic *Traffic) *tunnelSOCKS5 {
+	t := &tunnelSOCKS5{
+		Logger:      logger,
+		Remote:      remote,
+		sshConn:     ssh,
+		traffic:     traffic,
+		connections: make(map[int]Connection),
+	}
+	t.SetACL(acl)
+	return t
+}
+
+func (t *tunnelSOCKS5) Start(ctx context.Context) error {
+	t.Debugf("starting socks5 tunnel on %s", t.Local())
+
+	l, err := net.Listen("tcp", t.Local())
+	if err != nil {
+		return fmt.Errorf("%s: %s", t.Logger.Prefix(), err)
+	}
+
+	ctx, t.stopFn = context.WithCancel(ctx)
+	t.wg.Add(1)
+	go t.listen(ctx, l)
+	return nil
+}
+
+func (t *tunnelSOCKS5) Terminate(force bool) error {
+	n := atomic.LoadInt32(&t.connCount)
+	if !force && n > 0 {
+		return fmt.Errorf("tunnel has %d active connection(s)", n)
+	}
+	if t.stopFn == nil {
+		return nil
+	}
+
+	t.stopFn()
+	t.wg.Wait()
+	t.Infof("stopped")
+	t.stopFn = nil
+	return nil
+}
+
+func (t *tunnelSOCKS5) LastActive() time.Time {
+	if atomic.LoadInt32(&t.connCount) > 0 {
+		return time.Now()
+	}
+	return time.Unix(atomic.LoadInt64(&t.lastActive), 0)
+}
+
+func (t *tunnelSOCKS5) SetACL(acl *TunnelACL) {
+	t.acl.Store(acl)
+}
+
+// Connections returns the open connections ordered by their creation
+func (t *tunnelSOCKS5) Connections() []Connection {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	res := make([]Connection, 0, len(t.connections))
+	for _, c := range t.connections {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (t *tunnelSOCKS5) addConnection(protocol, source, destination string) int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.lastConnID++
+	t.connections[t.lastConnID] = Connection{
+		ID:          t.lastConnID,
+		Protocol:    protocol,
+		Source:      source,
+		Destination: destination,
+		CreatedAt:   time.Now(),
+	}
+	return t.lastConnID
+}
+
+func (t *tunnelSOCKS5) removeConnection(id int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.connections, id)
+	t.setLastActive()
+}
+
+func (t *tunnelSOCKS5) setLastActive() {
+	atomic.StoreInt64(&t.lastActive, time.Now().Unix())
+}
+
+func (t *tunnelSOCKS5) nextClientID() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.lastClientID++
+	return t.lastClientID
+}
+
+func (t *tunnelSOCKS5) listen(ctx context.Context, l net.Listener) {
+	defer t.wg.Done()
+
+	t.Infof("tunnel listening")
+
+	// background goroutine to close the listener when context is canceled
+	go func() {
+		<-ctx.Done()
+		if err := l.Close(); err != nil {
+			t.Errorf("Failed to close tunnel listener: %v", err)
+			return
+		}
+		t.Debugf("tunnel listener closed")
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				//listener closed
+			default:
+				t.Errorf("Failed to accept connection: %v", err)
+			}
+			return
+		}
+
+		acl := t.acl.Load()
+		if acl != nil {
+			tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+			if !ok || !acl.CheckAccess(tcpAddr.IP) {
+				t.Debugf("Access rejected. Remote addr: %s", conn.RemoteAddr())
+				conn.Close()
+				continue
+			}
+		}
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.accept(ctx, conn)
+		}()
+	}
+}
+
+func (t *tunnelSOCKS5) accept(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	atomic.AddInt32(&t.connCount, 1)
+	defer atomic.AddInt32(&t.connCount, -1)
+	defer t.setLastActive()
+
+	l := t.Fork("socks5 client#%d", t.nextClientID())
+	l.Debugf("Accept from %s", conn.RemoteAddr())
+
+	done := make(chan struct{})
+	defer close(done)
+	// link ctx to conn
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if t.sshConn == nil {
+		l.Debugf("No remote connection")
+		return
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(socks5HandshakeTimeout)); err != nil {
+		l.Debugf("Failed to set handshake deadline: %v", err)
+		return
+	}
+	if err := t.negotiateAuth(conn); err != nil {
+		l.Debugf("Handshake failed: %v", err)
+		return
+	}
+
+	cmd, dest, err := readSOCKS5Request(conn)
+	if err != nil {
+		l.Debugf("Invalid request: %v", err)
+		if err == errSOCKS5AddrType {
+			_ = writeSOCKS5Reply(conn, socks5ReplyAddrTypeUnsupported, nil)
+		}
+		return
+	}
+
+	switch cmd {
+	case socks5CmdConnect:
+		t.connect(l, conn, dest)
+	case socks5CmdUDPAssociate:
+		t.associateUDP(l, conn)
+	default:
+		l.Debugf("Unsupported command %d", cmd)
+		_ = writeSOCKS5Reply(conn, socks5ReplyCmdNotSupported, nil)
+	}
+}
+
+// negotiateAuth requires username and password authentication if the tunnel has auth_user and auth_password
+func (t *tunnelSOCKS5) negotiateAuth(conn net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("unsupported socks version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return err
+	}
+
+	method := byte(socks5AuthNone)
+	if t.AuthUser != "" && t.AuthPassword != "" {
+		method = socks5AuthPassword
+	}
+	if !bytes.Contains(methods, []byte{method}) {
+		_, _ = conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+		return errors.New("no acceptable authentication method")
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return err
+	}
+
+	if method == socks5AuthPassword {
+		return t.authenticate(conn)
+	}
+	return nil
+}
+
+func (t *tunnelSOCKS5) authenticate(conn net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socks5AuthVersion {
+		return fmt.Errorf("unsupported authentication version %d", header[0])
+	}
+	user := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, user); err != nil {
+		return err
+	}
+	passwordLen := make([]byte, 1)
+	if _, err := io.ReadFull(conn, passwordLen); err != nil {
+		return err
+	}
+	password := make([]byte, passwordLen[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return err
+	}
+
+	wantPassword, ok := t.Remote.BasicAuthPassword()
+	if !ok ||
+		subtle.ConstantTimeCompare(user, []byte(t.AuthUser)) != 1 ||
+		subtle.ConstantTimeCompare(password, []byte(wantPassword)) != 1 {
+		_, _ = conn.Write([]byte{socks5AuthVersion, 0x01})
+		return errors.New("invalid credentials")
+	}
+
+	_, err := conn.Write([]byte{socks5AuthVersion, 0x00})
+	return err
+}
+
+func (t *tunnelSOCKS5) connect(l *logger.Logger, conn net.Conn, dest string) {
+	// ssh request to open connection to the requested destination
+	dst, reqs, err := t.sshConn.OpenChannel("rport", []byte(dest))
+	if err != nil {
+		l.Infof("Could not connect to %s: %v", dest, err)
+		_ = writeSOCKS5Reply(conn, openChannelReply(err), nil)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	if err := writeSOCKS5Reply(conn, socks5ReplySucceeded, conn.LocalAddr()); err != nil {
+		dst.Close()
+		return
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		dst.Close()
+		return
+	}
+
+	id := t.addConnection(models.ProtocolTCP, conn.RemoteAddr().String(), dest)
+	defer t.removeConnection(id)
+
+	l.Debugf("Connected to %s", dest)
+	s, r := chshare.Pipe(t.traffic.Conn(conn), dst)
+	l.Debugf("Close connection to %s (sent %s received %s)", dest, sizestr.ToString(s), sizestr.ToString(r))
+}
+
+// associateUDP relays datagrams until the control connection is closed
+func (t *tunnelSOCKS5) associateUDP(l *logger.Logger, conn net.Conn) {
+	tcpAddr, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		_ = writeSOCKS5Reply(conn, socks5ReplyFailure, nil)
+		return
+	}
+	relay, err := net.ListenUDP("udp", &net.UDPAddr{IP: tcpAddr.IP})
+	if err != nil {
+		l.Errorf("Failed to listen for udp: %v", err)
+		_ = writeSOCKS5Reply(conn, socks5ReplyFailure, nil)
+		return
+	}
+	defer relay.Close()
+
+	if err := writeSOCKS5Reply(conn, socks5ReplySucceeded, relay.LocalAddr()); err != nil {
+		return
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	a := &socks5UDPAssociation{
+		tunnel:       t,
+		Logger:       l,
+		relay:        relay,
+		source:       conn.RemoteAddr().(*net.TCPAddr).IP,
+		destinations: make(map[string]*socks5UDPDestination),
+	}
+	go a.run()
+	defer a.close()
+
+	l.Debugf("UDP relay listening on %s", relay.LocalAddr())
+	// the association ends with the control connection
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+type socks5UDPAssociation struct {
+	*logger.Logger
+	tunnel *tunnelSOCKS5
+	relay  *net.UDPConn
+	// source is the ip of the control connection, datagrams from other ips are dropped
+	source net.IP
+
+	mtx          sync.Mutex
+	destinations map[string]*socks5UDPDestination
+}
+
+type socks5UDPDestination struct {
+	channel *comm.UDPChannel
+	closer  io.Closer
+	connID  int
+	err     error
+}
+
+func (a *socks5UDPAssociation) run() {
+	const maxMTU = 9012
+	buff := make([]byte, maxMTU)
+	for {
+		n, sourceAddr, err := a.relay.ReadFromUDP(buff)
+		if err != nil {
+			return
+		}
+		if !sourceAddr.IP.Equal(a.source) {
+			a.Debugf("Dropped datagram from %s", sourceAddr)
+			continue
+		}
+
+		// RSV, FRAG, destination and data
+		if n < 4 || buff[2] != 0 {
+			a.Debugf("Dropped invalid or fragmented datagram")
+			continue
+		}
+		r := bytes.NewReader(buff[3:n])
+		dest, err := readSOCKS5Addr(r)
+		if err != nil {
+			a.Debugf("Dropped datagram with invalid destination: %v", err)
+			continue
+		}
+		data := buff[n-r.Len() : n]
+
+		d := a.destination(sourceAddr, dest)
+		if d.err != nil {
+			continue
+		}
+		a.tunnel.setLastActive()
+		a.tunnel.traffic.up(len(data))
+		if err := d.channel.Encode(sourceAddr, data); err != nil {
+			a.Debugf("Failed to send datagram to %s: %v", dest, err)
+		}
+	}
+}
+
+// destination returns the channel to the destination, it's opened with the first datagram to the destination
+func (a *socks5UDPAssociation) destination(sourceAddr *net.UDPAddr, dest string) *socks5UDPDestination {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if d, ok := a.destinations[dest]; ok {
+		return d
+	}
+
+	d := &socks5UDPDestination{}
+	a.destinations[dest] = d
+	ch, reqs, err := a.tunnel.sshConn.OpenChannel("rport", []byte(dest+"/udp"))
+	if err != nil {
+		a.Infof("Could not connect to %s/udp: %v", dest, err)
+		d.err = err
+		return d
+	}
+	go ssh.DiscardRequests(reqs)
+
+	d.channel = comm.NewUDPChannel(ch)
+	d.closer = ch
+	d.connID = a.tunnel.addConnection(models.ProtocolUDP, sourceAddr.String(), dest)
+	go a.receive(dest, d)
+
+	return d
+}
+
+func (a *socks5UDPAssociation) receive(dest string, d *socks5UDPDestination) {
+	header := appendSOCKS5Addr([]byte{0, 0, 0}, dest)
+	for {
+		addr, data, err := d.channel.Decode()
+		if err != nil {
+			return
+		}
+		a.tunnel.setLastActive()
+		a.tunnel.traffic.down(len(data))
+		packet := append(append([]byte{}, header...), data...)
+		if _, err := a.relay.WriteToUDP(packet, addr); err != nil {
+			a.Debugf("Failed to relay datagram from %s: %v", dest, err)
+		}
+	}
+}
+
+func (a *socks5UDPAssociation) close() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, d := range a.destinations {
+		if d.closer != nil {
+			d.closer.Close()
+			a.tunnel.removeConnection(d.connID)
+		}
+	}
+}
+
+// openChannelReply returns the reply to a failed connect, the client rejects destinations not allowed by its config
+func openChannelReply(err error) byte {
+	var openErr *ssh.OpenChannelError
+	if errors.As(err, &openErr) && openErr.Reason == ssh.Prohibited {
+		return socks5ReplyNotAllowed
+	}
+	return socks5ReplyFailure
+}
+
+// readSOCKS5Request reads the command and the destination of a request
+func readSOCKS5Request(r io.Reader) (cmd byte, dest string, err error) {
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, "", err
+	}
+	if header[0] != socks5Version {
+		return 0, "", fmt.Errorf("unsupported socks version %d", header[0])
+	}
+	dest, err = readSOCKS5Addr(r)
+	if err != nil {
+		return 0, "", err
+	}
+	return header[1], dest, nil
+}
+
+// readSOCKS5Addr reads an address, it's returned as host:port
+func readSOCKS5Addr(r io.Reader) (string, error) {
+	addrType := make([]byte, 1)
+	if _, err := io.ReadFull(r, addrType); err != nil {
+		return "", err
+	}
+
+	var host string
+	switch addrType[0] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if addrType[0] == socks5AddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case socks5AddrDomain:
+		domainLen := make([]byte, 1)
+		if _, err := io.ReadFull(r, domainLen); err != nil {
+			return "", err
+		}
+		domain := make([]byte, domainLen[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", errSOCKS5AddrType
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// appendSOCKS5Addr appends host:port in the address format of the protocol
+func appendSOCKS5Addr(b []byte, addr string) []byte {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, portStr = "0.0.0.0", "0"
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		b = append(b, socks5AddrDomain, byte(len(host)))
+		b = append(b, host...)
+	case ip.To4() != nil:
+		b = append(b, socks5AddrIPv4)
+		b = append(b, ip.To4()...)
+	default:
+		b = append(b, socks5AddrIPv6)
+		b = append(b, ip.To16()...)
+	}
+	return binary.BigEndian.AppendUint16(b, uint16(port))
+}
+
+// writeSOCKS5Reply writes a reply, bindAddr is the address of the server side of the connection
+func writeSOCKS5Reply(w io.Writer, reply byte, bindAddr net.Addr) error {
+	addr := "0.0.0.0:0"
+	if bindAddr != nil {
+		addr = bindAddr.String()
+	}
+	_, err := w.Write(appendSOCKS5Addr([]byte{socks5Version, reply, 0x00}, addr))
+	return err
+}
@@ -0,0 +1,161 @@
+package clienttunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// sshConnFake opens channels to an echo server, destinations on port 22 are rejected like the client does for not allowed destinations
+type sshConnFake struct {
+	ssh.Conn
+	opened chan string
+}
+
+func (c *sshConnFake) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	dest := string(data)
+	if strings.HasSuffix(dest, ":22") {
+		return nil, nil, &ssh.OpenChannelError{Reason: ssh.Prohibited, Message: "not allowed"}
+	}
+	c.opened <- dest
+
+	server, client := net.Pipe()
+	go func() {
+		defer client.Close()
+		_, _ = io.Copy(client, client)
+	}()
+	reqs := make(chan *ssh.Request)
+	close(reqs)
+	return &channelFake{Conn: server}, reqs, nil
+}
+
+type channelFake struct {
+	net.Conn
+}
+
+func (c *channelFake) CloseWrite() error {
+	return c.Close()
+}
+
+func (c *channelFake) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return false, nil
+}
+
+func (c *channelFake) Stderr() io.ReadWriter {
+	return nil
+}
+
+func TestTunnelSOCKS5(t *testing.T) {
+	testCases := []struct {
+		name          string
+		authUser      string
+		authPassword  string
+		dialAuth      *proxy.Auth
+		dest          string
+		wantErr       string
+		wantConnected bool
+	}{
+		{
+			name:          "no auth",
+			dest:          "192.168.0.1:80",
+			wantConnected: true,
+		},
+		{
+			name:          "valid credentials",
+			authUser:      "admin",
+			authPassword:  "foobaz",
+			dialAuth:      &proxy.Auth{User: "admin", Password: "foobaz"},
+			dest:          "example.com:443",
+			wantConnected: true,
+		},
+		{
+			name:         "invalid credentials",
+			authUser:     "admin",
+			authPassword: "foobaz",
+			dialAuth:     &proxy.Auth{User: "admin", Password: "wrong"},
+			dest:         "example.com:443",
+			wantErr:      "username/password authentication failed",
+		},
+		{
+			name:         "missing credentials",
+			authUser:     "admin",
+			authPassword: "foobaz",
+			dest:         "example.com:443",
+			wantErr:      "no acceptable authentication methods",
+		},
+		{
+			name:    "destination not allowed",
+			dest:    "192.168.0.1:22",
+			wantErr: "connection not allowed by ruleset",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remote, err := models.NewSOCKS5Remote("127.0.0.1:0")
+			require.NoError(t, err)
+			remote.AuthUser = tc.authUser
+			remote.AuthPassword = tc.authPassword
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			sshConn := &sshConnFake{opened: make(chan string, 1)}
+			log := logger.NewLogger("socks5-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+			tunnel := newTunnelSOCKS5(log, sshConn, *remote, nil, NewTraffic(*remote, nil))
+			ctx, cancel := context.WithCancel(context.Background())
+			tunnel.stopFn = cancel
+			tunnel.wg.Add(1)
+			go tunnel.listen(ctx, l)
+			defer func() {
+				assert.NoError(t, tunnel.Terminate(true))
+			}()
+
+			dialer, err := proxy.SOCKS5("tcp", l.Addr().String(), tc.dialAuth, proxy.Direct)
+			require.NoError(t, err)
+			conn, err := dialer.Dial("tcp", tc.dest)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer conn.Close()
+
+			assert.Equal(t, tc.dest, <-sshConn.opened)
+
+			_, err = conn.Write([]byte("ping"))
+			require.NoError(t, err)
+			buf := make([]byte, 4)
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(buf))
+
+			connections := tunnel.Connections()
+			require.Len(t, connections, 1)
+			assert.Equal(t, models.ProtocolTCP, connections[0].Protocol)
+			assert.Equal(t, tc.dest, connections[0].Destination)
+			assert.Equal(t, conn.LocalAddr().String(), connections[0].Source)
+		})
+	}
+}
+
+func TestSOCKS5Addr(t *testing.T) {
+	for _, addr := range []string{"10.0.0.1:53", "[2001:db8::1]:443", "example.com:8080"} {
+		b := appendSOCKS5Addr(nil, addr)
+		got, err := readSOCKS5Addr(strings.NewReader(string(b)))
+		require.NoError(t, err)
+		assert.Equal(t, addr, got)
+	}
+}
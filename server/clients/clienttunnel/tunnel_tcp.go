@@ -3,7 +3,6 @@ package clienttunnel
 import (
 	"context"
 	"fmt"
-	"io"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -22,8 +21,10 @@ type tunnelTCP struct {
 	lastConnClose int64 // time stored as int64 so it can be used with atomic
 	*logger.Logger
 	models.Remote
-	sshConn ssh.Conn
-	acl     atomic.Pointer[TunnelACL] // parsed Remote.ACL field
+	sshConn  ssh.Conn
+	acl      atomic.Pointer[TunnelACL] // parsed Remote.ACL field
+	recorder ConnRecorder
+	traffic  *Traffic
 
 	stopFn                    func()
 	connectionIDAutoIncrement int
@@ -31,11 +32,13 @@ type tunnelTCP struct {
 	wg                        sync.WaitGroup // TODO: verify whether wait group is needed here
 }
 
-func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelTCP {
+func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, recorder ConnRecorder, traffic *Traffic) *tunnelTCP {
 	t := &tunnelTCP{
-		Logger:  logger,
-		Remote:  remote,
-		sshConn: ssh,
+		Logger:   logger,
+		Remote:   remote,
+		sshConn:  ssh,
+		recorder: recorder,
+		traffic:  traffic,
 	}
 	t.SetACL(acl)
 	return t
@@ -136,7 +139,8 @@ func (t *tunnelTCP) LastActive() time.Time {
 	return time.Unix(atomic.LoadInt64(&t.lastConnClose), 0)
 }
 
-func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
+func (t *tunnelTCP) accept(ctx context.Context, conn net.Conn) {
+	src := t.traffic.Conn(conn)
 	defer src.Close()
 	t.connectionIDAutoIncrement++
 	atomic.AddInt32(&t.connCount, 1)
@@ -175,6 +179,9 @@ func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
 	l.Debugf("from %+v", t.sshConn.RemoteAddr())
 
 	go ssh.DiscardRequests(reqs)
+	if t.recorder != nil {
+		src = t.recorder.Record(src, conn.RemoteAddr().String())
+	}
 	//then pipe
 	s, r := chshare.Pipe(src, dst)
 	l.Debugf("Close (sent %s received %s)", sizestr.ToString(s), sizestr.ToString(r))
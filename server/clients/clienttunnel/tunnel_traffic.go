@@ -0,0 +1,217 @@
+package clienttunnel
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openrport/openrport/share/models"
+)
+
+// RateLimiter is a token bucket limiting the throughput to a number of bytes per second. A nil RateLimiter and the
+// zero value don't limit anything, the zero value can be limited later with SetRate.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns nil if bytesPerSecond is not positive
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:   bytesPerSecond,
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// SetRate changes the limit, zero disables it
+func (l *RateLimiter) SetRate(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = bytesPerSecond
+	if l.tokens > float64(bytesPerSecond) {
+		l.tokens = float64(bytesPerSecond)
+	}
+}
+
+// Rate returns the limit in bytes per second
+func (l *RateLimiter) Rate() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+// maxChunk limits reads and writes so that waiting for a single chunk never takes much longer than a second
+func (l *RateLimiter) maxChunk(n int) int {
+	rate := l.Rate()
+	if rate > 0 && int64(n) > rate {
+		return int(rate)
+	}
+	return n
+}
+
+// Wait blocks until n bytes may pass. Bytes are taken from the bucket right away, so concurrent callers sharing
+// the limiter queue up behind each other.
+func (l *RateLimiter) Wait(n int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// Bandwidth holds the limiters shared by all tunnels of a client
+type Bandwidth struct {
+	Up   *RateLimiter
+	Down *RateLimiter
+}
+
+// TrafficStats are the totals of a tunnel
+type TrafficStats struct {
+	BytesUp           int64 `json:"bytes_up"`
+	BytesDown         int64 `json:"bytes_down"`
+	ActiveConnections int32 `json:"active_connections"`
+}
+
+// Traffic counts the bytes transferred by a tunnel and applies its bandwidth limits. Up is the traffic sent through
+// the tunnel to the client, down the traffic received from the client.
+type Traffic struct {
+	// Declare 64-bit integers before 32-bit for alignment when compiling Go on 32-bit ARM platforms
+	bytesUp     int64
+	bytesDown   int64
+	activeConns int32
+
+	upLimiters   []*RateLimiter
+	downLimiters []*RateLimiter
+}
+
+// NewTraffic applies the limits of the remote and the limits shared by all tunnels of the client, client is optional
+func NewTraffic(remote models.Remote, client *Bandwidth) *Traffic {
+	t := &Traffic{}
+	if l := NewRateLimiter(remote.BandwidthLimitUp); l != nil {
+		t.upLimiters = append(t.upLimiters, l)
+	}
+	if l := NewRateLimiter(remote.BandwidthLimitDown); l != nil {
+		t.downLimiters = append(t.downLimiters, l)
+	}
+	if client != nil {
+		if client.Up != nil {
+			t.upLimiters = append(t.upLimiters, client.Up)
+		}
+		if client.Down != nil {
+			t.downLimiters = append(t.downLimiters, client.Down)
+		}
+	}
+	return t
+}
+
+// Stats returns the current totals, it's safe to call on a nil Traffic
+func (t *Traffic) Stats() TrafficStats {
+	if t == nil {
+		return TrafficStats{}
+	}
+	return TrafficStats{
+		BytesUp:           atomic.LoadInt64(&t.bytesUp),
+		BytesDown:         atomic.LoadInt64(&t.bytesDown),
+		ActiveConnections: atomic.LoadInt32(&t.activeConns),
+	}
+}
+
+// up waits until n bytes may be sent to the client and counts them
+func (t *Traffic) up(n int) {
+	wait(t.upLimiters, n)
+	atomic.AddInt64(&t.bytesUp, int64(n))
+}
+
+// down waits until n bytes received from the client may be passed on and counts them
+func (t *Traffic) down(n int) {
+	wait(t.downLimiters, n)
+	atomic.AddInt64(&t.bytesDown, int64(n))
+}
+
+func wait(limiters []*RateLimiter, n int) {
+	for _, l := range limiters {
+		l.Wait(n)
+	}
+}
+
+func maxChunk(limiters []*RateLimiter, n int) int {
+	for _, l := range limiters {
+		n = l.maxChunk(n)
+	}
+	return n
+}
+
+// Conn wraps the connection of a tunnel user, it's counted as active until it's closed
+func (t *Traffic) Conn(conn io.ReadWriteCloser) io.ReadWriteCloser {
+	atomic.AddInt32(&t.activeConns, 1)
+	return &trafficConn{ReadWriteCloser: conn, traffic: t}
+}
+
+// trafficConn limits and counts what is read from the tunnel user as up and what is written to it as down
+type trafficConn struct {
+	io.ReadWriteCloser
+	traffic *Traffic
+	closed  sync.Once
+}
+
+func (c *trafficConn) Read(p []byte) (int, error) {
+	p = p[:maxChunk(c.traffic.upLimiters, len(p))]
+	n, err := c.ReadWriteCloser.Read(p)
+	if n > 0 {
+		c.traffic.up(n)
+	}
+	return n, err
+}
+
+func (c *trafficConn) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := maxChunk(c.traffic.downLimiters, len(p))
+		wait(c.traffic.downLimiters, chunk)
+		n, err := c.ReadWriteCloser.Write(p[:chunk])
+		atomic.AddInt64(&c.traffic.bytesDown, int64(n))
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
+
+func (c *trafficConn) Close() error {
+	c.closed.Do(func() {
+		atomic.AddInt32(&c.traffic.activeConns, -1)
+	})
+	return c.ReadWriteCloser.Close()
+}
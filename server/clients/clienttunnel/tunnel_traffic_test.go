@@ -0,0 +1,63 @@
+package clienttunnel
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/models"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0))
+
+	l := NewRateLimiter(100000)
+	start := time.Now()
+	// the first second is available right away
+	l.Wait(100000)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	l.Wait(50000)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	l.SetRate(0)
+	start = time.Now()
+	l.Wait(1000000)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestTrafficConn(t *testing.T) {
+	client := &Bandwidth{Down: NewRateLimiter(100000)}
+	traffic := NewTraffic(models.Remote{BandwidthLimitDown: 1000000}, client)
+	require.Len(t, traffic.downLimiters, 2)
+	require.Len(t, traffic.upLimiters, 0)
+
+	server, user := net.Pipe()
+	conn := traffic.Conn(server)
+	assert.Equal(t, int32(1), traffic.Stats().ActiveConnections)
+
+	go func() {
+		_, _ = io.Copy(io.Discard, user)
+	}()
+	start := time.Now()
+	// the lower limit of the client applies
+	n, err := conn.Write(make([]byte, 150000))
+	require.NoError(t, err)
+	assert.Equal(t, 150000, n)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+
+	go func() {
+		_, _ = user.Write([]byte("ping"))
+	}()
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, conn.Close())
+	assert.Equal(t, TrafficStats{BytesUp: 4, BytesDown: 150000}, traffic.Stats())
+}
@@ -23,6 +23,7 @@ type tunnelUDP struct {
 	sshConn     ssh.Conn
 	acl         atomic.Pointer[TunnelACL] // parsed Remote.ACL field
 	idleTimeout time.Duration
+	traffic     *Traffic
 
 	conn    *net.UDPConn
 	channel *comm.UDPChannel
@@ -33,7 +34,7 @@ type tunnelUDP struct {
 	lastActive time.Time
 }
 
-func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelUDP {
+func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, traffic *Traffic) *tunnelUDP {
 	t := &tunnelUDP{
 		Logger:      logger,
 		Remote:      remote,
@@ -41,6 +42,7 @@ func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl
 		done:        make(chan struct{}),
 		lastActive:  time.Now(),
 		idleTimeout: time.Duration(remote.IdleTimeoutMinutes) * time.Minute,
+		traffic:     traffic,
 	}
 	t.SetACL(acl)
 	return t
@@ -128,6 +130,7 @@ func (t *tunnelUDP) runInbound(ctx context.Context) error {
 			}
 		}
 
+		t.traffic.up(n)
 		err = t.channel.Encode(sourceAddr, buff[:n])
 		if err != nil {
 			return err
@@ -153,6 +156,7 @@ func (t *tunnelUDP) runOutbound(ctx context.Context) error {
 
 		t.setLastActive()
 
+		t.traffic.down(len(data))
 		_, err = t.conn.WriteToUDP(data, addr)
 		if err != nil {
 			return err
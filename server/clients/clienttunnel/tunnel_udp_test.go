@@ -20,7 +20,7 @@ func TestTunnelUDP(t *testing.T) {
 	udpReadTimeout = time.Millisecond
 	remote := models.Remote{}
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
-	tunnel := newTunnelUDP(logger, nil, remote, nil)
+	tunnel := newTunnelUDP(logger, nil, remote, nil, NewTraffic(remote, nil))
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	err := tunnel.start(context.Background(), serverChannel)
@@ -50,6 +50,7 @@ func TestTunnelUDP(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.WithinDuration(t, time.Now(), tunnel.LastActive(), 10*time.Millisecond)
+	assert.Equal(t, TrafficStats{BytesUp: 3, BytesDown: 3}, tunnel.traffic.Stats())
 }
 
 func TestTunnelUDPWithACL(t *testing.T) {
@@ -58,7 +59,7 @@ func TestTunnelUDPWithACL(t *testing.T) {
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
 	acl, err := ParseTunnelACL("127.0.0.2")
 	require.NoError(t, err)
-	tunnel := newTunnelUDP(logger, nil, remote, acl)
+	tunnel := newTunnelUDP(logger, nil, remote, acl, NewTraffic(remote, nil))
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	local1, err := net.ResolveUDPAddr("udp", "127.0.0.1:0")
@@ -20,6 +20,8 @@ type ClientRepository struct {
 	clientState map[string]*clientdata.Client
 	// db based store
 	clientStore ClientStore
+	// nil unless running in a high availability cluster
+	clientNodes ClientNodes
 
 	keepDisconnectedClients *time.Duration
 
@@ -62,15 +64,18 @@ func InitClientRepository(
 	ctx context.Context,
 	db *sqlx.DB,
 	keepDisconnectedClients *time.Duration,
+	clientNodes ClientNodes,
 	logger *logger.Logger,
 ) (*ClientRepository, error) {
 	provider := newSqliteProvider(db, keepDisconnectedClients)
-	initialClients, err := LoadInitialClients(ctx, provider, logger)
+	initialClients, err := LoadInitialClients(ctx, provider, clientNodes, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewClientRepositoryWithDB(initialClients, keepDisconnectedClients, provider, logger), nil
+	r := NewClientRepositoryWithDB(initialClients, keepDisconnectedClients, provider, logger)
+	r.clientNodes = clientNodes
+	return r, nil
 }
 
 func (r *ClientRepository) SetPostSaveHandlerFn(handlerFn func(cl *clientdata.Client)) {
@@ -131,6 +136,49 @@ func (r *ClientRepository) Delete(client *clientdata.Client) error {
 	return nil
 }
 
+// SyncFromStore replaces the clients not connected to this server with their state in the store. In a high
+// availability cluster it keeps the clients connected to the other nodes up to date. Clients the store says are
+// connected to a node that isn't alive anymore are shown as disconnected.
+func (r *ClientRepository) SyncFromStore(ctx context.Context) error {
+	store := r.getStore()
+	if store == nil {
+		return nil
+	}
+
+	stored, err := store.GetAll(ctx, r.log().Fork("client"))
+	if err != nil {
+		return fmt.Errorf("failed to get clients: %w", err)
+	}
+	elsewhere, err := connectedElsewhere(ctx, r.clientNodes)
+	if err != nil {
+		return err
+	}
+
+	now := clientdata.Now()
+	ids := make(map[string]bool, len(stored))
+	for _, c := range stored {
+		ids[c.GetID()] = true
+		if c.IsConnected() && !elsewhere[c.GetID()] {
+			c.SetDisconnectedAt(&now)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range stored {
+		if current := r.clientState[c.GetID()]; current != nil && current.IsConnectedHere() {
+			continue
+		}
+		r.clientState[c.GetID()] = c
+	}
+	for id, c := range r.clientState {
+		if !ids[id] && !c.IsConnectedHere() {
+			delete(r.clientState, id)
+		}
+	}
+	return nil
+}
+
 func (r *ClientRepository) GetClientsByTag(tags []string, operator string, allowDisconnected bool) (matchingClients []*clientdata.Client, err error) {
 	var availableClients []*clientdata.Client
 	if allowDisconnected {
@@ -8,8 +8,14 @@ import (
 	"github.com/openrport/openrport/share/logger"
 )
 
+// ClientNodes locates the clients connected to the other nodes of a high availability cluster.
+type ClientNodes interface {
+	ClientsConnectedElsewhere(ctx context.Context) (map[string]bool, error)
+}
+
 // LoadInitialClients returns an initial Client Repository state populated with clients from the internal storage.
-func LoadInitialClients(ctx context.Context, p ClientStore, logger *logger.Logger) ([]*clientdata.Client, error) {
+// nodes is nil unless the server runs in a high availability cluster.
+func LoadInitialClients(ctx context.Context, p ClientStore, nodes ClientNodes, logger *logger.Logger) ([]*clientdata.Client, error) {
 	logger.Debugf("loading existing clients")
 
 	// setup a logger for the clients
@@ -22,11 +28,16 @@ func LoadInitialClients(ctx context.Context, p ClientStore, logger *logger.Logge
 
 	logger.Debugf("loaded %d clients", len(all))
 
-	// mark previously connected clients as disconnected with current time
+	elsewhere, err := connectedElsewhere(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// mark previously connected clients as disconnected with current time, unless they are connected to another node
 	now := clientdata.Now()
 
 	for _, client := range all {
-		if client.IsConnected() {
+		if client.IsConnected() && !elsewhere[client.GetID()] {
 			client.SetDisconnectedAt(&now)
 			err := p.Save(ctx, client)
 			if err != nil {
@@ -37,3 +48,14 @@ func LoadInitialClients(ctx context.Context, p ClientStore, logger *logger.Logge
 
 	return all, nil
 }
+
+func connectedElsewhere(ctx context.Context, nodes ClientNodes) (map[string]bool, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+	elsewhere, err := nodes.ClientsConnectedElsewhere(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clients connected to other nodes: %v", err)
+	}
+	return elsewhere, nil
+}
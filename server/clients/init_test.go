@@ -50,7 +50,7 @@ func TestGetInitState(t *testing.T) {
 			p := NewFakeClientProvider(t, &tc.expiration, tc.dbClients...)
 			defer p.Close()
 
-			gotClients, gotErr := LoadInitialClients(ctx, p, testLog)
+			gotClients, gotErr := LoadInitialClients(ctx, p, nil, testLog)
 			assert.NoError(t, gotErr)
 			assert.Len(t, gotClients, len(tc.wantRes))
 
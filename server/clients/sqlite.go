@@ -17,6 +17,7 @@ import (
 	chshare "github.com/openrport/openrport/share/clientconfig"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/query"
 )
 
 type ClientStore interface {
@@ -30,10 +31,15 @@ type ClientStore interface {
 type SqliteProvider struct {
 	db                      *sqlx.DB
 	keepDisconnectedClients *time.Duration
+	converter               *query.SQLConverter
 }
 
 func newSqliteProvider(db *sqlx.DB, keepDisconnectedClients *time.Duration) *SqliteProvider {
-	return &SqliteProvider{db: db, keepDisconnectedClients: keepDisconnectedClients}
+	return &SqliteProvider{
+		db:                      db,
+		keepDisconnectedClients: keepDisconnectedClients,
+		converter:               query.NewSQLConverter(db.DriverName()),
+	}
 }
 
 func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clientdata.Client, error) {
@@ -41,7 +47,8 @@ func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clien
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		"SELECT * FROM clients WHERE disconnected_at IS NULL OR DATETIME(disconnected_at) >= DATETIME(?) OR ?",
+		p.converter.Rebind("SELECT * FROM clients WHERE disconnected_at IS NULL OR "+
+			p.converter.DateTime("disconnected_at")+" >= "+p.converter.DateTime("?")+" OR ?"),
 		p.keepDisconnectedClientsStart(),
 		p.keepDisconnectedClients == nil,
 	)
@@ -54,7 +61,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clien
 // test only
 func (p *SqliteProvider) get(ctx context.Context, id string, l *logger.Logger) (*clientdata.Client, error) {
 	res := &clientSqlite{}
-	err := p.db.GetContext(ctx, res, "SELECT * FROM clients WHERE id = ?", id)
+	err := p.db.GetContext(ctx, res, p.converter.Rebind("SELECT * FROM clients WHERE id = ?"), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -72,7 +79,11 @@ func (p *SqliteProvider) Save(ctx context.Context, client *clientdata.Client) er
 
 		_, err = p.db.NamedExecContext(
 			ctx,
-			"INSERT OR REPLACE INTO clients (id, client_auth_id, disconnected_at, details) VALUES (:id, :client_auth_id, :disconnected_at, :details)",
+			`INSERT INTO clients (id, client_auth_id, disconnected_at, details) VALUES (:id, :client_auth_id, :disconnected_at, :details)
+			ON CONFLICT (id) DO UPDATE SET
+				client_auth_id = EXCLUDED.client_auth_id,
+				disconnected_at = EXCLUDED.disconnected_at,
+				details = EXCLUDED.details`,
 			clientForSQL,
 		)
 
@@ -87,7 +98,8 @@ func (p *SqliteProvider) DeleteObsolete(ctx context.Context, l *logger.Logger) e
 
 		_, err = p.db.ExecContext(
 			ctx,
-			"DELETE FROM clients WHERE disconnected_at IS NOT NULL AND DATETIME(disconnected_at) < DATETIME(?) AND ?",
+			p.converter.Rebind("DELETE FROM clients WHERE disconnected_at IS NOT NULL AND "+
+				p.converter.DateTime("disconnected_at")+" < "+p.converter.DateTime("?")+" AND ?"),
 			p.keepDisconnectedClientsStart(),
 			p.keepDisconnectedClients != nil,
 		)
@@ -101,7 +113,7 @@ func (p *SqliteProvider) DeleteObsolete(ctx context.Context, l *logger.Logger) e
 func (p *SqliteProvider) Delete(ctx context.Context, id string, l *logger.Logger) error {
 	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
 
-		_, err = p.db.ExecContext(ctx, "DELETE FROM clients WHERE id = ?", id)
+		_, err = p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM clients WHERE id = ?"), id)
 
 		return nil, err
 	}, "delete", l)
@@ -0,0 +1,189 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/clients/storedtunnels"
+	"github.com/openrport/openrport/share/models"
+)
+
+// StoredTunnels provides the stored tunnels with an auto start policy and keeps their state
+type StoredTunnels interface {
+	ListAutoStart(ctx context.Context, clientID string) ([]*storedtunnels.StoredTunnel, error)
+	SetState(ctx context.Context, clientID, id, state, lastError string) error
+}
+
+func (s *ClientServiceProvider) SetStoredTunnels(storedTunnels StoredTunnels) {
+	s.storedTunnels = storedTunnels
+}
+
+// startStoredTunnelsOnConnect starts the stored tunnels of a client that has just connected
+func (s *ClientServiceProvider) startStoredTunnelsOnConnect(client *clientdata.Client) {
+	ctx := client.GetContext()
+	tunnels, err := s.storedTunnels.ListAutoStart(ctx, client.GetID())
+	if err != nil {
+		client.Log().Errorf("failed to get stored tunnels to start: %v", err)
+		return
+	}
+
+	for _, t := range tunnels {
+		if t.AutoStart == storedtunnels.AutoStartOnConnect || t.AutoStart == storedtunnels.AutoStartAlways {
+			s.startStoredTunnel(ctx, client, t)
+		}
+	}
+}
+
+// StartStoredTunnels starts the stored tunnels which are due, these are the ones started always that are down and
+// the scheduled ones with a schedule between since and now.
+func (s *ClientServiceProvider) StartStoredTunnels(ctx context.Context, since, now time.Time) error {
+	if s.storedTunnels == nil {
+		return nil
+	}
+
+	tunnels, err := s.storedTunnels.ListAutoStart(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tunnels {
+		switch t.AutoStart {
+		case storedtunnels.AutoStartAlways:
+		case storedtunnels.AutoStartSchedule:
+			if t.AutoStartSchedule == nil {
+				continue
+			}
+			schedule, err := storedtunnels.ScheduleParser.Parse(*t.AutoStartSchedule)
+			if err != nil {
+				s.log().Errorf("invalid schedule of stored tunnel %s: %v", t.ID, err)
+				continue
+			}
+			if schedule.Next(since).After(now) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		client, err := s.repo.GetActiveByID(t.ClientID)
+		if err != nil {
+			return err
+		}
+		// clients connected to another node of a HA cluster get their tunnels started by that node
+		if client == nil || client.IsPaused() || !client.IsConnectedHere() {
+			continue
+		}
+		s.startStoredTunnel(ctx, client, t)
+	}
+
+	return nil
+}
+
+// startStoredTunnel starts the stored tunnel unless it's running already and records the result
+func (s *ClientServiceProvider) startStoredTunnel(ctx context.Context, client *clientdata.Client, t *storedtunnels.StoredTunnel) {
+	// the task and a client connecting might try to start the same tunnel
+	s.storedTunnelsMu.Lock()
+	defer s.storedTunnelsMu.Unlock()
+
+	if findStoredTunnel(client, t.ID) != nil {
+		return
+	}
+
+	state := storedtunnels.StateRunning
+	lastError := ""
+	if err := s.doStartStoredTunnel(client, t); err != nil {
+		client.Log().Errorf("failed to start stored tunnel %s (%s): %v", t.ID, t.Name, err)
+		state = storedtunnels.StateFailed
+		lastError = err.Error()
+	} else {
+		client.Log().Infof("started stored tunnel %s (%s) due to auto start policy %q", t.ID, t.Name, t.AutoStart)
+	}
+
+	if err := s.storedTunnels.SetState(ctx, client.GetID(), t.ID, state, lastError); err != nil {
+		client.Log().Errorf("failed to save state of stored tunnel %s: %v", t.ID, err)
+	}
+}
+
+func (s *ClientServiceProvider) doStartStoredTunnel(client *clientdata.Client, t *storedtunnels.StoredTunnel) error {
+	remote, err := t.ToRemote()
+	if err != nil {
+		return err
+	}
+	if err := s.resolveTunnelSecrets(client.GetContext(), client.GetID(), remote); err != nil {
+		return err
+	}
+
+	allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), client.Log())
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("tunnel destination is not allowed by client configuration")
+	}
+
+	if existing := s.FindTunnelByRemote(client, remote); existing != nil {
+		return fmt.Errorf("tunnel %s to the same remote exists already", existing.ID)
+	}
+
+	_, err = s.StartClientTunnels(client, []*models.Remote{remote})
+	return err
+}
+
+// StoredTunnelState returns the state of a stored tunnel. Tunnels recorded as running are stopped if they are not
+// running on the client anymore, e.g. closed due to inactivity or disconnect.
+func StoredTunnelState(client *clientdata.Client, t *storedtunnels.StoredTunnel) string {
+	if t.State != storedtunnels.StateRunning {
+		return t.State
+	}
+	if client != nil && client.IsConnected() && findStoredTunnel(client, t.ID) != nil {
+		return storedtunnels.StateRunning
+	}
+	return storedtunnels.StateStopped
+}
+
+func findStoredTunnel(client *clientdata.Client, storedTunnelID string) *clienttunnel.Tunnel {
+	for _, t := range client.GetTunnels() {
+		if t.Remote.StoredTunnelID == storedTunnelID {
+			return t
+		}
+	}
+	return nil
+}
+
+// withoutStoredTunnels removes the remotes of auto started stored tunnels, they are started separately
+func withoutStoredTunnels(remotes []*models.Remote) []*models.Remote {
+	res := make([]*models.Remote, 0, len(remotes))
+	for _, r := range remotes {
+		if r.StoredTunnelID == "" {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+type StoredTunnelsTask struct {
+	service ClientService
+	lastRun time.Time
+}
+
+// NewStoredTunnelsTask returns a task to start the stored tunnels which should always run or which are scheduled.
+func NewStoredTunnelsTask(service ClientService) *StoredTunnelsTask {
+	return &StoredTunnelsTask{
+		service: service,
+		lastRun: time.Now(),
+	}
+}
+
+func (t *StoredTunnelsTask) Run(ctx context.Context) error {
+	now := time.Now()
+	err := t.service.StartStoredTunnels(ctx, t.lastRun, now)
+	t.lastRun = now
+	if err != nil {
+		return fmt.Errorf("failed to start stored tunnels: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/clients/storedtunnels"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/ptr"
+)
+
+type fakeStoredTunnels struct {
+	tunnels []*storedtunnels.StoredTunnel
+	states  map[string]string
+}
+
+func (f *fakeStoredTunnels) ListAutoStart(ctx context.Context, clientID string) ([]*storedtunnels.StoredTunnel, error) {
+	return f.tunnels, nil
+}
+
+func (f *fakeStoredTunnels) SetState(ctx context.Context, clientID, id, state, lastError string) error {
+	f.states[id] = state
+	return nil
+}
+
+func TestStartStoredTunnelsSkipsTunnelsNotDue(t *testing.T) {
+	connected := New(t).ID("connected").Logger(testLog).Build()
+	disconnected := New(t).ID("disconnected").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+	connected.Tunnels = append(connected.Tunnels, &clienttunnel.Tunnel{
+		ID:     "3",
+		Remote: models.Remote{RemoteHost: "127.0.0.1", RemotePort: "80", StoredTunnelID: "running"},
+	})
+
+	stored := &fakeStoredTunnels{
+		tunnels: []*storedtunnels.StoredTunnel{
+			{ID: "disconnected-client", ClientID: "disconnected", AutoStart: storedtunnels.AutoStartAlways},
+			{ID: "running", ClientID: "connected", AutoStart: storedtunnels.AutoStartAlways},
+			{ID: "on-connect", ClientID: "connected", AutoStart: storedtunnels.AutoStartOnConnect},
+			{ID: "not-scheduled", ClientID: "connected", AutoStart: storedtunnels.AutoStartSchedule, AutoStartSchedule: ptr.String("0 3 * * *")},
+		},
+		states: make(map[string]string),
+	}
+	cs := &ClientServiceProvider{
+		repo:   NewClientRepository([]*clientdata.Client{connected, disconnected}, nil, testLog),
+		logger: testLog,
+	}
+	cs.SetStoredTunnels(stored)
+
+	since := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	err := cs.StartStoredTunnels(context.Background(), since, since.Add(time.Minute))
+	require.NoError(t, err)
+
+	assert.Empty(t, stored.states)
+}
+
+func TestStoredTunnelState(t *testing.T) {
+	client := New(t).Logger(testLog).Build()
+	client.Tunnels = append(client.Tunnels, &clienttunnel.Tunnel{
+		ID:     "3",
+		Remote: models.Remote{RemoteHost: "127.0.0.1", RemotePort: "80", StoredTunnelID: "stored-1"},
+	})
+
+	testCases := []struct {
+		Name     string
+		Client   *clientdata.Client
+		Tunnel   *storedtunnels.StoredTunnel
+		Expected string
+	}{
+		{
+			Name:     "running",
+			Client:   client,
+			Tunnel:   &storedtunnels.StoredTunnel{ID: "stored-1", State: storedtunnels.StateRunning},
+			Expected: storedtunnels.StateRunning,
+		},
+		{
+			Name:     "closed",
+			Client:   client,
+			Tunnel:   &storedtunnels.StoredTunnel{ID: "stored-2", State: storedtunnels.StateRunning},
+			Expected: storedtunnels.StateStopped,
+		},
+		{
+			Name:     "client deleted",
+			Tunnel:   &storedtunnels.StoredTunnel{ID: "stored-1", State: storedtunnels.StateRunning},
+			Expected: storedtunnels.StateStopped,
+		},
+		{
+			Name:     "failed",
+			Client:   client,
+			Tunnel:   &storedtunnels.StoredTunnel{ID: "stored-2", State: storedtunnels.StateFailed},
+			Expected: storedtunnels.StateFailed,
+		},
+		{
+			Name:     "never started",
+			Client:   client,
+			Tunnel:   &storedtunnels.StoredTunnel{ID: "stored-2"},
+			Expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, StoredTunnelState(tc.Client, tc.Tunnel))
+		})
+	}
+}
+
+func TestWithoutStoredTunnels(t *testing.T) {
+	manual := &models.Remote{RemoteHost: "127.0.0.1", RemotePort: "22"}
+	stored := &models.Remote{RemoteHost: "127.0.0.1", RemotePort: "80", StoredTunnelID: "stored-1"}
+
+	assert.Equal(t, []*models.Remote{manual}, withoutStoredTunnels([]*models.Remote{manual, stored}))
+}
@@ -6,15 +6,38 @@ import (
 	"github.com/openrport/openrport/share/types"
 )
 
+// auto start policies of a stored tunnel, the tunnel is started only on request by default
+const (
+	AutoStartNone      = ""
+	AutoStartOnConnect = "on_connect"
+	AutoStartSchedule  = "schedule"
+	AutoStartAlways    = "always"
+)
+
+// states of a stored tunnel with an auto start policy
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+	StateFailed  = "failed"
+)
+
 type StoredTunnel struct {
-	ID             string            `json:"id" db:"id"`
-	ClientID       string            `json:"-" db:"client_id"`
-	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
-	Name           string            `json:"name" db:"name"`
-	Scheme         *string           `json:"scheme" db:"scheme"`
-	RemoteIP       *string           `json:"remote_ip" db:"remote_ip"`
-	RemotePort     *int              `json:"remote_port" db:"remote_port"`
-	PublicPort     *int              `json:"public_port" db:"public_port"`
-	ACL            *string           `json:"acl" db:"acl"`
-	FurtherOptions *types.JSONString `json:"further_options" db:"further_options"`
+	ID                 string            `json:"id" db:"id"`
+	ClientID           string            `json:"-" db:"client_id"`
+	CreatedAt          time.Time         `json:"created_at" db:"created_at"`
+	CreatedBy          string            `json:"created_by,omitempty" db:"created_by"`
+	Name               string            `json:"name" db:"name"`
+	Scheme             *string           `json:"scheme" db:"scheme"`
+	RemoteIP           *string           `json:"remote_ip" db:"remote_ip"`
+	RemotePort         *int              `json:"remote_port" db:"remote_port"`
+	PublicPort         *int              `json:"public_port" db:"public_port"`
+	ACL                *string           `json:"acl" db:"acl"`
+	FurtherOptions     *types.JSONString `json:"further_options" db:"further_options"`
+	AutoStart          string            `json:"auto_start" db:"auto_start"`
+	AutoStartSchedule  *string           `json:"auto_start_schedule" db:"auto_start_schedule"`
+	IdleTimeoutMinutes *int              `json:"idle_timeout_minutes" db:"idle_timeout_minutes"`
+	AutoClose          *string           `json:"auto_close" db:"auto_close"`
+	State              string            `json:"state" db:"state"`
+	LastError          string            `json:"last_error" db:"last_error"`
+	StateChangedAt     *time.Time        `json:"state_changed_at" db:"state_changed_at"`
 }
@@ -2,6 +2,7 @@ package storedtunnels
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -26,24 +27,34 @@ func (p *SQLiteProvider) Insert(ctx context.Context, t *StoredTunnel) error {
 			id,
 			client_id,
 			created_at,
+			created_by,
 			name,
 			scheme,
 			remote_ip,
 			remote_port,
 			public_port,
 			acl,
-			further_options
+			further_options,
+			auto_start,
+			auto_start_schedule,
+			idle_timeout_minutes,
+			auto_close
 		) VALUES (
 			:id,
 			:client_id,
 			:created_at,
+			:created_by,
 			:name,
 			:scheme,
 			:remote_ip,
 			:remote_port,
 			:public_port,
 			:acl,
-			:further_options
+			:further_options,
+			:auto_start,
+			:auto_start_schedule,
+			:idle_timeout_minutes,
+			:auto_close
 		)`,
 		t,
 	)
@@ -60,7 +71,11 @@ func (p *SQLiteProvider) Update(ctx context.Context, t *StoredTunnel) error {
 			remote_port = :remote_port,
 			public_port = :public_port,
 			acl = :acl,
-			further_options = :further_options
+			further_options = :further_options,
+			auto_start = :auto_start,
+			auto_start_schedule = :auto_start_schedule,
+			idle_timeout_minutes = :idle_timeout_minutes,
+			auto_close = :auto_close
 		WHERE client_id = :client_id AND id = :id`,
 		t,
 	)
@@ -76,7 +91,7 @@ func (p *SQLiteProvider) List(ctx context.Context, clientID string, options *que
 
 	q, params = p.converter.AppendOptionsToQuery(options, q, params)
 
-	err := p.db.SelectContext(ctx, &values, q, params...)
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
 	if err != nil {
 		return values, err
 	}
@@ -94,7 +109,7 @@ func (p *SQLiteProvider) Count(ctx context.Context, clientID string, options *qu
 	countOptions.Pagination = nil
 	q, params = p.converter.AppendOptionsToQuery(&countOptions, q, params)
 
-	err := p.db.GetContext(ctx, &result, q, params...)
+	err := p.db.GetContext(ctx, &result, p.converter.Rebind(q), params...)
 	if err != nil {
 		return 0, err
 	}
@@ -103,6 +118,29 @@ func (p *SQLiteProvider) Count(ctx context.Context, clientID string, options *qu
 }
 
 func (p *SQLiteProvider) Delete(ctx context.Context, clientID, id string) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM stored_tunnels WHERE client_id = ? AND id = ?", clientID, id)
+	_, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM stored_tunnels WHERE client_id = ? AND id = ?"), clientID, id)
+	return err
+}
+
+func (p *SQLiteProvider) ListAutoStart(ctx context.Context, clientID string) ([]*StoredTunnel, error) {
+	values := []*StoredTunnel{}
+
+	q := "SELECT * FROM stored_tunnels WHERE auto_start != ''"
+	var params []interface{}
+	if clientID != "" {
+		q += " AND client_id = ?"
+		params = append(params, clientID)
+	}
+	q += " ORDER BY client_id, created_at"
+
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
+	return values, err
+}
+
+func (p *SQLiteProvider) SetState(ctx context.Context, clientID, id, state, lastError string) error {
+	_, err := p.db.ExecContext(ctx,
+		p.converter.Rebind("UPDATE stored_tunnels SET state = ?, last_error = ?, state_changed_at = ? WHERE client_id = ? AND id = ?"),
+		state, lastError, time.Now().UTC(), clientID, id,
+	)
 	return err
 }
@@ -2,11 +2,19 @@ package storedtunnels
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	cron "github.com/robfig/cron/v3"
 
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/validation"
+	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/query"
 	"github.com/openrport/openrport/share/random"
 )
@@ -17,6 +25,7 @@ var (
 		"scheme":      true,
 		"remote_ip":   true,
 		"remote_port": true,
+		"auto_start":  true,
 	}
 	supportedSorts = map[string]bool{
 		"created_at":  true,
@@ -33,8 +42,14 @@ type Provider interface {
 	Update(context.Context, *StoredTunnel) error
 	List(context.Context, string, *query.ListOptions) ([]*StoredTunnel, error)
 	Count(context.Context, string, *query.ListOptions) (int, error)
+	ListAutoStart(ctx context.Context, clientID string) ([]*StoredTunnel, error)
+	SetState(ctx context.Context, clientID, id, state, lastError string) error
 }
 
+// ScheduleParser parses the cron spec of stored tunnels with the schedule auto start policy, it's the same format as
+// used by the command and script schedules.
+var ScheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
 type Manager struct {
 	provider Provider
 }
@@ -79,6 +94,14 @@ func (m *Manager) Create(ctx context.Context, clientID string, t *StoredTunnel)
 	t.ID = id
 	t.CreatedAt = time.Now()
 	t.ClientID = clientID
+	t.State = ""
+	t.LastError = ""
+	t.StateChangedAt = nil
+
+	err = validate(t)
+	if err != nil {
+		return nil, err
+	}
 
 	err = m.provider.Insert(ctx, t)
 	if err != nil {
@@ -90,8 +113,16 @@ func (m *Manager) Create(ctx context.Context, clientID string, t *StoredTunnel)
 
 func (m *Manager) Update(ctx context.Context, clientID string, t *StoredTunnel) (*StoredTunnel, error) {
 	t.ClientID = clientID
+	t.State = ""
+	t.LastError = ""
+	t.StateChangedAt = nil
+
+	err := validate(t)
+	if err != nil {
+		return nil, err
+	}
 
-	err := m.provider.Update(ctx, t)
+	err = m.provider.Update(ctx, t)
 	if err != nil {
 		return nil, err
 	}
@@ -102,3 +133,105 @@ func (m *Manager) Update(ctx context.Context, clientID string, t *StoredTunnel)
 func (m *Manager) Delete(ctx context.Context, clientID, id string) error {
 	return m.provider.Delete(ctx, clientID, id)
 }
+
+// ListAutoStart returns the stored tunnels having an auto start policy of the given client or of all clients if
+// clientID is empty
+func (m *Manager) ListAutoStart(ctx context.Context, clientID string) ([]*StoredTunnel, error) {
+	return m.provider.ListAutoStart(ctx, clientID)
+}
+
+// SetState records the state of an auto started tunnel, lastError is empty unless the tunnel failed to start
+func (m *Manager) SetState(ctx context.Context, clientID, id, state, lastError string) error {
+	return m.provider.SetState(ctx, clientID, id, state, lastError)
+}
+
+func validate(t *StoredTunnel) error {
+	switch t.AutoStart {
+	case AutoStartNone:
+		return nil
+	case AutoStartOnConnect, AutoStartSchedule, AutoStartAlways:
+	default:
+		return errors.APIError{
+			Message:    "Invalid auto_start.",
+			Err:        fmt.Errorf("auto_start must be one of %q, %q, %q or empty", AutoStartOnConnect, AutoStartSchedule, AutoStartAlways),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	if t.RemoteIP == nil || *t.RemoteIP == "" || t.RemotePort == nil {
+		return errors.APIError{
+			Message:    "remote_ip and remote_port are required to start the tunnel automatically.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	if t.AutoStart == AutoStartSchedule {
+		if t.AutoStartSchedule == nil {
+			return errors.APIError{
+				Message:    "auto_start_schedule is required.",
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+		if _, err := ScheduleParser.Parse(*t.AutoStartSchedule); err != nil {
+			return errors.APIError{
+				Message:    "Invalid auto_start_schedule.",
+				Err:        err,
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+
+	if t.AutoStart == AutoStartAlways && (t.IdleTimeoutMinutes != nil || t.AutoClose != nil) {
+		return errors.APIError{
+			Message:    "Tunnels started always can't be closed automatically, idle_timeout_minutes and auto_close must not be set.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	_, err := t.ToRemote()
+	return err
+}
+
+// ToRemote returns the remote to start the tunnel with
+func (t *StoredTunnel) ToRemote() (*models.Remote, error) {
+	if t.RemoteIP == nil || t.RemotePort == nil {
+		return nil, fmt.Errorf("stored tunnel %s has no remote", t.ID)
+	}
+
+	remoteStr := net.JoinHostPort(*t.RemoteIP, strconv.Itoa(*t.RemotePort))
+	if t.PublicPort != nil {
+		remoteStr = strconv.Itoa(*t.PublicPort) + ":" + remoteStr
+	}
+	remote, err := models.NewRemote(remoteStr)
+	if err != nil {
+		return nil, errors.APIError{
+			Message:    fmt.Sprintf("Invalid remote %q.", remoteStr),
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	remote.Name = t.Name
+	remote.Scheme = t.Scheme
+	remote.ACL = t.ACL
+	remote.StoredTunnelID = t.ID
+	// vault placeholders of auto started tunnels are resolved with the vault access of the user who stored the tunnel
+	remote.Owner = t.CreatedBy
+
+	if t.IdleTimeoutMinutes != nil {
+		idleTimeout, err := validation.ResolveIdleTunnelTimeoutValue(strconv.Itoa(*t.IdleTimeoutMinutes), false)
+		if err != nil {
+			return nil, err
+		}
+		remote.IdleTimeoutMinutes = int(idleTimeout.Minutes())
+	}
+
+	if t.AutoClose != nil {
+		remote.AutoClose, err = validation.ResolveTunnelAutoCloseValue(*t.AutoClose)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return remote, nil
+}
@@ -3,12 +3,15 @@ package storedtunnels
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/db/migration/clients"
 	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/ptr"
 	"github.com/openrport/openrport/share/query"
 	"github.com/openrport/openrport/share/random"
 )
@@ -54,3 +57,146 @@ func TestStoredTunnels(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, results.Meta.Count)
 }
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Tunnel        *StoredTunnel
+		ExpectedError string
+	}{
+		{
+			Name:   "no auto start",
+			Tunnel: &StoredTunnel{},
+		},
+		{
+			Name:          "invalid auto start",
+			Tunnel:        &StoredTunnel{AutoStart: "sometimes", RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22)},
+			ExpectedError: `auto_start must be one of "on_connect", "schedule", "always" or empty`,
+		},
+		{
+			Name:          "missing remote",
+			Tunnel:        &StoredTunnel{AutoStart: AutoStartOnConnect},
+			ExpectedError: "remote_ip and remote_port are required to start the tunnel automatically.",
+		},
+		{
+			Name:   "on connect",
+			Tunnel: &StoredTunnel{AutoStart: AutoStartOnConnect, RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22), IdleTimeoutMinutes: ptr.Int(10)},
+		},
+		{
+			Name:          "missing schedule",
+			Tunnel:        &StoredTunnel{AutoStart: AutoStartSchedule, RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22)},
+			ExpectedError: "auto_start_schedule is required.",
+		},
+		{
+			Name:          "invalid schedule",
+			Tunnel:        &StoredTunnel{AutoStart: AutoStartSchedule, AutoStartSchedule: ptr.String("* * *"), RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22)},
+			ExpectedError: "expected exactly 5 fields, found 3: [* * *]",
+		},
+		{
+			Name:   "schedule",
+			Tunnel: &StoredTunnel{AutoStart: AutoStartSchedule, AutoStartSchedule: ptr.String("0 8 * * 1-5"), RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22), AutoClose: ptr.String("9h")},
+		},
+		{
+			Name:          "always with auto close",
+			Tunnel:        &StoredTunnel{AutoStart: AutoStartAlways, RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22), AutoClose: ptr.String("1h")},
+			ExpectedError: "Tunnels started always can't be closed automatically, idle_timeout_minutes and auto_close must not be set.",
+		},
+		{
+			Name:          "invalid auto close",
+			Tunnel:        &StoredTunnel{AutoStart: AutoStartOnConnect, RemoteIP: ptr.String("127.0.0.1"), RemotePort: ptr.Int(22), AutoClose: ptr.String("soon")},
+			ExpectedError: `time: invalid duration "soon"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := validate(tc.Tunnel)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestToRemote(t *testing.T) {
+	tunnel := &StoredTunnel{
+		ID:                 "stored-1",
+		Name:               "ssh",
+		RemoteIP:           ptr.String("127.0.0.1"),
+		RemotePort:         ptr.Int(22),
+		PublicPort:         ptr.Int(2222),
+		Scheme:             ptr.String("ssh"),
+		ACL:                ptr.String("10.0.0.0/8"),
+		IdleTimeoutMinutes: ptr.Int(10),
+		AutoClose:          ptr.String("2h"),
+		CreatedBy:          "admin",
+	}
+
+	remote, err := tunnel.ToRemote()
+	require.NoError(t, err)
+
+	assert.Equal(t, &models.Remote{
+		Name:               "ssh",
+		Protocol:           models.ProtocolTCP,
+		LocalHost:          models.ZeroHost,
+		LocalPort:          "2222",
+		RemoteHost:         "127.0.0.1",
+		RemotePort:         "22",
+		Scheme:             ptr.String("ssh"),
+		ACL:                ptr.String("10.0.0.0/8"),
+		IdleTimeoutMinutes: 10,
+		AutoClose:          2 * time.Hour,
+		StoredTunnelID:     "stored-1",
+		Owner:              "admin",
+	}, remote)
+}
+
+func TestAutoStartState(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", clients.AssetNames(), clients.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	manager := New(db)
+
+	manual, err := manager.Create(ctx, "client-1", &StoredTunnel{Name: "manual"})
+	require.NoError(t, err)
+	always, err := manager.Create(ctx, "client-1", &StoredTunnel{
+		Name:       "always",
+		AutoStart:  AutoStartAlways,
+		RemoteIP:   ptr.String("127.0.0.1"),
+		RemotePort: ptr.Int(22),
+		State:      StateRunning, // ignored
+		CreatedBy:  "admin",
+	})
+	require.NoError(t, err)
+	_, err = manager.Create(ctx, "client-2", &StoredTunnel{
+		Name:       "on connect",
+		AutoStart:  AutoStartOnConnect,
+		RemoteIP:   ptr.String("127.0.0.1"),
+		RemotePort: ptr.Int(80),
+	})
+	require.NoError(t, err)
+
+	all, err := manager.ListAutoStart(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	tunnels, err := manager.ListAutoStart(ctx, "client-1")
+	require.NoError(t, err)
+	require.Len(t, tunnels, 1)
+	assert.Equal(t, always.ID, tunnels[0].ID)
+	assert.Equal(t, "admin", tunnels[0].CreatedBy)
+	assert.Equal(t, "", tunnels[0].State)
+	assert.Nil(t, tunnels[0].StateChangedAt)
+
+	require.NoError(t, manager.SetState(ctx, "client-1", always.ID, StateFailed, "port busy"))
+
+	tunnels, err = manager.ListAutoStart(ctx, "client-1")
+	require.NoError(t, err)
+	require.Len(t, tunnels, 1)
+	assert.Equal(t, StateFailed, tunnels[0].State)
+	assert.Equal(t, "port busy", tunnels[0].LastError)
+	assert.NotNil(t, tunnels[0].StateChangedAt)
+	assert.NotEqual(t, manual.ID, tunnels[0].ID)
+}
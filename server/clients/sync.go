@@ -0,0 +1,16 @@
+package clients
+
+import "context"
+
+// SyncTask keeps the clients connected to the other nodes of a high availability cluster up to date.
+type SyncTask struct {
+	cr *ClientRepository
+}
+
+func NewSyncTask(cr *ClientRepository) *SyncTask {
+	return &SyncTask{cr: cr}
+}
+
+func (t *SyncTask) Run(ctx context.Context) error {
+	return t.cr.SyncFromStore(ctx)
+}
@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/test"
+)
+
+type fakeClientNodes map[string]bool
+
+func (f fakeClientNodes) ClientsConnectedElsewhere(ctx context.Context) (map[string]bool, error) {
+	return f, nil
+}
+
+func TestSyncFromStore(t *testing.T) {
+	ctx := context.Background()
+	local := New(t).ID("local").Connection(test.NewConnMock()).Logger(testLog).Build()
+	localStored := New(t).ID("local").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+	elsewhere := New(t).ID("elsewhere").Logger(testLog).Build()
+	elsewhereLoaded := New(t).ID("elsewhere").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+	nodeGone := New(t).ID("node-gone").Logger(testLog).Build()
+	deleted := New(t).ID("deleted").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+	added := New(t).ID("added").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+
+	p := NewFakeClientProvider(t, nil, localStored, elsewhere, nodeGone, added)
+	defer p.Close()
+	repo := NewClientRepositoryWithDB([]*clientdata.Client{local, elsewhereLoaded, deleted}, nil, p, testLog)
+	repo.clientNodes = fakeClientNodes{"elsewhere": true}
+
+	require.NoError(t, NewSyncTask(repo).Run(ctx))
+
+	assert.Len(t, repo.clientState, 4)
+	assert.Same(t, local, repo.clientState["local"], "clients connected here are kept")
+	assert.True(t, repo.clientState["elsewhere"].IsConnected())
+	assert.False(t, repo.clientState["elsewhere"].IsConnectedHere())
+	assert.False(t, repo.clientState["node-gone"].IsConnected())
+	assert.False(t, repo.clientState["added"].IsConnected())
+	assert.Nil(t, repo.clientState["deleted"])
+
+	stored, err := p.get(ctx, "node-gone", testLog)
+	require.NoError(t, err)
+	assert.True(t, stored.IsConnected(), "the store is left to the node holding the connection")
+}
+
+func TestLoadInitialClientsKeepsClientsOfOtherNodes(t *testing.T) {
+	ctx := context.Background()
+	elsewhere := New(t).ID("elsewhere").Logger(testLog).Build()
+	previous := New(t).ID("previous").Logger(testLog).Build()
+
+	p := NewFakeClientProvider(t, nil, elsewhere, previous)
+	defer p.Close()
+
+	loaded, err := LoadInitialClients(ctx, p, fakeClientNodes{"elsewhere": true}, testLog)
+	require.NoError(t, err)
+
+	connected := make(map[string]bool)
+	for _, c := range loaded {
+		connected[c.GetID()] = c.IsConnected()
+	}
+	assert.Equal(t, map[string]bool{"elsewhere": true, "previous": false}, connected)
+}
@@ -0,0 +1,54 @@
+package clients
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/vaultplaceholder"
+)
+
+// VaultResolver resolves the vault placeholders in text with the vault access of the user
+type VaultResolver func(ctx context.Context, text, clientID, username string) (string, error)
+
+// resolveTunnelSecrets resolves the vault placeholders of the passwords of a tunnel started without a request of a
+// user, e.g. re-established when the client reconnects after a restart of the server. The resolved passwords are only
+// kept in memory, so they are resolved again with the vault access of the owner of the tunnel.
+func (s *ClientServiceProvider) resolveTunnelSecrets(ctx context.Context, clientID string, r *models.Remote) (err error) {
+	resolveAuth := vaultplaceholder.Has(r.AuthPassword) && r.ResolvedAuthPassword == ""
+	resolveSSH := vaultplaceholder.Has(r.SSHPassword) && r.ResolvedSSHPassword == ""
+	if !resolveAuth && !resolveSSH {
+		return nil
+	}
+	if s.resolveVault == nil || r.Owner == "" {
+		return errors.New("vault placeholders of the tunnel can't be resolved without the user who created it")
+	}
+
+	if resolveAuth {
+		r.ResolvedAuthPassword, err = s.resolveVault(ctx, r.AuthPassword, clientID, r.Owner)
+		if err != nil {
+			return err
+		}
+	}
+	if resolveSSH {
+		r.ResolvedSSHPassword, err = s.resolveVault(ctx, r.SSHPassword, clientID, r.Owner)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// excludeUnresolvableTunnels removes the tunnels to re-establish whose vault placeholders can't be resolved
+func (s *ClientServiceProvider) excludeUnresolvableTunnels(ctx context.Context, clog *logger.Logger, clientID string, tunnels []*models.Remote) []*models.Remote {
+	filtered := make([]*models.Remote, 0, len(tunnels))
+	for _, t := range tunnels {
+		if err := s.resolveTunnelSecrets(ctx, clientID, t); err != nil {
+			clog.Infof("Tunnel %q can't be re-established, removing: %v", t, err)
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
@@ -0,0 +1,34 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openrport/openrport/share/models"
+)
+
+func TestExcludeUnresolvableTunnels(t *testing.T) {
+	cs := &ClientServiceProvider{logger: testLog}
+	cs.SetVaultResolver(func(ctx context.Context, text, clientID, username string) (string, error) {
+		if username != "admin" {
+			return "", errors.New("no access")
+		}
+		return "resolved-" + clientID, nil
+	})
+
+	plain := &models.Remote{RemotePort: "80", AuthUser: "user", AuthPassword: "pwd"}
+	resolved := &models.Remote{RemotePort: "81", AuthUser: "user", AuthPassword: `{{ vault "pwd" }}`, ResolvedAuthPassword: "kept"}
+	owned := &models.Remote{RemotePort: "82", Owner: "admin", AuthUser: "user", AuthPassword: `{{ vault "pwd" }}`, SSHUser: "root", SSHPassword: `{{ vault "root" }}`}
+	noAccess := &models.Remote{RemotePort: "83", Owner: "other", AuthUser: "user", AuthPassword: `{{ vault "pwd" }}`}
+	noOwner := &models.Remote{RemotePort: "84", SSHUser: "root", SSHPassword: `{{ vault "root" }}`}
+
+	got := cs.excludeUnresolvableTunnels(context.Background(), testLog, "client-1", []*models.Remote{plain, resolved, owned, noAccess, noOwner})
+
+	assert.Equal(t, []*models.Remote{plain, resolved, owned}, got)
+	assert.Equal(t, "kept", resolved.ResolvedAuthPassword)
+	assert.Equal(t, "resolved-client-1", owned.ResolvedAuthPassword)
+	assert.Equal(t, "resolved-client-1", owned.ResolvedSSHPassword)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/mattn/go-sqlite3"
 
 	"github.com/openrport/openrport/share/query"
@@ -13,7 +14,10 @@ import (
 	"github.com/openrport/openrport/share/enums"
 )
 
-const mysqlDuplicateEntryErrorCode = 1062
+const (
+	mysqlDuplicateEntryErrorCode     = 1062
+	postgresUniqueViolationErrorCode = "23505"
+)
 
 type DatabaseProvider struct {
 	db        *sqlx.DB
@@ -39,17 +43,17 @@ func (c *DatabaseProvider) GetFiltered(filter *query.ListOptions) ([]*ClientAuth
 	filter.Sorts = nil
 	cQuery, cParams := c.converter.ConvertListOptionsToQuery(filter, fmt.Sprintf("SELECT COUNT(id) FROM %s", c.tableName))
 	var count = 0
-	if err := c.db.Get(&count, cQuery, cParams...); err != nil {
+	if err := c.db.Get(&count, c.converter.Rebind(cQuery), cParams...); err != nil {
 		return nil, 0, err
 	}
 	var result = []*ClientAuth{}
-	err := c.db.Select(&result, rQuery, rParams...)
+	err := c.db.Select(&result, c.converter.Rebind(rQuery), rParams...)
 	return result, count, err
 }
 
 func (c *DatabaseProvider) Get(id string) (*ClientAuth, error) {
 	result := &ClientAuth{}
-	err := c.db.Get(result, fmt.Sprintf("SELECT id, password FROM %s WHERE id = ?", c.tableName), id)
+	err := c.db.Get(result, c.converter.Rebind(fmt.Sprintf("SELECT id, password FROM %s WHERE id = ?", c.tableName)), id)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -69,6 +73,10 @@ func (c *DatabaseProvider) Add(client *ClientAuth) (bool, error) {
 			if typeErr.Number == mysqlDuplicateEntryErrorCode {
 				return false, nil
 			}
+		case *pq.Error:
+			if typeErr.Code == postgresUniqueViolationErrorCode {
+				return false, nil
+			}
 		}
 		return false, err
 	}
@@ -76,7 +84,7 @@ func (c *DatabaseProvider) Add(client *ClientAuth) (bool, error) {
 }
 
 func (c *DatabaseProvider) Delete(id string) error {
-	_, err := c.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", c.tableName), id)
+	_, err := c.db.Exec(c.converter.Rebind(fmt.Sprintf("DELETE FROM %s WHERE id = ?", c.tableName)), id)
 	return err
 }
 
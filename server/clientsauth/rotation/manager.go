@@ -0,0 +1,260 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/random"
+)
+
+// RequestTimeout is how long the server waits for the client to confirm it stored the new password
+const RequestTimeout = 30 * time.Second
+
+type Provider interface {
+	Get(ctx context.Context, clientAuthID string) (*Rotation, error)
+	ListDue(ctx context.Context, now time.Time) ([]*Rotation, error)
+	Save(ctx context.Context, r *Rotation) error
+	Delete(ctx context.Context, clientAuthID string) error
+}
+
+type ClientGetter interface {
+	GetAllByClientID(clientAuthID string) []*clientdata.Client
+}
+
+// Manager rotates the passwords of client auths. The client receives the new password over its connection,
+// so it stays connected. The previous password stays valid during the grace period, so a client that
+// reconnects before it stored the new password isn't locked out.
+type Manager struct {
+	provider           Provider
+	clientAuthProvider clientsauth.Provider
+	clients            ClientGetter
+	gracePeriod        time.Duration
+	logger             *logger.Logger
+
+	now func() time.Time
+}
+
+func NewManager(provider Provider, clientAuthProvider clientsauth.Provider, clients ClientGetter, gracePeriod time.Duration, logger *logger.Logger) *Manager {
+	return &Manager{
+		provider:           provider,
+		clientAuthProvider: clientAuthProvider,
+		clients:            clients,
+		gracePeriod:        gracePeriod,
+		logger:             logger,
+		now:                time.Now,
+	}
+}
+
+// Get returns the rotation state of the client auth, it's empty if it was never rotated or scheduled
+func (m *Manager) Get(ctx context.Context, clientAuthID string) (*Rotation, error) {
+	if err := m.checkClientAuthExists(clientAuthID); err != nil {
+		return nil, err
+	}
+
+	r, err := m.provider.Get(ctx, clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		r = &Rotation{ClientAuthID: clientAuthID}
+	}
+	return r, nil
+}
+
+// Schedule sets the interval of the rotations, the next rotation is due after the interval. An interval of 0 stops the rotations.
+func (m *Manager) Schedule(ctx context.Context, clientAuthID string, intervalSec int64) (*Rotation, error) {
+	if intervalSec < 0 {
+		return nil, errors2.APIError{
+			Message:    "interval_sec must not be negative.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	r, err := m.Get(ctx, clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.IntervalSec = intervalSec
+	r.NextRotationAt = nil
+	if intervalSec > 0 {
+		next := m.now().UTC().Add(time.Duration(intervalSec) * time.Second)
+		r.NextRotationAt = &next
+	}
+
+	if err := m.provider.Save(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Rotate sends a new password to the connected client and stores it once the client confirmed it
+func (m *Manager) Rotate(ctx context.Context, clientAuthID string) (*Rotation, error) {
+	r, err := m.Get(ctx, clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := m.clientAuthProvider.Get(clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := m.connectedClient(clientAuthID)
+	if err != nil {
+		return nil, m.saveError(ctx, r, err)
+	}
+
+	newPassword, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+
+	// the new password is accepted before the client gets it, in case the client stores it
+	// and reconnects without confirming the rotation
+	if err := m.setAlternate(r, newPassword); err != nil {
+		return nil, err
+	}
+	if err := m.provider.Save(ctx, r); err != nil {
+		return nil, err
+	}
+
+	reqBytes, err := json.Marshal(comm.RotateCredentialsRequest{Password: newPassword})
+	if err != nil {
+		return nil, err
+	}
+	ok, respBytes, err := comm.SendRequestWithTimeout(ctx, client.GetConnection(), comm.RequestTypeRotateCredentials, true, reqBytes, RequestTimeout, m.logger)
+	if err == nil && !ok {
+		err = fmt.Errorf("client error: %s", respBytes)
+	}
+	if err != nil {
+		return nil, m.saveError(ctx, r, fmt.Errorf("client %s didn't confirm the rotation: %w", client.GetID(), err))
+	}
+
+	// the provider can't update, the credentials are replaced. If the new password can't be stored, the previous
+	// credentials are restored and the new password, which the client uses already, stays accepted without expiry
+	// until the next rotation.
+	if err := m.clientAuthProvider.Delete(clientAuthID); err != nil {
+		return nil, m.saveError(ctx, r, fmt.Errorf("failed to delete the previous credentials: %w", err))
+	}
+	if _, err := m.clientAuthProvider.Add(&clientsauth.ClientAuth{ID: clientAuthID, Password: newPassword}); err != nil {
+		if _, restoreErr := m.clientAuthProvider.Add(clientAuth); restoreErr != nil {
+			m.logger.Errorf("Failed to restore the previous credentials of client auth %s: %v", clientAuthID, restoreErr)
+		}
+		r.AlternateValidUntil = nil
+		return nil, m.saveError(ctx, r, fmt.Errorf("failed to add the new credentials: %w", err))
+	}
+	if err := m.setAlternate(r, clientAuth.Password); err != nil {
+		return nil, err
+	}
+
+	now := m.now().UTC()
+	r.LastRotatedAt = &now
+	r.LastError = ""
+	if r.IntervalSec > 0 {
+		next := now.Add(time.Duration(r.IntervalSec) * time.Second)
+		r.NextRotationAt = &next
+	}
+	if err := m.provider.Save(ctx, r); err != nil {
+		return nil, err
+	}
+
+	m.logger.Infof("Password of client auth %s rotated, the previous password is valid until %s", clientAuthID, r.AlternateValidUntil)
+	return r, nil
+}
+
+func (m *Manager) checkClientAuthExists(clientAuthID string) error {
+	clientAuth, err := m.clientAuthProvider.Get(clientAuthID)
+	if err != nil {
+		return err
+	}
+	if clientAuth == nil {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("Client Auth with ID=%q not found.", clientAuthID),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+// connectedClient returns the only connected client using the client auth. Client auths used by
+// several clients can't be rotated, the disconnected ones wouldn't receive the new password.
+func (m *Manager) connectedClient(clientAuthID string) (*clientdata.Client, error) {
+	all := m.clients.GetAllByClientID(clientAuthID)
+	var connected *clientdata.Client
+	for _, c := range all {
+		if c.IsConnectedHere() {
+			connected = c
+		}
+	}
+	if len(all) > 1 {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("Client Auth with ID=%q is used by %d clients.", clientAuthID, len(all)),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+	if connected == nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("No client with Client Auth ID=%q is connected.", clientAuthID),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+	return connected, nil
+}
+
+func (m *Manager) hasConnectedClient(clientAuthID string) bool {
+	for _, c := range m.clients.GetAllByClientID(clientAuthID) {
+		if c.IsConnectedHere() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) setAlternate(r *Rotation, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	validUntil := m.now().UTC().Add(m.gracePeriod)
+	r.AlternatePasswordHash = string(hash)
+	r.AlternateValidUntil = &validUntil
+	return nil
+}
+
+// saveError stores the error of a failed rotation and returns it
+func (m *Manager) saveError(ctx context.Context, r *Rotation, err error) error {
+	r.LastError = err.Error()
+	if saveErr := m.provider.Save(ctx, r); saveErr != nil {
+		m.logger.Errorf("Failed to save rotation error of client auth %s: %v", r.ClientAuthID, saveErr)
+	}
+	return err
+}
+
+// CheckAlternatePassword returns true if the password is accepted for the client auth during the grace period of a rotation,
+// or until the next rotation if the new password of the last one couldn't be stored
+func (m *Manager) CheckAlternatePassword(ctx context.Context, clientAuthID string, password []byte) bool {
+	r, err := m.provider.Get(ctx, clientAuthID)
+	if err != nil {
+		m.logger.Errorf("Failed to get rotation of client auth %s: %v", clientAuthID, err)
+		return false
+	}
+	if r == nil || r.AlternatePasswordHash == "" || (r.AlternateValidUntil != nil && !m.now().Before(*r.AlternateValidUntil)) {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(r.AlternatePasswordHash), password) == nil
+}
+
+// Delete removes the rotation state of a deleted client auth
+func (m *Manager) Delete(ctx context.Context, clientAuthID string) error {
+	return m.provider.Delete(ctx, clientAuthID)
+}
@@ -0,0 +1,203 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientsmigration "github.com/openrport/openrport/db/migration/clients"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/test"
+)
+
+var testLog = logger.NewLogger("rotation", logger.LogOutput{File: nil}, logger.LogLevelDebug)
+
+type clientsMock map[string][]*clientdata.Client
+
+func (m clientsMock) GetAllByClientID(clientAuthID string) []*clientdata.Client {
+	return m[clientAuthID]
+}
+
+func newTestManager(t *testing.T, clients clientsMock) (*Manager, clientsauth.Provider) {
+	db := test.NewMemoryDB(t, clientsmigration.AssetNames(), clientsmigration.Asset)
+
+	clientAuthProvider := clientsauth.NewMockFileProvider([]*clientsauth.ClientAuth{
+		{ID: "auth-1", Password: "old-password"},
+		{ID: "auth-2", Password: "pass-2"},
+	}, t)
+	return NewManager(NewSQLiteProvider(db), clientAuthProvider, clients, time.Hour, testLog), clientAuthProvider
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	conn := test.NewConnMock()
+	conn.ReturnOk = true
+	m, clientAuthProvider := newTestManager(t, clientsMock{
+		"auth-1": {clients.New(t).ID("client-1").ClientAuthID("auth-1").Connection(conn).Build()},
+	})
+
+	r, err := m.Rotate(ctx, "auth-1")
+	require.NoError(t, err)
+	assert.Empty(t, r.LastError)
+	assert.NotNil(t, r.LastRotatedAt)
+	assert.Nil(t, r.NextRotationAt)
+
+	name, _, payload := conn.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeRotateCredentials, name)
+	req := comm.RotateCredentialsRequest{}
+	require.NoError(t, json.Unmarshal(payload, &req))
+
+	stored, err := clientAuthProvider.Get("auth-1")
+	require.NoError(t, err)
+	assert.Equal(t, req.Password, stored.Password)
+
+	assert.True(t, m.CheckAlternatePassword(ctx, "auth-1", []byte("old-password")))
+	assert.False(t, m.CheckAlternatePassword(ctx, "auth-1", []byte(req.Password)), "the current password isn't an alternate")
+	assert.False(t, m.CheckAlternatePassword(ctx, "auth-2", []byte("old-password")))
+
+	m.now = func() time.Time { return time.Now().Add(time.Hour + time.Minute) }
+	assert.False(t, m.CheckAlternatePassword(ctx, "auth-1", []byte("old-password")), "grace period is over")
+}
+
+func TestRotateNotConfirmed(t *testing.T) {
+	ctx := context.Background()
+	conn := test.NewConnMock()
+	conn.ReturnOk = false
+	conn.ReturnResponsePayload = []byte("read-only file system")
+	m, clientAuthProvider := newTestManager(t, clientsMock{
+		"auth-1": {clients.New(t).ID("client-1").ClientAuthID("auth-1").Connection(conn).Build()},
+	})
+
+	_, err := m.Rotate(ctx, "auth-1")
+	require.Error(t, err)
+
+	stored, err := clientAuthProvider.Get("auth-1")
+	require.NoError(t, err)
+	assert.Equal(t, "old-password", stored.Password)
+
+	r, err := m.Get(ctx, "auth-1")
+	require.NoError(t, err)
+	assert.Contains(t, r.LastError, "read-only file system")
+	assert.Nil(t, r.LastRotatedAt)
+}
+
+// failingAddProvider deletes client auths but fails to add the given number of them
+type failingAddProvider struct {
+	clientsauth.Provider
+	failures int
+}
+
+func (p *failingAddProvider) Add(clientAuth *clientsauth.ClientAuth) (bool, error) {
+	if p.failures > 0 {
+		p.failures--
+		return false, errors.New("disk full")
+	}
+	return p.Provider.Add(clientAuth)
+}
+
+func TestRotateKeepsNewPasswordIfNotStored(t *testing.T) {
+	ctx := context.Background()
+	conn := test.NewConnMock()
+	conn.ReturnOk = true
+	m, clientAuthProvider := newTestManager(t, clientsMock{
+		"auth-1": {clients.New(t).ID("client-1").ClientAuthID("auth-1").Connection(conn).Build()},
+	})
+	m.clientAuthProvider = &failingAddProvider{Provider: clientAuthProvider, failures: 1}
+
+	_, err := m.Rotate(ctx, "auth-1")
+	assert.EqualError(t, err, "failed to add the new credentials: disk full")
+
+	clientAuth, err := clientAuthProvider.Get("auth-1")
+	require.NoError(t, err)
+	require.NotNil(t, clientAuth, "the previous credentials are restored")
+	assert.Equal(t, "old-password", clientAuth.Password)
+
+	r, err := m.Get(ctx, "auth-1")
+	require.NoError(t, err)
+	assert.Nil(t, r.AlternateValidUntil)
+	assert.Equal(t, "failed to add the new credentials: disk full", r.LastError)
+
+	_, _, payload := conn.InputSendRequest()
+	req := comm.RotateCredentialsRequest{}
+	require.NoError(t, json.Unmarshal(payload, &req))
+	m.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	assert.True(t, m.CheckAlternatePassword(ctx, "auth-1", []byte(req.Password)), "the client uses the new password already")
+}
+
+func TestRotateWithoutSingleConnectedClient(t *testing.T) {
+	ctx := context.Background()
+	disconnected := clients.New(t).ID("client-1").ClientAuthID("auth-1").DisconnectedDuration(time.Minute).Build()
+	m, _ := newTestManager(t, clientsMock{
+		"auth-1": {disconnected},
+		"auth-2": {
+			clients.New(t).ID("client-2").ClientAuthID("auth-2").Build(),
+			clients.New(t).ID("client-3").ClientAuthID("auth-2").Build(),
+		},
+	})
+
+	_, err := m.Rotate(ctx, "auth-1")
+	test.RequireHTTPStatus(t, err, 409)
+
+	_, err = m.Rotate(ctx, "auth-2")
+	test.RequireHTTPStatus(t, err, 409)
+
+	_, err = m.Rotate(ctx, "unknown")
+	test.RequireHTTPStatus(t, err, 404)
+}
+
+func TestScheduleAndTask(t *testing.T) {
+	ctx := context.Background()
+	conn := test.NewConnMock()
+	conn.ReturnOk = true
+	disconnected := clients.New(t).ID("client-2").ClientAuthID("auth-2").DisconnectedDuration(time.Minute).Build()
+	m, clientAuthProvider := newTestManager(t, clientsMock{
+		"auth-1": {clients.New(t).ID("client-1").ClientAuthID("auth-1").Connection(conn).Build()},
+		"auth-2": {disconnected},
+	})
+
+	_, err := m.Schedule(ctx, "auth-1", -1)
+	test.RequireHTTPStatus(t, err, 400)
+
+	r, err := m.Schedule(ctx, "auth-1", 3600)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3600), r.IntervalSec)
+	require.NotNil(t, r.NextRotationAt)
+	_, err = m.Schedule(ctx, "auth-2", 3600)
+	require.NoError(t, err)
+
+	task := NewTask(m, nil, testLog)
+	require.NoError(t, task.Run(ctx))
+	stored, err := clientAuthProvider.Get("auth-1")
+	require.NoError(t, err)
+	assert.Equal(t, "old-password", stored.Password, "not due yet")
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, task.Run(ctx))
+
+	stored, err = clientAuthProvider.Get("auth-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, "old-password", stored.Password)
+	r, err = m.Get(ctx, "auth-1")
+	require.NoError(t, err)
+	assert.True(t, r.NextRotationAt.After(time.Now().Add(2*time.Hour)))
+
+	stored, err = clientAuthProvider.Get("auth-2")
+	require.NoError(t, err)
+	assert.Equal(t, "pass-2", stored.Password, "disconnected clients are skipped")
+	r, err = m.Get(ctx, "auth-2")
+	require.NoError(t, err)
+	assert.Empty(t, r.LastError)
+
+	r, err = m.Schedule(ctx, "auth-1", 0)
+	require.NoError(t, err)
+	assert.Nil(t, r.NextRotationAt)
+}
@@ -0,0 +1,24 @@
+package rotation
+
+import (
+	"time"
+)
+
+// Rotation holds the rotation schedule of a client auth and the password still accepted after the last rotation
+type Rotation struct {
+	ClientAuthID string `json:"client_auth_id" db:"client_auth_id"`
+	// IntervalSec is the time between scheduled rotations, 0 if rotations are not scheduled
+	IntervalSec    int64      `json:"interval_sec" db:"interval_sec"`
+	NextRotationAt *time.Time `json:"next_rotation_at" db:"next_rotation_at"`
+	LastRotatedAt  *time.Time `json:"last_rotated_at" db:"last_rotated_at"`
+	LastError      string     `json:"last_error" db:"last_error"`
+	// AlternatePasswordHash is the bcrypt hash of the password accepted in addition to the current one until AlternateValidUntil.
+	// It's the previous password after a rotation, or the new one if the client didn't confirm the rotation.
+	// AlternateValidUntil is nil if the new password couldn't be stored, it's accepted until the next rotation then.
+	AlternatePasswordHash string     `json:"-" db:"alternate_password_hash"`
+	AlternateValidUntil   *time.Time `json:"alternate_valid_until" db:"alternate_valid_until"`
+}
+
+type ScheduleRequest struct {
+	IntervalSec int64 `json:"interval_sec"`
+}
@@ -0,0 +1,80 @@
+package rotation
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
+)
+
+type SQLiteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSQLiteProvider(db *sqlx.DB) *SQLiteProvider {
+	return &SQLiteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+func (p *SQLiteProvider) Get(ctx context.Context, clientAuthID string) (*Rotation, error) {
+	r := &Rotation{}
+	err := p.db.GetContext(ctx, r, p.converter.Rebind("SELECT * FROM client_auth_rotations WHERE client_auth_id = ?"), clientAuthID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListDue returns the rotations scheduled before the given time
+func (p *SQLiteProvider) ListDue(ctx context.Context, now time.Time) ([]*Rotation, error) {
+	values := []*Rotation{}
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind("SELECT * FROM client_auth_rotations WHERE next_rotation_at IS NOT NULL AND "+p.converter.DateTime("next_rotation_at")+" <= "+p.converter.DateTime("?")+" ORDER BY next_rotation_at"), now)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *SQLiteProvider) Save(ctx context.Context, r *Rotation) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO client_auth_rotations (
+			client_auth_id,
+			interval_sec,
+			next_rotation_at,
+			last_rotated_at,
+			last_error,
+			alternate_password_hash,
+			alternate_valid_until
+		) VALUES (
+			:client_auth_id,
+			:interval_sec,
+			:next_rotation_at,
+			:last_rotated_at,
+			:last_error,
+			:alternate_password_hash,
+			:alternate_valid_until
+		) ON CONFLICT (client_auth_id) DO UPDATE SET
+			interval_sec = EXCLUDED.interval_sec,
+			next_rotation_at = EXCLUDED.next_rotation_at,
+			last_rotated_at = EXCLUDED.last_rotated_at,
+			last_error = EXCLUDED.last_error,
+			alternate_password_hash = EXCLUDED.alternate_password_hash,
+			alternate_valid_until = EXCLUDED.alternate_valid_until`,
+		r,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) Delete(ctx context.Context, clientAuthID string) error {
+	_, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM client_auth_rotations WHERE client_auth_id = ?"), clientAuthID)
+	return err
+}
@@ -0,0 +1,54 @@
+package rotation
+
+import (
+	"context"
+
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/share/logger"
+)
+
+// Task rotates the passwords of all client auths with a due rotation
+type Task struct {
+	manager  *Manager
+	auditLog *auditlog.AuditLog
+	logger   *logger.Logger
+}
+
+func NewTask(manager *Manager, auditLog *auditlog.AuditLog, logger *logger.Logger) *Task {
+	return &Task{
+		manager:  manager,
+		auditLog: auditLog,
+		logger:   logger,
+	}
+}
+
+// Run rotates the due client auths of connected clients. Rotations of disconnected clients stay due
+// until the client connects. A failed rotation is retried on the next run.
+func (t *Task) Run(ctx context.Context) error {
+	due, err := t.manager.provider.ListDue(ctx, t.manager.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for _, r := range due {
+		if !t.manager.hasConnectedClient(r.ClientAuthID) {
+			continue
+		}
+
+		rotated, err := t.manager.Rotate(ctx, r.ClientAuthID)
+		if err != nil {
+			t.logger.Infof("Scheduled rotation of client auth %s failed: %v", r.ClientAuthID, err)
+			t.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionFailed).
+				WithID(r.ClientAuthID).
+				WithResponse(map[string]string{"error": err.Error()}).
+				Save()
+			continue
+		}
+
+		t.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionRotate).
+			WithID(r.ClientAuthID).
+			WithResponse(rotated).
+			Save()
+	}
+	return nil
+}
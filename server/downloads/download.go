@@ -0,0 +1,38 @@
+package downloads
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	StatusRunning    = "running"
+	StatusSuccessful = "successful"
+	StatusFailed     = "failed"
+)
+
+// Download is a file pulled from a client and stored on the server
+type Download struct {
+	ID          string     `json:"id"`
+	ClientID    string     `json:"client_id"`
+	Path        string     `json:"path"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error"`
+	Size        int64      `json:"size"`
+	Md5Checksum string     `json:"md5_checksum"`
+	Mode        uint32     `json:"mode"`
+	ModTime     *time.Time `json:"mod_time"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// Filename returns the base name of the file on the client, independent of the os of the client
+func (d *Download) Filename() string {
+	name := filepath.Base(strings.ReplaceAll(d.Path, `\`, "/"))
+	if name == "." || name == "/" {
+		return d.ID
+	}
+	return name
+}
@@ -0,0 +1,306 @@
+package downloads
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/random"
+)
+
+const (
+	metaFileExt = ".json"
+	dataFileExt = ".data"
+)
+
+var ErrNotFound = errors.New("download not found")
+
+var validIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// Manager stores downloaded files together with a metadata json file in a single directory
+type Manager struct {
+	dir    string
+	logger *logger.Logger
+}
+
+// NewManager creates the downloads dir if needed. Downloads left running by a previous server process are marked as failed.
+func NewManager(dir string, logger *logger.Logger) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create downloads dir %q: %v", dir, err)
+	}
+	m := &Manager{
+		dir:    dir,
+		logger: logger,
+	}
+
+	list, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range list {
+		if d.Status == StatusRunning {
+			_ = m.finish(d, nil, errors.New("interrupted by server restart"))
+		}
+	}
+	return m, nil
+}
+
+// Create stores a new running download
+func (m *Manager) Create(clientID, path, username string) (*Download, error) {
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+	d := &Download{
+		ID:        id,
+		ClientID:  clientID,
+		Path:      path,
+		Status:    StatusRunning,
+		CreatedBy: username,
+		CreatedAt: time.Now().UTC(),
+	}
+	return d, m.saveMeta(d)
+}
+
+// RelayResultTrailer is the http trailer holding the result of a download relayed by another node, it's sent after the
+// file content
+const RelayResultTrailer = "X-Rport-Download-Result"
+
+// Fetch reads the file of the download from the client through the given connection and stores it.
+// The download is updated with the result, the returned error is also stored in the download.
+func (m *Manager) Fetch(conn ssh.Conn, d *Download) error {
+	ch, reqs, err := openChannel(conn, d.Path)
+	if err != nil {
+		return m.finish(d, nil, err)
+	}
+	defer ch.Close()
+
+	result, err := m.store(d, ch, func() (*comm.FileDownloadResult, error) {
+		return readResult(reqs)
+	})
+	return m.finish(d, result, err)
+}
+
+// FetchRelayed stores the file read from r, which is relayed by the node of a high availability cluster the client
+// is connected to. result returns the result of the client once r is read completely.
+func (m *Manager) FetchRelayed(r io.Reader, d *Download, result func() (*comm.FileDownloadResult, error)) error {
+	res, err := m.store(d, r, result)
+	return m.finish(d, res, err)
+}
+
+// Relay writes the file read from the client to w without storing it, it's used to pass the file on to another node
+// of a high availability cluster. The error is nil if the client started sending the file, the result contains the
+// outcome.
+func Relay(conn ssh.Conn, path string, w io.Writer) (*comm.FileDownloadResult, error) {
+	ch, reqs, err := openChannel(conn, path)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	result, err := func() (*comm.FileDownloadResult, error) {
+		if _, err := io.Copy(w, ch); err != nil {
+			return nil, err
+		}
+		return readResult(reqs)
+	}()
+	if err != nil {
+		result = &comm.FileDownloadResult{Error: err.Error()}
+	}
+	return result, nil
+}
+
+func openChannel(conn ssh.Conn, path string) (ssh.Channel, <-chan *ssh.Request, error) {
+	payload, err := json.Marshal(&comm.FileDownloadRequest{Path: path})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ch, reqs, err := conn.OpenChannel(comm.ChannelFileDownload, payload)
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			err = errors.New(openErr.Message)
+		}
+		return nil, nil, err
+	}
+	return ch, reqs, nil
+}
+
+// store writes the file content read from r to the data file of the download
+func (m *Manager) store(d *Download, r io.Reader, result func() (*comm.FileDownloadResult, error)) (*comm.FileDownloadResult, error) {
+	f, err := os.OpenFile(m.dataPath(d.ID), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	d.Size, err = io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return nil, err
+	}
+	d.Md5Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	res, err := result()
+	if err != nil {
+		return nil, err
+	}
+	return res, f.Close()
+}
+
+// readResult returns the result the client sends before closing its side, so it's already queued once all data is read
+func readResult(reqs <-chan *ssh.Request) (*comm.FileDownloadResult, error) {
+	for r := range reqs {
+		if r.Type != comm.RequestTypeFileDownloadResult {
+			if r.WantReply {
+				_ = r.Reply(false, nil)
+			}
+			continue
+		}
+		result := &comm.FileDownloadResult{}
+		if err := json.Unmarshal(r.Payload, result); err != nil {
+			return nil, fmt.Errorf("invalid file download result: %v", err)
+		}
+		return result, nil
+	}
+	return nil, errors.New("connection closed before the file was transferred completely")
+}
+
+// List returns all downloads, the most recent first
+func (m *Manager) List() ([]*Download, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Download, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), metaFileExt) {
+			continue
+		}
+		d, err := m.readMeta(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			m.logger.Errorf("failed to read download metadata %q: %v", entry.Name(), err)
+			continue
+		}
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (m *Manager) Get(id string) (*Download, error) {
+	if !validIDRegexp.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	d, err := m.readMeta(m.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// Open returns the file of a successful download which must be closed by the caller
+func (m *Manager) Open(d *Download) (*os.File, error) {
+	if d.Status != StatusSuccessful {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(m.dataPath(d.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (m *Manager) Delete(id string) error {
+	if !validIDRegexp.MatchString(id) {
+		return ErrNotFound
+	}
+	if err := os.Remove(m.dataPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err := os.Remove(m.metaPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Fail marks the download as failed without contacting the client, the given error is returned
+func (m *Manager) Fail(d *Download, err error) error {
+	return m.finish(d, nil, err)
+}
+
+func (m *Manager) finish(d *Download, result *comm.FileDownloadResult, err error) error {
+	finishedAt := time.Now().UTC()
+	d.FinishedAt = &finishedAt
+	if err == nil && result.Error != "" {
+		err = errors.New(result.Error)
+	}
+	if err != nil {
+		d.Status = StatusFailed
+		d.Error = err.Error()
+		if rmErr := os.Remove(m.dataPath(d.ID)); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+			m.logger.Errorf("failed to remove incomplete download %s: %v", d.ID, rmErr)
+		}
+	} else {
+		d.Status = StatusSuccessful
+		d.Mode = result.Mode
+		d.ModTime = &result.ModTime
+	}
+
+	if metaErr := m.saveMeta(d); metaErr != nil {
+		m.logger.Errorf("failed to save download %s: %v", d.ID, metaErr)
+	}
+	return err
+}
+
+func (m *Manager) dataPath(id string) string {
+	return filepath.Join(m.dir, id+dataFileExt)
+}
+
+func (m *Manager) metaPath(id string) string {
+	return filepath.Join(m.dir, id+metaFileExt)
+}
+
+func (m *Manager) saveMeta(d *Download) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	// write to a temp file first to never leave a partially written metadata file behind
+	tmp := m.metaPath(d.ID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.metaPath(d.ID))
+}
+
+func (m *Manager) readMeta(path string) (*Download, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &Download{}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,238 @@
+package downloads
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+var testLog = logger.NewLogger("downloads", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type fakeConn struct {
+	ssh.Conn
+	content   string
+	result    *comm.FileDownloadResult
+	rejectErr error
+	request   *comm.FileDownloadRequest
+}
+
+func (c *fakeConn) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	if name != comm.ChannelFileDownload {
+		return nil, nil, &ssh.OpenChannelError{Reason: ssh.UnknownChannelType}
+	}
+	req, err := comm.DecodeFileDownloadRequest(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.request = req
+	if c.rejectErr != nil {
+		return nil, nil, c.rejectErr
+	}
+
+	reqs := make(chan *ssh.Request, 1)
+	if c.result != nil {
+		payload, err := json.Marshal(c.result)
+		if err != nil {
+			return nil, nil, err
+		}
+		reqs <- &ssh.Request{Type: comm.RequestTypeFileDownloadResult, Payload: payload}
+	}
+	close(reqs)
+	return &fakeChannel{Reader: strings.NewReader(c.content)}, reqs, nil
+}
+
+type fakeChannel struct {
+	ssh.Channel
+	io.Reader
+}
+
+func (c *fakeChannel) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+func (c *fakeChannel) Close() error {
+	return nil
+}
+
+func TestFetch(t *testing.T) {
+	m, err := NewManager(t.TempDir(), testLog)
+	require.NoError(t, err)
+
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	conn := &fakeConn{
+		content: "log line\n",
+		result:  &comm.FileDownloadResult{Size: 9, Mode: 0644, ModTime: modTime},
+	}
+
+	d, err := m.Create("client-1", "/var/log/app.log", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, d.Status)
+
+	require.NoError(t, m.Fetch(conn, d))
+	assert.Equal(t, "/var/log/app.log", conn.request.Path)
+
+	stored, err := m.Get(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSuccessful, stored.Status)
+	assert.Equal(t, int64(9), stored.Size)
+	assert.Equal(t, "26cf5d0aae70ed73cca70cd2be014a89", stored.Md5Checksum)
+	assert.Equal(t, uint32(0644), stored.Mode)
+	assert.Equal(t, modTime, *stored.ModTime)
+	assert.Equal(t, "admin", stored.CreatedBy)
+	assert.NotNil(t, stored.FinishedAt)
+	assert.Equal(t, "app.log", stored.Filename())
+
+	f, err := m.Open(stored)
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "log line\n", string(content))
+}
+
+func TestFetchFailures(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Conn          *fakeConn
+		ExpectedError string
+	}{
+		{
+			Name:          "rejected by client",
+			Conn:          &fakeConn{rejectErr: &ssh.OpenChannelError{Reason: ssh.Prohibited, Message: "path /etc/shadow matches denied pattern /etc/shadow*"}},
+			ExpectedError: "path /etc/shadow matches denied pattern /etc/shadow*",
+		},
+		{
+			Name:          "read error on client",
+			Conn:          &fakeConn{content: "part", result: &comm.FileDownloadResult{Size: 4, Error: "input/output error"}},
+			ExpectedError: "input/output error",
+		},
+		{
+			Name:          "no result",
+			Conn:          &fakeConn{content: "part"},
+			ExpectedError: "connection closed before the file was transferred completely",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := NewManager(t.TempDir(), testLog)
+			require.NoError(t, err)
+
+			d, err := m.Create("client-1", "/etc/shadow", "admin")
+			require.NoError(t, err)
+
+			err = m.Fetch(tc.Conn, d)
+			assert.EqualError(t, err, tc.ExpectedError)
+
+			stored, err := m.Get(d.ID)
+			require.NoError(t, err)
+			assert.Equal(t, StatusFailed, stored.Status)
+			assert.Equal(t, tc.ExpectedError, stored.Error)
+
+			_, err = m.Open(stored)
+			assert.Equal(t, ErrNotFound, err)
+			_, err = os.Stat(m.dataPath(d.ID))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestRelayAndFetchRelayed(t *testing.T) {
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	conn := &fakeConn{
+		content: "log line\n",
+		result:  &comm.FileDownloadResult{Size: 9, Mode: 0644, ModTime: modTime},
+	}
+
+	// the node the client is connected to
+	relayed := &strings.Builder{}
+	result, err := Relay(conn, "/var/log/app.log", relayed)
+	require.NoError(t, err)
+	assert.Equal(t, "/var/log/app.log", conn.request.Path)
+	assert.Equal(t, "log line\n", relayed.String())
+
+	// the node the download was requested on
+	m, err := NewManager(t.TempDir(), testLog)
+	require.NoError(t, err)
+	d, err := m.Create("client-1", "/var/log/app.log", "admin")
+	require.NoError(t, err)
+
+	err = m.FetchRelayed(strings.NewReader(relayed.String()), d, func() (*comm.FileDownloadResult, error) {
+		return result, nil
+	})
+	require.NoError(t, err)
+
+	stored, err := m.Get(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSuccessful, stored.Status)
+	assert.Equal(t, int64(9), stored.Size)
+	assert.Equal(t, "26cf5d0aae70ed73cca70cd2be014a89", stored.Md5Checksum)
+	assert.Equal(t, modTime, *stored.ModTime)
+}
+
+func TestRelayFailures(t *testing.T) {
+	_, err := Relay(&fakeConn{rejectErr: &ssh.OpenChannelError{Reason: ssh.Prohibited, Message: "path denied"}}, "/etc/shadow", io.Discard)
+	assert.EqualError(t, err, "path denied")
+
+	result, err := Relay(&fakeConn{content: "part"}, "/var/log/app.log", io.Discard)
+	require.NoError(t, err, "the file is sent already, the error is passed on in the result")
+	assert.Equal(t, "connection closed before the file was transferred completely", result.Error)
+}
+
+func TestNewManagerFailsInterruptedDownloads(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, testLog)
+	require.NoError(t, err)
+	d, err := m.Create("client-1", "/var/log/app.log", "admin")
+	require.NoError(t, err)
+
+	m, err = NewManager(dir, testLog)
+	require.NoError(t, err)
+
+	stored, err := m.Get(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, stored.Status)
+	assert.Equal(t, "interrupted by server restart", stored.Error)
+}
+
+func TestListAndDelete(t *testing.T) {
+	m, err := NewManager(t.TempDir(), testLog)
+	require.NoError(t, err)
+
+	d1, err := m.Create("client-1", "/a", "admin")
+	require.NoError(t, err)
+	d2, err := m.Create("client-2", "/b", "admin")
+	require.NoError(t, err)
+	// meta files of other kinds are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(m.dir, "other.txt"), []byte("x"), 0600))
+
+	list, err := m.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, d2.ID, list[0].ID)
+	assert.Equal(t, d1.ID, list[1].ID)
+
+	require.NoError(t, m.Delete(d1.ID))
+	assert.Equal(t, ErrNotFound, m.Delete(d1.ID))
+	assert.Equal(t, ErrNotFound, m.Delete("../other"))
+
+	_, err = m.Get(d1.ID)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFilename(t *testing.T) {
+	assert.Equal(t, "app.log", (&Download{Path: "/var/log/app.log"}).Filename())
+	assert.Equal(t, "app.log", (&Download{Path: `C:\logs\app.log`}).Filename())
+	assert.Equal(t, "id-1", (&Download{ID: "id-1", Path: "/"}).Filename())
+}
@@ -0,0 +1,53 @@
+package enrollment
+
+import (
+	"time"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Token allows clients to enroll without a client auth credential created in advance.
+// Clients present "<id>_<secret>", only a hash of the secret is stored.
+type Token struct {
+	ID          string `json:"id" db:"id"`
+	Hash        string `json:"-" db:"hash"`
+	Description string `json:"description" db:"description"`
+	// MaxUses is the number of clients that can enroll with the token
+	MaxUses int `json:"max_uses" db:"max_uses"`
+	Uses    int `json:"uses" db:"uses"`
+	// RequireApproval holds enrolled clients in the pending state until an admin approves them
+	RequireApproval bool      `json:"require_approval" db:"require_approval"`
+	ExpiresAt       time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	CreatedBy       string    `json:"created_by" db:"created_by"`
+}
+
+// Usable returns true if the token is not expired and not used up
+func (t *Token) Usable(now time.Time) bool {
+	return t.Uses < t.MaxUses && now.Before(t.ExpiresAt)
+}
+
+type TokenRequest struct {
+	Description     string     `json:"description"`
+	MaxUses         int        `json:"max_uses"`
+	RequireApproval bool       `json:"require_approval"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+}
+
+// Enrollment is the client auth credential generated for a client that presented a token
+type Enrollment struct {
+	ClientAuthID string `json:"client_auth_id" db:"client_auth_id"`
+	TokenID      string `json:"token_id" db:"token_id"`
+	Status       string `json:"status" db:"status"`
+	// ClientID and ClientName are reported by the client when enrolling
+	ClientID   string     `json:"client_id" db:"client_id"`
+	ClientName string     `json:"client_name" db:"client_name"`
+	RemoteIP   string     `json:"remote_ip" db:"remote_ip"`
+	EnrolledAt time.Time  `json:"enrolled_at" db:"enrolled_at"`
+	DecidedAt  *time.Time `json:"decided_at" db:"decided_at"`
+	DecidedBy  *string    `json:"decided_by" db:"decided_by"`
+}
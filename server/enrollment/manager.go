@@ -0,0 +1,334 @@
+package enrollment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/share/query"
+	"github.com/openrport/openrport/share/random"
+)
+
+const (
+	DefaultTokenValidity = 24 * time.Hour
+	DefaultTokenMaxUses  = 1
+
+	// ClientAuthIDPrefix is the prefix of the client auth ids generated for enrolled clients
+	ClientAuthIDPrefix = "enrolled-"
+
+	tokenSeparator = "_"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired enrollment token")
+
+	supportedSorts = map[string]bool{
+		"enrolled_at": true,
+		"decided_at":  true,
+		"status":      true,
+		"client_name": true,
+	}
+	supportedFilters = map[string]bool{
+		"client_auth_id": true,
+		"token_id":       true,
+		"status":         true,
+		"client_id":      true,
+		"client_name":    true,
+		"enrolled_at":    true,
+	}
+	defaultSorts = map[string][]string{
+		"sort": {"-enrolled_at"},
+	}
+)
+
+type Provider interface {
+	InsertToken(ctx context.Context, t *Token) error
+	GetToken(ctx context.Context, id string) (*Token, error)
+	ListTokens(ctx context.Context) ([]*Token, error)
+	DeleteToken(ctx context.Context, id string) error
+	UseToken(ctx context.Context, id string) (bool, error)
+	InsertEnrollment(ctx context.Context, e *Enrollment) error
+	GetEnrollment(ctx context.Context, clientAuthID string) (*Enrollment, error)
+	ListEnrollments(ctx context.Context, options *query.ListOptions) ([]*Enrollment, error)
+	CountEnrollments(ctx context.Context, options *query.ListOptions) (int, error)
+	Decide(ctx context.Context, e *Enrollment) (bool, error)
+}
+
+// Manager hands out client auth credentials to clients presenting an enrollment token
+// and keeps the enrolled clients that require approval in the pending state.
+type Manager struct {
+	provider           Provider
+	clientAuthProvider clientsauth.Provider
+
+	now func() time.Time
+}
+
+func NewManager(provider Provider, clientAuthProvider clientsauth.Provider) *Manager {
+	return &Manager{
+		provider:           provider,
+		clientAuthProvider: clientAuthProvider,
+		now:                time.Now,
+	}
+}
+
+// CreateToken stores a new token and returns it together with the value clients have to present.
+// The value can't be retrieved later.
+func (m *Manager) CreateToken(ctx context.Context, r TokenRequest, username string) (*Token, string, error) {
+	now := m.now().UTC()
+	t := &Token{
+		ID:              random.AlphaNum(8),
+		Description:     r.Description,
+		MaxUses:         r.MaxUses,
+		RequireApproval: r.RequireApproval,
+		ExpiresAt:       now.Add(DefaultTokenValidity),
+		CreatedAt:       now,
+		CreatedBy:       username,
+	}
+	if t.MaxUses == 0 {
+		t.MaxUses = DefaultTokenMaxUses
+	}
+	if t.MaxUses < 0 {
+		return nil, "", errors2.APIError{
+			Message:    "max_uses must be a positive number.",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if r.ExpiresAt != nil {
+		if !r.ExpiresAt.After(now) {
+			return nil, "", errors2.APIError{
+				Message:    "expires_at must be in the future.",
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+		t.ExpiresAt = r.ExpiresAt.UTC()
+	}
+
+	secret, err := random.UUID4()
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+	t.Hash = string(hash)
+
+	if err := m.provider.InsertToken(ctx, t); err != nil {
+		return nil, "", err
+	}
+
+	return t, t.ID + tokenSeparator + secret, nil
+}
+
+func (m *Manager) ListTokens(ctx context.Context) ([]*Token, error) {
+	return m.provider.ListTokens(ctx)
+}
+
+// DeleteToken revokes the token, clients that already enrolled with it are not affected
+func (m *Manager) DeleteToken(ctx context.Context, id string) error {
+	t, err := m.provider.GetToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("Enrollment token[id=%q] not found.", id),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return m.provider.DeleteToken(ctx, id)
+}
+
+// Authenticate returns the token the value belongs to or ErrInvalidToken if it's unknown, expired or used up
+func (m *Manager) Authenticate(ctx context.Context, value string) (*Token, error) {
+	id, secret, ok := strings.Cut(value, tokenSeparator)
+	if !ok || id == "" || secret == "" {
+		return nil, ErrInvalidToken
+	}
+
+	t, err := m.provider.GetToken(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidToken
+	}
+	if bcrypt.CompareHashAndPassword([]byte(t.Hash), []byte(secret)) != nil {
+		return nil, ErrInvalidToken
+	}
+	if !t.Usable(m.now()) {
+		return nil, ErrInvalidToken
+	}
+	return t, nil
+}
+
+// Enroll uses the token once and returns the enrollment with the newly generated client auth credentials. The
+// credentials are added before the token is used, so a failure doesn't use up the token. They are deleted again if
+// the token can't be used.
+func (m *Manager) Enroll(ctx context.Context, value, clientID, clientName, remoteIP string) (*Enrollment, *clientsauth.ClientAuth, error) {
+	t, err := m.Authenticate(ctx, value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	password, err := random.UUID4()
+	if err != nil {
+		return nil, nil, err
+	}
+	clientAuth := &clientsauth.ClientAuth{
+		ID:       ClientAuthIDPrefix + random.AlphaNum(12),
+		Password: password,
+	}
+	added, err := m.clientAuthProvider.Add(clientAuth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to add client auth: %w", err)
+	}
+	if !added {
+		return nil, nil, fmt.Errorf("client auth %q already exists", clientAuth.ID)
+	}
+
+	used, err := m.provider.UseToken(ctx, t.ID)
+	if err == nil && !used {
+		// used up concurrently by another client
+		err = ErrInvalidToken
+	}
+	if err != nil {
+		if deleteErr := m.clientAuthProvider.Delete(clientAuth.ID); deleteErr != nil {
+			return nil, nil, fmt.Errorf("%w, failed to delete client auth %q: %v", err, clientAuth.ID, deleteErr)
+		}
+		return nil, nil, err
+	}
+
+	e := &Enrollment{
+		ClientAuthID: clientAuth.ID,
+		TokenID:      t.ID,
+		Status:       StatusApproved,
+		ClientID:     clientID,
+		ClientName:   clientName,
+		RemoteIP:     remoteIP,
+		EnrolledAt:   m.now().UTC(),
+	}
+	if t.RequireApproval {
+		e.Status = StatusPending
+	}
+	if err := m.provider.InsertEnrollment(ctx, e); err != nil {
+		return nil, nil, err
+	}
+
+	return e, clientAuth, nil
+}
+
+func (m *Manager) List(ctx context.Context, r *http.Request) (*api.SuccessPayload, error) {
+	options := query.NewOptions(r, defaultSorts, nil, nil)
+	err := query.ValidateListOptions(options, supportedSorts, supportedFilters, nil /*fields*/, &query.PaginationConfig{
+		MaxLimit:     100,
+		DefaultLimit: 20,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := m.provider.ListEnrollments(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := m.provider.CountEnrollments(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.SuccessPayload{
+		Data: entries,
+		Meta: api.NewMeta(count),
+	}, nil
+}
+
+// Get returns the enrollment of the given client auth id or a 404 APIError
+func (m *Manager) Get(ctx context.Context, clientAuthID string) (*Enrollment, error) {
+	e, err := m.provider.GetEnrollment(ctx, clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("Enrollment[client_auth_id=%q] not found.", clientAuthID),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return e, nil
+}
+
+// Approve allows the pending client to connect
+func (m *Manager) Approve(ctx context.Context, clientAuthID string, username string) (*Enrollment, error) {
+	return m.decide(ctx, clientAuthID, username, StatusApproved)
+}
+
+// Reject marks the pending client as rejected and deletes its client auth credentials
+func (m *Manager) Reject(ctx context.Context, clientAuthID string, username string) (*Enrollment, error) {
+	e, err := m.decide(ctx, clientAuthID, username, StatusRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.clientAuthProvider.Delete(clientAuthID); err != nil {
+		return nil, fmt.Errorf("failed to delete client auth: %w", err)
+	}
+
+	return e, nil
+}
+
+func (m *Manager) decide(ctx context.Context, clientAuthID string, username string, status string) (*Enrollment, error) {
+	e, err := m.Get(ctx, clientAuthID)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Status != StatusPending {
+		return nil, errNotPending(clientAuthID)
+	}
+
+	now := m.now().UTC()
+	e.Status = status
+	e.DecidedAt = &now
+	e.DecidedBy = &username
+
+	ok, err := m.provider.Decide(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// decided concurrently by another user
+		return nil, errNotPending(clientAuthID)
+	}
+
+	return e, nil
+}
+
+func errNotPending(clientAuthID string) error {
+	return errors2.APIError{
+		Message:    fmt.Sprintf("Enrollment[client_auth_id=%q] is not pending anymore.", clientAuthID),
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// CheckApproved returns an error if the client auth id belongs to an enrolled client, which is not approved.
+// Client auth ids that were not created by an enrollment are always allowed.
+func (m *Manager) CheckApproved(ctx context.Context, clientAuthID string) error {
+	e, err := m.provider.GetEnrollment(ctx, clientAuthID)
+	if err != nil {
+		return err
+	}
+	if e == nil || e.Status == StatusApproved {
+		return nil
+	}
+	return fmt.Errorf("enrollment is %s", e.Status)
+}
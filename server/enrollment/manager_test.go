@@ -0,0 +1,213 @@
+package enrollment
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientsmigration "github.com/openrport/openrport/db/migration/clients"
+	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/share/query"
+	"github.com/openrport/openrport/share/test"
+)
+
+func newTestManager(t *testing.T) (*Manager, clientsauth.Provider) {
+	db := test.NewMemoryDB(t, clientsmigration.AssetNames(), clientsmigration.Asset)
+
+	clientAuthProvider := clientsauth.NewMockFileProvider(nil, t)
+	return NewManager(NewSQLiteProvider(db), clientAuthProvider), clientAuthProvider
+}
+
+func TestCreateToken(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	token, value, err := m.CreateToken(ctx, TokenRequest{Description: "office"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTokenMaxUses, token.MaxUses)
+	assert.WithinDuration(t, time.Now().Add(DefaultTokenValidity), token.ExpiresAt, time.Minute)
+	assert.True(t, strings.HasPrefix(value, token.ID+"_"))
+	assert.NotContains(t, token.Hash, strings.TrimPrefix(value, token.ID+"_"))
+
+	tokens, err := m.ListTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "office", tokens[0].Description)
+	assert.Equal(t, "admin", tokens[0].CreatedBy)
+
+	past := time.Now().Add(-time.Minute)
+	_, _, err = m.CreateToken(ctx, TokenRequest{ExpiresAt: &past}, "admin")
+	test.RequireHTTPStatus(t, err, 400)
+
+	_, _, err = m.CreateToken(ctx, TokenRequest{MaxUses: -1}, "admin")
+	test.RequireHTTPStatus(t, err, 400)
+
+	require.NoError(t, m.DeleteToken(ctx, token.ID))
+	_, err = m.Authenticate(ctx, value)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	err = m.DeleteToken(ctx, token.ID)
+	test.RequireHTTPStatus(t, err, 404)
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	token, value, err := m.CreateToken(ctx, TokenRequest{}, "admin")
+	require.NoError(t, err)
+
+	authenticated, err := m.Authenticate(ctx, value)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, authenticated.ID)
+
+	for _, invalid := range []string{"", token.ID, token.ID + "_", token.ID + "_wrong", "unknown_" + strings.TrimPrefix(value, token.ID+"_")} {
+		_, err = m.Authenticate(ctx, invalid)
+		assert.Equal(t, ErrInvalidToken, err, invalid)
+	}
+
+	m.now = func() time.Time { return token.ExpiresAt.Add(time.Second) }
+	_, err = m.Authenticate(ctx, value)
+	assert.Equal(t, ErrInvalidToken, err, "expired")
+}
+
+func TestEnroll(t *testing.T) {
+	ctx := context.Background()
+	m, clientAuthProvider := newTestManager(t)
+
+	_, value, err := m.CreateToken(ctx, TokenRequest{MaxUses: 2}, "admin")
+	require.NoError(t, err)
+
+	e1, auth1, err := m.Enroll(ctx, value, "client-1", "Client 1", "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, e1.Status)
+	assert.Equal(t, auth1.ID, e1.ClientAuthID)
+	assert.True(t, strings.HasPrefix(auth1.ID, ClientAuthIDPrefix))
+	assert.Equal(t, "192.0.2.1", e1.RemoteIP)
+
+	stored, err := clientAuthProvider.Get(auth1.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, auth1.Password, stored.Password)
+	assert.NoError(t, m.CheckApproved(ctx, auth1.ID))
+
+	_, auth2, err := m.Enroll(ctx, value, "client-2", "Client 2", "192.0.2.2")
+	require.NoError(t, err)
+	assert.NotEqual(t, auth1.ID, auth2.ID)
+	assert.NotEqual(t, auth1.Password, auth2.Password)
+
+	_, _, err = m.Enroll(ctx, value, "client-3", "Client 3", "192.0.2.3")
+	assert.Equal(t, ErrInvalidToken, err, "used up")
+
+	tokens, err := m.ListTokens(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, tokens[0].Uses)
+
+	assert.NoError(t, m.CheckApproved(ctx, "not-enrolled"))
+}
+
+// failingClientAuthProvider fails to add client auths
+type failingClientAuthProvider struct {
+	clientsauth.Provider
+}
+
+func (failingClientAuthProvider) Add(*clientsauth.ClientAuth) (bool, error) {
+	return false, errors.New("read-only")
+}
+
+func TestEnrollKeepsTokenIfClientAuthFails(t *testing.T) {
+	ctx := context.Background()
+	m, clientAuthProvider := newTestManager(t)
+
+	_, value, err := m.CreateToken(ctx, TokenRequest{MaxUses: 1}, "admin")
+	require.NoError(t, err)
+
+	m.clientAuthProvider = failingClientAuthProvider{clientAuthProvider}
+	_, _, err = m.Enroll(ctx, value, "client-1", "Client 1", "192.0.2.1")
+	assert.EqualError(t, err, "failed to add client auth: read-only")
+
+	m.clientAuthProvider = clientAuthProvider
+	_, _, err = m.Enroll(ctx, value, "client-1", "Client 1", "192.0.2.1")
+	assert.NoError(t, err)
+}
+
+func TestEnrollConcurrently(t *testing.T) {
+	ctx := context.Background()
+	m, clientAuthProvider := newTestManager(t)
+
+	_, value, err := m.CreateToken(ctx, TokenRequest{MaxUses: 3}, "admin")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	enrolled := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.Enroll(ctx, value, "", "", ""); err == nil {
+				mu.Lock()
+				enrolled++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, enrolled)
+	_, count, err := clientAuthProvider.GetFiltered(&query.ListOptions{Pagination: query.NewPagination(100, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "client auths of clients losing the race are deleted")
+}
+
+func TestApproveAndReject(t *testing.T) {
+	ctx := context.Background()
+	m, clientAuthProvider := newTestManager(t)
+
+	_, value, err := m.CreateToken(ctx, TokenRequest{MaxUses: 2, RequireApproval: true}, "admin")
+	require.NoError(t, err)
+
+	e1, _, err := m.Enroll(ctx, value, "client-1", "Client 1", "192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, e1.Status)
+	assert.EqualError(t, m.CheckApproved(ctx, e1.ClientAuthID), "enrollment is pending")
+
+	e2, _, err := m.Enroll(ctx, value, "client-2", "Client 2", "192.0.2.2")
+	require.NoError(t, err)
+
+	list, err := m.List(ctx, httptest.NewRequest("GET", "/enrollments?filter[status]=pending", nil))
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+
+	approved, err := m.Approve(ctx, e1.ClientAuthID, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, approved.Status)
+	assert.Equal(t, "admin", *approved.DecidedBy)
+	assert.NoError(t, m.CheckApproved(ctx, e1.ClientAuthID))
+
+	_, err = m.Reject(ctx, e1.ClientAuthID, "admin")
+	test.RequireHTTPStatus(t, err, 409)
+
+	rejected, err := m.Reject(ctx, e2.ClientAuthID, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRejected, rejected.Status)
+	assert.EqualError(t, m.CheckApproved(ctx, e2.ClientAuthID), "enrollment is rejected")
+
+	deleted, err := clientAuthProvider.Get(e2.ClientAuthID)
+	require.NoError(t, err)
+	assert.Nil(t, deleted)
+
+	list, err = m.List(ctx, httptest.NewRequest("GET", "/enrollments?filter[status]=pending", nil))
+	require.NoError(t, err)
+	assert.Len(t, list.Data, 0)
+
+	_, err = m.Approve(ctx, "unknown", "admin")
+	test.RequireHTTPStatus(t, err, 404)
+}
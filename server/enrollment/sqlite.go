@@ -0,0 +1,170 @@
+package enrollment
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
+)
+
+type SQLiteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSQLiteProvider(db *sqlx.DB) *SQLiteProvider {
+	return &SQLiteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+func (p *SQLiteProvider) InsertToken(ctx context.Context, t *Token) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO enrollment_tokens (
+			id,
+			hash,
+			description,
+			max_uses,
+			uses,
+			require_approval,
+			expires_at,
+			created_at,
+			created_by
+		) VALUES (
+			:id,
+			:hash,
+			:description,
+			:max_uses,
+			:uses,
+			:require_approval,
+			:expires_at,
+			:created_at,
+			:created_by
+		)`,
+		t,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) GetToken(ctx context.Context, id string) (*Token, error) {
+	t := &Token{}
+	err := p.db.GetContext(ctx, t, p.converter.Rebind("SELECT * FROM enrollment_tokens WHERE id = ?"), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (p *SQLiteProvider) ListTokens(ctx context.Context) ([]*Token, error) {
+	values := []*Token{}
+	err := p.db.SelectContext(ctx, &values, "SELECT * FROM enrollment_tokens ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *SQLiteProvider) DeleteToken(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM enrollment_tokens WHERE id = ?"), id)
+	return err
+}
+
+// UseToken counts a use of the token. It returns false if the token is used up,
+// so concurrent enrollments can't exceed the max uses.
+func (p *SQLiteProvider) UseToken(ctx context.Context, id string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, p.converter.Rebind("UPDATE enrollment_tokens SET uses = uses + 1 WHERE id = ? AND uses < max_uses"), id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (p *SQLiteProvider) InsertEnrollment(ctx context.Context, e *Enrollment) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO enrollments (
+			client_auth_id,
+			token_id,
+			status,
+			client_id,
+			client_name,
+			remote_ip,
+			enrolled_at
+		) VALUES (
+			:client_auth_id,
+			:token_id,
+			:status,
+			:client_id,
+			:client_name,
+			:remote_ip,
+			:enrolled_at
+		)`,
+		e,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) GetEnrollment(ctx context.Context, clientAuthID string) (*Enrollment, error) {
+	e := &Enrollment{}
+	err := p.db.GetContext(ctx, e, p.converter.Rebind("SELECT * FROM enrollments WHERE client_auth_id = ?"), clientAuthID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (p *SQLiteProvider) ListEnrollments(ctx context.Context, options *query.ListOptions) ([]*Enrollment, error) {
+	q, params := p.converter.ConvertListOptionsToQuery(options, "SELECT * FROM enrollments")
+
+	values := []*Enrollment{}
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *SQLiteProvider) CountEnrollments(ctx context.Context, options *query.ListOptions) (int, error) {
+	countOptions := *options
+	countOptions.Sorts = nil
+	countOptions.Pagination = nil
+	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, "SELECT COUNT(*) FROM enrollments")
+
+	var count int
+	err := p.db.GetContext(ctx, &count, p.converter.Rebind(q), params...)
+	return count, err
+}
+
+// Decide stores the decision on a pending enrollment. It returns false if the enrollment is not pending anymore.
+func (p *SQLiteProvider) Decide(ctx context.Context, e *Enrollment) (bool, error) {
+	res, err := p.db.NamedExecContext(ctx,
+		`UPDATE enrollments SET
+			status = :status,
+			decided_at = :decided_at,
+			decided_by = :decided_by
+		WHERE client_auth_id = :client_auth_id AND status = '`+StatusPending+`'`,
+		e,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,74 @@
+package ha
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NodeTokenHeader authenticates requests the nodes send each other on behalf of the cluster, e.g. to run a job of a
+// multi-client job on a client connected to another node
+const NodeTokenHeader = "X-Rport-Node-Token"
+
+// dispatchTimeout limits the time another node may take to accept a job or to start sending a file
+var dispatchTimeout = 30 * time.Second
+
+// NodeToken derives the token the nodes authenticate each other with from the jwt_secret all nodes share
+func NodeToken(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("rport-ha-node"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NodeAuth middleware only accepts requests sent by another node of the cluster
+func (n *Node) NodeAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n == nil || subtle.ConstantTimeCompare([]byte(r.Header.Get(NodeTokenHeader)), []byte(n.token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Dispatch sends body as json to path of the API of another node. The response body must be closed by the caller,
+// a status other than 200 is returned as error with the message of the other node.
+func (n *Node) Dispatch(ctx context.Context, apiURL, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(apiURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(NodeTokenHeader, n.token)
+	req.Header.Set(ForwardedByHeader, n.id)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: dispatchTimeout,
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("node %s: %s: %s", apiURL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,86 @@
+package ha
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchToNodeAuth(t *testing.T) {
+	node1 := NewNode(nil, "node1", "http://10.0.0.1:3000", "secret", 0, testLog)
+	node2 := NewNode(nil, "node2", "http://10.0.0.2:3000", "secret", 0, testLog)
+	var gotBody, gotForwardedBy string
+	otherNode := httptest.NewServer(node2.NodeAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		gotForwardedBy = r.Header.Get(ForwardedByHeader)
+		_, _ = w.Write([]byte("ok"))
+	})))
+	defer otherNode.Close()
+
+	resp, err := node1.Dispatch(context.Background(), otherNode.URL, "/api/v1/ha/clients/client-1/jobs", map[string]string{"jid": "1"})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(b))
+	assert.JSONEq(t, `{"jid": "1"}`, gotBody)
+	assert.Equal(t, "node1", gotForwardedBy)
+}
+
+func TestNodeAuthRejectsOtherSecrets(t *testing.T) {
+	node := NewNode(nil, "node1", "http://10.0.0.1:3000", "secret", 0, testLog)
+	other := NewNode(nil, "node2", "http://10.0.0.2:3000", "other-secret", 0, testLog)
+	handler := node.NodeAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		Name  string
+		Token string
+	}{
+		{
+			Name: "no token",
+		},
+		{
+			Name:  "token of other secret",
+			Token: other.token,
+		},
+		{
+			Name:  "jwt secret",
+			Token: "secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ha/clients/client-1/jobs", nil)
+			if tc.Token != "" {
+				req.Header.Set(NodeTokenHeader, tc.Token)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestDispatchReturnsErrorOfNode(t *testing.T) {
+	node := NewNode(nil, "node1", "http://10.0.0.1:3000", "secret", 0, testLog)
+	otherNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "client is not connected", http.StatusConflict)
+	}))
+	defer otherNode.Close()
+
+	_, err := node.Dispatch(context.Background(), otherNode.URL, "/api/v1/ha/clients/client-1/jobs", nil)
+
+	assert.EqualError(t, err, "node "+otherNode.URL+": 409 Conflict: client is not connected")
+}
@@ -0,0 +1,74 @@
+package ha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/logger"
+)
+
+// ForwardedByHeader is set on requests forwarded to another node, they are never forwarded again.
+const ForwardedByHeader = "X-Rport-Forwarded-By"
+
+type clientLocator interface {
+	ClientNodeURL(ctx context.Context, clientID string) (string, error)
+}
+
+// ForwardMiddleware forwards requests for a client connected to another node to the API of that node.
+// isLocal reports whether the client is connected to this node. Authentication and client access are checked by
+// the target node again, as the request is passed on unchanged.
+func (n *Node) ForwardMiddleware(isLocal func(clientID string) bool) mux.MiddlewareFunc {
+	if n == nil {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	return forwardMiddleware(n, n.id, isLocal, n.logger)
+}
+
+func forwardMiddleware(locator clientLocator, nodeID string, isLocal func(clientID string) bool, log *logger.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientID := mux.Vars(r)[routes.ParamClientID]
+			if clientID == "" || r.Header.Get(ForwardedByHeader) != "" || isLocal(clientID) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			apiURL, err := locator.ClientNodeURL(r.Context(), clientID)
+			if err != nil {
+				log.Errorf("failed to find the node of client %s: %v", clientID, err)
+			}
+			if apiURL == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target, err := url.Parse(apiURL)
+			if err != nil {
+				log.Errorf("invalid api url %q of the node of client %s: %v", apiURL, clientID, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log.Debugf("forwarding %s %s for client %s to %s", r.Method, r.URL.Path, clientID, apiURL)
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			director := proxy.Director
+			proxy.Director = func(req *http.Request) {
+				director(req)
+				req.Host = target.Host
+				req.Header.Set(ForwardedByHeader, nodeID)
+			}
+			proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+				log.Errorf("failed to forward request for client %s to %s: %v", clientID, apiURL, err)
+				w.WriteHeader(http.StatusBadGateway)
+			}
+			proxy.ServeHTTP(w, r)
+		})
+	}
+}
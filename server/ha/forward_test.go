@@ -0,0 +1,136 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+var testLog = logger.NewLogger("ha-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type fakeLocator struct {
+	urls map[string]string
+	err  error
+}
+
+func (l *fakeLocator) ClientNodeURL(ctx context.Context, clientID string) (string, error) {
+	return l.urls[clientID], l.err
+}
+
+func TestForwardMiddleware(t *testing.T) {
+	var forwardedBy, forwardedPath string
+	otherNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedBy = r.Header.Get(ForwardedByHeader)
+		forwardedPath = r.URL.RequestURI()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer otherNode.Close()
+
+	locator := &fakeLocator{urls: map[string]string{"remote-client": otherNode.URL}}
+	isLocal := func(clientID string) bool {
+		return clientID == "local-client"
+	}
+
+	testCases := []struct {
+		Name            string
+		ClientID        string
+		Header          string
+		LocatorErr      error
+		ExpectedStatus  int
+		ExpectForwarded bool
+	}{
+		{
+			Name:           "local client",
+			ClientID:       "local-client",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:            "client on other node",
+			ClientID:        "remote-client",
+			ExpectedStatus:  http.StatusTeapot,
+			ExpectForwarded: true,
+		},
+		{
+			Name:           "unknown client",
+			ClientID:       "unknown-client",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "already forwarded",
+			ClientID:       "remote-client",
+			Header:         "node2",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "locator error",
+			ClientID:       "unknown-client",
+			LocatorErr:     errors.New("db down"),
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			forwardedBy, forwardedPath = "", ""
+			locator.err = tc.LocatorErr
+
+			router := mux.NewRouter()
+			clientDetails := router.PathPrefix("/api/v1/clients/{client_id}").Subrouter()
+			clientDetails.Use(forwardMiddleware(locator, "node1", isLocal, testLog))
+			clientDetails.HandleFunc("/commands", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/"+tc.ClientID+"/commands?sort=-id", nil)
+			if tc.Header != "" {
+				req.Header.Set(ForwardedByHeader, tc.Header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			if tc.ExpectForwarded {
+				assert.Equal(t, "node1", forwardedBy)
+				assert.Equal(t, "/api/v1/clients/"+tc.ClientID+"/commands?sort=-id", forwardedPath)
+			} else {
+				assert.Empty(t, forwardedPath)
+			}
+		})
+	}
+}
+
+func TestForwardMiddlewareNodeUnreachable(t *testing.T) {
+	locator := &fakeLocator{urls: map[string]string{"remote-client": "http://127.0.0.1:1"}}
+
+	router := mux.NewRouter()
+	router.Use(forwardMiddleware(locator, "node1", func(string) bool { return false }, testLog))
+	router.HandleFunc("/api/v1/clients/{client_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/clients/remote-client", nil))
+
+	require.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestForwardMiddlewareStandalone(t *testing.T) {
+	var node *Node
+	called := false
+	handler := node.ForwardMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, called)
+}
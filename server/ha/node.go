@@ -0,0 +1,229 @@
+// Package ha lets multiple rportd nodes share a single PostgreSQL database. The nodes elect a leader using a lease
+// stored in the database, only the leader runs the background tasks, while clients can connect to any node.
+package ha
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+const leaderLease = "leader"
+
+// Node represents this rportd node in the cluster. A nil Node is a standalone server which is always the leader.
+type Node struct {
+	id            string
+	apiURL        string
+	token         string
+	leaseDuration time.Duration
+	db            *sqlx.DB
+	logger        *logger.Logger
+
+	leader atomic.Bool
+
+	mu        sync.Mutex
+	onElected []func()
+	onDemoted []func()
+}
+
+// NewNode returns the node, secret is the jwt_secret shared by all nodes, the nodes authenticate each other with it
+func NewNode(db *sqlx.DB, id, apiURL, secret string, leaseDuration time.Duration, logger *logger.Logger) *Node {
+	return &Node{
+		id:            id,
+		apiURL:        apiURL,
+		token:         NodeToken(secret),
+		leaseDuration: leaseDuration,
+		db:            db,
+		logger:        logger,
+	}
+}
+
+func (n *Node) ID() string {
+	if n == nil {
+		return ""
+	}
+	return n.id
+}
+
+// IsLeader returns true if the node currently holds the leader lease.
+func (n *Node) IsLeader() bool {
+	return n == nil || n.leader.Load()
+}
+
+// OnElected registers f to be called each time the node becomes the leader.
+func (n *Node) OnElected(f func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onElected = append(n.onElected, f)
+}
+
+// OnDemoted registers f to be called each time the node loses the leadership.
+func (n *Node) OnDemoted(f func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onDemoted = append(n.onDemoted, f)
+}
+
+// Run keeps the node registered and tries to acquire or renew the leader lease until ctx is done.
+// The lease is renewed three times per lease duration, so a leader survives a single failed renewal.
+func (n *Node) Run(ctx context.Context) {
+	// connections held before a restart are gone
+	if _, err := n.db.ExecContext(ctx, "DELETE FROM ha_client_nodes WHERE node_id = $1", n.id); err != nil {
+		n.logger.Errorf("failed to remove stale clients of node %s: %v", n.id, err)
+	}
+
+	n.tick(ctx)
+
+	ticker := time.NewTicker(n.leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.tick(ctx)
+		case <-ctx.Done():
+			n.release()
+			return
+		}
+	}
+}
+
+func (n *Node) tick(ctx context.Context) {
+	if err := n.heartbeat(ctx); err != nil {
+		n.logger.Errorf("failed to register node %s: %v", n.id, err)
+	}
+
+	leader, err := n.acquireLease(ctx)
+	if err != nil {
+		// without the database another node might take over once the lease expired, so step down right away
+		n.logger.Errorf("failed to renew the leader lease: %v", err)
+		leader = false
+	}
+	n.setLeader(leader)
+}
+
+func (n *Node) heartbeat(ctx context.Context) error {
+	_, err := n.db.ExecContext(ctx, `INSERT INTO ha_nodes (node_id, api_url, last_seen_at) VALUES ($1, $2, now())
+		ON CONFLICT (node_id) DO UPDATE SET api_url = EXCLUDED.api_url, last_seen_at = EXCLUDED.last_seen_at`,
+		n.id, n.apiURL,
+	)
+	return err
+}
+
+// acquireLease takes over the lease if it's expired or renews it if it's held by this node already.
+// The database clock is used, so the clocks of the nodes don't need to be in sync.
+func (n *Node) acquireLease(ctx context.Context) (bool, error) {
+	var holder string
+	err := n.db.GetContext(ctx, &holder, `INSERT INTO ha_leases (name, node_id, expires_at) VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET node_id = EXCLUDED.node_id, expires_at = EXCLUDED.expires_at
+		WHERE ha_leases.node_id = EXCLUDED.node_id OR ha_leases.expires_at < now()
+		RETURNING node_id`,
+		leaderLease, n.id, n.leaseDuration.Milliseconds(),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return holder == n.id, nil
+}
+
+// release gives up the lease on shutdown, so another node can take over without waiting for the lease to expire.
+func (n *Node) release() {
+	n.setLeader(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := n.db.ExecContext(ctx, "DELETE FROM ha_leases WHERE name = $1 AND node_id = $2", leaderLease, n.id); err != nil {
+		n.logger.Errorf("failed to release the leader lease: %v", err)
+	}
+	if _, err := n.db.ExecContext(ctx, "DELETE FROM ha_client_nodes WHERE node_id = $1", n.id); err != nil {
+		n.logger.Errorf("failed to remove the clients of node %s: %v", n.id, err)
+	}
+}
+
+func (n *Node) setLeader(leader bool) {
+	if n.leader.Swap(leader) == leader {
+		return
+	}
+
+	n.mu.Lock()
+	callbacks := n.onDemoted
+	if leader {
+		callbacks = n.onElected
+	}
+	n.mu.Unlock()
+
+	if leader {
+		n.logger.Infof("node %s is the leader now", n.id)
+	} else {
+		n.logger.Infof("node %s is not the leader anymore", n.id)
+	}
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// ClientConnected records that the client holds its ssh connection on this node.
+func (n *Node) ClientConnected(ctx context.Context, clientID string) error {
+	if n == nil {
+		return nil
+	}
+	_, err := n.db.ExecContext(ctx, `INSERT INTO ha_client_nodes (client_id, node_id, connected_at) VALUES ($1, $2, now())
+		ON CONFLICT (client_id) DO UPDATE SET node_id = EXCLUDED.node_id, connected_at = EXCLUDED.connected_at`,
+		clientID, n.id,
+	)
+	return err
+}
+
+// ClientDisconnected removes the client from this node, unless it reconnected to another node meanwhile.
+func (n *Node) ClientDisconnected(ctx context.Context, clientID string) error {
+	if n == nil {
+		return nil
+	}
+	_, err := n.db.ExecContext(ctx, "DELETE FROM ha_client_nodes WHERE client_id = $1 AND node_id = $2", clientID, n.id)
+	return err
+}
+
+// ClientsConnectedElsewhere returns the ids of the clients holding their connection on other nodes that are alive.
+func (n *Node) ClientsConnectedElsewhere(ctx context.Context) (map[string]bool, error) {
+	if n == nil {
+		return nil, nil
+	}
+	var ids []string
+	err := n.db.SelectContext(ctx, &ids, `SELECT c.client_id FROM ha_client_nodes c
+		JOIN ha_nodes n ON n.node_id = c.node_id
+		WHERE c.node_id != $1 AND n.last_seen_at > now() - $2 * interval '1 millisecond'`,
+		n.id, n.leaseDuration.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	elsewhere := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		elsewhere[id] = true
+	}
+	return elsewhere, nil
+}
+
+// ClientNodeURL returns the API url of the other node holding the connection of the client.
+// It returns an empty string if the client isn't connected to any other node that is alive.
+func (n *Node) ClientNodeURL(ctx context.Context, clientID string) (string, error) {
+	var apiURL string
+	err := n.db.GetContext(ctx, &apiURL, `SELECT n.api_url FROM ha_client_nodes c
+		JOIN ha_nodes n ON n.node_id = c.node_id
+		WHERE c.client_id = $1 AND c.node_id != $2 AND n.last_seen_at > now() - $3 * interval '1 millisecond'`,
+		clientID, n.id, n.leaseDuration.Milliseconds(),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return apiURL, err
+}
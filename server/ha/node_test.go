@@ -0,0 +1,56 @@
+package ha
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/backend"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+// testPostgresDSNEnv allows to run the tests against a real postgres database, e.g. "host=127.0.0.1 user=rport dbname=rport_test"
+const testPostgresDSNEnv = "RPORT_TEST_POSTGRES_DSN"
+
+func TestNodesOnPostgres(t *testing.T) {
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", testPostgresDSNEnv)
+	}
+
+	db, err := backend.NewPostgres(dsn).Open("ha", "", nil, nil, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, "TRUNCATE ha_leases, ha_nodes, ha_client_nodes")
+	require.NoError(t, err)
+
+	node1 := NewNode(db, "node1", "http://10.0.0.1:3000", "secret", 3*time.Second, testLog)
+	node2 := NewNode(db, "node2", "http://10.0.0.2:3000", "secret", 3*time.Second, testLog)
+
+	node1.tick(ctx)
+	node2.tick(ctx)
+	assert.True(t, node1.IsLeader())
+	assert.False(t, node2.IsLeader())
+
+	require.NoError(t, node1.ClientConnected(ctx, "client-1"))
+	url, err := node2.ClientNodeURL(ctx, "client-1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://10.0.0.1:3000", url)
+	url, err = node1.ClientNodeURL(ctx, "client-1")
+	require.NoError(t, err)
+	assert.Empty(t, url)
+
+	node1.release()
+	node2.tick(ctx)
+	assert.False(t, node1.IsLeader())
+	assert.True(t, node2.IsLeader())
+
+	url, err = node2.ClientNodeURL(ctx, "client-1")
+	require.NoError(t, err)
+	assert.Empty(t, url)
+}
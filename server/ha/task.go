@@ -0,0 +1,61 @@
+package ha
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openrport/openrport/server/scheduler"
+)
+
+type leaderOnlyTask struct {
+	node *Node
+	task scheduler.Task
+}
+
+// LeaderOnly wraps the task to be skipped on nodes which aren't the leader.
+func LeaderOnly(node *Node, task scheduler.Task) scheduler.Task {
+	return &leaderOnlyTask{node: node, task: task}
+}
+
+func (t *leaderOnlyTask) Run(ctx context.Context) error {
+	if !t.node.IsLeader() {
+		return nil
+	}
+	return t.task.Run(ctx)
+}
+
+// Toggle starts and stops a service that must run on the leader only. Start and Stop are idempotent, so repeated
+// elections don't start the service twice and it's only stopped while it's running.
+type Toggle struct {
+	start func()
+	stop  func() error
+
+	mu      sync.Mutex
+	running bool
+}
+
+func NewToggle(start func(), stop func() error) *Toggle {
+	return &Toggle{start: start, stop: stop}
+}
+
+func (t *Toggle) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.running {
+		return
+	}
+	t.start()
+	t.running = true
+}
+
+func (t *Toggle) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.running {
+		return nil
+	}
+	t.running = false
+	return t.stop()
+}
@@ -0,0 +1,79 @@
+package ha
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingTask struct {
+	runs int
+}
+
+func (t *countingTask) Run(ctx context.Context) error {
+	t.runs++
+	return nil
+}
+
+func TestLeaderOnly(t *testing.T) {
+	node := &Node{}
+	task := &countingTask{}
+	wrapped := LeaderOnly(node, task)
+
+	require.NoError(t, wrapped.Run(context.Background()))
+	assert.Equal(t, 0, task.runs)
+
+	node.leader.Store(true)
+	require.NoError(t, wrapped.Run(context.Background()))
+	assert.Equal(t, 1, task.runs)
+}
+
+func TestLeaderOnlyStandalone(t *testing.T) {
+	task := &countingTask{}
+
+	require.NoError(t, LeaderOnly(nil, task).Run(context.Background()))
+
+	assert.Equal(t, 1, task.runs)
+}
+
+func TestSetLeaderCallbacks(t *testing.T) {
+	node := &Node{id: "node1", logger: testLog}
+	var elected, demoted int
+	node.OnElected(func() { elected++ })
+	node.OnDemoted(func() { demoted++ })
+
+	node.setLeader(true)
+	node.setLeader(true)
+	assert.True(t, node.IsLeader())
+	assert.Equal(t, 1, elected)
+	assert.Equal(t, 0, demoted)
+
+	node.setLeader(false)
+	assert.False(t, node.IsLeader())
+	assert.Equal(t, 1, elected)
+	assert.Equal(t, 1, demoted)
+}
+
+func TestToggle(t *testing.T) {
+	var started, stopped int
+	toggle := NewToggle(func() { started++ }, func() error {
+		stopped++
+		return nil
+	})
+
+	require.NoError(t, toggle.Stop())
+	assert.Equal(t, 0, stopped)
+
+	toggle.Start()
+	toggle.Start()
+	assert.Equal(t, 1, started)
+
+	require.NoError(t, toggle.Stop())
+	require.NoError(t, toggle.Stop())
+	assert.Equal(t, 1, stopped)
+
+	toggle.Start()
+	assert.Equal(t, 2, started)
+}
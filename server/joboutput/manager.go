@@ -0,0 +1,208 @@
+package joboutput
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+var ErrNotFound = errors.New("job output not found")
+
+var validJIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// Manager stores the full stdout and stderr of jobs streamed by clients, each job in its own directory
+type Manager struct {
+	dir string
+	// maxSize is the max size of the output of a single job stream in bytes, 0 means unlimited
+	maxSize int64
+	logger  *logger.Logger
+}
+
+func NewManager(dir string, maxSize int64, logger *logger.Logger) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create job output dir %q: %v", dir, err)
+	}
+	return &Manager{
+		dir:     dir,
+		maxSize: maxSize,
+		logger:  logger,
+	}, nil
+}
+
+// Create opens the output file of the given job stream for appending, it must be closed by the caller
+func (m *Manager) Create(jid, stream string) (*File, error) {
+	if err := validate(jid, stream); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(m.jobDir(jid), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(m.path(jid, stream), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &File{
+		file:    f,
+		name:    jid + "/" + stream,
+		size:    info.Size(),
+		maxSize: m.maxSize,
+		// only the truncation notice exceeds the max size, the output was already truncated by a previous chunk
+		truncated: m.maxSize > 0 && info.Size() > m.maxSize,
+		logger:    m.logger,
+	}, nil
+}
+
+// Open returns the output file of the given job stream, it must be closed by the caller
+func (m *Manager) Open(jid, stream string) (*os.File, error) {
+	if err := validate(jid, stream); err != nil {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(m.path(jid, stream))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// JIDs returns the ids of all jobs having a stored output
+func (m *Manager) JIDs() ([]string, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	jids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && validJIDRegexp.MatchString(entry.Name()) {
+			jids = append(jids, entry.Name())
+		}
+	}
+	return jids, nil
+}
+
+// Delete removes the stored output of the given job
+func (m *Manager) Delete(jid string) error {
+	if !validJIDRegexp.MatchString(jid) {
+		return ErrNotFound
+	}
+	return os.RemoveAll(m.jobDir(jid))
+}
+
+// DeleteModifiedBefore removes the output of all jobs last written before the given time,
+// it returns the number of jobs which output was removed
+func (m *Manager) DeleteModifiedBefore(t time.Time) (int, error) {
+	jids, err := m.JIDs()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, jid := range jids {
+		modTime, err := m.modTime(jid)
+		if err != nil {
+			return deleted, err
+		}
+		if !modTime.Before(t) {
+			continue
+		}
+		if err := m.Delete(jid); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// modTime returns the time the output of the job was last written
+func (m *Manager) modTime(jid string) (time.Time, error) {
+	entries, err := os.ReadDir(m.jobDir(jid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	var latest time.Time
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (m *Manager) jobDir(jid string) string {
+	return filepath.Join(m.dir, jid)
+}
+
+func (m *Manager) path(jid, stream string) string {
+	return filepath.Join(m.jobDir(jid), stream)
+}
+
+func validate(jid, stream string) error {
+	if !validJIDRegexp.MatchString(jid) {
+		return fmt.Errorf("invalid job id %q", jid)
+	}
+	if stream != models.ChannelStdout && stream != models.ChannelStderr {
+		return fmt.Errorf("invalid output stream %q", stream)
+	}
+	return nil
+}
+
+// File is the output file of a job stream. Output exceeding the max size is dropped and the file ends with a note that
+// the output was truncated.
+type File struct {
+	file      *os.File
+	name      string
+	size      int64
+	maxSize   int64
+	truncated bool
+	logger    *logger.Logger
+}
+
+// Write writes p to the file up to the max size, it never fails due to the max size so the stream is still consumed
+func (f *File) Write(p []byte) (int, error) {
+	if f.truncated {
+		return len(p), nil
+	}
+	data := p
+	if f.maxSize > 0 && f.size+int64(len(p)) > f.maxSize {
+		data = p[:max(f.maxSize-f.size, 0)]
+		f.truncated = true
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if f.truncated {
+		f.logger.Infof("output %s reached the max size of %d bytes, further output is not stored", f.name, f.maxSize)
+		if _, err := fmt.Fprintf(f.file, "\n[output truncated, max size of %d bytes reached]\n", f.maxSize); err != nil {
+			return n, err
+		}
+	}
+	return len(p), nil
+}
+
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Truncated returns true if output was dropped due to the max size
+func (f *File) Truncated() bool {
+	return f.truncated
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,101 @@
+package joboutput
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+var testLog = logger.NewLogger("joboutput-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func writeOutput(t *testing.T, m *Manager, jid, stream, data string) {
+	t.Helper()
+	f, err := m.Create(jid, stream)
+	require.NoError(t, err)
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestCreateAndOpen(t *testing.T) {
+	m, err := NewManager(t.TempDir(), 0, testLog)
+	require.NoError(t, err)
+
+	writeOutput(t, m, "job-1", models.ChannelStdout, "line 1\n")
+	writeOutput(t, m, "job-1", models.ChannelStdout, "line 2\n")
+	writeOutput(t, m, "job-1", models.ChannelStderr, "error\n")
+
+	f, err := m.Open("job-1", models.ChannelStdout)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", string(data))
+
+	_, err = m.Open("job-2", models.ChannelStdout)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Open("../job-1", models.ChannelStdout)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Open("job-1", "other")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = m.Create("job/1", models.ChannelStdout)
+	assert.Error(t, err)
+
+	jids, err := m.JIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job-1"}, jids)
+}
+
+func TestDeleteModifiedBefore(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, 0, testLog)
+	require.NoError(t, err)
+
+	writeOutput(t, m, "old", models.ChannelStdout, "old")
+	writeOutput(t, m, "recent", models.ChannelStdout, "recent")
+	writeOutput(t, m, "recent", models.ChannelStderr, "recent")
+	past := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "old", models.ChannelStdout), past, past))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "recent", models.ChannelStdout), past, past))
+
+	deleted, err := m.DeleteModifiedBefore(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	jids, err := m.JIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"recent"}, jids)
+}
+
+func TestCreateMaxSize(t *testing.T) {
+	m, err := NewManager(t.TempDir(), 10, testLog)
+	require.NoError(t, err)
+
+	writeOutput(t, m, "job-1", models.ChannelStdout, "1234")
+	writeOutput(t, m, "job-1", models.ChannelStdout, "567890abc")
+	writeOutput(t, m, "job-1", models.ChannelStdout, "def")
+	writeOutput(t, m, "job-1", models.ChannelStderr, "1234567890")
+
+	f, err := m.Open("job-1", models.ChannelStdout)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890\n[output truncated, max size of 10 bytes reached]\n", string(data))
+
+	f, err = m.Open("job-1", models.ChannelStderr)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err = io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890", string(data))
+}
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/notifications"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+const namespace = "rport"
+
+// collectTimeout limits the time spent on database queries during a scrape
+const collectTimeout = 10 * time.Second
+
+type ClientProvider interface {
+	CountActive() int
+	CountDisconnected() (int, error)
+	GetAll() []*clientdata.Client
+}
+
+type JobCounter interface {
+	CountByStatus(ctx context.Context) (map[string]int, error)
+}
+
+type NotificationQueue interface {
+	QueueLen(target notifications.Target) int
+}
+
+type HandshakeCounter interface {
+	// SSHHandshakes returns the number of ssh handshakes in progress and the max number of concurrent handshakes
+	SSHHandshakes() (inProgress, limit int)
+}
+
+var (
+	clientsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "clients"),
+		"Number of clients by connection status.",
+		[]string{"status"}, nil,
+	)
+	tunnelsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "tunnels"),
+		"Number of active tunnels by protocol.",
+		[]string{"protocol"}, nil,
+	)
+	jobsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "jobs"),
+		"Number of stored command and script jobs by status.",
+		[]string{"status"}, nil,
+	)
+	notificationsQueuedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "notifications", "queued"),
+		"Number of notifications waiting to be sent by target.",
+		[]string{"target"}, nil,
+	)
+	sshHandshakesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "ssh", "handshakes_in_progress"),
+		"Number of client ssh handshakes in progress.",
+		nil, nil,
+	)
+	sshHandshakesLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "ssh", "handshakes_limit"),
+		"Max number of concurrent client ssh handshakes.",
+		nil, nil,
+	)
+	clientCPUDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "client", "cpu_usage_percent"),
+		"Latest cpu usage reported by the client.",
+		[]string{"client_id", "client_name"}, nil,
+	)
+	clientMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "client", "memory_usage_percent"),
+		"Latest memory usage reported by the client.",
+		[]string{"client_id", "client_name"}, nil,
+	)
+	clientIODesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "client", "io_usage_percent"),
+		"Latest io usage reported by the client.",
+		[]string{"client_id", "client_name"}, nil,
+	)
+)
+
+// Collector exposes the state of the server as prometheus metrics. All values are read when the metrics are scraped,
+// except the client measurements which are kept in memory as they arrive.
+type Collector struct {
+	clients       ClientProvider
+	jobs          JobCounter
+	notifications NotificationQueue
+	handshakes    HandshakeCounter
+	logger        *logger.Logger
+
+	mu           sync.Mutex
+	measurements map[string]models.Measurement
+}
+
+func NewCollector(clients ClientProvider, jobs JobCounter, notifications NotificationQueue, handshakes HandshakeCounter, logger *logger.Logger) *Collector {
+	return &Collector{
+		clients:       clients,
+		jobs:          jobs,
+		notifications: notifications,
+		handshakes:    handshakes,
+		logger:        logger,
+		measurements:  make(map[string]models.Measurement),
+	}
+}
+
+// SaveMeasurement keeps the measurement as the latest one of its client
+func (c *Collector) SaveMeasurement(m models.Measurement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.measurements[m.ClientID] = m
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clientsDesc
+	ch <- tunnelsDesc
+	ch <- jobsDesc
+	ch <- notificationsQueuedDesc
+	ch <- sshHandshakesDesc
+	ch <- sshHandshakesLimitDesc
+	ch <- clientCPUDesc
+	ch <- clientMemoryDesc
+	ch <- clientIODesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.collectClients(ch)
+	c.collectJobs(ch)
+
+	for _, target := range notifications.AllTargets {
+		ch <- prometheus.MustNewConstMetric(notificationsQueuedDesc, prometheus.GaugeValue, float64(c.notifications.QueueLen(target)), string(target))
+	}
+
+	inProgress, limit := c.handshakes.SSHHandshakes()
+	ch <- prometheus.MustNewConstMetric(sshHandshakesDesc, prometheus.GaugeValue, float64(inProgress))
+	ch <- prometheus.MustNewConstMetric(sshHandshakesLimitDesc, prometheus.GaugeValue, float64(limit))
+}
+
+func (c *Collector) collectClients(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(clientsDesc, prometheus.GaugeValue, float64(c.clients.CountActive()), "connected")
+	disconnected, err := c.clients.CountDisconnected()
+	if err != nil {
+		c.logger.Errorf("failed to count disconnected clients: %v", err)
+		ch <- prometheus.NewInvalidMetric(clientsDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(clientsDesc, prometheus.GaugeValue, float64(disconnected), "disconnected")
+	}
+
+	tunnels := map[string]int{
+		models.ProtocolTCP:    0,
+		models.ProtocolUDP:    0,
+		models.ProtocolTCPUDP: 0,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	known := make(map[string]bool)
+	for _, client := range c.clients.GetAll() {
+		id := client.GetID()
+		known[id] = true
+		if !client.IsConnected() {
+			continue
+		}
+
+		for _, t := range client.GetTunnels() {
+			tunnels[t.Protocol]++
+		}
+
+		m, ok := c.measurements[id]
+		if !ok {
+			continue
+		}
+		name := client.GetName()
+		ch <- prometheus.MustNewConstMetric(clientCPUDesc, prometheus.GaugeValue, m.CPUUsagePercent, id, name)
+		ch <- prometheus.MustNewConstMetric(clientMemoryDesc, prometheus.GaugeValue, m.MemoryUsagePercent, id, name)
+		ch <- prometheus.MustNewConstMetric(clientIODesc, prometheus.GaugeValue, m.IoUsagePercent, id, name)
+	}
+	// forget measurements of deleted clients
+	for id := range c.measurements {
+		if !known[id] {
+			delete(c.measurements, id)
+		}
+	}
+
+	for protocol, count := range tunnels {
+		ch <- prometheus.MustNewConstMetric(tunnelsDesc, prometheus.GaugeValue, float64(count), protocol)
+	}
+}
+
+func (c *Collector) collectJobs(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.jobs.CountByStatus(ctx)
+	if err != nil {
+		c.logger.Errorf("failed to count jobs: %v", err)
+		ch <- prometheus.NewInvalidMetric(jobsDesc, err)
+		return
+	}
+
+	all := map[string]int{
+		models.JobStatusRunning:    0,
+		models.JobStatusSuccessful: 0,
+		models.JobStatusFailed:     0,
+		models.JobStatusUnknown:    0,
+	}
+	for status, count := range counts {
+		all[status] = count
+	}
+	for status, count := range all {
+		ch <- prometheus.MustNewConstMetric(jobsDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
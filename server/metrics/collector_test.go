@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/notifications"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+var testLog = logger.NewLogger("metrics-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type clientProviderMock struct {
+	clients []*clientdata.Client
+}
+
+func (m *clientProviderMock) CountActive() int {
+	count := 0
+	for _, c := range m.clients {
+		if c.IsConnected() {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *clientProviderMock) CountDisconnected() (int, error) {
+	return len(m.clients) - m.CountActive(), nil
+}
+
+func (m *clientProviderMock) GetAll() []*clientdata.Client {
+	return m.clients
+}
+
+type jobCounterMock struct {
+	counts map[string]int
+	err    error
+}
+
+func (m *jobCounterMock) CountByStatus(context.Context) (map[string]int, error) {
+	return m.counts, m.err
+}
+
+type notificationQueueMock map[notifications.Target]int
+
+func (m notificationQueueMock) QueueLen(target notifications.Target) int {
+	return m[target]
+}
+
+type handshakeCounterMock struct{}
+
+func (handshakeCounterMock) SSHHandshakes() (int, int) {
+	return 2, 50
+}
+
+func TestCollector(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c1.SetTunnels([]*clienttunnel.Tunnel{
+		{ID: "1", Remote: models.Remote{Protocol: models.ProtocolTCP}},
+		{ID: "2", Remote: models.Remote{Protocol: models.ProtocolTCP}},
+		{ID: "3", Remote: models.Remote{Protocol: models.ProtocolUDP}},
+	})
+	c2 := clients.New(t).ID("client-2").DisconnectedDuration(time.Minute).Logger(testLog).Build()
+
+	collector := NewCollector(
+		&clientProviderMock{clients: []*clientdata.Client{c1, c2}},
+		&jobCounterMock{counts: map[string]int{models.JobStatusSuccessful: 5, models.JobStatusFailed: 1}},
+		notificationQueueMock{notifications.TargetMail: 3},
+		handshakeCounterMock{},
+		testLog,
+	)
+	collector.SaveMeasurement(models.Measurement{ClientID: "client-1", CPUUsagePercent: 12.5, MemoryUsagePercent: 40, IoUsagePercent: 1.5})
+	collector.SaveMeasurement(models.Measurement{ClientID: "client-2", CPUUsagePercent: 99})
+	collector.SaveMeasurement(models.Measurement{ClientID: "deleted-client", CPUUsagePercent: 99})
+
+	expected := fmt.Sprintf(`
+# HELP rport_client_cpu_usage_percent Latest cpu usage reported by the client.
+# TYPE rport_client_cpu_usage_percent gauge
+rport_client_cpu_usage_percent{client_id="client-1",client_name=%[1]q} 12.5
+# HELP rport_client_io_usage_percent Latest io usage reported by the client.
+# TYPE rport_client_io_usage_percent gauge
+rport_client_io_usage_percent{client_id="client-1",client_name=%[1]q} 1.5
+# HELP rport_client_memory_usage_percent Latest memory usage reported by the client.
+# TYPE rport_client_memory_usage_percent gauge
+rport_client_memory_usage_percent{client_id="client-1",client_name=%[1]q} 40
+# HELP rport_clients Number of clients by connection status.
+# TYPE rport_clients gauge
+rport_clients{status="connected"} 1
+rport_clients{status="disconnected"} 1
+# HELP rport_jobs Number of stored command and script jobs by status.
+# TYPE rport_jobs gauge
+rport_jobs{status="failed"} 1
+rport_jobs{status="running"} 0
+rport_jobs{status="successful"} 5
+rport_jobs{status="unknown"} 0
+# HELP rport_notifications_queued Number of notifications waiting to be sent by target.
+# TYPE rport_notifications_queued gauge
+rport_notifications_queued{target="script"} 0
+rport_notifications_queued{target="smtp"} 3
+# HELP rport_ssh_handshakes_in_progress Number of client ssh handshakes in progress.
+# TYPE rport_ssh_handshakes_in_progress gauge
+rport_ssh_handshakes_in_progress 2
+# HELP rport_ssh_handshakes_limit Max number of concurrent client ssh handshakes.
+# TYPE rport_ssh_handshakes_limit gauge
+rport_ssh_handshakes_limit 50
+# HELP rport_tunnels Number of active tunnels by protocol.
+# TYPE rport_tunnels gauge
+rport_tunnels{protocol="tcp"} 2
+rport_tunnels{protocol="tcp+udp"} 0
+rport_tunnels{protocol="udp"} 1
+`, c1.GetName())
+
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	assert.NotContains(t, collector.measurements, "deleted-client")
+	assert.Contains(t, collector.measurements, "client-2")
+}
+
+func TestCollectorJobsError(t *testing.T) {
+	collector := NewCollector(
+		&clientProviderMock{},
+		&jobCounterMock{err: errors.New("database is locked")},
+		notificationQueueMock{},
+		handshakeCounterMock{},
+		testLog,
+	)
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(""), "rport_jobs")
+	assert.ErrorContains(t, err, "database is locked")
+}
+
+func TestHandlerAuth(t *testing.T) {
+	collector := NewCollector(&clientProviderMock{}, &jobCounterMock{}, notificationQueueMock{}, handshakeCounterMock{}, testLog)
+	handler := NewHandler(collector, "prometheus", "secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("prometheus", "wrong")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.SetBasicAuth("prometheus", "secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `rport_clients{status="connected"} 0`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
@@ -47,11 +47,16 @@ func NewSqliteProvider(dbPath string, dataSourceOptions sqlite.DataSourceOptions
 
 	logger.Infof("initialized database at %s", dbPath)
 
+	return NewDBProvider(db, logger), nil
+}
+
+// NewDBProvider returns a provider using an already opened and migrated DB.
+func NewDBProvider(db *sqlx.DB, logger *logger.Logger) DBProvider {
 	return &SqliteProvider{
 		db:        db,
 		logger:    logger,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SqliteProvider) ListMountpointsByClientID(ctx context.Context, clientID string, o *query.ListOptions) ([]*ClientMountpointsPayload, error) {
@@ -61,7 +66,7 @@ func (p *SqliteProvider) ListMountpointsByClientID(ctx context.Context, clientID
 	q, params = p.converter.AppendOptionsToQuery(o, q, params)
 
 	val := []*ClientMountpointsPayload{}
-	err := p.db.SelectContext(ctx, &val, q, params...)
+	err := p.db.SelectContext(ctx, &val, p.converter.Rebind(q), params...)
 	return val, err
 }
 
@@ -72,7 +77,7 @@ func (p *SqliteProvider) ListProcessesByClientID(ctx context.Context, clientID s
 	q, params = p.converter.AppendOptionsToQuery(o, q, params)
 
 	val := []*ClientProcessesPayload{}
-	err := p.db.SelectContext(ctx, &val, q, params...)
+	err := p.db.SelectContext(ctx, &val, p.converter.Rebind(q), params...)
 	return val, err
 }
 
@@ -83,7 +88,7 @@ func (p *SqliteProvider) ListMetricsByClientID(ctx context.Context, clientID str
 	q, params = p.converter.AppendOptionsToQuery(o, q, params)
 
 	val := []*ClientMetricsPayload{}
-	err := p.db.SelectContext(ctx, &val, q, params...)
+	err := p.db.SelectContext(ctx, &val, p.converter.Rebind(q), params...)
 	return val, err
 }
 
@@ -98,7 +103,7 @@ func (p *SqliteProvider) CountByClientID(ctx context.Context, clientID string, o
 	params = append(params, clientID)
 	q, params = p.converter.AppendOptionsToQuery(&countOptions, q, params)
 
-	err := p.db.GetContext(ctx, &result, q, params...)
+	err := p.db.GetContext(ctx, &result, p.converter.Rebind(q), params...)
 	if err != nil {
 		return 0, err
 	}
@@ -111,14 +116,14 @@ func (p *SqliteProvider) ListGraphMetricsByClientID(ctx context.Context, clientI
 	params = append(params, clientID)
 
 	q := `SELECT
-		timestamp,
-		round(avg(cpu_usage_percent),2) as cpu_usage_percent_avg,
+		` + p.intervalTimestamp() + `,
+		` + p.converter.Round("avg(cpu_usage_percent)", 2) + ` as cpu_usage_percent_avg,
 		min(cpu_usage_percent) as cpu_usage_percent_min,
 		max(cpu_usage_percent) as cpu_usage_percent_max,
-		round(avg(memory_usage_percent),2) as memory_usage_percent_avg,
+		` + p.converter.Round("avg(memory_usage_percent)", 2) + ` as memory_usage_percent_avg,
 		min(memory_usage_percent) as memory_usage_percent_min,
 		max(memory_usage_percent) as memory_usage_percent_max,
-		round(avg(io_usage_percent),2) as io_usage_percent_avg,
+		` + p.converter.Round("avg(io_usage_percent)", 2) + ` as io_usage_percent_avg,
 		min(io_usage_percent) as io_usage_percent_min,
 		max(io_usage_percent) as io_usage_percent_max
 	FROM measurements WHERE client_id = ?`
@@ -128,14 +133,14 @@ func (p *SqliteProvider) ListGraphMetricsByClientID(ctx context.Context, clientI
 	/*This is the part of "downsampling graph data" (group together graph points, so that you don't get too much points in one request).
 	The value of "29" comes from Thorsten. He did some research and found out that "29" would be the best fit.
 	*/
-	q = q + ` GROUP BY round((strftime('%s',timestamp)/(?)),0)`
+	q = q + ` GROUP BY ` + p.groupByInterval()
 	divisor := (math.Round(hours*100) / 100) * 29
 	params = append(params, divisor)
 
 	q = p.converter.AddOrderBy(lo.Sorts, q)
 
 	val := []*ClientGraphMetricsPayload{}
-	err := p.db.SelectContext(ctx, &val, q, params...)
+	err := p.db.SelectContext(ctx, &val, p.converter.Rebind(q), params...)
 	return val, err
 }
 
@@ -148,9 +153,9 @@ func (p *SqliteProvider) ListGraphByClientID(ctx context.Context, clientID strin
 		return nil, fmt.Errorf("unknown graph: %s", graph)
 	}
 
-	q := `SELECT timestamp, `
+	q := `SELECT ` + p.intervalTimestamp() + `, `
 	q = q + ` 
-		round(avg(` + field + `),2) as ` + alias + `_avg,
+		` + p.converter.Round("avg("+field+")", 2) + ` as ` + alias + `_avg,
 		min(` + field + `) as ` + alias + `_min,
 		max(` + field + `) as ` + alias + `_max`
 
@@ -158,7 +163,7 @@ func (p *SqliteProvider) ListGraphByClientID(ctx context.Context, clientID strin
 		field = strings.ReplaceAll(field, "_in", "_out")
 		alias = strings.ReplaceAll(alias, "_in", "_out")
 		q = q + `, 
-		round(avg(` + field + `),2) as ` + alias + `_avg,
+		` + p.converter.Round("avg("+field+")", 2) + ` as ` + alias + `_avg,
 		min(` + field + `) as ` + alias + `_min,
 		max(` + field + `) as ` + alias + `_max`
 	}
@@ -167,17 +172,31 @@ func (p *SqliteProvider) ListGraphByClientID(ctx context.Context, clientID strin
 
 	q, params = p.converter.AddWhere(lo.Filters, q, params)
 
-	q = q + ` GROUP BY round((strftime('%s',timestamp)/(?)),0)`
+	q = q + ` GROUP BY ` + p.groupByInterval()
 	divisor := (math.Round(hours*100) / 100) * 29
 	params = append(params, divisor)
 
 	query := p.converter.AddOrderBy(lo.Sorts, q)
 
 	val := []*ClientGraphMetricsGraphPayload{}
-	err := p.db.SelectContext(ctx, &val, query, params...)
+	err := p.db.SelectContext(ctx, &val, p.converter.Rebind(query), params...)
 	return val, err
 }
 
+// groupByInterval returns the expression grouping the measurements into intervals of the length given as parameter in seconds
+func (p *SqliteProvider) groupByInterval() string {
+	return p.converter.Round(p.converter.UnixTimestamp("timestamp")+"/(?)", 0)
+}
+
+// intervalTimestamp returns the timestamp of an interval. SQLite picks it from any row of the group, since it loses the
+// column type on aggregates, PostgreSQL only allows aggregated columns and uses the first timestamp of the interval.
+func (p *SqliteProvider) intervalTimestamp() string {
+	if p.db.DriverName() == query.DriverPostgres {
+		return "min(timestamp) as timestamp"
+	}
+	return "timestamp"
+}
+
 func (p *SqliteProvider) CreateMeasurement(ctx context.Context, measurement *models.Measurement) error {
 	q := `INSERT INTO measurements (client_id, timestamp, cpu_usage_percent, memory_usage_percent, io_usage_percent, processes, mountpoints, net_lan_in, net_lan_out, net_wan_in, net_wan_out) 
 		VALUES (:client_id, :timestamp, :cpu_usage_percent, :memory_usage_percent, :io_usage_percent, :processes, :mountpoints, `
@@ -204,7 +223,7 @@ func (p *SqliteProvider) CreateMeasurement(ctx context.Context, measurement *mod
 // DeleteMeasurementsBefore deletes entries in chunks of MaxDeletedEntries
 // to clean all you can run in loop as long as there are more than 0 rows affected
 func (p *SqliteProvider) DeleteMeasurementsBefore(ctx context.Context, compare time.Time) (int64, error) {
-	result, err := p.db.ExecContext(ctx, "DELETE FROM measurements WHERE  timestamp IN (SELECT distinct timestamp FROM measurements WHERE timestamp < ? ORDER BY timestamp LIMIT ?)", compare, MaxDeletedEntries)
+	result, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM measurements WHERE  timestamp IN (SELECT distinct timestamp FROM measurements WHERE timestamp < ? ORDER BY timestamp LIMIT ?)"), compare, MaxDeletedEntries)
 	if err != nil {
 		return 0, err
 	}
@@ -56,8 +56,8 @@ func (c cleaner) cleanOld() {
 	ctx := context.Background()
 	_, err := c.repo.db.ExecContext(
 		ctx,
-		"DELETE FROM `notifications_log` WHERE timestamp <= ?",
-		before.Format("2006-01-02 15:04:05"),
+		c.repo.converter.Rebind("DELETE FROM `notifications_log` WHERE "+c.repo.converter.DateTime("timestamp")+" <= "+c.repo.converter.DateTime("?")),
+		before,
 	)
 	if err != nil {
 		c.logger.Errorf("cleaning notifications failed: %v", err)
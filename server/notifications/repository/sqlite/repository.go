@@ -24,6 +24,7 @@ type Repository interface {
 	SetDone(ctx context.Context, details notifications.NotificationDetails, out string) error
 	SetError(ctx context.Context, details notifications.NotificationDetails, out, err string) error
 	NotificationStream(target notifications.Target) chan notifications.NotificationDetails
+	QueueLen(target notifications.Target) int
 	Close() error
 }
 
@@ -45,13 +46,13 @@ func (r repository) Count(ctx context.Context, options *query.ListOptions) (int,
 
 	countOptions := *options
 	countOptions.Pagination = nil
-	q := "SELECT COUNT(*) FROM notifications_log ORDER by notification_id"
+	q := "SELECT COUNT(*) FROM notifications_log"
 	params := []interface{}{}
 	q, params = r.converter.AppendOptionsToQuery(&countOptions, q, params)
 
 	row := r.db.QueryRowContext(
 		ctx,
-		q,
+		r.converter.Rebind(q),
 		params...,
 	)
 	err := row.Scan(&res)
@@ -129,10 +130,10 @@ func (r repository) save(ctx context.Context, details notifications.Notification
 
 	_, err := r.db.NamedExecContext(
 		ctx,
-		"INSERT INTO `notifications_log` "+
+		r.converter.Rebind("INSERT INTO `notifications_log` "+
 			" (`notification_id`, `contentType`, `reference_id`, `transport`, `recipients`, `state`, `subject`, `body`, `out`, `err`)"+
 			" VALUES "+
-			"(:notification_id, :contentType, :reference_id, :transport, :recipients, :state, :subject, :body, :out, :err)",
+			"(:notification_id, :contentType, :reference_id, :transport, :recipients, :state, :subject, :body, :out, :err)"),
 		n,
 	)
 
@@ -140,11 +141,12 @@ func (r repository) save(ctx context.Context, details notifications.Notification
 }
 
 func (r repository) Details(ctx context.Context, nid string) (notifications.NotificationDetails, bool, error) {
-	q := "SELECT * FROM `notifications_log` WHERE `notification_id` = ? order by oid asc"
+	q := "SELECT `notification_id`, `timestamp`, `contentType`, `reference_id`, `transport`, `recipients`, `state`, `subject`, `body`, `out`, `err`" +
+		" FROM `notifications_log` WHERE `notification_id` = ? order by oid asc"
 
 	empty := notifications.NotificationDetails{}
 	entities := []SQLNotification{}
-	err := r.db.SelectContext(ctx, &entities, q, nid)
+	err := r.db.SelectContext(ctx, &entities, r.converter.Rebind(q), nid)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return empty, false, nil
@@ -200,7 +202,7 @@ FROM notifications_log ORDER by timestamp desc`
 	err := r.db.SelectContext(
 		ctx,
 		&res,
-		q,
+		r.converter.Rebind(q),
 		params...,
 	)
 	return res, err
@@ -224,6 +226,11 @@ func (r repository) NotificationStream(target notifications.Target) chan notific
 	return r.sinks[target]
 }
 
+// QueueLen returns the number of notifications waiting to be processed for the target
+func (r repository) QueueLen(target notifications.Target) int {
+	return len(r.sinks[target])
+}
+
 func (r repository) Close() error {
 	for _, ch := range r.sinks {
 		close(ch)
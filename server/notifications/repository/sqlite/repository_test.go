@@ -128,6 +128,16 @@ func (suite *RepositoryTestSuite) TestRepositoryNotificationStream() {
 	suite.Equal(notification, retrieved)
 }
 
+func (suite *RepositoryTestSuite) TestRepositoryQueueLen() {
+	suite.Equal(0, suite.repository.QueueLen(notifications.TargetScript))
+
+	suite.CreateNotification()
+	suite.CreateNotification()
+
+	suite.Equal(2, suite.repository.QueueLen(notifications.TargetScript))
+	suite.Equal(0, suite.repository.QueueLen(notifications.TargetMail))
+}
+
 func (suite *RepositoryTestSuite) TestRepositoryRejectNewNotificationsWhenCloseToFullChannel() {
 	for i := 0; i < repo.MaxNotificationsQueue; i++ {
 		identifiable := refs.GenerateIdentifiable(notifications.NotificationType)
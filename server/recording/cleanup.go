@@ -0,0 +1,26 @@
+package recording
+
+import (
+	"context"
+	"time"
+)
+
+type CleanupTask struct {
+	manager         *Manager
+	storageDuration time.Duration
+}
+
+func NewCleanupTask(manager *Manager, storageDuration time.Duration) *CleanupTask {
+	return &CleanupTask{
+		manager:         manager,
+		storageDuration: storageDuration,
+	}
+}
+
+func (t *CleanupTask) Run(ctx context.Context) error {
+	deleted, err := t.manager.DeleteFinishedBefore(time.Now().Add(-t.storageDuration))
+	if deleted > 0 {
+		t.manager.logger.Infof("%d outdated recording(s) removed", deleted)
+	}
+	return err
+}
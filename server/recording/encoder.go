@@ -0,0 +1,100 @@
+package recording
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	eventInput  = "i"
+	eventOutput = "o"
+	eventResize = "r"
+)
+
+type encoder interface {
+	writeEvent(elapsed time.Duration, event string, data []byte) (int, error)
+}
+
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+type asciicastEncoder struct {
+	w io.Writer
+}
+
+func newAsciicastEncoder(w io.Writer, header asciicastHeader) (*asciicastEncoder, int, error) {
+	header.Version = 2
+	b, err := json.Marshal(header)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	if err != nil {
+		return nil, n, err
+	}
+	return &asciicastEncoder{w: w}, n, nil
+}
+
+// writeEvent writes a single event line. Invalid UTF-8 sequences are replaced by the json encoder.
+func (e *asciicastEncoder) writeEvent(elapsed time.Duration, event string, data []byte) (int, error) {
+	b, err := json.Marshal([]interface{}{elapsed.Seconds(), event, string(data)})
+	if err != nil {
+		return 0, err
+	}
+	return e.w.Write(append(b, '\n'))
+}
+
+type rawEncoder struct {
+	w io.Writer
+}
+
+func (e *rawEncoder) writeEvent(elapsed time.Duration, event string, data []byte) (int, error) {
+	if event == eventResize {
+		// terminal size is meaningless for raw streams
+		return 0, nil
+	}
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint64(header[0:8], uint64(elapsed.Microseconds()))
+	header[8] = event[0]
+	binary.BigEndian.PutUint32(header[9:13], uint32(len(data)))
+
+	n, err := e.w.Write(header)
+	if err != nil {
+		return n, err
+	}
+	m, err := e.w.Write(data)
+	return n + m, err
+}
+
+// Frame is a single chunk of data of a raw recording
+type Frame struct {
+	Offset    time.Duration
+	Direction byte
+	Data      []byte
+}
+
+// ReadRawFrame reads the next frame of a raw recording, it returns io.EOF when no more frames are available
+func ReadRawFrame(r io.Reader) (*Frame, error) {
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	f := &Frame{
+		Offset:    time.Duration(binary.BigEndian.Uint64(header[0:8])) * time.Microsecond,
+		Direction: header[8],
+		Data:      make([]byte, binary.BigEndian.Uint32(header[9:13])),
+	}
+	if _, err := io.ReadFull(r, f.Data); err != nil {
+		return nil, fmt.Errorf("incomplete frame: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,213 @@
+package recording
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/random"
+)
+
+const metaFileExt = ".json"
+
+var ErrNotFound = errors.New("recording not found")
+
+var validIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+type Options struct {
+	Dir string
+	// MaxSize is the max size of a single recording in bytes, 0 means unlimited
+	MaxSize int64
+	// RecordAll enforces recording of all tunnels and terminal sessions
+	RecordAll bool
+}
+
+// Manager stores recordings as a pair of a metadata json file and a data file in a single directory
+type Manager struct {
+	opts   Options
+	logger *logger.Logger
+}
+
+func NewManager(opts Options, logger *logger.Logger) (*Manager, error) {
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recordings dir %q: %v", opts.Dir, err)
+	}
+	return &Manager{
+		opts:   opts,
+		logger: logger,
+	}, nil
+}
+
+// ShouldRecord returns true when a session is to be recorded, either because it was requested or all sessions are recorded.
+// It's safe to call on a nil manager, which means recording is disabled.
+func (m *Manager) ShouldRecord(requested bool) bool {
+	if m == nil {
+		return false
+	}
+	return requested || m.opts.RecordAll
+}
+
+// Start creates a new recording. Width and height are only used for asciicast recordings.
+func (m *Manager) Start(rec *Recording, width, height uint16) (*Recorder, error) {
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = id
+	rec.StartedAt = time.Now().UTC()
+
+	f, err := os.OpenFile(m.dataPath(rec), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		manager: m,
+		rec:     rec,
+		file:    f,
+		start:   time.Now(),
+	}
+
+	if rec.Format == FormatAsciicast {
+		var n int
+		r.enc, n, err = newAsciicastEncoder(f, asciicastHeader{
+			Width:     width,
+			Height:    height,
+			Timestamp: rec.StartedAt.Unix(),
+			Title:     fmt.Sprintf("%s %s %s", rec.Type, rec.ClientID, rec.Remote),
+		})
+		r.rec.Size += int64(n)
+	} else {
+		r.enc = &rawEncoder{w: f}
+	}
+	if err == nil {
+		err = m.saveMeta(rec)
+	}
+	if err != nil {
+		f.Close()
+		_ = m.remove(rec)
+		return nil, err
+	}
+
+	m.logger.Debugf("recording %s of %s on client %s started", rec.ID, rec.Type, rec.ClientID)
+	return r, nil
+}
+
+// List returns all recordings, the most recent first
+func (m *Manager) List() ([]*Recording, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Recording, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), metaFileExt) {
+			continue
+		}
+		rec, err := m.readMeta(filepath.Join(m.opts.Dir, entry.Name()))
+		if err != nil {
+			m.logger.Errorf("failed to read recording metadata %q: %v", entry.Name(), err)
+			continue
+		}
+		result = append(result, rec)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	return result, nil
+}
+
+func (m *Manager) Get(id string) (*Recording, error) {
+	if !validIDRegexp.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	rec, err := m.readMeta(filepath.Join(m.opts.Dir, id+metaFileExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return rec, err
+}
+
+// Open returns the recording together with its data file which must be closed by the caller
+func (m *Manager) Open(id string) (*Recording, *os.File, error) {
+	rec, err := m.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(m.dataPath(rec))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	return rec, f, err
+}
+
+// DeleteFinishedBefore removes all recordings finished before the given time, it returns the number of removed recordings
+func (m *Manager) DeleteFinishedBefore(t time.Time) (int, error) {
+	recs, err := m.List()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, rec := range recs {
+		if rec.FinishedAt == nil || !rec.FinishedAt.Before(t) {
+			continue
+		}
+		if err := m.remove(rec); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (m *Manager) dataPath(rec *Recording) string {
+	return filepath.Join(m.opts.Dir, rec.Filename())
+}
+
+func (m *Manager) metaPath(rec *Recording) string {
+	return filepath.Join(m.opts.Dir, rec.ID+metaFileExt)
+}
+
+func (m *Manager) saveMeta(rec *Recording) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// write to a temp file first to never leave a partially written metadata file behind
+	tmp := m.metaPath(rec) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.metaPath(rec))
+}
+
+func (m *Manager) readMeta(path string) (*Recording, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Recording{}
+	if err := json.Unmarshal(b, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (m *Manager) remove(rec *Recording) error {
+	if err := os.Remove(m.dataPath(rec)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(m.metaPath(rec)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
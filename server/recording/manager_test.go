@@ -0,0 +1,196 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+var testLog = logger.NewLogger("recording", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func newTestManager(t *testing.T, opts Options) *Manager {
+	opts.Dir = filepath.Join(t.TempDir(), "recordings")
+	m, err := NewManager(opts, testLog)
+	require.NoError(t, err)
+	return m
+}
+
+func TestShouldRecord(t *testing.T) {
+	var disabled *Manager
+	assert.False(t, disabled.ShouldRecord(true))
+
+	m := newTestManager(t, Options{})
+	assert.False(t, m.ShouldRecord(false))
+	assert.True(t, m.ShouldRecord(true))
+
+	all := newTestManager(t, Options{RecordAll: true})
+	assert.True(t, all.ShouldRecord(false))
+}
+
+func TestAsciicastRecording(t *testing.T) {
+	m := newTestManager(t, Options{})
+
+	r, err := m.Start(&Recording{Type: TypeTerminal, Format: FormatAsciicast, ClientID: "client-1", Username: "admin"}, 100, 30)
+	require.NoError(t, err)
+	r.Input([]byte("ls\n"))
+	r.Output([]byte("file.txt\n"))
+	r.Resize(120, 40)
+	require.NoError(t, r.Close())
+
+	rec, f, err := m.Open(r.ID())
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, "admin", rec.Username)
+	assert.NotNil(t, rec.FinishedAt)
+	assert.Equal(t, r.ID()+".cast", rec.Filename())
+
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	header := asciicastHeader{}
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, uint16(100), header.Width)
+	assert.Equal(t, uint16(30), header.Height)
+
+	var events [][]interface{}
+	for scanner.Scan() {
+		var event []interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 3)
+	assert.Equal(t, []interface{}{"i", "ls\n"}, events[0][1:])
+	assert.Equal(t, []interface{}{"o", "file.txt\n"}, events[1][1:])
+	assert.Equal(t, []interface{}{"r", "120x40"}, events[2][1:])
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), rec.Size)
+}
+
+func TestRawRecordingWithMaxSize(t *testing.T) {
+	m := newTestManager(t, Options{MaxSize: 40})
+
+	r, err := m.Start(&Recording{Type: TypeTunnel, Format: FormatRaw, ClientID: "client-1"}, 0, 0)
+	require.NoError(t, err)
+	r.Input([]byte("request"))
+	r.Resize(10, 10)
+	r.Output([]byte("response"))
+	r.Output([]byte("exceeds the max size"))
+	require.NoError(t, r.Close())
+
+	rec, f, err := m.Open(r.ID())
+	require.NoError(t, err)
+	defer f.Close()
+	assert.True(t, rec.Truncated)
+	assert.Equal(t, int64(2*13+len("request")+len("response")), rec.Size)
+
+	frame, err := ReadRawFrame(f)
+	require.NoError(t, err)
+	assert.Equal(t, byte('i'), frame.Direction)
+	assert.Equal(t, "request", string(frame.Data))
+
+	frame, err = ReadRawFrame(f)
+	require.NoError(t, err)
+	assert.Equal(t, byte('o'), frame.Direction)
+	assert.Equal(t, "response", string(frame.Data))
+
+	_, err = ReadRawFrame(f)
+	assert.Equal(t, io.EOF, err)
+}
+
+type fakeConn struct {
+	io.Reader
+	io.Writer
+	closed bool
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTunnelRecorder(t *testing.T) {
+	m := newTestManager(t, Options{})
+	scheme := "http"
+	remote := models.Remote{LocalHost: "0.0.0.0", LocalPort: "3390", RemoteHost: "127.0.0.1", RemotePort: "80", Scheme: &scheme, Owner: "admin"}
+
+	conn := &fakeConn{Reader: strings.NewReader("GET / HTTP/1.1\r\n"), Writer: io.Discard}
+	wrapped := m.NewTunnelRecorder("client-1", "1", remote).Record(conn, "192.0.2.1:50000")
+
+	_, err := io.ReadAll(wrapped)
+	require.NoError(t, err)
+	_, err = wrapped.Write([]byte("HTTP/1.1 200 OK\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, wrapped.Close())
+	assert.True(t, conn.closed)
+
+	recs, err := m.List()
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	rec := recs[0]
+	assert.Equal(t, TypeTunnel, rec.Type)
+	assert.Equal(t, FormatAsciicast, rec.Format)
+	assert.Equal(t, "client-1", rec.ClientID)
+	assert.Equal(t, "1", rec.TunnelID)
+	assert.Equal(t, "http", rec.Scheme)
+	assert.Equal(t, "admin", rec.Username)
+	assert.Equal(t, "192.0.2.1:50000", rec.SourceAddr)
+	assert.NotNil(t, rec.FinishedAt)
+}
+
+func TestGetInvalidID(t *testing.T) {
+	m := newTestManager(t, Options{})
+
+	_, err := m.Get("../etc/passwd")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = m.Get("unknown")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestCleanupTask(t *testing.T) {
+	m := newTestManager(t, Options{})
+
+	finished, err := m.Start(&Recording{Type: TypeTerminal, Format: FormatAsciicast}, 80, 24)
+	require.NoError(t, err)
+	require.NoError(t, finished.Close())
+	running, err := m.Start(&Recording{Type: TypeTerminal, Format: FormatAsciicast}, 80, 24)
+	require.NoError(t, err)
+	defer running.Close()
+
+	// nothing is outdated yet
+	require.NoError(t, NewCleanupTask(m, time.Hour).Run(context.Background()))
+	recs, err := m.List()
+	require.NoError(t, err)
+	assert.Len(t, recs, 2)
+
+	require.NoError(t, NewCleanupTask(m, -time.Hour).Run(context.Background()))
+	recs, err = m.List()
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, running.ID(), recs[0].ID)
+
+	_, err = m.Get(finished.ID())
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestFormatForScheme(t *testing.T) {
+	telnet, ssh := "Telnet", "ssh"
+	assert.Equal(t, FormatAsciicast, FormatForScheme(&telnet))
+	assert.Equal(t, FormatRaw, FormatForScheme(&ssh))
+	assert.Equal(t, FormatRaw, FormatForScheme(nil))
+}
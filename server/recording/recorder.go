@@ -0,0 +1,122 @@
+package recording
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recorder writes the events of a single session to a recording.
+// All methods are safe to call on a nil Recorder, which means the session is not recorded.
+type Recorder struct {
+	manager *Manager
+	rec     *Recording
+	file    *os.File
+	enc     encoder
+	start   time.Time
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (r *Recorder) ID() string {
+	if r == nil {
+		return ""
+	}
+	return r.rec.ID
+}
+
+// Input records data sent to the client
+func (r *Recorder) Input(p []byte) {
+	r.write(eventInput, p)
+}
+
+// Output records data received from the client
+func (r *Recorder) Output(p []byte) {
+	r.write(eventOutput, p)
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.write(eventResize, []byte(fmt.Sprintf("%dx%d", cols, rows)))
+}
+
+func (r *Recorder) write(event string, p []byte) {
+	if r == nil || len(p) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.rec.Truncated {
+		return
+	}
+	if maxSize := r.manager.opts.MaxSize; maxSize > 0 && r.rec.Size+int64(len(p)) > maxSize {
+		r.rec.Truncated = true
+		r.manager.logger.Infof("recording %s reached the max size of %d bytes, further data is not recorded", r.rec.ID, maxSize)
+		return
+	}
+
+	n, err := r.enc.writeEvent(time.Since(r.start), event, p)
+	r.rec.Size += int64(n)
+	if err != nil {
+		r.manager.logger.Errorf("failed to write recording %s: %v", r.rec.ID, err)
+	}
+}
+
+// Close finishes the recording
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	err := r.file.Close()
+	finishedAt := time.Now().UTC()
+	r.rec.FinishedAt = &finishedAt
+	if metaErr := r.manager.saveMeta(r.rec); metaErr != nil {
+		err = metaErr
+	}
+	r.manager.logger.Debugf("recording %s finished, %d bytes recorded", r.rec.ID, r.rec.Size)
+	return err
+}
+
+// WrapConn returns a connection that records all data read from conn as input and all data written to conn as output.
+// The recording is finished when the returned connection is closed.
+func (r *Recorder) WrapConn(conn io.ReadWriteCloser) io.ReadWriteCloser {
+	if r == nil {
+		return conn
+	}
+	return &recordedConn{ReadWriteCloser: conn, recorder: r}
+}
+
+type recordedConn struct {
+	io.ReadWriteCloser
+	recorder *Recorder
+}
+
+func (c *recordedConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	c.recorder.Input(p[:n])
+	return n, err
+}
+
+func (c *recordedConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	c.recorder.Output(p[:n])
+	return n, err
+}
+
+func (c *recordedConn) Close() error {
+	err := c.ReadWriteCloser.Close()
+	if recErr := c.recorder.Close(); recErr != nil {
+		c.recorder.manager.logger.Errorf("failed to finish recording %s: %v", c.recorder.ID(), recErr)
+	}
+	return err
+}
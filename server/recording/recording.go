@@ -0,0 +1,71 @@
+package recording
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	TypeTunnel   = "tunnel"
+	TypeTerminal = "terminal"
+
+	// FormatAsciicast is the asciicast v2 format, see https://docs.asciinema.org/manual/asciicast/v2/
+	FormatAsciicast = "asciicast"
+	// FormatRaw is a sequence of frames, each consisting of
+	// an 8 byte big endian offset in microseconds since the start of the recording,
+	// a direction byte ('i' for data sent to the client, 'o' for data received from the client),
+	// a 4 byte big endian length and the data itself.
+	FormatRaw = "raw"
+
+	DefaultTerminalWidth  = 80
+	DefaultTerminalHeight = 24
+)
+
+// textSchemes are tunnel schemes with mostly printable traffic that can be replayed as asciicast
+var textSchemes = map[string]bool{
+	"telnet": true,
+	"http":   true,
+	"ftp":    true,
+	"smtp":   true,
+	"pop3":   true,
+	"imap":   true,
+}
+
+// Recording contains the metadata of a recorded session
+type Recording struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Format     string     `json:"format"`
+	ClientID   string     `json:"client_id"`
+	TunnelID   string     `json:"tunnel_id"`
+	Remote     string     `json:"remote"`
+	Scheme     string     `json:"scheme"`
+	Username   string     `json:"username"`
+	SourceAddr string     `json:"source_addr"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Size       int64      `json:"size"`
+	Truncated  bool       `json:"truncated"`
+}
+
+// FormatForScheme returns the recording format for tunnel traffic of the given scheme
+func FormatForScheme(scheme *string) string {
+	if scheme != nil && textSchemes[strings.ToLower(*scheme)] {
+		return FormatAsciicast
+	}
+	return FormatRaw
+}
+
+func (r *Recording) Filename() string {
+	if r.Format == FormatAsciicast {
+		return r.ID + ".cast"
+	}
+	return r.ID + ".raw"
+}
+
+func (r *Recording) ContentType() string {
+	if r.Format == FormatAsciicast {
+		return "application/x-asciicast"
+	}
+	return "application/octet-stream"
+}
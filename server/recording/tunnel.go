@@ -0,0 +1,47 @@
+package recording
+
+import (
+	"io"
+
+	"github.com/openrport/openrport/share/models"
+)
+
+// TunnelRecorder starts a new recording for every connection of a tunnel
+type TunnelRecorder struct {
+	manager  *Manager
+	clientID string
+	tunnelID string
+	remote   models.Remote
+}
+
+func (m *Manager) NewTunnelRecorder(clientID, tunnelID string, remote models.Remote) *TunnelRecorder {
+	return &TunnelRecorder{
+		manager:  m,
+		clientID: clientID,
+		tunnelID: tunnelID,
+		remote:   remote,
+	}
+}
+
+// Record wraps the connection, on failure the connection is returned as is to not interrupt the tunnel
+func (t *TunnelRecorder) Record(conn io.ReadWriteCloser, sourceAddr string) io.ReadWriteCloser {
+	rec := &Recording{
+		Type:       TypeTunnel,
+		Format:     FormatForScheme(t.remote.Scheme),
+		ClientID:   t.clientID,
+		TunnelID:   t.tunnelID,
+		Remote:     t.remote.Remote(),
+		Username:   t.remote.Owner,
+		SourceAddr: sourceAddr,
+	}
+	if t.remote.Scheme != nil {
+		rec.Scheme = *t.remote.Scheme
+	}
+
+	recorder, err := t.manager.Start(rec, DefaultTerminalWidth, DefaultTerminalHeight)
+	if err != nil {
+		t.manager.logger.Errorf("failed to start recording of tunnel %s on client %s: %v", t.tunnelID, t.clientID, err)
+		return conn
+	}
+	return recorder.WrapConn(conn)
+}
@@ -0,0 +1,143 @@
+package roles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openrport/openrport/server/api/errors"
+)
+
+type Provider interface {
+	GetAll(ctx context.Context) ([]*Role, error)
+	Get(ctx context.Context, id string) (*Role, error)
+	Save(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Manager manages the custom roles and checks the access they grant to the members of user groups.
+// The access granted by the permissions of user groups is checked by the users service, it's only listed here as built-in roles.
+type Manager struct {
+	provider Provider
+}
+
+func NewManager(provider Provider) *Manager {
+	return &Manager{
+		provider: provider,
+	}
+}
+
+// List returns the built-in roles followed by the custom roles
+func (m *Manager) List(ctx context.Context) ([]*Role, error) {
+	custom, err := m.provider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(BuiltInRoles(), custom...), nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Role, error) {
+	if strings.HasPrefix(id, BuiltInPrefix) {
+		for _, r := range BuiltInRoles() {
+			if r.ID == id {
+				return r, nil
+			}
+		}
+	}
+
+	role, err := m.provider.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("role %q not found", id),
+		}
+	}
+	return role, nil
+}
+
+// Save creates or updates a custom role
+func (m *Manager) Save(ctx context.Context, role *Role) error {
+	if err := role.Validate(); err != nil {
+		return errors.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "Invalid role.",
+			Err:        err,
+		}
+	}
+	if role.UserGroups == nil {
+		role.UserGroups = []string{}
+	}
+	return m.provider.Save(ctx, role)
+}
+
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	role, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return errors.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "built-in roles cannot be deleted, change the permissions of the user groups instead",
+		}
+	}
+	return m.provider.Delete(ctx, id)
+}
+
+// grantedTo returns the custom roles granted to at least one of the user groups
+func (m *Manager) grantedTo(ctx context.Context, userGroups []string) ([]*Role, error) {
+	all, err := m.provider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Role
+	for _, r := range all {
+		if intersects(r.UserGroups, userGroups) {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+// Authorize returns true if a custom role granted to one of the user groups allows the access to the resource of the target
+func (m *Manager) Authorize(ctx context.Context, userGroups []string, resource string, access Access, target Target) (bool, error) {
+	granted, err := m.grantedTo(ctx, userGroups)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range granted {
+		if r.Grants(resource, access, target) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ManagedUserGroups returns the user groups the members of the given user groups can access the resource for.
+// If all is true, the access is not limited to some user groups.
+func (m *Manager) ManagedUserGroups(ctx context.Context, userGroups []string, resource string, access Access) (groups []string, all bool, err error) {
+	granted, err := m.grantedTo(ctx, userGroups)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, r := range granted {
+		if r.Scope.HasClientScope() {
+			continue
+		}
+		if a, ok := r.Rules[resource]; !ok || !a.Allows(access) {
+			continue
+		}
+		if len(r.Scope.UserGroups) == 0 {
+			return nil, true, nil
+		}
+		groups = append(groups, r.Scope.UserGroups...)
+	}
+	return groups, false, nil
+}
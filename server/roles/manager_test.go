@@ -0,0 +1,130 @@
+package roles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientgroupsmigration "github.com/openrport/openrport/db/migration/client_groups"
+	"github.com/openrport/openrport/share/test"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	db := test.NewMemoryDB(t, clientgroupsmigration.AssetNames(), clientgroupsmigration.Asset)
+
+	return NewManager(NewSQLiteProvider(db))
+}
+
+func TestManagerCRUD(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	role := &Role{
+		ID:          "helpdesk",
+		Description: "Support staff",
+		UserGroups:  []string{"support"},
+		Rules:       Rules{ResourceClients: AccessRead},
+		Scope:       Scope{ClientTags: []string{"kiosk"}},
+	}
+	require.NoError(t, m.Save(ctx, role))
+
+	stored, err := m.Get(ctx, "helpdesk")
+	require.NoError(t, err)
+	assert.Equal(t, role, stored)
+
+	role.Rules[ResourceUsers] = AccessWrite
+	role.UserGroups = nil
+	require.NoError(t, m.Save(ctx, role))
+
+	stored, err = m.Get(ctx, "helpdesk")
+	require.NoError(t, err)
+	assert.Equal(t, Rules{ResourceClients: AccessRead, ResourceUsers: AccessWrite}, stored.Rules)
+	assert.Len(t, stored.UserGroups, 0)
+
+	all, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, len(BuiltInRoles())+1)
+	assert.Equal(t, "builtin-tunnels", all[0].ID)
+	assert.True(t, all[0].BuiltIn)
+	assert.Equal(t, "helpdesk", all[len(all)-1].ID)
+
+	test.RequireHTTPStatus(t, m.Save(ctx, &Role{ID: "helpdesk"}), 400)
+	test.RequireHTTPStatus(t, m.Delete(ctx, "builtin-commands"), 400)
+
+	require.NoError(t, m.Delete(ctx, "helpdesk"))
+	_, err = m.Get(ctx, "helpdesk")
+	test.RequireHTTPStatus(t, err, 404)
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	require.NoError(t, m.Save(ctx, &Role{
+		ID:         "viewer",
+		UserGroups: []string{"support"},
+		Rules:      Rules{ResourceClientGroups: AccessRead},
+	}))
+	require.NoError(t, m.Save(ctx, &Role{
+		ID:         "kiosk-operator",
+		UserGroups: []string{"support", "kiosk"},
+		Rules:      Rules{ResourceClients: AccessWrite},
+		Scope:      Scope{ClientTags: []string{"kiosk"}},
+	}))
+
+	kiosk := Target{Client: &ClientTarget{Tags: []string{"kiosk"}}}
+
+	allowed, err := m.Authorize(ctx, []string{"support"}, ResourceClientGroups, AccessRead, Target{})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = m.Authorize(ctx, []string{"support"}, ResourceClientGroups, AccessWrite, Target{})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = m.Authorize(ctx, []string{"kiosk"}, ResourceClients, AccessWrite, kiosk)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = m.Authorize(ctx, []string{"kiosk"}, ResourceClients, AccessWrite, Target{})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = m.Authorize(ctx, []string{"guests"}, ResourceClients, AccessRead, kiosk)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestManagedUserGroups(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	require.NoError(t, m.Save(ctx, &Role{
+		ID:         "team-lead",
+		UserGroups: []string{"leads"},
+		Rules:      Rules{ResourceUsers: AccessWrite},
+		Scope:      Scope{UserGroups: []string{"team-a", "team-b"}},
+	}))
+	require.NoError(t, m.Save(ctx, &Role{
+		ID:         "auditor",
+		UserGroups: []string{"auditors"},
+		Rules:      Rules{ResourceUsers: AccessRead},
+	}))
+
+	groups, all, err := m.ManagedUserGroups(ctx, []string{"leads"}, ResourceUsers, AccessWrite)
+	require.NoError(t, err)
+	assert.False(t, all)
+	assert.Equal(t, []string{"team-a", "team-b"}, groups)
+
+	groups, all, err = m.ManagedUserGroups(ctx, []string{"auditors"}, ResourceUsers, AccessRead)
+	require.NoError(t, err)
+	assert.True(t, all)
+	assert.Nil(t, groups)
+
+	groups, all, err = m.ManagedUserGroups(ctx, []string{"auditors"}, ResourceUsers, AccessWrite)
+	require.NoError(t, err)
+	assert.False(t, all)
+	assert.Empty(t, groups)
+}
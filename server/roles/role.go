@@ -0,0 +1,273 @@
+package roles
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/share/types"
+)
+
+type Access string
+
+const (
+	AccessRead  Access = "read"
+	AccessWrite Access = "write"
+)
+
+// Allows returns true if the access includes the required one, write access includes read access
+func (a Access) Allows(required Access) bool {
+	return a == AccessWrite || a == required
+}
+
+// AccessForMethod returns the access needed for a http request, requests that don't change anything need read access
+func AccessForMethod(method string) Access {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return AccessRead
+	default:
+		return AccessWrite
+	}
+}
+
+// Resources only administrators could access before roles were introduced
+const (
+	ResourceClients          = "clients"
+	ResourceClientGroups     = "client-groups"
+	ResourceClientsAuth      = "clients-auth"
+	ResourceUsers            = "users"
+	ResourceUserGroups       = "user-groups"
+	ResourceNotificationLogs = "notification-logs"
+	ResourceAlerting         = "alerting"
+	ResourceVaultAdmin       = "vault-admin"
+)
+
+// ResourceRoles can't be granted, managing roles is limited to administrators
+const ResourceRoles = "roles"
+
+// AdminResources are the resources of routes protected by the admin access middleware
+var AdminResources = []string{
+	ResourceClients,
+	ResourceClientGroups,
+	ResourceClientsAuth,
+	ResourceUsers,
+	ResourceUserGroups,
+	ResourceNotificationLogs,
+	ResourceAlerting,
+	ResourceVaultAdmin,
+}
+
+// AllResources are all resources roles can grant access to, the user group permissions and the admin resources
+var AllResources = append(append([]string{}, users.AllPermissions...), AdminResources...)
+
+// BuiltInPrefix is the prefix of the ids of roles that represent the permissions of user groups
+const BuiltInPrefix = "builtin-"
+
+const idMaxLength = 50
+
+var validIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Role struct {
+	ID          string `json:"id" db:"id"`
+	Description string `json:"description" db:"description"`
+	// UserGroups the role is granted to
+	UserGroups types.StringSlice `json:"user_groups" db:"user_groups"`
+	Rules      Rules             `json:"rules" db:"rules"`
+	Scope      Scope             `json:"scope" db:"scope"`
+	// BuiltIn roles are not stored, they are granted through the permissions of user groups
+	BuiltIn bool `json:"built_in" db:"-"`
+}
+
+// Rules holds the access granted per resource
+type Rules map[string]Access
+
+// Scope limits the objects the rules of a role apply to, an empty scope applies to all objects
+type Scope struct {
+	// ClientGroups and ClientTags limit the role to requests on a single client which is member of one of the client
+	// groups or has one of the tags, or to requests on one of the client groups
+	ClientGroups []string `json:"client_groups"`
+	ClientTags   []string `json:"client_tags"`
+	// UserGroups limits the role to users that are only member of these groups and to these user groups
+	UserGroups []string `json:"user_groups"`
+}
+
+// Target describes the object a request is performed on
+type Target struct {
+	// Client is set for requests on a single client or client group
+	Client *ClientTarget
+	// UserGroups is non-nil for requests on a single user or user group, it holds the groups of the user or the group itself
+	UserGroups []string
+}
+
+type ClientTarget struct {
+	// Groups are the ids of the client groups the client is member of or the id of the requested client group
+	Groups []string
+	Tags   []string
+}
+
+// HasClientScope returns true if the role is limited to some clients
+func (s Scope) HasClientScope() bool {
+	return len(s.ClientGroups) > 0 || len(s.ClientTags) > 0
+}
+
+// Matches returns true if the target is within the scope
+func (s Scope) Matches(target Target) bool {
+	if s.HasClientScope() {
+		if target.Client == nil {
+			return false
+		}
+		if !intersects(s.ClientGroups, target.Client.Groups) && !intersects(s.ClientTags, target.Client.Tags) {
+			return false
+		}
+	}
+	if len(s.UserGroups) > 0 && target.UserGroups != nil {
+		if len(target.UserGroups) == 0 || !IsSubset(target.UserGroups, s.UserGroups) {
+			return false
+		}
+	}
+	return true
+}
+
+func intersects(a, b []string) bool {
+	for _, v := range a {
+		for _, w := range b {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsSubset returns true if all values are contained in set
+func IsSubset(values, set []string) bool {
+	for _, v := range values {
+		found := false
+		for _, s := range set {
+			if v == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Grants returns true if the role allows the access to the resource of the target
+func (r Role) Grants(resource string, access Access, target Target) bool {
+	granted, ok := r.Rules[resource]
+	return ok && granted.Allows(access) && r.Scope.Matches(target)
+}
+
+// BuiltInRoles returns the roles representing the permissions of user groups,
+// they grant write access to the resource of the permission without scope
+func BuiltInRoles() []*Role {
+	res := make([]*Role, 0, len(users.AllPermissions))
+	for _, p := range users.AllPermissions {
+		res = append(res, &Role{
+			ID:          BuiltInPrefix + p,
+			Description: fmt.Sprintf("Granted by the %q permission of user groups", p),
+			UserGroups:  types.StringSlice{},
+			Rules:       Rules{p: AccessWrite},
+			BuiltIn:     true,
+		})
+	}
+	return res
+}
+
+func (r Role) Validate() error {
+	if strings.TrimSpace(r.ID) == "" {
+		return errors.New("role id cannot be empty")
+	}
+	if len(r.ID) > idMaxLength {
+		return fmt.Errorf("invalid role id: max length %d, got %d", idMaxLength, len(r.ID))
+	}
+	if !validIDRegexp.MatchString(r.ID) {
+		return fmt.Errorf("invalid role id %q: can contain only \"A-Za-z0-9_-\"", r.ID)
+	}
+	if strings.HasPrefix(r.ID, BuiltInPrefix) {
+		return fmt.Errorf("invalid role id %q: the prefix %q is reserved for built-in roles", r.ID, BuiltInPrefix)
+	}
+	if len(r.Rules) == 0 {
+		return errors.New("role must have at least one rule")
+	}
+
+	resources := make([]string, 0, len(r.Rules))
+	for resource := range r.Rules {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		if !IsSubset([]string{resource}, AllResources) {
+			return fmt.Errorf("invalid resource %q, must be one of: %s", resource, strings.Join(AllResources, ", "))
+		}
+		if access := r.Rules[resource]; access != AccessRead && access != AccessWrite {
+			return fmt.Errorf("invalid access %q to %q, must be %q or %q", access, resource, AccessRead, AccessWrite)
+		}
+	}
+
+	for _, g := range r.UserGroups {
+		if g == users.Administrators {
+			return fmt.Errorf("%s have all permissions, the role cannot be granted to them", users.Administrators)
+		}
+	}
+	for _, g := range r.Scope.UserGroups {
+		if g == users.Administrators {
+			return fmt.Errorf("the scope cannot include %s", users.Administrators)
+		}
+	}
+
+	return nil
+}
+
+func (r Rules) Value() (driver.Value, error) {
+	return marshalJSONColumn(r)
+}
+
+func (r *Rules) Scan(value interface{}) error {
+	return scanJSONColumn(value, r, "rules")
+}
+
+func (s Scope) Value() (driver.Value, error) {
+	return marshalJSONColumn(s)
+}
+
+func (s *Scope) Scan(value interface{}) error {
+	return scanJSONColumn(value, s, "scope")
+}
+
+func marshalJSONColumn(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func scanJSONColumn(value interface{}, dest interface{}, name string) error {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("failed to decode %q field: unknown column type %T", name, value)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to decode %q field: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package roles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openrport/openrport/server/api/users"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		role    Role
+		wantErr string
+	}{
+		{
+			name: "valid",
+			role: Role{ID: "helpdesk", Rules: Rules{ResourceClients: AccessRead, users.PermissionCommands: AccessWrite}},
+		},
+		{
+			name:    "empty id",
+			role:    Role{ID: " ", Rules: Rules{ResourceClients: AccessRead}},
+			wantErr: "role id cannot be empty",
+		},
+		{
+			name:    "invalid id",
+			role:    Role{ID: "help desk", Rules: Rules{ResourceClients: AccessRead}},
+			wantErr: `invalid role id "help desk": can contain only "A-Za-z0-9_-"`,
+		},
+		{
+			name:    "reserved prefix",
+			role:    Role{ID: "builtin-commands", Rules: Rules{ResourceClients: AccessRead}},
+			wantErr: `invalid role id "builtin-commands": the prefix "builtin-" is reserved for built-in roles`,
+		},
+		{
+			name:    "no rules",
+			role:    Role{ID: "helpdesk"},
+			wantErr: "role must have at least one rule",
+		},
+		{
+			name:    "unknown resource",
+			role:    Role{ID: "helpdesk", Rules: Rules{"planets": AccessRead}},
+			wantErr: `invalid resource "planets"`,
+		},
+		{
+			name:    "roles can't be granted",
+			role:    Role{ID: "helpdesk", Rules: Rules{ResourceRoles: AccessRead}},
+			wantErr: `invalid resource "roles"`,
+		},
+		{
+			name:    "invalid access",
+			role:    Role{ID: "helpdesk", Rules: Rules{ResourceClients: "execute"}},
+			wantErr: `invalid access "execute" to "clients", must be "read" or "write"`,
+		},
+		{
+			name:    "granted to administrators",
+			role:    Role{ID: "helpdesk", UserGroups: []string{users.Administrators}, Rules: Rules{ResourceClients: AccessRead}},
+			wantErr: "Administrators have all permissions, the role cannot be granted to them",
+		},
+		{
+			name:    "administrators in scope",
+			role:    Role{ID: "helpdesk", Rules: Rules{ResourceUsers: AccessWrite}, Scope: Scope{UserGroups: []string{users.Administrators}}},
+			wantErr: "the scope cannot include Administrators",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.role.Validate()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGrants(t *testing.T) {
+	role := Role{
+		ID:    "helpdesk",
+		Rules: Rules{ResourceClients: AccessRead, ResourceUsers: AccessWrite},
+		Scope: Scope{
+			ClientGroups: []string{"branch-office"},
+			ClientTags:   []string{"kiosk"},
+			UserGroups:   []string{"staff"},
+		},
+	}
+	branchClient := &ClientTarget{Groups: []string{"all", "branch-office"}}
+	kioskClient := &ClientTarget{Tags: []string{"kiosk"}}
+	otherClient := &ClientTarget{Groups: []string{"all"}, Tags: []string{"server"}}
+
+	testCases := []struct {
+		name     string
+		resource string
+		access   Access
+		target   Target
+		want     bool
+	}{
+		{name: "read client in group", resource: ResourceClients, access: AccessRead, target: Target{Client: branchClient}, want: true},
+		{name: "read client with tag", resource: ResourceClients, access: AccessRead, target: Target{Client: kioskClient}, want: true},
+		{name: "write client", resource: ResourceClients, access: AccessWrite, target: Target{Client: branchClient}},
+		{name: "client out of scope", resource: ResourceClients, access: AccessRead, target: Target{Client: otherClient}},
+		{name: "no client", resource: ResourceClients, access: AccessRead},
+		{name: "resource not granted", resource: ResourceAlerting, access: AccessRead, target: Target{Client: branchClient}},
+		{name: "write user in scope", resource: ResourceUsers, access: AccessWrite, target: Target{Client: branchClient, UserGroups: []string{"staff"}}, want: true},
+		{name: "user out of scope", resource: ResourceUsers, access: AccessWrite, target: Target{Client: branchClient, UserGroups: []string{"staff", "admins"}}},
+		{name: "user without groups", resource: ResourceUsers, access: AccessWrite, target: Target{Client: branchClient, UserGroups: []string{}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, role.Grants(tc.resource, tc.access, tc.target))
+		})
+	}
+}
+
+func TestAccessForMethod(t *testing.T) {
+	assert.Equal(t, AccessRead, AccessForMethod("GET"))
+	assert.Equal(t, AccessRead, AccessForMethod("HEAD"))
+	assert.Equal(t, AccessWrite, AccessForMethod("POST"))
+	assert.Equal(t, AccessWrite, AccessForMethod("DELETE"))
+}
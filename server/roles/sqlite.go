@@ -0,0 +1,62 @@
+package roles
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/share/query"
+)
+
+type SQLiteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSQLiteProvider(db *sqlx.DB) *SQLiteProvider {
+	return &SQLiteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+func (p *SQLiteProvider) GetAll(ctx context.Context) ([]*Role, error) {
+	res := []*Role{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM roles ORDER BY LOWER(id)")
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *SQLiteProvider) Get(ctx context.Context, id string) (*Role, error) {
+	res := &Role{}
+	err := p.db.GetContext(ctx, res, p.converter.Rebind("SELECT * FROM roles WHERE id = ?"), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *SQLiteProvider) Save(ctx context.Context, role *Role) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO roles (id, description, user_groups, rules, scope) VALUES (:id, :description, :user_groups, :rules, :scope)
+		ON CONFLICT (id) DO UPDATE SET
+			description = EXCLUDED.description,
+			user_groups = EXCLUDED.user_groups,
+			rules = EXCLUDED.rules,
+			scope = EXCLUDED.scope`,
+		role,
+	)
+	return err
+}
+
+func (p *SQLiteProvider) Delete(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM roles WHERE id = ?"), id)
+	return err
+}
@@ -1,21 +1,27 @@
 package routes
 
 const (
-	ParamClientID         = "client_id"
-	ParamClientAuthID     = "client_auth_id"
-	ParamUserID           = "user_id"
-	ParamSessionID        = "session_id"
-	ParamJobID            = "job_id"
-	ParamGroupID          = "group_id"
-	ParamTokenPrefix      = "prefix"
-	ParamVaultValueID     = "vault_value_id"
-	ParamScriptValueID    = "script_value_id"
-	ParamCommandValueID   = "command_value_id"
-	ParamGraphName        = "graph_name"
-	ParamTemplateID       = "template_id"
-	ParamProblemID        = "problem_id"
-	ParamNotificationID   = "notification_id"
-	ParamSampleDataChoice = "sample_data_choice"
+	ParamClientID          = "client_id"
+	ParamClientAuthID      = "client_auth_id"
+	ParamUserID            = "user_id"
+	ParamSessionID         = "session_id"
+	ParamJobID             = "job_id"
+	ParamGroupID           = "group_id"
+	ParamTokenPrefix       = "prefix"
+	ParamVaultValueID      = "vault_value_id"
+	ParamScriptValueID     = "script_value_id"
+	ParamCommandValueID    = "command_value_id"
+	ParamGraphName         = "graph_name"
+	ParamTemplateID        = "template_id"
+	ParamProblemID         = "problem_id"
+	ParamNotificationID    = "notification_id"
+	ParamSampleDataChoice  = "sample_data_choice"
+	ParamRecordingID       = "recording_id"
+	ParamDownloadID        = "download_id"
+	ParamOutputStream      = "stream"
+	ParamCredentialID      = "credential_id"
+	ParamRoleID            = "role_id"
+	ParamEnrollmentTokenID = "token_id"
 
 	AllRoutesPrefix             = "/api/v1"
 	AuthRoutesPrefix            = "/auth"
@@ -23,6 +29,7 @@ const (
 	AuthSettingsRoute           = "/ext/settings"
 	AuthDeviceSettingsRoute     = "/ext/settings/device"
 	AlertingServiceRoutesPrefix = "/monitoring"
+	SCIMRoutesPrefix            = "/scim/v2"
 	ASRuleSetRoute              = "/rules"
 	ASTemplatesRoute            = "/notification-templates"
 	ASProblemsRoute             = "/problems"
@@ -30,5 +37,16 @@ const (
 	ASSampleDataRoute           = "/sample-data"
 	TotPRoutes                  = "/me/totp-secret"
 	Verify2FaRoute              = "/verify-2fa"
+	WebAuthnRegistrationRoute   = "/me/webauthn/registration"
+	WebAuthnCredentialsRoute    = "/me/webauthn/credentials"
+	WebAuthnLoginOptionsRoute   = "/login/webauthn/options"
+	WebAuthnLoginRoute          = "/login/webauthn"
 	FilesUploadRouteName        = "files"
+	ClientsListRouteName        = "clients"
+	MultiClientCommandRouteName = "multi-client-command"
+	MultiClientScriptRouteName  = "multi-client-script"
+	CommandsWSRouteName         = "commands-ws"
+	ScriptsWSRouteName          = "scripts-ws"
+	SchedulesCreateRouteName    = "schedules-create"
+	DownloadsCreateRouteName    = "downloads-create"
 )
@@ -0,0 +1,434 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api/users"
+)
+
+// groupMembers returns the names of all groups and their members. Groups are the union of groups with details and
+// groups users are member of, so without group details a group only exists as long as it has members.
+func (h *handler) groupMembers() ([]string, map[string][]string, error) {
+	var groups []users.Group
+	if h.users.SupportsGroupPermissions() {
+		var err error
+		groups, err = h.users.ListGroups()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	all, err := h.users.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members := make(map[string][]string, len(groups))
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if _, ok := members[g.Name]; !ok {
+			members[g.Name] = []string{}
+			names = append(names, g.Name)
+		}
+	}
+	for _, u := range all {
+		for _, g := range u.Groups {
+			if _, ok := members[g]; !ok {
+				names = append(names, g)
+			}
+			members[g] = append(members[g], u.Username)
+		}
+	}
+	sort.Strings(names)
+
+	return names, members, nil
+}
+
+func (h *handler) toGroup(name string, members []string, withMembers bool) Group {
+	res := Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          name,
+		DisplayName: name,
+		Meta:        &Meta{ResourceType: ResourceTypeGroup, Location: h.location("Groups", name)},
+	}
+	if withMembers {
+		res.Members = make([]MultiValue, 0, len(members))
+		for _, m := range members {
+			res.Members = append(res.Members, MultiValue{Value: m, Display: m, Ref: h.location("Users", m)})
+		}
+	}
+	return res
+}
+
+// withMembers returns false if members are excluded from the response, groups of identity providers can be huge
+func withMembers(req *http.Request) bool {
+	for _, attr := range strings.Split(req.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *handler) handleListGroups(w http.ResponseWriter, req *http.Request) {
+	names, members, err := h.groupMembers()
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if filter := req.URL.Query().Get("filter"); filter != "" {
+		attr, value, err := parseEqFilter(filter)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		if !strings.EqualFold(attr, "displayName") && !strings.EqualFold(attr, "id") {
+			h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, fmt.Sprintf("filtering by %q is not supported", attr)))
+			return
+		}
+		var matching []string
+		for _, name := range names {
+			if name == value {
+				matching = append(matching, name)
+			}
+		}
+		names = matching
+	}
+
+	h.writeList(w, req, len(names), func(start, end int) interface{} {
+		res := make([]Group, 0, end-start)
+		for _, name := range names[start:end] {
+			res = append(res, h.toGroup(name, members[name], withMembers(req)))
+		}
+		return res
+	})
+}
+
+// getGroup returns the members of an existing group
+func (h *handler) getGroup(name string) ([]string, error) {
+	_, members, err := h.groupMembers()
+	if err != nil {
+		return nil, err
+	}
+	groupMembers, ok := members[name]
+	if !ok {
+		return nil, NewError(http.StatusNotFound, "", fmt.Sprintf("group %q not found", name))
+	}
+	return groupMembers, nil
+}
+
+func (h *handler) handleGetGroup(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["id"]
+	members, err := h.getGroup(name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.toGroup(name, members, withMembers(req)))
+}
+
+func (h *handler) handleCreateGroup(w http.ResponseWriter, req *http.Request) {
+	var in Group
+	if !h.readJSON(w, req, &in) {
+		return
+	}
+	if in.DisplayName == "" {
+		h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "displayName is required"))
+		return
+	}
+
+	_, members, err := h.groupMembers()
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if _, ok := members[in.DisplayName]; ok {
+		h.writeError(w, NewError(http.StatusConflict, ErrTypeUniqueness, fmt.Sprintf("group %q already exists", in.DisplayName)))
+		return
+	}
+
+	if h.users.SupportsGroupPermissions() {
+		if _, err := h.users.UpdateGroup(in.DisplayName, users.NewGroup(in.DisplayName, nil, nil)); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if err := h.setMembers(in.DisplayName, nil, memberSet(in.Members)); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeGroup(w, in.DisplayName, http.StatusCreated)
+}
+
+func (h *handler) handleReplaceGroup(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["id"]
+	members, err := h.getGroup(name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var in Group
+	if !h.readJSON(w, req, &in) {
+		return
+	}
+
+	h.updateGroup(w, name, members, in.DisplayName, memberSet(in.Members))
+}
+
+func (h *handler) handlePatchGroup(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["id"]
+	members, err := h.getGroup(name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var patch PatchRequest
+	if !h.readJSON(w, req, &patch) {
+		return
+	}
+
+	// the operations are applied in memory first, the result is saved at once
+	newName := name
+	desired := make(map[string]bool, len(members))
+	for _, m := range members {
+		desired[m] = true
+	}
+	for _, op := range patch.Operations {
+		values := map[string]json.RawMessage{}
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "patch value without path must be an object"))
+				return
+			}
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			if err := applyGroupOp(strings.ToLower(op.Op), path, value, &newName, desired); err != nil {
+				h.writeError(w, err)
+				return
+			}
+		}
+	}
+
+	h.updateGroup(w, name, members, newName, desired)
+}
+
+func applyGroupOp(op, path string, value json.RawMessage, name *string, members map[string]bool) error {
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "displayname":
+		if op == "remove" {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "displayName cannot be removed")
+		}
+		if err := json.Unmarshal(value, name); err != nil || *name == "" {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of displayName: %s", value))
+		}
+	case attr == "members":
+		var values []MultiValue
+		if len(value) > 0 {
+			if err := json.Unmarshal(value, &values); err != nil {
+				return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of members: %s", value))
+			}
+		}
+		switch op {
+		case "add":
+			for _, v := range values {
+				members[v.Value] = true
+			}
+		case "remove":
+			if len(values) == 0 {
+				for m := range members {
+					delete(members, m)
+				}
+			}
+			for _, v := range values {
+				delete(members, v.Value)
+			}
+		case "replace":
+			for m := range members {
+				delete(members, m)
+			}
+			for _, v := range values {
+				members[v.Value] = true
+			}
+		default:
+			return NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, fmt.Sprintf("unsupported patch operation %q", op))
+		}
+	case strings.HasPrefix(attr, "members["):
+		// a filtered path like members[value eq "john"]
+		if op != "remove" {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidPath, fmt.Sprintf("unsupported path %q for operation %q", path, op))
+		}
+		filter := strings.TrimSuffix(path[len("members["):], "]")
+		filterAttr, member, err := parseEqFilter(filter)
+		if err != nil || !strings.EqualFold(filterAttr, "value") {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidPath, fmt.Sprintf("unsupported path %q", path))
+		}
+		delete(members, member)
+	default:
+		return NewError(http.StatusBadRequest, ErrTypeInvalidPath, fmt.Sprintf("unsupported path %q", path))
+	}
+	return nil
+}
+
+func memberSet(values []MultiValue) map[string]bool {
+	res := make(map[string]bool, len(values))
+	for _, v := range values {
+		res[v.Value] = true
+	}
+	return res
+}
+
+// updateGroup renames the group if newName differs and sets the members to the desired ones
+func (h *handler) updateGroup(w http.ResponseWriter, name string, members []string, newName string, desired map[string]bool) {
+	if newName != "" && newName != name {
+		if err := h.renameGroup(name, newName, members); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		name = newName
+	}
+
+	if err := h.setMembers(name, members, desired); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeGroup(w, name, http.StatusOK)
+}
+
+func (h *handler) renameGroup(name, newName string, members []string) error {
+	_, all, err := h.groupMembers()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[newName]; ok {
+		return NewError(http.StatusConflict, ErrTypeUniqueness, fmt.Sprintf("group %q already exists", newName))
+	}
+
+	if h.users.SupportsGroupPermissions() {
+		details, err := h.users.GetGroup(name)
+		if err != nil {
+			return err
+		}
+		details.Name = newName
+		if _, err := h.users.UpdateGroup(newName, details); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range members {
+		user, err := h.getUser(m)
+		if err != nil {
+			return err
+		}
+		groups := make([]string, 0, len(user.Groups))
+		for _, g := range user.Groups {
+			if g == name {
+				g = newName
+			}
+			groups = append(groups, g)
+		}
+		if err := h.users.Change(&users.User{Groups: groups}, m); err != nil {
+			return err
+		}
+	}
+
+	if h.users.SupportsGroupPermissions() {
+		return h.users.DeleteGroup(name)
+	}
+	return nil
+}
+
+// setMembers adds and removes the group to users so that the members of the group match the desired ones
+func (h *handler) setMembers(name string, current []string, desired map[string]bool) error {
+	isMember := make(map[string]bool, len(current))
+	for _, m := range current {
+		isMember[m] = true
+		if !desired[m] {
+			if err := h.changeMembership(name, m, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	toAdd := make([]string, 0, len(desired))
+	for m := range desired {
+		if !isMember[m] {
+			toAdd = append(toAdd, m)
+		}
+	}
+	sort.Strings(toAdd)
+	for _, m := range toAdd {
+		if err := h.changeMembership(name, m, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *handler) changeMembership(group, username string, add bool) error {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("member %q is not a user", username))
+	}
+
+	// a non-nil slice replaces all memberships of the user
+	groups := make([]string, 0, len(user.Groups)+1)
+	for _, g := range user.Groups {
+		if g != group {
+			groups = append(groups, g)
+		}
+	}
+	if add {
+		groups = append(groups, group)
+	}
+	return h.users.Change(&users.User{Groups: groups}, username)
+}
+
+func (h *handler) writeGroup(w http.ResponseWriter, name string, status int) {
+	members, err := h.getGroup(name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if status == http.StatusCreated {
+		w.Header().Set("Location", h.location("Groups", name))
+	}
+	h.writeJSON(w, status, h.toGroup(name, members, true))
+}
+
+func (h *handler) handleDeleteGroup(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["id"]
+	members, err := h.getGroup(name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if h.users.SupportsGroupPermissions() {
+		// deleting the group details removes the memberships as well
+		err = h.users.DeleteGroup(name)
+	} else {
+		err = h.setMembers(name, members, nil)
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,198 @@
+package scim
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const maxRequestBytes = 1024 * 1024
+
+// UserService is the part of the users api service the provisioning is done with
+type UserService interface {
+	GetAll() ([]*users.User, error)
+	GetByUsername(username string) (*users.User, error)
+	Change(*users.User, string) error
+	Delete(string) error
+	ListGroups() ([]users.Group, error)
+	GetGroup(string) (users.Group, error)
+	UpdateGroup(string, users.Group) (users.Group, error)
+	DeleteGroup(string) error
+	SupportsGroupPermissions() bool
+}
+
+// SessionRevoker revokes the api sessions of deactivated and deleted users
+type SessionRevoker interface {
+	DeleteAllByUser(ctx context.Context, username string) error
+}
+
+type handler struct {
+	prefix       string
+	users        UserService
+	sessions     SessionRevoker
+	twoFAByEmail bool
+	logger       *logger.Logger
+}
+
+// NewHandler returns the SCIM service provider mounted at prefix. Requests must authenticate with the given bearer token.
+// If twoFAByEmail is true, the primary email of a user is stored as receiver of the 2fa tokens.
+func NewHandler(prefix, token string, userService UserService, sessions SessionRevoker, twoFAByEmail bool, logger *logger.Logger) http.Handler {
+	h := &handler{
+		prefix:       prefix,
+		users:        userService,
+		sessions:     sessions,
+		twoFAByEmail: twoFAByEmail,
+		logger:       logger,
+	}
+
+	r := mux.NewRouter().PathPrefix(prefix).Subrouter()
+	r.HandleFunc("/ServiceProviderConfig", h.handleGetServiceProviderConfig).Methods(http.MethodGet)
+	r.HandleFunc("/Users", h.handleListUsers).Methods(http.MethodGet)
+	r.HandleFunc("/Users", h.handleCreateUser).Methods(http.MethodPost)
+	r.HandleFunc("/Users/{id}", h.handleGetUser).Methods(http.MethodGet)
+	r.HandleFunc("/Users/{id}", h.handleReplaceUser).Methods(http.MethodPut)
+	r.HandleFunc("/Users/{id}", h.handlePatchUser).Methods(http.MethodPatch)
+	r.HandleFunc("/Users/{id}", h.handleDeleteUser).Methods(http.MethodDelete)
+	r.HandleFunc("/Groups", h.handleListGroups).Methods(http.MethodGet)
+	r.HandleFunc("/Groups", h.handleCreateGroup).Methods(http.MethodPost)
+	r.HandleFunc("/Groups/{id}", h.handleGetGroup).Methods(http.MethodGet)
+	r.HandleFunc("/Groups/{id}", h.handleReplaceGroup).Methods(http.MethodPut)
+	r.HandleFunc("/Groups/{id}", h.handlePatchGroup).Methods(http.MethodPatch)
+	r.HandleFunc("/Groups/{id}", h.handleDeleteGroup).Methods(http.MethodDelete)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.writeError(w, NewError(http.StatusNotFound, "", "resource not found"))
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.writeError(w, NewError(http.StatusMethodNotAllowed, "", "method not allowed"))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reqToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rport scim"`)
+			h.writeError(w, NewError(http.StatusUnauthorized, "", "invalid scim token"))
+			return
+		}
+		if req.Body != nil {
+			req.Body = http.MaxBytesReader(w, req.Body, maxRequestBytes)
+		}
+		r.ServeHTTP(w, req)
+	})
+}
+
+func (h *handler) handleGetServiceProviderConfig(w http.ResponseWriter, req *http.Request) {
+	supported := func(v bool) map[string]bool {
+		return map[string]bool{"supported": v}
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 0},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Authentication with the token configured in 'scim_token'",
+			"primary":     true,
+		}},
+		"meta": Meta{ResourceType: "ServiceProviderConfig", Location: h.prefix + "/ServiceProviderConfig"},
+	})
+}
+
+func (h *handler) location(resource, id string) string {
+	return h.prefix + "/" + resource + "/" + url.PathEscape(id)
+}
+
+// page applies the 1-based startIndex and count query params to the number of total results
+func page(req *http.Request, total int) (start, end int, err error) {
+	start = 1
+	end = total
+	if v := req.URL.Query().Get("startIndex"); v != "" {
+		start, err = strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid startIndex %q", v))
+		}
+		if start < 1 {
+			start = 1
+		}
+	}
+	if v := req.URL.Query().Get("count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid count %q", v))
+		}
+		if count < 0 {
+			count = 0
+		}
+		if start-1+count < end {
+			end = start - 1 + count
+		}
+	}
+	if start-1 > end {
+		return end, end, nil
+	}
+	return start - 1, end, nil
+}
+
+func (h *handler) writeList(w http.ResponseWriter, req *http.Request, total int, resources func(start, end int) interface{}) {
+	start, end, err := page(req, total)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   start + 1,
+		ItemsPerPage: end - start,
+		Resources:    resources(start, end),
+	})
+}
+
+func (h *handler) readJSON(w http.ResponseWriter, req *http.Request, dest interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(dest); err != nil {
+		h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, fmt.Sprintf("invalid request body: %v", err)))
+		return false
+	}
+	return true
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Errorf("failed to write scim response: %v", err)
+	}
+}
+
+func (h *handler) writeError(w http.ResponseWriter, err error) {
+	scimErr := toError(err)
+	status, _ := strconv.Atoi(scimErr.Status)
+	if status >= http.StatusInternalServerError {
+		h.logger.Errorf("scim request failed: %v", err)
+	}
+	h.writeJSON(w, status, scimErr)
+}
+
+// revokeSessions logs out the user from all api sessions, failures are logged only as the user change was already saved
+func (h *handler) revokeSessions(ctx context.Context, username string) {
+	if h.sessions == nil {
+		return
+	}
+	if err := h.sessions.DeleteAllByUser(ctx, username); err != nil {
+		h.logger.Errorf("failed to revoke api sessions of user %q: %v", username, err)
+	}
+}
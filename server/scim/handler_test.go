@@ -0,0 +1,351 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	testPrefix = "/api/v1/scim/v2"
+	testToken  = "0123456789abcdef0123456789abcdef"
+)
+
+var testLog = logger.NewLogger("scim-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+// fakeUserService keeps users and group details in memory like a users database with a group details table
+type fakeUserService struct {
+	users        []*users.User
+	groupDetails map[string]users.Group
+}
+
+func (s *fakeUserService) GetAll() ([]*users.User, error) {
+	return s.users, nil
+}
+
+func (s *fakeUserService) GetByUsername(username string) (*users.User, error) {
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeUserService) Change(u *users.User, username string) error {
+	if username == "" {
+		s.users = append(s.users, u)
+		return nil
+	}
+	existing, _ := s.GetByUsername(username)
+	if existing == nil {
+		return errors2.APIError{HTTPStatus: http.StatusNotFound, Message: fmt.Sprintf("cannot find user by username '%s'", username)}
+	}
+	if u.Username != "" {
+		existing.Username = u.Username
+	}
+	if u.Password != "" {
+		existing.Password = u.Password
+	}
+	if u.TwoFASendTo != "" {
+		existing.TwoFASendTo = u.TwoFASendTo
+	}
+	if u.Groups != nil {
+		existing.Groups = u.Groups
+	}
+	if u.Disabled != nil {
+		existing.Disabled = u.Disabled
+	}
+	return nil
+}
+
+func (s *fakeUserService) Delete(username string) error {
+	for i, u := range s.users {
+		if u.Username == username {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			return nil
+		}
+	}
+	return errors2.APIError{HTTPStatus: http.StatusNotFound, Message: fmt.Sprintf("cannot find user by username '%s'", username)}
+}
+
+func (s *fakeUserService) ListGroups() ([]users.Group, error) {
+	var res []users.Group
+	for _, g := range s.groupDetails {
+		res = append(res, g)
+	}
+	return res, nil
+}
+
+func (s *fakeUserService) GetGroup(name string) (users.Group, error) {
+	return s.groupDetails[name], nil
+}
+
+func (s *fakeUserService) UpdateGroup(name string, g users.Group) (users.Group, error) {
+	s.groupDetails[name] = g
+	return g, nil
+}
+
+func (s *fakeUserService) DeleteGroup(name string) error {
+	delete(s.groupDetails, name)
+	for _, u := range s.users {
+		groups := []string{}
+		for _, g := range u.Groups {
+			if g != name {
+				groups = append(groups, g)
+			}
+		}
+		u.Groups = groups
+	}
+	return nil
+}
+
+func (s *fakeUserService) SupportsGroupPermissions() bool {
+	return s.groupDetails != nil
+}
+
+type fakeSessions struct {
+	revoked []string
+}
+
+func (s *fakeSessions) DeleteAllByUser(_ context.Context, username string) error {
+	s.revoked = append(s.revoked, username)
+	return nil
+}
+
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+func (s testServer) call(method, path string, body interface{}) (int, map[string]interface{}) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		require.NoError(s.t, err)
+	}
+	req := httptest.NewRequest(method, testPrefix+path, bytes.NewReader(reqBody))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+
+	if w.Body.Len() == 0 {
+		return w.Code, nil
+	}
+	assert.Equal(s.t, ContentType, w.Header().Get("Content-Type"))
+	res := map[string]interface{}{}
+	require.NoError(s.t, json.Unmarshal(w.Body.Bytes(), &res), w.Body.String())
+	return w.Code, res
+}
+
+func newTestServer(t *testing.T, userService UserService, sessions SessionRevoker) testServer {
+	return testServer{
+		t:       t,
+		handler: NewHandler(testPrefix, testToken, userService, sessions, true, testLog),
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	handler := NewHandler(testPrefix, testToken, &fakeUserService{}, &fakeSessions{}, false, testLog)
+
+	for _, auth := range []string{"", "Bearer wrong", "Basic " + testToken} {
+		req := httptest.NewRequest(http.MethodGet, testPrefix+"/Users", nil)
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, auth)
+		assert.JSONEq(t, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401","detail":"invalid scim token"}`, w.Body.String())
+	}
+}
+
+func TestUsers(t *testing.T) {
+	userService := &fakeUserService{users: []*users.User{
+		{Username: "admin", Groups: []string{users.Administrators}},
+	}}
+	sessions := &fakeSessions{}
+	s := newTestServer(t, userService, sessions)
+
+	status, resp := s.call(http.MethodPost, "/Users", map[string]interface{}{
+		"schemas":  []string{SchemaUser},
+		"userName": "john",
+		"active":   true,
+		"emails":   []map[string]interface{}{{"value": "other@example.com"}, {"value": "john@example.com", "primary": true}},
+	})
+	require.Equal(t, http.StatusCreated, status, resp)
+	assert.Equal(t, "john", resp["id"])
+	assert.Equal(t, true, resp["active"])
+	john, _ := userService.GetByUsername("john")
+	require.NotNil(t, john)
+	assert.Equal(t, "john@example.com", john.TwoFASendTo)
+	assert.Len(t, john.Password, generatedPasswordLength, "a random password is set")
+
+	status, resp = s.call(http.MethodPost, "/Users", map[string]interface{}{"userName": "john"})
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, ErrTypeUniqueness, resp["scimType"])
+
+	status, resp = s.call(http.MethodGet, `/Users?filter=userName+eq+"JOHN"`, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 1, resp["totalResults"])
+
+	status, resp = s.call(http.MethodGet, `/Users?filter=userName+co+"jo"`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ErrTypeInvalidFilter, resp["scimType"])
+
+	status, resp = s.call(http.MethodGet, "/Users?startIndex=2&count=5", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 2, resp["totalResults"])
+	assert.EqualValues(t, 2, resp["startIndex"])
+	assert.EqualValues(t, 1, resp["itemsPerPage"])
+	assert.Equal(t, "john", resp["Resources"].([]interface{})[0].(map[string]interface{})["userName"])
+
+	t.Run("deactivate", func(t *testing.T) {
+		// azure sends booleans as string
+		status, resp := s.call(http.MethodPatch, "/Users/john", map[string]interface{}{
+			"schemas":    []string{SchemaPatchOp},
+			"Operations": []map[string]interface{}{{"op": "Replace", "path": "active", "value": "False"}},
+		})
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, false, resp["active"])
+		assert.True(t, john.IsDisabled())
+		assert.Equal(t, []string{"john"}, sessions.revoked)
+
+		status, resp = s.call(http.MethodPatch, "/Users/john", map[string]interface{}{
+			"schemas":    []string{SchemaPatchOp},
+			"Operations": []map[string]interface{}{{"op": "replace", "value": map[string]interface{}{"active": true}}},
+		})
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.False(t, john.IsDisabled())
+		assert.Equal(t, []string{"john"}, sessions.revoked)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		status, resp := s.call(http.MethodPut, "/Users/john", map[string]interface{}{
+			"schemas":  []string{SchemaUser},
+			"userName": "john.doe",
+			"active":   false,
+		})
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, "john.doe", resp["id"])
+		assert.Equal(t, "john.doe", john.Username)
+		assert.True(t, john.IsDisabled())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		sessions.revoked = nil
+		status, _ := s.call(http.MethodDelete, "/Users/john.doe", nil)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, []string{"john.doe"}, sessions.revoked)
+
+		status, resp := s.call(http.MethodGet, "/Users/john.doe", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "404", resp["status"])
+	})
+}
+
+func TestGroups(t *testing.T) {
+	alice := &users.User{Username: "alice", Groups: []string{"devs"}}
+	bob := &users.User{Username: "bob", Groups: []string{users.Administrators}}
+	userService := &fakeUserService{
+		users:        []*users.User{alice, bob},
+		groupDetails: map[string]users.Group{users.Administrators: users.AdministratorsGroup},
+	}
+	s := newTestServer(t, userService, &fakeSessions{})
+
+	status, resp := s.call(http.MethodGet, "/Groups?excludedAttributes=members", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 2, resp["totalResults"])
+	assert.NotContains(t, resp["Resources"].([]interface{})[0], "members")
+
+	status, resp = s.call(http.MethodPost, "/Groups", map[string]interface{}{
+		"schemas":     []string{SchemaGroup},
+		"displayName": "ops",
+		"members":     []map[string]string{{"value": "alice"}},
+	})
+	require.Equal(t, http.StatusCreated, status, resp)
+	assert.Equal(t, "ops", resp["id"])
+	assert.Contains(t, userService.groupDetails, "ops")
+	assert.Equal(t, []string{"devs", "ops"}, alice.Groups)
+
+	status, resp = s.call(http.MethodPost, "/Groups", map[string]interface{}{
+		"displayName": "ops",
+	})
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, ErrTypeUniqueness, resp["scimType"])
+
+	status, resp = s.call(http.MethodPatch, "/Groups/ops", map[string]interface{}{
+		"schemas": []string{SchemaPatchOp},
+		"Operations": []map[string]interface{}{
+			{"op": "add", "path": "members", "value": []map[string]string{{"value": "bob"}}},
+			{"op": "remove", "path": `members[value eq "alice"]`},
+		},
+	})
+	require.Equal(t, http.StatusOK, status, resp)
+	assert.Equal(t, []string{"devs"}, alice.Groups)
+	assert.Equal(t, []string{users.Administrators, "ops"}, bob.Groups)
+
+	status, resp = s.call(http.MethodPatch, "/Groups/ops", map[string]interface{}{
+		"schemas":    []string{SchemaPatchOp},
+		"Operations": []map[string]interface{}{{"op": "add", "path": "members", "value": []map[string]string{{"value": "unknown"}}}},
+	})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, ErrTypeInvalidValue, resp["scimType"])
+
+	t.Run("rename", func(t *testing.T) {
+		status, resp := s.call(http.MethodPatch, "/Groups/ops", map[string]interface{}{
+			"schemas":    []string{SchemaPatchOp},
+			"Operations": []map[string]interface{}{{"op": "replace", "value": map[string]interface{}{"displayName": "operations"}}},
+		})
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, "operations", resp["id"])
+		assert.Equal(t, []string{users.Administrators, "operations"}, bob.Groups)
+		assert.Contains(t, userService.groupDetails, "operations")
+		assert.NotContains(t, userService.groupDetails, "ops")
+	})
+
+	t.Run("replace members", func(t *testing.T) {
+		status, resp := s.call(http.MethodPut, "/Groups/devs", map[string]interface{}{
+			"schemas":     []string{SchemaGroup},
+			"displayName": "devs",
+			"members":     []map[string]string{{"value": "bob"}},
+		})
+		require.Equal(t, http.StatusOK, status, resp)
+		assert.Equal(t, []string{}, alice.Groups)
+		assert.Equal(t, []string{users.Administrators, "operations", "devs"}, bob.Groups)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		status, _ := s.call(http.MethodDelete, "/Groups/operations", nil)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, []string{users.Administrators, "devs"}, bob.Groups)
+
+		status, _ = s.call(http.MethodGet, "/Groups/operations", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestGroupsWithoutGroupDetails(t *testing.T) {
+	alice := &users.User{Username: "alice", Groups: []string{"devs", "ops"}}
+	s := newTestServer(t, &fakeUserService{users: []*users.User{alice}}, &fakeSessions{})
+
+	status, resp := s.call(http.MethodGet, `/Groups?filter=displayName+eq+"devs"`, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 1, resp["totalResults"])
+
+	status, _ = s.call(http.MethodDelete, "/Groups/devs", nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Equal(t, []string{"ops"}, alice.Groups)
+}
@@ -0,0 +1,169 @@
+// Package scim implements a SCIM 2.0 (RFC 7643, RFC 7644) service provider for API users and groups,
+// so identity providers can provision and deprovision users automatically.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ContentType = "application/scim+json"
+
+	ResourceTypeUser  = "User"
+	ResourceTypeGroup = "Group"
+)
+
+// scimType values of errors, see RFC 7644 section 3.12
+const (
+	ErrTypeInvalidFilter = "invalidFilter"
+	ErrTypeUniqueness    = "uniqueness"
+	ErrTypeInvalidSyntax = "invalidSyntax"
+	ErrTypeInvalidPath   = "invalidPath"
+	ErrTypeInvalidValue  = "invalidValue"
+	ErrTypeNoTarget      = "noTarget"
+)
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+// MultiValue is an item of a multi-valued attribute like emails, groups or members
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type User struct {
+	Schemas    []string     `json:"schemas"`
+	ID         string       `json:"id,omitempty"`
+	ExternalID string       `json:"externalId,omitempty"`
+	UserName   string       `json:"userName"`
+	Active     *bool        `json:"active,omitempty"`
+	Password   string       `json:"password,omitempty"`
+	Emails     []MultiValue `json:"emails,omitempty"`
+	Groups     []MultiValue `json:"groups,omitempty"`
+	Meta       *Meta        `json:"meta,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []MultiValue `json:"members,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func (e Error) Error() string {
+	return e.Detail
+}
+
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// toError converts errors of the user service to SCIM errors keeping the http status
+func toError(err error) Error {
+	var scimErr Error
+	if errors.As(err, &scimErr) {
+		return scimErr
+	}
+
+	var apiErr errors2.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatus != 0 {
+		return NewError(apiErr.HTTPStatus, "", apiErrorDetail(apiErr))
+	}
+
+	var apiErrs errors2.APIErrors
+	if errors.As(err, &apiErrs) && len(apiErrs) > 0 {
+		details := make([]string, 0, len(apiErrs))
+		for _, e := range apiErrs {
+			details = append(details, apiErrorDetail(e))
+		}
+		return NewError(apiErrs[0].HTTPStatus, "", strings.Join(details, ", "))
+	}
+
+	return NewError(http.StatusInternalServerError, "", err.Error())
+}
+
+func apiErrorDetail(err errors2.APIError) string {
+	if err.Message != "" && err.Err != nil {
+		return fmt.Sprintf("%s: %v", err.Message, err.Err)
+	}
+	return err.Error()
+}
+
+// parseEqFilter parses the only filter supported, an equality check of a single attribute like 'userName eq "john"'
+func parseEqFilter(filter string) (attr, value string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(filter), " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return "", "", NewError(http.StatusBadRequest, ErrTypeInvalidFilter, fmt.Sprintf("unsupported filter %q, only 'eq' is supported", filter))
+	}
+
+	value, err = strconv.Unquote(parts[2])
+	if err != nil {
+		return "", "", NewError(http.StatusBadRequest, ErrTypeInvalidFilter, fmt.Sprintf("invalid filter value %s", parts[2]))
+	}
+	return parts[0], value, nil
+}
+
+// parseBool parses a boolean that some identity providers send as string
+func parseBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
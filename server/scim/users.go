@@ -0,0 +1,325 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/share/security"
+)
+
+const generatedPasswordLength = 32
+
+// userChanges holds the attributes of a user set by a PUT or PATCH request, nil means unchanged
+type userChanges struct {
+	userName *string
+	password string
+	active   *bool
+	email    *string
+}
+
+func (h *handler) toUser(u *users.User) User {
+	active := !u.IsDisabled()
+	res := User{
+		Schemas:  []string{SchemaUser},
+		ID:       u.Username,
+		UserName: u.Username,
+		Active:   &active,
+		Meta:     &Meta{ResourceType: ResourceTypeUser, Location: h.location("Users", u.Username)},
+	}
+	if h.twoFAByEmail && u.TwoFASendTo != "" {
+		res.Emails = []MultiValue{{Value: u.TwoFASendTo, Type: "work", Primary: true}}
+	}
+	for _, g := range u.Groups {
+		res.Groups = append(res.Groups, MultiValue{Value: g, Display: g, Ref: h.location("Groups", g)})
+	}
+	return res
+}
+
+// primaryEmail returns the email marked as primary or the first one
+func primaryEmail(emails []MultiValue) string {
+	for _, e := range emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+func (h *handler) getUser(username string) (*users.User, error) {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, NewError(http.StatusNotFound, "", fmt.Sprintf("user %q not found", username))
+	}
+	return user, nil
+}
+
+func (h *handler) handleListUsers(w http.ResponseWriter, req *http.Request) {
+	all, err := h.users.GetAll()
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if filter := req.URL.Query().Get("filter"); filter != "" {
+		attr, value, err := parseEqFilter(filter)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		if !strings.EqualFold(attr, "userName") && !strings.EqualFold(attr, "id") {
+			h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidFilter, fmt.Sprintf("filtering by %q is not supported", attr)))
+			return
+		}
+		var matching []*users.User
+		for _, u := range all {
+			// userName is case insensitive by the SCIM spec
+			if strings.EqualFold(u.Username, value) {
+				matching = append(matching, u)
+			}
+		}
+		all = matching
+	}
+
+	h.writeList(w, req, len(all), func(start, end int) interface{} {
+		res := make([]User, 0, end-start)
+		for _, u := range all[start:end] {
+			res = append(res, h.toUser(u))
+		}
+		return res
+	})
+}
+
+func (h *handler) handleGetUser(w http.ResponseWriter, req *http.Request) {
+	user, err := h.getUser(mux.Vars(req)["id"])
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.toUser(user))
+}
+
+func (h *handler) handleCreateUser(w http.ResponseWriter, req *http.Request) {
+	var in User
+	if !h.readJSON(w, req, &in) {
+		return
+	}
+	if in.UserName == "" {
+		h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "userName is required"))
+		return
+	}
+
+	existing, err := h.users.GetByUsername(in.UserName)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if existing != nil {
+		h.writeError(w, NewError(http.StatusConflict, ErrTypeUniqueness, fmt.Sprintf("user %q already exists", in.UserName)))
+		return
+	}
+
+	password := in.Password
+	if password == "" {
+		// users provisioned without password log in with another method, e.g. passkeys or a password reset by an admin
+		password, err = security.NewRandomToken(generatedPasswordLength)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	user := &users.User{
+		Username: in.UserName,
+		Password: password,
+	}
+	if h.twoFAByEmail {
+		user.TwoFASendTo = primaryEmail(in.Emails)
+	}
+	if in.Active != nil && !*in.Active {
+		disabled := true
+		user.Disabled = &disabled
+	}
+
+	if err := h.users.Change(user, ""); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	created, err := h.getUser(in.UserName)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", h.location("Users", created.Username))
+	h.writeJSON(w, http.StatusCreated, h.toUser(created))
+}
+
+func (h *handler) handleReplaceUser(w http.ResponseWriter, req *http.Request) {
+	user, err := h.getUser(mux.Vars(req)["id"])
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var in User
+	if !h.readJSON(w, req, &in) {
+		return
+	}
+
+	changes := userChanges{password: in.Password, active: in.Active}
+	if in.UserName != "" {
+		changes.userName = &in.UserName
+	}
+	if len(in.Emails) > 0 {
+		email := primaryEmail(in.Emails)
+		changes.email = &email
+	}
+	h.updateUser(w, req, user, changes)
+}
+
+func (h *handler) handlePatchUser(w http.ResponseWriter, req *http.Request) {
+	user, err := h.getUser(mux.Vars(req)["id"])
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var patch PatchRequest
+	if !h.readJSON(w, req, &patch) {
+		return
+	}
+
+	changes := userChanges{}
+	for _, op := range patch.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		case "remove":
+			// none of the supported attributes can be removed
+			continue
+		default:
+			h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidSyntax, fmt.Sprintf("unsupported patch operation %q", op.Op)))
+			return
+		}
+
+		values := map[string]json.RawMessage{}
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				h.writeError(w, NewError(http.StatusBadRequest, ErrTypeInvalidValue, "patch value without path must be an object"))
+				return
+			}
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			if err := changes.apply(path, value); err != nil {
+				h.writeError(w, err)
+				return
+			}
+		}
+	}
+	h.updateUser(w, req, user, changes)
+}
+
+// apply sets the attribute at path, attributes not stored by rport are ignored
+func (c *userChanges) apply(path string, value json.RawMessage) error {
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of active: %s", value))
+		}
+		c.active = &active
+	case attr == "username":
+		var userName string
+		if err := json.Unmarshal(value, &userName); err != nil || userName == "" {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of userName: %s", value))
+		}
+		c.userName = &userName
+	case attr == "password":
+		if err := json.Unmarshal(value, &c.password); err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value of password")
+		}
+	case attr == "emails":
+		var emails []MultiValue
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of emails: %s", value))
+		}
+		email := primaryEmail(emails)
+		c.email = &email
+	case strings.HasPrefix(attr, "emails["):
+		// a filtered path like emails[type eq "work"].value
+		var email string
+		if err := json.Unmarshal(value, &email); err != nil {
+			return NewError(http.StatusBadRequest, ErrTypeInvalidValue, fmt.Sprintf("invalid value of %s: %s", path, value))
+		}
+		c.email = &email
+	}
+	return nil
+}
+
+func (h *handler) updateUser(w http.ResponseWriter, req *http.Request, user *users.User, changes userChanges) {
+	changed := false
+	update := &users.User{}
+	if changes.userName != nil && *changes.userName != user.Username {
+		update.Username = *changes.userName
+		changed = true
+	}
+	if changes.password != "" {
+		update.Password = changes.password
+		changed = true
+	}
+	if h.twoFAByEmail && changes.email != nil && *changes.email != "" && *changes.email != user.TwoFASendTo {
+		update.TwoFASendTo = *changes.email
+		changed = true
+	}
+	deactivated := false
+	if changes.active != nil && *changes.active == user.IsDisabled() {
+		disabled := !*changes.active
+		update.Disabled = &disabled
+		deactivated = disabled
+		changed = true
+	}
+
+	if changed {
+		if err := h.users.Change(update, user.Username); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+	if deactivated {
+		h.revokeSessions(req.Context(), user.Username)
+	}
+
+	username := user.Username
+	if update.Username != "" {
+		username = update.Username
+	}
+	updated, err := h.getUser(username)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.toUser(updated))
+}
+
+func (h *handler) handleDeleteUser(w http.ResponseWriter, req *http.Request) {
+	username := mux.Vars(req)["id"]
+	if err := h.users.Delete(username); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.revokeSessions(req.Context(), username)
+	w.WriteHeader(http.StatusNoContent)
+}
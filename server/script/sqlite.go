@@ -42,7 +42,7 @@ func (p *SqliteProvider) GetByID(ctx context.Context, id string, ro *query.Retri
 	q = p.converter.ConvertRetrieveOptionsToQuery(ro, q)
 
 	val = new(Script)
-	err = p.db.GetContext(ctx, val, q, id)
+	err = p.db.GetContext(ctx, val, p.converter.Rebind(q), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return val, false, nil
@@ -61,7 +61,7 @@ func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]Scr
 
 	q, params := p.converter.ConvertListOptionsToQuery(lo, q)
 
-	err := p.db.SelectContext(ctx, &values, q, params...)
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
 	if err != nil {
 		return values, err
 	}
@@ -79,10 +79,10 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Script, nowDate time.Time)
 
 		_, err = p.db.NamedExecContext(
 			ctx,
-			"INSERT INTO `scripts`"+
+			p.converter.Rebind("INSERT INTO `scripts`"+
 				" (`id`, `name`, `created_at`, `created_by`, `interpreter`, `is_sudo`, `cwd`, `script`, `updated_at`, `updated_by`, `tags`, `timeout_sec`)"+
 				" VALUES "+
-				"(:id, :name, :created_at, :created_by, :interpreter, :is_sudo, :cwd, :script, :updated_at, :updated_by, :tags, :timeout_sec)",
+				"(:id, :name, :created_at, :created_by, :interpreter, :is_sudo, :cwd, :script, :updated_at, :updated_by, :tags, :timeout_sec)"),
 			s,
 		)
 
@@ -101,13 +101,13 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Script, nowDate time.Time)
 		"`timeout_sec` = :timeout_sec" +
 		" WHERE id = :id "
 
-	_, err := p.db.NamedExecContext(ctx, q, s)
+	_, err := p.db.NamedExecContext(ctx, p.converter.Rebind(q), s)
 
 	return s.ID, err
 }
 
 func (p *SqliteProvider) Delete(ctx context.Context, id string) error {
-	res, err := p.db.ExecContext(ctx, "DELETE FROM `scripts` WHERE `id` = ?", id)
+	res, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM `scripts` WHERE `id` = ?"), id)
 
 	if err != nil {
 		return err
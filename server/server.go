@@ -16,35 +16,48 @@ import (
 
 	// sql drivers
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/patrickmn/go-cache"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/client_groups"
 	clientsmigration "github.com/openrport/openrport/db/migration/clients"
 	jobsmigration "github.com/openrport/openrport/db/migration/jobs"
-	"github.com/openrport/openrport/db/sqlite"
+	monitoringmigration "github.com/openrport/openrport/db/migration/monitoring"
 	rportplus "github.com/openrport/openrport/plus"
 	alertingcap "github.com/openrport/openrport/plus/capabilities/alerting"
 	"github.com/openrport/openrport/server/acme"
 	"github.com/openrport/openrport/server/api/jobs"
 	"github.com/openrport/openrport/server/api/jobs/schedule"
 	"github.com/openrport/openrport/server/api/session"
+	"github.com/openrport/openrport/server/approvals"
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/caddy"
 	"github.com/openrport/openrport/server/cgroups"
 	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clientcert"
 	"github.com/openrport/openrport/server/clients"
 	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/server/clientsauth/rotation"
+	"github.com/openrport/openrport/server/downloads"
+	"github.com/openrport/openrport/server/enrollment"
+	"github.com/openrport/openrport/server/ha"
+	"github.com/openrport/openrport/server/joboutput"
+	"github.com/openrport/openrport/server/metrics"
 	"github.com/openrport/openrport/server/monitoring"
 	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/server/recording"
+	"github.com/openrport/openrport/server/roles"
 	"github.com/openrport/openrport/server/scheduler"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/capabilities"
 	"github.com/openrport/openrport/share/files"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/security"
 	"github.com/openrport/openrport/share/ws"
 )
 
@@ -52,6 +65,9 @@ const (
 	cleanupMeasurementsInterval = time.Minute * 2
 	cleanupAPISessionsInterval  = time.Hour
 	cleanupJobsInterval         = time.Hour
+	syncSchedulesInterval       = time.Minute
+	rotateClientAuthsInterval   = time.Minute
+	startStoredTunnelsInterval  = time.Minute
 	LogNumGoRoutinesInterval    = time.Minute * 2
 
 	DefaultMaxClientDBConnections = 50
@@ -67,9 +83,14 @@ type Server struct {
 	clientListener      *ClientListener
 	apiListener         *APIListener
 	config              *chconfig.Config
+	db                  *backend.Backend
+	ha                  *ha.Node
 	clientService       clients.ClientService
 	clientDB            *sqlx.DB
 	clientAuthProvider  clientsauth.Provider
+	clientCertAuthority *clientcert.Authority
+	enrollmentManager   *enrollment.Manager
+	clientAuthRotation  *rotation.Manager
 	jobProvider         JobProvider
 	clientGroupProvider cgroups.ClientGroupProvider
 	monitoringService   monitoring.Service
@@ -80,12 +101,20 @@ type Server struct {
 	auditLog            *auditlog.AuditLog
 	capabilities        *models.Capabilities
 	scheduleManager     *schedule.Manager
+	approvalManager     *approvals.Manager
+	roleManager         *roles.Manager
 	filesAPI            files.FileAPI
 	plusManager         rportplus.Manager
 	caddyServer         *caddy.Server
 	acme                *acme.Acme
 	alertingService     alertingcap.Service
+	alerting            *ha.Toggle
 	monitoringQueue     monitoring.MeasurementSaver
+	recordings          *recording.Manager
+	jobOutput           *joboutput.Manager
+	downloads           *downloads.Manager
+	metrics             *metrics.Collector
+	metricsServer       *chshare.HTTPServer
 }
 
 type ServerOpts struct {
@@ -159,7 +188,23 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		s.Errorf("Failed to store fingerprint %q in file %q: %v", fingerprint, fingerprintFile, err)
 	}
 
-	jobsDB, err := sqlite.New(
+	s.db = backend.NewSQLite()
+	if config.Database.IsPostgres() {
+		s.db = backend.NewPostgres(config.Database.Dsn)
+		s.Infof("DB: all stores are kept in %s", config.Database.DsnForLogs())
+	}
+
+	if config.HA.Enabled {
+		haDB, err := s.db.Open("ha", "", nil, nil, config.Server.GetSQLiteDataSourceOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ha DB instance: %v", err)
+		}
+		s.ha = ha.NewNode(haDB, config.HA.NodeID, config.HA.APIURL, config.API.JWTSecret, config.HA.LeaseDuration, s.Logger.Fork("ha"))
+		s.Infof("HA: running as node %s, reachable by the other nodes on %s", config.HA.NodeID, config.HA.APIURL)
+	}
+
+	jobsDB, err := s.db.Open(
+		"jobs",
 		path.Join(config.Server.DataDir, "jobs.db"),
 		jobsmigration.AssetNames(),
 		jobsmigration.Asset,
@@ -171,7 +216,8 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 
 	s.jobProvider = jobs.NewSqliteProvider(jobsDB, s.Logger)
 
-	groupsDB, err := sqlite.New(
+	groupsDB, err := s.db.Open(
+		"client_groups",
 		path.Join(config.Server.DataDir, "client_groups.db"),
 		client_groups.AssetNames(),
 		client_groups.Asset,
@@ -185,15 +231,19 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	s.roleManager = roles.NewManager(roles.NewSQLiteProvider(groupsDB))
 
-	monitoringProvider, err := monitoring.NewSqliteProvider(
+	monitoringDB, err := s.db.Open(
+		"monitoring",
 		path.Join(config.Server.DataDir, "monitoring.db"),
+		monitoringmigration.AssetNames(),
+		monitoringmigration.Asset,
 		config.Server.GetSQLiteDataSourceOptions(),
-		s.Logger,
 	)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create monitoring DB instance: %v", err)
 	}
+	monitoringProvider := monitoring.NewDBProvider(monitoringDB, s.Logger)
 
 	// even if monitoring disabled, always create the monitoring service to support queries of past data etc
 	s.monitoringService = monitoring.NewService(monitoringProvider, s.Logger.Fork("monitoring"))
@@ -207,7 +257,8 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	// concurrent thread access.
 	sourceOptions.MaxOpenConnections = DefaultMaxClientDBConnections
 
-	s.clientDB, err = sqlite.New(
+	s.clientDB, err = s.db.Open(
+		"clients",
 		path.Join(config.Server.DataDir, "clients.db"),
 		clientsmigration.AssetNames(),
 		clientsmigration.Asset,
@@ -223,18 +274,50 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		keepDisconnectedClients = &config.Server.KeepDisconnectedClients
 	}
 
+	// clients connected to the other nodes of a HA cluster stay connected
+	var clientNodes clients.ClientNodes
+	if s.ha != nil {
+		clientNodes = s.ha
+	}
+
 	s.clientService, err = clients.InitClientService(
 		ctx,
 		&s.config.Server.InternalTunnelProxyConfig,
 		ports.NewPortDistributor(config.AllowedPorts()),
 		s.clientDB,
 		keepDisconnectedClients,
+		clientNodes,
 		s.Logger,
 		s.acme,
 	)
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.SetReverseTunnelAllowed(s.config.Server.IsReverseTunnelAllowed)
+	s.clientService.SetClientGroupProvider(s.clientGroupProvider)
+
+	if config.Recordings.Enabled {
+		s.recordings, err = recording.NewManager(recording.Options{
+			Dir:       config.Recordings.Dir,
+			MaxSize:   config.Recordings.MaxRecordingSize,
+			RecordAll: config.Recordings.RecordAll,
+		}, s.Logger.Fork("recordings"))
+		if err != nil {
+			return nil, err
+		}
+		s.clientService.SetRecordingManager(s.recordings)
+		s.Infof("Recordings enabled, stored in %q", config.Recordings.Dir)
+	}
+
+	s.jobOutput, err = joboutput.NewManager(config.JobOutput.Dir, config.JobOutput.MaxSize, s.Logger.Fork("job-output"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.downloads, err = downloads.NewManager(config.GetDownloadDir(), s.Logger.Fork("downloads"))
+	if err != nil {
+		return nil, err
+	}
 
 	if rportplus.IsPlusEnabled(config.PlusConfig) {
 		licCapEx := s.plusManager.GetLicenseCapabilityEx()
@@ -248,10 +331,12 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		s.config.Server.DataDir,
 		s.config.API.AuditLog,
 		s.config.Server.GetSQLiteDataSourceOptions(),
+		s.db,
 	)
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.SetAuditLog(s.auditLog)
 
 	if config.Database.Driver != "" {
 		s.authDB, err = sqlx.Connect(config.Database.Driver, config.Database.Dsn)
@@ -266,6 +351,32 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		return nil, err
 	}
 
+	clientCertConfig := clientcert.Config{
+		CAKeyFile:          config.Server.ClientCertCAKey,
+		TrustedCAFiles:     config.Server.ClientCertTrustedCAs,
+		Validity:           config.Server.ClientCertValidity,
+		RevocationListFile: config.Server.ClientCertRevocationList,
+	}
+	if clientCertConfig.Enabled() {
+		s.clientCertAuthority, err = clientcert.NewAuthority(clientCertConfig, s.Logger.Fork("client-cert"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.enrollmentManager = enrollment.NewManager(
+		enrollment.NewSQLiteProvider(s.clientDB),
+		s.clientAuthProvider,
+	)
+
+	s.clientAuthRotation = rotation.NewManager(
+		rotation.NewSQLiteProvider(s.clientDB),
+		s.clientAuthProvider,
+		s.clientService,
+		config.Server.ClientAuthRotationGracePeriod,
+		s.Logger.Fork("client-auth-rotation"),
+	)
+
 	s.clientListener, err = NewClientListener(s, privateKey)
 	if err != nil {
 		return nil, err
@@ -277,6 +388,18 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.SetStoredTunnels(s.apiListener.storedTunnels)
+	s.clientService.SetVaultResolver(s.apiListener.resolveVaultPlaceholders)
+
+	if config.Metrics.Enabled {
+		metricsLog := logger.NewLogger("metrics", config.Logging.LogOutput, config.Logging.LogLevel)
+		s.metrics = metrics.NewCollector(s.clientService, s.jobProvider, s.apiListener.notificationsStorage, s.clientListener, metricsLog)
+		var opts []chshare.ServerOption
+		if config.Metrics.CertFile != "" {
+			opts = append(opts, chshare.WithTLS(config.Metrics.CertFile, config.Metrics.KeyFile, security.TLSConfig(config.API.TLSMin)))
+		}
+		s.metricsServer = chshare.NewHTTPServer(int(config.API.MaxRequestBytes), metricsLog, opts...)
+	}
 
 	s.capabilities = capabilities.NewServerCapabilities(&config.Monitoring)
 
@@ -284,6 +407,17 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	if s.ha != nil {
+		s.scheduleManager.SetLeaderCheck(s.ha.IsLeader)
+	}
+
+	s.approvalManager = approvals.NewManager(
+		approvals.NewSQLiteProvider(jobsDB),
+		notifications.NewDispatcher(s.apiListener.notificationsStorage),
+		config.Approvals.NotificationTarget,
+		config.Approvals.NotificationRecipients,
+		s.Logger.Fork("approvals"),
+	)
 
 	if s.config.CaddyEnabled() {
 		cfg := s.config
@@ -302,7 +436,20 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 
 	if s.alertingService != nil {
 		dispatcher := notifications.NewDispatcher(s.apiListener.notificationsStorage)
-		s.alertingService.Run(ctx, config.Notifications.NotificationScriptDir, dispatcher, maxAlertingWorkers)
+		s.alerting = ha.NewToggle(func() {
+			s.alertingService.Run(ctx, config.Notifications.NotificationScriptDir, dispatcher, maxAlertingWorkers)
+		}, s.alertingService.Stop)
+		if s.ha == nil {
+			s.alerting.Start()
+		} else {
+			// alerts must be raised only once, so only the leader runs the alerting service
+			s.ha.OnElected(s.alerting.Start)
+			s.ha.OnDemoted(func() {
+				if err := s.alerting.Stop(); err != nil {
+					s.Errorf("failed to stop alerting service: %v", err)
+				}
+			})
+		}
 	}
 	return s, nil
 }
@@ -379,10 +526,23 @@ func (s *Server) Run(ctx context.Context) error {
 
 	s.acme.Start()
 
+	if s.ha != nil {
+		go s.ha.Run(ctx)
+		go scheduler.Run(ctx, s.Logger.Fork("task schedules sync"), schedule.NewSyncTask(s.scheduleManager), syncSchedulesInterval)
+		go scheduler.Run(ctx, s.Logger.Fork("task clients sync"), clients.NewSyncTask(s.clientService.GetRepo()), s.config.HA.LeaseDuration)
+		s.Infof("HA: tasks working on the shared DB will run on the leader only")
+	}
+
+	if s.clientAuthProvider.IsWriteable() && s.config.Server.AuthWrite {
+		// runs on all HA nodes, each node rotates the clients connected to it
+		rotationTask := rotation.NewTask(s.clientAuthRotation, s.auditLog, s.Logger.Fork("client-auth-rotation"))
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", rotationTask)), rotationTask, rotateClientAuthsInterval)
+	}
+
 	// TODO(m-terel): add graceful shutdown of background task
 	if s.config.Server.PurgeDisconnectedClients {
 		s.Infof("Period to keep disconnected clients is set to %v", s.config.Server.KeepDisconnectedClients)
-		go scheduler.Run(ctx, s.Logger, clients.NewCleanupTask(s.Logger, s.clientListener.server.clientService.GetRepo()), s.config.Server.PurgeDisconnectedClientsInterval)
+		go scheduler.Run(ctx, s.Logger, ha.LeaderOnly(s.ha, clients.NewCleanupTask(s.Logger, s.clientListener.server.clientService.GetRepo())), s.config.Server.PurgeDisconnectedClientsInterval)
 		s.Infof("Task to purge disconnected clients will run with interval %v", s.config.Server.PurgeDisconnectedClientsInterval)
 	} else {
 		s.Debugf("Task to purge disconnected clients disabled")
@@ -409,20 +569,31 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 
 		monitoringCleanupTask := monitoring.NewCleanupTask(s.Logger, s.monitoringService, cleaningPeriod)
-		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", monitoringCleanupTask)), monitoringCleanupTask, cleanupMeasurementsInterval)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", monitoringCleanupTask)), ha.LeaderOnly(s.ha, monitoringCleanupTask), cleanupMeasurementsInterval)
 		s.Infof("Task to cleanup measurements will run with interval %v", cleanupMeasurementsInterval)
 	} else {
 		s.Infof("Measurement disabled")
 	}
 
+	// every node starts the stored tunnels of its own clients, so it runs in HA mode on all nodes
+	storedTunnelsTask := clients.NewStoredTunnelsTask(s.clientService)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", storedTunnelsTask)), storedTunnelsTask, startStoredTunnelsInterval)
+	s.Infof("Task to start always-on and scheduled stored tunnels will run with interval %v", startStoredTunnelsInterval)
+
 	sessionsCleanupTask := session.NewCleanupTask(s.apiListener.apiSessions)
-	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", sessionsCleanupTask)), sessionsCleanupTask, cleanupAPISessionsInterval)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", sessionsCleanupTask)), ha.LeaderOnly(s.ha, sessionsCleanupTask), cleanupAPISessionsInterval)
 	s.Infof("Task to cleanup expired api sessions will run with interval %v", cleanupAPISessionsInterval)
 
-	jobsCleanupTask := jobs.NewCleanupTask(s.jobProvider, s.config.Server.JobsMaxResults)
-	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", jobsCleanupTask)), jobsCleanupTask, cleanupJobsInterval)
+	jobsCleanupTask := jobs.NewCleanupTask(s.jobProvider, s.config.Server.JobsMaxResults, s.jobOutput, s.config.JobOutput.StorageDuration)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", jobsCleanupTask)), ha.LeaderOnly(s.ha, jobsCleanupTask), cleanupJobsInterval)
 	s.Infof("Task to cleanup jobs will run with interval %v", cleanupJobsInterval)
 
+	if s.recordings != nil {
+		recordingsCleanupTask := recording.NewCleanupTask(s.recordings, s.config.Recordings.StorageDuration)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", recordingsCleanupTask)), recordingsCleanupTask, s.config.Recordings.CleanupInterval)
+		s.Infof("Task to cleanup recordings older than %v will run with interval %v", s.config.Recordings.StorageDuration, s.config.Recordings.CleanupInterval)
+	}
+
 	// Only on debug mode, log the number of running go routines
 	if s.config.Logging.LogLevel == logger.LogLevelDebug {
 		go func() {
@@ -460,6 +631,14 @@ func (s *Server) Start(ctx context.Context) error {
 		err = s.apiListener.Start(ctx, s.config.API.Address)
 	}
 
+	if s.metricsServer != nil {
+		s.Logger.Infof("will serve metrics on %s/metrics", s.config.Metrics.Address)
+		user, pass := s.config.Metrics.GetAuth()
+		if err := s.metricsServer.GoListenAndServe(ctx, s.config.Metrics.Address, metrics.NewHandler(s.metrics, user, pass)); err != nil {
+			return err
+		}
+	}
+
 	if s.config.CaddyEnabled() {
 		err = s.caddyServer.Start(ctx)
 	}
@@ -471,6 +650,9 @@ func (s *Server) Wait() error {
 	wg := &errgroup.Group{}
 	wg.Go(s.clientListener.Wait)
 	wg.Go(s.apiListener.Wait)
+	if s.metricsServer != nil {
+		wg.Go(s.metricsServer.Wait)
+	}
 	// if caddy configured then also set up a dependency on the caddy server running
 	if s.config.CaddyEnabled() {
 		wg.Go(s.caddyServer.Wait)
@@ -485,6 +667,9 @@ func (s *Server) Close() error {
 
 	wg.Go(s.clientListener.Close)
 	wg.Go(s.apiListener.Close)
+	if s.metricsServer != nil {
+		wg.Go(s.metricsServer.Close)
+	}
 	if s.config.CaddyEnabled() {
 		wg.Go(s.caddyServer.Close)
 	}
@@ -512,9 +697,9 @@ func (s *Server) Close() error {
 	wg.Go(s.monitoringQueue.Close)
 
 	// TODO: (rs):  should we be shutting down the other plugin capabilities here?
-	if s.alertingService != nil {
+	if s.alerting != nil {
 		s.Debugf("stopping alerting service")
-		wg.Go(s.alertingService.Stop)
+		wg.Go(s.alerting.Stop)
 	}
 
 	err := wg.Wait()
@@ -278,6 +278,14 @@ func (al *APIListener) sendFileToClient(wg *sync.WaitGroup, file *models.Uploade
 		}
 		return
 	}
+	if cl.GetConnection() == nil {
+		resChan <- &uploadResult{
+			err:    errors3.ErrClientConnectedElsewhere,
+			client: cl,
+			resp:   nil,
+		}
+		return
+	}
 	resp := &models.UploadResponse{}
 	err := comm.SendRequestAndGetResponse(cl.GetConnection(), comm.RequestTypeUpload, file, resp, al.Log())
 
@@ -1,9 +1,11 @@
 package validation
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	errors2 "github.com/openrport/openrport/server/api/errors"
@@ -53,7 +55,19 @@ func ResolveIdleTunnelTimeoutValue(idleTimeoutMinutesStr string, skipIdleTimeout
 }
 
 func SchemeSupportsHTTPProxy(schemeStr string) bool {
-	return schemeStr == "http" || schemeStr == "https" || schemeStr == "vnc" || schemeStr == "rdp"
+	return schemeStr == "http" || schemeStr == "https" || schemeStr == "vnc" || schemeStr == "rdp" || schemeStr == "ssh"
+}
+
+// ValidateSSHHostKeyFingerprint checks the fingerprint is in the format ssh-keygen -l prints, e.g. SHA256:nThbg6kX...
+func ValidateSSHHostKeyFingerprint(fingerprint string) error {
+	hash, ok := strings.CutPrefix(fingerprint, "SHA256:")
+	if decoded, err := base64.RawStdEncoding.DecodeString(hash); !ok || err != nil || len(decoded) != 32 {
+		return errors2.APIError{
+			Message:    "invalid ssh_host_key, expected a SHA256 fingerprint as printed by ssh-keygen -l",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return nil
 }
 
 const (
@@ -0,0 +1,77 @@
+package keyprovider
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	TypeFile    = "file"
+	TypePKCS11  = "pkcs11"
+	TypeTransit = "transit"
+
+	DefaultPKCS11Tool     = "pkcs11-tool"
+	DefaultTransitMount   = "transit"
+	DefaultTransitTimeout = 10 * time.Second
+)
+
+// Config selects where the vault key is read from to unlock the vault without a password from the API
+type Config struct {
+	KeyProvider string `mapstructure:"key_provider"`
+
+	KeyFile string `mapstructure:"key_file"`
+
+	PKCS11Tool        string `mapstructure:"pkcs11_tool"`
+	PKCS11Module      string `mapstructure:"pkcs11_module"`
+	PKCS11TokenLabel  string `mapstructure:"pkcs11_token_label"`
+	PKCS11ObjectLabel string `mapstructure:"pkcs11_object_label"`
+	PKCS11PinFile     string `mapstructure:"pkcs11_pin_file"`
+
+	TransitAddress        string        `mapstructure:"transit_address"`
+	TransitTokenFile      string        `mapstructure:"transit_token_file"`
+	TransitMount          string        `mapstructure:"transit_mount"`
+	TransitKeyName        string        `mapstructure:"transit_key_name"`
+	TransitCiphertextFile string        `mapstructure:"transit_ciphertext_file"`
+	TransitTimeout        time.Duration `mapstructure:"transit_timeout"`
+}
+
+func (c *Config) Enabled() bool {
+	return c.KeyProvider != ""
+}
+
+func (c *Config) ParseAndValidate() error {
+	switch c.KeyProvider {
+	case "":
+		return nil
+	case TypeFile:
+		if c.KeyFile == "" {
+			return errors.New("'key_file' is required for key provider 'file'")
+		}
+	case TypePKCS11:
+		if c.PKCS11Tool == "" {
+			c.PKCS11Tool = DefaultPKCS11Tool
+		}
+		if c.PKCS11Module == "" || c.PKCS11ObjectLabel == "" || c.PKCS11PinFile == "" {
+			return errors.New("'pkcs11_module', 'pkcs11_object_label' and 'pkcs11_pin_file' are required for key provider 'pkcs11'")
+		}
+	case TypeTransit:
+		if c.TransitMount == "" {
+			c.TransitMount = DefaultTransitMount
+		}
+		if c.TransitTimeout <= 0 {
+			c.TransitTimeout = DefaultTransitTimeout
+		}
+		if c.TransitAddress == "" || c.TransitTokenFile == "" || c.TransitKeyName == "" || c.TransitCiphertextFile == "" {
+			return errors.New("'transit_address', 'transit_token_file', 'transit_key_name' and 'transit_ciphertext_file' are required for key provider 'transit'")
+		}
+		u, err := url.Parse(c.TransitAddress)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid 'transit_address' %q, expected an http or https URL", c.TransitAddress)
+		}
+	default:
+		return fmt.Errorf("invalid 'key_provider' %q, expected one of %q, %q or %q", c.KeyProvider, TypeFile, TypePKCS11, TypeTransit)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package keyprovider
+
+import "context"
+
+// FileProvider reads the vault key from a file, which must be readable by the rportd user only
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{
+		path: path,
+	}
+}
+
+func (p *FileProvider) Key(ctx context.Context) (string, error) {
+	return readSecretFile(p.path)
+}
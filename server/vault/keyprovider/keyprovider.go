@@ -0,0 +1,50 @@
+package keyprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider returns the vault key. The key is read on every call, so a changed key is used without a restart.
+type KeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// New returns the key provider of the config, nil if no key provider is configured
+func New(c Config) KeyProvider {
+	switch c.KeyProvider {
+	case TypeFile:
+		return NewFileProvider(c.KeyFile)
+	case TypePKCS11:
+		return NewPKCS11Provider(c.PKCS11Tool, c.PKCS11Module, c.PKCS11TokenLabel, c.PKCS11ObjectLabel, c.PKCS11PinFile)
+	case TypeTransit:
+		return NewTransitProvider(c.TransitAddress, c.TransitTokenFile, c.TransitMount, c.TransitKeyName, c.TransitCiphertextFile, c.TransitTimeout)
+	}
+	return nil
+}
+
+// readSecretFile returns the trimmed content of a file which must not be accessible by other users
+func readSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPermissions(info); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
+
+var errInsecurePermissions = errors.New("must not be accessible by group or others, use 'chmod 600'")
@@ -0,0 +1,165 @@
+package keyprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSecretFile(t *testing.T, name, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+	require.NoError(t, os.Chmod(path, perm))
+	return path
+}
+
+func TestConfigParseAndValidate(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Config      Config
+		ExpectedErr string
+	}{
+		{
+			Name:   "disabled",
+			Config: Config{},
+		}, {
+			Name:        "unknown provider",
+			Config:      Config{KeyProvider: "kms"},
+			ExpectedErr: `invalid 'key_provider' "kms", expected one of "file", "pkcs11" or "transit"`,
+		}, {
+			Name:        "file without path",
+			Config:      Config{KeyProvider: TypeFile},
+			ExpectedErr: "'key_file' is required for key provider 'file'",
+		}, {
+			Name:        "pkcs11 without module",
+			Config:      Config{KeyProvider: TypePKCS11, PKCS11ObjectLabel: "key", PKCS11PinFile: "/pin"},
+			ExpectedErr: "'pkcs11_module', 'pkcs11_object_label' and 'pkcs11_pin_file' are required for key provider 'pkcs11'",
+		}, {
+			Name: "transit with invalid address",
+			Config: Config{
+				KeyProvider:           TypeTransit,
+				TransitAddress:        "vault:8200",
+				TransitTokenFile:      "/token",
+				TransitKeyName:        "rport",
+				TransitCiphertextFile: "/ciphertext",
+			},
+			ExpectedErr: `invalid 'transit_address' "vault:8200", expected an http or https URL`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.ParseAndValidate()
+			if tc.ExpectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedErr)
+			}
+		})
+	}
+
+	transit := Config{
+		KeyProvider:           TypeTransit,
+		TransitAddress:        "https://vault:8200",
+		TransitTokenFile:      "/token",
+		TransitKeyName:        "rport",
+		TransitCiphertextFile: "/ciphertext",
+	}
+	require.NoError(t, transit.ParseAndValidate())
+	assert.Equal(t, DefaultTransitMount, transit.TransitMount)
+	assert.Equal(t, DefaultTransitTimeout, transit.TransitTimeout)
+}
+
+func TestFileProvider(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := NewFileProvider(writeSecretFile(t, "vault.key", "secret-key\n", 0600)).Key(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", key)
+
+	_, err = NewFileProvider(writeSecretFile(t, "vault.key", " \n", 0600)).Key(ctx)
+	assert.ErrorContains(t, err, "is empty")
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing")).Key(ctx)
+	assert.Error(t, err)
+
+	if runtime.GOOS != "windows" {
+		_, err = NewFileProvider(writeSecretFile(t, "vault.key", "secret-key", 0644)).Key(ctx)
+		assert.ErrorIs(t, err, errInsecurePermissions)
+	}
+}
+
+func TestPKCS11Provider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake pkcs11-tool is a shell script")
+	}
+	ctx := context.Background()
+
+	// the fake tool prints its arguments and the pin instead of the object
+	tool := writeSecretFile(t, "pkcs11-tool", "#!/bin/sh\necho \"$@\" \"$RPORT_PKCS11_PIN\"\n", 0700)
+	pinFile := writeSecretFile(t, "pin", "1234\n", 0600)
+
+	key, err := NewPKCS11Provider(tool, "/usr/lib/softhsm/libsofthsm2.so", "rport", "vault-key", pinFile).Key(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "--module /usr/lib/softhsm/libsofthsm2.so --token-label rport --login --pin env:RPORT_PKCS11_PIN --read-object --type data --label vault-key 1234", key)
+
+	failingTool := writeSecretFile(t, "pkcs11-tool", "#!/bin/sh\necho 'error: object not found' >&2\nexit 1\n", 0700)
+	_, err = NewPKCS11Provider(failingTool, "/usr/lib/softhsm/libsofthsm2.so", "", "vault-key", pinFile).Key(ctx)
+	assert.ErrorContains(t, err, "error: object not found")
+}
+
+// transitStub emulates the decrypt endpoint of the transit secrets engine
+func transitStub(t *testing.T, token, ciphertext, plaintext string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/transit/decrypt/rport" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		req := transitDecryptRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Ciphertext != ciphertext {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors": ["invalid ciphertext: unable to decrypt"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"plaintext": "` + base64.StdEncoding.EncodeToString([]byte(plaintext)) + `"}}`))
+	}))
+}
+
+func TestTransitProvider(t *testing.T) {
+	ctx := context.Background()
+	server := transitStub(t, "s.token", "vault:v1:abcd", "secret-key")
+	defer server.Close()
+
+	tokenFile := writeSecretFile(t, "token", "s.token", 0600)
+	ciphertextFile := writeSecretFile(t, "ciphertext", "vault:v1:abcd\n", 0600)
+
+	key, err := NewTransitProvider(server.URL+"/", tokenFile, "transit", "rport", ciphertextFile, time.Second).Key(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", key)
+
+	wrongToken := writeSecretFile(t, "token", "s.wrong", 0600)
+	_, err = NewTransitProvider(server.URL, wrongToken, "transit", "rport", ciphertextFile, time.Second).Key(ctx)
+	assert.EqualError(t, err, "transit decrypt failed with status 403: permission denied")
+
+	wrongCiphertext := writeSecretFile(t, "ciphertext", "vault:v1:other", 0600)
+	_, err = NewTransitProvider(server.URL, tokenFile, "transit", "rport", wrongCiphertext, time.Second).Key(ctx)
+	assert.EqualError(t, err, "transit decrypt failed with status 400: invalid ciphertext: unable to decrypt")
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package keyprovider
+
+import (
+	"fmt"
+	"os"
+)
+
+func checkPermissions(info os.FileInfo) error {
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("has permissions %v, %w", info.Mode().Perm(), errInsecurePermissions)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package keyprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pkcs11PinEnv passes the PIN to pkcs11-tool, on the command line it would be visible to all users of the host
+const pkcs11PinEnv = "RPORT_PKCS11_PIN"
+
+// PKCS11Provider reads the vault key from a data object of a PKCS#11 token, e.g. an HSM or a smart card.
+// The token is accessed with pkcs11-tool of OpenSC, which loads the PKCS#11 module of the token. The PIN is passed in
+// the environment, which requires OpenSC 0.20 or later.
+type PKCS11Provider struct {
+	tool        string
+	module      string
+	tokenLabel  string
+	objectLabel string
+	pinFile     string
+}
+
+func NewPKCS11Provider(tool, module, tokenLabel, objectLabel, pinFile string) *PKCS11Provider {
+	return &PKCS11Provider{
+		tool:        tool,
+		module:      module,
+		tokenLabel:  tokenLabel,
+		objectLabel: objectLabel,
+		pinFile:     pinFile,
+	}
+}
+
+func (p *PKCS11Provider) Key(ctx context.Context) (string, error) {
+	pin, err := readSecretFile(p.pinFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read pkcs11 pin: %w", err)
+	}
+
+	args := []string{"--module", p.module}
+	if p.tokenLabel != "" {
+		args = append(args, "--token-label", p.tokenLabel)
+	}
+	args = append(args, "--login", "--pin", "env:"+pkcs11PinEnv, "--read-object", "--type", "data", "--label", p.objectLabel)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.tool, args...)
+	cmd.Env = append(os.Environ(), pkcs11PinEnv+"="+pin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to read object %q from pkcs11 token: %v: %s", p.objectLabel, err, strings.TrimSpace(stderr.String()))
+	}
+
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", fmt.Errorf("object %q of the pkcs11 token is empty", p.objectLabel)
+	}
+	return key, nil
+}
@@ -0,0 +1,93 @@
+package keyprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransitProvider decrypts the vault key with the decrypt endpoint of the HashiCorp Vault transit secrets engine.
+// Only the ciphertext is stored on the rportd host, the key to decrypt it never leaves the transit server.
+type TransitProvider struct {
+	address        string
+	tokenFile      string
+	mount          string
+	keyName        string
+	ciphertextFile string
+	client         *http.Client
+}
+
+func NewTransitProvider(address, tokenFile, mount, keyName, ciphertextFile string, timeout time.Duration) *TransitProvider {
+	return &TransitProvider{
+		address:        strings.TrimSuffix(address, "/"),
+		tokenFile:      tokenFile,
+		mount:          mount,
+		keyName:        keyName,
+		ciphertextFile: ciphertextFile,
+		client:         &http.Client{Timeout: timeout},
+	}
+}
+
+type transitDecryptRequest struct {
+	Ciphertext string `json:"ciphertext"`
+}
+
+type transitDecryptResponse struct {
+	Data struct {
+		Plaintext string `json:"plaintext"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (p *TransitProvider) Key(ctx context.Context) (string, error) {
+	token, err := readSecretFile(p.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read transit token: %w", err)
+	}
+	ciphertext, err := readSecretFile(p.ciphertextFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read transit ciphertext: %w", err)
+	}
+
+	body, err := json.Marshal(transitDecryptRequest{Ciphertext: ciphertext})
+	if err != nil {
+		return "", err
+	}
+	decryptURL := fmt.Sprintf("%s/v1/%s/decrypt/%s", p.address, p.mount, url.PathEscape(p.keyName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, decryptURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transit decrypt request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	decryptResp := transitDecryptResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&decryptResp); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to decode transit decrypt response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transit decrypt failed with status %d: %s", resp.StatusCode, strings.Join(decryptResp.Errors, ", "))
+	}
+
+	plaintext, err := base64.StdEncoding.DecodeString(decryptResp.Data.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("invalid plaintext in transit decrypt response: %w", err)
+	}
+	key := strings.TrimSpace(string(plaintext))
+	if key == "" {
+		return "", fmt.Errorf("transit decrypt returned an empty key")
+	}
+	return key, nil
+}
@@ -47,6 +47,8 @@ type DbProvider interface {
 	FindByKeyAndClientID(ctx context.Context, key, clientID string) (val StoredValue, found bool, err error)
 	Save(ctx context.Context, user string, idToUpdate int64, val *InputValue, nowDate time.Time) (int64, error)
 	Delete(ctx context.Context, id int) error
+	ListEncryptedValues(ctx context.Context) ([]EncryptedValue, error)
+	ReplaceEncryptedValues(ctx context.Context, values []EncryptedValue, newStatus DbStatus) error
 	io.Closer
 }
 
@@ -61,9 +63,15 @@ type DbProviderFactory interface {
 	Init() error
 }
 
+type KeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
 type Manager struct {
-	passLock  sync.RWMutex
-	pass      string
+	passLock sync.RWMutex
+	pass     string
+	// rekeyLock blocks changes of values while all values are re-encrypted
+	rekeyLock sync.RWMutex
 	dbFactory DbProviderFactory
 	pm        PassManager
 	logger    *logger.Logger
@@ -182,6 +190,77 @@ func (m *Manager) UnLock(ctx context.Context, pass string) error {
 	return nil
 }
 
+// UnLockWithKeyProvider unlocks the vault with the key of the key provider instead of a password
+func (m *Manager) UnLockWithKeyProvider(ctx context.Context, kp KeyProvider) error {
+	key, err := kp.Key(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get vault key from key provider: %w", err)
+	}
+	return m.UnLock(ctx, key)
+}
+
+// ReKey re-encrypts all values with the new password, which replaces the current one. Values can be read
+// while they are re-encrypted, changes wait until the re-keying is done.
+func (m *Manager) ReKey(ctx context.Context, newPass string) error {
+	if err := m.pm.ValidatePass(newPass); err != nil {
+		return err
+	}
+	if err := m.checkUnlockedAndInitialized(ctx); err != nil {
+		return err
+	}
+
+	m.rekeyLock.Lock()
+	defer m.rekeyLock.Unlock()
+
+	m.passLock.RLock()
+	oldPass := m.pass
+	m.passLock.RUnlock()
+	if oldPass == "" {
+		return errors2.APIError{
+			Message:    "vault is locked",
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	db := m.dbFactory.GetDbProvider()
+	values, err := db.ListEncryptedValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range values {
+		decrypted, err := enc.Aes256DecryptByPassFromBase64String(values[i].Value, oldPass)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt value with id %d: %w", values[i].ID, err)
+		}
+		values[i].Value, err = enc.Aes256EncryptByPassToBase64String(decrypted, newPass)
+		if err != nil {
+			return err
+		}
+	}
+
+	newStatus := DbStatus{
+		StatusName: DbStatusInit,
+	}
+	newStatus.EncCheckValue, newStatus.DecCheckValue, err = m.pm.GetEncRandValue(newPass)
+	if err != nil {
+		return err
+	}
+
+	// reading values waits only while the values are replaced and the password is switched
+	m.passLock.Lock()
+	defer m.passLock.Unlock()
+
+	err = db.ReplaceEncryptedValues(ctx, values, newStatus)
+	if err != nil {
+		return err
+	}
+	m.pass = newPass
+	m.logger.Infof("re-keyed vault, %d values re-encrypted", len(values))
+
+	return nil
+}
+
 func (m *Manager) Lock(ctx context.Context) error {
 	if m.IsLocked() {
 		return errors2.APIError{
@@ -294,6 +373,10 @@ func (m *Manager) GetOne(ctx context.Context, id int, user UserDataProvider) (St
 
 	db := m.dbFactory.GetDbProvider()
 
+	// the value must be decrypted with the password it was read with, it can change by re-keying
+	m.passLock.RLock()
+	defer m.passLock.RUnlock()
+
 	val, found, err := db.GetByID(ctx, id)
 	if err != nil {
 		return StoredValue{}, false, err
@@ -308,9 +391,6 @@ func (m *Manager) GetOne(ctx context.Context, id int, user UserDataProvider) (St
 		return StoredValue{}, false, err
 	}
 
-	m.passLock.RLock()
-	defer m.passLock.RUnlock()
-
 	decryptedValue, err := enc.Aes256DecryptByPassFromBase64String(val.Value, m.pass)
 	if err != nil {
 		return StoredValue{}, false, err
@@ -320,7 +400,53 @@ func (m *Manager) GetOne(ctx context.Context, id int, user UserDataProvider) (St
 	return val, true, nil
 }
 
+// Resolve returns the decrypted value of the key for a client. A value stored for the client takes precedence over
+// a value stored for all clients.
+func (m *Manager) Resolve(ctx context.Context, key, clientID string, user UserDataProvider) (string, error) {
+	err := m.checkUnlockedAndInitialized(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	db := m.dbFactory.GetDbProvider()
+
+	m.passLock.RLock()
+	defer m.passLock.RUnlock()
+
+	val, found, err := db.FindByKeyAndClientID(ctx, key, clientID)
+	if err != nil {
+		return "", err
+	}
+	if !found && clientID != "" {
+		val, found, err = db.FindByKeyAndClientID(ctx, key, "")
+		if err != nil {
+			return "", err
+		}
+	}
+	if !found {
+		return "", errors2.APIError{
+			Message:    fmt.Sprintf("vault key %q not found for client %q", key, clientID),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+
+	err = m.checkGroupAccess(&val, user)
+	if err != nil {
+		return "", err
+	}
+
+	decryptedValue, err := enc.Aes256DecryptByPassFromBase64String(val.Value, m.pass)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decryptedValue), nil
+}
+
 func (m *Manager) Store(ctx context.Context, existingID int64, valueToStore *InputValue, user UserDataProvider) (StoredValueID, error) {
+	m.rekeyLock.RLock()
+	defer m.rekeyLock.RUnlock()
+
 	err := m.checkUnlockedAndInitialized(ctx)
 	if err != nil {
 		return StoredValueID{}, err
@@ -384,6 +510,9 @@ func (m *Manager) Store(ctx context.Context, existingID int64, valueToStore *Inp
 }
 
 func (m *Manager) Delete(ctx context.Context, id int, user UserDataProvider) error {
+	m.rekeyLock.RLock()
+	defer m.rekeyLock.RUnlock()
+
 	err := m.checkUnlockedAndInitialized(ctx)
 	if err != nil {
 		return err
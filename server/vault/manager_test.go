@@ -103,6 +103,15 @@ func (dpm *DbProviderMock) Delete(ctx context.Context, id int) error {
 	return dpm.DeleteErrorToGive
 }
 
+func (dpm *DbProviderMock) ListEncryptedValues(ctx context.Context) ([]EncryptedValue, error) {
+	return nil, nil
+}
+
+func (dpm *DbProviderMock) ReplaceEncryptedValues(ctx context.Context, values []EncryptedValue, newStatus DbStatus) error {
+	dpm.statusToStore = newStatus
+	return nil
+}
+
 func (dpm *DbProviderMock) GetDbProvider() DbProvider {
 	return dpm
 }
@@ -1000,3 +1009,115 @@ func TestDeleteKeyWithNoGroupAccess(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+type keyProviderMock struct {
+	key string
+	err error
+}
+
+func (kpm keyProviderMock) Key(ctx context.Context) (string, error) {
+	return kpm.key, kpm.err
+}
+
+func TestReKey(t *testing.T) {
+	ctx := context.Background()
+	dbFactory := NewStatefulDbProviderFactory(
+		func() (DbProvider, error) {
+			return NewSqliteProvider(configMock{}, testLog)
+		},
+		&NotInitDbProvider{},
+	)
+	mngr := NewManager(dbFactory, &Aes256PassManager{}, testLog)
+	defer mngr.Close()
+
+	err := mngr.ReKey(ctx, "new-pass")
+	assert.Error(t, err, "not initialized")
+
+	require.NoError(t, mngr.Init(ctx, "old-pass"))
+
+	user := UserDataProviderMock{UsernameToGive: "admin"}
+	ids := []int64{}
+	for _, val := range []string{"secret 1", "secret 2"} {
+		stored, err := mngr.Store(ctx, 0, &InputValue{Key: val, Value: val, Type: SecretType}, user)
+		require.NoError(t, err)
+		ids = append(ids, stored.ID)
+	}
+
+	err = mngr.ReKey(ctx, "12")
+	assert.Error(t, err, "password too short")
+
+	require.NoError(t, mngr.ReKey(ctx, "new-pass"))
+
+	for i, val := range []string{"secret 1", "secret 2"} {
+		stored, found, err := mngr.GetOne(ctx, int(ids[i]), user)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, val, stored.Value)
+	}
+
+	require.NoError(t, mngr.Lock(ctx))
+	assert.Equal(t, WrongPasswordError, mngr.UnLock(ctx, "old-pass"))
+
+	err = mngr.UnLockWithKeyProvider(ctx, keyProviderMock{err: errors.New("token not present")})
+	assert.EqualError(t, err, "failed to get vault key from key provider: token not present")
+
+	require.NoError(t, mngr.UnLockWithKeyProvider(ctx, keyProviderMock{key: "new-pass"}))
+	stored, _, err := mngr.GetOne(ctx, int(ids[0]), user)
+	require.NoError(t, err)
+	assert.Equal(t, "secret 1", stored.Value)
+
+	require.NoError(t, mngr.Lock(ctx))
+	err = mngr.ReKey(ctx, "other-pass")
+	assert.Error(t, err, "locked")
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	dbFactory := NewStatefulDbProviderFactory(
+		func() (DbProvider, error) {
+			return NewSqliteProvider(configMock{}, testLog)
+		},
+		&NotInitDbProvider{},
+	)
+	mngr := NewManager(dbFactory, &Aes256PassManager{}, testLog)
+	defer mngr.Close()
+
+	admin := UserDataProviderMock{UsernameToGive: "admin", GroupsToGive: []string{"Administrators"}}
+	user := UserDataProviderMock{UsernameToGive: "user"}
+
+	_, err := mngr.Resolve(ctx, "db", "client-1", user)
+	assert.EqualError(t, err, "vault is locked")
+
+	require.NoError(t, mngr.Init(ctx, "pass1234"))
+	for _, val := range []InputValue{
+		{Key: "db", Value: "global secret", Type: SecretType},
+		{Key: "db", ClientID: "client-1", Value: "client secret", Type: SecretType},
+		{Key: "admin", Value: "admin secret", Type: SecretType, RequiredGroup: "Administrators"},
+	} {
+		val := val
+		_, err := mngr.Store(ctx, 0, &val, admin)
+		require.NoError(t, err)
+	}
+
+	value, err := mngr.Resolve(ctx, "db", "client-1", user)
+	require.NoError(t, err)
+	assert.Equal(t, "client secret", value)
+
+	value, err = mngr.Resolve(ctx, "db", "client-2", user)
+	require.NoError(t, err)
+	assert.Equal(t, "global secret", value)
+
+	_, err = mngr.Resolve(ctx, "unknown", "client-1", user)
+	assert.EqualError(t, err, `vault key "unknown" not found for client "client-1"`)
+
+	_, err = mngr.Resolve(ctx, "admin", "client-1", user)
+	assert.EqualError(t, err, "your group doesn't allow access to this value")
+
+	value, err = mngr.Resolve(ctx, "admin", "client-1", admin)
+	require.NoError(t, err)
+	assert.Equal(t, "admin secret", value)
+
+	require.NoError(t, mngr.Lock(ctx))
+	_, err = mngr.Resolve(ctx, "db", "client-1", user)
+	assert.EqualError(t, err, "vault is locked")
+}
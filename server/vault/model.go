@@ -23,6 +23,14 @@ type StatusReport struct {
 
 type PassRequest struct {
 	Password string `json:"password"`
+	// FromKeyProvider uses the key of the configured key provider instead of the password
+	FromKeyProvider bool `json:"from_key_provider"`
+}
+
+// EncryptedValue is a stored value as it's saved in the database, used to re-encrypt all values with a new key
+type EncryptedValue struct {
+	ID    int    `db:"id"`
+	Value string `db:"value"`
 }
 
 type StoredValueID struct {
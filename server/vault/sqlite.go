@@ -42,11 +42,16 @@ func NewSqliteProvider(c Config, logger *logger.Logger) (*SqliteProvider, error)
 
 	logger.Infof("initialized database at %s", dbPath)
 
+	return NewDBProvider(db, logger), nil
+}
+
+// NewDBProvider returns a provider using an already opened and migrated DB.
+func NewDBProvider(db *sqlx.DB, logger *logger.Logger) *SqliteProvider {
 	return &SqliteProvider{
 		logger:    logger,
 		db:        db,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SqliteProvider) Close() error {
@@ -59,7 +64,7 @@ func (p *SqliteProvider) Close() error {
 
 func (p *SqliteProvider) GetStatus(ctx context.Context) (DbStatus, error) {
 	res := DbStatus{}
-	err := p.db.GetContext(ctx, &res, "SELECT * FROM `status` LIMIT 1")
+	err := p.db.GetContext(ctx, &res, p.converter.Rebind("SELECT * FROM `status` LIMIT 1"))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return res, nil
@@ -77,7 +82,7 @@ func (p *SqliteProvider) SetStatus(ctx context.Context, newStatus DbStatus) erro
 	}
 
 	var idToUpdate int
-	err = tx.GetContext(ctx, &idToUpdate, "SELECT id FROM `status` LIMIT 1")
+	err = tx.GetContext(ctx, &idToUpdate, p.converter.Rebind("SELECT id FROM `status` LIMIT 1"))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			idToUpdate = 0
@@ -90,7 +95,7 @@ func (p *SqliteProvider) SetStatus(ctx context.Context, newStatus DbStatus) erro
 	if idToUpdate == 0 {
 		_, err = tx.ExecContext(
 			ctx,
-			"INSERT INTO `status` (`db_status`, `enc_check`, `dec_check`) VALUES (?, ?, ?)",
+			p.converter.Rebind("INSERT INTO `status` (`db_status`, `enc_check`, `dec_check`) VALUES (?, ?, ?)"),
 			newStatus.StatusName,
 			newStatus.EncCheckValue,
 			newStatus.DecCheckValue,
@@ -108,7 +113,7 @@ func (p *SqliteProvider) SetStatus(ctx context.Context, newStatus DbStatus) erro
 			newStatus.DecCheckValue,
 			idToUpdate,
 		}
-		_, err = tx.ExecContext(ctx, q, params...)
+		_, err = tx.ExecContext(ctx, p.converter.Rebind(q), params...)
 		if err != nil {
 			p.handleRollback(tx)
 			return err
@@ -124,7 +129,7 @@ func (p *SqliteProvider) SetStatus(ctx context.Context, newStatus DbStatus) erro
 }
 
 func (p *SqliteProvider) GetByID(ctx context.Context, id int) (val StoredValue, found bool, err error) {
-	err = p.db.GetContext(ctx, &val, "SELECT * FROM `values` WHERE `id` = ? LIMIT 1", id)
+	err = p.db.GetContext(ctx, &val, p.converter.Rebind("SELECT * FROM `values` WHERE `id` = ? LIMIT 1"), id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return val, false, nil
@@ -143,7 +148,7 @@ func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]Val
 
 	q, params := p.converter.ConvertListOptionsToQuery(lo, q)
 
-	err := p.db.SelectContext(ctx, &values, q, params...)
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind(q), params...)
 	if err != nil {
 		return values, err
 	}
@@ -152,7 +157,7 @@ func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]Val
 }
 
 func (p *SqliteProvider) FindByKeyAndClientID(ctx context.Context, key, clientID string) (val StoredValue, found bool, err error) {
-	err = p.db.GetContext(ctx, &val, "SELECT * FROM `values` WHERE `key` = ? and `client_id` = ? LIMIT 1", key, clientID)
+	err = p.db.GetContext(ctx, &val, p.converter.Rebind("SELECT * FROM `values` WHERE `key` = ? and `client_id` = ? LIMIT 1"), key, clientID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return val, false, nil
@@ -166,9 +171,10 @@ func (p *SqliteProvider) FindByKeyAndClientID(ctx context.Context, key, clientID
 
 func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64, val *InputValue, nowDate time.Time) (int64, error) {
 	if idToUpdate == 0 {
-		res, err := p.db.ExecContext(
+		err := p.db.GetContext(
 			ctx,
-			"INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			&idToUpdate,
+			p.converter.Rebind("INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING `id`"),
 			val.ClientID,
 			val.RequiredGroup,
 			nowDate.Format(time.RFC3339),
@@ -179,11 +185,6 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 			val.Value,
 			val.Type,
 		)
-
-		if err != nil {
-			return 0, err
-		}
-		idToUpdate, err = res.LastInsertId()
 		if err != nil {
 			return 0, err
 		}
@@ -199,7 +200,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 			val.Type,
 			idToUpdate,
 		}
-		_, err := p.db.ExecContext(ctx, q, params...)
+		_, err := p.db.ExecContext(ctx, p.converter.Rebind(q), params...)
 		if err != nil {
 			return 0, err
 		}
@@ -209,7 +210,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 }
 
 func (p *SqliteProvider) Delete(ctx context.Context, id int) error {
-	res, err := p.db.ExecContext(ctx, "DELETE FROM `values` WHERE `id` = ?", id)
+	res, err := p.db.ExecContext(ctx, p.converter.Rebind("DELETE FROM `values` WHERE `id` = ?"), id)
 
 	if err != nil {
 		return err
@@ -227,6 +228,56 @@ func (p *SqliteProvider) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+func (p *SqliteProvider) ListEncryptedValues(ctx context.Context) ([]EncryptedValue, error) {
+	values := []EncryptedValue{}
+	err := p.db.SelectContext(ctx, &values, p.converter.Rebind("SELECT `id`, `value` FROM `values` ORDER BY `id`"))
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ReplaceEncryptedValues updates the values and the status in a single transaction, so all values are
+// always encrypted with the key matching the status
+func (p *SqliteProvider) ReplaceEncryptedValues(ctx context.Context, values []EncryptedValue, newStatus DbStatus) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, val := range values {
+		res, err := tx.ExecContext(ctx, p.converter.Rebind("UPDATE `values` SET `value` = ? WHERE `id` = ?"), val.Value, val.ID)
+		if err != nil {
+			p.handleRollback(tx)
+			return err
+		}
+		affectedRows, err := res.RowsAffected()
+		if err != nil {
+			p.handleRollback(tx)
+			return err
+		}
+		if affectedRows == 0 {
+			p.handleRollback(tx)
+			return fmt.Errorf("cannot find entry by id %d", val.ID)
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		p.converter.Rebind("UPDATE `status` SET `db_status` = ?, `enc_check` = ?, `dec_check` = ?"),
+		newStatus.StatusName,
+		newStatus.EncCheckValue,
+		newStatus.DecCheckValue,
+	)
+	if err != nil {
+		p.handleRollback(tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (p *SqliteProvider) handleRollback(tx *sqlx.Tx) {
 	err := tx.Rollback()
 	if err != nil {
@@ -270,6 +321,14 @@ func (nidp *NotInitDbProvider) Delete(ctx context.Context, id int) error {
 	return ErrDatabaseNotInitialised
 }
 
+func (nidp *NotInitDbProvider) ListEncryptedValues(ctx context.Context) ([]EncryptedValue, error) {
+	return nil, ErrDatabaseNotInitialised
+}
+
+func (nidp *NotInitDbProvider) ReplaceEncryptedValues(ctx context.Context, values []EncryptedValue, newStatus DbStatus) error {
+	return ErrDatabaseNotInitialised
+}
+
 func (nidp *NotInitDbProvider) Close() error {
 	return nil
 }
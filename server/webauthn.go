@@ -0,0 +1,311 @@
+package chserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/patrickmn/go-cache"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+)
+
+const (
+	webAuthnSessionTimeout = 5 * time.Minute
+	// user handles are limited to 64 bytes by the spec, the username is used as handle
+	webAuthnMaxUserHandleLength = 64
+)
+
+type WebAuthnKeyStatus uint
+
+func (s WebAuthnKeyStatus) String() string {
+	switch s {
+	case WebAuthnKeyPending:
+		return "pending"
+	case WebAuthnKeyExists:
+		return "exists"
+	default:
+		return ""
+	}
+}
+
+const (
+	WebAuthnKeyPending WebAuthnKeyStatus = iota + 1
+	WebAuthnKeyExists
+)
+
+var errWebAuthnInvalidResponse = errors2.APIError{
+	HTTPStatus: http.StatusUnauthorized,
+	Message:    "invalid webauthn response",
+}
+
+var errWebAuthnSessionNotFound = errors2.APIError{
+	HTTPStatus: http.StatusBadRequest,
+	Message:    "webauthn challenge not found or expired",
+}
+
+// webAuthnUser exposes a user to the webauthn library, the username is used as user handle
+type webAuthnUser struct {
+	*users.User
+}
+
+func (u webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.Username)
+}
+
+func (u webAuthnUser) WebAuthnName() string {
+	return u.Username
+}
+
+func (u webAuthnUser) WebAuthnDisplayName() string {
+	return u.Username
+}
+
+func (u webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(u.User.WebAuthnCredentials))
+	for _, c := range u.User.WebAuthnCredentials {
+		transport := make([]protocol.AuthenticatorTransport, 0, len(c.Transport))
+		for _, t := range c.Transport {
+			transport = append(transport, protocol.AuthenticatorTransport(t))
+		}
+		creds = append(creds, webauthn.Credential{
+			ID:              c.ID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transport,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return creds
+}
+
+// WebAuthnService runs the registration and login ceremonies of security keys and passkeys,
+// the challenges sent to the browser are kept in memory until the response is received
+type WebAuthnService struct {
+	webAuthn *webauthn.WebAuthn
+	sessions *cache.Cache
+	mu       sync.Mutex
+}
+
+func NewWebAuthnService(config chconfig.WebAuthnConfig) (*WebAuthnService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPDisplayName,
+		RPOrigins:     config.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid webauthn config: %w", err)
+	}
+
+	return &WebAuthnService{
+		webAuthn: w,
+		sessions: cache.New(webAuthnSessionTimeout, time.Minute),
+	}, nil
+}
+
+// BeginRegistration returns the options to create a new credential for the given user
+func (s *WebAuthnService) BeginRegistration(user *users.User) (*protocol.CredentialCreation, error) {
+	if len(user.Username) > webAuthnMaxUserHandleLength {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("usernames longer than %d bytes cannot be used with webauthn", webAuthnMaxUserHandleLength),
+		}
+	}
+
+	wu := webAuthnUser{User: user}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.WebAuthnCredentials))
+	for _, c := range wu.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	options, session, err := s.webAuthn.BeginRegistration(wu, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	s.saveSession("registration", session)
+
+	return options, nil
+}
+
+// FinishRegistration verifies the attestation read from body and returns the new credential
+func (s *WebAuthnService) FinishRegistration(user *users.User, name string, body io.Reader) (*users.WebAuthnCredential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "invalid webauthn credential",
+			Err:        err,
+		}
+	}
+
+	session, ok := s.popSession("registration", parsed.Response.CollectedClientData.Challenge)
+	if !ok {
+		return nil, errWebAuthnSessionNotFound
+	}
+
+	cred, err := s.webAuthn.CreateCredential(webAuthnUser{User: user}, *session, parsed)
+	if err != nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "failed to verify webauthn credential",
+			Err:        err,
+		}
+	}
+
+	transport := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transport = append(transport, string(t))
+	}
+
+	return &users.WebAuthnCredential{
+		ID:              cred.ID,
+		Name:            name,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Transport:       transport,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+		CreatedAt:       time.Now().UTC(),
+	}, nil
+}
+
+// BeginLogin returns the assertion options for the credentials of the given user,
+// a nil user starts a passwordless login with a discoverable credential
+func (s *WebAuthnService) BeginLogin(user *users.User) (*protocol.CredentialAssertion, error) {
+	var options *protocol.CredentialAssertion
+	var session *webauthn.SessionData
+	var err error
+	if user == nil {
+		// passwordless logins replace password and second factor, so the key must verify the user
+		options, session, err = s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	} else {
+		options, session, err = s.webAuthn.BeginLogin(webAuthnUser{User: user})
+	}
+	if err != nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "failed to start webauthn login",
+			Err:        err,
+		}
+	}
+	s.saveSession("login", session)
+
+	return options, nil
+}
+
+// FinishLogin verifies the assertion read from body. For a second factor login the username must be given
+// and has to match the user the login was started for, otherwise the user is looked up by the user handle of the credential.
+// The sign count and last usage of the used credential are updated in the returned user.
+func (s *WebAuthnService) FinishLogin(
+	username string,
+	body io.Reader,
+	getUser func(username string) (*users.User, error),
+) (*users.User, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, errWebAuthnInvalidResponse
+	}
+
+	session, ok := s.popSession("login", parsed.Response.CollectedClientData.Challenge)
+	if !ok {
+		return nil, errWebAuthnSessionNotFound
+	}
+
+	var user *users.User
+	var cred *webauthn.Credential
+	if len(session.UserID) > 0 {
+		if username == "" || username != string(session.UserID) {
+			return nil, errWebAuthnInvalidResponse
+		}
+		user, err = getUser(username)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errWebAuthnInvalidResponse
+		}
+		cred, err = s.webAuthn.ValidateLogin(webAuthnUser{User: user}, *session, parsed)
+	} else {
+		cred, err = s.webAuthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+			u, err := getUser(string(userHandle))
+			if err != nil {
+				return nil, err
+			}
+			if u == nil {
+				return nil, errors.New("user not found")
+			}
+			user = u
+			return webAuthnUser{User: u}, nil
+		}, *session, parsed)
+	}
+	if err != nil {
+		return user, errWebAuthnInvalidResponse
+	}
+	if cred.Authenticator.CloneWarning {
+		return user, errors2.APIError{
+			HTTPStatus: http.StatusUnauthorized,
+			Message:    "the sign count of the webauthn credential went backwards, the key might be cloned",
+		}
+	}
+
+	for i := range user.WebAuthnCredentials {
+		if bytes.Equal(user.WebAuthnCredentials[i].ID, cred.ID) {
+			now := time.Now().UTC()
+			user.WebAuthnCredentials[i].SignCount = cred.Authenticator.SignCount
+			user.WebAuthnCredentials[i].LastUsedAt = &now
+			return user, nil
+		}
+	}
+
+	return user, errWebAuthnInvalidResponse
+}
+
+func (s *WebAuthnService) saveSession(ceremony string, session *webauthn.SessionData) {
+	session.Expires = time.Now().Add(webAuthnSessionTimeout)
+	s.sessions.SetDefault(ceremony+":"+session.Challenge, session)
+}
+
+// popSession returns the session of the given challenge, each challenge can be used only once
+func (s *WebAuthnService) popSession(ceremony, challenge string) (*webauthn.SessionData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := ceremony + ":" + challenge
+	v, ok := s.sessions.Get(key)
+	if !ok {
+		return nil, false
+	}
+	s.sessions.Delete(key)
+	return v.(*webauthn.SessionData), true
+}
+
+// EncodeWebAuthnCredentialID returns the credential id as used in URLs
+func EncodeWebAuthnCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
@@ -0,0 +1,216 @@
+package chserver
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+)
+
+const (
+	testWebAuthnRPID   = "rport.example.com"
+	testWebAuthnOrigin = "https://rport.example.com"
+)
+
+var testWebAuthnConfig = chconfig.WebAuthnConfig{
+	Enabled:       true,
+	RPID:          testWebAuthnRPID,
+	RPDisplayName: chconfig.DefaultWebAuthnRPDisplayName,
+	RPOrigins:     []string{testWebAuthnOrigin},
+}
+
+// softAuthenticator is a security key holding a single P-256 credential in memory
+type softAuthenticator struct {
+	t          *testing.T
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	signCount  uint32
+}
+
+func newSoftAuthenticator(t *testing.T, username string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &softAuthenticator{t: t, key: key, id: id, userHandle: []byte(username)}
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *softAuthenticator) authData(flags byte, attestedCredData []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testWebAuthnRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attestedCredData...)
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testWebAuthnOrigin,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+// register returns the attestation response of a new credential for the given challenge
+func (a *softAuthenticator) register(challenge string) []byte {
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, publicKey...)
+
+	// user present, user verified, attested credential data included
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, attested),
+	})
+	require.NoError(a.t, err)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    b64url(a.id),
+		"rawId": b64url(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64url(a.clientData("webauthn.create", challenge)),
+			"attestationObject": b64url(attestationObject),
+		},
+	})
+	require.NoError(a.t, err)
+	return body
+}
+
+// assert returns the assertion response signing the given challenge
+func (a *softAuthenticator) assert(challenge string) []byte {
+	a.signCount++
+	authData := a.authData(0x05, nil)
+	clientData := a.clientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    b64url(a.id),
+		"rawId": b64url(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64url(clientData),
+			"authenticatorData": b64url(authData),
+			"signature":         b64url(sig),
+			"userHandle":        b64url(a.userHandle),
+		},
+	})
+	require.NoError(a.t, err)
+	return body
+}
+
+func TestWebAuthnService(t *testing.T) {
+	s, err := NewWebAuthnService(testWebAuthnConfig)
+	require.NoError(t, err)
+
+	user := &users.User{Username: "admin"}
+	getUser := func(username string) (*users.User, error) {
+		if username == user.Username {
+			return user, nil
+		}
+		return nil, nil
+	}
+	key := newSoftAuthenticator(t, user.Username)
+
+	options, err := s.BeginRegistration(user)
+	require.NoError(t, err)
+	attestation := key.register(options.Response.Challenge.String())
+	cred, err := s.FinishRegistration(user, "my key", bytes.NewReader(attestation))
+	require.NoError(t, err)
+	assert.Equal(t, key.id, cred.ID)
+	assert.Equal(t, "my key", cred.Name)
+	user.WebAuthnCredentials = users.WebAuthnCredentials{*cred}
+
+	_, err = s.FinishRegistration(user, "my key", bytes.NewReader(attestation))
+	assert.Equal(t, errWebAuthnSessionNotFound, err, "challenges must not be reused")
+
+	t.Run("second factor", func(t *testing.T) {
+		assertion, err := s.BeginLogin(user)
+		require.NoError(t, err)
+		response := key.assert(assertion.Response.Challenge.String())
+
+		got, err := s.FinishLogin(user.Username, bytes.NewReader(response), getUser)
+		require.NoError(t, err)
+		assert.Equal(t, key.signCount, got.WebAuthnCredentials[0].SignCount)
+		assert.NotNil(t, got.WebAuthnCredentials[0].LastUsedAt)
+
+		_, err = s.FinishLogin(user.Username, bytes.NewReader(response), getUser)
+		assert.Equal(t, errWebAuthnSessionNotFound, err)
+	})
+
+	t.Run("second factor of other user", func(t *testing.T) {
+		assertion, err := s.BeginLogin(user)
+		require.NoError(t, err)
+		response := key.assert(assertion.Response.Challenge.String())
+
+		_, err = s.FinishLogin("other", bytes.NewReader(response), getUser)
+		assert.Equal(t, errWebAuthnInvalidResponse, err)
+	})
+
+	t.Run("passwordless", func(t *testing.T) {
+		assertion, err := s.BeginLogin(nil)
+		require.NoError(t, err)
+		response := key.assert(assertion.Response.Challenge.String())
+
+		got, err := s.FinishLogin("", bytes.NewReader(response), getUser)
+		require.NoError(t, err)
+		assert.Equal(t, user.Username, got.Username)
+	})
+
+	t.Run("cloned key", func(t *testing.T) {
+		assertion, err := s.BeginLogin(user)
+		require.NoError(t, err)
+		clone := *key
+		clone.signCount = 0
+		response := clone.assert(assertion.Response.Challenge.String())
+
+		_, err = s.FinishLogin(user.Username, bytes.NewReader(response), getUser)
+		assert.EqualError(t, err, "the sign count of the webauthn credential went backwards, the key might be cloned")
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		assertion, err := s.BeginLogin(nil)
+		require.NoError(t, err)
+		response := newSoftAuthenticator(t, user.Username).assert(assertion.Response.Challenge.String())
+
+		_, err = s.FinishLogin("", bytes.NewReader(response), getUser)
+		assert.Equal(t, errWebAuthnInvalidResponse, err)
+	})
+}
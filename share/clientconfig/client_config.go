@@ -20,6 +20,9 @@ type Config struct {
 	Tunnels                  TunnelsConfig       `json:"-"`
 	InterpreterAliasesConfig map[string]any      `json:"-" mapstructure:"interpreter-aliases"`
 	FileReceptionConfig      FileReceptionConfig `json:"file_reception" mapstructure:"file-reception"`
+	FileDownloadConfig       FileDownloadConfig  `json:"file_download" mapstructure:"file-download"`
+	FileSystemConfig         FileSystemConfig    `json:"file_system" mapstructure:"file-system"`
+	RemoteTerminal           TerminalConfig      `json:"remote_terminal" mapstructure:"remote-terminal"`
 
 	InterpreterAliases          map[string]string                   `json:"interpreter_aliases"`
 	InterpreterAliasesEncodings map[string]InterpreterAliasEncoding `json:"interpreter_aliases_encodings"`
@@ -32,6 +35,9 @@ type ClientConfig struct {
 	ServerSwitchbackInterval time.Duration     `json:"server_switchback_interval" mapstructure:"server_switchback_interval"`
 	Fingerprint              string            `json:"fingerprint" mapstructure:"fingerprint"`
 	Auth                     string            `json:"auth" mapstructure:"auth"`
+	AuthKeyFile              string            `json:"auth_key_file" mapstructure:"auth_key_file"`
+	AuthCertFile             string            `json:"auth_cert_file" mapstructure:"auth_cert_file"`
+	EnrollmentToken          string            `json:"-" mapstructure:"enrollment_token"`
 	Proxy                    string            `json:"proxy" mapstructure:"proxy"`
 	ID                       string            `json:"id" mapstructure:"id"`
 	UseSystemID              bool              `json:"use_system_id" mapstructure:"use_system_id"`
@@ -114,6 +120,24 @@ type FileReceptionConfig struct {
 	Enabled   bool     `json:"enabled" mapstructure:"enabled"`
 }
 
+type FileDownloadConfig struct {
+	Enabled bool     `json:"enabled" mapstructure:"enabled"`
+	Allowed []string `json:"allowed" mapstructure:"allowed"`
+	Denied  []string `json:"denied" mapstructure:"denied"`
+}
+
+type FileSystemConfig struct {
+	Enabled       bool     `json:"enabled" mapstructure:"enabled"`
+	ModifyEnabled bool     `json:"modify_enabled" mapstructure:"modify_enabled"`
+	Allowed       []string `json:"allowed" mapstructure:"allowed"`
+	Denied        []string `json:"denied" mapstructure:"denied"`
+}
+
+type TerminalConfig struct {
+	Enabled bool   `json:"enabled" mapstructure:"enabled"`
+	Shell   string `json:"shell" mapstructure:"shell"`
+}
+
 type InterpreterAliasEncoding struct {
 	InputEncoding  string `json:"input_encoding"`
 	OutputEncoding string `json:"output_encoding"`
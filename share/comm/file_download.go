@@ -0,0 +1,37 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// ChannelFileDownload is the ssh channel type opened by the server to read a file from a client.
+	// The client rejects the channel if the file can't be sent, otherwise it writes the file content to the channel
+	// followed by a RequestTypeFileDownloadResult request.
+	ChannelFileDownload = "file_download"
+
+	// RequestTypeFileDownloadResult is sent by the client on a file download channel once the file content is written
+	RequestTypeFileDownloadResult = "file_download_result"
+)
+
+type FileDownloadRequest struct {
+	Path string `json:"path"`
+}
+
+func DecodeFileDownloadRequest(b []byte) (*FileDownloadRequest, error) {
+	res := &FileDownloadRequest{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
+
+type FileDownloadResult struct {
+	Size    int64     `json:"size"`
+	Mode    uint32    `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	// Error is set if the file couldn't be read completely
+	Error string `json:"error"`
+}
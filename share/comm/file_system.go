@@ -0,0 +1,62 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RequestTypeFileSystem request type sent by server to clients to browse and modify the file system of the client
+const RequestTypeFileSystem = "file_system"
+
+const (
+	FileSystemOperationList   = "list"
+	FileSystemOperationStat   = "stat"
+	FileSystemOperationDelete = "delete"
+	FileSystemOperationMkdir  = "mkdir"
+)
+
+const (
+	FileTypeFile    = "file"
+	FileTypeDir     = "dir"
+	FileTypeSymlink = "symlink"
+	FileTypeOther   = "other"
+)
+
+type FileSystemRequest struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	// Recursive deletes non-empty directories or creates missing parent directories
+	Recursive bool `json:"recursive"`
+	// Offset and Limit select the page of directory entries returned by a list operation, entries are sorted by name
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+func DecodeFileSystemRequest(b []byte) (*FileSystemRequest, error) {
+	res := &FileSystemRequest{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
+
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Type    string    `json:"type"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	// LinkTarget is the destination of a symbolic link
+	LinkTarget string `json:"link_target,omitempty"`
+}
+
+type FileSystemResponse struct {
+	// File is the stated, deleted or created file
+	File *FileInfo `json:"file,omitempty"`
+	// Entries is the requested page of a listed directory
+	Entries []*FileInfo `json:"entries,omitempty"`
+	// TotalCount is the number of all entries of a listed directory
+	TotalCount int `json:"total_count"`
+}
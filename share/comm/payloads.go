@@ -15,6 +15,8 @@ const (
 	RequestTypeCheckTunnelAllowed   = "check_tunnel_allowed"
 
 	RequestTypeUpdateClientAttributes = "update_client_metadata"
+	// RequestTypeRotateCredentials asks the client to store a new password of its client auth
+	RequestTypeRotateCredentials = "rotate_credentials"
 
 	// RequestTypeCmdResult request types sent by clients to server
 	RequestTypeCmdResult       = "cmd_result"
@@ -22,9 +24,15 @@ const (
 	RequestTypeSaveMeasurement = "save_measurement"
 	RequestTypeUpload          = "upload"
 	RequestTypeIPAddresses     = "ip_addresses"
+	RequestTypeSignCertificate = "sign_certificate"
+	// RequestTypeEnroll is the only request accepted on connections authenticated by an enrollment token
+	RequestTypeEnroll = "enroll"
 
 	// RequestTypePing request types understood on both sides, client and server
 	RequestTypePing = "ping"
+
+	// EnrollmentAuthID is the ssh user of clients enrolling with a token, the token is sent as password
+	EnrollmentAuthID = "__enrollment__"
 )
 
 type CheckPortRequest struct {
@@ -57,3 +65,33 @@ type CheckTunnelAllowedRequest struct {
 type CheckTunnelAllowedResponse struct {
 	IsAllowed bool
 }
+
+// SignCertificateRequest asks the server to issue a certificate for the public key of the client
+type SignCertificateRequest struct {
+	// PublicKey in the authorized_keys format
+	PublicKey string
+}
+
+type SignCertificateResponse struct {
+	// Certificate in the authorized_keys format
+	Certificate string
+}
+
+// EnrollRequest is sent by clients enrolling with a token to receive their own client auth credentials
+type EnrollRequest struct {
+	ClientID string
+	Name     string
+}
+
+type EnrollResponse struct {
+	// Auth is the client auth credentials in the "<client-auth-id>:<password>" format
+	Auth string
+	// Pending is true if the client has to be approved before it can connect
+	Pending bool
+}
+
+// RotateCredentialsRequest holds the new password of the client auth used by the client.
+// The client replies success once it stored it.
+type RotateCredentialsRequest struct {
+	Password string
+}
@@ -0,0 +1,43 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// RequestTypeStartReverseTunnel asks the client to listen for connections to be forwarded to a server-side destination
+	RequestTypeStartReverseTunnel = "start_reverse_tunnel"
+	// RequestTypeStopReverseTunnel asks the client to close the listener of a reverse tunnel
+	RequestTypeStopReverseTunnel = "stop_reverse_tunnel"
+
+	// ChannelReverseTunnel is the ssh channel type opened by the client for each connection accepted by a reverse tunnel.
+	// The extra data is the tunnel id, the server connects the channel to the destination of the tunnel.
+	ChannelReverseTunnel = "reverse_tunnel"
+)
+
+type StartReverseTunnelRequest struct {
+	ID string `json:"id"`
+	// Local is the host:port the client listens on
+	Local string `json:"local"`
+}
+
+func DecodeStartReverseTunnelRequest(b []byte) (*StartReverseTunnelRequest, error) {
+	res := &StartReverseTunnelRequest{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
+
+type StopReverseTunnelRequest struct {
+	ID string `json:"id"`
+}
+
+func DecodeStopReverseTunnelRequest(b []byte) (*StopReverseTunnelRequest, error) {
+	res := &StopReverseTunnelRequest{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
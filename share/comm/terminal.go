@@ -0,0 +1,48 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ChannelTerminal is the ssh channel type opened by the server to run an interactive terminal session on a client
+	ChannelTerminal = "terminal"
+
+	// RequestTypeTerminalResize is sent by the server on a terminal channel whenever the browser window is resized
+	RequestTypeTerminalResize = "terminal_resize"
+	// RequestTypeTerminalExit is sent by the client on a terminal channel when the shell has exited
+	RequestTypeTerminalExit = "terminal_exit"
+)
+
+type TerminalRequest struct {
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+	Term string `json:"term"`
+}
+
+func DecodeTerminalRequest(b []byte) (*TerminalRequest, error) {
+	res := &TerminalRequest{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
+
+type TerminalResize struct {
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+func DecodeTerminalResize(b []byte) (*TerminalResize, error) {
+	res := &TerminalResize{}
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", res, err)
+	}
+	return res, nil
+}
+
+type TerminalExit struct {
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error"`
+}
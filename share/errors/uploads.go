@@ -3,3 +3,5 @@ package errors
 import "errors"
 
 var ErrUploadsDisabled = errors.New("uploads are disabled on this client, check [file-reception] enabled option")
+
+var ErrClientConnectedElsewhere = errors.New("client is connected to another node, uploads are only sent to the clients connected to the node receiving the upload")
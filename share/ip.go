@@ -29,6 +29,45 @@ func RemoteIP(r *http.Request) string {
 	return ips[0]
 }
 
+// TrustedRemoteIP returns the IP the request comes from. X-Forwarded-For is set by the sender, so it's only used if the
+// request comes from one of the trusted proxies. The header is read from the right, the first address that isn't a
+// trusted proxy is the one the proxies received the request from.
+func TrustedRemoteIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	ips := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(ips) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(ips[i])
+		if ip == "" {
+			continue
+		}
+		if !isTrustedProxy(ip, trustedProxies) {
+			return ip
+		}
+		remoteIP = ip
+	}
+	return remoteIP
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
 func firstValidIP(ips []string, allowPrivate bool) (string, bool) {
 	for _, ipStr := range ips {
 		ip := net.ParseIP(strings.TrimSpace(ipStr))
@@ -1,10 +1,12 @@
 package chshare
 
 import (
+	"net"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRemoteIP(t *testing.T) {
@@ -55,3 +57,65 @@ func TestRemoteIP(t *testing.T) {
 		})
 	}
 }
+
+func TestTrustedRemoteIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("192.168.0.0/24")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name          string
+		RemoteAddr    string
+		XForwardedFor string
+		ExpectedIP    string
+	}{
+		{
+			Name:       "no header",
+			RemoteAddr: "8.8.4.4:1234",
+			ExpectedIP: "8.8.4.4",
+		},
+		{
+			Name:          "header from untrusted address",
+			RemoteAddr:    "8.8.4.4:1234",
+			XForwardedFor: "8.8.8.8",
+			ExpectedIP:    "8.8.4.4",
+		},
+		{
+			Name:          "header from trusted proxy",
+			RemoteAddr:    "192.168.0.13:1234",
+			XForwardedFor: "8.8.8.8",
+			ExpectedIP:    "8.8.8.8",
+		},
+		{
+			Name:          "address sent by client is ignored",
+			RemoteAddr:    "192.168.0.13:1234",
+			XForwardedFor: "1.1.1.1, 8.8.8.8",
+			ExpectedIP:    "8.8.8.8",
+		},
+		{
+			Name:          "chain of trusted proxies",
+			RemoteAddr:    "192.168.0.13:1234",
+			XForwardedFor: "8.8.8.8, 192.168.0.14",
+			ExpectedIP:    "8.8.8.8",
+		},
+		{
+			Name:       "trusted proxy without header",
+			RemoteAddr: "192.168.0.13:1234",
+			ExpectedIP: "192.168.0.13",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.RemoteAddr
+			if tc.XForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.XForwardedFor)
+			}
+			ip := TrustedRemoteIP(req, []*net.IPNet{proxies})
+
+			assert.Equal(t, tc.ExpectedIP, ip)
+		})
+	}
+}
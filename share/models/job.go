@@ -36,6 +36,11 @@ type Job struct {
 	IsSudo       bool       `json:"is_sudo"`
 	IsScript     bool       `json:"is_script"`
 	StreamResult bool       `json:"stream_result"`
+	// StreamToFile makes the client stream the full output, which the server stores in files
+	StreamToFile bool `json:"stream_to_file"`
+	// VaultEnv holds the values of the vault placeholders in the command by environment variable name.
+	// It's only sent to the client, jobs are never stored with it.
+	VaultEnv map[string]string `json:"vault_env,omitempty"`
 }
 
 type JobResult struct {
@@ -52,18 +57,19 @@ type JobClientTags struct {
 // TODO: check that ClientTags is populated where required
 type MultiJob struct {
 	MultiJobSummary
-	ClientIDs   []string       `json:"client_ids"`
-	GroupIDs    []string       `json:"group_ids"`
-	ClientTags  *JobClientTags `json:"tags"`
-	Command     string         `json:"command"`
-	Cwd         string         `json:"cwd"`
-	Interpreter string         `json:"interpreter"`
-	TimeoutSec  int            `json:"timeout_sec"`
-	Concurrent  bool           `json:"concurrent"`
-	AbortOnErr  bool           `json:"abort_on_err"`
-	Jobs        []*Job         `json:"jobs"`
-	IsSudo      bool           `json:"is_sudo"`
-	IsScript    bool           `json:"is_script"`
+	ClientIDs    []string       `json:"client_ids"`
+	GroupIDs     []string       `json:"group_ids"`
+	ClientTags   *JobClientTags `json:"tags"`
+	Command      string         `json:"command"`
+	Cwd          string         `json:"cwd"`
+	Interpreter  string         `json:"interpreter"`
+	TimeoutSec   int            `json:"timeout_sec"`
+	Concurrent   bool           `json:"concurrent"`
+	AbortOnErr   bool           `json:"abort_on_err"`
+	Jobs         []*Job         `json:"jobs"`
+	IsSudo       bool           `json:"is_sudo"`
+	IsScript     bool           `json:"is_script"`
+	StreamToFile bool           `json:"stream_to_file"`
 }
 
 type MultiJobSummary struct {
@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/openrport/openrport/share/vaultplaceholder"
 )
 
 // short-hand conversions
@@ -30,29 +32,42 @@ const (
 	ProtocolTCP    = "tcp"
 	ProtocolUDP    = "udp"
 	ProtocolTCPUDP = "tcp+udp"
+	// ProtocolSOCKS5 tunnels have no fixed remote, the destination is requested by each SOCKS5 connection
+	ProtocolSOCKS5 = "socks5"
 )
 
 var protocolRe = regexp.MustCompile(`(.*)\/(tcp|udp|tcp\+udp)$`)
 
 // TODO(m-terel): Remote should be only used for parsing command args and URL query params. Current Remote is kind of a Tunnel model. Refactor to use separate models for representation and business logic.
 type Remote struct {
-	Name               string        `json:"name"`
-	Protocol           string        `json:"protocol"`
-	LocalHost          string        `json:"lhost"`
-	LocalPort          string        `json:"lport"`
-	LocalPortRandom    bool          `json:"lport_random"`
-	Owner              string        `json:"owner"`
-	RemoteHost         string        `json:"rhost"`
-	RemotePort         string        `json:"rport"`
-	Scheme             *string       `json:"scheme"`
-	ACL                *string       `json:"acl"` // string representation of Tunnel.TunnelACL field
-	IdleTimeoutMinutes int           `json:"idle_timeout_minutes"`
-	AutoClose          time.Duration `json:"auto_close"`
-	HTTPProxy          bool          `json:"http_proxy"`
-	HostHeader         string        `json:"host_header"`
-	AuthUser           string        `json:"auth_user"`
-	AuthPassword       string        `json:"auth_password"`
-	TunnelURL          string        `json:"tunnel_url"`
+	Name                 string        `json:"name"`
+	Protocol             string        `json:"protocol"`
+	LocalHost            string        `json:"lhost"`
+	LocalPort            string        `json:"lport"`
+	LocalPortRandom      bool          `json:"lport_random"`
+	Owner                string        `json:"owner"`
+	RemoteHost           string        `json:"rhost"`
+	RemotePort           string        `json:"rport"`
+	Scheme               *string       `json:"scheme"`
+	ACL                  *string       `json:"acl"` // string representation of Tunnel.TunnelACL field
+	IdleTimeoutMinutes   int           `json:"idle_timeout_minutes"`
+	AutoClose            time.Duration `json:"auto_close"`
+	HTTPProxy            bool          `json:"http_proxy"`
+	HostHeader           string        `json:"host_header"`
+	AuthUser             string        `json:"auth_user"`
+	AuthPassword         string        `json:"auth_password"`
+	ResolvedAuthPassword string        `json:"-"` // AuthPassword with vault placeholders resolved, kept in memory only
+	TunnelURL            string        `json:"tunnel_url"`
+	Record               bool          `json:"record"`
+	StoredTunnelID       string        `json:"stored_tunnel_id,omitempty"`     // set if started automatically from a stored tunnel
+	Reverse              bool          `json:"reverse,omitempty"`              // the client listens on local, connections are forwarded to remote on the server side
+	TargetClientID       string        `json:"target_client_id,omitempty"`     // the client listens on local, connections are forwarded to remote on the target client
+	BandwidthLimitUp     int64         `json:"bandwidth_limit_up,omitempty"`   // bytes per second sent to the client, 0 is unlimited
+	BandwidthLimitDown   int64         `json:"bandwidth_limit_down,omitempty"` // bytes per second received from the client, 0 is unlimited
+	SSHUser              string        `json:"ssh_user,omitempty"`             // user the ssh tunnel proxy logs in with
+	SSHPassword          string        `json:"ssh_password,omitempty"`         // vault placeholder of the password or private key of SSHUser
+	ResolvedSSHPassword  string        `json:"-"`                              // SSHPassword with vault placeholders resolved, kept in memory only
+	SSHHostKey           string        `json:"ssh_host_key,omitempty"`         // SHA256 fingerprint of the host key the ssh tunnel proxy accepts
 }
 
 func NewRemote(s string) (*Remote, error) {
@@ -102,6 +117,35 @@ func NewRemote(s string) (*Remote, error) {
 	return r, nil
 }
 
+// NewSOCKS5Remote returns the remote of a socks5 tunnel listening on local, which is a port or host:port.
+// The local port is chosen randomly if local is empty.
+func NewSOCKS5Remote(local string) (*Remote, error) {
+	r := &Remote{
+		Protocol: ProtocolSOCKS5,
+	}
+	if local == "" {
+		return r, nil
+	}
+
+	host, port := ZeroHost, local
+	if strings.Contains(local, ":") {
+		var err error
+		host, port, err = net.SplitHostPort(local)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !isPort(port) {
+		return nil, errors.New("Invalid local port")
+	}
+	if host == "" || !isHost(host) {
+		return nil, errors.New("Invalid local host")
+	}
+	r.LocalHost = host
+	r.LocalPort = port
+	return r, nil
+}
+
 var isPortRegExp = regexp.MustCompile(`^\d+$`)
 
 func isPort(s string) bool {
@@ -116,13 +160,22 @@ func isHost(s string) bool {
 // implement Stringer
 func (r Remote) String() string {
 	s := r.LocalHost + ":" + r.LocalPort + ":" + r.Remote()
+	if r.Protocol == ProtocolSOCKS5 {
+		s = r.LocalHost + ":" + r.LocalPort
+	}
 
+	if r.Reverse {
+		s = "R:" + s
+	}
 	if r.Protocol != ProtocolTCP {
 		s += "/" + r.Protocol
 	}
 	if r.ACL != nil {
 		s += "(acl:" + *r.ACL + ")"
 	}
+	if r.TargetClientID != "" {
+		s += "(target:" + r.TargetClientID + ")"
+	}
 	return s
 }
 
@@ -152,6 +205,19 @@ func (r *Remote) IsProtocol(other string) bool {
 	return false
 }
 
+// ListenProtocol returns the protocol of the port the tunnel listens on
+func (r *Remote) ListenProtocol() string {
+	if r.Protocol == ProtocolSOCKS5 {
+		return ProtocolTCP
+	}
+	return r.Protocol
+}
+
+// ListensOnClient returns true if the local address is on the client and not on the server
+func (r *Remote) ListensOnClient() bool {
+	return r.Reverse || r.TargetClientID != ""
+}
+
 func (r *Remote) EqualACL(acl *string) bool {
 	if r.ACL != nil && acl != nil {
 		return *r.ACL == *acl
@@ -166,6 +232,27 @@ func (r *Remote) IsLocalSpecified() bool {
 	return r.LocalHost != "" && r.LocalPort != ""
 }
 
+// BasicAuthPassword returns the password the tunnel proxy requires. ok is false if the password refers to vault values
+// which aren't resolved, no password matches then.
+func (r *Remote) BasicAuthPassword() (password string, ok bool) {
+	if r.ResolvedAuthPassword != "" {
+		return r.ResolvedAuthPassword, true
+	}
+	if vaultplaceholder.Has(r.AuthPassword) {
+		return "", false
+	}
+	return r.AuthPassword, true
+}
+
+// SSHCredentials returns the credentials the ssh tunnel proxy logs in with, secret is a password or a private key.
+// ok is false if the tunnel has none or they aren't resolved, the user enters them in the browser then.
+func (r *Remote) SSHCredentials() (user, secret string, ok bool) {
+	if r.SSHUser == "" || r.ResolvedSSHPassword == "" {
+		return "", "", false
+	}
+	return r.SSHUser, r.ResolvedSSHPassword, true
+}
+
 func (r *Remote) NewDownstreamProxyURL(subdomain string, basedomain string, port string) (proxyURL string) {
 	if port == "" {
 		return "https://" + subdomain + "." + basedomain
@@ -209,3 +209,77 @@ func TestShouldGetDomainPartsFromTunnelURL(t *testing.T) {
 		})
 	}
 }
+
+func TestBasicAuthPassword(t *testing.T) {
+	cases := []struct {
+		name         string
+		remote       Remote
+		wantPassword string
+		wantOK       bool
+	}{
+		{
+			name:         "plain password",
+			remote:       Remote{AuthPassword: "pass"},
+			wantPassword: "pass",
+			wantOK:       true,
+		},
+		{
+			name:         "resolved vault placeholder",
+			remote:       Remote{AuthPassword: `{{ vault "proxy" }}`, ResolvedAuthPassword: "secret"},
+			wantPassword: "secret",
+			wantOK:       true,
+		},
+		{
+			name:   "unresolved vault placeholder",
+			remote: Remote{AuthPassword: `{{ vault "proxy" }}`},
+			wantOK: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			password, ok := tc.remote.BasicAuthPassword()
+
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantPassword, password)
+		})
+	}
+}
+
+func TestNewSOCKS5Remote(t *testing.T) {
+	cases := []struct {
+		local      string
+		wantString string
+		wantErr    string
+	}{
+		{
+			local:      "",
+			wantString: ":/socks5",
+		},
+		{
+			local:      "3000",
+			wantString: "0.0.0.0:3000/socks5",
+		},
+		{
+			local:      "127.0.0.1:3000",
+			wantString: "127.0.0.1:3000/socks5",
+		},
+		{
+			local:   "127.0.0.1:abc",
+			wantErr: "Invalid local port",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.local, func(t *testing.T) {
+			remote, err := NewSOCKS5Remote(tc.local)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, ProtocolSOCKS5, remote.Protocol)
+			assert.Equal(t, ProtocolTCP, remote.ListenProtocol())
+			assert.Equal(t, tc.wantString, remote.String())
+		})
+	}
+}
@@ -3,6 +3,14 @@ package query
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
 )
 
 type SQLConverter struct {
@@ -53,10 +61,14 @@ func (c *SQLConverter) AddWhere(filterOptions []FilterOption, q string, params [
 					part = fmt.Sprintf("(%s OR %s IS NULL)", part, col)
 				} else if strings.Contains(val, "*") && filterOptions[i].Operator.Code() == "=" {
 					// Implement a SQL LIKE search triggered by a wildcard
-					if c.dbDriverName == "mysql" {
+					switch c.dbDriverName {
+					case DriverMySQL:
 						//MySQL needs the backslash escaped, that means double-backslash;  WHERE LOWER(id) LIKE 'op\%' escape "\\";
 						part = fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '\\\\'", col)
-					} else {
+					case DriverPostgres:
+						// PostgreSQL has no implicit cast of non text columns to text
+						part = fmt.Sprintf("LOWER(CAST(%s AS TEXT)) LIKE ? ESCAPE '\\'", col)
+					default:
 						//SQLite needs a single backslash
 						part = fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '\\'", col)
 					}
@@ -135,3 +147,45 @@ func (c *SQLConverter) addLimitOffset(pagination *Pagination, q string, params [
 
 	return q, params
 }
+
+// Rebind converts a query written with ? placeholders and backtick quoted identifiers, as understood by SQLite and MySQL,
+// into the dialect of the database.
+func (c *SQLConverter) Rebind(q string) string {
+	if c.dbDriverName != DriverPostgres {
+		return q
+	}
+
+	return sqlx.Rebind(sqlx.DOLLAR, strings.ReplaceAll(q, "`", `"`))
+}
+
+// DateTime returns an expression to compare a date stored in the given column or placeholder chronologically.
+// SQLite stores dates as text, which is only comparable once normalized.
+func (c *SQLConverter) DateTime(expr string) string {
+	if c.dbDriverName == DriverPostgres {
+		return expr
+	}
+
+	return fmt.Sprintf("DATETIME(%s)", expr)
+}
+
+// UnixTimestamp returns an expression converting the date of the given column or expression to seconds since epoch.
+func (c *SQLConverter) UnixTimestamp(expr string) string {
+	switch c.dbDriverName {
+	case DriverPostgres:
+		return fmt.Sprintf("EXTRACT(EPOCH FROM %s)", expr)
+	case DriverMySQL:
+		return fmt.Sprintf("UNIX_TIMESTAMP(%s)", expr)
+	default:
+		return fmt.Sprintf("strftime('%%s', %s)", expr)
+	}
+}
+
+// Round returns an expression rounding the given numeric expression to the number of decimal places.
+func (c *SQLConverter) Round(expr string, places int) string {
+	if c.dbDriverName == DriverPostgres {
+		// PostgreSQL rounds to decimal places only with the numeric type
+		return fmt.Sprintf("round(CAST(%s AS NUMERIC), %d)", expr, places)
+	}
+
+	return fmt.Sprintf("round(%s, %d)", expr, places)
+}
@@ -122,6 +122,20 @@ func TestConvertListOptionsToQuery(t *testing.T) {
 			ExpectedQuery:  `SELECT * FROM res1 WHERE LOWER(field1) LIKE ? ESCAPE '\\' AND LOWER(field2) LIKE ? ESCAPE '\\' ORDER BY field1 ASC`,
 			ExpectedParams: []interface{}{"val%", "val%"},
 		},
+		{
+			Name:         "wildcard option PostgreSQL variant",
+			DbDriverName: "postgres",
+			Options: &query.ListOptions{
+				Filters: []query.FilterOption{
+					{
+						Column: []string{"field1"},
+						Values: []string{"val*"},
+					},
+				},
+			},
+			ExpectedQuery:  `SELECT * FROM res1 WHERE LOWER(CAST(field1 AS TEXT)) LIKE ? ESCAPE '\'`,
+			ExpectedParams: []interface{}{"val%"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -149,3 +163,49 @@ func TestConvertListOptionsToQuery(t *testing.T) {
 	}
 
 }
+
+func TestSQLConverterDialects(t *testing.T) {
+	testCases := []struct {
+		DbDriverName          string
+		ExpectedRebind        string
+		ExpectedDateTime      string
+		ExpectedUnixTimestamp string
+		ExpectedRound         string
+	}{
+		{
+			DbDriverName:          "sqlite3",
+			ExpectedRebind:        "SELECT * FROM `values` WHERE `key` = ? AND client_id = ?",
+			ExpectedDateTime:      "DATETIME(started_at)",
+			ExpectedUnixTimestamp: "strftime('%s', started_at)",
+			ExpectedRound:         "round(avg(cpu), 2)",
+		},
+		{
+			DbDriverName:          "mysql",
+			ExpectedRebind:        "SELECT * FROM `values` WHERE `key` = ? AND client_id = ?",
+			ExpectedDateTime:      "DATETIME(started_at)",
+			ExpectedUnixTimestamp: "UNIX_TIMESTAMP(started_at)",
+			ExpectedRound:         "round(avg(cpu), 2)",
+		},
+		{
+			DbDriverName:          "postgres",
+			ExpectedRebind:        `SELECT * FROM "values" WHERE "key" = $1 AND client_id = $2`,
+			ExpectedDateTime:      "started_at",
+			ExpectedUnixTimestamp: "EXTRACT(EPOCH FROM started_at)",
+			ExpectedRound:         "round(CAST(avg(cpu) AS NUMERIC), 2)",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.DbDriverName, func(t *testing.T) {
+			t.Parallel()
+
+			converter := query.NewSQLConverter(tc.DbDriverName)
+
+			assert.Equal(t, tc.ExpectedRebind, converter.Rebind("SELECT * FROM `values` WHERE `key` = ? AND client_id = ?"))
+			assert.Equal(t, tc.ExpectedDateTime, converter.DateTime("started_at"))
+			assert.Equal(t, tc.ExpectedUnixTimestamp, converter.UnixTimestamp("started_at"))
+			assert.Equal(t, tc.ExpectedRound, converter.Round("avg(cpu)", 2))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiErrors "github.com/openrport/openrport/server/api/errors"
+)
+
+// RequireHTTPStatus requires err to be an APIError with the status
+func RequireHTTPStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr apiErrors.APIError
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, status, apiErr.HTTPStatus)
+}
@@ -6,8 +6,18 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/sqlite"
 )
 
+// NewMemoryDB returns an in-memory sqlite db with the migrations applied, it's closed when the test ends
+func NewMemoryDB(t *testing.T, assetNames []string, asset func(name string) ([]byte, error)) *sqlx.DB {
+	db, err := sqlite.New(":memory:", assetNames, asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
 func ConvertDbRowsToMapSlice(dbRows *sqlx.Rows) ([]map[string]interface{}, error) {
 	dataRows := make([]map[string]interface{}, 0)
 	for dbRows.Next() {
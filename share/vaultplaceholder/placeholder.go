@@ -0,0 +1,85 @@
+// Package vaultplaceholder handles the placeholders referring to vault values, e.g. {{ vault "db_password" }}.
+// The server resolves them when a command, script or tunnel is started, so secrets are never stored with it.
+package vaultplaceholder
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables the client passes the resolved values in
+const EnvPrefix = "RPORT_VAULT_"
+
+// Mask replaces resolved values in outputs
+const Mask = "******"
+
+var placeholderRe = regexp.MustCompile(`{{\s*vault\s+"([^"]+)"\s*}}`)
+
+// Has returns true if text contains at least one placeholder
+func Has(text string) bool {
+	return placeholderRe.MatchString(text)
+}
+
+// Keys returns the vault keys referred to in text without duplicates, in the order of their first occurrence
+func Keys(text string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		if seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		keys = append(keys, m[1])
+	}
+	return keys
+}
+
+// Replace replaces each placeholder in text with the result of repl for its key
+func Replace(text string, repl func(key string) string) string {
+	return placeholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+		return repl(placeholderRe.FindStringSubmatch(placeholder)[1])
+	})
+}
+
+// EnvName returns the name of the environment variable holding the value of key, e.g. RPORT_VAULT_DB_PASSWORD for db-password
+func EnvName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	return EnvPrefix + string(name)
+}
+
+// MaskValues replaces all occurrences of the values in text
+func MaskValues(text string, values []string) string {
+	for _, v := range sortedValues(values) {
+		text = strings.ReplaceAll(text, v, Mask)
+	}
+	return text
+}
+
+// sortedValues returns the non-empty values, longest first, so values containing others are masked as a whole
+func sortedValues(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return len(res[i]) > len(res[j])
+	})
+	return res
+}
+
+// maskBytes replaces all occurrences of the values in b
+func maskBytes(b []byte, values []string) []byte {
+	for _, v := range values {
+		b = bytes.ReplaceAll(b, []byte(v), []byte(Mask))
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package vaultplaceholder
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	text := `mysql -u root -p{{ vault "db-password" }} -h {{vault "host"}} && echo {{  vault  "db-password"  }} {{ vault db }}`
+
+	assert.True(t, Has(text))
+	assert.Equal(t, []string{"db-password", "host"}, Keys(text))
+	assert.False(t, Has("echo {{ .Env.HOME }}"))
+	assert.Nil(t, Keys("echo hello"))
+}
+
+func TestReplace(t *testing.T) {
+	res := Replace(`echo {{ vault "a" }} {{vault "b.c"}} {{ vault "a" }}`, func(key string) string {
+		return "$" + EnvName(key)
+	})
+
+	assert.Equal(t, "echo $RPORT_VAULT_A $RPORT_VAULT_B_C $RPORT_VAULT_A", res)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "RPORT_VAULT_DB_PASSWORD", EnvName("db-password"))
+	assert.Equal(t, "RPORT_VAULT_API_KEY_2", EnvName("api key 2"))
+}
+
+func TestMaskValues(t *testing.T) {
+	res := MaskValues("user secret, password secret123", []string{"secret", "secret123", ""})
+
+	assert.Equal(t, "user ******, password ******", res)
+}
+
+func TestMaskingWriter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name:   "value in one write",
+			writes: []string{"password: s3cr3t\n"},
+			want:   "password: ******\n",
+		},
+		{
+			name:   "value split across writes",
+			writes: []string{"password: s3", "cr", "3t\n"},
+			want:   "password: ******\n",
+		},
+		{
+			name:   "beginning of value at the end",
+			writes: []string{"not s3cr"},
+			want:   "not s3cr",
+		},
+		{
+			name:   "no value",
+			writes: []string{"hello ", "world"},
+			want:   "hello world",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			w := NewMaskingWriter(out, []string{"s3cr3t"})
+			for _, s := range tc.writes {
+				n, err := w.Write([]byte(s))
+				require.NoError(t, err)
+				assert.Equal(t, len(s), n)
+				assert.NotContains(t, out.String(), "s3cr3t")
+			}
+			require.NoError(t, w.Flush())
+
+			assert.Equal(t, tc.want, out.String())
+		})
+	}
+}
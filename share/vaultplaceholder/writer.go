@@ -0,0 +1,67 @@
+package vaultplaceholder
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// MaskingWriter replaces the values in the data written before passing it on. A value can be split across
+// writes, so the end of the data is held back while it could be the beginning of a value. Flush writes it.
+type MaskingWriter struct {
+	w      io.Writer
+	values []string
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func NewMaskingWriter(w io.Writer, values []string) *MaskingWriter {
+	return &MaskingWriter{
+		w:      w,
+		values: sortedValues(values),
+	}
+}
+
+func (m *MaskingWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data := maskBytes(append(m.pending, p...), m.values)
+	keep := m.partialValueLen(data)
+	m.pending = append([]byte(nil), data[len(data)-keep:]...)
+
+	if out := data[:len(data)-keep]; len(out) > 0 {
+		if _, err := m.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the data held back
+func (m *MaskingWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(m.pending)
+	m.pending = nil
+	return err
+}
+
+// partialValueLen returns the length of the longest end of data which is the beginning of a value
+func (m *MaskingWriter) partialValueLen(data []byte) int {
+	longest := 0
+	for _, v := range m.values {
+		for n := len(v) - 1; n > longest; n-- {
+			if n <= len(data) && bytes.HasSuffix(data, []byte(v[:n])) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}